@@ -63,14 +63,16 @@ You may specify one or more benchmarks or controls to run (separated by a space)
 		AddBoolFlag(constants.ArgHeader, true, "Include column headers for csv and table output").
 		AddBoolFlag(constants.ArgHelp, false, "Help for check", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddStringFlag(constants.ArgSeparator, ",", "Separator string for csv output").
-		AddStringFlag(constants.ArgOutput, constants.OutputFormatText, "Output format: brief, csv, html, json, md, text, snapshot or none").
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatText, "Output format: brief, csv, diff, html, json, md, ndjson-stream, sarif, text, snapshot or none").
 		AddBoolFlag(constants.ArgTiming, false, "Turn on the timer which reports check time").
 		AddStringSliceFlag(constants.ArgSearchPath, nil, "Set a custom search_path for the steampipe user for a check session (comma-separated)").
 		AddStringSliceFlag(constants.ArgSearchPathPrefix, nil, "Set a prefix to the current search path for a check session (comma-separated)").
 		AddStringFlag(constants.ArgTheme, "dark", "Set the output theme for 'text' output: light, dark or plain").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, md, nunit3, sps (snapshot), asff").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, diff, html, json, junit, md, nunit3, sarif, sps (snapshot), asff").
 		AddBoolFlag(constants.ArgProgress, true, "Display control execution progress").
 		AddBoolFlag(constants.ArgDryRun, false, "Show which controls will be run without running them").
+		AddStringSliceFlag(constants.ArgWaiverFile, nil, "Specify a file declaring waivers, which report the matching failed results as skipped").
+		AddStringFlag(constants.ArgDiff, "", "Compare the results with a previous check snapshot, displaying only regressions and improvements").
 		AddStringSliceFlag(constants.ArgTag, nil, "Filter controls based on their tag values ('--tag key=value')").
 		AddStringSliceFlag(constants.ArgVarFile, nil, "Specify an .spvar file containing variable values").
 		// NOTE: use StringArrayFlag for ArgVariable, not StringSliceFlag
@@ -79,7 +81,9 @@ You may specify one or more benchmarks or controls to run (separated by a space)
 		AddStringArrayFlag(constants.ArgVariable, nil, "Specify the value of a variable").
 		AddStringFlag(constants.ArgWhere, "", "SQL 'where' clause, or named query, used to filter controls (cannot be used with '--tag')").
 		AddIntFlag(constants.ArgDatabaseQueryTimeout, constants.DatabaseDefaultCheckQueryTimeout, "The query timeout").
+		AddIntFlag(constants.ArgControlTimeout, 0, "The timeout of each control in seconds, including retries (used for controls which do not set a timeout)").
 		AddIntFlag(constants.ArgMaxParallel, constants.DefaultMaxConnections, "The maximum number of concurrent database connections to open").
+		AddStringSliceFlag(constants.ArgPluginMaxParallel, nil, "The maximum number of controls to run in parallel for a plugin or connection ('--plugin-max-parallel aws=3')").
 		AddBoolFlag(constants.ArgModInstall, true, "Specify whether to install mod dependencies before running the check").
 		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
 		AddBoolFlag(constants.ArgSnapshot, false, "Create snapshot in Turbot Pipes with the default (workspace) visibility").
@@ -128,6 +132,18 @@ func runCheckCmd(cmd *cobra.Command, args []string) {
 		error_helpers.FailOnError(sperr.New("cannot execute 'all' with other benchmarks/controls"))
 	}
 
+	// if a previous snapshot was given, load it to compare the results with
+	var previousResults *controldisplay.CheckSnapshotResults
+	if diffPath := viper.GetString(constants.ArgDiff); diffPath != "" {
+		var err error
+		previousResults, err = controldisplay.LoadCheckSnapshot(diffPath)
+		if err != nil {
+			exitCode = constants.ExitCodeInsufficientOrWrongInputs
+			error_helpers.ShowError(ctx, err)
+			return
+		}
+	}
+
 	// show the status spinner
 	statushooks.Show(ctx)
 
@@ -170,8 +186,15 @@ func runCheckCmd(cmd *cobra.Command, args []string) {
 		}
 
 		// append the total number of alarms and errors for multiple runs
-		totalAlarms += namedTree.tree.Root.Summary.Status.Alarm
-		totalErrors += namedTree.tree.Root.Summary.Status.Error
+		// when comparing with a previous snapshot, only new alarms and errors are counted
+		if previousResults != nil {
+			summary := controldisplay.NewCheckDiff(previousResults, namedTree.tree).Summary
+			totalAlarms += summary.NewAlarms
+			totalErrors += summary.NewErrors
+		} else {
+			totalAlarms += namedTree.tree.Root.Summary.Status.Alarm
+			totalErrors += namedTree.tree.Root.Summary.Status.Error
+		}
 
 		err = publishSnapshot(ctx, namedTree.tree, viper.GetBool(constants.ArgShare), viper.GetBool(constants.ArgSnapshot))
 		if err != nil {
@@ -262,6 +285,7 @@ func getExecutionTrees(ctx context.Context, initData *control.InitData, args ...
 		if err != nil {
 			return nil, sperr.WrapWithMessage(err, "could not create merged execution tree")
 		}
+		executionTree.Waivers = initData.Waivers
 		name := fmt.Sprintf("check.%s", initData.Workspace.Mod.ShortName)
 		trees = append(trees, newNamedExecutionTree(name, executionTree))
 	} else {
@@ -273,6 +297,7 @@ func getExecutionTrees(ctx context.Context, initData *control.InitData, args ...
 			if err != nil {
 				return nil, sperr.WrapWithMessage(err, "could not create execution tree for %s", arg)
 			}
+			executionTree.Waivers = initData.Waivers
 			name, err := getExportName(arg, initData.Workspace.Mod.ShortName)
 			if err != nil {
 				return nil, sperr.WrapWithMessage(err, "could not evaluate export name for %s", arg)
@@ -310,6 +335,10 @@ func getExitCode(alarms int, errors int) int {
 
 // create the context for the check run - add a control status renderer
 func createCheckContext(ctx context.Context) context.Context {
+	// when streaming results, write each control result to stdout as soon as it completes
+	if viper.GetString(constants.ArgOutput) == constants.OutputFormatNdjsonStream {
+		return controlstatus.AddControlHooksToContext(ctx, controldisplay.NewNdjsonStreamControlHooks(os.Stdout))
+	}
 	return controlstatus.AddControlHooksToContext(ctx, controlstatus.NewStatusControlHooks())
 }
 
@@ -346,9 +375,28 @@ func validateCheckArgs(ctx context.Context, cmd *cobra.Command, args []string) b
 		return false
 	}
 
+	// the diff format requires a previous snapshot to compare with
+	if viper.GetString(constants.ArgDiff) == "" && usesDiffFormat() {
+		error_helpers.ShowError(ctx, fmt.Errorf("the '%s' format requires '--%s'", constants.OutputFormatDiff, constants.ArgDiff))
+		return false
+	}
+
 	return true
 }
 
+// usesDiffFormat returns whether the diff format is used for the output or an export
+func usesDiffFormat() bool {
+	if viper.GetString(constants.ArgOutput) == constants.OutputFormatDiff {
+		return true
+	}
+	for _, export := range viper.GetStringSlice(constants.ArgExport) {
+		if export == constants.OutputFormatDiff || export == strings.TrimPrefix(constants.DiffExtension, ".") {
+			return true
+		}
+	}
+	return false
+}
+
 func printTiming(tree *controlexecute.ExecutionTree) {
 	if !shouldPrintTiming() {
 		return
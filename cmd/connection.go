@@ -0,0 +1,702 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/cmdconfig"
+	"github.com/turbot/steampipe/pkg/connection/refresh_rpc"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/contexthelpers"
+	"github.com/turbot/steampipe/pkg/db/db_local"
+	"github.com/turbot/steampipe/pkg/display"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/filepaths"
+	"github.com/turbot/steampipe/pkg/pluginmanager"
+	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// Connection management commands
+func connectionCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "connection [command]",
+		Args:  cobra.NoArgs,
+		Short: "Steampipe connection management",
+		Long: `Steampipe connection management.
+
+Connections are configured in .spc files in the Steampipe config directory.
+
+Examples:
+
+  # List the configured connections
+  steampipe connection list
+
+  # Add a connection and import its schema into the running service
+  steampipe connection add aws_dev --plugin aws --config 'profile = "dev"'
+
+  # Remove a connection
+  steampipe connection remove aws_dev
+
+  # Add connections for the cloud accounts in a terraform state
+  steampipe connection import --from-terraform ./terraform.tfstate
+
+  # Test the plugin can connect using the config of a connection
+  steampipe connection test aws
+
+  # Refresh the connections in the running service, showing a summary of the changes as json
+  steampipe connection refresh --output json
+
+  # Show the search path which is set for steampipe users
+  steampipe connection search-path`,
+	}
+
+	cmd.AddCommand(connectionListCmd())
+	cmd.AddCommand(connectionShowCmd())
+	cmd.AddCommand(connectionAddCmd())
+	cmd.AddCommand(connectionRemoveCmd())
+	cmd.AddCommand(connectionImportCmd())
+	cmd.AddCommand(connectionTestCmd())
+	cmd.AddCommand(connectionRefreshCmd())
+	cmd.AddCommand(connectionSearchPathCmd())
+	cmd.Flags().BoolP(constants.ArgHelp, "h", false, "Help for connection")
+
+	return cmd
+}
+
+// List connections
+func connectionListCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Args:  cobra.NoArgs,
+		Run:   runConnectionListCmd,
+		Short: "List the configured connections",
+		Long: `List the configured connections.
+
+Examples:
+
+  # List the configured connections
+  steampipe connection list`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for connection list", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runConnectionListCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	utils.LogTime("runConnectionListCmd start")
+	defer func() {
+		utils.LogTime("runConnectionListCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	connections := steampipeconfig.GlobalConfig.Connections
+	headers := []string{"Connection", "Plugin", "Type", "Import Schema", "File"}
+	var rows [][]string
+	for _, name := range utils.SortedMapKeys(connections) {
+		c := connections[name]
+		rows = append(rows, []string{c.Name, c.PluginAlias, c.Type, c.ImportSchema, connectionDeclLocation(c)})
+	}
+	if len(rows) == 0 {
+		rows = append(rows, []string{"", "", "", "", ""})
+	}
+	display.ShowWrappedTable(headers, rows, &display.ShowWrappedTableOptions{AutoMerge: false})
+}
+
+// Show a connection
+func connectionShowCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "show [flags] connection",
+		Args:  cobra.ExactArgs(1),
+		Run:   runConnectionShowCmd,
+		Short: "Show the config of a connection",
+		Long: `Show the config of a connection.
+
+Examples:
+
+  # Show the aws connection
+  steampipe connection show aws`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for connection show", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runConnectionShowCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	utils.LogTime("runConnectionShowCmd start")
+	defer func() {
+		utils.LogTime("runConnectionShowCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	connectionName := args[0]
+	c, ok := steampipeconfig.GlobalConfig.Connections[connectionName]
+	if !ok {
+		error_helpers.ShowError(ctx, fmt.Errorf("connection '%s' does not exist", connectionName))
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+
+	fmt.Printf("Name:          %s\n", c.Name)
+	fmt.Printf("Plugin:        %s\n", c.PluginAlias)
+	fmt.Printf("Type:          %s\n", c.Type)
+	if c.Type == modconfig.ConnectionTypeAggregator {
+		fmt.Printf("Connections:   %s\n", strings.Join(c.ConnectionNames, ", "))
+		if len(c.ConnectionRegexes) > 0 {
+			fmt.Printf("Regex:         %s\n", strings.Join(c.ConnectionRegexes, ", "))
+		}
+		for _, k := range utils.SortedMapKeys(c.ConnectionTags) {
+			fmt.Printf("Tag:           %s=%s\n", k, c.ConnectionTags[k])
+		}
+		fmt.Printf("Members:       %s\n", strings.Join(c.ResolvedConnectionNames, ", "))
+	}
+	fmt.Printf("Import schema: %s\n", c.ImportSchema)
+	if c.MaxConcurrency != nil {
+		fmt.Printf("Concurrency:   max %d\n", *c.MaxConcurrency)
+	}
+	if c.RateLimit != nil {
+		fmt.Printf("Rate limit:    %v calls/s\n", *c.RateLimit)
+	}
+	fmt.Printf("File:          %s\n", connectionDeclLocation(c))
+	if c.Error != nil {
+		fmt.Printf("Error:         %s\n", c.Error.Error())
+	}
+	if config := strings.TrimSpace(c.Config); config != "" {
+		fmt.Printf("Config:\n%s\n", config)
+	}
+}
+
+// Add a connection
+func connectionAddCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "add [flags] connection",
+		Args:  cobra.ExactArgs(1),
+		Run:   runConnectionAddCmd,
+		Short: "Add a connection",
+		Long: `Add a connection.
+
+The connection is written to a new file '<connection>.spc' in the Steampipe config directory.
+If the service is running, the connection config is reloaded and the schema for the new connection is imported.
+
+Examples:
+
+  # Add a connection using the default aws plugin config
+  steampipe connection add aws_dev --plugin aws
+
+  # Add a connection with plugin specific config
+  steampipe connection add aws_dev --plugin aws --config 'profile = "dev"
+  regions = ["us-east-1"]'`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddStringFlag(constants.ArgPlugin, "", "The plugin used by the connection").
+		AddStringFlag(constants.ArgConnectionConfig, "", "The plugin specific connection config, in HCL").
+		AddBoolFlag(constants.ArgHelp, false, "Help for connection add", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runConnectionAddCmd(cmd *cobra.Command, args []string) {
+	// setup a cancel context and start cancel handler
+	ctx, cancel := context.WithCancel(cmd.Context())
+	contexthelpers.StartCancelHandler(cancel)
+
+	utils.LogTime("runConnectionAddCmd start")
+	defer func() {
+		utils.LogTime("runConnectionAddCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	connectionName := args[0]
+	if _, ok := steampipeconfig.GlobalConfig.Connections[connectionName]; ok {
+		error_helpers.ShowError(ctx, fmt.Errorf("connection '%s' already exists", connectionName))
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+
+	configPath, err := steampipeconfig.AddConnectionConfig(filepaths.EnsureConfigDir(), connectionName, viper.GetString(constants.ArgPlugin), viper.GetString(constants.ArgConnectionConfig))
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, fmt.Sprintf("failed to add connection '%s'", connectionName))
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+	fmt.Printf("Added connection '%s' to %s\n", connectionName, configPath)
+
+	refreshChangedConnections(ctx, connectionName)
+}
+
+// Remove a connection
+func connectionRemoveCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "remove [flags] connection",
+		Args:  cobra.ExactArgs(1),
+		Run:   runConnectionRemoveCmd,
+		Short: "Remove a connection",
+		Long: `Remove a connection.
+
+The connection block is removed from the file it is declared in - if the file contains no other config, it is deleted.
+If the service is running, the connection config is reloaded and the schema for the connection is dropped.
+
+Examples:
+
+  # Remove the aws_dev connection
+  steampipe connection remove aws_dev`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for connection remove", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runConnectionRemoveCmd(cmd *cobra.Command, args []string) {
+	// setup a cancel context and start cancel handler
+	ctx, cancel := context.WithCancel(cmd.Context())
+	contexthelpers.StartCancelHandler(cancel)
+
+	utils.LogTime("runConnectionRemoveCmd start")
+	defer func() {
+		utils.LogTime("runConnectionRemoveCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	connectionName := args[0]
+	c, ok := steampipeconfig.GlobalConfig.Connections[connectionName]
+	if !ok {
+		error_helpers.ShowError(ctx, fmt.Errorf("connection '%s' does not exist", connectionName))
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+
+	if err := steampipeconfig.RemoveConnectionConfig(c); err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, fmt.Sprintf("failed to remove connection '%s'", connectionName))
+		exitCode = constants.ExitCodeFileSystemAccessFailure
+		return
+	}
+	fmt.Printf("Removed connection '%s' from %s\n", connectionName, c.DeclRange.Filename)
+
+	// there is nothing to force update - the refresh deletes the schemas of connections which are no longer configured
+	refreshChangedConnections(ctx)
+}
+
+// Import connections
+func connectionImportCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "import [flags]",
+		Args:  cobra.NoArgs,
+		Run:   runConnectionImportCmd,
+		Short: "Add connections for the cloud accounts in a terraform state",
+		Long: `Add connections for the cloud accounts in a terraform state.
+
+A connection is generated for each AWS account (from aws_organizations_account resources and the
+aws_organizations_organization data source), GCP project (google_project) and Azure subscription (azurerm_subscription).
+AWS connections use a profile with the name of the account.
+
+--from-terraform is either a state file or a terraform working directory - for a directory, the state of the
+selected terraform workspace is used.
+
+Each connection is written to a new file '<connection>.spc' in the Steampipe config directory - connections which
+already exist are skipped. If the service is running, the connection config is reloaded and the schemas for the
+new connections are imported.
+
+Examples:
+
+  # Add connections for the accounts in a state file
+  steampipe connection import --from-terraform ./terraform.tfstate
+
+  # Add connections for the accounts in the selected workspace of a terraform working directory
+  steampipe connection import --from-terraform ~/src/infra`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddStringFlag(constants.ArgFromTerraform, "", "The terraform state file or working directory to import connections from").
+		AddBoolFlag(constants.ArgHelp, false, "Help for connection import", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runConnectionImportCmd(cmd *cobra.Command, _ []string) {
+	// setup a cancel context and start cancel handler
+	ctx, cancel := context.WithCancel(cmd.Context())
+	contexthelpers.StartCancelHandler(cancel)
+
+	utils.LogTime("runConnectionImportCmd start")
+	defer func() {
+		utils.LogTime("runConnectionImportCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	statePath := viper.GetString(constants.ArgFromTerraform)
+	if statePath == "" {
+		error_helpers.ShowError(ctx, fmt.Errorf("--%s must be specified", constants.ArgFromTerraform))
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+	connections, err := steampipeconfig.LoadTerraformConnections(statePath)
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "failed to load terraform state")
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+	if len(connections) == 0 {
+		fmt.Printf("No cloud accounts found in %s\n", statePath)
+		return
+	}
+
+	var added []string
+	configDir := filepaths.EnsureConfigDir()
+	for _, c := range connections {
+		if _, ok := steampipeconfig.GlobalConfig.Connections[c.Name]; ok {
+			fmt.Printf("Skipped connection '%s' (%s) - it already exists\n", c.Name, c.Source)
+			continue
+		}
+		// a connection for a plugin which is not installed would fail to load
+		if pluginPath, _ := filepaths.GetPluginPath(modconfig.ResolvePluginImageRef(c.Plugin), c.Plugin); pluginPath == "" {
+			fmt.Printf("Skipped connection '%s' (%s) - plugin '%s' is not installed, install it with 'steampipe plugin install %s'\n", c.Name, c.Source, c.Plugin, c.Plugin)
+			continue
+		}
+		configPath, err := steampipeconfig.AddConnectionConfig(configDir, c.Name, c.Plugin, c.Config)
+		if err != nil {
+			error_helpers.ShowErrorWithMessage(ctx, err, fmt.Sprintf("failed to add connection '%s'", c.Name))
+			exitCode = constants.ExitCodeInsufficientOrWrongInputs
+			continue
+		}
+		fmt.Printf("Added connection '%s' (%s) to %s\n", c.Name, c.Source, configPath)
+		added = append(added, c.Name)
+	}
+
+	if len(added) > 0 {
+		refreshChangedConnections(ctx, added...)
+	}
+}
+
+// Test a connection
+func connectionTestCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "test [flags] [connection]",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runConnectionTestCmd,
+		Short: "Test the plugin can connect using the config of a connection",
+		Long: `Test the plugin can connect using the config of a connection.
+
+Read a single row from one of the connection tables, reporting any error returned by the plugin.
+The query is executed directly against the plugin - the connection schema is not imported or changed.
+If no connection is given, all connections other than aggregators are tested.
+
+The status and latency of each test are recorded, and may be queried from the steampipe_connection_health table.
+
+Examples:
+
+  # Test the aws connection
+  steampipe connection test aws
+
+  # Test all connections
+  steampipe connection test`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for connection test", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runConnectionTestCmd(cmd *cobra.Command, args []string) {
+	// setup a cancel context and start cancel handler
+	ctx, cancel := context.WithCancel(cmd.Context())
+	contexthelpers.StartCancelHandler(cancel)
+
+	utils.LogTime("runConnectionTestCmd start")
+	defer func() {
+		utils.LogTime("runConnectionTestCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	var connectionNames []string
+	if len(args) == 1 {
+		connectionName := args[0]
+		if _, ok := steampipeconfig.GlobalConfig.Connections[connectionName]; !ok {
+			error_helpers.ShowError(ctx, fmt.Errorf("connection '%s' does not exist", connectionName))
+			exitCode = constants.ExitCodeInsufficientOrWrongInputs
+			return
+		}
+		connectionNames = append(connectionNames, connectionName)
+	}
+
+	health, err := testConnections(ctx, connectionNames...)
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "connection test failed")
+		exitCode = constants.ExitCodeConnectionTestFailed
+		// still show the results of any connections which were tested
+	}
+
+	// a single connection is reported in full
+	if len(connectionNames) == 1 && len(health) == 1 {
+		h := health[0]
+		if !h.Healthy() {
+			error_helpers.ShowErrorWithMessage(ctx, errors.New(h.Error), fmt.Sprintf("connection '%s' test failed", h.ConnectionName))
+			exitCode = constants.ExitCodeConnectionTestFailed
+			return
+		}
+		fmt.Printf("Connection '%s' is working (queried table '%s' in %dms)\n", h.ConnectionName, h.Table, h.LatencyMs)
+		return
+	}
+
+	headers := []string{"Connection", "Status", "Latency (ms)", "Table", "Error"}
+	var rows [][]string
+	for _, h := range health {
+		rows = append(rows, []string{h.ConnectionName, h.Status, fmt.Sprintf("%d", h.LatencyMs), h.Table, h.Error})
+		if !h.Healthy() {
+			exitCode = constants.ExitCodeConnectionTestFailed
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, []string{"", "", "", "", ""})
+	}
+	display.ShowWrappedTable(headers, rows, &display.ShowWrappedTableOptions{AutoMerge: false})
+}
+
+// Show the user search path
+func connectionSearchPathCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "search-path",
+		Args:  cobra.NoArgs,
+		Run:   runConnectionSearchPathCmd,
+		Short: "Show the search path which is set for steampipe users",
+		Long: `Show the search path which is set for steampipe users.
+
+The search path is computed from the connection config and the search_path and search_path_limit options,
+exactly as it is when connections are refreshed - the service is not started and the search path is not changed.
+
+Examples:
+
+  # Show the search path
+  steampipe connection search-path`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for connection search-path", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runConnectionSearchPathCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	utils.LogTime("runConnectionSearchPathCmd start")
+	defer func() {
+		utils.LogTime("runConnectionSearchPathCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	searchPath, omitted := db_local.ComputeUserSearchPath()
+	fmt.Println(db_local.FormatSearchPath(searchPath))
+	if warning := db_local.SearchPathLimitWarning(omitted); warning != "" {
+		error_helpers.ShowWarning(warning)
+	}
+}
+
+func testConnections(ctx context.Context, connectionNames ...string) ([]*steampipeconfig.ConnectionHealth, error) {
+	statushooks.Show(ctx)
+	defer statushooks.Done(ctx)
+
+	// start service - the plugin manager is started with the database
+	client, res := db_local.GetLocalClient(ctx, constants.InvokerPlugin, nil)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	defer client.Close(ctx)
+
+	statushooks.SetStatus(ctx, "Testing connections")
+	pluginManager, err := pluginmanager.GetPluginManager()
+	if err != nil {
+		return nil, err
+	}
+	return db_local.CheckConnectionHealth(ctx, pluginManager, connectionNames...)
+}
+
+// refreshChangedConnections asks the running service to reload the connection config and refresh connections,
+// force updating the given connections
+// if the service is not running there is nothing to do - the change is picked up when the service is next started
+// Refresh connections
+func connectionRefreshCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "refresh [flags] [connection...]",
+		Args:  cobra.ArbitraryArgs,
+		Run:   runConnectionRefreshCmd,
+		Short: "Refresh the connections in the running service",
+		Long: `Refresh the connections in the running service.
+
+The connection config is reloaded and the schemas of any changed connections are updated.
+The schemas of the given connections are updated even if they have not changed.
+A summary of the changes is shown once the refresh is complete.
+
+Examples:
+
+  # Refresh any changed connections
+  steampipe connection refresh
+
+  # Update the schema of the aws connection
+  steampipe connection refresh aws
+
+  # Show the refresh summary as json
+  steampipe connection refresh --output json
+
+  # Show the number of foreign tables and approximate catalog size of each connection schema
+  steampipe connection refresh --refresh-catalog-stats`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatTable, "Select a console output format: table or json").
+		AddBoolFlag(constants.ArgHelp, false, "Help for connection refresh", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runConnectionRefreshCmd(cmd *cobra.Command, args []string) {
+	// setup a cancel context and start cancel handler
+	ctx, cancel := context.WithCancel(cmd.Context())
+	contexthelpers.StartCancelHandler(cancel)
+
+	utils.LogTime("runConnectionRefreshCmd start")
+	defer func() {
+		utils.LogTime("runConnectionRefreshCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	// validate output arg
+	output := viper.GetString(constants.ArgOutput)
+	if !helpers.StringSliceContains([]string{constants.OutputFormatTable, constants.OutputFormatJSON}, output) {
+		error_helpers.ShowError(ctx, fmt.Errorf("output flag must be either 'json' or 'table'"))
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+
+	for _, connectionName := range args {
+		if _, ok := steampipeconfig.GlobalConfig.Connections[connectionName]; !ok {
+			error_helpers.ShowError(ctx, fmt.Errorf("connection '%s' does not exist", connectionName))
+			exitCode = constants.ExitCodeInsufficientOrWrongInputs
+			return
+		}
+	}
+
+	if info, _ := db_local.GetState(); info == nil {
+		error_helpers.ShowError(ctx, fmt.Errorf("the service is not running - connections are refreshed when it is next started"))
+		exitCode = constants.ExitCodeConnectionRefreshFailed
+		return
+	}
+
+	summary := refreshConnections(ctx, args...)
+	if summary == nil {
+		return
+	}
+	rendered, err := summary.Render(output)
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		return
+	}
+	fmt.Println(strings.TrimSuffix(rendered, "\n"))
+}
+
+// refreshChangedConnections refreshes the connections of the running service after the connection config
+// has been changed, showing a single line summary of the changes made
+func refreshChangedConnections(ctx context.Context, forceUpdateConnectionNames ...string) {
+	if info, _ := db_local.GetState(); info == nil {
+		fmt.Println("The service is not running - the change will be applied when it is next started")
+		return
+	}
+	if summary := refreshConnections(ctx, forceUpdateConnectionNames...); summary != nil {
+		fmt.Printf("Refreshed connections: %s\n", summary)
+	}
+}
+
+// refreshConnections reloads the connection config of the running service and refreshes its connections,
+// force updating the given connections
+// the summary of the refresh is returned - this is nil if the refresh could not be run
+func refreshConnections(ctx context.Context, forceUpdateConnectionNames ...string) *steampipeconfig.RefreshConnectionSummary {
+
+	statushooks.Show(ctx)
+	defer statushooks.Done(ctx)
+	statushooks.SetStatus(ctx, "Refreshing connections")
+
+	client, err := refresh_rpc.NewClient(filepaths.RefreshServiceSocketPath())
+	if err != nil {
+		error_helpers.ShowWarning(fmt.Sprintf("could not contact the service to refresh connections - the change will be applied by the connection watcher: %s", err.Error()))
+		return nil
+	}
+	defer client.Close()
+
+	// display the progress of the refresh while waiting for it to complete
+	refreshID := uuid.New().String()
+	stopProgress := db_local.ShowRefreshProgress(ctx, refreshID)
+	res, err := client.ReloadAndRefreshConnections(refresh_rpc.RefreshRequest{
+		ForceUpdateConnectionNames: forceUpdateConnectionNames,
+		RefreshID:                  refreshID,
+		CatalogStats:               viper.GetBool(constants.ArgRefreshCatalogStats),
+	})
+	stopProgress()
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "failed to refresh connections")
+		exitCode = constants.ExitCodeConnectionRefreshFailed
+		return nil
+	}
+	statushooks.Done(ctx)
+	res.ShowWarnings()
+	if res.Error != nil {
+		error_helpers.ShowErrorWithMessage(ctx, res.Error, "failed to refresh connections")
+		exitCode = constants.ExitCodeConnectionRefreshFailed
+		return nil
+	}
+	for _, name := range forceUpdateConnectionNames {
+		if failure, ok := res.FailedConnections[name]; ok {
+			error_helpers.ShowError(ctx, fmt.Errorf("connection '%s' failed to refresh: %s", name, failure))
+			exitCode = constants.ExitCodeConnectionRefreshFailed
+		}
+	}
+	return res.Summary()
+}
+
+// connectionDeclLocation returns the file and line the connection is declared at
+func connectionDeclLocation(c *modconfig.Connection) string {
+	if c.DeclRange.Filename == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.DeclRange.Filename, c.DeclRange.Start.Line)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -48,8 +49,26 @@ The current mod is the working directory, or the directory specified by the --mo
 		AddModLocationFlag().
 		AddBoolFlag(constants.ArgHelp, false, "Help for dashboard", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddBoolFlag(constants.ArgModInstall, true, "Specify whether to install mod dependencies before running the dashboard").
-		AddStringFlag(constants.ArgDashboardListen, string(dashboardserver.ListenTypeLocal), "Accept connections from: local (localhost only) or network (open)").
-		AddIntFlag(constants.ArgDashboardPort, constants.DashboardServerDefaultPort, "Dashboard server port").
+		AddStringFlag(constants.ArgDashboardListen, string(dashboardserver.ListenTypeLocal), "Accept connections from: local (localhost only), network (open) or a specific host name or IP address").
+		AddIntFlag(constants.ArgDashboardPort, constants.DashboardServerDefaultPort, "Dashboard server port (use 0 to select a free port)").
+		AddIntFlag(constants.ArgDashboardReadHeaderTimeout, int(constants.DashboardReadHeaderTimeout.Seconds()), "Dashboard server timeout for reading request headers, in seconds").
+		AddIntFlag(constants.ArgDashboardReadTimeout, int(constants.DashboardReadTimeout.Seconds()), "Dashboard server timeout for reading a request, in seconds").
+		AddIntFlag(constants.ArgDashboardWriteTimeout, int(constants.DashboardWriteTimeout.Seconds()), "Dashboard server timeout for writing a response, in seconds (does not apply to websocket connections)").
+		AddIntFlag(constants.ArgDashboardIdleTimeout, int(constants.DashboardIdleTimeout.Seconds()), "Dashboard server timeout for idle keep-alive connections, in seconds").
+		AddIntFlag(constants.ArgDashboardMaxMessageSize, constants.DashboardMaxMessageSize, "Maximum size of a message sent to a dashboard client, in bytes - larger messages are replaced with a truncation notice (0 for no limit)").
+		AddIntFlag(constants.ArgDashboardMessageBufferSize, constants.DashboardMessageBufferSize, "Maximum number of messages queued for a dashboard client - slow clients which exceed this are disconnected").
+		AddBoolFlag(constants.ArgDashboardDeltaUpdates, false, "Send only the rows which have changed when a dashboard panel is re-executed, rather than the full result").
+		AddStringFlag(constants.ArgDashboardSSLCert, "", "Path to a PEM encoded certificate used to serve the dashboard over TLS").
+		AddStringFlag(constants.ArgDashboardSSLKey, "", "Path to the PEM encoded private key for the dashboard server certificate").
+		AddStringFlag(constants.ArgTLSClientCA, "", "Path to a PEM encoded CA certificate - when set, dashboard clients must present a certificate signed by this CA").
+		AddStringFlag(constants.ArgDashboardAuth, constants.DashboardAuthNone, "Authenticate dashboard clients using: none, token (a shared token), basic (a users file) or oidc").
+		AddStringFlag(constants.ArgDashboardAuthToken, "", "The shared token for token authentication (a random token is generated if this is not set)").
+		AddStringFlag(constants.ArgDashboardAuthUsers, "", "Path to a file of 'user:password[:role]' lines for basic authentication - passwords may be bcrypt hashes").
+		AddStringFlag(constants.ArgDashboardAuthRole, "", "The Postgres role used to run the queries of authenticated dashboard sessions").
+		AddIntFlag(constants.ArgDashboardAuthSessionTTL, int(constants.DashboardAuthSessionTTL.Seconds()), "The lifetime of an authenticated dashboard session, in seconds").
+		AddStringFlag(constants.ArgDashboardOIDCIssuer, "", "The issuer URL of the OIDC provider used for oidc authentication").
+		AddStringFlag(constants.ArgDashboardOIDCClientID, "", "The client id registered with the OIDC provider").
+		AddStringFlag(constants.ArgDashboardOIDCClientSecret, "", "The client secret registered with the OIDC provider").
 		AddBoolFlag(constants.ArgBrowser, true, "Specify whether to launch the browser after starting the dashboard server").
 		AddStringSliceFlag(constants.ArgSearchPath, nil, "Set a custom search_path for the steampipe user for a dashboard session (comma-separated)").
 		AddStringSliceFlag(constants.ArgSearchPathPrefix, nil, "Set a prefix to the current search path for a dashboard session (comma-separated)").
@@ -60,7 +79,7 @@ The current mod is the working directory, or the directory specified by the --mo
 		// Cobra will interpret values passed to a StringSliceFlag as CSV, where args passed to StringArrayFlag are not parsed and used raw
 		AddStringArrayFlag(constants.ArgVariable, nil, "Specify the value of a variable").
 		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
-		AddStringFlag(constants.ArgOutput, constants.OutputFormatNone, "Select a console output format: none, snapshot").
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatNone, "Select a console output format: none, snapshot, json").
 		AddBoolFlag(constants.ArgSnapshot, false, "Create snapshot in Turbot Pipes with the default (workspace) visibility").
 		AddBoolFlag(constants.ArgShare, false, "Create snapshot in Turbot Pipes with 'anyone_with_link' visibility").
 		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path or a Turbot Pipes workspace").
@@ -69,11 +88,20 @@ The current mod is the working directory, or the directory specified by the --mo
 		// Cobra will interpret values passed to a StringSliceFlag as CSV, where args passed to StringArrayFlag are not parsed and used raw
 		AddStringArrayFlag(constants.ArgDashboardInput, nil, "Specify the value of a dashboard input").
 		AddStringArrayFlag(constants.ArgSnapshotTag, nil, "Specify tags to set on the snapshot").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported format: sps (snapshot)").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: sps (snapshot), html, pdf").
+		// NOTE: use StringArrayFlag for ArgDashboardSchedule, as cron expressions contain commas
+		AddStringArrayFlag(constants.ArgDashboardSchedule, nil, "Run a dashboard on a schedule, of the form '<dashboard>=<cron expression>', e.g. 'aws_insights.dashboard.aws_account_report=0 6 * * *'").
+		AddStringSliceFlag(constants.ArgDashboardScheduleExport, []string{constants.OutputFormatSnapshot}, "Export the results of scheduled runs to file, supported formats: sps (snapshot), html, pdf").
+		AddStringFlag(constants.ArgDashboardScheduleOutputDir, ".", "The directory the results of scheduled runs are exported to").
+		AddStringFlag(constants.ArgDashboardScheduleWebhook, "", "POST the snapshot of each scheduled run to this URL").
+		AddStringFlag(constants.ArgDashboardScheduleS3, "", "Upload the exported results of scheduled runs to this S3 location, of the form 's3://<bucket>/<prefix>'").
+		// NOTE: use StringArrayFlag for ArgDashboardWorkspace, as paths may contain commas
+		AddStringArrayFlag(constants.ArgDashboardWorkspace, nil, "Serve the dashboards of an additional workspace, of the form '[<name>=]<path>' - the workspace is named after its mod if no name is given").
 		// hidden flags that are used internally
 		AddBoolFlag(constants.ArgServiceMode, false, "Hidden flag to specify whether this is starting as a service", cmdconfig.FlagOptions.Hidden())
 
 	cmd.AddCommand(getListSubCmd(listSubCmdOptions{parentCmd: cmd}))
+	cmd.AddCommand(dashboardSnapshotCmd())
 
 	return cmd
 }
@@ -124,13 +152,17 @@ func runDashboardCmd(cmd *cobra.Command, args []string) {
 	serverListen := dashboardserver.ListenType(viper.GetString(constants.ArgDashboardListen))
 	error_helpers.FailOnError(serverListen.IsValid())
 
-	serverHost := ""
-	if serverListen == dashboardserver.ListenTypeLocal {
-		serverHost = "127.0.0.1"
-	}
-	if err := utils.IsPortBindable(serverHost, int(serverPort)); err != nil {
-		exitCode = constants.ExitCodeBindPortUnavailable
-		error_helpers.FailOnError(err)
+	// if an explicit port was given, fail early if it is unavailable
+	// (if the port is 0, a free port will be selected when the server starts)
+	if !serverPort.IsAuto() {
+		serverHost := serverListen.Host()
+		if serverListen == dashboardserver.ListenTypeLocal {
+			serverHost = "127.0.0.1"
+		}
+		if err := utils.IsPortBindable(serverHost, int(serverPort)); err != nil {
+			exitCode = constants.ExitCodeBindPortUnavailable
+			error_helpers.FailOnError(dashboardserver.PortInUseError(serverPort))
+		}
 	}
 
 	// create context for the dashboard execution
@@ -149,6 +181,10 @@ func runDashboardCmd(cmd *cobra.Command, args []string) {
 	defer initData.Cleanup(dashboardCtx)
 	if initData.Result.Error != nil {
 		exitCode = constants.ExitCodeInitializationFailed
+		// if startup was cancelled (i.e. the process was interrupted or terminated), say so explicitly
+		if error_helpers.IsContextCanceled(dashboardCtx) {
+			error_helpers.FailOnError(errors.New("refresh interrupted - dashboard startup was cancelled"))
+		}
 		error_helpers.FailOnError(initData.Result.Error)
 	}
 
@@ -161,14 +197,33 @@ func runDashboardCmd(cmd *cobra.Command, args []string) {
 	server, err := dashboardserver.NewServer(dashboardCtx, initData.Client, initData.Workspace)
 	error_helpers.FailOnError(err)
 
+	// add any additional workspaces to serve
+	workspaceArgs, err := dashboardserver.WorkspaceArgsFromConfig()
+	error_helpers.FailOnError(err)
+	for _, workspaceArg := range workspaceArgs {
+		w := loadAdditionalWorkspace(dashboardCtx, workspaceArg.Path)
+		defer w.Close()
+		error_helpers.FailOnError(server.AddWorkspace(dashboardCtx, workspaceArg.Name, w))
+	}
+
 	// start the server asynchronously - this returns a chan which is signalled when the internal API server terminates
-	doneChan := server.Start(dashboardCtx)
+	doneChan, err := server.Start(dashboardCtx)
+	if err != nil {
+		exitCode = constants.ExitCodeBindPortUnavailable
+		error_helpers.FailOnError(err)
+	}
 
 	// cleanup
 	defer server.Shutdown(dashboardCtx)
 
+	// start any scheduled runs - these use the inputs passed on the command line
+	scheduleInputs, err := collectInputs()
+	error_helpers.FailOnError(err)
+	error_helpers.FailOnError(server.StartScheduler(dashboardCtx, dashboardserver.ScheduleOptionsFromConfig(scheduleInputs)))
+
 	// server has started - update state file/start browser, as required
-	onServerStarted(dashboardCtx, serverPort, serverListen, initData.Workspace)
+	// use the port the server actually bound to, which may differ from the requested port if that was 0
+	onServerStarted(dashboardCtx, server, serverListen, initData.Workspace)
 
 	// wait for API server to terminate
 	<-doneChan
@@ -202,7 +257,7 @@ func validateDashboardArgs(ctx context.Context, args []string) (string, error) {
 		}
 	}
 
-	validOutputFormats := []string{constants.OutputFormatSnapshot, constants.OutputFormatSnapshotShort, constants.OutputFormatNone}
+	validOutputFormats := []string{constants.OutputFormatSnapshot, constants.OutputFormatSnapshotShort, constants.OutputFormatJSON, constants.OutputFormatNone}
 	output := viper.GetString(constants.ArgOutput)
 	if !helpers.StringSliceContains(validOutputFormats, output) {
 		return "", fmt.Errorf("invalid output format: '%s', must be one of [%s]", output, strings.Join(validOutputFormats, ", "))
@@ -218,14 +273,42 @@ func displaySnapshot(snapshot *dashboardtypes.SteampipeSnapshot) {
 			!viper.IsSet(constants.ArgOutput) &&
 			!viper.GetBool(constants.ArgShare) &&
 			!viper.GetBool(constants.ArgSnapshot) {
-			fmt.Println("Output format defaulted to 'none'. Supported formats: none, snapshot.")
+			fmt.Println("Output format defaulted to 'none'. Supported formats: none, snapshot, json.")
 		}
 	case constants.OutputFormatSnapshot, constants.OutputFormatSnapshotShort:
 		// just display result
 		snapshotText, err := json.MarshalIndent(snapshot, "", "  ")
 		error_helpers.FailOnError(err)
 		fmt.Println(string(snapshotText))
+	case constants.OutputFormatJSON:
+		// display the panel data only
+		dashboardData, err := dashboardtypes.NewDashboardData(snapshot)
+		error_helpers.FailOnError(err)
+		dataText, err := json.MarshalIndent(dashboardData, "", "  ")
+		error_helpers.FailOnError(err)
+		fmt.Println(string(dataText))
+	}
+}
+
+// verifyDashboardData returns an error if any panel of the dashboard failed
+// (this is only done when displaying the panel data, so consumers of the data do not silently use partial results)
+func verifyDashboardData(snapshot *dashboardtypes.SteampipeSnapshot) error {
+	if viper.GetString(constants.ArgOutput) != constants.OutputFormatJSON {
+		return nil
+	}
+	return verifyDashboardPanels(snapshot)
+}
+
+// verifyDashboardPanels returns an error listing the panels of the dashboard which failed (if any)
+func verifyDashboardPanels(snapshot *dashboardtypes.SteampipeSnapshot) error {
+	dashboardData, err := dashboardtypes.NewDashboardData(snapshot)
+	if err != nil {
+		return err
+	}
+	if panelErrors := dashboardData.PanelErrors(); len(panelErrors) > 0 {
+		return fmt.Errorf("%d dashboard %s failed:\n%s", len(panelErrors), utils.Pluralize("panel", len(panelErrors)), strings.Join(panelErrors, "\n"))
 	}
+	return nil
 }
 
 func initDashboard(ctx context.Context) *initialisation.InitData {
@@ -245,6 +328,21 @@ func initDashboard(ctx context.Context) *initialisation.InitData {
 	return initData
 }
 
+// loadAdditionalWorkspace loads a workspace passed using --dashboard-workspace - as for the default workspace,
+// the workspace must have a mod file
+func loadAdditionalWorkspace(ctx context.Context, workspacePath string) *workspace.Workspace {
+	dashboardserver.OutputWait(ctx, fmt.Sprintf("Loading Workspace %s", workspacePath))
+	w, errAndWarnings := workspace.LoadWorkspaceAtPathPromptingForVariables(ctx, workspacePath)
+	if errAndWarnings.GetError() != nil {
+		error_helpers.FailOnError(fmt.Errorf("failed to load workspace %s: %s", workspacePath, error_helpers.HandleCancelError(errAndWarnings.GetError()).Error()))
+	}
+	if !w.ModfileExists() {
+		w.Close()
+		error_helpers.FailOnError(fmt.Errorf("failed to load workspace %s: %s", workspacePath, workspace.ErrorNoModDefinition.Error()))
+	}
+	return w
+}
+
 func getInitData(ctx context.Context) *initialisation.InitData {
 	w, errAndWarnings := workspace.LoadWorkspacePromptingForVariables(ctx)
 	if errAndWarnings.GetError() != nil {
@@ -269,7 +367,7 @@ func getInitData(ctx context.Context) *initialisation.InitData {
 }
 
 func dashboardExporters() []export.Exporter {
-	return []export.Exporter{&export.SnapshotExporter{}}
+	return []export.Exporter{&export.SnapshotExporter{}, &export.HtmlExporter{}, &export.PdfExporter{}}
 }
 
 func runSingleDashboard(ctx context.Context, targetName string, inputs map[string]interface{}) error {
@@ -283,25 +381,9 @@ func runSingleDashboard(ctx context.Context, targetName string, inputs map[strin
 
 	// shutdown the service on exit
 	defer initData.Cleanup(ctx)
-	if err := initData.Result.Error; err != nil {
-		return initData.Result.Error
-	}
-	// targetName must be a named resource
-	// parse the name to verify
-	targetResource, err := verifyNamedResource(targetName, initData.Workspace)
-	if err != nil {
-		return err
-	}
-	// update name to make sure it is fully qualified
-	targetName = targetResource.Name()
-
-	// if there is a usage warning we display it
-	initData.Result.DisplayMessages()
 
-	// so a dashboard name was specified - just call GenerateSnapshot
-	snap, err := dashboardexecute.GenerateSnapshot(ctx, targetName, initData, inputs)
+	snap, err := generateDashboardSnapshot(ctx, initData, targetName, inputs)
 	if err != nil {
-		exitCode = constants.ExitCodeSnapshotCreationFailed
 		return err
 	}
 	// display the snapshot result (if needed)
@@ -326,9 +408,39 @@ func runSingleDashboard(ctx context.Context, targetName string, inputs map[strin
 		fmt.Printf("\n")
 	}
 
+	if err := verifyDashboardData(snap); err != nil {
+		exitCode = constants.ExitCodeDashboardPanelsFailed
+		return err
+	}
 	return nil
 }
 
+// generateDashboardSnapshot runs the named dashboard (or benchmark) and returns the snapshot of the result
+func generateDashboardSnapshot(ctx context.Context, initData *initialisation.InitData, targetName string, inputs map[string]interface{}) (*dashboardtypes.SteampipeSnapshot, error) {
+	if err := initData.Result.Error; err != nil {
+		return nil, initData.Result.Error
+	}
+	// targetName must be a named resource
+	// parse the name to verify
+	targetResource, err := verifyNamedResource(targetName, initData.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	// update name to make sure it is fully qualified
+	targetName = targetResource.Name()
+
+	// if there is a usage warning we display it
+	initData.Result.DisplayMessages()
+
+	// so a dashboard name was specified - just call GenerateSnapshot
+	snap, err := dashboardexecute.GenerateSnapshot(ctx, targetName, initData, inputs)
+	if err != nil {
+		exitCode = constants.ExitCodeSnapshotCreationFailed
+		return nil, err
+	}
+	return snap, nil
+}
+
 func verifyNamedResource(targetName string, w *workspace.Workspace) (modconfig.HclResource, error) {
 	parsedName, err := modconfig.ParseResourceName(targetName)
 	if err != nil {
@@ -384,14 +496,14 @@ func setExitCodeForDashboardError(err error) {
 }
 
 // execute any required actions after successful server startup
-func onServerStarted(ctx context.Context, serverPort dashboardserver.ListenPort, serverListen dashboardserver.ListenType, w *workspace.Workspace) {
+func onServerStarted(ctx context.Context, server *dashboardserver.Server, serverListen dashboardserver.ListenType, w *workspace.Workspace) {
 	if isRunningAsService() {
 		// for service mode only, save the state
-		saveDashboardState(serverPort, serverListen)
+		saveDashboardState(server.Port(), serverListen)
 	} else {
 		// start browser if required
 		if viper.GetBool(constants.ArgBrowser) {
-			url := buildDashboardURL(serverPort, w)
+			url := buildDashboardURL(server.URL(), w)
 			if err := utils.OpenBrowser(url); err != nil {
 				dashboardserver.OutputWarning(ctx, "Could not start web browser.")
 				log.Println("[TRACE] dashboard server started but failed to start client", err)
@@ -400,8 +512,8 @@ func onServerStarted(ctx context.Context, serverPort dashboardserver.ListenPort,
 	}
 }
 
-func buildDashboardURL(serverPort dashboardserver.ListenPort, w *workspace.Workspace) string {
-	url := fmt.Sprintf("http://localhost:%d", serverPort)
+func buildDashboardURL(serverURL string, w *workspace.Workspace) string {
+	url := serverURL
 	if len(w.SourceSnapshots) == 1 {
 		for snapshotName := range w.GetResourceMaps().Snapshots {
 			url += fmt.Sprintf("/%s", snapshotName)
@@ -439,6 +551,8 @@ func saveDashboardState(serverPort dashboardserver.ListenPort, serverListen dash
 		Port:       int(serverPort),
 		ListenType: string(serverListen),
 		Listen:     constants.DashboardListenAddresses,
+		SSLCert:    viper.GetString(constants.ArgDashboardSSLCert),
+		SSLKey:     viper.GetString(constants.ArgDashboardSSLKey),
 	}
 
 	if serverListen == dashboardserver.ListenTypeNetwork {
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/cmdconfig"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// dashboardSnapshotFormats maps the output formats of 'dashboard snapshot' to the extension of the file they write
+var dashboardSnapshotFormats = map[string]string{
+	constants.OutputFormatSnapshot: constants.SnapshotExtension,
+	constants.OutputFormatHTML:     constants.HtmlExtension,
+	constants.OutputFormatPDF:      constants.PdfExtension,
+}
+
+// Render a dashboard to a file
+func dashboardSnapshotCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "snapshot [flags] benchmark/dashboard",
+		Args:  cobra.ExactArgs(1),
+		Run:   runDashboardSnapshotCmd,
+		Short: "Run a named dashboard and write the result to a file",
+		Long: `Run a named dashboard (or benchmark) and write the result to a file, without starting the dashboard UI.
+
+The output format is one of:
+  snapshot  a snapshot file (.sps) - the dashboard structure and data as JSON
+  html      a self-contained html report of the panel data, with the snapshot JSON embedded
+  pdf       a plain text pdf report of the panel data
+
+If no --output-file is given, the file is written to the current directory, named after the dashboard and the time it was run.
+The exit code is non-zero if any panel of the dashboard failed, so the command may be used in CI pipelines.
+
+Examples:
+
+  # Write a snapshot of a dashboard
+  steampipe dashboard snapshot aws_insights.dashboard.aws_account_report
+
+  # Write an html report of a dashboard to a given file
+  steampipe dashboard snapshot aws_insights.dashboard.aws_account_report --output html --output-file report.html`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddCloudFlags().
+		AddWorkspaceDatabaseFlag().
+		AddModLocationFlag().
+		AddBoolFlag(constants.ArgHelp, false, "Help for dashboard snapshot", cmdconfig.FlagOptions.WithShortHand("h")).
+		AddBoolFlag(constants.ArgModInstall, true, "Specify whether to install mod dependencies before running the dashboard").
+		AddStringSliceFlag(constants.ArgSearchPath, nil, "Set a custom search_path for the steampipe user for a dashboard session (comma-separated)").
+		AddStringSliceFlag(constants.ArgSearchPathPrefix, nil, "Set a prefix to the current search path for a dashboard session (comma-separated)").
+		AddIntFlag(constants.ArgMaxParallel, constants.DefaultMaxConnections, "The maximum number of concurrent database connections to open").
+		AddStringSliceFlag(constants.ArgVarFile, nil, "Specify an .spvar file containing variable values").
+		AddBoolFlag(constants.ArgProgress, true, "Display dashboard execution progress").
+		// NOTE: use StringArrayFlag for ArgVariable, not StringSliceFlag
+		// Cobra will interpret values passed to a StringSliceFlag as CSV, where args passed to StringArrayFlag are not parsed and used raw
+		AddStringArrayFlag(constants.ArgVariable, nil, "Specify the value of a variable").
+		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
+		// NOTE: use StringArrayFlag for ArgDashboardInput, not StringSliceFlag
+		// Cobra will interpret values passed to a StringSliceFlag as CSV, where args passed to StringArrayFlag are not parsed and used raw
+		AddStringArrayFlag(constants.ArgDashboardInput, nil, "Specify the value of a dashboard input").
+		AddStringFlag(constants.ArgSnapshotTitle, "", "The title to give a snapshot").
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatSnapshot, "Select the output format: snapshot, html, pdf").
+		AddStringFlag(constants.ArgOutputFile, "", "The file to write the output to")
+	return cmd
+}
+
+func runDashboardSnapshotCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	var err error
+	utils.LogTime("runDashboardSnapshotCmd start")
+	defer func() {
+		utils.LogTime("runDashboardSnapshotCmd end")
+		if r := recover(); r != nil {
+			err = helpers.ToError(r)
+			error_helpers.ShowError(ctx, err)
+		}
+		setExitCodeForDashboardError(err)
+	}()
+
+	exportTarget, err := dashboardSnapshotExportTarget(viper.GetString(constants.ArgOutput), viper.GetString(constants.ArgOutputFile))
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.FailOnError(err)
+	}
+	inputs, err := collectInputs()
+	error_helpers.FailOnError(err)
+
+	dashboardName := args[0]
+	ctx = createSnapshotContext(ctx, dashboardName)
+
+	statushooks.SetStatus(ctx, "Initializing…")
+	initData := getInitData(ctx)
+	statushooks.Done(ctx)
+
+	// shutdown the service on exit
+	defer initData.Cleanup(ctx)
+	if initData.Result.Error == nil {
+		initData.RegisterExporters(dashboardExporters()...)
+	}
+
+	snap, err := generateDashboardSnapshot(ctx, initData, dashboardName, inputs)
+	error_helpers.FailOnError(err)
+
+	exportMsg, err := initData.ExportManager.DoExport(ctx, snap.FileNameRoot, snap, []string{exportTarget})
+	error_helpers.FailOnErrorWithMessage(err, "failed to export snapshot")
+	if viper.GetBool(constants.ArgProgress) {
+		fmt.Println(strings.Join(exportMsg, "\n"))
+	}
+
+	if err := verifyDashboardPanels(snap); err != nil {
+		exitCode = constants.ExitCodeDashboardPanelsFailed
+		error_helpers.FailOnError(err)
+	}
+}
+
+// dashboardSnapshotExportTarget returns the export target for the given output format and file
+// (the format name if no file is given, otherwise the file, which must have the extension of the format)
+func dashboardSnapshotExportTarget(output, outputFile string) (string, error) {
+	if output == constants.OutputFormatSnapshotShort {
+		output = constants.OutputFormatSnapshot
+	}
+	extension, ok := dashboardSnapshotFormats[output]
+	if !ok {
+		return "", fmt.Errorf("invalid output format: '%s', must be one of [%s]", output, strings.Join(utils.SortedMapKeys(dashboardSnapshotFormats), ", "))
+	}
+	if outputFile == "" {
+		return output, nil
+	}
+	if filepath.Ext(outputFile) != extension {
+		return "", fmt.Errorf("the output file for %s output must have the extension '%s'", output, extension)
+	}
+	return outputFile, nil
+}
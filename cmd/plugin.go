@@ -24,6 +24,7 @@ import (
 	"github.com/turbot/steampipe/pkg/ociinstaller"
 	"github.com/turbot/steampipe/pkg/ociinstaller/versionfile"
 	"github.com/turbot/steampipe/pkg/plugin"
+	"github.com/turbot/steampipe/pkg/pluginmanager"
 	"github.com/turbot/steampipe/pkg/statushooks"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
@@ -71,7 +72,10 @@ Examples:
   steampipe plugin list
 
   # Uninstall a plugin
-  steampipe plugin uninstall aws`,
+  steampipe plugin uninstall aws
+
+  # Show the schema changes a refresh would make
+  steampipe plugin schema-diff aws`,
 		PersistentPostRun: func(_ *cobra.Command, args []string) {
 			utils.LogTime("cmd.plugin.PersistentPostRun start")
 			defer utils.LogTime("cmd.plugin.PersistentPostRun end")
@@ -82,6 +86,7 @@ Examples:
 	cmd.AddCommand(pluginListCmd())
 	cmd.AddCommand(pluginUninstallCmd())
 	cmd.AddCommand(pluginUpdateCmd())
+	cmd.AddCommand(pluginSchemaDiffCmd())
 	cmd.Flags().BoolP(constants.ArgHelp, "h", false, "Help for plugin")
 
 	return cmd
@@ -193,6 +198,37 @@ Examples:
 	return cmd
 }
 
+// Show the schema changes a refresh would make
+func pluginSchemaDiffCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "schema-diff [flags] [connection ...]",
+		Args:  cobra.ArbitraryArgs,
+		Run:   runPluginSchemaDiffCmd,
+		Short: "Show the schema changes a refresh would make",
+		Long: `Show the schema changes a refresh would make.
+
+Compare the schema provided by the plugin for each connection with the schema currently in the database,
+listing the tables and columns which would be added or removed.
+
+Examples:
+
+  # Show schema changes for all connections
+  steampipe plugin schema-diff
+
+  # Show schema changes for specific connections
+  steampipe plugin schema-diff aws gcp
+
+  # Show schema changes as json
+  steampipe plugin schema-diff --output json`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddStringFlag(constants.ArgOutput, "table", "Output format: table or json").
+		AddBoolFlag(constants.ArgHelp, false, "Help for plugin schema-diff", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
 // Uninstall a plugin
 func pluginUninstallCmd() *cobra.Command {
 	var cmd = &cobra.Command{
@@ -895,3 +931,118 @@ func getConnectionState(ctx context.Context) (steampipeconfig.ConnectionStateMap
 
 	return connectionStateMap, res
 }
+
+func runPluginSchemaDiffCmd(cmd *cobra.Command, args []string) {
+	// setup a cancel context and start cancel handler
+	ctx, cancel := context.WithCancel(cmd.Context())
+	contexthelpers.StartCancelHandler(cancel)
+
+	utils.LogTime("runPluginSchemaDiffCmd start")
+	defer func() {
+		utils.LogTime("runPluginSchemaDiffCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	connectionNames, err := getSchemaDiffConnectionNames(args)
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+
+	diffs, res := getConnectionSchemaDiffs(ctx, connectionNames)
+	if res.Error != nil {
+		error_helpers.ShowErrorWithMessage(ctx, res.Error, "schema diff failed")
+		exitCode = constants.ExitCodePluginLoadingError
+		return
+	}
+
+	output, err := diffs.Render(viper.GetString(constants.ArgOutput))
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return
+	}
+	fmt.Println(output)
+	res.ShowWarnings()
+}
+
+// getSchemaDiffConnectionNames returns the (sorted) connections to diff - either those passed as args, or all connections
+// aggregator connections are excluded as their schema is a union of their child connection schemas
+func getSchemaDiffConnectionNames(args []string) ([]string, error) {
+	connections := steampipeconfig.GlobalConfig.Connections
+	if len(args) == 0 {
+		args = utils.SortedMapKeys(connections)
+	}
+
+	var connectionNames []string
+	for _, name := range args {
+		connection, ok := connections[name]
+		if !ok {
+			return nil, fmt.Errorf("connection '%s' does not exist", name)
+		}
+		if connection.Type == modconfig.ConnectionTypeAggregator {
+			continue
+		}
+		connectionNames = append(connectionNames, name)
+	}
+	return connectionNames, nil
+}
+
+func getConnectionSchemaDiffs(ctx context.Context, connectionNames []string) (steampipeconfig.ConnectionSchemaDiffs, *error_helpers.ErrorAndWarnings) {
+	statushooks.Show(ctx)
+	defer statushooks.Done(ctx)
+
+	// start service
+	client, res := db_local.GetLocalClient(ctx, constants.InvokerPlugin, nil)
+	if res.Error != nil {
+		return nil, res
+	}
+	defer client.Close(ctx)
+
+	// fetch the plugin schema for each connection
+	statushooks.SetStatus(ctx, "Fetching plugin schemas")
+	pluginManager, err := pluginmanager.GetPluginManager()
+	if err != nil {
+		res.Error = err
+		return nil, res
+	}
+	connectionPlugins, refreshResult := steampipeconfig.CreateConnectionPlugins(pluginManager, connectionNames)
+	res.Merge(&refreshResult.ErrorAndWarnings)
+	if res.Error != nil {
+		return nil, res
+	}
+
+	// load the current database schema for each connection
+	statushooks.SetStatus(ctx, "Loading connection schemas")
+	conn, err := client.AcquireManagementConnection(ctx)
+	if err != nil {
+		res.Error = err
+		return nil, res
+	}
+	defer conn.Release()
+	currentSchemas, err := steampipeconfig.LoadConnectionSchemaColumns(ctx, conn.Conn(), connectionNames)
+	if err != nil {
+		res.Error = err
+		return nil, res
+	}
+
+	var diffs steampipeconfig.ConnectionSchemaDiffs
+	for _, name := range connectionNames {
+		connectionPlugin, ok := connectionPlugins[name]
+		if !ok {
+			// a warning will have been added when creating the connection plugin
+			continue
+		}
+		pluginData, ok := connectionPlugin.ConnectionMap[name]
+		if !ok {
+			res.AddWarning(fmt.Sprintf("plugin did not return a schema for connection '%s'", name))
+			continue
+		}
+		diffs = append(diffs, steampipeconfig.NewConnectionSchemaDiff(name, pluginData.Schema, currentSchemas[name]))
+	}
+	return diffs, res
+}
@@ -13,14 +13,15 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/go-kit/logging"
-	"github.com/turbot/go-kit/types"
 	sdklogging "github.com/turbot/steampipe-plugin-sdk/v5/logging"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/telemetry"
 	"github.com/turbot/steampipe/pkg/cmdconfig"
 	"github.com/turbot/steampipe/pkg/connection"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/filepaths"
 	"github.com/turbot/steampipe/pkg/pluginmanager_service"
+	pb "github.com/turbot/steampipe/pkg/pluginmanager_service/grpc/proto"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 )
 
@@ -56,7 +57,15 @@ func doRunPluginManager(cmd *cobra.Command) error {
 		return err
 	}
 
-	if shouldRunConnectionWatcher() {
+	// initialise telemetry, so connection refreshes are traced if tracing is enabled
+	shutdownTelemetry, err := telemetry.Init(constants.AppName)
+	if err != nil {
+		log.Printf("[WARN] failed to initialise telemetry: %s", err.Error())
+	} else {
+		defer shutdownTelemetry()
+	}
+
+	if connection.ConnectionWatcherEnabled() {
 		log.Printf("[INFO] starting connection watcher")
 		connectionWatcher, err := connection.NewConnectionWatcher(pluginManager)
 		if err != nil {
@@ -67,6 +76,18 @@ func doRunPluginManager(cmd *cobra.Command) error {
 		defer connectionWatcher.Close()
 	}
 
+	// serve refresh requests from other processes over a local socket
+	refreshServer, err := connection.NewRefreshServer(pluginManager, filepaths.RefreshServiceSocketPath())
+	if err != nil {
+		// not fatal - refreshes may still be requested via the plugin manager
+		log.Printf("[WARN] failed to start refresh server: %s", err.Error())
+	} else {
+		defer refreshServer.Close()
+	}
+
+	// on SIGTERM, interrupt any in-flight refresh (so it can update the connection state table) before exiting
+	handlePluginManagerTermination(pluginManager)
+
 	log.Printf("[INFO] about to serve")
 	pluginManager.Serve()
 	return nil
@@ -97,7 +118,10 @@ func createPluginManager(cmd *cobra.Command) (*pluginmanager_service.PluginManag
 	configMap := connection.NewConnectionConfigMap(steampipeConfig.Connections)
 	log.Printf("[TRACE] loaded config map: %s", strings.Join(steampipeConfig.ConnectionNames(), ","))
 
-	pluginManager, err := pluginmanager_service.NewPluginManager(cmd.Context(), configMap, steampipeConfig.PluginsInstances, logger)
+	// the limiters for connections with max_concurrency or rate_limit set are passed to the plugins with the user limiters
+	connectionLimiters := connection.NewConnectionLimiterMap(steampipeConfig.Connections)
+
+	pluginManager, err := pluginmanager_service.NewPluginManager(cmd.Context(), configMap, steampipeConfig.PluginsInstances, connectionLimiters, logger)
 	if err != nil {
 		log.Printf("[WARN] failed to create plugin manager: %s", err.Error())
 		return nil, err
@@ -106,14 +130,19 @@ func createPluginManager(cmd *cobra.Command) (*pluginmanager_service.PluginManag
 	return pluginManager, nil
 }
 
-func shouldRunConnectionWatcher() bool {
-	// if EnvConnectionWatcher is set, overwrite the value in DefaultConnectionOptions
-	if envStr, ok := os.LookupEnv(constants.EnvConnectionWatcher); ok {
-		if parsedEnv, err := types.ToBool(envStr); err == nil {
-			return parsedEnv
+func handlePluginManagerTermination(pluginManager *pluginmanager_service.PluginManager) {
+	termCh := make(chan os.Signal, 1)
+	signal.Notify(termCh, syscall.SIGTERM)
+	go func() {
+		<-termCh
+		log.Printf("[INFO] plugin manager received SIGTERM - shutting down")
+		// NOTE: Shutdown interrupts any in-flight connection refresh,
+		// and returns immediately if the Shutdown RPC has already been handled
+		if _, err := pluginManager.Shutdown(&pb.ShutdownRequest{}); err != nil {
+			log.Printf("[WARN] plugin manager shutdown failed: %s", err.Error())
 		}
-	}
-	return true
+		os.Exit(0)
+	}()
 }
 
 func createPluginManagerLog() hclog.Logger {
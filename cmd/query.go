@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
@@ -19,12 +18,10 @@ import (
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/contexthelpers"
 	"github.com/turbot/steampipe/pkg/dashboard/dashboardexecute"
-	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
 	"github.com/turbot/steampipe/pkg/display"
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/query"
 	"github.com/turbot/steampipe/pkg/query/queryexecute"
-	"github.com/turbot/steampipe/pkg/query/queryresult"
 	"github.com/turbot/steampipe/pkg/statushooks"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
 	"github.com/turbot/steampipe/pkg/utils"
@@ -50,7 +47,10 @@ Examples:
   steampipe query
 
   # Run a specific query directly
-  steampipe query "select * from cloud"`,
+  steampipe query "select * from cloud"
+
+  # Re-run a query every 30 seconds, highlighting the rows which have changed
+  steampipe query "select * from cloud" --watch 30s --watch-highlight`,
 
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			ctx := cmd.Context()
@@ -78,9 +78,12 @@ Examples:
 		AddBoolFlag(constants.ArgHelp, false, "Help for query", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddBoolFlag(constants.ArgHeader, true, "Include column headers csv and table output").
 		AddStringFlag(constants.ArgSeparator, ",", "Separator string for csv output").
-		AddStringFlag(constants.ArgOutput, "table", "Output format: line, csv, json, table or snapshot").
+		AddStringFlag(constants.ArgOutput, "table", "Output format: line, csv, json, table, parquet, arrow or snapshot").
 		AddBoolFlag(constants.ArgTiming, false, "Turn on the timer which reports query time").
-		AddBoolFlag(constants.ArgWatch, true, "Watch SQL files in the current workspace (works only in interactive mode)").
+		// NOTE: ArgWatch is a string flag - it may be a boolean or an interval
+		// a bare '--watch' is 'true' - an interval following it ('--watch 30s') is parsed as a query argument, and taken from the args by queryexecute.WatchIntervalArg
+		AddStringFlag(constants.ArgWatch, "true", "Watch SQL files in the current workspace in interactive mode (true/false), or re-run the query at an interval, e.g. '--watch 30s'", cmdconfig.FlagOptions.NoOptDefVal("true")).
+		AddBoolFlag(constants.ArgWatchHighlight, false, "Highlight the rows which have changed since the previous run of a query re-run using --watch").
 		AddStringSliceFlag(constants.ArgSearchPath, nil, "Set a custom search_path for the steampipe user for a query session (comma-separated)").
 		AddStringSliceFlag(constants.ArgSearchPathPrefix, nil, "Set a prefix to the current search path for a query session (comma-separated)").
 		AddStringSliceFlag(constants.ArgVarFile, nil, "Specify a file containing variable values").
@@ -94,7 +97,10 @@ Examples:
 		AddStringArrayFlag(constants.ArgSnapshotTag, nil, "Specify tags to set on the snapshot").
 		AddStringFlag(constants.ArgSnapshotTitle, "", "The title to give a snapshot").
 		AddIntFlag(constants.ArgDatabaseQueryTimeout, 0, "The query timeout").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported format: sps (snapshot)").
+		AddIntFlag(constants.ArgPageSize, 0, "The number of rows in each page of results - a cursor is displayed to fetch the next page").
+		AddIntFlag(constants.ArgMaxRows, 0, "The maximum number of rows to fetch for each query, across all pages").
+		AddStringFlag(constants.ArgCursor, "", "Resume a query from the cursor displayed with the previous page of results").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: arrow, parquet, sps (snapshot) - arrow and parquet exports are read from the query snapshot, so the full result is held in memory").
 		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path or a Turbot Pipes workspace").
 		AddBoolFlag(constants.ArgProgress, true, "Display snapshot upload status")
 
@@ -113,6 +119,9 @@ func runQueryCmd(cmd *cobra.Command, args []string) {
 		}
 	}()
 
+	// an interval passed as '--watch 30s' is parsed as a query argument
+	args = queryexecute.WatchIntervalArg(cmd.Flags().Changed(constants.ArgWatch), args)
+
 	// validate args
 	err := validateQueryArgs(ctx, args)
 	error_helpers.FailOnError(err)
@@ -133,6 +142,18 @@ func runQueryCmd(cmd *cobra.Command, args []string) {
 
 	// enable paging only in interactive mode
 	interactiveMode := len(args) == 0
+	if interactiveMode && display.IsColumnarOutputFormat(viper.GetString(constants.ArgOutput)) {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.FailOnError(sperr.New("'%s' output is not supported in interactive mode", viper.GetString(constants.ArgOutput)))
+	}
+	if interactiveMode && (viper.GetInt(constants.ArgPageSize) > 0 || viper.GetInt(constants.ArgMaxRows) > 0 || viper.GetString(constants.ArgCursor) != "") {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.FailOnError(sperr.New("pagination is not supported in interactive mode"))
+	}
+	if interval, _ := queryexecute.WatchInterval(); interactiveMode && interval > 0 {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.FailOnError(sperr.New("'--%s=%s' requires a query - to watch the files of the workspace in interactive mode, use '--%s=true'", constants.ArgWatch, interval, constants.ArgWatch))
+	}
 	// set config to indicate whether we are running an interactive query
 	viper.Set(constants.ConfigKeyInteractive, interactiveMode)
 
@@ -190,16 +211,72 @@ func validateQueryArgs(ctx context.Context, args []string) error {
 		return err
 	}
 
-	validOutputFormats := []string{constants.OutputFormatLine, constants.OutputFormatCSV, constants.OutputFormatTable, constants.OutputFormatJSON, constants.OutputFormatSnapshot, constants.OutputFormatSnapshotShort, constants.OutputFormatNone}
+	validOutputFormats := []string{constants.OutputFormatLine, constants.OutputFormatCSV, constants.OutputFormatTable, constants.OutputFormatJSON, constants.OutputFormatSnapshot, constants.OutputFormatSnapshotShort, constants.OutputFormatParquet, constants.OutputFormatArrow, constants.OutputFormatNone}
 	output := viper.GetString(constants.ArgOutput)
 	if !helpers.StringSliceContains(validOutputFormats, output) {
 		exitCode = constants.ExitCodeInsufficientOrWrongInputs
 		return sperr.New("invalid output format: '%s', must be one of [%s]", output, strings.Join(validOutputFormats, ", "))
 	}
+	if err := validatePaginationArgs(args); err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return err
+	}
+	if err := validateWatchArgs(); err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return err
+	}
+	// binary output is a single file, so can only contain the result of a single query
+	if display.IsColumnarOutputFormat(output) && len(args) > 1 {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		return sperr.New("'%s' output requires a single query", output)
+	}
 
 	return nil
 }
 
+// validatePaginationArgs validates the --page-size, --max-rows and --cursor args
+// pagination is only supported for batch queries which are not run as snapshots, and a cursor
+// identifies the position in the results of a single query
+func validatePaginationArgs(args []string) error {
+	pageSize := viper.GetInt(constants.ArgPageSize)
+	maxRows := viper.GetInt(constants.ArgMaxRows)
+	cursor := viper.GetString(constants.ArgCursor)
+	if pageSize < 0 || maxRows < 0 {
+		return sperr.New("'--%s' and '--%s' must not be negative", constants.ArgPageSize, constants.ArgMaxRows)
+	}
+	if pageSize == 0 && maxRows == 0 && cursor == "" {
+		return nil
+	}
+	if snapshotRequired() {
+		return sperr.New("'--%s', '--%s' and '--%s' are not supported for snapshots or exports", constants.ArgPageSize, constants.ArgMaxRows, constants.ArgCursor)
+	}
+	if (pageSize > 0 || cursor != "") && len(args) > 1 {
+		return sperr.New("'--%s' and '--%s' require a single query", constants.ArgPageSize, constants.ArgCursor)
+	}
+	if cursor != "" {
+		if _, err := queryexecute.ParseQueryCursor(cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateWatchArgs validates the --watch interval - re-run queries are displayed, rather than written to a snapshot or file
+// (queries may only be re-run in batch mode - this is checked once any query piped to stdin has been read)
+func validateWatchArgs() error {
+	interval, err := queryexecute.WatchInterval()
+	if err != nil || interval == 0 {
+		return err
+	}
+	if snapshotRequired() {
+		return sperr.New("'--%s=%s' is not supported for snapshots or exports", constants.ArgWatch, interval)
+	}
+	if output := viper.GetString(constants.ArgOutput); display.IsColumnarOutputFormat(output) {
+		return sperr.New("'--%s=%s' is not supported for '%s' output", constants.ArgWatch, interval, output)
+	}
+	return nil
+}
+
 func executeSnapshotQuery(initData *query.InitData, ctx context.Context) int {
 	// start cancel handler to intercept interrupts and cancel the context
 	// NOTE: use the initData Cancel function to ensure any initialisation is cancelled if needed
@@ -259,7 +336,7 @@ func executeSnapshotQuery(initData *query.InitData, ctx context.Context) int {
 				fmt.Println(string(jsonOutput))
 			default:
 				// otherwise convert the snapshot into a query result
-				result, err := snapshotToQueryResult(snap)
+				result, err := query.SnapshotToQueryResult(snap)
 				error_helpers.FailOnErrorWithMessage(err, "failed to display result as snapshot")
 				display.ShowOutput(ctx, result, display.WithTimingDisabled())
 			}
@@ -286,42 +363,6 @@ func executeSnapshotQuery(initData *query.InitData, ctx context.Context) int {
 	return 0
 }
 
-func snapshotToQueryResult(snap *dashboardtypes.SteampipeSnapshot) (*queryresult.Result, error) {
-	// the table of a snapshot query has a fixed name
-	tablePanel, ok := snap.Panels[modconfig.SnapshotQueryTableName]
-	if !ok {
-		return nil, sperr.New("dashboard does not contain table result for query")
-	}
-	chartRun := tablePanel.(*dashboardexecute.LeafRun)
-	if !ok {
-		return nil, sperr.New("failed to read query result from snapshot")
-	}
-	// check for error
-	if err := chartRun.GetError(); err != nil {
-		return nil, error_helpers.DecodePgError(err)
-	}
-
-	res := queryresult.NewResult(chartRun.Data.Columns)
-
-	// start a goroutine to stream the results as rows
-	go func() {
-		for _, d := range chartRun.Data.Rows {
-			// we need to allocate a new slice everytime, since this gets read
-			// asynchronously on the other end and we need to make sure that we don't overwrite
-			// data already sent
-			rowVals := make([]interface{}, len(chartRun.Data.Columns))
-			for i, c := range chartRun.Data.Columns {
-				rowVals[i] = d[c.Name]
-			}
-			res.StreamRow(rowVals)
-		}
-		res.TimingResult <- chartRun.TimingResult
-		res.Close()
-	}()
-
-	return res, nil
-}
-
 // convert the given command line query into a query resource and add to workspace
 // this is to allow us to use existing dashboard execution code
 func ensureSnapshotQueryResource(name string, resolvedQuery *modconfig.ResolvedQuery, w *workspace.Workspace) (queryProvider modconfig.HclResource, existingResource bool) {
@@ -350,11 +391,9 @@ func ensureSnapshotQueryResource(name string, resolvedQuery *modconfig.ResolvedQ
 
 func snapshotRequired() bool {
 	SnapshotFormatNames := []string{constants.OutputFormatSnapshot, constants.OutputFormatSnapshotShort}
-	// if a snapshot exporter is specified return true
-	for _, e := range viper.GetStringSlice(constants.ArgExport) {
-		if helpers.StringSliceContains(SnapshotFormatNames, e) || path.Ext(e) == constants.SnapshotExtension {
-			return true
-		}
+	// all exporters export the query snapshot - if any export is specified return true
+	if len(viper.GetStringSlice(constants.ArgExport)) > 0 {
+		return true
 	}
 	// if share/snapshot args are set or output is snapshot, return true
 	return viper.IsSet(constants.ArgShare) ||
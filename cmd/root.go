@@ -59,10 +59,26 @@ func InitCmd() {
 	rootCmd.PersistentFlags().String(constants.ArgWorkspaceProfile, "default", "The workspace profile to use") // workspace profile profile is a global flag since install-dir(global) can be set through the workspace profile
 	rootCmd.PersistentFlags().String(constants.ArgInstallDir, filepaths.DefaultInstallDir, "Path to the Config Directory")
 	rootCmd.PersistentFlags().Bool(constants.ArgSchemaComments, true, "Include schema comments when importing connection schemas")
+	rootCmd.PersistentFlags().Bool(constants.ArgSkipPluginValidation, false, "Import connections for plugins using a newer steampipe-plugin-sdk version than Steampipe (for plugin development)")
+	rootCmd.PersistentFlags().Bool(constants.ArgPruneSchemas, false, "Drop any connection schemas which do not correspond to a configured connection when refreshing connections")
+	rootCmd.PersistentFlags().Bool(constants.ArgForceUpdateAll, false, "Drop and reimport the schema of every connection when refreshing connections (this may take a long time)")
+	rootCmd.PersistentFlags().String(constants.ArgRefreshApplicationName, constants.RefreshConnectionAppNamePrefix, "The Postgres application_name prefix of the database sessions used to refresh connections")
+	rootCmd.PersistentFlags().Bool(constants.ArgNoRefresh, false, "Do not update connection schemas when refreshing connections - use the existing schemas (e.g. during database maintenance)")
+	rootCmd.PersistentFlags().Bool(constants.ArgRefreshCatalogStats, false, "Report the number of foreign tables and approximate catalog size of each connection schema after refreshing connections")
+	rootCmd.PersistentFlags().Int(constants.ArgUpdateSchemaMaxParallel, constants.DefaultUpdateSchemaMaxParallel, "The maximum number of connection schemas imported or cloned in parallel when refreshing connections")
+	rootCmd.PersistentFlags().Bool(constants.ArgQuiet, false, "Suppress status and progress output (warnings and errors are still displayed)")
 
 	error_helpers.FailOnError(viper.BindPFlag(constants.ArgInstallDir, rootCmd.PersistentFlags().Lookup(constants.ArgInstallDir)))
 	error_helpers.FailOnError(viper.BindPFlag(constants.ArgWorkspaceProfile, rootCmd.PersistentFlags().Lookup(constants.ArgWorkspaceProfile)))
 	error_helpers.FailOnError(viper.BindPFlag(constants.ArgSchemaComments, rootCmd.PersistentFlags().Lookup(constants.ArgSchemaComments)))
+	error_helpers.FailOnError(viper.BindPFlag(constants.ArgSkipPluginValidation, rootCmd.PersistentFlags().Lookup(constants.ArgSkipPluginValidation)))
+	error_helpers.FailOnError(viper.BindPFlag(constants.ArgPruneSchemas, rootCmd.PersistentFlags().Lookup(constants.ArgPruneSchemas)))
+	error_helpers.FailOnError(viper.BindPFlag(constants.ArgForceUpdateAll, rootCmd.PersistentFlags().Lookup(constants.ArgForceUpdateAll)))
+	error_helpers.FailOnError(viper.BindPFlag(constants.ArgRefreshApplicationName, rootCmd.PersistentFlags().Lookup(constants.ArgRefreshApplicationName)))
+	error_helpers.FailOnError(viper.BindPFlag(constants.ArgNoRefresh, rootCmd.PersistentFlags().Lookup(constants.ArgNoRefresh)))
+	error_helpers.FailOnError(viper.BindPFlag(constants.ArgRefreshCatalogStats, rootCmd.PersistentFlags().Lookup(constants.ArgRefreshCatalogStats)))
+	error_helpers.FailOnError(viper.BindPFlag(constants.ArgUpdateSchemaMaxParallel, rootCmd.PersistentFlags().Lookup(constants.ArgUpdateSchemaMaxParallel)))
+	error_helpers.FailOnError(viper.BindPFlag(constants.ArgQuiet, rootCmd.PersistentFlags().Lookup(constants.ArgQuiet)))
 
 	AddCommands()
 
@@ -72,7 +88,7 @@ func InitCmd() {
 	rootCmd.Flags().BoolP(constants.ArgHelp, "h", false, "Help for steampipe")
 	rootCmd.Flags().BoolP(constants.ArgVersion, "v", false, "Version for steampipe")
 
-	hideRootFlags(constants.ArgSchemaComments)
+	hideRootFlags(constants.ArgSchemaComments, constants.ArgSkipPluginValidation)
 
 	// tell OS to reclaim memory immediately
 	os.Setenv("GODEBUG", "madvdontneed=1")
@@ -89,6 +105,8 @@ func AddCommands() {
 	// explicitly initialise commands here rather than in init functions to allow us to handle errors from the config load
 	rootCmd.AddCommand(
 		pluginCmd(),
+		connectionCmd(),
+		searchPathCmd(),
 		queryCmd(),
 		checkCmd(),
 		serviceCmd(),
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	filehelpers "github.com/turbot/go-kit/files"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/cmdconfig"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_local"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/filepaths"
+	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// Search path management commands
+func searchPathCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "search-path [command]",
+		Args:  cobra.NoArgs,
+		Short: "Steampipe search path management",
+		Long: `Steampipe search path management.
+
+The search path and search path prefix are saved in the active workspace profile. They are applied to the
+search path of steampipe users and to interactive sessions - if the service is running, the search path is
+updated immediately, without refreshing connections.
+
+Examples:
+
+  # Show the search path
+  steampipe search-path show
+
+  # Set the search path
+  steampipe search-path set aws_prod,aws_dev
+
+  # Add schemas to the front of the search path
+  steampipe search-path prepend gcp
+
+  # Remove the search path overrides from the workspace profile
+  steampipe search-path reset`,
+	}
+
+	cmd.AddCommand(searchPathShowCmd())
+	cmd.AddCommand(searchPathSetCmd())
+	cmd.AddCommand(searchPathPrependCmd())
+	cmd.AddCommand(searchPathResetCmd())
+	cmd.Flags().BoolP(constants.ArgHelp, "h", false, "Help for search-path")
+
+	return cmd
+}
+
+// Show the search path
+func searchPathShowCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "show",
+		Args:  cobra.NoArgs,
+		Run:   runSearchPathShowCmd,
+		Short: "Show the search path and the overrides set in the workspace profile",
+		Long: `Show the search path which is set for steampipe users, and the search path overrides set in the workspace profile.
+
+Examples:
+
+  # Show the search path
+  steampipe search-path show`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for search-path show", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runSearchPathShowCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	utils.LogTime("runSearchPathShowCmd start")
+	defer func() {
+		utils.LogTime("runSearchPathShowCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	profile := steampipeconfig.GlobalWorkspaceProfile
+	searchPath, omitted := db_local.ComputeUserSearchPath()
+	fmt.Printf("Search path:        %s\n", db_local.FormatSearchPath(searchPath))
+	fmt.Printf("Workspace:          %s\n", profile.ProfileName)
+	fmt.Printf("search_path:        %s\n", strings.Join(profile.GetSearchPath(), ","))
+	fmt.Printf("search_path_prefix: %s\n", strings.Join(profile.GetSearchPathPrefix(), ","))
+	if warning := db_local.SearchPathLimitWarning(omitted); warning != "" {
+		error_helpers.ShowWarning(warning)
+	}
+}
+
+// Set the search path
+func searchPathSetCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "set [flags] schema[,schema...]",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runSearchPathSetCmd,
+		Short: "Set the search path in the workspace profile",
+		Long: `Set the search path in the workspace profile.
+
+The search path replaces the search path computed from the connection config - any search path prefix is retained.
+
+Examples:
+
+  # Set the search path
+  steampipe search-path set aws_prod,aws_dev`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for search-path set", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runSearchPathSetCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	utils.LogTime("runSearchPathSetCmd start")
+	defer func() {
+		utils.LogTime("runSearchPathSetCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	profile := steampipeconfig.GlobalWorkspaceProfile
+	profile.SearchPath = steampipeconfig.FormatWorkspaceSearchPath(searchPathArgs(args))
+	updateSearchPath(ctx)
+}
+
+// Prepend schemas to the search path
+func searchPathPrependCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "prepend [flags] schema[,schema...]",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runSearchPathPrependCmd,
+		Short: "Add schemas to the search path prefix in the workspace profile",
+		Long: `Add schemas to the search path prefix in the workspace profile.
+
+The schemas are added in front of the existing search path prefix.
+
+Examples:
+
+  # Add gcp to the front of the search path
+  steampipe search-path prepend gcp`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for search-path prepend", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runSearchPathPrependCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	utils.LogTime("runSearchPathPrependCmd start")
+	defer func() {
+		utils.LogTime("runSearchPathPrependCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	profile := steampipeconfig.GlobalWorkspaceProfile
+	prefix := searchPathArgs(args)
+	for _, schema := range profile.GetSearchPathPrefix() {
+		if !helpers.StringSliceContains(prefix, schema) {
+			prefix = append(prefix, schema)
+		}
+	}
+	profile.SearchPathPrefix = steampipeconfig.FormatWorkspaceSearchPath(prefix)
+	updateSearchPath(ctx)
+}
+
+// Reset the search path
+func searchPathResetCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "reset",
+		Args:  cobra.NoArgs,
+		Run:   runSearchPathResetCmd,
+		Short: "Remove the search path overrides from the workspace profile",
+		Long: `Remove the search path and search path prefix from the workspace profile.
+
+The search path reverts to the search path computed from the connection config.
+
+Examples:
+
+  # Reset the search path
+  steampipe search-path reset`,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddBoolFlag(constants.ArgHelp, false, "Help for search-path reset", cmdconfig.FlagOptions.WithShortHand("h"))
+	return cmd
+}
+
+func runSearchPathResetCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	utils.LogTime("runSearchPathResetCmd start")
+	defer func() {
+		utils.LogTime("runSearchPathResetCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	profile := steampipeconfig.GlobalWorkspaceProfile
+	profile.SearchPath = nil
+	profile.SearchPathPrefix = nil
+	updateSearchPath(ctx)
+}
+
+// searchPathArgs returns the schemas passed as args - each arg may be a comma separated list
+func searchPathArgs(args []string) []string {
+	var res []string
+	for _, arg := range args {
+		for _, schema := range strings.Split(arg, ",") {
+			if schema = strings.TrimSpace(schema); schema != "" && !helpers.StringSliceContains(res, schema) {
+				res = append(res, schema)
+			}
+		}
+	}
+	return res
+}
+
+// updateSearchPath saves the search path overrides of the active workspace profile
+// and, if the service is running, applies the search path to it
+func updateSearchPath(ctx context.Context) {
+	profile := steampipeconfig.GlobalWorkspaceProfile
+
+	installDir, err := filehelpers.Tildefy(viper.GetString(constants.ArgInstallDir))
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		exitCode = constants.ExitCodeFileSystemAccessFailure
+		return
+	}
+	workspaceProfileDir, err := filepaths.WorkspaceProfileDir(installDir)
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		exitCode = constants.ExitCodeFileSystemAccessFailure
+		return
+	}
+	configPath, err := steampipeconfig.SaveWorkspaceProfileSearchPath(profile, workspaceProfileDir)
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, fmt.Sprintf("failed to save the search path of workspace '%s'", profile.ProfileName))
+		exitCode = constants.ExitCodeFileSystemAccessFailure
+		return
+	}
+	fmt.Printf("Saved the search path of workspace '%s' to %s\n", profile.ProfileName, configPath)
+
+	state, err := db_local.GetState()
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		exitCode = constants.ExitCodeServiceSetupFailure
+		return
+	}
+	if state == nil {
+		searchPath, _ := db_local.ComputeUserSearchPath()
+		fmt.Printf("The service is not running - the search path %s will be set when it is started\n", db_local.FormatSearchPath(searchPath))
+		return
+	}
+
+	statushooks.Show(ctx)
+	statushooks.SetStatus(ctx, "Setting search path")
+	searchPath, omitted, err := db_local.ApplyUserSearchPath(ctx)
+	statushooks.Done(ctx)
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "failed to set the search path")
+		exitCode = constants.ExitCodeServiceSetupFailure
+		return
+	}
+	fmt.Printf("Search path: %s\n", db_local.FormatSearchPath(searchPath))
+	if warning := db_local.SearchPathLimitWarning(omitted); warning != "" {
+		error_helpers.ShowWarning(warning)
+	}
+}
@@ -23,8 +23,8 @@ import (
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/filepaths"
 	"github.com/turbot/steampipe/pkg/pluginmanager"
-	pb "github.com/turbot/steampipe/pkg/pluginmanager_service/grpc/proto"
 	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"github.com/turbot/steampipe/pkg/utils"
 )
 
@@ -71,10 +71,14 @@ connection from any Postgres compatible database client.`,
 		AddBoolFlag(constants.ArgServiceShowPassword, false, "View database password for connecting from another machine").
 		// dashboard server
 		AddBoolFlag(constants.ArgDashboard, false, "Run the dashboard webserver with the service").
-		AddStringFlag(constants.ArgDashboardListen, string(dashboardserver.ListenTypeNetwork), "Accept connections from: local (localhost only) or network (open) (dashboard)").
+		AddStringFlag(constants.ArgDashboardListen, string(dashboardserver.ListenTypeNetwork), "Accept connections from: local (localhost only), network (open) or a specific host name or IP address (dashboard)").
 		AddIntFlag(constants.ArgDashboardPort, constants.DashboardServerDefaultPort, "Report server port").
+		AddStringFlag(constants.ArgDashboardSSLCert, "", "Path to a PEM encoded certificate used to serve the dashboard over TLS").
+		AddStringFlag(constants.ArgDashboardSSLKey, "", "Path to the PEM encoded private key for the dashboard server certificate").
 		// foreground enables the service to run in the foreground - till exit
 		AddBoolFlag(constants.ArgForeground, false, "Run the service in the foreground").
+		AddStringSliceFlag(constants.ArgPlugin, nil, "Force a refresh of all connections for the specified plugins").
+		AddBoolFlag(constants.ArgVerify, false, "Once connections are refreshed, verify that every ready connection has a non-empty schema").
 
 		// flags relevant only if the --dashboard arg is used:
 		AddStringSliceFlag(constants.ArgVarFile, nil, "Specify an .spvar file containing variable values (only applies if '--dashboard' flag is also set)").
@@ -141,7 +145,8 @@ func serviceRestartCmd() *cobra.Command {
 	cmdconfig.
 		OnCmd(cmd).
 		AddBoolFlag(constants.ArgHelp, false, "Help for service restart", cmdconfig.FlagOptions.WithShortHand("h")).
-		AddBoolFlag(constants.ArgForce, false, "Forces the service to restart, releasing all open connections and ports")
+		AddBoolFlag(constants.ArgForce, false, "Forces the service to restart, releasing all open connections and ports").
+		AddStringSliceFlag(constants.ArgPlugin, nil, "Force a refresh of all connections for the specified plugins")
 
 	return cmd
 }
@@ -184,6 +189,10 @@ func runServiceStartCmd(cmd *cobra.Command, _ []string) {
 
 	printStatus(ctx, startResult.DbState, startResult.PluginManagerState, dashboardState, alreadyRunning)
 
+	if viper.GetBool(constants.ArgVerify) {
+		verifyConnections(ctx)
+	}
+
 	if viper.GetBool(constants.ArgForeground) {
 		runServiceInForeground(ctx)
 	}
@@ -261,17 +270,39 @@ func startServiceAndRefreshConnections(ctx context.Context, listenAddresses []st
 		error_helpers.FailOnError(startResult.Error)
 	}
 
-	if startResult.Status == db_local.ServiceStarted {
-		// ask the plugin manager to refresh connections
-		// this is executed asyncronously by the plugin manager
-		// we ignore this error, since RefreshConnections is async and all errors will flow through
-		// the notification system
-		// we do not expect any I/O errors on this since the PluginManager is running in the same box
-		_, _ = startResult.PluginManager.RefreshConnections(&pb.RefreshConnectionsRequest{})
-	}
+	// ask the plugin manager to refresh connections
+	startResult.RequestConnectionRefresh(pluginmanager.NewRefreshConnectionsRequest(viper.GetStringSlice(constants.ArgPlugin)...))
 	return startResult
 }
 
+// verifyConnections waits for the connection refresh to complete, then verifies (without making changes)
+// that every ready connection has a non-empty schema
+func verifyConnections(ctx context.Context) {
+	statushooks.Show(ctx)
+	statushooks.SetStatus(ctx, "Verifying connections…")
+
+	res, err := func() (*steampipeconfig.VerifyConnectionsResult, error) {
+		defer statushooks.Done(ctx)
+		conn, err := db_local.CreateLocalDbConnection(ctx, &db_local.CreateDbOptions{Username: constants.DatabaseSuperUser})
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close(ctx)
+		return steampipeconfig.VerifyConnections(ctx, conn)
+	}()
+	if err != nil {
+		exitCode = constants.ExitCodeConnectionVerifyFailure
+		error_helpers.ShowErrorWithMessage(ctx, err, "failed to verify connections")
+		return
+	}
+	if res.HasFailures() {
+		exitCode = constants.ExitCodeConnectionVerifyFailure
+		error_helpers.ShowWarning(res.String())
+		return
+	}
+	fmt.Println(res.String())
+}
+
 func tryToStopServices(ctx context.Context) {
 	// stop db service
 	if _, err := db_local.StopServices(ctx, false, constants.InvokerService); err != nil {
@@ -303,7 +334,11 @@ func startDashboardServer(ctx context.Context) (*dashboardserver.DashboardServic
 			return nil, err
 		}
 		// start dashboard service
-		err = dashboardserver.RunForService(ctx, serverListen, serverPort)
+		tlsOpts := dashboardserver.TLSOptions{
+			CertFile: viper.GetString(constants.ArgDashboardSSLCert),
+			KeyFile:  viper.GetString(constants.ArgDashboardSSLKey),
+		}
+		err = dashboardserver.RunForService(ctx, serverListen, serverPort, tlsOpts)
 		if err != nil {
 			return nil, err
 		}
@@ -457,7 +492,8 @@ to force a restart.
 
 	// if the dashboard was running, start it
 	if currentDashboardState != nil {
-		err = dashboardserver.RunForService(ctx, dashboardserver.ListenType(currentDashboardState.ListenType), dashboardserver.ListenPort(currentDashboardState.Port))
+		tlsOpts := dashboardserver.TLSOptions{CertFile: currentDashboardState.SSLCert, KeyFile: currentDashboardState.SSLKey}
+		err = dashboardserver.RunForService(ctx, dashboardserver.ListenType(currentDashboardState.ListenType), dashboardserver.ListenPort(currentDashboardState.Port), tlsOpts)
 		error_helpers.FailOnError(err)
 
 		// reload the state
@@ -742,7 +778,7 @@ Database:
 	dashboardMsg := ""
 
 	if dashboardState != nil {
-		browserUrl := fmt.Sprintf("http://%s:%d/", dashboardState.Listen[0], dashboardState.Port)
+		browserUrl := dashboardState.URL()
 		dashboardMsg = fmt.Sprintf(`
 Dashboard:
 
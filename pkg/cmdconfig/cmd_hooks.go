@@ -28,6 +28,7 @@ import (
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/filepaths"
 	"github.com/turbot/steampipe/pkg/ociinstaller/versionfile"
+	"github.com/turbot/steampipe/pkg/statushooks"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"github.com/turbot/steampipe/pkg/task"
 	"github.com/turbot/steampipe/pkg/utils"
@@ -101,6 +102,12 @@ func preRunHook(cmd *cobra.Command, args []string) {
 	// log file.
 	createLogger(logBuffer, cmd)
 
+	// in quiet mode, disable status hooks for the whole command
+	// (warnings and errors are not displayed using status hooks so are unaffected)
+	if viper.GetBool(constants.ArgQuiet) {
+		cmd.SetContext(statushooks.DisableStatusHooks(cmd.Context()))
+	}
+
 	// runScheduledTasks skips running tasks if this instance is the plugin manager
 	waitForTasksChannel = runScheduledTasks(cmd.Context(), cmd, args, ew)
 
@@ -99,10 +99,19 @@ func setBaseDefaults() {
 		constants.ArgIntrospection: constants.IntrospectionNone,
 
 		// from global database options
-		constants.ArgDatabasePort:         constants.DatabaseDefaultPort,
-		constants.ArgDatabaseStartTimeout: constants.DBStartTimeout.Seconds(),
-		constants.ArgServiceCacheEnabled:  true,
-		constants.ArgCacheMaxTtl:          300,
+		constants.ArgDatabasePort:             constants.DatabaseDefaultPort,
+		constants.ArgDatabaseStartTimeout:     constants.DBStartTimeout.Seconds(),
+		constants.ArgServiceCacheEnabled:      true,
+		constants.ArgCacheMaxTtl:              300,
+		constants.ArgCloneSchema:              constants.CloneSchemaAuto,
+		constants.ArgGrantPrivileges:          constants.DefaultGrantPrivileges,
+		constants.ArgRefreshLockTimeout:       constants.RefreshLockTimeout.Seconds(),
+		constants.ArgCommentLock:              constants.CommentLockNone,
+		constants.ArgConnectionStateBatchSize: constants.DefaultConnectionStateBatchSize,
+		constants.ArgUpdateSchemaMaxParallel:  constants.DefaultUpdateSchemaMaxParallel,
+		constants.ArgConnectionStateSchema:    constants.InternalSchema,
+		constants.ArgConnectionStateTable:     constants.ConnectionTable,
+		constants.ArgSchemaQueryTimeout:       constants.SchemaQueryTimeout.Seconds(),
 
 		// dashboard
 		constants.ArgDashboardStartTimeout: constants.DashboardStartTimeout.Seconds(),
@@ -157,18 +166,40 @@ func setDefaultsFromEnv() {
 		constants.EnvPipesToken: {[]string{constants.ArgCloudToken}, String},
 		constants.EnvCloudToken: {[]string{constants.ArgCloudToken}, String},
 		//
-		constants.EnvSnapshotLocation:      {[]string{constants.ArgSnapshotLocation}, String},
-		constants.EnvWorkspaceDatabase:     {[]string{constants.ArgWorkspaceDatabase}, String},
-		constants.EnvServicePassword:       {[]string{constants.ArgServicePassword}, String},
-		constants.EnvDisplayWidth:          {[]string{constants.ArgDisplayWidth}, Int},
-		constants.EnvMaxParallel:           {[]string{constants.ArgMaxParallel}, Int},
-		constants.EnvQueryTimeout:          {[]string{constants.ArgDatabaseQueryTimeout}, Int},
-		constants.EnvDatabaseStartTimeout:  {[]string{constants.ArgDatabaseStartTimeout}, Int},
-		constants.EnvDashboardStartTimeout: {[]string{constants.ArgDashboardStartTimeout}, Int},
-		constants.EnvCacheTTL:              {[]string{constants.ArgCacheTtl}, Int},
-		constants.EnvCacheMaxTTL:           {[]string{constants.ArgCacheMaxTtl}, Int},
-		constants.EnvMemoryMaxMb:           {[]string{constants.ArgMemoryMaxMb}, Int},
-		constants.EnvMemoryMaxMbPlugin:     {[]string{constants.ArgMemoryMaxMbPlugin}, Int},
+		constants.EnvSnapshotLocation:         {[]string{constants.ArgSnapshotLocation}, String},
+		constants.EnvWorkspaceDatabase:        {[]string{constants.ArgWorkspaceDatabase}, String},
+		constants.EnvServicePassword:          {[]string{constants.ArgServicePassword}, String},
+		constants.EnvDisplayWidth:             {[]string{constants.ArgDisplayWidth}, Int},
+		constants.EnvMaxParallel:              {[]string{constants.ArgMaxParallel}, Int},
+		constants.EnvQueryTimeout:             {[]string{constants.ArgDatabaseQueryTimeout}, Int},
+		constants.EnvDatabaseStartTimeout:     {[]string{constants.ArgDatabaseStartTimeout}, Int},
+		constants.EnvDashboardStartTimeout:    {[]string{constants.ArgDashboardStartTimeout}, Int},
+		constants.EnvCacheTTL:                 {[]string{constants.ArgCacheTtl}, Int},
+		constants.EnvCacheMaxTTL:              {[]string{constants.ArgCacheMaxTtl}, Int},
+		constants.EnvMemoryMaxMb:              {[]string{constants.ArgMemoryMaxMb}, Int},
+		constants.EnvMemoryMaxMbPlugin:        {[]string{constants.ArgMemoryMaxMbPlugin}, Int},
+		constants.EnvCloneSchema:              {[]string{constants.ArgCloneSchema}, String},
+		constants.EnvRefreshTimeout:           {[]string{constants.ArgRefreshTimeout}, Int},
+		constants.EnvSearchPathLimit:          {[]string{constants.ArgSearchPathLimit}, Int},
+		constants.EnvRefreshLockTimeout:       {[]string{constants.ArgRefreshLockTimeout}, Int},
+		constants.EnvCommentLock:              {[]string{constants.ArgCommentLock}, String},
+		constants.EnvConnectionStateBatchSize: {[]string{constants.ArgConnectionStateBatchSize}, Int},
+		constants.EnvConnectionStateSchema:    {[]string{constants.ArgConnectionStateSchema}, String},
+		constants.EnvConnectionStateTable:     {[]string{constants.ArgConnectionStateTable}, String},
+		constants.EnvSchemaQueryTimeout:       {[]string{constants.ArgSchemaQueryTimeout}, Int},
+		constants.EnvUpdateSchemaMaxParallel:  {[]string{constants.ArgUpdateSchemaMaxParallel}, Int},
+		constants.EnvCloneSchemaBatchSize:     {[]string{constants.ArgCloneSchemaBatchSize}, Int},
+
+		// dashboard server authentication
+		constants.EnvDashboardAuth:             {[]string{constants.ArgDashboardAuth}, String},
+		constants.EnvDashboardAuthToken:        {[]string{constants.ArgDashboardAuthToken}, String},
+		constants.EnvDashboardAuthUsers:        {[]string{constants.ArgDashboardAuthUsers}, String},
+		constants.EnvDashboardAuthRole:         {[]string{constants.ArgDashboardAuthRole}, String},
+		constants.EnvDashboardOIDCIssuer:       {[]string{constants.ArgDashboardOIDCIssuer}, String},
+		constants.EnvDashboardOIDCClientID:     {[]string{constants.ArgDashboardOIDCClientID}, String},
+		constants.EnvDashboardOIDCClientSecret: {[]string{constants.ArgDashboardOIDCClientSecret}, String},
+		constants.EnvDashboardSSLCert:          {[]string{constants.ArgDashboardSSLCert}, String},
+		constants.EnvDashboardSSLKey:           {[]string{constants.ArgDashboardSSLKey}, String},
 
 		// we need this value to go into different locations
 		constants.EnvCacheEnabled: {[]string{
@@ -0,0 +1,168 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sethvargo/go-retry"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// the maximum number of attempts to begin the transaction used to apply comments,
+// and the interval before the first retry (this doubles for each subsequent retry)
+const (
+	commentsBeginAttempts = 5
+	commentsBeginBackoff  = 100 * time.Millisecond
+)
+
+// onOperationComplete is called once the statements of a connection operation have executed successfully,
+// with an executor for the same transaction - this is used to update the connection state table, so the
+// state is committed together with the schema change
+type onOperationComplete func(tx sqlExecutor) error
+
+// connectionExecutor executes the database operations which create, clone, delete and comment connection schemas
+//
+// each operation executes its statements then calls onComplete in a single transaction, which is committed only if
+// both succeed. If a statement fails, the transaction is rolled back and a *statementError is returned
+// (any other error indicates a failure to begin or commit the transaction, or an error returned by onComplete)
+type connectionExecutor interface {
+	// UpdateSchema imports the foreign schema for a connection using the given sql
+	UpdateSchema(ctx context.Context, connectionName, sql string, onComplete onOperationComplete) error
+	// CloneSchema creates the schema for a connection by cloning an exemplar schema using the given sql
+	CloneSchema(ctx context.Context, connectionName, sql string, onComplete onOperationComplete) error
+	// CloneSchemas creates the schemas for a batch of connections by cloning their exemplar schemas using the given sql
+	CloneSchemas(ctx context.Context, connectionNames []string, sql string, onComplete onOperationComplete) error
+	// DeleteSchema drops the schema of a connection using the given sql
+	DeleteSchema(ctx context.Context, connectionName, sql string, onComplete onOperationComplete) error
+	// ApplyComments sets the comments on the schema of a connection using the given sql
+	// if lockStatement is set, it is executed first - a failure to take the lock is not a *statementError
+	ApplyComments(ctx context.Context, connectionName, lockStatement, sql string, onComplete onOperationComplete) error
+	// Exec executes a statement outside of any operation transaction
+	// (this is used to record a failed operation in the connection state table)
+	sqlExecutor
+}
+
+// statementError is returned by a connectionExecutor if a statement of an operation fails
+type statementError struct {
+	err error
+}
+
+func (e *statementError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statementError) Unwrap() error {
+	return e.err
+}
+
+// txConnectionExecutor implements connectionExecutor by executing each operation in a database transaction
+type txConnectionExecutor struct {
+	begin func(ctx context.Context) (pgx.Tx, error)
+	exec  func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// newPoolConnectionExecutor returns a connectionExecutor which executes operations using the pool returned by getPool
+// the pool is retrieved for every operation, so once the pool is recreated, subsequent operations use the new pool
+func newPoolConnectionExecutor(getPool func() *pgxpool.Pool) *txConnectionExecutor {
+	return &txConnectionExecutor{
+		begin: func(ctx context.Context) (pgx.Tx, error) {
+			return getPool().Begin(ctx)
+		},
+		exec: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			return getPool().Exec(ctx, sql, arguments...)
+		},
+	}
+}
+
+func (e *txConnectionExecutor) UpdateSchema(ctx context.Context, connectionName, sql string, onComplete onOperationComplete) error {
+	tx, err := e.begin(ctx)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to create transaction to perform update query")
+	}
+	return e.execute(ctx, tx, "", sql, onComplete, "update for connection '%s'", connectionName)
+}
+
+func (e *txConnectionExecutor) CloneSchema(ctx context.Context, connectionName, sql string, onComplete onOperationComplete) error {
+	return e.UpdateSchema(ctx, connectionName, sql, onComplete)
+}
+
+func (e *txConnectionExecutor) CloneSchemas(ctx context.Context, connectionNames []string, sql string, onComplete onOperationComplete) error {
+	tx, err := e.begin(ctx)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to create transaction to perform clone query")
+	}
+	return e.execute(ctx, tx, "", sql, onComplete, "clone of connections '%s'", strings.Join(connectionNames, "', '"))
+}
+
+func (e *txConnectionExecutor) DeleteSchema(ctx context.Context, connectionName, sql string, onComplete onOperationComplete) error {
+	tx, err := e.begin(ctx)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to create transaction to perform delete query")
+	}
+	return e.execute(ctx, tx, "", sql, onComplete, "deletion of connection '%s'", connectionName)
+}
+
+func (e *txConnectionExecutor) ApplyComments(ctx context.Context, connectionName, lockStatement, sql string, onComplete onOperationComplete) error {
+	// comments are applied after a burst of updates, when all pooled connections may be momentarily busy - so retry
+	tx, err := e.beginWithRetry(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		// the updates have completed so the pool should be free - this indicates the pool is exhausted
+		return sperr.WrapWithMessage(err, "failed to acquire a database connection to set comments for connection '%s' after %d attempts - the refresh connection pool may be exhausted", connectionName, commentsBeginAttempts)
+	}
+	return e.execute(ctx, tx, lockStatement, sql, onComplete, "comments for connection '%s'", connectionName)
+}
+
+func (e *txConnectionExecutor) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return e.exec(ctx, sql, arguments...)
+}
+
+// beginWithRetry begins a transaction, retrying with exponential backoff (for at most commentsBeginAttempts attempts)
+func (e *txConnectionExecutor) beginWithRetry(ctx context.Context) (pgx.Tx, error) {
+	var tx pgx.Tx
+	backoff := retry.WithMaxRetries(commentsBeginAttempts-1, retry.NewExponential(commentsBeginBackoff))
+	err := retry.Do(ctx, backoff, func(ctx context.Context) error {
+		var err error
+		tx, err = e.begin(ctx)
+		if err != nil {
+			return retry.RetryableError(err)
+		}
+		return nil
+	})
+	return tx, err
+}
+
+// execute executes the operation sql then calls onComplete, in the given transaction
+// if set, the lock statement is executed first (this is only used when applying comments)
+// commitDescription (a format string taking the connection name) is used in the commit error message
+func (e *txConnectionExecutor) execute(ctx context.Context, tx pgx.Tx, lockStatement, sql string, onComplete onOperationComplete, commitDescription, connectionName string) error {
+	// roll back unless committed (this is a no-op if the transaction has been committed)
+	defer tx.Rollback(ctx)
+
+	if lockStatement != "" {
+		if _, err := tx.Exec(ctx, lockStatement); err != nil {
+			return sperr.WrapWithMessage(err, "failed to acquire lock to set comments for connection '%s'", connectionName)
+		}
+	}
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return &statementError{err: err}
+	}
+	if err := onComplete(tx); err != nil {
+		return err
+	}
+
+	// (if the pool has lost its connection, return the error unwrapped so the caller can recreate the pool and retry)
+	if err := tx.Commit(ctx); err != nil {
+		if isPoolConnectionError(err) {
+			return err
+		}
+		return sperr.WrapWithMessage(err, "failed to commit "+commitDescription, connectionName)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/introspection"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// fakeConnectionExecutor is a connectionExecutor which records the operations executed, without a database
+type fakeConnectionExecutor struct {
+	mut sync.Mutex
+	// the operations executed, e.g. "update a"
+	operations []string
+	// the statements executed by the operation callbacks, and outside of any operation
+	statements []string
+	// map of connection name to the statement error returned for its operations
+	failures map[string]error
+}
+
+func (e *fakeConnectionExecutor) UpdateSchema(_ context.Context, connectionName, _ string, onComplete onOperationComplete) error {
+	return e.execute("update", connectionName, onComplete)
+}
+
+func (e *fakeConnectionExecutor) CloneSchema(_ context.Context, connectionName, _ string, onComplete onOperationComplete) error {
+	return e.execute("clone", connectionName, onComplete)
+}
+
+// CloneSchemas records a single "clone a,b" operation, which fails if any of the connections fails
+func (e *fakeConnectionExecutor) CloneSchemas(_ context.Context, connectionNames []string, _ string, onComplete onOperationComplete) error {
+	e.mut.Lock()
+	e.operations = append(e.operations, "clone "+strings.Join(connectionNames, ","))
+	var err error
+	for _, connectionName := range connectionNames {
+		if e.failures[connectionName] != nil {
+			err = e.failures[connectionName]
+		}
+	}
+	e.mut.Unlock()
+
+	if err != nil {
+		return &statementError{err: err}
+	}
+	return onComplete(e)
+}
+
+func (e *fakeConnectionExecutor) DeleteSchema(_ context.Context, connectionName, _ string, onComplete onOperationComplete) error {
+	return e.execute("delete", connectionName, onComplete)
+}
+
+func (e *fakeConnectionExecutor) ApplyComments(_ context.Context, connectionName, _, _ string, onComplete onOperationComplete) error {
+	return e.execute("comments", connectionName, onComplete)
+}
+
+func (e *fakeConnectionExecutor) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	e.statements = append(e.statements, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (e *fakeConnectionExecutor) execute(operation, connectionName string, onComplete onOperationComplete) error {
+	e.mut.Lock()
+	e.operations = append(e.operations, operation+" "+connectionName)
+	err := e.failures[connectionName]
+	e.mut.Unlock()
+
+	if err != nil {
+		return &statementError{err: err}
+	}
+	return onComplete(e)
+}
+
+func TestFakeConnectionExecutor(t *testing.T) {
+	s := newUpdateTestState()
+	for _, name := range []string{"b", "c", "d"} {
+		connectionState := newTestConnectionState(name, constants.ConnectionStateUpdating)
+		s.connectionUpdates.Update[name] = connectionState
+		s.connectionUpdates.FinalConnectionState[name] = connectionState
+	}
+	executor := &fakeConnectionExecutor{failures: map[string]error{"c": errors.New("schema c is in use")}}
+	s.executor = executor
+	ctx := context.Background()
+
+	if err := s.executeUpdateQuery(ctx, "import a", "a", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.executeUpdateQuery(ctx, "clone b", "b", true); err != nil {
+		t.Fatal(err)
+	}
+	// a failed clone is returned so the caller can fall back to importing the schema
+	var cloneErr *cloneFailedError
+	if err := s.executeUpdateQuery(ctx, "clone c", "c", true); !errors.As(err, &cloneErr) {
+		t.Errorf("expected a failed clone to return a cloneFailedError, got %v", err)
+	}
+	// failed deletions are written to the state table, not returned
+	if err := s.executeDeleteQueries(ctx, []string{"d", "c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOperations := []string{"update a", "clone b", "clone c", "delete d", "delete c"}
+	if !reflect.DeepEqual(executor.operations, expectedOperations) {
+		t.Errorf("expected operations %v, got %v", expectedOperations, executor.operations)
+	}
+
+	expectedResults := map[string]struct {
+		actual   []string
+		expected []string
+	}{
+		"created": {s.res.CreatedConnections, []string{"a"}},
+		"cloned":  {s.res.ClonedConnections, []string{"b"}},
+		"deleted": {s.res.DeletedConnections, []string{"d"}},
+	}
+	for name, test := range expectedResults {
+		if !reflect.DeepEqual(test.actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, test.actual)
+		}
+	}
+
+	// the state table records the deletion of d and the failure to delete c
+	var expectedStatements []string
+	for _, q := range introspection.GetDeleteConnectionStateSql(steampipeconfig.DefaultConnectionStateTable(), "d") {
+		expectedStatements = append(expectedStatements, q.Query)
+	}
+	expectedStatements = append(expectedStatements, introspection.GetConnectionEventSql(&steampipeconfig.ConnectionEvent{}).Query)
+	for _, q := range introspection.GetConnectionStateErrorSql(steampipeconfig.DefaultConnectionStateTable(), "c", executor.failures["c"]) {
+		expectedStatements = append(expectedStatements, q.Query)
+	}
+	expectedStatements = append(expectedStatements, introspection.GetConnectionEventSql(&steampipeconfig.ConnectionEvent{}).Query)
+	actualStatements := executor.statements[len(executor.statements)-len(expectedStatements):]
+	if !reflect.DeepEqual(actualStatements, expectedStatements) {
+		t.Errorf("expected state statements %v, got %v", expectedStatements, actualStatements)
+	}
+}
+
+func TestApplyCommentsBeginRetry(t *testing.T) {
+	db := &fakeDatabase{onRead: func([]string) {}}
+	beginErr := errors.New("failed to connect: too many clients already")
+	commentsSQL := `COMMENT ON FOREIGN TABLE "a"."t" is 'test';`
+
+	testCases := map[string]struct {
+		failures      int
+		cancelled     bool
+		expectAttempt int
+		expectErr     bool
+	}{
+		"first attempt fails": {failures: 1, expectAttempt: 2},
+		"cancelled":           {failures: commentsBeginAttempts, cancelled: true, expectAttempt: 0, expectErr: true},
+	}
+	for name, test := range testCases {
+		db.committed = nil
+		attempts := 0
+		executor := newFakeTxExecutor(&fakeTx{db: db})
+		begin := executor.begin
+		executor.begin = func(ctx context.Context) (pgx.Tx, error) {
+			attempts++
+			if attempts <= test.failures {
+				return nil, beginErr
+			}
+			return begin(ctx)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if test.cancelled {
+			cancel()
+		}
+		err := executor.ApplyComments(ctx, "a", "", commentsSQL, func(sqlExecutor) error { return nil })
+		cancel()
+
+		if attempts != test.expectAttempt {
+			t.Errorf("Test: '%s' FAILED : expected %d attempts, got %d", name, test.expectAttempt, attempts)
+		}
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test: '%s' FAILED : expected error %v, got %v", name, test.expectErr, err)
+			continue
+		}
+		if !test.expectErr && !reflect.DeepEqual(db.committed, []string{commentsSQL}) {
+			t.Errorf("Test: '%s' FAILED : expected the comments to be committed, got %v", name, db.committed)
+		}
+	}
+}
+
+// concurrencyTrackingExecutor is a fakeConnectionExecutor which records the maximum number of schema updates in flight
+type concurrencyTrackingExecutor struct {
+	*fakeConnectionExecutor
+	mut         sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (e *concurrencyTrackingExecutor) UpdateSchema(ctx context.Context, connectionName, sql string, onComplete onOperationComplete) error {
+	e.mut.Lock()
+	e.inFlight++
+	e.maxInFlight = max(e.maxInFlight, e.inFlight)
+	e.mut.Unlock()
+	defer func() {
+		e.mut.Lock()
+		e.inFlight--
+		e.mut.Unlock()
+	}()
+
+	// give the other updates a chance to start
+	time.Sleep(10 * time.Millisecond)
+	return e.fakeConnectionExecutor.UpdateSchema(ctx, connectionName, sql, onComplete)
+}
+
+func TestExecuteUpdatesInParallel(t *testing.T) {
+	testCases := map[string]int{"serial": 1, "parallel": 4}
+	for name, maxParallel := range testCases {
+		s := newUpdateTestState()
+		s.opts.MaxUpdateParallel = maxParallel
+		s.opts.CloneSchema = constants.CloneSchemaNever
+		s.exemplarSchemaMap = map[string]string{}
+		updates := make(map[string]*steampipeconfig.ConnectionState)
+		for i := 0; i < 12; i++ {
+			connectionState := newTestConnectionState(fmt.Sprintf("c%d", i), constants.ConnectionStateUpdating)
+			updates[connectionState.ConnectionName] = connectionState
+			s.connectionUpdates.Update[connectionState.ConnectionName] = connectionState
+			s.connectionUpdates.FinalConnectionState[connectionState.ConnectionName] = connectionState
+		}
+		executor := &concurrencyTrackingExecutor{fakeConnectionExecutor: &fakeConnectionExecutor{failures: map[string]error{
+			"c3": errors.New("import failed"),
+			"c7": errors.New("import failed"),
+		}}}
+		s.executor = executor
+
+		errs := s.executeUpdatesInParallel(context.Background(), updates)
+
+		if len(executor.operations) != len(updates) {
+			t.Errorf("Test: '%s' FAILED : expected %d updates, got %d", name, len(updates), len(executor.operations))
+		}
+		if executor.maxInFlight != maxParallel {
+			t.Errorf("Test: '%s' FAILED : expected %d updates in flight, got %d", name, maxParallel, executor.maxInFlight)
+		}
+		// the failures are written to the state table rather than returned, and the other connections are created
+		if len(errs) != 0 {
+			t.Errorf("Test: '%s' FAILED : expected no errors, got %v", name, errs)
+		}
+		if len(s.res.CreatedConnections) != len(updates)-2 {
+			t.Errorf("Test: '%s' FAILED : expected %d created connections, got %v", name, len(updates)-2, s.res.CreatedConnections)
+		}
+	}
+}
@@ -3,8 +3,9 @@ package connection
 import (
 	"context"
 	"log"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/db/db_common"
@@ -13,27 +14,78 @@ import (
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 )
 
+// sqlExecutor is implemented by both pgx.Conn and pgx.Tx, allowing state table updates to be executed
+// either directly or inside the transaction which updates the connection schema
+type sqlExecutor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// connectionStateTableUpdater updates the connection_state table as connections are updated
+//
+// the connection_state table never leads the connection schemas:
+//   - connections are set to 'updating' before any schema DDL is executed
+//   - a connection is set to 'ready' (or deleted from the table) inside the same transaction
+//     as the DDL which creates (or drops) its schema, so both changes become visible together
+//   - if the DDL fails, the transaction is rolled back and the connection is set to 'error'
+//
+// each change of connection state is also sent as a ConnectionEvent on the connection events channel
+// (see constants.PostgresConnectionEventChannel), in the transaction which updates the table
 type connectionStateTableUpdater struct {
 	updates *steampipeconfig.ConnectionUpdates
 	pool    *pgxpool.Pool
+	// the table the connection state is written to
+	table steampipeconfig.ConnectionStateTable
 }
 
-func newConnectionStateTableUpdater(updates *steampipeconfig.ConnectionUpdates, pool *pgxpool.Pool) *connectionStateTableUpdater {
-	log.Println("[DEBUG] newConnectionStateTableUpdater start")
-	defer log.Println("[DEBUG] newConnectionStateTableUpdater end")
+func newConnectionStateTableUpdater(ctx context.Context, updates *steampipeconfig.ConnectionUpdates, pool *pgxpool.Pool, table steampipeconfig.ConnectionStateTable) *connectionStateTableUpdater {
+	logDebug(ctx, "newConnectionStateTableUpdater start")
+	defer logDebug(ctx, "newConnectionStateTableUpdater end")
 
 	return &connectionStateTableUpdater{
 		updates: updates,
 		pool:    pool,
+		table:   table,
 	}
 }
 
 // update connection state table to indicate the updates that will be done
-func (u *connectionStateTableUpdater) start(ctx context.Context) error {
-	log.Println("[DEBUG] connectionStateTableUpdater.start start")
-	defer log.Println("[DEBUG] connectionStateTableUpdater.start end")
+// the updates are written in batches of batchSize connections to limit the size of each transaction
+func (u *connectionStateTableUpdater) start(ctx context.Context, batchSize int) error {
+	logDebug(ctx, "connectionStateTableUpdater.start start")
+	defer logDebug(ctx, "connectionStateTableUpdater.start end")
+
+	conn, err := u.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
 
+	flush := func(ctx context.Context, queries []db_common.QueryWithArgs) error {
+		_, err := db_local.ExecuteSqlWithArgsInTransaction(ctx, conn.Conn(), queries...)
+		return err
+	}
+	return u.writeInitialState(ctx, batchSize, flush)
+}
+
+// writeInitialState builds the queries to set the initial state of all connections, calling flush for each batch
+// of batchSize connections (a batch size of 0 means all queries are flushed at once)
+func (u *connectionStateTableUpdater) writeInitialState(ctx context.Context, batchSize int, flush func(context.Context, []db_common.QueryWithArgs) error) error {
 	var queries []db_common.QueryWithArgs
+	batchCount := 0
+
+	// add the queries for a connection, flushing if the batch is full
+	add := func(connectionQueries []db_common.QueryWithArgs) error {
+		queries = append(queries, connectionQueries...)
+		batchCount++
+		if batchSize > 0 && batchCount >= batchSize {
+			if err := flush(ctx, queries); err != nil {
+				return err
+			}
+			queries = nil
+			batchCount = 0
+		}
+		return nil
+	}
 
 	// update the conection state table to set appropriate state for all connections
 	// set updates to "updating"
@@ -46,9 +98,17 @@ func (u *connectionStateTableUpdater) start(ctx context.Context) error {
 			// if this connection has an error, set to error
 			connectionState.State = constants.ConnectionStateError
 			connectionState.ConnectionError = &validationError.Message
+			now := time.Now()
+			connectionState.LastErrorAt = &now
 		}
 		// get the sql to update the connection state in the table to match the struct
-		queries = append(queries, introspection.GetUpsertConnectionStateSql(connectionState)...)
+		connectionQueries := introspection.GetUpsertConnectionStateSql(u.table, connectionState)
+		if event := initialStateEvent(ctx, connectionState); event != nil {
+			connectionQueries = append(connectionQueries, introspection.GetConnectionEventSql(event))
+		}
+		if err := add(connectionQueries); err != nil {
+			return err
+		}
 	}
 	// set deletions to "deleting"
 	for name := range u.updates.Delete {
@@ -57,32 +117,52 @@ func (u *connectionStateTableUpdater) start(ctx context.Context) error {
 		if _, connectionDisabled := u.updates.Disabled[name]; connectionDisabled {
 			continue
 		}
+		// likewise if the import of a lazy connection is being deferred, the state remains "lazy"
+		if _, connectionLazy := u.updates.Lazy[name]; connectionLazy {
+			continue
+		}
 
-		queries = append(queries, introspection.GetSetConnectionStateSql(name, constants.ConnectionStateDeleting)...)
+		if err := add(introspection.GetSetConnectionStateSql(u.table, name, constants.ConnectionStateDeleting)); err != nil {
+			return err
+		}
 	}
 
 	// set any connections with import_schema=disabled to "disabled"
 	// also build a lookup of disabled connections
 	for name := range u.updates.Disabled {
-		queries = append(queries, introspection.GetSetConnectionStateSql(name, constants.ConnectionStateDisabled)...)
+		if err := add(introspection.GetSetConnectionStateSql(u.table, name, constants.ConnectionStateDisabled)); err != nil {
+			return err
+		}
 	}
-	conn, err := u.pool.Acquire(ctx)
-	if err != nil {
-		return err
+
+	// flush any remaining queries
+	if len(queries) == 0 {
+		return nil
 	}
-	defer conn.Release()
-	if _, err = db_local.ExecuteSqlWithArgsInTransaction(ctx, conn.Conn(), queries...); err != nil {
-		return err
+	return flush(ctx, queries)
+}
+
+// initialStateEvent returns the event to send for the initial state of a connection
+// (nil if the connection is neither being updated nor invalid)
+func initialStateEvent(ctx context.Context, connectionState *steampipeconfig.ConnectionState) *steampipeconfig.ConnectionEvent {
+	switch connectionState.State {
+	case constants.ConnectionStateUpdating:
+		return steampipeconfig.NewConnectionEvent(steampipeconfig.ConnectionEventUpdating, connectionState.ConnectionName, RefreshIDFromContext(ctx))
+	case constants.ConnectionStateError:
+		return steampipeconfig.NewConnectionErrorEvent(connectionState.ConnectionName, RefreshIDFromContext(ctx), connectionState.Error())
 	}
 	return nil
 }
 
-func (u *connectionStateTableUpdater) onConnectionReady(ctx context.Context, conn *pgx.Conn, name string) error {
-	log.Println("[DEBUG] connectionStateTableUpdater.onConnectionReady start")
-	defer log.Println("[DEBUG] connectionStateTableUpdater.onConnectionReady end")
+// onConnectionReady sets the connection state to ready, recording the refresh time
+// this must be executed in the transaction which creates the connection schema
+func (u *connectionStateTableUpdater) onConnectionReady(ctx context.Context, conn sqlExecutor, name string) error {
+	logDebug(ctx, "connectionStateTableUpdater.onConnectionReady start")
+	defer logDebug(ctx, "connectionStateTableUpdater.onConnectionReady end")
 
 	connection := u.updates.FinalConnectionState[name]
-	queries := introspection.GetSetConnectionStateSql(connection.ConnectionName, constants.ConnectionStateReady)
+	queries := introspection.GetSetConnectionStateReadySql(u.table, connection.ConnectionName, time.Now())
+	queries = append(queries, introspection.GetConnectionEventSql(steampipeconfig.NewConnectionEvent(steampipeconfig.ConnectionEventUpdated, connection.ConnectionName, RefreshIDFromContext(ctx))))
 	for _, q := range queries {
 		if _, err := conn.Exec(ctx, q.Query, q.Args...); err != nil {
 			return err
@@ -91,12 +171,12 @@ func (u *connectionStateTableUpdater) onConnectionReady(ctx context.Context, con
 	return nil
 }
 
-func (u *connectionStateTableUpdater) onConnectionCommentsLoaded(ctx context.Context, conn *pgx.Conn, name string) error {
-	log.Println("[DEBUG] connectionStateTableUpdater.onConnectionCommentsLoaded start")
-	defer log.Println("[DEBUG] connectionStateTableUpdater.onConnectionCommentsLoaded end")
+func (u *connectionStateTableUpdater) onConnectionCommentsLoaded(ctx context.Context, conn sqlExecutor, name string) error {
+	logDebug(ctx, "connectionStateTableUpdater.onConnectionCommentsLoaded start")
+	defer logDebug(ctx, "connectionStateTableUpdater.onConnectionCommentsLoaded end")
 
 	connection := u.updates.FinalConnectionState[name]
-	queries := introspection.GetSetConnectionStateCommentLoadedSql(connection.ConnectionName, true)
+	queries := introspection.GetSetConnectionStateCommentLoadedSql(u.table, connection.ConnectionName, true)
 	for _, q := range queries {
 		if _, err := conn.Exec(ctx, q.Query, q.Args...); err != nil {
 			return err
@@ -105,15 +185,22 @@ func (u *connectionStateTableUpdater) onConnectionCommentsLoaded(ctx context.Con
 	return nil
 }
 
-func (u *connectionStateTableUpdater) onConnectionDeleted(ctx context.Context, conn *pgx.Conn, name string) error {
-	log.Println("[DEBUG] connectionStateTableUpdater.onConnectionDeleted start")
-	defer log.Println("[DEBUG] connectionStateTableUpdater.onConnectionDeleted end")
+// onConnectionDeleted removes the connection from the state table
+// this must be executed in the transaction which drops the connection schema
+func (u *connectionStateTableUpdater) onConnectionDeleted(ctx context.Context, conn sqlExecutor, name string) error {
+	logDebug(ctx, "connectionStateTableUpdater.onConnectionDeleted start")
+	defer logDebug(ctx, "connectionStateTableUpdater.onConnectionDeleted end")
 
 	// if this connection has schema import disabled, DO NOT delete from the conneciotn state table
 	if _, connectionDisabled := u.updates.Disabled[name]; connectionDisabled {
 		return nil
 	}
-	queries := introspection.GetDeleteConnectionStateSql(name)
+	// nor if the import of a lazy connection has been deferred
+	if _, connectionLazy := u.updates.Lazy[name]; connectionLazy {
+		return nil
+	}
+	queries := introspection.GetDeleteConnectionStateSql(u.table, name)
+	queries = append(queries, introspection.GetConnectionEventSql(steampipeconfig.NewConnectionEvent(steampipeconfig.ConnectionEventDeleted, name, RefreshIDFromContext(ctx))))
 	for _, q := range queries {
 		if _, err := conn.Exec(ctx, q.Query, q.Args...); err != nil {
 			return err
@@ -122,16 +209,20 @@ func (u *connectionStateTableUpdater) onConnectionDeleted(ctx context.Context, c
 	return nil
 }
 
-func (u *connectionStateTableUpdater) onConnectionError(ctx context.Context, conn *pgx.Conn, connectionName string, err error) error {
-	log.Println("[DEBUG] connectionStateTableUpdater.onConnectionError start")
-	defer log.Println("[DEBUG] connectionStateTableUpdater.onConnectionError end")
+func (u *connectionStateTableUpdater) onConnectionError(ctx context.Context, conn sqlExecutor, connectionName string, err error) error {
+	logDebug(ctx, "connectionStateTableUpdater.onConnectionError start")
+	defer logDebug(ctx, "connectionStateTableUpdater.onConnectionError end")
 
-	queries := introspection.GetConnectionStateErrorSql(connectionName, err)
-	for _, q := range queries {
+	for _, q := range introspection.GetConnectionStateErrorSql(u.table, connectionName, err) {
 		if _, err := conn.Exec(ctx, q.Query, q.Args...); err != nil {
 			return err
 		}
 	}
 
+	// the connection state has been updated - failing to send the event must not fail the update
+	event := introspection.GetConnectionEventSql(steampipeconfig.NewConnectionErrorEvent(connectionName, RefreshIDFromContext(ctx), err.Error()))
+	if _, notifyErr := conn.Exec(ctx, event.Query, event.Args...); notifyErr != nil {
+		log.Printf("[WARN] failed to send connection error event for %s: %s", connectionName, notifyErr.Error())
+	}
 	return nil
 }
@@ -0,0 +1,431 @@
+package connection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/introspection"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// fakeDatabase models the committed state of the database as a list of executed statements
+type fakeDatabase struct {
+	committed []string
+	// called after every change to the committed state, to simulate a concurrent reader
+	onRead func(committed []string)
+}
+
+// fakeTx is a transaction against a fakeDatabase - statements only become visible when it is committed
+type fakeTx struct {
+	pgx.Tx
+	db        *fakeDatabase
+	pending   []string
+	commitErr error
+	// if set, this error is returned by every Exec
+	execErr error
+}
+
+func (tx *fakeTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	if tx.execErr != nil {
+		return pgconn.CommandTag{}, tx.execErr
+	}
+	tx.pending = append(tx.pending, sql)
+	// a concurrent reader must not see uncommitted statements
+	tx.db.onRead(tx.db.committed)
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	if tx.commitErr != nil {
+		return tx.commitErr
+	}
+	tx.db.committed = append(tx.db.committed, tx.pending...)
+	tx.pending = nil
+	tx.db.onRead(tx.db.committed)
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	tx.pending = nil
+	return nil
+}
+
+// newFakeTxExecutor returns a connection executor which executes every operation in the given transaction
+// statements executed outside of an operation are committed immediately
+func newFakeTxExecutor(tx *fakeTx) *txConnectionExecutor {
+	return &txConnectionExecutor{
+		begin: func(context.Context) (pgx.Tx, error) {
+			return tx, nil
+		},
+		exec: func(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+			tx.db.committed = append(tx.db.committed, sql)
+			return pgconn.CommandTag{}, nil
+		},
+	}
+}
+
+// testRefreshOptions returns the refresh options used when no config is set
+func testRefreshOptions() *RefreshOptions {
+	return &RefreshOptions{
+		SchemaComments:           true,
+		CommentLock:              constants.CommentLockNone,
+		CloneSchema:              constants.CloneSchemaAuto,
+		GrantPrivileges:          constants.DefaultGrantPrivileges,
+		ConnectionStateBatchSize: constants.DefaultConnectionStateBatchSize,
+		MaxUpdateParallel:        1,
+	}
+}
+
+func newUpdateTestState() *refreshConnectionState {
+	connectionState := newTestConnectionState("a", constants.ConnectionStateUpdating)
+	updates := &steampipeconfig.ConnectionUpdates{
+		Update:               steampipeconfig.ConnectionStateMap{"a": connectionState},
+		FinalConnectionState: steampipeconfig.ConnectionStateMap{"a": connectionState},
+	}
+	return &refreshConnectionState{
+		opts:              testRefreshOptions(),
+		connectionUpdates: updates,
+		tableUpdater:      &connectionStateTableUpdater{updates: updates, table: steampipeconfig.DefaultConnectionStateTable()},
+		res:               &steampipeconfig.RefreshConnectionResult{},
+	}
+}
+
+func TestConnectionStateNeverLeadsSchema(t *testing.T) {
+	s := newUpdateTestState()
+	schemaSql := "create schema a;"
+
+	reads := 0
+	db := &fakeDatabase{}
+	db.onRead = func(committed []string) {
+		reads++
+		haveSchema, ready := false, false
+		for _, statement := range committed {
+			if statement == schemaSql {
+				haveSchema = true
+			}
+			if strings.Contains(statement, "state = 'ready'") {
+				ready = true
+			}
+		}
+		if ready && !haveSchema {
+			t.Errorf("read %d: connection state is ready before the schema exists", reads)
+		}
+		if haveSchema != ready {
+			t.Errorf("read %d: schema and connection state were not committed together", reads)
+		}
+	}
+
+	s.executor = newFakeTxExecutor(&fakeTx{db: db})
+	if err := s.executeUpdateQuery(context.Background(), schemaSql, "a", false); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.committed) < 2 {
+		t.Fatalf("expected the schema and state updates to be committed, got %v", db.committed)
+	}
+	if len(s.res.CreatedConnections) != 1 {
+		t.Errorf("expected the connection to be recorded as created once committed")
+	}
+}
+
+func TestConnectionUpdateCommitFailure(t *testing.T) {
+	s := newUpdateTestState()
+	db := &fakeDatabase{onRead: func([]string) {}}
+
+	s.executor = newFakeTxExecutor(&fakeTx{db: db, commitErr: errors.New("serialization failure")})
+	err := s.executeUpdateQuery(context.Background(), "create schema a;", "a", false)
+	if err == nil {
+		t.Fatalf("expected commit failure to be returned")
+	}
+	if len(db.committed) != 0 || len(s.res.CreatedConnections) != 0 {
+		t.Errorf("expected nothing to be committed or recorded, got committed %v, created %v", db.committed, s.res.CreatedConnections)
+	}
+}
+
+// newSyntheticUpdates builds connection updates which update the given number of connections
+func newSyntheticUpdates(count int) *steampipeconfig.ConnectionUpdates {
+	updates := &steampipeconfig.ConnectionUpdates{
+		Update:               steampipeconfig.ConnectionStateMap{},
+		FinalConnectionState: steampipeconfig.ConnectionStateMap{},
+	}
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("connection_%d", i)
+		connectionState := newTestConnectionState(name, constants.ConnectionStatePending)
+		updates.Update[name] = connectionState
+		updates.FinalConnectionState[name] = connectionState
+	}
+	return updates
+}
+
+func TestWriteInitialStateInBatches(t *testing.T) {
+	const connectionCount = 5000
+
+	for _, batchSize := range []int{0, 1000, 3000} {
+		updates := newSyntheticUpdates(connectionCount)
+		u := &connectionStateTableUpdater{updates: updates, table: steampipeconfig.DefaultConnectionStateTable()}
+
+		var batches [][]db_common.QueryWithArgs
+		flush := func(_ context.Context, queries []db_common.QueryWithArgs) error {
+			batches = append(batches, queries)
+			return nil
+		}
+		if err := u.writeInitialState(context.Background(), batchSize, flush); err != nil {
+			t.Fatalf("batch size %d: unexpected error: %s", batchSize, err.Error())
+		}
+
+		expectedBatches := 1
+		if batchSize > 0 {
+			expectedBatches = (connectionCount + batchSize - 1) / batchSize
+		}
+		if len(batches) != expectedBatches {
+			t.Errorf("batch size %d: expected %d flushes, got %d", batchSize, expectedBatches, len(batches))
+		}
+
+		// the same queries must be written whatever the batch size
+		// (the upsert of each connection, and its 'updating' event)
+		queriesPerConnection := len(introspection.GetUpsertConnectionStateSql(u.table, updates.FinalConnectionState["connection_0"])) + 1
+		total := 0
+		for _, b := range batches {
+			total += len(b)
+		}
+		if total != connectionCount*queriesPerConnection {
+			t.Errorf("batch size %d: expected %d queries, got %d", batchSize, connectionCount*queriesPerConnection, total)
+		}
+		for name, connectionState := range updates.FinalConnectionState {
+			if connectionState.State != constants.ConnectionStateUpdating {
+				t.Errorf("batch size %d: expected connection %s to be updating, got %s", batchSize, name, connectionState.State)
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkWriteInitialState(b *testing.B) {
+	updates := newSyntheticUpdates(5000)
+	u := &connectionStateTableUpdater{updates: updates, table: steampipeconfig.DefaultConnectionStateTable()}
+	flush := func(context.Context, []db_common.QueryWithArgs) error { return nil }
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := u.writeInitialState(context.Background(), constants.DefaultConnectionStateBatchSize, flush); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// recordingExecutor records every statement it executes, along with its arguments
+type recordingExecutor struct {
+	sql  []string
+	args [][]any
+}
+
+func (e *recordingExecutor) Exec(_ context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	e.sql = append(e.sql, sql)
+	e.args = append(e.args, arguments)
+	return pgconn.CommandTag{}, nil
+}
+
+func TestConnectionReadyRecordsRefreshTime(t *testing.T) {
+	s := newUpdateTestState()
+
+	// refresh the connection and return the refresh time written to the state table
+	refresh := func() time.Time {
+		executor := &recordingExecutor{}
+		if err := s.tableUpdater.onConnectionReady(context.Background(), executor, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if len(executor.args) == 0 {
+			t.Fatal("expected connection state to be updated")
+		}
+		refreshTime, ok := executor.args[0][0].(time.Time)
+		if !ok {
+			t.Fatalf("expected last_refreshed to be written, got args %v", executor.args[0])
+		}
+		return refreshTime
+	}
+
+	first := refresh()
+	time.Sleep(time.Millisecond)
+	second := refresh()
+	if !second.After(first) {
+		t.Errorf("expected last_refreshed to advance across refreshes, got %s then %s", first, second)
+	}
+}
+
+func TestConfiguredConnectionStateTable(t *testing.T) {
+	prevSchema := viper.GetString(constants.ArgConnectionStateSchema)
+	prevTable := viper.GetString(constants.ArgConnectionStateTable)
+	defer func() {
+		viper.Set(constants.ArgConnectionStateSchema, prevSchema)
+		viper.Set(constants.ArgConnectionStateTable, prevTable)
+	}()
+	viper.Set(constants.ArgConnectionStateSchema, "instance_b")
+	viper.Set(constants.ArgConnectionStateTable, `connection"state`)
+
+	table := steampipeconfig.ConnectionStateTableFromConfig()
+	expectedIdentifier := `"instance_b"."connection""state"`
+	if table.Identifier() != expectedIdentifier {
+		t.Fatalf("expected table identifier %s, got %s", expectedIdentifier, table.Identifier())
+	}
+
+	updates := newSyntheticUpdates(3)
+	updates.Delete = map[string]struct{}{"deleted": {}}
+	u := newConnectionStateTableUpdater(context.Background(), updates, nil, table)
+
+	// all statements must use the configured table - and the legacy table must not be written
+	var statements []string
+	flush := func(_ context.Context, queries []db_common.QueryWithArgs) error {
+		for _, q := range queries {
+			statements = append(statements, q.Query)
+		}
+		return nil
+	}
+	if err := u.writeInitialState(context.Background(), 0, flush); err != nil {
+		t.Fatal(err)
+	}
+	executor := &recordingExecutor{}
+	if err := u.onConnectionReady(context.Background(), executor, "connection_0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.onConnectionCommentsLoaded(context.Background(), executor, "connection_0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.onConnectionDeleted(context.Background(), executor, "deleted"); err != nil {
+		t.Fatal(err)
+	}
+	statements = append(statements, executor.sql...)
+
+	// 3 upserts, 1 delete, ready, comments loaded and deleted (the connection events do not use the table)
+	var tableStatements []string
+	for _, statement := range statements {
+		if !strings.Contains(statement, "pg_notify") {
+			tableStatements = append(tableStatements, statement)
+		}
+	}
+	if len(tableStatements) != 7 {
+		t.Fatalf("expected 7 statements, got %d: %v", len(tableStatements), tableStatements)
+	}
+	for _, statement := range tableStatements {
+		if !strings.Contains(statement, expectedIdentifier) {
+			t.Errorf("expected statement to use the configured table: %s", statement)
+		}
+		if strings.Contains(statement, constants.ConnectionTable) || strings.Contains(statement, constants.LegacyConnectionStateTable) {
+			t.Errorf("expected statement not to use the default tables: %s", statement)
+		}
+	}
+}
+
+func TestConnectionEvents(t *testing.T) {
+	ctx := WithRefreshID(context.Background(), "refresh-1")
+	updates := newSyntheticUpdates(1)
+	updates.Delete = map[string]struct{}{"deleted": {}}
+	u := newConnectionStateTableUpdater(ctx, updates, nil, steampipeconfig.DefaultConnectionStateTable())
+
+	executor := &recordingExecutor{}
+	flush := func(ctx context.Context, queries []db_common.QueryWithArgs) error {
+		for _, q := range queries {
+			if _, err := executor.Exec(ctx, q.Query, q.Args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := u.writeInitialState(ctx, 0, flush); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.onConnectionReady(ctx, executor, "connection_0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.onConnectionError(ctx, executor, "connection_0", errors.New("plugin crashed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.onConnectionDeleted(ctx, executor, "deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	// decode the events sent on the connection events channel
+	var events []string
+	for i, sql := range executor.sql {
+		if !strings.Contains(sql, "pg_notify") {
+			continue
+		}
+		if channel := executor.args[i][0]; channel != constants.PostgresConnectionEventChannel {
+			t.Errorf("expected the event to be sent on %s, got %v", constants.PostgresConnectionEventChannel, channel)
+		}
+		var event steampipeconfig.ConnectionEvent
+		if err := json.Unmarshal([]byte(executor.args[i][1].(string)), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.RefreshID != "refresh-1" || event.StructVersion != steampipeconfig.ConnectionEventStructVersion {
+			t.Errorf("expected the event to include the refresh ID and struct version, got %+v", event)
+		}
+		events = append(events, fmt.Sprintf("%s %s %s", event.Event, event.Connection, event.Error))
+	}
+
+	expected := []string{
+		"updating connection_0 ",
+		"updated connection_0 ",
+		"error connection_0 plugin crashed",
+		"deleted deleted ",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+}
+
+// notifyFailingExecutor records statements, failing any pg_notify
+type notifyFailingExecutor struct {
+	recordingExecutor
+}
+
+func (e *notifyFailingExecutor) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	e.recordingExecutor.Exec(ctx, sql, arguments...)
+	if strings.Contains(sql, "pg_notify") {
+		return pgconn.CommandTag{}, errors.New("payload string too long")
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func TestConnectionErrorEvent(t *testing.T) {
+	ctx := WithRefreshID(context.Background(), "refresh-1")
+	u := newConnectionStateTableUpdater(ctx, newSyntheticUpdates(1), nil, steampipeconfig.DefaultConnectionStateTable())
+
+	// an error which fits in a notification before json escaping, but not after
+	connectionErr := errors.New(strings.Repeat(`"\`, 3000))
+
+	// the event payload must be truncated to fit in a notification
+	executor := &recordingExecutor{}
+	if err := u.onConnectionError(ctx, executor, "connection_0", connectionErr); err != nil {
+		t.Fatal(err)
+	}
+	payload := executor.args[len(executor.args)-1][1].(string)
+	if len(payload) >= 8000 {
+		t.Errorf("expected the event payload to be shorter than 8000 bytes, got %d", len(payload))
+	}
+	var event steampipeconfig.ConnectionEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(event.Error, "...") || !strings.HasPrefix(connectionErr.Error(), strings.TrimSuffix(event.Error, "...")) {
+		t.Errorf("expected the event to include the truncated error, got %s", event.Error)
+	}
+
+	// failing to send the event must not fail the connection state update
+	failingExecutor := &notifyFailingExecutor{}
+	if err := u.onConnectionError(ctx, failingExecutor, "connection_0", connectionErr); err != nil {
+		t.Errorf("expected a failed event not to fail the connection state update, got %s", err.Error())
+	}
+	if len(failingExecutor.sql) < 2 || strings.Contains(failingExecutor.sql[0], "pg_notify") {
+		t.Errorf("expected the connection state to be updated before the event is sent, got %v", failingExecutor.sql)
+	}
+}
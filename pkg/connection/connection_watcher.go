@@ -6,13 +6,27 @@ import (
 	filehelpers "github.com/turbot/go-kit/files"
 	"github.com/turbot/go-kit/filewatcher"
 	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/go-kit/types"
 	"github.com/turbot/steampipe/pkg/cmdconfig"
 	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/filepaths"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"log"
+	"os"
 )
 
+// ConnectionWatcherEnabled returns whether connection config changes should be watched
+// this is true unless disabled by setting STEAMPIPE_CONNECTION_WATCHER to false
+func ConnectionWatcherEnabled() bool {
+	if envStr, ok := os.LookupEnv(constants.EnvConnectionWatcher); ok {
+		if parsedEnv, err := types.ToBool(envStr); err == nil {
+			return parsedEnv
+		}
+	}
+	return true
+}
+
 type ConnectionWatcher struct {
 	fileWatcherErrorHandler func(error)
 	watcher                 *filewatcher.FileWatcher
@@ -62,21 +76,44 @@ func (w *ConnectionWatcher) handleFileWatcherEvent([]fsnotify.Event) {
 	ctx := context.Background()
 
 	log.Printf("[INFO] ConnectionWatcher handleFileWatcherEvent")
+	opts, err := reloadConnectionConfig(ctx, w.pluginManager)
+	if err != nil {
+		log.Printf("[WARN] not refreshing connections: %s", err.Error())
+		return
+	}
+
+	log.Printf("[INFO] calling RefreshConnections asyncronously")
+
+	// call RefreshConnections asyncronously
+	// the RefreshConnections implements its own locking to ensure only a single execution and a single queues execution
+	go RefreshConnections(ctx, w.pluginManager, opts)
+
+	log.Printf("[TRACE] File watch event done")
+}
+
+func (w *ConnectionWatcher) Close() {
+	w.watcher.Close()
+}
+
+// reloadConnectionConfig reloads the connection config from the config directory, updating GlobalConfig,
+// viper and the plugin manager, and returns the refresh options loaded from the updated config
+// any errors or warnings are also sent as a postgres notification
+func reloadConnectionConfig(ctx context.Context, pluginManager pluginManager) (*RefreshOptions, error) {
 	config, errorsAndWarnings := steampipeconfig.LoadConnectionConfig()
 	// send notification if there were any errors or warnings
 	if !errorsAndWarnings.Empty() {
-		w.pluginManager.SendPostgresErrorsAndWarningsNotification(ctx, errorsAndWarnings)
+		pluginManager.SendPostgresErrorsAndWarningsNotification(ctx, errorsAndWarnings)
 		// if there was an error return
-		if errorsAndWarnings.GetError() != nil {
-			log.Printf("[WARN] error loading updated connection config: %v", errorsAndWarnings.GetError())
-			return
+		if err := errorsAndWarnings.GetError(); err != nil {
+			log.Printf("[WARN] error loading updated connection config: %v", err)
+			return nil, err
 		}
 	}
 
 	log.Printf("[INFO] loaded updated config")
 
 	// We need to update the viper config and GlobalConfig
-	// as these are both used by RefreshConnectionAndSearchPathsWithLocalClient
+	// as these are both used by RefreshConnections
 
 	// set the global steampipe config
 	steampipeconfig.GlobalConfig = config
@@ -85,7 +122,7 @@ func (w *ConnectionWatcher) handleFileWatcherEvent([]fsnotify.Event) {
 	// convert config to format expected by plugin manager
 	// (plugin manager cannot reference steampipe config to avoid circular deps)
 	configMap := NewConnectionConfigMap(config.Connections)
-	w.pluginManager.OnConnectionConfigChanged(ctx, configMap, config.PluginsInstances)
+	pluginManager.OnConnectionConfigChanged(ctx, configMap, config.PluginsInstances, NewConnectionLimiterMap(config.Connections))
 
 	// The only configurations from GlobalConfig which have
 	// impact during Refresh are Database options and the Connections
@@ -100,15 +137,10 @@ func (w *ConnectionWatcher) handleFileWatcherEvent([]fsnotify.Event) {
 	// to use the GlobalConfig here and ignore Workspace Profile in general
 	cmdconfig.SetDefaultsFromConfig(steampipeconfig.GlobalConfig.ConfigMap())
 
-	log.Printf("[INFO] calling RefreshConnections asyncronously")
-
-	// call RefreshConnections asyncronously
-	// the RefreshConnections implements its own locking to ensure only a single execution and a single queues execution
-	go RefreshConnections(ctx, w.pluginManager)
-
-	log.Printf("[TRACE] File watch event done")
-}
-
-func (w *ConnectionWatcher) Close() {
-	w.watcher.Close()
+	// load the refresh options now the config has been reloaded
+	opts, warnings := LoadRefreshOptions(ctx)
+	if len(warnings) > 0 {
+		pluginManager.SendPostgresErrorsAndWarningsNotification(ctx, error_helpers.NewErrorsAndWarning(nil, warnings...))
+	}
+	return opts, nil
 }
@@ -10,12 +10,15 @@ import (
 
 type pluginManager interface {
 	shared.PluginManager
-	OnConnectionConfigChanged(context.Context, ConnectionConfigMap, map[string]*modconfig.Plugin)
+	OnConnectionConfigChanged(context.Context, ConnectionConfigMap, map[string]*modconfig.Plugin, PluginLimiterMap)
 	GetConnectionConfig() ConnectionConfigMap
 	HandlePluginLimiterChanges(PluginLimiterMap) error
 	Pool() *pgxpool.Pool
+	RecreatePool(context.Context) (*pgxpool.Pool, error)
 	ShouldFetchRateLimiterDefs() bool
 	LoadPluginRateLimiters(map[string]string) (PluginLimiterMap, error)
-	SendPostgresSchemaNotification(context.Context) error
+	SendPostgresSchemaNotification(ctx context.Context, updatedConnections, deletedConnections []string) error
 	SendPostgresErrorsAndWarningsNotification(context.Context, *error_helpers.ErrorAndWarnings)
+	SendPostgresRefreshProgressNotification(ctx context.Context, status string)
+	StartRefresh() (context.Context, func())
 }
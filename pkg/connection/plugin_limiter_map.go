@@ -12,6 +12,46 @@ func (l PluginLimiterMap) Equals(other PluginLimiterMap) bool {
 	return maps.EqualFunc(l, other, func(m1, m2 LimiterMap) bool { return m1.Equals(m2) })
 }
 
+// NewConnectionLimiterMap builds a map of the limiters for all connections with max_concurrency or rate_limit set,
+// keyed by plugin instance
+// NOTE: connections in error are EXCLUDED
+func NewConnectionLimiterMap(connectionMap map[string]*modconfig.Connection) PluginLimiterMap {
+	res := make(PluginLimiterMap)
+	for _, c := range connectionMap {
+		if c.Error != nil {
+			continue
+		}
+		l := c.RateLimiter()
+		if l == nil {
+			continue
+		}
+		limitersForPlugin := res[l.PluginInstance]
+		if limitersForPlugin == nil {
+			limitersForPlugin = make(LimiterMap)
+		}
+		limitersForPlugin[l.Name] = l
+		res[l.PluginInstance] = limitersForPlugin
+	}
+	return res
+}
+
+// Merge returns a new map containing the limiters of both maps
+// (if both maps contain a limiter with the same name for a plugin, the limiter in other is used)
+func (l PluginLimiterMap) Merge(other PluginLimiterMap) PluginLimiterMap {
+	res := make(PluginLimiterMap, len(l))
+	for _, m := range []PluginLimiterMap{l, other} {
+		for plugin, limitersForPlugin := range m {
+			merged := res[plugin]
+			if merged == nil {
+				merged = make(LimiterMap, len(limitersForPlugin))
+			}
+			maps.Copy(merged, limitersForPlugin)
+			res[plugin] = merged
+		}
+	}
+	return res
+}
+
 type PluginMap map[string]*modconfig.Plugin
 
 func (p PluginMap) ToPluginLimiterMap() PluginLimiterMap {
@@ -0,0 +1,56 @@
+package connection
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func TestNewConnectionLimiterMap(t *testing.T) {
+	awsInstance := "aws"
+	gcpInstance := "gcp"
+	maxConcurrency := int64(2)
+	rateLimit := float32(10)
+	connections := map[string]*modconfig.Connection{
+		"aws_dev":  {Name: "aws_dev", Plugin: "aws", PluginInstance: &awsInstance, MaxConcurrency: &maxConcurrency},
+		"aws_prod": {Name: "aws_prod", Plugin: "aws", PluginInstance: &awsInstance, RateLimit: &rateLimit},
+		"aws_test": {Name: "aws_test", Plugin: "aws", PluginInstance: &awsInstance},
+		"gcp":      {Name: "gcp", Plugin: "gcp", PluginInstance: &gcpInstance, RateLimit: &rateLimit, Error: errors.New("invalid")},
+	}
+
+	limiters := NewConnectionLimiterMap(connections)
+	if len(limiters) != 1 || len(limiters["aws"]) != 2 {
+		t.Fatalf("expected 2 limiters for plugin instance aws, got %v", limiters)
+	}
+	for _, name := range []string{"connection_aws_dev", "connection_aws_prod"} {
+		if _, ok := limiters["aws"][name]; !ok {
+			t.Errorf("expected limiter %s", name)
+		}
+	}
+}
+
+func TestPluginLimiterMapMerge(t *testing.T) {
+	configLimiter := &modconfig.RateLimiter{Name: "config", Source: modconfig.LimiterSourceConfig}
+	connectionLimiter := &modconfig.RateLimiter{Name: "connection_aws_dev", Source: modconfig.LimiterSourceConnection}
+	gcpLimiter := &modconfig.RateLimiter{Name: "connection_gcp", Source: modconfig.LimiterSourceConnection}
+
+	userLimiters := PluginLimiterMap{"aws": {"config": configLimiter}}
+	connectionLimiters := PluginLimiterMap{"aws": {"connection_aws_dev": connectionLimiter}, "gcp": {"connection_gcp": gcpLimiter}}
+
+	merged := userLimiters.Merge(connectionLimiters)
+	if len(merged["aws"]) != 2 || merged["aws"]["config"] != configLimiter || merged["aws"]["connection_aws_dev"] != connectionLimiter {
+		t.Errorf("expected config and connection limiters for aws, got %v", merged["aws"])
+	}
+	if len(merged["gcp"]) != 1 {
+		t.Errorf("expected connection limiter for gcp, got %v", merged["gcp"])
+	}
+	// the maps being merged are not modified
+	if len(userLimiters["aws"]) != 1 {
+		t.Errorf("expected the user limiters not to be modified, got %v", userLimiters["aws"])
+	}
+	// merging a nil map returns a copy
+	if merged := userLimiters.Merge(nil); !merged.Equals(userLimiters) {
+		t.Errorf("expected merging nil to return the same limiters, got %v", merged)
+	}
+}
@@ -0,0 +1,62 @@
+package connection
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"golang.org/x/exp/maps"
+)
+
+// catalogStatsQuery returns the number of foreign tables and the approximate catalog size of each of the given schemas
+// the catalog size is the size of the pg_class, pg_attribute and pg_description rows describing the schema's relations
+// (this is approximate - it excludes index entries and tuple overhead - but is cheap to compute)
+const catalogStatsQuery = `select
+  n.nspname,
+  (select count(*) from pg_catalog.pg_class c where c.relnamespace = n.oid and c.relkind = 'f'),
+  coalesce((select sum(pg_catalog.pg_column_size(c.*)) from pg_catalog.pg_class c where c.relnamespace = n.oid), 0)
+  + coalesce((select sum(pg_catalog.pg_column_size(a.*)) from pg_catalog.pg_attribute a join pg_catalog.pg_class c on a.attrelid = c.oid where c.relnamespace = n.oid), 0)
+  + coalesce((select sum(pg_catalog.pg_column_size(d.*)) from pg_catalog.pg_description d join pg_catalog.pg_class c on d.objoid = c.oid where c.relnamespace = n.oid), 0)
+from pg_catalog.pg_namespace n
+where n.nspname = any($1)`
+
+// rowQuerier is satisfied by pgxpool.Pool, pgx.Conn and pgx.Tx
+type rowQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// addCatalogStats adds the catalog usage of each connection schema to the result
+// the stats are informational only, so failing to load them is a warning rather than an error
+func (s *refreshConnectionState) addCatalogStats(ctx context.Context) {
+	if s.res.Error != nil {
+		return
+	}
+	stats, err := loadCatalogStats(ctx, s.getPool(), maps.Keys(s.connectionUpdates.FinalConnectionState))
+	if err != nil {
+		logWarn(ctx, "failed to load connection catalog stats: %s", err.Error())
+		s.res.AddWarning("failed to load connection catalog stats: " + err.Error())
+		return
+	}
+	s.res.CatalogStats = stats
+}
+
+// loadCatalogStats returns a map of connection name to the catalog usage of its schema
+// connections which do not have a schema are not included
+func loadCatalogStats(ctx context.Context, querier rowQuerier, connectionNames []string) (map[string]*steampipeconfig.ConnectionCatalogStats, error) {
+	rows, err := querier.Query(ctx, catalogStatsQuery, connectionNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]*steampipeconfig.ConnectionCatalogStats, len(connectionNames))
+	for rows.Next() {
+		var connectionName string
+		stats := &steampipeconfig.ConnectionCatalogStats{}
+		if err := rows.Scan(&connectionName, &stats.ForeignTables, &stats.CatalogBytes); err != nil {
+			return nil, err
+		}
+		res[connectionName] = stats
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,76 @@
+package connection
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// fakeCatalog is a rowQuerier which answers the catalog stats query from a seeded map of schema name to stats
+type fakeCatalog struct {
+	schemas map[string]*steampipeconfig.ConnectionCatalogStats
+}
+
+func (c *fakeCatalog) Query(_ context.Context, _ string, args ...any) (pgx.Rows, error) {
+	rows := &fakeCatalogRows{index: -1}
+	for _, name := range args[0].([]string) {
+		if stats, ok := c.schemas[name]; ok {
+			rows.values = append(rows.values, []any{name, stats.ForeignTables, stats.CatalogBytes})
+		}
+	}
+	return rows, nil
+}
+
+// fakeCatalogRows iterates the rows returned by a fakeCatalog (all other methods are unimplemented)
+type fakeCatalogRows struct {
+	pgx.Rows
+	values [][]any
+	index  int
+}
+
+func (r *fakeCatalogRows) Next() bool {
+	r.index++
+	return r.index < len(r.values)
+}
+
+func (r *fakeCatalogRows) Scan(dest ...any) error {
+	row := r.values[r.index]
+	*dest[0].(*string) = row[0].(string)
+	*dest[1].(*int) = row[1].(int)
+	*dest[2].(*int64) = row[2].(int64)
+	return nil
+}
+
+func (r *fakeCatalogRows) Err() error { return nil }
+
+func (r *fakeCatalogRows) Close() {}
+
+func TestLoadCatalogStats(t *testing.T) {
+	catalog := &fakeCatalog{schemas: map[string]*steampipeconfig.ConnectionCatalogStats{
+		"aws":    {ForeignTables: 450, CatalogBytes: 5_200_000},
+		"gcp":    {ForeignTables: 120, CatalogBytes: 1_100_000},
+		"public": {ForeignTables: 0, CatalogBytes: 800},
+	}}
+
+	// 'azure' has no schema so is not reported, and schemas which are not connections are not reported
+	stats, err := loadCatalogStats(context.Background(), catalog, []string{"aws", "gcp", "azure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]*steampipeconfig.ConnectionCatalogStats{
+		"aws": {ForeignTables: 450, CatalogBytes: 5_200_000},
+		"gcp": {ForeignTables: 120, CatalogBytes: 1_100_000},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected %v, got %v", expected, stats)
+	}
+
+	// the stats are included in the refresh summary
+	res := &steampipeconfig.RefreshConnectionResult{CatalogStats: stats}
+	if summary := res.Summary(); !reflect.DeepEqual(summary.CatalogStats, expected) {
+		t.Errorf("expected the summary to include the catalog stats, got %v", summary.CatalogStats)
+	}
+}
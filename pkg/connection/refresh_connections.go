@@ -2,12 +2,21 @@ package connection
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/spf13/viper"
 	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_local"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/ociinstaller"
+	"github.com/turbot/steampipe/pkg/statushooks"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/turbot/steampipe/pkg/utils"
 )
 
 // only allow one execution of refresh connections
@@ -16,44 +25,79 @@ var executeLock sync.Mutex
 // only allow one queued execution
 var queueLock sync.Mutex
 
-func RefreshConnections(ctx context.Context, pluginManager pluginManager, forceUpdateConnectionNames ...string) (res *steampipeconfig.RefreshConnectionResult) {
-	log.Println("[INFO] RefreshConnections start")
-	defer log.Println("[INFO] RefreshConnections end")
+// RefreshConnections refreshes connections using the given options (see LoadRefreshOptions),
+// force updating the given connections
+// if the context does not have a refresh ID (see WithRefreshID), one is generated - this is returned in the result
+func RefreshConnections(ctx context.Context, pluginManager pluginManager, opts *RefreshOptions, forceUpdateConnectionNames ...string) (res *steampipeconfig.RefreshConnectionResult) {
+	ctx, refreshID := ensureRefreshID(ctx)
+	// tag the database sessions used by the refresh with the refresh ID
+	ctx = db_local.WithApplicationName(ctx, db_local.RefreshConnectionAppName(refreshID))
+
+	logInfo(ctx, "RefreshConnections start")
+	defer logInfo(ctx, "RefreshConnections end")
 
 	// TODO KAI if we, for example, access a nil map, this does not seem to catch it and startup hangs
 	defer func() {
 		if r := recover(); r != nil {
 			res = steampipeconfig.NewErrorRefreshConnectionResult(helpers.ToError(r))
 		}
+		res.RefreshID = refreshID
 	}()
 
 	t := time.Now()
-	defer log.Printf("[INFO] refreshConnections completion time (%fs)", time.Since(t).Seconds())
+	defer func() {
+		logInfo(ctx, "refreshConnections completion time (%fs)", time.Since(t).Seconds())
+	}()
+
+	// if refresh is disabled (e.g. during database maintenance), do not update any connection schemas
+	if opts.NoRefresh {
+		return refreshSearchPathOnly(ctx, func(ctx context.Context) error {
+			_, _, err := db_local.SetUserSearchPath(ctx, pluginManager.Pool())
+			return err
+		})
+	}
 
 	// first grab the queue lock
 	if !queueLock.TryLock() {
 		// someone has it - they will execute so we have nothing to do
-		log.Printf("[INFO] another execution is already queued - returning")
+		logInfo(ctx, "another execution is already queued - returning")
 		return &steampipeconfig.RefreshConnectionResult{}
 	}
 
-	log.Printf("[INFO] acquired refreshQueueLock, try to acquire refreshExecuteLock")
+	logInfo(ctx, "acquired refreshQueueLock, try to acquire refreshExecuteLock")
 
 	// so we have the queue lock, now wait on the execute lock
 	executeLock.Lock()
 	defer func() {
 		executeLock.Unlock()
-		log.Printf("[INFO] released refreshExecuteLock")
+		logInfo(ctx, "released refreshExecuteLock")
 	}()
 
 	// we have the execute-lock, release the queue-lock so someone else can queue
 	queueLock.Unlock()
-	log.Printf("[INFO] acquired refreshExecuteLock, released refreshQueueLock")
+	logInfo(ctx, "acquired refreshExecuteLock, released refreshQueueLock")
 
 	// now refresh connections
 
+	// in quiet mode, do not report refresh progress
+	ctx = refreshStatusContext(ctx)
+
+	// if a refresh timeout is configured, apply it to the whole refresh
+	if timeout := opts.refreshTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// only allow one refresh at a time across all processes using the database
+	releaseRefreshLock, err := acquireRefreshLock(ctx, pluginManager.Pool(), opts.refreshLockTimeout())
+	if err != nil {
+		return steampipeconfig.NewErrorRefreshConnectionResult(err)
+	}
+	defer releaseRefreshLock()
+
 	// package up all necessary data into a state object
-	state, err := newRefreshConnectionState(ctx, pluginManager, forceUpdateConnectionNames)
+	state, err := newRefreshConnectionState(ctx, pluginManager, opts, forceUpdateConnectionNames)
 	if err != nil {
 		return steampipeconfig.NewErrorRefreshConnectionResult(err)
 	}
@@ -63,3 +107,80 @@ func RefreshConnections(ctx context.Context, pluginManager pluginManager, forceU
 
 	return state.res
 }
+
+// refreshSearchPathOnly is used in place of a refresh when refresh is disabled
+// no connection schemas are updated or deleted - the search path is set for the existing schemas, using setSearchPath
+// as the database may be unavailable for writes, failing to set the search path is a warning rather than an error
+func refreshSearchPathOnly(ctx context.Context, setSearchPath func(context.Context) error) *steampipeconfig.RefreshConnectionResult {
+	logWarn(ctx, "connection refresh is disabled - using the existing connection schemas")
+
+	res := &steampipeconfig.RefreshConnectionResult{}
+	res.AddWarning(fmt.Sprintf("connection refresh is disabled (--%s) - connection schemas may be out of date", constants.ArgNoRefresh))
+	if err := setSearchPath(ctx); err != nil {
+		res.AddWarning(fmt.Sprintf("failed to set the search path: %s", err.Error()))
+	}
+	return res
+}
+
+// refreshStatusContext returns the context to use for a refresh
+// if quiet mode is enabled, status hooks are disabled - errors and warnings are returned in the refresh result
+// so are still reported
+func refreshStatusContext(ctx context.Context) context.Context {
+	if viper.GetBool(constants.ArgQuiet) {
+		return statushooks.DisableStatusHooks(ctx)
+	}
+	return ctx
+}
+
+// RefreshConnectionsForPlugins refreshes connections, forcing an update of all connections which use
+// any of the given plugins
+// a warning is sent for any plugin which is not used by any connection
+// NOTE: the refresh is performed even if no connections use the plugins, so the connection state is reconciled
+func RefreshConnectionsForPlugins(ctx context.Context, pluginManager pluginManager, opts *RefreshOptions, pluginNames ...string) *steampipeconfig.RefreshConnectionResult {
+	// tag the refresh before sending any warnings, so they have the same refresh ID as the result
+	ctx, _ = ensureRefreshID(ctx)
+
+	connectionNames := forceUpdateConnectionsForPlugins(ctx, pluginManager, pluginNames)
+	return RefreshConnections(ctx, pluginManager, opts, connectionNames...)
+}
+
+// forceUpdateConnectionsForPlugins returns the connections to force update for the given plugins
+// a warning notification is sent for any plugin which is not used by any connection
+func forceUpdateConnectionsForPlugins(ctx context.Context, pluginManager pluginManager, pluginNames []string) []string {
+	connectionNames, unmatchedPlugins := connectionsForPlugins(steampipeconfig.GlobalConfig.Connections, pluginNames)
+
+	if len(unmatchedPlugins) > 0 {
+		warnings := &error_helpers.ErrorAndWarnings{}
+		for _, pluginName := range unmatchedPlugins {
+			warnings.AddWarning(fmt.Sprintf("no connections found for plugin '%s'", pluginName))
+		}
+		pluginManager.SendPostgresErrorsAndWarningsNotification(ctx, warnings)
+	}
+	if len(connectionNames) > 0 {
+		logInfo(ctx, "RefreshConnectionsForPlugins forcing update of %d %s: %s", len(connectionNames), utils.Pluralize("connection", len(connectionNames)), strings.Join(connectionNames, ","))
+	}
+	return connectionNames
+}
+
+// connectionsForPlugins returns the names of all connections which use any of the given plugins,
+// and the plugins which are not used by any connection
+// plugins may be specified either by the name used in the connection config or by image ref
+func connectionsForPlugins(connections map[string]*modconfig.Connection, pluginNames []string) (connectionNames []string, unmatchedPlugins []string) {
+	for _, pluginName := range pluginNames {
+		pluginFQN := ociinstaller.NewSteampipeImageRef(pluginName).DisplayImageRef()
+		matched := false
+		for _, name := range utils.SortedMapKeys(connections) {
+			c := connections[name]
+			if c.PluginAlias == pluginName || c.Plugin == pluginName || c.Plugin == pluginFQN {
+				matched = true
+				if !helpers.StringSliceContains(connectionNames, name) {
+					connectionNames = append(connectionNames, name)
+				}
+			}
+		}
+		if !matched {
+			unmatchedPlugins = append(unmatchedPlugins, pluginName)
+		}
+	}
+	return connectionNames, unmatchedPlugins
+}
@@ -0,0 +1,113 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// cloneSchemaBatches clones the schemas of the given connections from their exemplar schemas, in batches of up to
+// batchSize connections - the clone sql of each batch is executed as a single script, so each batch takes a single
+// round trip (and a single transaction) rather than one per connection
+//
+// the connections which have not been cloned are returned, to be updated individually. These are:
+// - connections which cannot be cloned
+// - connections which depend on another connection being updated (these must be updated in dependency order)
+// - the connections of any batch which failed (a batch is all or nothing, so the failure may be due to a single
+// connection - updating them individually isolates the failure, and falls back to importing the schema if required)
+func (s *refreshConnectionState) cloneSchemaBatches(ctx context.Context, updates map[string]*steampipeconfig.ConnectionState, batchSize int) map[string]*steampipeconfig.ConnectionState {
+	remaining := make(map[string]*steampipeconfig.ConnectionState)
+	cloneSql := make(map[string]string)
+	for connectionName, connectionState := range updates {
+		if dependsOnUpdate(connectionName, s.connectionDependencies, updates) {
+			remaining[connectionName] = connectionState
+			continue
+		}
+		s.exemplarSchemaMapMut.Lock()
+		sql, _, isClone := s.getUpdateQuery(ctx, connectionState, s.opts.CloneSchema)
+		s.exemplarSchemaMapMut.Unlock()
+		if !isClone {
+			remaining[connectionName] = connectionState
+			continue
+		}
+		cloneSql[connectionName] = sql
+	}
+
+	for _, batch := range cloneBatches(utils.SortedMapKeys(cloneSql), batchSize) {
+		if ctx.Err() != nil {
+			// leave the remaining connections to be failed by the individual updates
+			for _, connectionName := range batch {
+				remaining[connectionName] = updates[connectionName]
+			}
+			continue
+		}
+		if err := s.executeCloneBatch(ctx, batch, cloneSql); err != nil {
+			logWarn(ctx, "failed to clone the schemas of %d connections in a batch: %s - cloning them individually", len(batch), err.Error())
+			for _, connectionName := range batch {
+				remaining[connectionName] = updates[connectionName]
+			}
+		}
+	}
+	return remaining
+}
+
+// executeCloneBatch executes the clone sql for a batch of connections, and sets them all to ready, in one transaction
+func (s *refreshConnectionState) executeCloneBatch(ctx context.Context, connectionNames []string, cloneSql map[string]string) (err error) {
+	ctx, span := startRefreshSpan(ctx, "cloneSchemaBatch", attributeConnectionCount.Int(len(connectionNames)))
+	defer func() { endRefreshSpan(span, err) }()
+	logInfo(ctx, "cloning schemas for %d connections in a batch", len(connectionNames))
+
+	var sb strings.Builder
+	for _, connectionName := range connectionNames {
+		sb.WriteString(cloneSql[connectionName])
+		sb.WriteString("\n")
+	}
+	onComplete := func(tx sqlExecutor) error {
+		for _, connectionName := range connectionNames {
+			if err := s.tableUpdater.onConnectionReady(ctx, tx, connectionName); err != nil {
+				return sperr.WrapWithMessage(err, "failed to update connection state table")
+			}
+		}
+		return nil
+	}
+
+	startTime := time.Now()
+	if err := s.executor.CloneSchemas(ctx, connectionNames, sb.String(), onComplete); err != nil {
+		return err
+	}
+	// apportion the batch duration between the connections, so the progress estimates remain per connection
+	duration := time.Since(startTime) / time.Duration(len(connectionNames))
+	for range connectionNames {
+		s.updateProgress.onUpdateComplete(ctx, true, duration)
+	}
+
+	s.resMut.Lock()
+	s.res.ClonedConnections = append(s.res.ClonedConnections, connectionNames...)
+	s.resMut.Unlock()
+	return nil
+}
+
+// dependsOnUpdate returns whether the given connection depends on any of the connections being updated
+func dependsOnUpdate(connectionName string, dependencies map[string][]string, updates map[string]*steampipeconfig.ConnectionState) bool {
+	for _, dependency := range dependencies[connectionName] {
+		if _, ok := updates[dependency]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneBatches splits the connection names into batches of at most batchSize connections
+func cloneBatches(connectionNames []string, batchSize int) [][]string {
+	var batches [][]string
+	for len(connectionNames) > 0 {
+		n := min(batchSize, len(connectionNames))
+		batches = append(batches, connectionNames[:n])
+		connectionNames = connectionNames[n:]
+	}
+	return batches
+}
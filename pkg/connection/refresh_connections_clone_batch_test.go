@@ -0,0 +1,84 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+func TestCloneSchemaBatches(t *testing.T) {
+	s := newUpdateTestState()
+	s.exemplarSchemaMap = map[string]string{testPlugin: "a"}
+	// c6 depends on c5, so must be updated after it
+	s.connectionDependencies = map[string][]string{"c6": {"c5"}}
+	updates := make(map[string]*steampipeconfig.ConnectionState)
+	for i := 0; i < 7; i++ {
+		connectionState := newTestConnectionState(fmt.Sprintf("c%d", i), constants.ConnectionStateUpdating)
+		updates[connectionState.ConnectionName] = connectionState
+		s.connectionUpdates.FinalConnectionState[connectionState.ConnectionName] = connectionState
+	}
+	executor := &fakeConnectionExecutor{failures: map[string]error{"c4": errors.New("clone failed")}}
+	s.executor = executor
+
+	remaining := s.cloneSchemaBatches(context.Background(), updates, 3)
+
+	expectedOperations := []string{"clone c0,c1,c2", "clone c3,c4,c5"}
+	if !reflect.DeepEqual(executor.operations, expectedOperations) {
+		t.Errorf("expected operations %v, got %v", expectedOperations, executor.operations)
+	}
+	// the connections of the failed batch, and the dependent connection, are left to be updated individually
+	expectedRemaining := []string{"c3", "c4", "c5", "c6"}
+	if actual := utils.SortedMapKeys(remaining); !reflect.DeepEqual(actual, expectedRemaining) {
+		t.Errorf("expected remaining updates %v, got %v", expectedRemaining, actual)
+	}
+	if !reflect.DeepEqual(s.res.ClonedConnections, []string{"c0", "c1", "c2"}) {
+		t.Errorf("expected c0, c1 and c2 to be cloned, got %v", s.res.ClonedConnections)
+	}
+	// each connection of the successful batch is set to ready (and an event sent) in the batch transaction
+	events := 0
+	for _, statement := range executor.statements {
+		if strings.Contains(statement, "pg_notify") {
+			events++
+		}
+	}
+	if events != 3 {
+		t.Errorf("expected an event for each cloned connection, got %d", events)
+	}
+}
+
+func TestCloneSchemaBatchesWithoutExemplar(t *testing.T) {
+	s := newUpdateTestState()
+	s.exemplarSchemaMap = map[string]string{}
+	executor := &fakeConnectionExecutor{}
+	s.executor = executor
+
+	remaining := s.cloneSchemaBatches(context.Background(), s.connectionUpdates.Update, 10)
+	if len(executor.operations) != 0 || len(remaining) != 1 {
+		t.Errorf("expected a connection with no exemplar schema to be updated individually, got operations %v", executor.operations)
+	}
+}
+
+func TestCloneBatches(t *testing.T) {
+	testCases := map[string]struct {
+		names     []string
+		batchSize int
+		expected  [][]string
+	}{
+		"empty":        {nil, 2, nil},
+		"single batch": {[]string{"a", "b"}, 5, [][]string{{"a", "b"}}},
+		"exact":        {[]string{"a", "b", "c", "d"}, 2, [][]string{{"a", "b"}, {"c", "d"}}},
+		"partial last": {[]string{"a", "b", "c"}, 2, [][]string{{"a", "b"}, {"c"}}},
+	}
+	for name, test := range testCases {
+		if actual := cloneBatches(test.names, test.batchSize); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"golang.org/x/exp/maps"
+)
+
+// loadConnectionDependencies loads the declared dependencies between all configured connections
+// returns an error if the dependencies contain a cycle
+func (s *refreshConnectionState) loadConnectionDependencies() error {
+	connections := steampipeconfig.GlobalConfig.Connections
+	dependencies, warnings := connectionDependencies(connections)
+	for _, warning := range warnings {
+		s.res.AddWarning(warning)
+	}
+	// validate the dependencies of all connections, not just those being updated
+	if _, err := dependencyLevels(maps.Keys(connections), dependencies); err != nil {
+		return err
+	}
+	s.connectionDependencies = dependencies
+	return nil
+}
+
+// connectionDependencies builds a map of connection name to the names of the connections it depends on,
+// as declared by the 'depends_on' connection property
+// dependencies on connections which do not exist are ignored (with a warning)
+func connectionDependencies(connections map[string]*modconfig.Connection) (map[string][]string, []string) {
+	dependencies := make(map[string][]string)
+	var warnings []string
+	for name, connection := range connections {
+		for _, dependency := range connection.DependsOn {
+			if _, ok := connections[dependency]; !ok {
+				warnings = append(warnings, fmt.Sprintf("connection '%s' depends on connection '%s' which does not exist", name, dependency))
+				continue
+			}
+			dependencies[name] = append(dependencies[name], dependency)
+		}
+	}
+	sort.Strings(warnings)
+	return dependencies, warnings
+}
+
+// dependencyLevels sorts the given connections into levels, such that each connection only depends on
+// connections in earlier levels - the connections within a level may therefore be updated in parallel
+// dependencies on connections which are not in connectionNames are ignored
+// if the dependencies contain a cycle, an error is returned
+func dependencyLevels(connectionNames []string, dependencies map[string][]string) ([][]string, error) {
+	remaining := make(map[string]bool, len(connectionNames))
+	for _, name := range connectionNames {
+		remaining[name] = true
+	}
+
+	var levels [][]string
+	for len(remaining) > 0 {
+		// find all connections whose dependencies have been satisfied
+		var level []string
+		for name := range remaining {
+			ready := true
+			for _, dependency := range dependencies[name] {
+				if remaining[dependency] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, name)
+			}
+		}
+		if len(level) == 0 {
+			return nil, dependencyCycleError(remaining, dependencies)
+		}
+		sort.Strings(level)
+		for _, name := range level {
+			delete(remaining, name)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// dependencyCycleError builds an error describing a dependency cycle between the given connections,
+// all of which have unsatisfied dependencies
+func dependencyCycleError(remaining map[string]bool, dependencies map[string][]string) error {
+	var names []string
+	for name := range remaining {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// follow unsatisfied dependencies from the first connection until we revisit a connection
+	// - as every connection has an unsatisfied dependency, this must find a cycle
+	visited := make(map[string]int)
+	var path []string
+	for current := names[0]; ; {
+		if idx, ok := visited[current]; ok {
+			cycle := append(path[idx:], current)
+			return fmt.Errorf("connection dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+		visited[current] = len(path)
+		path = append(path, current)
+		next := ""
+		for _, dependency := range dependencies[current] {
+			if remaining[dependency] {
+				next = dependency
+				break
+			}
+		}
+		current = next
+	}
+}
+
+// addDependencies moves any (transitive) dependencies of the connections in updates from otherUpdates into updates
+// this ensures a connection is not updated before the connections it depends on
+func addDependencies(ctx context.Context, updates, otherUpdates map[string]*steampipeconfig.ConnectionState, dependencies map[string][]string) {
+	var toCheck []string
+	for name := range updates {
+		toCheck = append(toCheck, name)
+	}
+	for len(toCheck) > 0 {
+		name := toCheck[0]
+		toCheck = toCheck[1:]
+		for _, dependency := range dependencies[name] {
+			if connectionState, ok := otherUpdates[dependency]; ok {
+				logInfo(ctx, "connection '%s' depends on '%s' - updating '%s' first", name, dependency, dependency)
+				updates[dependency] = connectionState
+				delete(otherUpdates, dependency)
+				toCheck = append(toCheck, dependency)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package connection
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+type dependencyLevelsTest struct {
+	connections    []string
+	dependencies   map[string][]string
+	expectedLevels [][]string
+	expectedError  string
+}
+
+var testCasesDependencyLevels = map[string]dependencyLevelsTest{
+	"no dependencies": {
+		connections:    []string{"c", "a", "b"},
+		expectedLevels: [][]string{{"a", "b", "c"}},
+	},
+	"chain": {
+		connections:    []string{"a", "b", "c"},
+		dependencies:   map[string][]string{"c": {"b"}, "b": {"a"}},
+		expectedLevels: [][]string{{"a"}, {"b"}, {"c"}},
+	},
+	"diamond": {
+		connections:    []string{"a", "b", "c", "d"},
+		dependencies:   map[string][]string{"b": {"a"}, "c": {"a"}, "d": {"b", "c"}},
+		expectedLevels: [][]string{{"a"}, {"b", "c"}, {"d"}},
+	},
+	"dependency not being updated": {
+		connections:    []string{"b", "c"},
+		dependencies:   map[string][]string{"b": {"a"}, "c": {"b"}},
+		expectedLevels: [][]string{{"b"}, {"c"}},
+	},
+	"cycle": {
+		connections:   []string{"a", "b", "c", "d"},
+		dependencies:  map[string][]string{"a": {"c"}, "b": {"a"}, "c": {"b"}, "d": {"a"}},
+		expectedError: "connection dependency cycle detected: a -> c -> b -> a",
+	},
+	"self dependency": {
+		connections:   []string{"a"},
+		dependencies:  map[string][]string{"a": {"a"}},
+		expectedError: "connection dependency cycle detected: a -> a",
+	},
+}
+
+func TestDependencyLevels(t *testing.T) {
+	for name, test := range testCasesDependencyLevels {
+		levels, err := dependencyLevels(test.connections, test.dependencies)
+		if test.expectedError != "" {
+			if err == nil || err.Error() != test.expectedError {
+				t.Errorf("Test: '%s' FAILED : expected error '%s', got %v", name, test.expectedError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(levels, test.expectedLevels) {
+			t.Errorf("Test: '%s' FAILED : expected levels %v, got %v", name, test.expectedLevels, levels)
+		}
+	}
+}
+
+func TestConnectionDependencies(t *testing.T) {
+	connections := map[string]*modconfig.Connection{
+		"a": {Name: "a"},
+		"b": {Name: "b", DependsOn: []string{"a", "missing"}},
+	}
+	dependencies, warnings := connectionDependencies(connections)
+	if !reflect.DeepEqual(dependencies, map[string][]string{"b": {"a"}}) {
+		t.Errorf("unexpected dependencies %v", dependencies)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "'missing' which does not exist") {
+		t.Errorf("expected a warning for the missing dependency, got %v", warnings)
+	}
+}
+
+func TestInitialUpdatesIncludeDependencies(t *testing.T) {
+	// 'a' is first in the search path, but depends on 'b', which depends on 'c'
+	updates := steampipeconfig.ConnectionStateMap{
+		"a": newTestConnectionState("a", constants.ConnectionStatePending),
+		"b": newTestConnectionState("b", constants.ConnectionStatePending),
+		"c": newTestConnectionState("c", constants.ConnectionStatePending),
+		"d": newTestConnectionState("d", constants.ConnectionStatePending),
+	}
+	s := &refreshConnectionState{
+		opts: testRefreshOptions(),
+		connectionUpdates: &steampipeconfig.ConnectionUpdates{
+			Update:               updates,
+			FinalConnectionState: updates,
+		},
+		searchPath:             []string{"a", "b", "c", "d"},
+		connectionDependencies: map[string][]string{"a": {"b"}, "b": {"c"}},
+	}
+
+	initialUpdates, remainingUpdates, _, _ := s.getInitialAndRemainingUpdates(context.Background())
+	for _, name := range []string{"a", "b", "c"} {
+		if _, ok := initialUpdates[name]; !ok {
+			t.Errorf("expected '%s' to be an initial update", name)
+		}
+	}
+	if _, ok := remainingUpdates["d"]; !ok || len(remainingUpdates) != 1 {
+		t.Errorf("expected only 'd' to be a remaining update, got %v", remainingUpdates)
+	}
+}
@@ -0,0 +1,101 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// refreshLockKey is the (arbitrary, well-known) key of the Postgres advisory lock which is held for the duration
+// of a refresh - this ensures only one refresh runs at a time, across all processes using the database
+const refreshLockKey int64 = 7132545031
+
+// the interval at which to retry acquiring the refresh lock while another refresh is in progress
+const refreshLockRetryInterval = 100 * time.Millisecond
+
+// the time allowed to close the refresh lock connection
+const refreshLockReleaseTimeout = 5 * time.Second
+
+var errRefreshInProgress = errors.New("connection refresh already in progress")
+
+// advisoryLock is a lock which may be acquired without blocking
+type advisoryLock interface {
+	tryLock(ctx context.Context) (bool, error)
+}
+
+// acquireRefreshLock acquires the refresh advisory lock using a dedicated database session, waiting up to timeout
+// for a refresh running in another process to complete
+// returns a function to release the lock
+func acquireRefreshLock(ctx context.Context, pool *pgxpool.Pool, timeout time.Duration) (release func(), err error) {
+	poolConn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// advisory locks are held by the session, so take the connection out of the pool
+	// - the lock is released by closing the connection
+	// (this also ensures the pool may be recreated while the lock is held)
+	conn := poolConn.Hijack()
+	closeConn := func() {
+		// the refresh context may have been cancelled - use a new context to close the connection
+		closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshLockReleaseTimeout)
+		defer cancel()
+		if err := conn.Close(closeCtx); err != nil {
+			logWarn(ctx, "failed to close refresh lock connection: %s", err.Error())
+		}
+	}
+
+	if err := acquireAdvisoryLock(ctx, &pgAdvisoryLock{conn: conn, key: refreshLockKey}, timeout); err != nil {
+		closeConn()
+		return nil, err
+	}
+	logInfo(ctx, "acquired refresh advisory lock")
+
+	release = func() {
+		closeConn()
+		logInfo(ctx, "released refresh advisory lock")
+	}
+	return release, nil
+}
+
+// acquireAdvisoryLock tries to acquire the lock, retrying until the timeout has elapsed
+// if the lock is still held by someone else, errRefreshInProgress is returned
+func acquireAdvisoryLock(ctx context.Context, lock advisoryLock, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for attempt := 0; ; attempt++ {
+		acquired, err := lock.tryLock(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to acquire refresh lock: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return errRefreshInProgress
+		}
+		if attempt == 0 {
+			logInfo(ctx, "another connection refresh is in progress - waiting up to %s", timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(refreshLockRetryInterval):
+		}
+	}
+}
+
+// pgAdvisoryLock is a session level Postgres advisory lock
+type pgAdvisoryLock struct {
+	conn *pgx.Conn
+	key  int64
+}
+
+func (l *pgAdvisoryLock) tryLock(ctx context.Context) (bool, error) {
+	var acquired bool
+	err := l.conn.QueryRow(ctx, "select pg_try_advisory_lock($1)", l.key).Scan(&acquired)
+	return acquired, err
+}
@@ -0,0 +1,74 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// heldLock is an advisory lock which is held by someone else until it has been tried the given number of times
+type heldLock struct {
+	heldFor  int
+	attempts int
+}
+
+func (l *heldLock) tryLock(context.Context) (bool, error) {
+	l.attempts++
+	return l.attempts > l.heldFor, nil
+}
+
+type acquireAdvisoryLockTest struct {
+	heldFor     int
+	timeout     time.Duration
+	expectError error
+	// the minimum time the caller should wait
+	minWait time.Duration
+}
+
+var testCasesAcquireAdvisoryLock = map[string]acquireAdvisoryLockTest{
+	"not held": {
+		heldFor: 0,
+		timeout: 0,
+	},
+	"held - no wait": {
+		heldFor:     1,
+		timeout:     0,
+		expectError: errRefreshInProgress,
+	},
+	"held - released while waiting": {
+		heldFor: 2,
+		timeout: time.Second,
+		minWait: 2 * refreshLockRetryInterval,
+	},
+	"held - wait times out": {
+		heldFor:     100,
+		timeout:     3 * refreshLockRetryInterval / 2,
+		expectError: errRefreshInProgress,
+		minWait:     3 * refreshLockRetryInterval / 2,
+	},
+}
+
+func TestAcquireAdvisoryLock(t *testing.T) {
+	for name, test := range testCasesAcquireAdvisoryLock {
+		lock := &heldLock{heldFor: test.heldFor}
+		start := time.Now()
+		err := acquireAdvisoryLock(context.Background(), lock, test.timeout)
+		if !errors.Is(err, test.expectError) {
+			t.Errorf("Test: '%s' FAILED : expected error %v, got %v", name, test.expectError, err)
+			continue
+		}
+		// the caller must have waited for the lock to be released, or for the timeout to elapse
+		if elapsed := time.Since(start); elapsed < test.minWait {
+			t.Errorf("Test: '%s' FAILED : expected the caller to wait, returned after %s", name, elapsed)
+		}
+	}
+}
+
+func TestAcquireAdvisoryLockCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := acquireAdvisoryLock(ctx, &heldLock{heldFor: 100}, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled wait to return context.Canceled, got %v", err)
+	}
+}
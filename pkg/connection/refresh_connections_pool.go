@@ -0,0 +1,86 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// the maximum number of times the connection pool will be recreated during a single refresh
+// this avoids thrashing if the database is unavailable
+const maxPoolRecreations = 1
+
+// isPoolConnectionError returns whether the error indicates the pool has lost its connection to the database
+// (as opposed to an error executing a statement)
+func isPoolConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// class 08 - connection exception
+		// 57P01 admin_shutdown, 57P02 crash_shutdown, 57P03 cannot_connect_now
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	if errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, net.ErrClosed) {
+		return true
+	}
+	// pgxpool returns this (unexported) error if the pool has been closed
+	return strings.Contains(err.Error(), "closed pool")
+}
+
+func (s *refreshConnectionState) getPool() *pgxpool.Pool {
+	s.poolMut.RLock()
+	defer s.poolMut.RUnlock()
+	return s.pool
+}
+
+// executeWithPoolRecovery executes the given operation - if it fails because the pool has lost its connection
+// to the database, the pool is recreated (at most maxPoolRecreations times per refresh) and the operation is retried
+func (s *refreshConnectionState) executeWithPoolRecovery(ctx context.Context, operation func() error) error {
+	err := operation()
+	if !isPoolConnectionError(err) {
+		return err
+	}
+
+	if recreateErr := s.recreatePool(ctx, err); recreateErr != nil {
+		return err
+	}
+	return operation()
+}
+
+// recreatePool recreates the connection pool, unless it has already been recreated maxPoolRecreations times
+// if another goroutine has already recreated the pool since the given error occurred, this is a no-op
+func (s *refreshConnectionState) recreatePool(ctx context.Context, cause error) error {
+	s.poolMut.Lock()
+	defer s.poolMut.Unlock()
+
+	if s.poolRecreations >= maxPoolRecreations {
+		logWarn(ctx, "connection pool failed (%s) - pool has already been recreated %d times, giving up", cause.Error(), s.poolRecreations)
+		return cause
+	}
+	logWarn(ctx, "connection pool failed (%s) - recreating pool", cause.Error())
+
+	pool, err := s.poolFactory(ctx)
+	if err != nil {
+		logWarn(ctx, "failed to recreate connection pool: %s", err.Error())
+		return err
+	}
+	s.pool = pool
+	s.poolRecreations++
+	return nil
+}
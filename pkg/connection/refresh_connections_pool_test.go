@@ -0,0 +1,70 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestIsPoolConnectionError(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"nil":                {err: nil, expected: false},
+		"closed pool":        {err: errors.New("closed pool"), expected: true},
+		"unexpected eof":     {err: fmt.Errorf("failed to begin: %w", io.ErrUnexpectedEOF), expected: true},
+		"admin shutdown":     {err: &pgconn.PgError{Code: "57P01"}, expected: true},
+		"connection failure": {err: &pgconn.PgError{Code: "08006"}, expected: true},
+		"syntax error":       {err: &pgconn.PgError{Code: "42601"}, expected: false},
+		"other error":        {err: errors.New("plugin failed"), expected: false},
+	}
+	for name, test := range testCases {
+		if actual := isPoolConnectionError(test.err); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestExecuteWithPoolRecovery(t *testing.T) {
+	recreatedPool := &pgxpool.Pool{}
+	var recreations int
+	s := &refreshConnectionState{
+		opts: testRefreshOptions(),
+		poolFactory: func(context.Context) (*pgxpool.Pool, error) {
+			recreations++
+			return recreatedPool, nil
+		},
+	}
+
+	// the first call fails with a connection error, calls after the pool is recreated succeed
+	var calls int
+	operation := func() error {
+		calls++
+		if s.getPool() != recreatedPool {
+			return errors.New("closed pool")
+		}
+		return nil
+	}
+
+	if err := s.executeWithPoolRecovery(context.Background(), operation); err != nil {
+		t.Fatalf("expected operation to succeed after pool recreation, got %s", err.Error())
+	}
+	if calls != 2 || recreations != 1 {
+		t.Errorf("expected 2 calls and 1 recreation, got %d calls and %d recreations", calls, recreations)
+	}
+
+	// the pool must only be recreated once
+	failingOperation := func() error { return io.ErrUnexpectedEOF }
+	if err := s.executeWithPoolRecovery(context.Background(), failingOperation); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected connection error to be returned once recreations are exhausted, got %v", err)
+	}
+	if recreations != 1 {
+		t.Errorf("expected pool to be recreated once, got %d", recreations)
+	}
+}
@@ -2,14 +2,17 @@ package connection
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"strconv"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -22,11 +25,16 @@ import (
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/introspection"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
 	"github.com/turbot/steampipe/pkg/utils"
 	"golang.org/x/exp/maps"
 	"golang.org/x/sync/semaphore"
 )
 
+// commentLockKey is the (arbitrary, well-known) key of the advisory lock taken when setting comments
+// with the 'advisory' comment lock mode
+const commentLockKey int64 = 7132545032
+
 type connectionError struct {
 	name string
 	err  error
@@ -34,12 +42,24 @@ type connectionError struct {
 
 type refreshConnectionState struct {
 	// a connection pool to the DB service which uses the server appname
-	pool                       *pgxpool.Pool
-	searchPath                 []string
-	connectionUpdates          *steampipeconfig.ConnectionUpdates
-	tableUpdater               *connectionStateTableUpdater
+	// NOTE: access using getPool as the pool may be recreated if it fails
+	pool            *pgxpool.Pool
+	poolMut         sync.RWMutex
+	poolFactory     func(context.Context) (*pgxpool.Pool, error)
+	poolRecreations int
+	searchPath      []string
+	// connection schemas omitted from the search path due to the search path limit
+	omittedSearchPathSchemas []string
+	connectionUpdates        *steampipeconfig.ConnectionUpdates
+	tableUpdater             *connectionStateTableUpdater
+	// the table the connection state is stored in
+	connectionStateTable       steampipeconfig.ConnectionStateTable
 	res                        *steampipeconfig.RefreshConnectionResult
 	forceUpdateConnectionNames []string
+	// map of connection name to the names of the connections it depends on
+	connectionDependencies map[string][]string
+	// lock to protect res, which is updated by parallel update goroutines
+	resMut sync.Mutex
 	// properties for schema/comment cloning
 	exemplarSchemaMapMut sync.Mutex
 
@@ -49,26 +69,40 @@ type refreshConnectionState struct {
 	// if a plugin has an entry in this map, all connections schemas can be cloned from teh exemplar schema
 	exemplarCommentsMap map[string]string
 	pluginManager       pluginManager
+	// the options controlling the refresh
+	opts *RefreshOptions
+	// executes the connection schema updates, deletions and comments
+	executor connectionExecutor
+	// the progress of the connection updates
+	updateProgress *updateProgress
+	// reports the status of the refresh
+	statusReporter *refreshStatusReporter
 }
 
-func newRefreshConnectionState(ctx context.Context, pluginManager pluginManager, forceUpdateConnectionNames []string) (*refreshConnectionState, error) {
-	log.Println("[DEBUG] newRefreshConnectionState start")
-	defer log.Println("[DEBUG] newRefreshConnectionState end")
+func newRefreshConnectionState(ctx context.Context, pluginManager pluginManager, opts *RefreshOptions, forceUpdateConnectionNames []string) (*refreshConnectionState, error) {
+	logDebug(ctx, "newRefreshConnectionState start")
+	defer logDebug(ctx, "newRefreshConnectionState end")
 
 	pool := pluginManager.Pool()
 	// set user search path first
-	log.Printf("[INFO] setting up search path")
-	searchPath, err := db_local.SetUserSearchPath(ctx, pool)
+	logInfo(ctx, "setting up search path")
+	searchPath, omittedSearchPathSchemas, err := db_local.SetUserSearchPath(ctx, pool)
 	if err != nil {
 		return nil, err
 	}
 
 	res := &refreshConnectionState{
 		pool:                       pool,
+		poolFactory:                pluginManager.RecreatePool,
 		searchPath:                 searchPath,
+		omittedSearchPathSchemas:   omittedSearchPathSchemas,
 		forceUpdateConnectionNames: forceUpdateConnectionNames,
 		pluginManager:              pluginManager,
+		opts:                       opts,
+		connectionStateTable:       steampipeconfig.ConnectionStateTableFromConfig(),
+		statusReporter:             newRefreshStatusReporter(pluginManager),
 	}
+	res.executor = newPoolConnectionExecutor(res.getPool)
 
 	return res, nil
 }
@@ -77,40 +111,75 @@ func newRefreshConnectionState(ctx context.Context, pluginManager pluginManager,
 // and update the database schema and search path to reflect the required connections
 // return whether any changes have been made
 func (s *refreshConnectionState) refreshConnections(ctx context.Context) {
-	log.Println("[DEBUG] refreshConnectionState.refreshConnections start")
-	defer log.Println("[DEBUG] refreshConnectionState.refreshConnections end")
+	ctx, span := startRefreshSpan(ctx, "refreshConnections")
+	defer func() {
+		var err error
+		if s.res != nil {
+			err = s.res.Error
+		}
+		endRefreshSpan(span, err)
+	}()
+	logDebug(ctx, "refreshConnectionState.refreshConnections start")
+	defer logDebug(ctx, "refreshConnectionState.refreshConnections end")
 	// if there was an error (other than a connection error, which will NOT have been assigned to res),
 	// set state of all incomplete connections to error
 	defer func() {
 		if s.res != nil {
+			completionCtx, cancel := s.completionContext(ctx)
+			defer cancel()
 			if s.res.Error != nil {
-				s.setIncompleteConnectionStateToError(ctx, sperr.WrapWithMessage(s.res.Error, "refreshConnections failed before connection update was complete"))
+				s.setIncompleteConnectionStateToError(completionCtx, sperr.WrapWithMessage(s.res.Error, "refreshConnections failed before connection update was complete"))
 			}
 			if !s.res.ErrorAndWarnings.Empty() {
-				log.Printf("[INFO] refreshConnections completed with errors, sending notification")
-				s.pluginManager.SendPostgresErrorsAndWarningsNotification(ctx, &s.res.ErrorAndWarnings)
+				logInfo(ctx, "refreshConnections completed with errors, sending notification")
+				s.pluginManager.SendPostgresErrorsAndWarningsNotification(completionCtx, &s.res.ErrorAndWarnings)
 			}
 
 		}
 	}()
-	log.Printf("[INFO] building connectionUpdates")
+	logInfo(ctx, "building connectionUpdates")
 
 	var opts []steampipeconfig.ConnectionUpdatesOption
 	if len(s.forceUpdateConnectionNames) > 0 {
 		opts = append(opts, steampipeconfig.WithForceUpdate(s.forceUpdateConnectionNames))
 	}
+	if s.opts.ForceUpdateAll {
+		logInfo(ctx, "--%s is set - all connection schemas will be dropped and reimported", constants.ArgForceUpdateAll)
+		opts = append(opts, steampipeconfig.WithForceUpdateAll(true))
+	}
+	if s.opts.SkipPluginValidation {
+		opts = append(opts, steampipeconfig.WithSkipPluginValidation(true))
+	}
+	if s.opts.PruneSchemas {
+		opts = append(opts, steampipeconfig.WithPrune(true, s.opts.DryRun))
+	}
 
 	// build a ConnectionUpdates struct
 	// this determines any necessary connection updates and starts any necessary plugins
-	s.connectionUpdates, s.res = steampipeconfig.NewConnectionUpdates(ctx, s.pool, s.pluginManager, opts...)
+	s.connectionUpdates, s.res = steampipeconfig.NewConnectionUpdates(ctx, s.getPool(), s.pluginManager, opts...)
 
-	defer s.logRefreshConnectionResults()
+	defer s.logRefreshConnectionResults(ctx)
 	// were we successful?
 	if s.res.Error != nil {
 		return
 	}
+	// if enabled, report the catalog usage of the connection schemas once the refresh is complete
+	if s.opts.CatalogStats {
+		defer s.addCatalogStats(ctx)
+	}
 
-	log.Printf("[INFO] created connectionUpdates")
+	logInfo(ctx, "created connectionUpdates")
+	span.SetAttributes(
+		attributeUpdateCount.Int(len(s.connectionUpdates.Update)),
+		attributeDeleteCount.Int(len(s.connectionUpdates.Delete)),
+	)
+
+	// load the declared dependencies between connections
+	// (fail before executing any DDL if these contain a cycle)
+	if err := s.loadConnectionDependencies(); err != nil {
+		s.res.Error = err
+		return
+	}
 
 	//  reload plugin rate limiter definitions for all plugins which are updated - the plugin will already be loaded
 	if len(s.connectionUpdates.PluginsWithUpdatedBinary) > 0 {
@@ -130,20 +199,24 @@ func (s *refreshConnectionState) refreshConnections(ctx context.Context) {
 	}
 
 	// delete the connection state file - it will be rewritten when we are complete
-	log.Printf("[INFO] deleting connections state file")
+	logInfo(ctx, "deleting connections state file")
 	steampipeconfig.DeleteConnectionStateFile()
 	defer func() {
 		if s.res.Error == nil {
-			log.Printf("[INFO] saving connections state file")
+			logInfo(ctx, "saving connections state file")
 			steampipeconfig.SaveConnectionStateFile(s.res, s.connectionUpdates)
 		}
 	}()
 
 	// warn about missing plugins
-	s.addMissingPluginWarnings()
+	s.addMissingPluginWarnings(ctx)
+	// warn about connections omitted from the search path
+	if warning := db_local.SearchPathLimitWarning(s.omittedSearchPathSchemas); warning != "" {
+		s.res.AddWarning(warning)
+	}
 
 	// create object to update the connection state table and notify of state changes
-	s.tableUpdater = newConnectionStateTableUpdater(s.connectionUpdates, s.pool)
+	s.tableUpdater = newConnectionStateTableUpdater(ctx, s.connectionUpdates, s.getPool(), s.connectionStateTable)
 
 	// NOTE: delete any DYNAMIC plugin connections which will be updated
 	// to avoid them being accessed before they are updated
@@ -152,43 +225,49 @@ func (s *refreshConnectionState) refreshConnections(ctx context.Context) {
 
 	// update connectionState table to reflect the updates (i.e. set connections to updating/deleting/ready as appropriate)
 	// also this will update the schema hashes of plugins
-	if err := s.tableUpdater.start(ctx); err != nil {
+	if err := s.tableUpdater.start(ctx, s.opts.ConnectionStateBatchSize); err != nil {
 		s.res.Error = err
 		return
 	}
 
+	// drop the schemas of any connections which failed validation
+	// (this must be done even if there are no other updates)
+	s.executeDropInvalidConnectionQueries(ctx)
+
 	// if there are no updates, just return
 	if !s.connectionUpdates.HasUpdates() {
-		log.Println("[INFO] no updates required")
+		logInfo(ctx, "no updates required")
 		return
 	}
 
-	log.Printf("[INFO] execute connection queries")
+	logInfo(ctx, "execute connection queries")
 
 	// execute any necessary queries
 	s.executeConnectionQueries(ctx)
 	if s.res.Error != nil {
-		log.Printf("[WARN] refreshConnections failed with err %s", s.res.Error.Error())
+		logWarn(ctx, "refreshConnections failed with err %s", s.res.Error.Error())
 		return
 	}
 
 	s.res.UpdatedConnections = true
 }
 
-func (s *refreshConnectionState) addMissingPluginWarnings() {
-	log.Printf("[INFO] refreshConnections: identify missing plugins")
+func (s *refreshConnectionState) addMissingPluginWarnings(ctx context.Context) {
+	logInfo(ctx, "refreshConnections: identify missing plugins")
 
 	var connectionNames []string
 	// add warning if there are connections left over, from missing plugins
 	if len(s.connectionUpdates.MissingPlugins) > 0 {
 		// warning
-		for _, conns := range s.connectionUpdates.MissingPlugins {
+		for plugin, conns := range s.connectionUpdates.MissingPlugins {
 			for _, con := range conns {
 				connectionNames = append(connectionNames, con.Name)
+				// also record the missing plugins in a structured form, so callers need not parse the warning
+				s.res.AddMissingPlugin(plugin, con.Name)
 			}
 
 		}
-		pluginNames := maps.Keys(s.connectionUpdates.MissingPlugins)
+		pluginNames := utils.SortedMapKeys(s.connectionUpdates.MissingPlugins)
 
 		s.res.AddWarning(fmt.Sprintf("%d %s required by %d %s %s missing. To install, please run: %s",
 			len(pluginNames),
@@ -200,7 +279,7 @@ func (s *refreshConnectionState) addMissingPluginWarnings() {
 	}
 }
 
-func (s *refreshConnectionState) logRefreshConnectionResults() {
+func (s *refreshConnectionState) logRefreshConnectionResults(ctx context.Context) {
 	var cmdName = viper.Get(constants.ConfigKeyActiveCommand).(*cobra.Command).Name()
 	if cmdName != "plugin-manager" {
 		return
@@ -212,25 +291,26 @@ func (s *refreshConnectionState) logRefreshConnectionResults() {
 	}
 	if s.res != nil {
 		op.WriteString(fmt.Sprintf("%s\n", s.res.String()))
+		op.WriteString(fmt.Sprintf("Summary:\n%s\n", s.res.Summary().Table()))
 	}
 
-	log.Printf("[TRACE] refresh connections: \n%s\n", helpers.Tabify(op.String(), "    "))
+	logTrace(ctx, "refresh connections: \n%s\n", helpers.Tabify(op.String(), "    "))
 }
 
 func (s *refreshConnectionState) executeConnectionQueries(ctx context.Context) {
-	log.Println("[DEBUG] refreshConnectionState.executeConnectionQueries start")
-	defer log.Println("[DEBUG] refreshConnectionState.executeConnectionQueries end")
+	logDebug(ctx, "refreshConnectionState.executeConnectionQueries start")
+	defer logDebug(ctx, "refreshConnectionState.executeConnectionQueries end")
 
 	// execute deletions
 	if err := s.executeDeleteQueries(ctx, s.connectionUpdates.GetConnectionsToDelete()); err != nil {
 		// just log
-		log.Printf("[WARN] failed to delete all unused schemas: %s", err.Error())
+		logWarn(ctx, "failed to delete all unused schemas: %s", err.Error())
 	}
 
 	// execute updates
 	numUpdates := len(s.connectionUpdates.Update)
 	numMissingComments := len(s.connectionUpdates.MissingComments)
-	log.Printf("[INFO] executeConnectionQueries: num updates: %d, connections missing comments: %d", numUpdates, numMissingComments)
+	logInfo(ctx, "executeConnectionQueries: num updates: %d, connections missing comments: %d", numUpdates, numMissingComments)
 
 	if numUpdates+numMissingComments > 0 {
 		// get schema queries - this updates schemas for validated plugins and drops schemas for unvalidated plugins
@@ -240,27 +320,37 @@ func (s *refreshConnectionState) executeConnectionQueries(ctx context.Context) {
 	}
 
 	if len(s.connectionUpdates.Delete) > 0 {
-		log.Printf("[INFO] deleted all unnecessary schemas - sending notification")
+		logInfo(ctx, "deleted all unnecessary schemas - sending notification")
 
 		// if there are no updates and there ARE deletes, notify
 		// (is there are updates, deletes will be notified by executeUpdateQueries)
-		if err := s.pluginManager.SendPostgresSchemaNotification(ctx); err != nil {
+		if err := s.sendSchemaNotification(ctx); err != nil {
 			// just log
-			log.Printf("[WARN] failed to send schema deletion Postgres notification: %s", err.Error())
+			logWarn(ctx, "failed to send schema deletion Postgres notification: %s", err.Error())
 		}
 	}
 }
 
+// sendSchemaNotification sends a schema update notification, listing the connections updated and deleted by the refresh
+func (s *refreshConnectionState) sendSchemaNotification(ctx context.Context) error {
+	updated := utils.SortedMapKeys(s.connectionUpdates.Update)
+	deleted := s.connectionUpdates.GetConnectionsToDelete()
+	slices.Sort(deleted)
+	return s.pluginManager.SendPostgresSchemaNotification(ctx, updated, deleted)
+}
+
 // execute all update queries
 // NOTE: this only sets res.Error if there is a failure to set update the connection state table
 // - all other connection based failures are recorded in the connection state table
 func (s *refreshConnectionState) executeUpdateQueries(ctx context.Context) {
-	log.Println("[DEBUG] refreshConnectionState.executeUpdateQueries start")
-	defer log.Println("[DEBUG] refreshConnectionState.executeUpdateQueries end")
+	ctx, span := startRefreshSpan(ctx, "executeUpdateQueries", attributeUpdateCount.Int(len(s.connectionUpdates.Update)))
+	defer func() { endRefreshSpan(span, s.res.Error) }()
+	logDebug(ctx, "refreshConnectionState.executeUpdateQueries start")
+	defer logDebug(ctx, "refreshConnectionState.executeUpdateQueries end")
 
 	defer func() {
 		if s.res.Error != nil {
-			log.Printf("[INFO] executeUpdateQueries returned error: %v", s.res.Error)
+			logInfo(ctx, "executeUpdateQueries returned error: %v", s.res.Error)
 		}
 	}()
 
@@ -271,25 +361,39 @@ func (s *refreshConnectionState) executeUpdateQueries(ctx context.Context) {
 	// we need to execute the updates in search path order
 	// i.e. we first need to update the first search path connection for each plugin (this can be done in parallel)
 	// then we can update the remaining connections in parallel
-	initialUpdates, remainingUpdates, dynamicUpdates := s.getInitialAndRemainingUpdates()
+	// aggregator connections are updated last, once their child connections have been updated
+	initialUpdates, remainingUpdates, dynamicUpdates, aggregatorUpdates := s.getInitialAndRemainingUpdates(ctx)
 
 	// dynamic plugins must be updated for each plugin in search path order
 	// dynamicUpdates is a map keyed by plugin with all the updates for that plugin
 
 	// create exemplar maps
-	s.exemplarSchemaMap = make(map[string]string)
+	// seed the exemplar schemas with any existing schemas which can be cloned
+	cloneMode := s.opts.CloneSchema
+	s.exemplarSchemaMap = connectionUpdates.ExemplarSchemas(func(c *steampipeconfig.ConnectionState) bool {
+		return canCloneSchema(cloneMode, c)
+	})
 	s.exemplarCommentsMap = make(map[string]string)
-	log.Printf("[INFO] executing %d update %s", numUpdates, utils.Pluralize("query", numUpdates))
+	logInfo(ctx, "executing %d update %s", numUpdates, utils.Pluralize("query", numUpdates))
+
+	// track progress - the remaining updates will be cloned from the exemplar schemas where possible
+	clones := 0
+	for _, c := range remainingUpdates {
+		if canCloneSchema(cloneMode, c) {
+			clones++
+		}
+	}
+	s.updateProgress = newUpdateProgress(numUpdates-clones, clones, s.opts.MaxUpdateParallel, s.statusReporter)
 
 	// execute initial updates
-	log.Printf("[INFO] executing initial updates")
+	logInfo(ctx, "executing initial updates")
 	var errors []error
 	moreErrors := s.executeUpdatesInParallel(ctx, initialUpdates)
 	errors = append(errors, moreErrors...)
 
 	// execute dynamic updates (note, we update all connections in search path order,
 	// so must call executeUpdateSetsInParallel)
-	log.Printf("[INFO] executing dynamic updates")
+	logInfo(ctx, "executing dynamic updates")
 	moreErrors = s.executeUpdateSetsInParallel(ctx, dynamicUpdates)
 	errors = append(errors, moreErrors...)
 
@@ -297,43 +401,52 @@ func (s *refreshConnectionState) executeUpdateQueries(ctx context.Context) {
 	// resolve unqualified queries/tables
 	if len(errors) > 0 {
 		s.res.Error = error_helpers.CombineErrors(errors...)
-		log.Printf("[WARN] initial updates failed: %s", s.res.Error.Error())
+		logWarn(ctx, "initial updates failed: %s", s.res.Error.Error())
 		return
 	}
 
-	log.Printf("[INFO] set comments for initial updates")
+	logInfo(ctx, "set comments for initial updates")
 	// now set comments for initial updates and dynamic connections
 	// note errors will be empty to get here
 	s.UpdateCommentsInParallel(ctx, maps.Values(initialUpdates), connectionPlugins)
 
-	log.Printf("[INFO] set comments for dynamic updates")
+	logInfo(ctx, "set comments for dynamic updates")
 	// convert dynamicUpdates to an array of connection states
 	var dynamicUpdateArray = updateSetMapToArray(dynamicUpdates)
 	s.UpdateCommentsInParallel(ctx, dynamicUpdateArray, connectionPlugins)
 
-	log.Printf("[INFO] updated all exemplar schemas - sending notification")
+	logInfo(ctx, "updated all exemplar schemas - sending notification")
 	// now that we have updated all exemplar schemars, send postgres notification
 	// this gives any attached interactive clients a chance to update their inspect data and autocomplete
-	if err := s.pluginManager.SendPostgresSchemaNotification(ctx); err != nil {
+	if err := s.sendSchemaNotification(ctx); err != nil {
 		// just log
-		log.Printf("[WARN] failed to send schem update Postgres notification: %s", err.Error())
+		logWarn(ctx, "failed to send schem update Postgres notification: %s", err.Error())
 	}
 
-	log.Printf("[INFO] Execute %d remaining %s",
+	logInfo(ctx, "Execute %d remaining %s",
 		len(remainingUpdates),
 		utils.Pluralize("updates", len(remainingUpdates)))
 	// now execute remaining updates
-	moreErrors = s.executeUpdatesInParallel(ctx, remainingUpdates)
+	// (where possible, these are cloned from the exemplar schemas)
+	moreErrors = s.cloneConnectionSchemas(ctx, remainingUpdates)
+	errors = append(errors, moreErrors...)
+
+	logInfo(ctx, "Execute %d aggregator %s",
+		len(aggregatorUpdates),
+		utils.Pluralize("updates", len(aggregatorUpdates)))
+	// now all child connections have been updated, execute aggregator updates
+	moreErrors = s.executeUpdatesInParallel(ctx, aggregatorUpdates)
 	errors = append(errors, moreErrors...)
 
-	log.Printf("[INFO] Set comments for %d remaining %s and %d %s missing comments",
+	logInfo(ctx, "Set comments for %d remaining %s and %d %s missing comments",
 		len(remainingUpdates),
 		utils.Pluralize("updates", len(remainingUpdates)),
 		len(connectionUpdates.MissingComments),
 		utils.Pluralize("updates", len(connectionUpdates.MissingComments)),
 	)
-	// set comments for remaining updates
+	// set comments for remaining updates and aggregators
 	s.UpdateCommentsInParallel(ctx, maps.Values(remainingUpdates), connectionPlugins)
+	s.UpdateCommentsInParallel(ctx, maps.Values(aggregatorUpdates), connectionPlugins)
 	// set comments for any other connection without comment set
 	s.UpdateCommentsInParallel(ctx, maps.Values(s.connectionUpdates.MissingComments), connectionPlugins)
 
@@ -341,20 +454,34 @@ func (s *refreshConnectionState) executeUpdateQueries(ctx context.Context) {
 		s.res.Error = error_helpers.CombineErrors(errors...)
 	}
 
-	log.Printf("[INFO] all update queries executed")
+	logInfo(ctx, "all update queries executed")
 
-	for _, failure := range connectionUpdates.InvalidConnections {
-		log.Printf("[TRACE] remove schema for connection failing validation connection %s, plugin Name %s\n ", failure.ConnectionName, failure.Plugin)
+	logInfo(ctx, "executeUpdateQueries complete")
+	return
+}
+
+// cloneConnectionSchemas executes the updates for all connections which are not the first in the search path
+// for their plugin - if the plugin schema can be cloned, these are cloned from the exemplar schema
+func (s *refreshConnectionState) cloneConnectionSchemas(ctx context.Context, updates map[string]*steampipeconfig.ConnectionState) []error {
+	// if batching is enabled, clone as many schemas as possible in batches - the rest are updated individually
+	if s.opts.CloneSchemaBatchSize > 0 {
+		updates = s.cloneSchemaBatches(ctx, updates, s.opts.CloneSchemaBatchSize)
+	}
+	return s.executeUpdatesInParallel(ctx, updates)
+}
+
+// drop the schemas of any connections which failed validation and are flagged as ShouldDropIfExists
+func (s *refreshConnectionState) executeDropInvalidConnectionQueries(ctx context.Context) {
+	for _, failure := range s.connectionUpdates.InvalidConnections {
+		logTrace(ctx, "remove schema for connection failing validation connection %s, plugin Name %s\n ", failure.ConnectionName, failure.Plugin)
 		if failure.ShouldDropIfExists {
-			_, err := s.pool.Exec(ctx, db_common.GetDeleteConnectionQuery(failure.ConnectionName))
+			_, err := s.executor.Exec(ctx, db_common.GetDeleteConnectionQuery(failure.ConnectionName))
 			if err != nil {
 				// NOTE: do not return an error if we fail to remove an invalid connection - just log it
-				log.Printf("[WARN] failed to delete invalid connection '%s' (%s) : %s", failure.ConnectionName, failure.Message, err.Error())
+				logWarn(ctx, "failed to delete invalid connection '%s' (%s) : %s", failure.ConnectionName, failure.Message, err.Error())
 			}
 		}
 	}
-	log.Printf("[INFO] executeUpdateQueries complete")
-	return
 }
 
 // convert map update sets (used for dynamic schemas) to an array of the underlying connection states
@@ -369,16 +496,26 @@ func updateSetMapToArray(updateSetMap map[string][]*steampipeconfig.ConnectionSt
 // create/update connections
 
 func (s *refreshConnectionState) executeUpdatesInParallel(ctx context.Context, updates map[string]*steampipeconfig.ConnectionState) (errors []error) {
-	log.Println("[DEBUG] refreshConnectionState.executeUpdatesInParallel start")
-	defer log.Println("[DEBUG] refreshConnectionState.executeUpdatesInParallel end")
+	logDebug(ctx, "refreshConnectionState.executeUpdatesInParallel start")
+	defer logDebug(ctx, "refreshConnectionState.executeUpdatesInParallel end")
+
+	// connections which depend on other connections must be updated after them
+	// so split the updates into levels - the updates within each level may be executed in parallel
+	levels, err := dependencyLevels(maps.Keys(updates), s.connectionDependencies)
+	if err != nil {
+		return []error{err}
+	}
 
-	// convert updates to update sets
-	updatesAsSets := make(map[string][]*steampipeconfig.ConnectionState, len(updates))
-	for k, v := range updates {
-		updatesAsSets[k] = []*steampipeconfig.ConnectionState{v}
+	for _, level := range levels {
+		// convert updates to update sets
+		updatesAsSets := make(map[string][]*steampipeconfig.ConnectionState, len(level))
+		for _, connectionName := range level {
+			updatesAsSets[connectionName] = []*steampipeconfig.ConnectionState{updates[connectionName]}
+		}
+		// just call executeUpdateSetsInParallel
+		errors = append(errors, s.executeUpdateSetsInParallel(ctx, updatesAsSets)...)
 	}
-	// just call executeUpdateSetsInParallel
-	return s.executeUpdateSetsInParallel(ctx, updatesAsSets)
+	return errors
 }
 
 // execute sets of updates in parallel - this is required as for dynamic plugins, we must update all connections in
@@ -386,150 +523,179 @@ func (s *refreshConnectionState) executeUpdatesInParallel(ctx context.Context, u
 // - for convenience we also use this function for static connections by mapping the input data
 // from map[string]*steampipeconfig.ConnectionState to map[string][]*steampipeconfig.ConnectionState
 func (s *refreshConnectionState) executeUpdateSetsInParallel(ctx context.Context, updates map[string][]*steampipeconfig.ConnectionState) (errors []error) {
-	log.Println("[DEBUG] refreshConnectionState.executeUpdateSetsInParallel start")
-	defer log.Println("[DEBUG] refreshConnectionState.executeUpdateSetsInParallel end")
+	logDebug(ctx, "refreshConnectionState.executeUpdateSetsInParallel start")
+	defer logDebug(ctx, "refreshConnectionState.executeUpdateSetsInParallel end")
 
 	var wg sync.WaitGroup
 	var errChan = make(chan *connectionError)
-
-	// default to running a single update at a time
-	var maxParallel = int64(1)
-	// allow override of this behaviour vis env var
-	if envMaxStr, ok := os.LookupEnv("STEAMPIPE_UPDATE_SCHEMA_MAX_PARALLEL"); ok {
-		envMax, err := strconv.Atoi(envMaxStr)
-		if err == nil {
-			maxParallel = int64(envMax)
-		}
+	// closed once all connection errors have been handled
+	errorsHandled := make(chan struct{})
+
+	// the updates are executed by up to maxParallel goroutines (see constants.ArgUpdateSchemaMaxParallel)
+	// each update uses a pool session, so there is no benefit running more updates than the pool has sessions
+	maxParallel := int64(s.opts.MaxUpdateParallel)
+	if pool := s.getPool(); pool != nil && maxParallel > int64(pool.Config().MaxConns) {
+		maxParallel = int64(pool.Config().MaxConns)
 	}
-	log.Printf("[INFO] executeUpdateSetsInParallel - maxParallel= %d", maxParallel)
+	logInfo(ctx, "executeUpdateSetsInParallel - maxParallel= %d", maxParallel)
 
 	sem := semaphore.NewWeighted(maxParallel)
 
+	var connectionErrors []error
 	go func() {
-		for {
-			select {
-			case connectionError := <-errChan:
-				if connectionError == nil {
-					return
-				}
-				errors = append(errors, connectionError.err)
-				conn, poolErr := s.pool.Acquire(ctx)
-				if poolErr == nil {
-					s.tableUpdater.onConnectionError(ctx, conn.Conn(), connectionError.name, connectionError.err)
-					conn.Release()
-				}
-			}
+		defer close(errorsHandled)
+		for connectionError := range errChan {
+			connectionErrors = append(connectionErrors, connectionError.err)
+			s.tableUpdater.onConnectionError(ctx, s.executor, connectionError.name, connectionError.err)
 		}
 	}()
 
-	// allow disabling of schema clone via env var
-	var cloneSchemaEnabled = true
-	if envClone, ok := os.LookupEnv("STEAMPIPE_CLONE_SCHEMA"); ok {
-		cloneSchemaEnabled = strings.ToLower(envClone) == "true"
-	}
-	log.Printf("[INFO] executeUpdateForConnections - cloneSchema=%v", cloneSchemaEnabled)
+	cloneMode := s.opts.CloneSchema
+	logInfo(ctx, "executeUpdateForConnections - cloneSchema=%s", cloneMode)
 
 	// each update may be multiple connections, to execute in order
 	for _, states := range updates {
-		wg.Add(1)
 		// use semaphore to limit goroutines
 		if err := sem.Acquire(ctx, 1); err != nil {
+			// if we fail to acquire semaphore (i.e. the context is cancelled), do not start any more updates
+			// but wait for the updates in progress to complete
 			errors = append(errors, err)
-			// if we fail to acquire semaphore, just give up
-			return errors
+			break
 		}
+		wg.Add(1)
 		go func(connectionStates []*steampipeconfig.ConnectionState) {
 			defer func() {
 				wg.Done()
 				sem.Release(1)
 			}()
 
-			s.executeUpdateForConnections(ctx, errChan, cloneSchemaEnabled, connectionStates...)
+			s.executeUpdateForConnections(ctx, errChan, cloneMode, connectionStates...)
 		}(states)
 
 	}
 
 	wg.Wait()
 	close(errChan)
+	<-errorsHandled
 
-	return errors
+	return append(errors, connectionErrors...)
 }
 
 // syncronously execute the update queries for one or more connections
-func (s *refreshConnectionState) executeUpdateForConnections(ctx context.Context, errChan chan *connectionError, cloneSchemaEnabled bool, connectionStates ...*steampipeconfig.ConnectionState) {
-	log.Println("[DEBUG] refreshConnectionState.executeUpdateForConnections start")
-	defer log.Println("[DEBUG] refreshConnectionState.executeUpdateForConnections end")
+func (s *refreshConnectionState) executeUpdateForConnections(ctx context.Context, errChan chan *connectionError, cloneMode string, connectionStates ...*steampipeconfig.ConnectionState) {
+	logDebug(ctx, "refreshConnectionState.executeUpdateForConnections start")
+	defer logDebug(ctx, "refreshConnectionState.executeUpdateForConnections end")
 
 	for _, connectionState := range connectionStates {
 		connectionName := connectionState.ConnectionName
-		remoteSchema := utils.PluginFQNToSchemaName(connectionState.Plugin)
-		var sql string
 
 		s.exemplarSchemaMapMut.Lock()
-		// is this plugin in the exemplarSchemaMap
-		exemplarSchemaName, haveExemplarSchema := s.exemplarSchemaMap[connectionState.Plugin]
-		if haveExemplarSchema && cloneSchemaEnabled {
-			// we can clone!
-			sql = getCloneSchemaQuery(exemplarSchemaName, connectionState)
-		} else {
-			// just get sql to execute update query, and update the connection state table, in a transaction
-			sql = db_common.GetUpdateConnectionQuery(connectionName, remoteSchema)
-		}
+		sql, haveExemplarSchema, isClone := s.getUpdateQuery(ctx, connectionState, cloneMode)
 		s.exemplarSchemaMapMut.Unlock()
 
-		// the only error this will return is the failure to update the state table
+		// the only error this will return is the failure to update the state table, or a pool connection failure
 		// - all other errors are written to the state table
-		if err := s.executeUpdateQuery(ctx, sql, connectionName); err != nil {
+		// if the pool has lost its connection to the database, it will be recreated and the update retried
+		startTime := time.Now()
+		completedAsClone := isClone
+		updateCtx, span := startRefreshSpan(ctx, "updateConnection", attributeConnectionName.String(connectionName))
+		err := s.executeUpdateWithCloneFallback(updateCtx, connectionState, sql, isClone, func(sql string, isClone bool) error {
+			completedAsClone = isClone
+			return s.executeWithPoolRecovery(updateCtx, func() error {
+				return s.executeUpdateQuery(updateCtx, sql, connectionName, isClone)
+			})
+		})
+		// record whether the schema was cloned or imported (if the clone failed, the schema is imported instead)
+		span.SetAttributes(attributeClone.Bool(completedAsClone))
+		endRefreshSpan(span, err)
+		s.updateProgress.onUpdateComplete(ctx, completedAsClone, time.Since(startTime))
+		if err != nil {
 			errChan <- &connectionError{connectionName, err}
 		} else {
 			// we can clone this plugin, add to exemplarSchemaMap
 			// (AFTER executing the update query)
-			if !haveExemplarSchema && connectionState.CanCloneSchema() {
+			if !haveExemplarSchema && canCloneSchema(cloneMode, connectionState) {
+				s.exemplarSchemaMapMut.Lock()
 				s.exemplarSchemaMap[connectionState.Plugin] = connectionName
+				s.exemplarSchemaMapMut.Unlock()
 			}
 		}
 	}
 }
 
-func (s *refreshConnectionState) executeUpdateQuery(ctx context.Context, sql, connectionName string) (err error) {
-	log.Println("[DEBUG] refreshConnectionState.executeUpdateQuery start")
-	defer log.Println("[DEBUG] refreshConnectionState.executeUpdateQuery end")
-
-	// create a transaction
-	tx, err := s.pool.Begin(ctx)
-	if err != nil {
-		return sperr.WrapWithMessage(err, "failed to create transaction to perform update query")
+// executeUpdateWithCloneFallback executes the update sql for a connection using the given function
+// if the sql clones the exemplar schema and the clone fails (for a reason other than a transient error),
+// the foreign schema is imported instead - the connection is only marked as failed if the import also fails
+func (s *refreshConnectionState) executeUpdateWithCloneFallback(ctx context.Context, connectionState *steampipeconfig.ConnectionState, sql string, isClone bool, execute func(sql string, isClone bool) error) error {
+	err := execute(sql, isClone)
+	var cloneErr *cloneFailedError
+	if !errors.As(err, &cloneErr) {
+		return err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		} else {
-			tx.Commit(ctx)
-		}
-	}()
+	logWarn(ctx, "failed to clone schema for connection %s: %s - falling back to importing the schema", connectionState.ConnectionName, cloneErr.err.Error())
+	return execute(importSchemaQuery(connectionState, s.opts.GrantPrivileges), false)
+}
 
-	// execute update sql
-	_, err = tx.Exec(ctx, sql)
-	if err != nil {
-		// update failed connections in result
-		s.res.AddFailedConnection(connectionName, err.Error())
+// executeUpdateQuery executes the update sql for a connection and sets the connection state to ready in the same
+// transaction, so the connection state can never claim the connection is ready before its schema exists
+func (s *refreshConnectionState) executeUpdateQuery(ctx context.Context, sql, connectionName string, isClone bool) error {
+	logDebug(ctx, "refreshConnectionState.executeUpdateQuery start")
+	defer logDebug(ctx, "refreshConnectionState.executeUpdateQuery end")
 
-		// update the state table
-		//(the transaction will be aborted - create a connection for the update)
-		if conn, poolErr := s.pool.Acquire(ctx); poolErr == nil {
-			defer conn.Release()
-			if statusErr := s.tableUpdater.onConnectionError(ctx, conn.Conn(), connectionName, err); statusErr != nil {
-				// NOTE: do not return the error - unless we failed to update the connection state table
-				return error_helpers.CombineErrorsWithPrefix(fmt.Sprintf("failed to update connection %s and failed to update connection_state table", connectionName), err, statusErr)
-			}
+	// update state table (inside transaction)
+	onComplete := func(tx sqlExecutor) error {
+		if err := s.tableUpdater.onConnectionReady(ctx, tx, connectionName); err != nil {
+			return sperr.WrapWithMessage(err, "failed to update connection state table")
 		}
 		return nil
 	}
+	var err error
+	if isClone {
+		err = s.executor.CloneSchema(ctx, connectionName, sql, onComplete)
+	} else {
+		err = s.executor.UpdateSchema(ctx, connectionName, sql, onComplete)
+	}
 
-	// update state table (inside transaction)
-	err = s.tableUpdater.onConnectionReady(ctx, tx.Conn(), connectionName)
+	var stmtErr *statementError
+	if errors.As(err, &stmtErr) {
+		err = stmtErr.err
+		// if the pool has lost its connection, return the error so the caller can recreate the pool and retry
+		if isPoolConnectionError(err) {
+			return err
+		}
+		// if cloning the exemplar schema failed, return the error so the caller can fall back to importing the schema
+		// (a transient failure would be just as likely to affect the import, so is treated as a failure of the connection)
+		if isClone && !isTransientError(err) {
+			return &cloneFailedError{err: err}
+		}
+		// update failed connections in result
+		s.resMut.Lock()
+		s.res.AddFailedConnection(connectionName, err.Error())
+		s.resMut.Unlock()
+
+		return s.onStatementError(ctx, connectionName, err)
+	}
 	if err != nil {
-		return sperr.WrapWithMessage(err, "failed to update connection state table")
+		return err
+	}
+
+	// only record the update once it is committed
+	s.resMut.Lock()
+	if isClone {
+		s.res.ClonedConnections = append(s.res.ClonedConnections, connectionName)
+	} else {
+		s.res.CreatedConnections = append(s.res.CreatedConnections, connectionName)
+	}
+	s.resMut.Unlock()
+	return nil
+}
+
+// onStatementError writes the failure of the sql of a connection operation to the connection state table
+// NOTE: this only returns an error if we fail to update the state table
+func (s *refreshConnectionState) onStatementError(ctx context.Context, connectionName string, err error) error {
+	// (the operation transaction has been rolled back - update the state table outside of it)
+	if statusErr := s.tableUpdater.onConnectionError(ctx, s.executor, connectionName, err); statusErr != nil {
+		return error_helpers.CombineErrorsWithPrefix(fmt.Sprintf("failed to update connection %s and failed to update connection_state table", connectionName), err, statusErr)
 	}
 	return nil
 }
@@ -537,17 +703,26 @@ func (s *refreshConnectionState) executeUpdateQuery(ctx context.Context, sql, co
 // set connection comments
 
 func (s *refreshConnectionState) UpdateCommentsInParallel(ctx context.Context, updates []*steampipeconfig.ConnectionState, plugins map[string]*steampipeconfig.ConnectionPlugin) (errors []error) {
-	if !viper.GetBool(constants.ArgSchemaComments) {
+	// exclude any connections which have comments disabled
+	updates = connectionsWithSchemaComments(updates, s.opts.SchemaComments)
+	if len(updates) == 0 {
 		return nil
 	}
+	ctx, span := startRefreshSpan(ctx, "updateComments", attributeConnectionCount.Int(len(updates)))
+	defer func() { endRefreshSpan(span, error_helpers.CombineErrors(errors...)) }()
 
 	var wg sync.WaitGroup
 	var errChan = make(chan *connectionError)
 
 	// use as many goroutines as we have connections
-	var maxUpdateThreads = int64(s.pool.Config().MaxConns)
+	var maxUpdateThreads = int64(s.getPool().Config().MaxConns)
 	sem := semaphore.NewWeighted(maxUpdateThreads)
 
+	progress := newCommentsProgress(len(updates), s.statusReporter)
+
+	// generate the comments sql for all connections before executing any of it
+	queries := buildCommentsQueries(ctx, updates, plugins, commentsBuildWorkers())
+
 	go func() {
 		for {
 			select {
@@ -576,7 +751,13 @@ func (s *refreshConnectionState) UpdateCommentsInParallel(ctx context.Context, u
 				sem.Release(1)
 			}()
 
-			s.updateCommentsForConnection(ctx, errChan, plugins, connectionState)
+			sql, ok := queries[connectionState.ConnectionName]
+			if !ok {
+				// no connection plugin was loaded for this connection (this will have been logged)
+				return
+			}
+			progress.start(ctx, connectionState.ConnectionName)
+			s.updateCommentsForConnection(ctx, errChan, connectionState.ConnectionName, sql)
 		}(connectionState)
 
 	}
@@ -587,23 +768,69 @@ func (s *refreshConnectionState) UpdateCommentsInParallel(ctx context.Context, u
 	return errors
 }
 
-// syncronously execute the comments queries for one or more connections
-func (s *refreshConnectionState) updateCommentsForConnection(ctx context.Context, errChan chan *connectionError, connectionPluginMap map[string]*steampipeconfig.ConnectionPlugin, connectionState *steampipeconfig.ConnectionState) {
-	connectionName := connectionState.ConnectionName
+// commentsProgress reports the progress of setting connection comments
+type commentsProgress struct {
+	total    int
+	started  atomic.Int32
+	reporter *refreshStatusReporter
+}
 
-	var sql string
+func newCommentsProgress(total int, reporter *refreshStatusReporter) *commentsProgress {
+	return &commentsProgress{total: total, reporter: reporter}
+}
 
-	// we should have a connectionPlugin loaded for this connection
-	connectionPlugin, ok := connectionPluginMap[connectionName]
-	if !ok {
-		log.Printf("[WARN] no connection plugin loaded for connection '%s', which needs comments updating", connectionName)
-		return
+// start sets the status to indicate comments are being set for the given connection
+func (p *commentsProgress) start(ctx context.Context, connectionName string) {
+	n := p.started.Add(1)
+	p.reporter.setStatus(ctx, fmt.Sprintf("Commenting %d of %d %s (%s)", n, p.total, utils.Pluralize("connection", p.total), connectionName))
+}
+
+// buildCommentsQueries generates the comments sql for each of the given connections, keyed by connection name
+// building the sql is pure cpu work (iterating every table and column of the schema) so this is done using a pool
+// of workers, independently of executing the queries
+// connections with no connection plugin loaded are omitted
+func buildCommentsQueries(ctx context.Context, updates []*steampipeconfig.ConnectionState, plugins map[string]*steampipeconfig.ConnectionPlugin, workers int) map[string]string {
+	queries := make(map[string]string, len(updates))
+	var queriesMut sync.Mutex
+
+	connectionNames := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for connectionName := range connectionNames {
+				// we should have a connectionPlugin loaded for this connection
+				connectionPlugin, ok := plugins[connectionName]
+				if !ok {
+					logWarn(ctx, "no connection plugin loaded for connection '%s', which needs comments updating", connectionName)
+					continue
+				}
+				schema := connectionPlugin.ConnectionMap[connectionName].Schema.Schema
+				sql := db_common.GetCommentsQueryForPlugin(connectionName, schema)
+
+				queriesMut.Lock()
+				queries[connectionName] = sql
+				queriesMut.Unlock()
+			}
+		}()
+	}
+	for _, connectionState := range updates {
+		connectionNames <- connectionState.ConnectionName
 	}
+	close(connectionNames)
+	wg.Wait()
 
-	schema := connectionPlugin.ConnectionMap[connectionName].Schema.Schema
-	// just get sql to execute update query, and update the connection state table, in a transaction
-	sql = db_common.GetCommentsQueryForPlugin(connectionName, schema)
+	return queries
+}
+
+// commentsBuildWorkers returns the number of workers used to build comments sql
+func commentsBuildWorkers() int {
+	return runtime.GOMAXPROCS(0)
+}
 
+// syncronously execute the comments query for a connection
+func (s *refreshConnectionState) updateCommentsForConnection(ctx context.Context, errChan chan *connectionError, connectionName, sql string) {
 	// comment cloning disabled for now
 	//// if this schema is static, add to the exemplar map
 	//state.exemplarSchemaMapMut.Lock()
@@ -633,50 +860,159 @@ func (s *refreshConnectionState) updateCommentsForConnection(ctx context.Context
 	//}
 }
 
+// executeCommentQuery executes the comments query for a connection, taking the configured comment lock first
 func (s *refreshConnectionState) executeCommentQuery(ctx context.Context, sql, connectionName string) error {
-	// create a transaction
-	tx, err := s.pool.Begin(ctx)
-	if err != nil {
-		return sperr.WrapWithMessage(err, "failed to create transaction to perform update query")
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		} else {
-			tx.Commit(ctx)
+	// update state table (inside transaction)
+	onComplete := func(tx sqlExecutor) error {
+		// ignore error
+		if err := s.tableUpdater.onConnectionCommentsLoaded(ctx, tx, connectionName); err != nil {
+			logWarn(ctx, "failed to set 'comments_set' for connection '%s': %s", connectionName, err.Error())
 		}
-	}()
+		return nil
+	}
+	lockStatement := commentLockStatement(s.opts.CommentLock)
+	err := s.executor.ApplyComments(ctx, connectionName, lockStatement, sql, onComplete)
 
-	// execute update sql
-	_, err = tx.Exec(ctx, sql)
-	if err != nil {
-		// update the state table
-		//(the transaction will be aborted - create a connection for the update)
-		if conn, poolErr := s.pool.Acquire(ctx); poolErr == nil {
-			defer conn.Release()
-			if statusErr := s.tableUpdater.onConnectionError(ctx, conn.Conn(), connectionName, err); statusErr != nil {
-				// NOTE: do not return the error - unless we failed to update the connection state table
-				return error_helpers.CombineErrorsWithPrefix(fmt.Sprintf("failed to update connection %s and failed to update connection_state table", connectionName), err, statusErr)
-			}
-		}
+	var stmtErr *statementError
+	if errors.As(err, &stmtErr) {
+		return s.onStatementError(ctx, connectionName, stmtErr.err)
+	}
+	return err
+}
 
-		return nil
+// getUpdateQuery returns the sql to create the schema for the given connection, and whether the plugin has an exemplar schema
+// if there is an exemplar schema for the plugin and cloning is not disabled, the exemplar schema is cloned,
+// otherwise the foreign schema is imported
+// it also returns whether the returned sql clones the exemplar schema
+// NOTE: exemplarSchemaMapMut must be locked by the caller
+func (s *refreshConnectionState) getUpdateQuery(ctx context.Context, connectionState *steampipeconfig.ConnectionState, cloneMode string) (sql string, haveExemplarSchema bool, isClone bool) {
+	// is this plugin in the exemplarSchemaMap
+	exemplarSchemaName, haveExemplarSchema := s.exemplarSchemaMap[connectionState.Plugin]
+	if haveExemplarSchema && exemplarSchemaName == "" {
+		// this is not expected - but rather than executing a broken clone query, fall back to importing the schema
+		// (treat the plugin as having no exemplar so this connection may become the exemplar)
+		logWarn(ctx, "no exemplar schema available for plugin %s - importing schema for connection %s", connectionState.Plugin, connectionState.ConnectionName)
+		haveExemplarSchema = false
+	}
+	// aggregator schemas combine the schemas of their child connections so must always be imported, never cloned
+	isAggregator := connectionState.GetType() == modconfig.ConnectionTypeAggregator
+	if haveExemplarSchema && cloneMode != constants.CloneSchemaNever && !isAggregator {
+		// we can clone!
+		return getCloneSchemaQuery(exemplarSchemaName, connectionState, s.opts.GrantPrivileges), haveExemplarSchema, true
 	}
+	// just get sql to execute update query, and update the connection state table, in a transaction
+	return importSchemaQuery(connectionState, s.opts.GrantPrivileges), haveExemplarSchema, false
+}
 
-	// update state table (inside transaction)
-	// ignore error
-	if err := s.tableUpdater.onConnectionCommentsLoaded(ctx, tx.Conn(), connectionName); err != nil {
-		log.Printf("[WARN] failed to set 'comments_set' for connection '%s': %s", connectionName, err.Error())
+// importSchemaQuery returns the sql to import the foreign schema for the given connection,
+// granting the given privileges to steampipe users
+func importSchemaQuery(connectionState *steampipeconfig.ConnectionState, privileges []string) string {
+	remoteSchema := utils.PluginFQNToSchemaName(connectionState.Plugin)
+	return db_common.GetUpdateConnectionQuery(connectionState.ConnectionName, remoteSchema, privileges, connectionTags(connectionState.ConnectionName))
+}
+
+// cloneFailedError is returned when cloning the exemplar schema for a connection fails
+type cloneFailedError struct {
+	err error
+}
+
+func (e *cloneFailedError) Error() string {
+	return fmt.Sprintf("failed to clone schema: %s", e.err.Error())
+}
+
+func (e *cloneFailedError) Unwrap() error {
+	return e.err
+}
+
+// isTransientError returns whether the error is a transient failure (i.e. a deadlock, serialization failure or
+// cancellation) rather than a failure of the statement itself
+func isTransientError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 40P01 deadlock_detected, 40001 serialization_failure, 57014 query_canceled
+		return pgErr.Code == "40P01" || pgErr.Code == "40001" || pgErr.Code == "57014"
 	}
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
 
+// connectionTags returns the tags declared in the config of the given connection
+func connectionTags(connectionName string) map[string]string {
+	if steampipeconfig.GlobalConfig == nil {
+		return nil
+	}
+	if connection, ok := steampipeconfig.GlobalConfig.Connections[connectionName]; ok {
+		return connection.Tags
+	}
 	return nil
 }
 
-func getCloneSchemaQuery(exemplarSchemaName string, connectionState *steampipeconfig.ConnectionState) string {
-	return fmt.Sprintf("select clone_foreign_schema('%s', '%s', '%s');", exemplarSchemaName, connectionState.ConnectionName, connectionState.Plugin)
+// schemaCommentsEnabled returns whether comments should be set for the given connection
+// this is determined by the schema_comments property of the connection config, if set,
+// falling back to the global schema comments setting (defaultEnabled)
+func schemaCommentsEnabled(connectionName string, defaultEnabled bool) bool {
+	if steampipeconfig.GlobalConfig != nil {
+		if connection, ok := steampipeconfig.GlobalConfig.Connections[connectionName]; ok && connection.SchemaComments != nil {
+			return *connection.SchemaComments
+		}
+	}
+	return defaultEnabled
 }
 
-func (s *refreshConnectionState) getInitialAndRemainingUpdates() (initialUpdates, remainingUpdates map[string]*steampipeconfig.ConnectionState, dynamicUpdates map[string][]*steampipeconfig.ConnectionState) {
+// connectionsWithSchemaComments returns the connections for which comments should be set
+// defaultEnabled is the global schema comments setting
+func connectionsWithSchemaComments(updates []*steampipeconfig.ConnectionState, defaultEnabled bool) []*steampipeconfig.ConnectionState {
+	var res []*steampipeconfig.ConnectionState
+	for _, connectionState := range updates {
+		if schemaCommentsEnabled(connectionState.ConnectionName, defaultEnabled) {
+			res = append(res, connectionState)
+		}
+	}
+	return res
+}
+
+// commentLockStatement returns the statement to take the lock for the given comment lock mode
+// (or an empty string if no lock should be taken)
+func commentLockStatement(mode string) string {
+	switch mode {
+	case constants.CommentLockTable:
+		return "lock table pg_namespace;"
+	case constants.CommentLockAdvisory:
+		return fmt.Sprintf("select pg_advisory_xact_lock(%d);", commentLockKey)
+	}
+	return ""
+}
+
+// canCloneSchema returns whether the schema for the given connection may be used as an exemplar
+// for other connections of the same plugin, using the given clone mode
+func canCloneSchema(cloneMode string, connectionState *steampipeconfig.ConnectionState) bool {
+	switch cloneMode {
+	case constants.CloneSchemaNever:
+		return false
+	case constants.CloneSchemaAlways:
+		// aggregator schemas are never used as exemplars as they combine the schemas of their children
+		return connectionState.GetType() != modconfig.ConnectionTypeAggregator
+	default:
+		return connectionState.CanCloneSchema()
+	}
+}
+
+func getCloneSchemaQuery(exemplarSchemaName string, connectionState *steampipeconfig.ConnectionState, privileges []string) string {
+	sql := fmt.Sprintf("select clone_foreign_schema('%s', '%s', '%s');", exemplarSchemaName, connectionState.ConnectionName, connectionState.Plugin)
+	// clone_foreign_schema grants select privileges - if other privileges are configured, replace them
+	if !slices.Equal(privileges, constants.DefaultGrantPrivileges) {
+		sql += "\n" + db_common.GetGrantPrivilegesQuery(connectionState.ConnectionName, privileges)
+	}
+	// clone_foreign_schema does not comment the schema - if the connection has tags, set the comment to include them
+	if tags := connectionTags(connectionState.ConnectionName); len(tags) > 0 {
+		sql += "\n" + db_common.GetSchemaCommentQuery(connectionState.ConnectionName, utils.PluginFQNToSchemaName(connectionState.Plugin), tags)
+	}
+	return sql
+}
+
+// getInitialAndRemainingUpdates splits the required updates into the sets which must be executed in order:
+// the first search path connection for each static plugin, the search path ordered updates for each dynamic plugin,
+// the remaining static connections and finally the aggregator connections, which depend on their child connections
+func (s *refreshConnectionState) getInitialAndRemainingUpdates(ctx context.Context) (initialUpdates, remainingUpdates map[string]*steampipeconfig.ConnectionState, dynamicUpdates map[string][]*steampipeconfig.ConnectionState, aggregatorUpdates map[string]*steampipeconfig.ConnectionState) {
 	updates := s.connectionUpdates.Update
 	searchPathConnections := s.connectionUpdates.FinalConnectionState.GetFirstSearchPathConnectionForPlugins(s.searchPath)
 
@@ -685,9 +1021,20 @@ func (s *refreshConnectionState) getInitialAndRemainingUpdates() (initialUpdates
 	// dynamic plugins must be updated for each plugin in search path order
 	// build a map keyed by plugin, with the value the ordered updates for that plugin
 	dynamicUpdates = make(map[string][]*steampipeconfig.ConnectionState)
+	aggregatorUpdates = make(map[string]*steampipeconfig.ConnectionState)
+
+	// aggregators are always updated after all other connections
+	for connectionName, connectionState := range updates {
+		if connectionState.GetType() == modconfig.ConnectionTypeAggregator {
+			aggregatorUpdates[connectionName] = connectionState
+		}
+	}
 
 	// convert this into a lookup of initial updates to execute
 	for _, connectionName := range searchPathConnections {
+		if _, isAggregator := aggregatorUpdates[connectionName]; isAggregator {
+			continue
+		}
 		if connectionState, updateRequired := updates[connectionName]; updateRequired {
 			if connectionState.SchemaMode == plugin.SchemaModeDynamic {
 				pluginInstance := *connectionState.PluginInstance
@@ -700,25 +1047,32 @@ func (s *refreshConnectionState) getInitialAndRemainingUpdates() (initialUpdates
 	// now add remaining updates to remainingUpdates
 	for connectionName, connectionState := range updates {
 		_, isInitialUpdate := initialUpdates[connectionName]
-		if connectionState.SchemaMode == plugin.SchemaModeStatic && !isInitialUpdate {
+		_, isAggregator := aggregatorUpdates[connectionName]
+		if connectionState.SchemaMode == plugin.SchemaModeStatic && !isInitialUpdate && !isAggregator {
 			remainingUpdates[connectionName] = connectionState
 		}
 
 	}
-	return initialUpdates, remainingUpdates, dynamicUpdates
+	// any connections which the initial updates depend on must also be updated initially
+	addDependencies(ctx, initialUpdates, remainingUpdates, s.connectionDependencies)
+	return initialUpdates, remainingUpdates, dynamicUpdates, aggregatorUpdates
 }
 
-func (s *refreshConnectionState) executeDeleteQueries(ctx context.Context, deletions []string) error {
+func (s *refreshConnectionState) executeDeleteQueries(ctx context.Context, deletions []string) (err error) {
+	ctx, span := startRefreshSpan(ctx, "executeDeleteQueries", attributeDeleteCount.Int(len(deletions)))
+	defer func() { endRefreshSpan(span, err) }()
 	t := time.Now()
-	log.Printf("[INFO] execute %d delete %s", len(deletions), utils.Pluralize("query", len(deletions)))
+	logInfo(ctx, "execute %d delete %s", len(deletions), utils.Pluralize("query", len(deletions)))
 	defer func() {
-		log.Printf("[INFO] completed execute delete queries (%fs)", time.Since(t).Seconds())
+		logInfo(ctx, "completed execute delete queries (%fs)", time.Since(t).Seconds())
 	}()
 
 	var errors []error
 
 	for _, c := range deletions {
-		err := s.executeDeleteQuery(ctx, c)
+		err := s.executeWithPoolRecovery(ctx, func() error {
+			return s.executeDeleteQuery(ctx, c)
+		})
 		if err != nil {
 			errors = append(errors, err)
 		}
@@ -729,42 +1083,31 @@ func (s *refreshConnectionState) executeDeleteQueries(ctx context.Context, delet
 // delete the schema and update remove the connection from the state table
 // NOTE: this only returns an error if we fail to update the state table
 func (s *refreshConnectionState) executeDeleteQuery(ctx context.Context, connectionName string) error {
-	// create a transaction
-	tx, err := s.pool.Begin(ctx)
-	if err != nil {
-		return sperr.WrapWithMessage(err, "failed to create transaction to perform delete query")
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		} else {
-			tx.Commit(ctx)
-		}
-	}()
-
 	sql := db_common.GetDeleteConnectionQuery(connectionName)
 
-	// execute delete sql
-	_, err = tx.Exec(ctx, sql)
-	if err != nil {
-		// update the state table
-		//(the transaction will be aborted - create a connection for the update)
-		if conn, poolErr := s.pool.Acquire(ctx); poolErr == nil {
-			defer conn.Release()
-			if statusErr := s.tableUpdater.onConnectionError(ctx, conn.Conn(), connectionName, err); statusErr != nil {
-				// NOTE: do not return the error - unless we failed to update the connection state table
-				return error_helpers.CombineErrorsWithPrefix(fmt.Sprintf("failed to update connection %s and failed to update connection_state table", connectionName), err, statusErr)
-			}
+	// delete state table entry (inside transaction)
+	err := s.executor.DeleteSchema(ctx, connectionName, sql, func(tx sqlExecutor) error {
+		if err := s.tableUpdater.onConnectionDeleted(ctx, tx, connectionName); err != nil {
+			return sperr.WrapWithMessage(err, "failed to delete connection state table entry for '%s'", connectionName)
 		}
-
 		return nil
-	}
+	})
 
-	// delete state table entry (inside transaction)
-	err = s.tableUpdater.onConnectionDeleted(ctx, tx.Conn(), connectionName)
+	var stmtErr *statementError
+	if errors.As(err, &stmtErr) {
+		// if the pool has lost its connection, return the error so the caller can recreate the pool and retry
+		if isPoolConnectionError(stmtErr.err) {
+			return stmtErr.err
+		}
+		return s.onStatementError(ctx, connectionName, stmtErr.err)
+	}
 	if err != nil {
-		return sperr.WrapWithMessage(err, "failed to delete connection state table entry for '%s'", connectionName)
+		return err
 	}
+
+	s.resMut.Lock()
+	s.res.DeletedConnections = append(s.res.DeletedConnections, connectionName)
+	s.resMut.Unlock()
 	return nil
 }
 
@@ -773,17 +1116,29 @@ func (s *refreshConnectionState) setIncompleteConnectionStateToError(ctx context
 	// create wrapped error
 	connectionStateError := sperr.WrapWithMessage(err, "failed to update Steampipe connections")
 	// load connection state
-	conn, err := s.pool.Acquire(ctx)
+	conn, err := s.getPool().Acquire(ctx)
 	if err != nil {
-		log.Printf("[WARN] setAllConnectionStateToError failed to acquire connection from pool: %s", err.Error())
+		logWarn(ctx, "setAllConnectionStateToError failed to acquire connection from pool: %s", err.Error())
 		return
 	}
 	defer conn.Release()
 
-	queries := introspection.GetIncompleteConnectionStateErrorSql(connectionStateError)
-
-	if _, err = db_local.ExecuteSqlWithArgsInTransaction(ctx, conn.Conn(), queries...); err != nil {
-		log.Printf("[WARN] setAllConnectionStateToError failed to set connection states to error: %s", err.Error())
+	err = pgx.BeginFunc(ctx, conn.Conn(), func(tx pgx.Tx) error {
+		return s.writeIncompleteConnectionStateErrors(ctx, tx, connectionStateError)
+	})
+	if err != nil {
+		logWarn(ctx, "setAllConnectionStateToError failed to set connection states to error: %s", err.Error())
 		return
 	}
 }
+
+// writeIncompleteConnectionStateErrors sets the state of all connections which are not ready, disabled
+// or already in error (i.e. pending or updating) to error
+func (s *refreshConnectionState) writeIncompleteConnectionStateErrors(ctx context.Context, executor sqlExecutor, err error) error {
+	for _, q := range introspection.GetIncompleteConnectionStateErrorSql(s.connectionStateTable, err) {
+		if _, err := executor.Exec(ctx, q.Query, q.Args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
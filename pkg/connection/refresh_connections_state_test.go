@@ -0,0 +1,582 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"golang.org/x/exp/maps"
+)
+
+const testPlugin = "hub.steampipe.io/plugins/turbot/test@latest"
+
+var testPluginModTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestConnectionState(name, state string) *steampipeconfig.ConnectionState {
+	return &steampipeconfig.ConnectionState{
+		ConnectionName: name,
+		Plugin:         testPlugin,
+		State:          state,
+		SchemaMode:     plugin.SchemaModeStatic,
+		PluginModTime:  testPluginModTime,
+	}
+}
+
+type exemplarSchemaTest struct {
+	current        steampipeconfig.ConnectionStateMap
+	delete         []string
+	expectClone    bool
+	expectExemplar string
+}
+
+var testCasesExemplarSchema = map[string]exemplarSchemaTest{
+	"existing ready connection": {
+		current:        steampipeconfig.ConnectionStateMap{"a": newTestConnectionState("a", constants.ConnectionStateReady)},
+		expectClone:    true,
+		expectExemplar: "a",
+	},
+	"existing connection deleted": {
+		current: steampipeconfig.ConnectionStateMap{"a": newTestConnectionState("a", constants.ConnectionStateReady)},
+		delete:  []string{"a"},
+	},
+	"existing connection in error": {
+		current: steampipeconfig.ConnectionStateMap{"a": newTestConnectionState("a", constants.ConnectionStateError)},
+	},
+	"no existing connection": {
+		current: steampipeconfig.ConnectionStateMap{},
+	},
+}
+
+func TestUpdateQueryUsesExistingExemplarSchema(t *testing.T) {
+	for name, test := range testCasesExemplarSchema {
+		// a second refresh, adding connection 'b' for a plugin which may already have a connection
+		newConnection := newTestConnectionState("b", constants.ConnectionStatePending)
+		final := steampipeconfig.ConnectionStateMap{"b": newConnection}
+		for connectionName, c := range test.current {
+			final[connectionName] = c
+		}
+		updates := &steampipeconfig.ConnectionUpdates{
+			Update:                 steampipeconfig.ConnectionStateMap{"b": newConnection},
+			Delete:                 make(map[string]struct{}),
+			Error:                  make(map[string]struct{}),
+			CurrentConnectionState: test.current,
+			FinalConnectionState:   final,
+		}
+		for _, connectionName := range test.delete {
+			updates.Delete[connectionName] = struct{}{}
+			delete(final, connectionName)
+		}
+
+		canClone := func(c *steampipeconfig.ConnectionState) bool { return canCloneSchema(constants.CloneSchemaAuto, c) }
+		s := &refreshConnectionState{opts: testRefreshOptions(), connectionUpdates: updates, exemplarSchemaMap: updates.ExemplarSchemas(canClone)}
+		sql, _, _ := s.getUpdateQuery(context.Background(), newConnection, constants.CloneSchemaAuto)
+
+		isClone := strings.Contains(sql, "clone_foreign_schema")
+		if isClone != test.expectClone {
+			t.Errorf("Test: '%s' FAILED : expected clone=%v, got sql: %s", name, test.expectClone, sql)
+		}
+		if exemplar := s.exemplarSchemaMap[testPlugin]; exemplar != test.expectExemplar {
+			t.Errorf("Test: '%s' FAILED : expected exemplar '%s', got '%s'", name, test.expectExemplar, exemplar)
+		}
+	}
+}
+
+type cloneSchemaModeTest struct {
+	mode       string
+	schemaMode string
+	// is the connection schema registered as an exemplar
+	expectExemplar bool
+	// is the schema of a subsequent connection cloned
+	expectClone bool
+}
+
+var testCasesCloneSchemaMode = map[string]cloneSchemaModeTest{
+	"auto static":    {mode: constants.CloneSchemaAuto, schemaMode: plugin.SchemaModeStatic, expectExemplar: true, expectClone: true},
+	"auto dynamic":   {mode: constants.CloneSchemaAuto, schemaMode: plugin.SchemaModeDynamic, expectExemplar: false, expectClone: false},
+	"always static":  {mode: constants.CloneSchemaAlways, schemaMode: plugin.SchemaModeStatic, expectExemplar: true, expectClone: true},
+	"always dynamic": {mode: constants.CloneSchemaAlways, schemaMode: plugin.SchemaModeDynamic, expectExemplar: true, expectClone: true},
+	"never static":   {mode: constants.CloneSchemaNever, schemaMode: plugin.SchemaModeStatic, expectExemplar: false, expectClone: false},
+	"never dynamic":  {mode: constants.CloneSchemaNever, schemaMode: plugin.SchemaModeDynamic, expectExemplar: false, expectClone: false},
+}
+
+func TestCloneSchemaMode(t *testing.T) {
+	for name, test := range testCasesCloneSchemaMode {
+		exemplar := newTestConnectionState("a", constants.ConnectionStateReady)
+		exemplar.SchemaMode = test.schemaMode
+		newConnection := newTestConnectionState("b", constants.ConnectionStatePending)
+		newConnection.SchemaMode = test.schemaMode
+
+		if canClone := canCloneSchema(test.mode, exemplar); canClone != test.expectExemplar {
+			t.Errorf("Test: '%s' FAILED : expected canCloneSchema=%v, got %v", name, test.expectExemplar, canClone)
+		}
+
+		// simulate the exemplar having been registered by a previous update
+		s := &refreshConnectionState{opts: testRefreshOptions(), exemplarSchemaMap: map[string]string{}}
+		if canCloneSchema(test.mode, exemplar) {
+			s.exemplarSchemaMap[testPlugin] = exemplar.ConnectionName
+		}
+		sql, _, _ := s.getUpdateQuery(context.Background(), newConnection, test.mode)
+		if isClone := strings.Contains(sql, "clone_foreign_schema"); isClone != test.expectClone {
+			t.Errorf("Test: '%s' FAILED : expected clone=%v, got sql: %s", name, test.expectClone, sql)
+		}
+	}
+}
+
+func TestCloneSchemaModeForcesImportWithExemplar(t *testing.T) {
+	// even if an exemplar exists, 'never' must import the schema
+	s := &refreshConnectionState{opts: testRefreshOptions(), exemplarSchemaMap: map[string]string{testPlugin: "a"}}
+	sql, haveExemplar, _ := s.getUpdateQuery(context.Background(), newTestConnectionState("b", constants.ConnectionStatePending), constants.CloneSchemaNever)
+	if !haveExemplar || strings.Contains(sql, "clone_foreign_schema") {
+		t.Errorf("expected schema import when clone mode is 'never', got sql: %s", sql)
+	}
+}
+
+func TestUpdateQueryMissingExemplarSchema(t *testing.T) {
+	connectionState := newTestConnectionState("b", constants.ConnectionStatePending)
+	exemplarMaps := map[string]map[string]string{
+		"no entry":    {},
+		"empty entry": {testPlugin: ""},
+	}
+	for name, exemplarSchemaMap := range exemplarMaps {
+		s := &refreshConnectionState{opts: testRefreshOptions(), exemplarSchemaMap: exemplarSchemaMap}
+		sql, haveExemplar, _ := s.getUpdateQuery(context.Background(), connectionState, constants.CloneSchemaAuto)
+		if strings.Contains(sql, "clone_foreign_schema") || !strings.Contains(sql, "import foreign schema") {
+			t.Errorf("Test: '%s' FAILED : expected fallback to schema import, got sql: %s", name, sql)
+		}
+		// the connection should be able to become the exemplar
+		if haveExemplar {
+			t.Errorf("Test: '%s' FAILED : expected haveExemplar to be false", name)
+		}
+	}
+}
+
+func TestCloneFailureFallsBackToImport(t *testing.T) {
+	s := newUpdateTestState()
+	logger := &capturingLogger{}
+	ctx := WithLogger(context.Background(), logger)
+	connectionState := s.connectionUpdates.Update["a"]
+	db := &fakeDatabase{onRead: func([]string) {}}
+
+	var executed []string
+	execute := func(sql string, isClone bool) error {
+		executed = append(executed, sql)
+		tx := &fakeTx{db: db}
+		if isClone {
+			tx.execErr = &pgconn.PgError{Code: "XX000", Message: "clone_foreign_schema failed"}
+		}
+		s.executor = newFakeTxExecutor(tx)
+		return s.executeUpdateQuery(ctx, sql, "a", isClone)
+	}
+
+	cloneSql := getCloneSchemaQuery("b", connectionState, constants.DefaultGrantPrivileges)
+	if err := s.executeUpdateWithCloneFallback(ctx, connectionState, cloneSql, true, execute); err != nil {
+		t.Fatal(err)
+	}
+
+	importSql := importSchemaQuery(connectionState, constants.DefaultGrantPrivileges)
+	if !reflect.DeepEqual(executed, []string{cloneSql, importSql}) {
+		t.Errorf("expected the clone to be attempted then the schema to be imported, got %v", executed)
+	}
+	if !reflect.DeepEqual(s.res.CreatedConnections, []string{"a"}) || len(s.res.ClonedConnections) != 0 || len(s.res.FailedConnections) != 0 {
+		t.Errorf("expected the connection to be recorded as created, got created %v, cloned %v, failed %v", s.res.CreatedConnections, s.res.ClonedConnections, s.res.FailedConnections)
+	}
+	if len(db.committed) == 0 || db.committed[0] != importSql {
+		t.Errorf("expected the imported schema to be committed, got %v", db.committed)
+	}
+	var warnings []string
+	for _, message := range logger.messages {
+		if strings.HasPrefix(message, "warn: ") {
+			warnings = append(warnings, message)
+		}
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "warn: failed to clone schema for connection a") || !strings.Contains(warnings[0], "falling back to importing the schema") {
+		t.Errorf("expected the fallback to be logged, got %v", logger.messages)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	testCases := map[string]struct {
+		err       error
+		transient bool
+	}{
+		"deadlock":              {&pgconn.PgError{Code: "40P01"}, true},
+		"serialization failure": {&pgconn.PgError{Code: "40001"}, true},
+		"query cancelled":       {&pgconn.PgError{Code: "57014"}, true},
+		"context cancelled":     {fmt.Errorf("clone failed: %w", context.Canceled), true},
+		"internal error":        {&pgconn.PgError{Code: "XX000"}, false},
+		"duplicate object":      {&pgconn.PgError{Code: "42710"}, false},
+	}
+	for name, test := range testCases {
+		if actual := isTransientError(test.err); actual != test.transient {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.transient, actual)
+		}
+	}
+}
+
+// statusRecorder is a status hook which records all statuses which are set
+type statusRecorder struct {
+	statushooks.StatusHooks
+	mut      sync.Mutex
+	statuses []string
+}
+
+func (r *statusRecorder) SetStatus(status string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.statuses = append(r.statuses, status)
+}
+
+func TestCommentsProgress(t *testing.T) {
+	recorder := &statusRecorder{StatusHooks: statushooks.NullHooks}
+	ctx := statushooks.AddStatusHooksToContext(context.Background(), recorder)
+
+	connections := []string{"aws", "gcp", "azure"}
+	progress := newCommentsProgress(len(connections), nil)
+	for _, c := range connections {
+		progress.start(ctx, c)
+	}
+
+	expected := []string{
+		"Commenting 1 of 3 connections (aws)",
+		"Commenting 2 of 3 connections (gcp)",
+		"Commenting 3 of 3 connections (azure)",
+	}
+	if !reflect.DeepEqual(recorder.statuses, expected) {
+		t.Errorf("expected statuses %v, got %v", expected, recorder.statuses)
+	}
+}
+
+func TestSetInterruptedError(t *testing.T) {
+	s := &refreshConnectionState{
+		opts: testRefreshOptions(),
+		connectionUpdates: &steampipeconfig.ConnectionUpdates{
+			Update: steampipeconfig.ConnectionStateMap{
+				"a": newTestConnectionState("a", constants.ConnectionStatePending),
+				"b": newTestConnectionState("b", constants.ConnectionStatePending),
+				"c": newTestConnectionState("c", constants.ConnectionStatePending),
+			},
+		},
+		res: &steampipeconfig.RefreshConnectionResult{CreatedConnections: []string{"b"}},
+	}
+
+	// a refresh which has not timed out must not set an error
+	if s.setInterruptedError(context.Background()) || s.res.Error != nil {
+		t.Fatalf("expected no timeout error for a live context")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if !s.setInterruptedError(ctx) || s.res.Error == nil {
+		t.Fatalf("expected a timeout error")
+	}
+	if msg := s.res.Error.Error(); !strings.Contains(msg, "2 connections not updated: a, c") {
+		t.Errorf("expected timeout error to list incomplete connections, got: %s", msg)
+	}
+}
+
+// connectionStateTable simulates the connection state table, applying the statements which set the state of
+// incomplete connections to error - as for a real connection, statements fail if the context is done
+type connectionStateTable struct {
+	states map[string]string
+}
+
+func (c *connectionStateTable) Exec(ctx context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	if err := ctx.Err(); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	if strings.Contains(sql, fmt.Sprintf("SET state = '%s'", constants.ConnectionStateError)) {
+		for name, state := range c.states {
+			if state != constants.ConnectionStateReady && state != constants.ConnectionStateDisabled {
+				c.states[name] = constants.ConnectionStateError
+			}
+		}
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func TestCancelledRefreshLeavesNoConnectionUpdating(t *testing.T) {
+	s := &refreshConnectionState{
+		opts: testRefreshOptions(),
+		connectionUpdates: &steampipeconfig.ConnectionUpdates{
+			Update: steampipeconfig.ConnectionStateMap{
+				"a": newTestConnectionState("a", constants.ConnectionStateUpdating),
+				"b": newTestConnectionState("b", constants.ConnectionStateUpdating),
+				"c": newTestConnectionState("c", constants.ConnectionStateUpdating),
+			},
+		},
+		connectionStateTable: steampipeconfig.DefaultConnectionStateTable(),
+		res:                  &steampipeconfig.RefreshConnectionResult{},
+	}
+	table := &connectionStateTable{states: map[string]string{
+		"a":        constants.ConnectionStateUpdating,
+		"b":        constants.ConnectionStateUpdating,
+		"c":        constants.ConnectionStatePending,
+		"disabled": constants.ConnectionStateDisabled,
+	}}
+
+	// cancel the refresh once the first connection has been updated
+	ctx, cancel := context.WithCancel(context.Background())
+	s.res.CreatedConnections = append(s.res.CreatedConnections, "b")
+	table.states["b"] = constants.ConnectionStateReady
+	cancel()
+
+	completionCtx, completionCancel := s.completionContext(ctx)
+	defer completionCancel()
+	if completionCtx.Err() != nil {
+		t.Fatalf("expected the completion context to be usable after the refresh was cancelled")
+	}
+	if s.res.Error == nil || !strings.Contains(s.res.Error.Error(), "connection refresh interrupted - 2 connections not updated: a, c") {
+		t.Fatalf("expected an interrupted error listing the incomplete connections, got: %v", s.res.Error)
+	}
+	if err := s.writeIncompleteConnectionStateErrors(completionCtx, table, s.res.Error); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"a":        constants.ConnectionStateError,
+		"b":        constants.ConnectionStateReady,
+		"c":        constants.ConnectionStateError,
+		"disabled": constants.ConnectionStateDisabled,
+	}
+	if !reflect.DeepEqual(table.states, expected) {
+		t.Errorf("expected connection states %v, got %v", expected, table.states)
+	}
+}
+
+func TestAggregatorUpdatedAfterChildren(t *testing.T) {
+	aggregatorType := modconfig.ConnectionTypeAggregator
+	aggregator := newTestConnectionState("all", constants.ConnectionStatePending)
+	aggregator.Type = &aggregatorType
+	child1 := newTestConnectionState("child1", constants.ConnectionStatePending)
+	child2 := newTestConnectionState("child2", constants.ConnectionStatePending)
+
+	updates := steampipeconfig.ConnectionStateMap{"all": aggregator, "child1": child1, "child2": child2}
+	s := &refreshConnectionState{
+		opts: testRefreshOptions(),
+		connectionUpdates: &steampipeconfig.ConnectionUpdates{
+			Update:               updates,
+			FinalConnectionState: updates,
+		},
+		// the aggregator is first in the search path
+		searchPath: []string{"all", "child1", "child2"},
+	}
+
+	initialUpdates, remainingUpdates, dynamicUpdates, aggregatorUpdates := s.getInitialAndRemainingUpdates(context.Background())
+	if !reflect.DeepEqual(maps.Keys(aggregatorUpdates), []string{"all"}) {
+		t.Errorf("expected the aggregator to be updated last, got aggregator updates %v", maps.Keys(aggregatorUpdates))
+	}
+	for _, childUpdates := range []map[string]*steampipeconfig.ConnectionState{initialUpdates, remainingUpdates} {
+		if _, ok := childUpdates["all"]; ok {
+			t.Errorf("expected the aggregator not to be updated with its children")
+		}
+	}
+	if len(initialUpdates)+len(remainingUpdates) != 2 || len(dynamicUpdates) != 0 {
+		t.Errorf("expected both children to be updated before the aggregator, got initial %v, remaining %v", maps.Keys(initialUpdates), maps.Keys(remainingUpdates))
+	}
+
+	// once a child has been imported and registered as the exemplar, the aggregator must still be imported
+	s.exemplarSchemaMap = map[string]string{testPlugin: "child1"}
+	for _, cloneMode := range []string{constants.CloneSchemaAuto, constants.CloneSchemaAlways} {
+		sql, _, isClone := s.getUpdateQuery(context.Background(), aggregator, cloneMode)
+		if isClone || strings.Contains(sql, "clone_foreign_schema") {
+			t.Errorf("clone mode '%s': expected aggregator schema to be imported, got sql: %s", cloneMode, sql)
+		}
+		if canCloneSchema(cloneMode, aggregator) {
+			t.Errorf("clone mode '%s': expected aggregator not to be usable as an exemplar", cloneMode)
+		}
+	}
+	// the children may still be cloned
+	if _, _, isClone := s.getUpdateQuery(context.Background(), child2, constants.CloneSchemaAuto); !isClone {
+		t.Errorf("expected child connection to be cloned from the exemplar")
+	}
+}
+
+func TestQuietRefreshDisablesStatusHooks(t *testing.T) {
+	prevQuiet := viper.GetBool(constants.ArgQuiet)
+	defer viper.Set(constants.ArgQuiet, prevQuiet)
+
+	for _, quiet := range []bool{false, true} {
+		viper.Set(constants.ArgQuiet, quiet)
+
+		recorder := &statusRecorder{StatusHooks: statushooks.NullHooks}
+		ctx := refreshStatusContext(statushooks.AddStatusHooksToContext(context.Background(), recorder))
+
+		newCommentsProgress(1, nil).start(ctx, "aws")
+
+		if quiet && len(recorder.statuses) != 0 {
+			t.Errorf("quiet=%v: expected no statuses, got %v", quiet, recorder.statuses)
+		}
+		if !quiet && len(recorder.statuses) != 1 {
+			t.Errorf("quiet=%v: expected a single status, got %v", quiet, recorder.statuses)
+		}
+
+		// errors must still be returned in the refresh result
+		s := &refreshConnectionState{
+			opts: testRefreshOptions(),
+			connectionUpdates: &steampipeconfig.ConnectionUpdates{
+				Update: steampipeconfig.ConnectionStateMap{"aws": newTestConnectionState("aws", constants.ConnectionStatePending)},
+			},
+			res: &steampipeconfig.RefreshConnectionResult{},
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+		<-timeoutCtx.Done()
+		s.setInterruptedError(timeoutCtx)
+		cancel()
+		if s.res.Error == nil {
+			t.Errorf("quiet=%v: expected the refresh result to contain an error", quiet)
+		}
+	}
+}
+
+func TestAddMissingPluginWarnings(t *testing.T) {
+	s := &refreshConnectionState{
+		opts: testRefreshOptions(),
+		connectionUpdates: &steampipeconfig.ConnectionUpdates{
+			MissingPlugins: map[string][]modconfig.Connection{
+				"aws": {{Name: "aws_dev"}, {Name: "aws_prod"}},
+				"gcp": {{Name: "gcp"}},
+			},
+		},
+		res: &steampipeconfig.RefreshConnectionResult{},
+	}
+
+	s.addMissingPluginWarnings(context.Background())
+
+	if len(s.res.Warnings) != 1 || !strings.Contains(s.res.Warnings[0], "steampipe plugin install aws gcp") {
+		t.Errorf("expected a warning containing the plugin install command, got %v", s.res.Warnings)
+	}
+
+	expected := map[string][]string{
+		"aws": {"aws_dev", "aws_prod"},
+		"gcp": {"gcp"},
+	}
+	if !reflect.DeepEqual(s.res.MissingPlugins, expected) {
+		t.Errorf("expected missing plugins %v, got %v", expected, s.res.MissingPlugins)
+	}
+}
+
+func TestCommentLock(t *testing.T) {
+	commentsSQL := `COMMENT ON FOREIGN TABLE "a"."t" is 'test';`
+	// map of comment lock mode to the expected leading statement of the transaction
+	testCases := map[string]string{
+		constants.CommentLockNone:     commentsSQL,
+		constants.CommentLockTable:    "lock table pg_namespace;",
+		constants.CommentLockAdvisory: fmt.Sprintf("select pg_advisory_xact_lock(%d);", commentLockKey),
+	}
+
+	for mode, expected := range testCases {
+		db := &fakeDatabase{onRead: func([]string) {}}
+		s := newUpdateTestState()
+		s.opts.CommentLock = mode
+
+		s.executor = newFakeTxExecutor(&fakeTx{db: db})
+		if err := s.executeCommentQuery(context.Background(), commentsSQL, "a"); err != nil {
+			t.Errorf("mode %s: unexpected error: %s", mode, err.Error())
+			continue
+		}
+		if len(db.committed) == 0 || db.committed[0] != expected {
+			t.Errorf("mode %s: expected leading statement %q, got %v", mode, expected, db.committed)
+		}
+	}
+}
+
+func TestBuildCommentsQueries(t *testing.T) {
+	// build connections for a number of plugins, each with a schema containing commented tables and columns
+	const pluginCount = 20
+	var updates []*steampipeconfig.ConnectionState
+	plugins := make(map[string]*steampipeconfig.ConnectionPlugin)
+	for i := 0; i < pluginCount; i++ {
+		connectionName := fmt.Sprintf("conn_%d", i)
+		schema := make(map[string]*proto.TableSchema)
+		for j := 0; j < 10; j++ {
+			schema[fmt.Sprintf("table_%d", j)] = &proto.TableSchema{
+				Description: fmt.Sprintf("table %d of plugin %d", j, i),
+				Columns: []*proto.ColumnDefinition{
+					{Name: "id", Description: "the id"},
+					{Name: "name", Description: "the 'name'"},
+				},
+			}
+		}
+		updates = append(updates, newTestConnectionState(connectionName, constants.ConnectionStateUpdating))
+		plugins[connectionName] = &steampipeconfig.ConnectionPlugin{
+			PluginName: fmt.Sprintf("plugin_%d", i),
+			ConnectionMap: map[string]*steampipeconfig.ConnectionPluginData{
+				connectionName: {Name: connectionName, Schema: &proto.Schema{Schema: schema}},
+			},
+		}
+	}
+	// a connection with no plugin loaded is omitted
+	updates = append(updates, newTestConnectionState("no_plugin", constants.ConnectionStateUpdating))
+
+	// build the expected queries serially
+	expected := make(map[string]string)
+	for connectionName, connectionPlugin := range plugins {
+		expected[connectionName] = db_common.GetCommentsQueryForPlugin(connectionName, connectionPlugin.ConnectionMap[connectionName].Schema.Schema)
+	}
+
+	for _, workers := range []int{0, 1, 4, pluginCount * 2} {
+		queries := buildCommentsQueries(context.Background(), updates, plugins, workers)
+		if !reflect.DeepEqual(queries, expected) {
+			t.Errorf("Test: %d workers FAILED : comments queries differ from the serially built queries", workers)
+		}
+	}
+}
+
+func TestPerConnectionSchemaComments(t *testing.T) {
+	prevConfig := steampipeconfig.GlobalConfig
+	defer func() { steampipeconfig.GlobalConfig = prevConfig }()
+
+	// comments are globally enabled, but disabled for a single connection
+	disabled := false
+	steampipeconfig.GlobalConfig = steampipeconfig.NewSteampipeConfig("")
+	steampipeconfig.GlobalConfig.Connections = map[string]*modconfig.Connection{
+		"commented":   {Name: "commented"},
+		"uncommented": {Name: "uncommented", SchemaComments: &disabled},
+	}
+
+	schema := map[string]*proto.TableSchema{
+		"table": {Description: "a table", Columns: []*proto.ColumnDefinition{{Name: "id", Description: "the id"}}},
+	}
+	var updates []*steampipeconfig.ConnectionState
+	plugins := make(map[string]*steampipeconfig.ConnectionPlugin)
+	for connectionName := range steampipeconfig.GlobalConfig.Connections {
+		updates = append(updates, newTestConnectionState(connectionName, constants.ConnectionStateUpdating))
+		plugins[connectionName] = &steampipeconfig.ConnectionPlugin{
+			PluginName: testPlugin,
+			ConnectionMap: map[string]*steampipeconfig.ConnectionPluginData{
+				connectionName: {Name: connectionName, Schema: &proto.Schema{Schema: schema}},
+			},
+		}
+	}
+
+	queries := buildCommentsQueries(context.Background(), connectionsWithSchemaComments(updates, true), plugins, 1)
+	if !strings.Contains(strings.ToLower(queries["commented"]), "comment on") {
+		t.Errorf("expected comments to be set for connection 'commented', got sql: %s", queries["commented"])
+	}
+	if sql, ok := queries["uncommented"]; ok {
+		t.Errorf("expected no comments to be set for connection 'uncommented', got sql: %s", sql)
+	}
+
+	// a connection may enable comments when they are globally disabled
+	enabled := true
+	steampipeconfig.GlobalConfig.Connections["uncommented"].SchemaComments = &enabled
+	commented := connectionsWithSchemaComments(updates, false)
+	if len(commented) != 1 || commented[0].ConnectionName != "uncommented" {
+		t.Errorf("expected only connection 'uncommented' to have comments set, got %v", commented)
+	}
+}
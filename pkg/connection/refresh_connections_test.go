@@ -0,0 +1,60 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func TestConnectionsForPlugins(t *testing.T) {
+	connections := map[string]*modconfig.Connection{
+		"aws_dev":  {Name: "aws_dev", PluginAlias: "aws", Plugin: "hub.steampipe.io/plugins/turbot/aws@latest"},
+		"aws_prod": {Name: "aws_prod", PluginAlias: "turbot/aws", Plugin: "hub.steampipe.io/plugins/turbot/aws@latest"},
+		"gcp":      {Name: "gcp", PluginAlias: "gcp", Plugin: "hub.steampipe.io/plugins/turbot/gcp@latest"},
+		"azure":    {Name: "azure", PluginAlias: "azure", Plugin: "hub.steampipe.io/plugins/turbot/azure@latest"},
+	}
+
+	connectionNames, unmatched := connectionsForPlugins(connections, []string{"aws", "gcp", "github"})
+
+	expectedConnections := []string{"aws_dev", "aws_prod", "gcp"}
+	if !reflect.DeepEqual(connectionNames, expectedConnections) {
+		t.Errorf("expected connections %v, got %v", expectedConnections, connectionNames)
+	}
+	expectedUnmatched := []string{"github"}
+	if !reflect.DeepEqual(unmatched, expectedUnmatched) {
+		t.Errorf("expected unmatched plugins %v, got %v", expectedUnmatched, unmatched)
+	}
+}
+
+func TestRefreshSearchPathOnly(t *testing.T) {
+	testCases := map[string]struct {
+		searchPathErr error
+		warnings      int
+	}{
+		"search path set":    {nil, 1},
+		"search path failed": {errors.New("cannot execute ALTER ROLE in a read-only transaction"), 2},
+	}
+	for name, test := range testCases {
+		searchPathCalls := 0
+		res := refreshSearchPathOnly(context.Background(), func(context.Context) error {
+			searchPathCalls++
+			return test.searchPathErr
+		})
+
+		// the existing search path is still applied
+		if searchPathCalls != 1 {
+			t.Errorf("Test: '%s' FAILED : expected the search path to be set once, got %d", name, searchPathCalls)
+		}
+		// no connection schemas are updated or deleted, and the refresh succeeds
+		if res.Error != nil || res.UpdatedConnections || len(res.CreatedConnections)+len(res.ClonedConnections)+len(res.DeletedConnections) != 0 {
+			t.Errorf("Test: '%s' FAILED : expected no error and no connection updates, got %+v", name, res)
+		}
+		if len(res.Warnings) != test.warnings || !strings.Contains(res.Warnings[0], "connection schemas may be out of date") {
+			t.Errorf("Test: '%s' FAILED : expected %d warnings including a stale schema warning, got %v", name, test.warnings, res.Warnings)
+		}
+	}
+}
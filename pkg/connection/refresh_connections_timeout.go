@@ -0,0 +1,77 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// the time allowed to update the connection state table after a refresh has timed out
+const refreshCompletionTimeout = 10 * time.Second
+
+// setInterruptedError checks whether the refresh context deadline has been exceeded or the context has been
+// cancelled (e.g. the process received SIGTERM), and if so sets the result error, listing the connections
+// which were not updated
+// returns whether the refresh was interrupted
+func (s *refreshConnectionState) setInterruptedError(ctx context.Context) bool {
+	var msg string
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		msg = "connection refresh timed out"
+		if timeout := s.opts.refreshTimeout(); timeout > 0 {
+			msg = fmt.Sprintf("connection refresh timed out after %s", timeout)
+		}
+	case errors.Is(ctx.Err(), context.Canceled):
+		msg = "connection refresh interrupted"
+	default:
+		return false
+	}
+
+	incomplete := s.incompleteConnections()
+	if len(incomplete) > 0 {
+		msg = fmt.Sprintf("%s - %d %s not updated: %s", msg, len(incomplete), utils.Pluralize("connection", len(incomplete)), strings.Join(incomplete, ", "))
+	}
+
+	s.resMut.Lock()
+	s.res.Error = errors.New(msg)
+	s.resMut.Unlock()
+	return true
+}
+
+// completionContext returns the context used to update the connection state table and send notifications once
+// the refresh is complete - if the refresh timed out or was cancelled, ctx can no longer be used for this so a
+// context with the same values but with a new (short) deadline is returned
+func (s *refreshConnectionState) completionContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if !s.setInterruptedError(ctx) {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), refreshCompletionTimeout)
+}
+
+// incompleteConnections returns the (sorted) names of connections which require an update
+// but have not been created, cloned or failed
+func (s *refreshConnectionState) incompleteConnections() []string {
+	if s.connectionUpdates == nil {
+		return nil
+	}
+
+	s.resMut.Lock()
+	defer s.resMut.Unlock()
+
+	var incomplete []string
+	for _, name := range utils.SortedMapKeys(s.connectionUpdates.Update) {
+		if helpers.StringSliceContains(s.res.CreatedConnections, name) || helpers.StringSliceContains(s.res.ClonedConnections, name) {
+			continue
+		}
+		if _, failed := s.res.FailedConnections[name]; failed {
+			continue
+		}
+		incomplete = append(incomplete, name)
+	}
+	return incomplete
+}
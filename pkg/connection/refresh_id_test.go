@@ -0,0 +1,76 @@
+package connection
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// notifyingPluginManager records the refresh ID of each errors and warnings notification sent
+// (all other methods are unimplemented)
+type notifyingPluginManager struct {
+	pluginManager
+	notificationRefreshIDs []string
+}
+
+func (m *notifyingPluginManager) SendPostgresErrorsAndWarningsNotification(ctx context.Context, _ *error_helpers.ErrorAndWarnings) {
+	m.notificationRefreshIDs = append(m.notificationRefreshIDs, RefreshIDFromContext(ctx))
+}
+
+func TestRefreshID(t *testing.T) {
+	prevConfig := steampipeconfig.GlobalConfig
+	defer func() { steampipeconfig.GlobalConfig = prevConfig }()
+	steampipeconfig.GlobalConfig = steampipeconfig.NewSteampipeConfig("")
+
+	// the refresh ID is included in every log message
+	logger := &capturingLogger{}
+	ctx := WithLogger(WithRefreshID(context.Background(), "refresh-1"), logger)
+	updates := map[string]*steampipeconfig.ConnectionState{"a": newTestConnectionState("a", constants.ConnectionStatePending)}
+	otherUpdates := map[string]*steampipeconfig.ConnectionState{"b": newTestConnectionState("b", constants.ConnectionStatePending)}
+	addDependencies(ctx, updates, otherUpdates, map[string][]string{"a": {"b"}})
+	if len(logger.messages) == 0 {
+		t.Fatal("expected log messages")
+	}
+	for _, message := range logger.messages {
+		if !strings.Contains(message, "[refresh refresh-1]") {
+			t.Errorf("expected message to include the refresh ID: %s", message)
+		}
+	}
+
+	// the refresh ID is sent with notifications
+	// (there are no connections for the plugin, so nothing is force updated, but a single warning notification is sent)
+	pluginManager := &notifyingPluginManager{}
+	if connectionNames := forceUpdateConnectionsForPlugins(ctx, pluginManager, []string{"aws"}); len(connectionNames) != 0 {
+		t.Errorf("expected no connections to force update, got %v", connectionNames)
+	}
+	if len(pluginManager.notificationRefreshIDs) != 1 || pluginManager.notificationRefreshIDs[0] != "refresh-1" {
+		t.Errorf("expected notification refresh ID 'refresh-1', got %v", pluginManager.notificationRefreshIDs)
+	}
+
+	// if there is no refresh ID, one is generated
+	ctx, refreshID := ensureRefreshID(context.Background())
+	if _, err := uuid.Parse(refreshID); err != nil || RefreshIDFromContext(ctx) != refreshID {
+		t.Errorf("expected a generated refresh ID, got '%s'", refreshID)
+	}
+	// an existing refresh ID is kept
+	if _, refreshID := ensureRefreshID(WithRefreshID(context.Background(), "refresh-1")); refreshID != "refresh-1" {
+		t.Errorf("expected refresh ID 'refresh-1', got '%s'", refreshID)
+	}
+
+	// without a refresh ID, messages are not prefixed
+	if format, args := withRefreshID(context.Background(), "message %s", []any{"a"}); format != "message %s" || len(args) != 1 {
+		t.Errorf("expected an unprefixed message, got '%s' %v", format, args)
+	}
+
+	// a refresh ID containing a format verb is logged verbatim
+	logger = &capturingLogger{}
+	logInfo(WithLogger(WithRefreshID(context.Background(), "id-%d"), logger), "connection '%s'", "a")
+	if expected := "info: [refresh id-%d] connection 'a'"; len(logger.messages) != 1 || logger.messages[0] != expected {
+		t.Errorf("expected message %q, got %v", expected, logger.messages)
+	}
+}
@@ -0,0 +1,96 @@
+package connection
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// Logger receives the log messages of a connection refresh
+// this allows an embedding application to capture refresh logging with its own (e.g. structured) logger
+type Logger interface {
+	Trace(format string, args ...any)
+	Debug(format string, args ...any)
+	Info(format string, args ...any)
+	Warn(format string, args ...any)
+	Error(format string, args ...any)
+}
+
+// globalLogger writes to the global logger, prefixing each message with its level
+// - this is the default refresh logger
+type globalLogger struct{}
+
+func (globalLogger) Trace(format string, args ...any) { log.Printf("[TRACE] "+format, args...) }
+func (globalLogger) Debug(format string, args ...any) { log.Printf("[DEBUG] "+format, args...) }
+func (globalLogger) Info(format string, args ...any)  { log.Printf("[INFO] "+format, args...) }
+func (globalLogger) Warn(format string, args ...any)  { log.Printf("[WARN] "+format, args...) }
+func (globalLogger) Error(format string, args ...any) { log.Printf("[ERROR] "+format, args...) }
+
+type loggerContextKey struct{}
+
+// WithLogger returns a context which routes the log messages of any refresh performed using it to the given logger
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// loggerFromContext returns the logger added to the context with WithLogger, or the global logger if there is none
+func loggerFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(Logger); ok && logger != nil {
+		return logger
+	}
+	return globalLogger{}
+}
+
+type refreshIDContextKey struct{}
+
+// WithRefreshID returns a context which tags any refresh performed using it with the given ID
+// the ID is included in every refresh log message, and is returned in the RefreshConnectionResult and
+// in any Postgres notifications sent by the refresh - this allows the output of a specific refresh to be correlated
+func WithRefreshID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, refreshIDContextKey{}, id)
+}
+
+// RefreshIDFromContext returns the refresh ID added to the context with WithRefreshID, or an empty string if there is none
+func RefreshIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(refreshIDContextKey{}).(string)
+	return id
+}
+
+// ensureRefreshID returns a context with a refresh ID, generating one if the context does not already have one
+func ensureRefreshID(ctx context.Context) (context.Context, string) {
+	if id := RefreshIDFromContext(ctx); id != "" {
+		return ctx, id
+	}
+	id := uuid.New().String()
+	return WithRefreshID(ctx, id), id
+}
+
+// withRefreshID prefixes the log message with the refresh ID from the context, if there is one
+// the ID is passed as an argument rather than added to the format, so it is never interpreted as a format verb
+func withRefreshID(ctx context.Context, format string, args []any) (string, []any) {
+	if id := RefreshIDFromContext(ctx); id != "" {
+		return "[refresh %s] " + format, append([]any{id}, args...)
+	}
+	return format, args
+}
+
+func logTrace(ctx context.Context, format string, args ...any) {
+	format, args = withRefreshID(ctx, format, args)
+	loggerFromContext(ctx).Trace(format, args...)
+}
+
+func logDebug(ctx context.Context, format string, args ...any) {
+	format, args = withRefreshID(ctx, format, args)
+	loggerFromContext(ctx).Debug(format, args...)
+}
+
+func logInfo(ctx context.Context, format string, args ...any) {
+	format, args = withRefreshID(ctx, format, args)
+	loggerFromContext(ctx).Info(format, args...)
+}
+
+func logWarn(ctx context.Context, format string, args ...any) {
+	format, args = withRefreshID(ctx, format, args)
+	loggerFromContext(ctx).Warn(format, args...)
+}
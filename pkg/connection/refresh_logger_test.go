@@ -0,0 +1,51 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// capturingLogger records every message it receives, prefixed with its level
+type capturingLogger struct {
+	messages []string
+}
+
+func (l *capturingLogger) add(level, format string, args ...any) {
+	l.messages = append(l.messages, fmt.Sprintf("%s: %s", level, fmt.Sprintf(format, args...)))
+}
+
+func (l *capturingLogger) Trace(format string, args ...any) { l.add("trace", format, args...) }
+func (l *capturingLogger) Debug(format string, args ...any) { l.add("debug", format, args...) }
+func (l *capturingLogger) Info(format string, args ...any)  { l.add("info", format, args...) }
+func (l *capturingLogger) Warn(format string, args ...any)  { l.add("warn", format, args...) }
+func (l *capturingLogger) Error(format string, args ...any) { l.add("error", format, args...) }
+
+func TestRefreshLogger(t *testing.T) {
+	logger := &capturingLogger{}
+	ctx := WithLogger(context.Background(), logger)
+
+	setRefreshConfig(t, map[string]any{constants.ArgCloneSchema: "sometimes", constants.ArgUpdateSchemaMaxParallel: 1})
+	LoadRefreshOptions(ctx)
+
+	updates := map[string]*steampipeconfig.ConnectionState{"a": newTestConnectionState("a", constants.ConnectionStatePending)}
+	otherUpdates := map[string]*steampipeconfig.ConnectionState{"b": newTestConnectionState("b", constants.ConnectionStatePending)}
+	addDependencies(ctx, updates, otherUpdates, map[string][]string{"a": {"b"}})
+
+	expected := []string{
+		"warn: invalid clone_schema 'sometimes': must be one of auto, always, never - defaulting to 'auto'",
+		"info: connection 'a' depends on 'b' - updating 'b' first",
+	}
+	if !reflect.DeepEqual(logger.messages, expected) {
+		t.Errorf("expected messages %v, got %v", expected, logger.messages)
+	}
+
+	// without a logger, messages go to the global logger
+	if _, ok := loggerFromContext(context.Background()).(globalLogger); !ok {
+		t.Errorf("expected the global logger to be used by default")
+	}
+}
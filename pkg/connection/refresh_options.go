@@ -0,0 +1,249 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// RefreshOptions are the settings which control a connection refresh
+// NOTE: the json form of this struct is described by the schema in refresh_options.schema.json,
+// which is generated from the struct and refreshOptionConstraints - regenerate it if either is changed
+// (go test ./pkg/connection -run TestRefreshOptionsSchema -update)
+type RefreshOptions struct {
+	NoRefresh                bool     `json:"no_refresh"`
+	ForceUpdateAll           bool     `json:"force_update_all"`
+	SkipPluginValidation     bool     `json:"skip_plugin_validation"`
+	PruneSchemas             bool     `json:"prune_schemas"`
+	DryRun                   bool     `json:"dry_run"`
+	CatalogStats             bool     `json:"catalog_stats"`
+	SchemaComments           bool     `json:"schema_comments"`
+	CommentLock              string   `json:"comment_lock"`
+	CloneSchema              string   `json:"clone_schema"`
+	GrantPrivileges          []string `json:"grant_privileges"`
+	RefreshTimeout           int      `json:"refresh_timeout"`
+	RefreshLockTimeout       int      `json:"refresh_lock_timeout"`
+	ConnectionStateBatchSize int      `json:"connection_state_batch_size"`
+	MaxUpdateParallel        int      `json:"max_update_parallel"`
+	CloneSchemaBatchSize     int      `json:"clone_schema_batch_size"`
+}
+
+// refreshOptionConstraint describes a RefreshOptions field, and the values it may take
+type refreshOptionConstraint struct {
+	description string
+	// the allowed values of a string field, or of the items of a string slice field
+	enum []string
+	// the minimum value of an int field, or the minimum number of items of a slice field
+	minimum int
+}
+
+// refreshOptionConstraints is a map of RefreshOptions json field name to the constraint for the field
+var refreshOptionConstraints = map[string]refreshOptionConstraint{
+	"no_refresh":             {description: "Do not update connection schemas - use the existing schemas (e.g. during database maintenance)"},
+	"force_update_all":       {description: "Drop and reimport the schema of every connection"},
+	"skip_plugin_validation": {description: "Import connections for plugins using a newer steampipe-plugin-sdk version than Steampipe"},
+	"prune_schemas":          {description: "Drop any connection schemas which do not correspond to a configured connection"},
+	"dry_run":                {description: "Report the schemas which would be pruned, without dropping them"},
+	"catalog_stats":          {description: "Report the number of foreign tables and approximate catalog size of each connection schema"},
+	"schema_comments":        {description: "Set comments on connection schema tables and columns (this may be overridden per connection)"},
+	"comment_lock": {
+		description: "The lock taken while setting schema comments",
+		enum:        []string{constants.CommentLockNone, constants.CommentLockTable, constants.CommentLockAdvisory},
+	},
+	"clone_schema": {
+		description: "Whether connection schemas are cloned from an exemplar schema of the same plugin",
+		enum:        []string{constants.CloneSchemaAuto, constants.CloneSchemaAlways, constants.CloneSchemaNever},
+	},
+	"grant_privileges": {
+		description: "The privileges granted to steampipe users on connection schema tables",
+		enum:        constants.GrantablePrivileges,
+		minimum:     1,
+	},
+	"refresh_timeout":             {description: "The maximum duration of a refresh in seconds (0 for no limit)"},
+	"refresh_lock_timeout":        {description: "The time in seconds to wait for a refresh running in another process to complete"},
+	"connection_state_batch_size": {description: "The number of connections written to the connection state table in each transaction (0 for a single transaction)"},
+	"max_update_parallel": {
+		description: "The maximum number of connection schemas imported or cloned in parallel",
+		minimum:     1,
+	},
+	"clone_schema_batch_size": {description: "The number of connection schemas cloned in each batch, in a single round trip (0 to clone each schema separately)"},
+}
+
+// RefreshRequestOptions are the refresh options which only apply to a single refresh
+// unlike the other refresh options, these are not loaded from config by the plugin manager - they are sent with each
+// refresh request, so they do not persist for the lifetime of the plugin manager
+type RefreshRequestOptions struct {
+	NoRefresh      bool
+	ForceUpdateAll bool
+	PruneSchemas   bool
+	DryRun         bool
+	// report the catalog stats for this refresh, even if they are not enabled in config
+	CatalogStats bool
+}
+
+// WithRequestOptions returns a copy of the options, with the options for a single refresh request set
+func (o *RefreshOptions) WithRequestOptions(requestOpts RefreshRequestOptions) *RefreshOptions {
+	res := *o
+	res.NoRefresh = requestOpts.NoRefresh
+	res.ForceUpdateAll = requestOpts.ForceUpdateAll
+	res.PruneSchemas = requestOpts.PruneSchemas
+	res.DryRun = requestOpts.DryRun
+	res.CatalogStats = res.CatalogStats || requestOpts.CatalogStats
+	return &res
+}
+
+// defaultRefreshOptions returns the refresh options used when no config is set
+func defaultRefreshOptions() *RefreshOptions {
+	return &RefreshOptions{
+		CommentLock:              constants.CommentLockNone,
+		CloneSchema:              constants.CloneSchemaAuto,
+		GrantPrivileges:          constants.DefaultGrantPrivileges,
+		RefreshLockTimeout:       int(constants.RefreshLockTimeout.Seconds()),
+		ConnectionStateBatchSize: constants.DefaultConnectionStateBatchSize,
+		MaxUpdateParallel:        constants.DefaultUpdateSchemaMaxParallel,
+	}
+}
+
+// LoadRefreshOptions reads the refresh options from config
+// an invalid option falls back to its default value, so a bad setting does not prevent every refresh -
+// a warning is logged and returned for each invalid option
+// NOTE: the one-shot options (see RefreshRequestOptions) are not loaded - use WithRequestOptions to set them
+func LoadRefreshOptions(ctx context.Context) (*RefreshOptions, []string) {
+	opts := &RefreshOptions{
+		SkipPluginValidation:     viper.GetBool(constants.ArgSkipPluginValidation),
+		CatalogStats:             viper.GetBool(constants.ArgRefreshCatalogStats),
+		SchemaComments:           viper.GetBool(constants.ArgSchemaComments),
+		CommentLock:              strings.ToLower(viper.GetString(constants.ArgCommentLock)),
+		CloneSchema:              strings.ToLower(viper.GetString(constants.ArgCloneSchema)),
+		GrantPrivileges:          viper.GetStringSlice(constants.ArgGrantPrivileges),
+		RefreshTimeout:           viper.GetInt(constants.ArgRefreshTimeout),
+		RefreshLockTimeout:       viper.GetInt(constants.ArgRefreshLockTimeout),
+		ConnectionStateBatchSize: viper.GetInt(constants.ArgConnectionStateBatchSize),
+		MaxUpdateParallel:        viper.GetInt(constants.ArgUpdateSchemaMaxParallel),
+		CloneSchemaBatchSize:     viper.GetInt(constants.ArgCloneSchemaBatchSize),
+	}
+	if opts.CommentLock == "" {
+		opts.CommentLock = constants.CommentLockNone
+	}
+	switch opts.CloneSchema {
+	// STEAMPIPE_CLONE_SCHEMA previously accepted a boolean
+	case "true", "":
+		opts.CloneSchema = constants.CloneSchemaAuto
+	case "false":
+		opts.CloneSchema = constants.CloneSchemaNever
+	}
+	if len(opts.GrantPrivileges) == 0 {
+		opts.GrantPrivileges = constants.DefaultGrantPrivileges
+	}
+
+	// replace any invalid option with its default value
+	var warnings []string
+	value := reflect.ValueOf(opts).Elem()
+	defaultValue := reflect.ValueOf(defaultRefreshOptions()).Elem()
+	for i, field := range reflect.VisibleFields(value.Type()) {
+		errors := validateRefreshOption(field, value.Field(i))
+		if len(errors) == 0 {
+			continue
+		}
+		value.Field(i).Set(defaultValue.Field(i))
+		for _, err := range errors {
+			warning := fmt.Sprintf("%s - defaulting to %s", err.Error(), refreshOptionValueString(defaultValue.Field(i)))
+			logWarn(ctx, "%s", warning)
+			warnings = append(warnings, warning)
+		}
+	}
+	return opts, warnings
+}
+
+// Validate checks every field against its constraint (see refreshOptionConstraints),
+// returning all failures as a single error
+func (o *RefreshOptions) Validate() error {
+	return refreshOptionsError(o.validate())
+}
+
+func (o *RefreshOptions) validate() []error {
+	var errors []error
+	value := reflect.ValueOf(o).Elem()
+	for i, field := range reflect.VisibleFields(value.Type()) {
+		errors = append(errors, validateRefreshOption(field, value.Field(i))...)
+	}
+	return errors
+}
+
+// validateRefreshOption checks the value of a RefreshOptions field against its constraint
+func validateRefreshOption(field reflect.StructField, fieldValue reflect.Value) []error {
+	var errors []error
+	name := refreshOptionName(field)
+	constraint := refreshOptionConstraints[name]
+
+	switch fieldValue.Kind() {
+	case reflect.Int:
+		if fieldValue.Int() < int64(constraint.minimum) {
+			errors = append(errors, fmt.Errorf("%s must be at least %d, got %d", name, constraint.minimum, fieldValue.Int()))
+		}
+	case reflect.String:
+		if len(constraint.enum) > 0 && !helpers.StringSliceContains(constraint.enum, fieldValue.String()) {
+			errors = append(errors, fmt.Errorf("invalid %s '%s': must be one of %s", name, fieldValue.String(), strings.Join(constraint.enum, ", ")))
+		}
+	case reflect.Slice:
+		if fieldValue.Len() < constraint.minimum {
+			errors = append(errors, fmt.Errorf("%s must contain at least %d %s", name, constraint.minimum, utils.Pluralize("item", constraint.minimum)))
+		}
+		for j := 0; j < fieldValue.Len(); j++ {
+			item := fieldValue.Index(j).String()
+			if len(constraint.enum) > 0 && !helpers.StringSliceContains(constraint.enum, strings.ToLower(item)) {
+				errors = append(errors, fmt.Errorf("invalid %s '%s': must be one of %s", name, item, strings.Join(constraint.enum, ", ")))
+			}
+		}
+	}
+	return errors
+}
+
+// refreshOptionValueString returns the value of a RefreshOptions field, formatted for a warning message
+func refreshOptionValueString(fieldValue reflect.Value) string {
+	switch fieldValue.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("'%s'", fieldValue.String())
+	case reflect.Slice:
+		items := make([]string, fieldValue.Len())
+		for i := range items {
+			items[i] = fieldValue.Index(i).String()
+		}
+		return fmt.Sprintf("'%s'", strings.Join(items, ", "))
+	}
+	return fmt.Sprintf("%v", fieldValue.Interface())
+}
+
+// refreshOptionsError combines refresh option validation failures into a single error (in the order they occurred)
+func refreshOptionsError(errors []error) error {
+	if len(errors) == 0 {
+		return nil
+	}
+	messages := make([]string, len(errors))
+	for i, err := range errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Errorf("invalid refresh options:\n\t%s", strings.Join(messages, "\n\t"))
+}
+
+// refreshOptionName returns the json name of a RefreshOptions field
+func refreshOptionName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// refreshTimeout returns the maximum duration of a refresh, or 0 if there is no limit
+func (o *RefreshOptions) refreshTimeout() time.Duration {
+	return time.Duration(o.RefreshTimeout) * time.Second
+}
+
+// refreshLockTimeout returns the time to wait for a refresh running in another process to complete
+func (o *RefreshOptions) refreshLockTimeout() time.Duration {
+	return time.Duration(o.RefreshLockTimeout) * time.Second
+}
@@ -0,0 +1,53 @@
+package connection
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// RefreshOptionsJSONSchema returns a JSON schema describing the json form of RefreshOptions
+// the schema is generated from the struct fields and refreshOptionConstraints
+func RefreshOptionsJSONSchema() ([]byte, error) {
+	properties := make(map[string]map[string]any)
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(RefreshOptions{})) {
+		name := refreshOptionName(field)
+		constraint := refreshOptionConstraints[name]
+		property := map[string]any{"description": constraint.description}
+
+		switch field.Type.Kind() {
+		case reflect.Bool:
+			property["type"] = "boolean"
+		case reflect.Int:
+			property["type"] = "integer"
+			property["minimum"] = constraint.minimum
+		case reflect.String:
+			property["type"] = "string"
+			if len(constraint.enum) > 0 {
+				property["enum"] = constraint.enum
+			}
+		case reflect.Slice:
+			items := map[string]any{"type": "string"}
+			if len(constraint.enum) > 0 {
+				items["enum"] = constraint.enum
+			}
+			property["type"] = "array"
+			property["items"] = items
+			if constraint.minimum > 0 {
+				property["minItems"] = constraint.minimum
+			}
+		default:
+			return nil, fmt.Errorf("refresh option %s has unsupported type %s", name, field.Type)
+		}
+		properties[name] = property
+	}
+
+	schema := map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "Steampipe connection refresh options",
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
@@ -0,0 +1,149 @@
+package connection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+)
+
+var updateSchema = flag.Bool("update", false, "regenerate refresh_options.schema.json")
+
+// setRefreshConfig sets the given viper config for the duration of the test
+func setRefreshConfig(t *testing.T, config map[string]any) {
+	for key, value := range config {
+		prev := viper.Get(key)
+		t.Cleanup(func() { viper.Set(key, prev) })
+		viper.Set(key, value)
+	}
+}
+
+func TestLoadRefreshOptions(t *testing.T) {
+	setRefreshConfig(t, map[string]any{
+		// one-shot options are not loaded from config
+		constants.ArgNoRefresh:                true,
+		constants.ArgForceUpdateAll:           true,
+		constants.ArgPruneSchemas:             true,
+		constants.ArgSchemaComments:           true,
+		constants.ArgCommentLock:              "Advisory",
+		constants.ArgCloneSchema:              "false",
+		constants.ArgGrantPrivileges:          []string{},
+		constants.ArgRefreshTimeout:           300,
+		constants.ArgRefreshLockTimeout:       30,
+		constants.ArgConnectionStateBatchSize: 100,
+		constants.ArgUpdateSchemaMaxParallel:  4,
+	})
+
+	opts, warnings := LoadRefreshOptions(context.Background())
+	if len(warnings) > 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	expected := &RefreshOptions{
+		SchemaComments:           true,
+		CommentLock:              constants.CommentLockAdvisory,
+		CloneSchema:              constants.CloneSchemaNever,
+		GrantPrivileges:          constants.DefaultGrantPrivileges,
+		RefreshTimeout:           300,
+		RefreshLockTimeout:       30,
+		ConnectionStateBatchSize: 100,
+		MaxUpdateParallel:        4,
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, opts)
+	}
+
+	// one-shot options are set per request, without changing the loaded options
+	requestOpts := opts.WithRequestOptions(RefreshRequestOptions{NoRefresh: true, ForceUpdateAll: true, PruneSchemas: true, DryRun: true})
+	if !requestOpts.NoRefresh || !requestOpts.ForceUpdateAll || !requestOpts.PruneSchemas || !requestOpts.DryRun {
+		t.Errorf("expected the request options to be set, got %+v", requestOpts)
+	}
+	if opts.NoRefresh || opts.ForceUpdateAll || opts.PruneSchemas || opts.DryRun {
+		t.Errorf("expected the loaded options to be unchanged, got %+v", opts)
+	}
+}
+
+func TestLoadRefreshOptionsErrors(t *testing.T) {
+	setRefreshConfig(t, map[string]any{
+		constants.ArgCommentLock:             "sometimes",
+		constants.ArgCloneSchema:             "maybe",
+		constants.ArgGrantPrivileges:         []string{"select", "drop"},
+		constants.ArgRefreshTimeout:          -1,
+		constants.ArgRefreshLockTimeout:      0,
+		constants.ArgUpdateSchemaMaxParallel: 0,
+	})
+
+	// invalid options fall back to their default values
+	opts, warnings := LoadRefreshOptions(context.Background())
+	defaults := defaultRefreshOptions()
+	if opts.CommentLock != defaults.CommentLock || opts.CloneSchema != defaults.CloneSchema ||
+		!reflect.DeepEqual(opts.GrantPrivileges, defaults.GrantPrivileges) || opts.RefreshTimeout != defaults.RefreshTimeout ||
+		opts.MaxUpdateParallel != defaults.MaxUpdateParallel {
+		t.Errorf("expected the invalid options to default to %+v, got %+v", defaults, opts)
+	}
+	// valid options are unchanged
+	if opts.RefreshLockTimeout != 0 {
+		t.Errorf("expected refresh_lock_timeout 0, got %d", opts.RefreshLockTimeout)
+	}
+	// every failure is reported
+	expectedWarnings := []string{
+		"invalid comment_lock 'sometimes'",
+		"invalid clone_schema 'maybe'",
+		"invalid grant_privileges 'drop'",
+		"refresh_timeout must be at least 0, got -1",
+		"max_update_parallel must be at least 1, got 0",
+	}
+	if len(warnings) != len(expectedWarnings) {
+		t.Fatalf("expected %d warnings, got %v", len(expectedWarnings), warnings)
+	}
+	for i, message := range expectedWarnings {
+		if !strings.HasPrefix(warnings[i], message) || !strings.Contains(warnings[i], " - defaulting to ") {
+			t.Errorf("expected warning %q, got: %s", message, warnings[i])
+		}
+	}
+
+	// an empty list of privileges is an error when validating options which have not been loaded from config
+	if err := (&RefreshOptions{CommentLock: constants.CommentLockNone, CloneSchema: constants.CloneSchemaAuto, MaxUpdateParallel: 1}).Validate(); err == nil || !strings.Contains(err.Error(), "grant_privileges must contain at least 1 item") {
+		t.Errorf("expected a grant_privileges error, got %v", err)
+	}
+}
+
+func TestRefreshOptionsSchema(t *testing.T) {
+	schema, err := RefreshOptionsJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const schemaFile = "refresh_options.schema.json"
+	if *updateSchema {
+		if err := os.WriteFile(schemaFile, append(schema, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	existing, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(existing), schema) {
+		t.Errorf("%s is out of date - regenerate it by running this test with -update", schemaFile)
+	}
+
+	// the schema describes every field
+	var parsed struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(RefreshOptions{})) {
+		name := refreshOptionName(field)
+		if property, ok := parsed.Properties[name]; !ok || property["description"] == "" {
+			t.Errorf("expected the schema to describe %s", name)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// the number of completed updates the rolling average update duration is calculated over
+const updateDurationWindow = 20
+
+// the minimum interval between the refresh progress notifications sent to clients
+const progressNotificationInterval = 500 * time.Millisecond
+
+// refreshStatusReporter reports the status of a refresh
+// the status is set using the status hooks of the context, and sent to clients as a refresh progress notification
+// (refreshes are run by the plugin manager, which has no status display - clients waiting for a refresh display
+// the notifications instead). Notifications are throttled to at most one per progressNotificationInterval
+type refreshStatusReporter struct {
+	pluginManager pluginManager
+	lastSent      time.Time
+	mut           sync.Mutex
+}
+
+func newRefreshStatusReporter(pluginManager pluginManager) *refreshStatusReporter {
+	return &refreshStatusReporter{pluginManager: pluginManager}
+}
+
+func (r *refreshStatusReporter) setStatus(ctx context.Context, status string) {
+	statushooks.SetStatus(ctx, status)
+	// a nil reporter only sets the status
+	if r == nil || r.pluginManager == nil || viper.GetBool(constants.ArgQuiet) {
+		return
+	}
+	r.mut.Lock()
+	if time.Since(r.lastSent) < progressNotificationInterval {
+		r.mut.Unlock()
+		return
+	}
+	r.lastSent = time.Now()
+	r.mut.Unlock()
+
+	r.pluginManager.SendPostgresRefreshProgressNotification(ctx, status)
+}
+
+// rollingAverage is the average of the most recent durations added to it
+type rollingAverage struct {
+	durations []time.Duration
+	next      int
+}
+
+func (a *rollingAverage) add(d time.Duration) {
+	if len(a.durations) < updateDurationWindow {
+		a.durations = append(a.durations, d)
+		return
+	}
+	a.durations[a.next] = d
+	a.next = (a.next + 1) % updateDurationWindow
+}
+
+func (a *rollingAverage) average() (time.Duration, bool) {
+	if len(a.durations) == 0 {
+		return 0, false
+	}
+	var total time.Duration
+	for _, d := range a.durations {
+		total += d
+	}
+	return total / time.Duration(len(a.durations)), true
+}
+
+// updateProgress tracks the progress of the connection updates of a refresh and estimates the time remaining
+// cloning a schema is much faster than importing it, so the average duration of imports and clones are tracked separately
+type updateProgress struct {
+	total       int
+	completed   int
+	parallelism int
+	// the number of pending imports and clones
+	pendingImports int
+	pendingClones  int
+	importDuration rollingAverage
+	cloneDuration  rollingAverage
+	reporter       *refreshStatusReporter
+	mut            sync.Mutex
+}
+
+func newUpdateProgress(imports, clones, parallelism int, reporter *refreshStatusReporter) *updateProgress {
+	return &updateProgress{
+		total:          imports + clones,
+		parallelism:    max(parallelism, 1),
+		pendingImports: imports,
+		pendingClones:  clones,
+		reporter:       reporter,
+	}
+}
+
+// onUpdateComplete records the completion of a connection update and reports the progress
+func (p *updateProgress) onUpdateComplete(ctx context.Context, isClone bool, duration time.Duration) {
+	// a nil progress is a no-op
+	if p == nil {
+		return
+	}
+	p.mut.Lock()
+	p.completed++
+	// (if a clone falls back to an import, the import completes in place of the pending clone)
+	if isClone || p.pendingImports == 0 {
+		p.pendingClones = max(p.pendingClones-1, 0)
+	} else {
+		p.pendingImports--
+	}
+	if isClone {
+		p.cloneDuration.add(duration)
+	} else {
+		p.importDuration.add(duration)
+	}
+	completed, total := p.completed, p.total
+	remaining, haveEstimate := p.remaining()
+	p.mut.Unlock()
+
+	status := fmt.Sprintf("Created %d of %d %s", completed, total, utils.Pluralize("connection", total))
+	if haveEstimate && remaining > 0 {
+		status = fmt.Sprintf("%s (~%s remaining)", status, formatRemaining(remaining))
+	}
+	p.reporter.setStatus(ctx, status)
+}
+
+// remaining returns the estimated time to complete the pending updates
+// if no update of a kind has completed yet, the average duration of the other kind is used
+// NOTE: the mutex must be held when calling this
+func (p *updateProgress) remaining() (time.Duration, bool) {
+	importAverage, haveImportAverage := p.importDuration.average()
+	cloneAverage, haveCloneAverage := p.cloneDuration.average()
+	switch {
+	case !haveImportAverage && !haveCloneAverage:
+		return 0, false
+	case !haveImportAverage:
+		importAverage = cloneAverage
+	case !haveCloneAverage:
+		cloneAverage = importAverage
+	}
+	remaining := time.Duration(p.pendingImports)*importAverage + time.Duration(p.pendingClones)*cloneAverage
+	return remaining / time.Duration(p.parallelism), true
+}
+
+// formatRemaining formats the estimated time remaining, rounded to the nearest minute (or second, if less than a minute)
+func formatRemaining(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(math.Ceil(d.Seconds())))
+	}
+	return fmt.Sprintf("%dm", int(math.Round(d.Minutes())))
+}
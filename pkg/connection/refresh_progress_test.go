@@ -0,0 +1,170 @@
+package connection
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/statushooks"
+)
+
+// recordingStatusHook records the last status set
+type recordingStatusHook struct {
+	statushooks.NullStatusHook
+	status string
+}
+
+func (h *recordingStatusHook) SetStatus(status string) {
+	h.status = status
+}
+
+func TestUpdateProgressEstimate(t *testing.T) {
+	testCases := map[string]struct {
+		imports, clones, parallelism int
+		// the updates to complete: true for a clone, false for an import
+		completedClones []bool
+		importDuration  time.Duration
+		cloneDuration   time.Duration
+		expected        time.Duration
+	}{
+		"imports only": {
+			imports: 10, parallelism: 1,
+			completedClones: []bool{false, false},
+			importDuration:  10 * time.Second,
+			expected:        80 * time.Second,
+		},
+		"imports and clones": {
+			imports: 5, clones: 100, parallelism: 1,
+			completedClones: []bool{false, false, false, true, true},
+			importDuration:  20 * time.Second,
+			cloneDuration:   time.Second,
+			expected:        2*20*time.Second + 98*time.Second,
+		},
+		"no clone completed yet": {
+			imports: 2, clones: 3, parallelism: 1,
+			completedClones: []bool{false},
+			importDuration:  4 * time.Second,
+			// the import rate is used for the pending clones
+			expected: 4 * 4 * time.Second,
+		},
+		"parallel updates": {
+			imports: 12, parallelism: 4,
+			completedClones: []bool{false, false, false, false},
+			importDuration:  30 * time.Second,
+			expected:        60 * time.Second,
+		},
+	}
+	for name, test := range testCases {
+		progress := newUpdateProgress(test.imports, test.clones, test.parallelism, nil)
+		for _, isClone := range test.completedClones {
+			duration := test.importDuration
+			if isClone {
+				duration = test.cloneDuration
+			}
+			progress.onUpdateComplete(context.Background(), isClone, duration)
+		}
+
+		progress.mut.Lock()
+		completed, total := progress.completed, progress.total
+		remaining, _ := progress.remaining()
+		progress.mut.Unlock()
+		if completed != len(test.completedClones) || total != test.imports+test.clones {
+			t.Errorf("Test: '%s' FAILED : expected progress %d of %d, got %d of %d", name, len(test.completedClones), test.imports+test.clones, completed, total)
+		}
+		// allow for rounding
+		if diff := (remaining - test.expected).Abs(); diff > time.Second {
+			t.Errorf("Test: '%s' FAILED : expected ~%s remaining, got %s", name, test.expected, remaining)
+		}
+	}
+}
+
+func TestUpdateProgressRollingAverage(t *testing.T) {
+	progress := newUpdateProgress(100, 0, 1, nil)
+	// slow initial imports are dropped from the average once enough faster imports have completed
+	for i := 0; i < 10; i++ {
+		progress.onUpdateComplete(context.Background(), false, time.Minute)
+	}
+	for i := 0; i < updateDurationWindow; i++ {
+		progress.onUpdateComplete(context.Background(), false, time.Second)
+	}
+	progress.mut.Lock()
+	remaining, ok := progress.remaining()
+	progress.mut.Unlock()
+	if expected := 70 * time.Second; !ok || remaining != expected {
+		t.Errorf("expected %s remaining, got %s", expected, remaining)
+	}
+}
+
+func TestUpdateProgressStatus(t *testing.T) {
+	statusHook := &recordingStatusHook{}
+	ctx := statushooks.AddStatusHooksToContext(context.Background(), statusHook)
+
+	setRefreshConfig(t, map[string]any{constants.ArgQuiet: false})
+	pluginManager := &progressNotifyingPluginManager{}
+	progress := newUpdateProgress(300, 0, 1, newRefreshStatusReporter(pluginManager))
+	for i := 0; i < 40; i++ {
+		progress.onUpdateComplete(ctx, false, time.Second)
+	}
+	if expected := "Created 40 of 300 connections (~4m remaining)"; statusHook.status != expected {
+		t.Errorf("expected status '%s', got '%s'", expected, statusHook.status)
+	}
+	// the progress is also sent to clients (throttled, so only the first status is sent)
+	if !reflect.DeepEqual(pluginManager.statuses, []string{"Created 1 of 300 connections (~5m remaining)"}) {
+		t.Errorf("expected the progress to be sent to clients, got %v", pluginManager.statuses)
+	}
+
+	// a nil progress is a no-op
+	var nilProgress *updateProgress
+	nilProgress.onUpdateComplete(ctx, false, time.Second)
+	if !strings.HasPrefix(statusHook.status, "Created 40 of 300") {
+		t.Errorf("expected a nil progress not to set the status, got '%s'", statusHook.status)
+	}
+}
+
+// progressNotifyingPluginManager records the refresh progress notifications sent
+// (all other methods are unimplemented)
+type progressNotifyingPluginManager struct {
+	pluginManager
+	statuses []string
+}
+
+func (m *progressNotifyingPluginManager) SendPostgresRefreshProgressNotification(_ context.Context, status string) {
+	m.statuses = append(m.statuses, status)
+}
+
+func TestRefreshStatusReporter(t *testing.T) {
+	setRefreshConfig(t, map[string]any{constants.ArgQuiet: false})
+	statusHook := &recordingStatusHook{}
+	ctx := statushooks.AddStatusHooksToContext(context.Background(), statusHook)
+
+	pluginManager := &progressNotifyingPluginManager{}
+	reporter := newRefreshStatusReporter(pluginManager)
+	reporter.setStatus(ctx, "Created 1 of 3 connections")
+	reporter.setStatus(ctx, "Created 2 of 3 connections")
+
+	// the status is always set, but notifications are throttled
+	if statusHook.status != "Created 2 of 3 connections" {
+		t.Errorf("expected the status to be set, got '%s'", statusHook.status)
+	}
+	if !reflect.DeepEqual(pluginManager.statuses, []string{"Created 1 of 3 connections"}) {
+		t.Errorf("expected a single notification, got %v", pluginManager.statuses)
+	}
+
+	// once the interval has elapsed, the next status is sent
+	reporter.lastSent = time.Now().Add(-progressNotificationInterval)
+	reporter.setStatus(ctx, "Created 3 of 3 connections")
+	if len(pluginManager.statuses) != 2 {
+		t.Errorf("expected a notification once the interval has elapsed, got %v", pluginManager.statuses)
+	}
+
+	// no notifications are sent in quiet mode
+	setRefreshConfig(t, map[string]any{constants.ArgQuiet: true})
+	reporter.lastSent = time.Time{}
+	reporter.setStatus(ctx, "Commenting 1 of 1 connection (aws)")
+	if len(pluginManager.statuses) != 2 {
+		t.Errorf("expected no notification in quiet mode, got %v", pluginManager.statuses)
+	}
+}
@@ -0,0 +1,131 @@
+// Package refresh_rpc contains the types used to call the connection refresh service over its unix socket
+// these are kept separate from the service itself so that clients which cannot depend on the connection package
+// (e.g. the database client) are able to request refreshes
+package refresh_rpc
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// ServiceName is the name the refresh service is registered with - clients call methods as
+// '<ServiceName>.<method>'
+const ServiceName = "RefreshService"
+
+// RefreshRequest is the request to refresh connections
+type RefreshRequest struct {
+	// the connections to force update - if empty, only connections which have changed are updated
+	ForceUpdateConnectionNames []string
+	// the ID to tag the refresh with - if empty, an ID is generated
+	RefreshID string
+	// should the connection config be reloaded before refreshing
+	// this is set by clients which have just changed the connection config
+	ReloadConfig bool
+	// should the catalog stats of each connection schema be reported, even if they are not enabled in config
+	CatalogStats bool
+}
+
+// RefreshResponse is the JSON representation of a RefreshConnectionResult
+type RefreshResponse struct {
+	Error              string
+	Warnings           []string
+	UpdatedConnections bool
+	FailedConnections  map[string]string
+	CreatedConnections []string
+	ClonedConnections  []string
+	DeletedConnections []string
+	MissingPlugins     map[string][]string
+	RefreshID          string
+	CatalogStats       map[string]*steampipeconfig.ConnectionCatalogStats
+}
+
+// NewRefreshResponse converts a RefreshConnectionResult into its JSON representation
+func NewRefreshResponse(res *steampipeconfig.RefreshConnectionResult) *RefreshResponse {
+	r := &RefreshResponse{
+		Warnings:           res.Warnings,
+		UpdatedConnections: res.UpdatedConnections,
+		FailedConnections:  res.FailedConnections,
+		CreatedConnections: res.CreatedConnections,
+		ClonedConnections:  res.ClonedConnections,
+		DeletedConnections: res.DeletedConnections,
+		MissingPlugins:     res.MissingPlugins,
+		RefreshID:          res.RefreshID,
+		CatalogStats:       res.CatalogStats,
+	}
+	if res.Error != nil {
+		r.Error = res.Error.Error()
+	}
+	return r
+}
+
+// Result converts the response back into a RefreshConnectionResult
+func (r *RefreshResponse) Result() *steampipeconfig.RefreshConnectionResult {
+	res := &steampipeconfig.RefreshConnectionResult{
+		ErrorAndWarnings:   error_helpers.ErrorAndWarnings{Warnings: r.Warnings},
+		UpdatedConnections: r.UpdatedConnections,
+		FailedConnections:  r.FailedConnections,
+		CreatedConnections: r.CreatedConnections,
+		ClonedConnections:  r.ClonedConnections,
+		DeletedConnections: r.DeletedConnections,
+		MissingPlugins:     r.MissingPlugins,
+		RefreshID:          r.RefreshID,
+		CatalogStats:       r.CatalogStats,
+	}
+	if r.Error != "" {
+		res.Error = errors.New(r.Error)
+	}
+	return res
+}
+
+// Client calls the refresh service over its unix socket
+type Client struct {
+	client *rpc.Client
+}
+
+// NewClient connects to the refresh service listening on the given socket path
+func NewClient(socketPath string) (*Client, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{client: jsonrpc.NewClient(conn)}, nil
+}
+
+// RefreshConnections refreshes all connections, force updating the given connections
+func (c *Client) RefreshConnections(forceUpdateConnectionNames ...string) (*steampipeconfig.RefreshConnectionResult, error) {
+	var res RefreshResponse
+	if err := c.client.Call(ServiceName+".RefreshConnections", RefreshRequest{ForceUpdateConnectionNames: forceUpdateConnectionNames}, &res); err != nil {
+		return nil, err
+	}
+	return res.Result(), nil
+}
+
+// ReloadAndRefreshConnections reloads the connection config then refreshes all connections, force updating the given connections
+// this is used after changing the connection config, so the refresh does not depend on the service having seen the change
+// the refresh is tagged with the ID in the request (if set), so the caller may identify its progress notifications
+func (c *Client) ReloadAndRefreshConnections(req RefreshRequest) (*steampipeconfig.RefreshConnectionResult, error) {
+	req.ReloadConfig = true
+	var res RefreshResponse
+	if err := c.client.Call(ServiceName+".RefreshConnections", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Result(), nil
+}
+
+// RefreshConnection refreshes connections, force updating the named connection
+func (c *Client) RefreshConnection(connectionName string) (*steampipeconfig.RefreshConnectionResult, error) {
+	var res RefreshResponse
+	if err := c.client.Call(ServiceName+".RefreshConnection", connectionName, &res); err != nil {
+		return nil, err
+	}
+	return res.Result(), nil
+}
+
+func (c *Client) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,141 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+
+	"github.com/turbot/steampipe/pkg/connection/refresh_rpc"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// refreshFunc refreshes connections using the given one-shot options, force updating the given connections
+type refreshFunc func(ctx context.Context, requestOpts RefreshRequestOptions, forceUpdateConnectionNames ...string) *steampipeconfig.RefreshConnectionResult
+
+// reloadFunc reloads the connection config
+type reloadFunc func(ctx context.Context) error
+
+// startRefreshFunc registers an in-flight refresh, returning the context to use for the refresh
+// and a function to call when it is complete
+type startRefreshFunc func() (context.Context, func())
+
+// RefreshService is the RPC receiver for refresh requests
+// NOTE: all exported methods must have the signature required by net/rpc
+type RefreshService struct {
+	refresh      refreshFunc
+	reload       reloadFunc
+	startRefresh startRefreshFunc
+}
+
+// RefreshConnections refreshes all connections, force updating any connections in the request
+func (s *RefreshService) RefreshConnections(req refresh_rpc.RefreshRequest, res *refresh_rpc.RefreshResponse) error {
+	log.Printf("[INFO] RefreshService RefreshConnections, forced connections: %v", req.ForceUpdateConnectionNames)
+	// the refresh is registered with the plugin manager, so it is interrupted (and waited for) on shutdown
+	ctx, done := s.startRefresh()
+	defer done()
+	if req.RefreshID != "" {
+		ctx = WithRefreshID(ctx, req.RefreshID)
+	}
+	if req.ReloadConfig {
+		if err := s.reload(ctx); err != nil {
+			*res = *refresh_rpc.NewRefreshResponse(steampipeconfig.NewErrorRefreshConnectionResult(err))
+			return nil
+		}
+	}
+	requestOpts := RefreshRequestOptions{CatalogStats: req.CatalogStats}
+	*res = *refresh_rpc.NewRefreshResponse(s.refresh(ctx, requestOpts, req.ForceUpdateConnectionNames...))
+	return nil
+}
+
+// RefreshConnection refreshes connections, force updating the named connection
+func (s *RefreshService) RefreshConnection(connectionName string, res *refresh_rpc.RefreshResponse) error {
+	log.Printf("[INFO] RefreshService RefreshConnection %s", connectionName)
+	if connectionName == "" {
+		return fmt.Errorf("a connection name must be specified")
+	}
+	ctx, done := s.startRefresh()
+	defer done()
+	*res = *refresh_rpc.NewRefreshResponse(s.refresh(ctx, RefreshRequestOptions{}, connectionName))
+	return nil
+}
+
+// RefreshServer serves refresh requests over a unix socket, using JSON-RPC
+// this allows a long-lived process which already has the connection config loaded (i.e. the plugin manager)
+// to perform refreshes on behalf of other processes
+//
+// only peers running as the same user as the server are accepted - this is enforced by checking
+// the peer credentials of each connection (where supported) and by restricting the socket file permissions
+type RefreshServer struct {
+	listener  net.Listener
+	rpcServer *rpc.Server
+}
+
+// NewRefreshServer starts a refresh server, listening on the given socket path
+func NewRefreshServer(pluginManager pluginManager, socketPath string) (*RefreshServer, error) {
+	return newRefreshServer(func(ctx context.Context, requestOpts RefreshRequestOptions, forceUpdateConnectionNames ...string) *steampipeconfig.RefreshConnectionResult {
+		opts, warnings := LoadRefreshOptions(ctx)
+		res := RefreshConnections(ctx, pluginManager, opts.WithRequestOptions(requestOpts), forceUpdateConnectionNames...)
+		res.AddWarning(warnings...)
+		return res
+	}, func(ctx context.Context) error {
+		_, err := reloadConnectionConfig(ctx, pluginManager)
+		return err
+	}, pluginManager.StartRefresh, socketPath)
+}
+
+func newRefreshServer(refresh refreshFunc, reload reloadFunc, startRefresh startRefreshFunc, socketPath string) (*RefreshServer, error) {
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName(refresh_rpc.ServiceName, &RefreshService{refresh: refresh, reload: reload, startRefresh: startRefresh}); err != nil {
+		return nil, err
+	}
+
+	// remove any socket left behind by a previous server
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	// only the owner may connect
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	s := &RefreshServer{
+		listener:  listener,
+		rpcServer: rpcServer,
+	}
+	go s.serve()
+	return s, nil
+}
+
+func (s *RefreshServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("[WARN] refresh server failed to accept connection: %s", err.Error())
+			continue
+		}
+		if err := checkPeerCredentials(conn); err != nil {
+			log.Printf("[WARN] refresh server rejected connection: %s", err.Error())
+			conn.Close()
+			continue
+		}
+		go s.rpcServer.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// Close stops the server and removes the socket
+func (s *RefreshServer) Close() error {
+	return s.listener.Close()
+}
@@ -0,0 +1,37 @@
+//go:build darwin
+
+package connection
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkPeerCredentials verifies the process at the other end of the socket is running as the same user as this process
+func checkPeerCredentials(conn net.Conn) error {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("unexpected connection type %T", conn)
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var cred *unix.Xucred
+	var credErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	}); err != nil {
+		return err
+	}
+	if credErr != nil {
+		return credErr
+	}
+	if int(cred.Uid) != os.Getuid() {
+		return fmt.Errorf("peer uid %d does not match server uid %d", cred.Uid, os.Getuid())
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/connection/refresh_rpc"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+func TestRefreshServiceRoundTrip(t *testing.T) {
+	var forced []string
+	var refreshID string
+	var requestOptions RefreshRequestOptions
+
+	// refreshes must use the context of the plugin manager, so they are interrupted on shutdown
+	managerCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var started, completed int
+	startRefresh := func() (context.Context, func()) {
+		started++
+		return managerCtx, func() { completed++ }
+	}
+	var refreshCtxs []context.Context
+
+	refresh := func(ctx context.Context, requestOpts RefreshRequestOptions, forceUpdateConnectionNames ...string) *steampipeconfig.RefreshConnectionResult {
+		refreshCtxs = append(refreshCtxs, ctx)
+		forced = forceUpdateConnectionNames
+		refreshID = RefreshIDFromContext(ctx)
+		requestOptions = requestOpts
+		var catalogStats map[string]*steampipeconfig.ConnectionCatalogStats
+		if requestOpts.CatalogStats {
+			catalogStats = map[string]*steampipeconfig.ConnectionCatalogStats{"e": {ForeignTables: 10, CatalogBytes: 2000}}
+		}
+		return &steampipeconfig.RefreshConnectionResult{
+			CatalogStats:       catalogStats,
+			ErrorAndWarnings:   error_helpers.ErrorAndWarnings{Warnings: []string{"a warning"}},
+			UpdatedConnections: true,
+			FailedConnections:  map[string]string{"b": "failed"},
+			CreatedConnections: forceUpdateConnectionNames,
+		}
+	}
+
+	var reloads int
+	var reloadErr error
+	reload := func(ctx context.Context) error {
+		refreshCtxs = append(refreshCtxs, ctx)
+		reloads++
+		return reloadErr
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "refresh.sock")
+	server, err := newRefreshServer(refresh, reload, startRefresh, socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, err := refresh_rpc.NewClient(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	res, err := client.RefreshConnection("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(forced, []string{"a"}) {
+		t.Errorf("expected connection 'a' to be force updated, got %v", forced)
+	}
+	if res.Error != nil || !res.UpdatedConnections || !reflect.DeepEqual(res.Warnings, []string{"a warning"}) ||
+		!reflect.DeepEqual(res.FailedConnections, map[string]string{"b": "failed"}) || !reflect.DeepEqual(res.CreatedConnections, []string{"a"}) {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	res, err = client.RefreshConnections("c", "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(forced, []string{"c", "d"}) || !reflect.DeepEqual(res.CreatedConnections, []string{"c", "d"}) {
+		t.Errorf("expected connections 'c' and 'd' to be force updated, got %v", forced)
+	}
+
+	if reloads != 0 {
+		t.Errorf("expected the config not to be reloaded, got %d reloads", reloads)
+	}
+
+	// the config is reloaded before refreshing if requested
+	res, err = client.ReloadAndRefreshConnections(refresh_rpc.RefreshRequest{RefreshID: "refresh-1", ForceUpdateConnectionNames: []string{"e"}, CatalogStats: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloads != 1 || !reflect.DeepEqual(forced, []string{"e"}) {
+		t.Errorf("expected the config to be reloaded then connection 'e' force updated, got %d reloads, forced %v", reloads, forced)
+	}
+	// the refresh is tagged with the requested ID
+	if refreshID != "refresh-1" {
+		t.Errorf("expected the refresh to be tagged with ID 'refresh-1', got '%s'", refreshID)
+	}
+	// the catalog stats are requested, and returned with the result
+	if !requestOptions.CatalogStats {
+		t.Error("expected the catalog stats to be requested")
+	}
+	if expected := map[string]*steampipeconfig.ConnectionCatalogStats{"e": {ForeignTables: 10, CatalogBytes: 2000}}; !reflect.DeepEqual(res.CatalogStats, expected) {
+		t.Errorf("expected catalog stats %v, got %v", expected, res.CatalogStats)
+	}
+
+	// a reload failure is returned and no refresh is performed
+	reloadErr = errors.New("invalid config")
+	forced = nil
+	res, err = client.ReloadAndRefreshConnections(refresh_rpc.RefreshRequest{ForceUpdateConnectionNames: []string{"f"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Error == nil || res.Error.Error() != "invalid config" || forced != nil {
+		t.Errorf("expected the reload error to be returned without refreshing, got %+v, forced %v", res, forced)
+	}
+
+	// a missing connection name is rejected
+	if _, err := client.RefreshConnection(""); err == nil {
+		t.Error("expected an error refreshing a connection with no name")
+	}
+
+	// every request was registered with the plugin manager, and used its context
+	if started != 4 || completed != 4 {
+		t.Errorf("expected 4 refreshes to be started and completed, got %d started, %d completed", started, completed)
+	}
+	for _, ctx := range refreshCtxs {
+		if ctx.Done() != managerCtx.Done() {
+			t.Error("expected the refresh to use the plugin manager refresh context")
+		}
+	}
+}
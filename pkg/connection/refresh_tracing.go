@@ -0,0 +1,38 @@
+package connection
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// span attribute keys
+const (
+	attributeUpdateCount     = attribute.Key("steampipe.connection.update_count")
+	attributeDeleteCount     = attribute.Key("steampipe.connection.delete_count")
+	attributeConnectionCount = attribute.Key("steampipe.connection.count")
+	attributeConnectionName  = attribute.Key("steampipe.connection.name")
+	attributeClone           = attribute.Key("steampipe.connection.clone")
+)
+
+// the name of the tracer the refresh spans are created with
+const tracerName = "github.com/turbot/steampipe/pkg/connection"
+
+// startRefreshSpan starts a span for a phase of the refresh, as a child of the current span in the context
+// spans are created using the global tracer provider - this is set by telemetry.Init if tracing is enabled
+// (using STEAMPIPE_OTEL_LEVEL), otherwise the spans are not recorded
+func startRefreshSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// endRefreshSpan records the error (if any) as a span event and ends the span
+func endRefreshSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
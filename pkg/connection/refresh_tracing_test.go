@@ -0,0 +1,110 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// schemaNotifyingPluginManager accepts schema notifications
+// (all other methods are unimplemented)
+type schemaNotifyingPluginManager struct {
+	pluginManager
+}
+
+func (*schemaNotifyingPluginManager) SendPostgresSchemaNotification(context.Context, []string, []string) error {
+	return nil
+}
+
+func (*schemaNotifyingPluginManager) SendPostgresRefreshProgressNotification(context.Context, string) {
+}
+
+func TestRefreshSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	setTestTracerProvider(t, provider)
+	tracer := provider.Tracer("test")
+
+	// the pool is only used for its config - it does not connect until a connection is acquired
+	pool, err := pgxpool.New(context.Background(), "postgres://steampipe@127.0.0.1:1/steampipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	updates := &steampipeconfig.ConnectionUpdates{
+		Update:               steampipeconfig.ConnectionStateMap{},
+		MissingComments:      steampipeconfig.ConnectionStateMap{"a": newTestConnectionState("a", constants.ConnectionStateReady)},
+		FinalConnectionState: steampipeconfig.ConnectionStateMap{},
+	}
+	s := &refreshConnectionState{
+		opts:              testRefreshOptions(),
+		connectionUpdates: updates,
+		pluginManager:     &schemaNotifyingPluginManager{},
+		pool:              pool,
+		res:               &steampipeconfig.RefreshConnectionResult{},
+	}
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	if err := s.executeDeleteQueries(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	s.executeUpdateQueries(ctx)
+	root.End()
+
+	spans := make(map[string][]tracetest.SpanStub)
+	spanIDs := make(map[trace.SpanID]string)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = append(spans[span.Name], span)
+		spanIDs[span.SpanContext.SpanID()] = span.Name
+	}
+
+	expectedParents := map[string]string{
+		"executeDeleteQueries": "root",
+		"executeUpdateQueries": "root",
+		"updateComments":       "executeUpdateQueries",
+	}
+	for name, expectedParent := range expectedParents {
+		if len(spans[name]) == 0 {
+			t.Errorf("expected a '%s' span", name)
+			continue
+		}
+		for _, span := range spans[name] {
+			if parent := spanIDs[span.Parent.SpanID()]; parent != expectedParent {
+				t.Errorf("expected '%s' span to be a child of '%s', got '%s'", name, expectedParent, parent)
+			}
+		}
+	}
+}
+
+func TestRefreshSpanErrors(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	setTestTracerProvider(t, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	_, span := startRefreshSpan(context.Background(), "failed")
+	endRefreshSpan(span, errors.New("update failed"))
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || len(spans[0].Events) != 1 || spans[0].Events[0].Name != "exception" {
+		t.Errorf("expected the error to be recorded as a span event, got %v", spans)
+	}
+
+	// if tracing is not enabled, spans are not recorded
+	otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	if _, span := startRefreshSpan(context.Background(), "untraced"); span.IsRecording() {
+		t.Errorf("expected the span not to be recorded when tracing is not enabled")
+	}
+}
+
+// setTestTracerProvider sets the global tracer provider for the duration of the test
+func setTestTracerProvider(t *testing.T, provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+}
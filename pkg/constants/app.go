@@ -11,4 +11,7 @@ const (
 	ClientConnectionAppNamePrefix       = "steampipe_client"
 	ServiceConnectionAppNamePrefix      = "steampipe_service"
 	ClientSystemConnectionAppNamePrefix = "steampipe_client_system"
+	// the default application name prefix of the connections used to refresh connections
+	// NOTE: this must start with ServiceConnectionAppNamePrefix so these connections are recognised as service connections
+	RefreshConnectionAppNamePrefix = "steampipe_service_refresh"
 )
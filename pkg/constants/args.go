@@ -10,6 +10,9 @@ const (
 	ArgOn                      = "on"
 	ArgOff                     = "off"
 	ArgClear                   = "clear"
+	ArgSearch                  = "search"
+	ArgName                    = "name"
+	ArgVerbose                 = "verbose"
 	ArgDatabaseListenAddresses = "database-listen"
 	ArgDatabasePort            = "database-port"
 	ArgDatabaseQueryTimeout    = "query-timeout"
@@ -27,6 +30,14 @@ const (
 	ArgInstallDir              = "install-dir"
 	ArgWorkspaceDatabase       = "workspace-database"
 	ArgSchemaComments          = "schema-comments"
+	ArgSkipPluginValidation    = "skip-plugin-validation"
+	ArgPruneSchemas            = "prune-schemas"
+	ArgForceUpdateAll          = "force-update-all"
+	ArgRefreshApplicationName  = "refresh-application-name"
+	ArgNoRefresh               = "no-refresh"
+	ArgRefreshCatalogStats     = "refresh-catalog-stats"
+	ArgUpdateSchemaMaxParallel = "update-schema-max-parallel"
+	ArgQuiet                   = "quiet"
 	ArgCloudHost               = "cloud-host"
 	ArgCloudToken              = "cloud-token"
 	ArgSearchPath              = "search-path"
@@ -66,6 +77,80 @@ const (
 	ArgDatabaseStartTimeout    = "database-start-timeout"
 	ArgMemoryMaxMb             = "memory-max-mb"
 	ArgMemoryMaxMbPlugin       = "memory-max-mb-plugin"
+
+	// dashboard server http timeouts (in seconds)
+	ArgDashboardReadHeaderTimeout = "dashboard-read-header-timeout"
+	ArgDashboardReadTimeout       = "dashboard-read-timeout"
+	ArgDashboardWriteTimeout      = "dashboard-write-timeout"
+	ArgDashboardIdleTimeout       = "dashboard-idle-timeout"
+	// dashboard websocket limits
+	ArgDashboardMaxMessageSize    = "dashboard-max-message-size"
+	ArgDashboardMessageBufferSize = "dashboard-message-buffer-size"
+	ArgDashboardDeltaUpdates      = "dashboard-delta-updates"
+	// dashboard server TLS
+	ArgDashboardSSLCert = "dashboard-ssl-cert"
+	ArgDashboardSSLKey  = "dashboard-ssl-key"
+	ArgTLSClientCA      = "tls-client-ca"
+
+	// connection refresh and schema import
+	ArgCloneSchema              = "clone-schema"
+	ArgRefreshTimeout           = "refresh-timeout"
+	ArgGrantPrivileges          = "grant-privileges"
+	ArgSearchPathLimit          = "search-path-limit"
+	ArgRefreshLockTimeout       = "refresh-lock-timeout"
+	ArgCommentLock              = "comment-lock"
+	ArgConnectionStateBatchSize = "connection-state-batch-size"
+	ArgCloneSchemaBatchSize     = "clone-schema-batch-size"
+	ArgConnectionStateSchema    = "connection-state-schema"
+	ArgConnectionStateTable     = "connection-state-table"
+	ArgSchemaQueryTimeout       = "schema-query-timeout"
+
+	// connection command
+	ArgPlugin           = "plugin"
+	ArgConnectionConfig = "config"
+	ArgFromTerraform    = "from-terraform"
+	ArgOutputFile       = "output-file"
+	ArgVerify           = "verify"
+
+	// dashboard server authentication
+	ArgDashboardAuth             = "dashboard-auth"
+	ArgDashboardAuthToken        = "dashboard-auth-token"
+	ArgDashboardAuthUsers        = "dashboard-auth-users"
+	ArgDashboardAuthRole         = "dashboard-auth-role"
+	ArgDashboardAuthSessionTTL   = "dashboard-auth-session-ttl"
+	ArgDashboardOIDCIssuer       = "dashboard-oidc-issuer"
+	ArgDashboardOIDCClientID     = "dashboard-oidc-client-id"
+	ArgDashboardOIDCClientSecret = "dashboard-oidc-client-secret"
+
+	// scheduled dashboard runs
+	ArgDashboardSchedule          = "dashboard-schedule"
+	ArgDashboardScheduleExport    = "dashboard-schedule-export"
+	ArgDashboardScheduleOutputDir = "dashboard-schedule-output-dir"
+	ArgDashboardScheduleWebhook   = "dashboard-schedule-webhook"
+	ArgDashboardScheduleS3        = "dashboard-schedule-s3"
+
+	// additional workspaces served by the dashboard server
+	ArgDashboardWorkspace = "dashboard-workspace"
+
+	// the default timeout of check controls (in seconds)
+	ArgControlTimeout = "control-timeout"
+
+	// a previous check snapshot to compare check results with
+	ArgDiff = "diff"
+
+	// files declaring waivers for check results
+	ArgWaiverFile = "waiver-file"
+
+	// the maximum number of check controls run in parallel for a plugin or connection
+	ArgPluginMaxParallel = "plugin-max-parallel"
+
+	// pagination of batch query results
+	ArgPageSize = "page-size"
+	ArgMaxRows  = "max-rows"
+	ArgCursor   = "cursor"
+
+	// highlight the rows which have changed between runs of a query re-run using '--watch=<interval>'
+	ArgWatchHighlight = "watch-highlight"
 )
 
 // metaquery mode arguments
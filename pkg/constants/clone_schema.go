@@ -0,0 +1,18 @@
+package constants
+
+// constants for the clone_schema database option
+// this controls whether a connection schema is cloned from an existing schema for the same plugin
+// rather than being imported from the plugin
+//
+//   - auto: clone schemas for plugins with a static schema (default).
+//     Cloning is much faster than importing for large numbers of connections
+//   - always: clone whenever an exemplar schema exists, including for plugins with a dynamic schema.
+//     This is fastest, but connections of a dynamic plugin may end up with the tables of the exemplar connection
+//     rather than their own
+//   - never: always import the schema from the plugin.
+//     This is slowest but avoids relying on clone_foreign_schema, which may misbehave on some Postgres versions
+const (
+	CloneSchemaAuto   = "auto"
+	CloneSchemaAlways = "always"
+	CloneSchemaNever  = "never"
+)
@@ -0,0 +1,18 @@
+package constants
+
+// constants for the comment_lock database option
+// this controls the explicit lock taken by the transaction which sets the table and column comments
+// for a connection schema
+//
+//   - none: no explicit lock is taken - the comment statements rely on statement-level locking (default).
+//     This allows the comments of many connections to be set in parallel with no impact on other sessions
+//   - table: lock pg_namespace for the duration of the transaction.
+//     This serializes setting comments against all other catalog activity (e.g. schema creation), which avoids
+//     concurrent catalog update errors, but may cause lock timeouts for unrelated sessions on a busy database
+//   - advisory: take a transaction level advisory lock.
+//     This serializes setting comments across connections (and processes) without blocking other catalog activity
+const (
+	CommentLockNone     = "none"
+	CommentLockTable    = "table"
+	CommentLockAdvisory = "advisory"
+)
@@ -10,4 +10,6 @@ const (
 	ConfigKeyServerSearchPath            = "server-search-path"
 	ConfigKeyServerSearchPathPrefix      = "server-search-path-prefix"
 	ConfigKeyBypassHomeDirModfileWarning = "bypass-home-dir-modfile-warning"
+	// set by '.timing verbose' - show a breakdown of the query time by phase
+	ConfigKeyTimingVerbose = "timing-verbose"
 )
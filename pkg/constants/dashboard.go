@@ -2,6 +2,7 @@ package constants
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/turbot/steampipe/pkg/version"
 )
@@ -12,6 +13,27 @@ var DashboardListenAddresses = []string{"localhost", "127.0.0.1"}
 const (
 	DashboardServerDefaultPort    = 9194
 	DashboardAssetsImageRefFormat = "us-docker.pkg.dev/steampipe/steampipe/assets:%s"
+
+	// default timeouts for the dashboard http server
+	DashboardReadHeaderTimeout = 10 * time.Second
+	DashboardReadTimeout       = 30 * time.Second
+	DashboardWriteTimeout      = 60 * time.Second
+	DashboardIdleTimeout       = 120 * time.Second
+
+	// the default maximum size of a message sent to a dashboard client, in bytes
+	DashboardMaxMessageSize = 64 * 1024 * 1024
+	// the default number of messages which may be queued for a dashboard client before it is disconnected
+	DashboardMessageBufferSize = 256
+	// the maximum number of rows sent to a dashboard client in each batch as a panel query streams its result
+	DashboardRowBatchSize = 100
+
+	// dashboard server authentication types
+	DashboardAuthNone  = "none"
+	DashboardAuthToken = "token"
+	DashboardAuthBasic = "basic"
+	DashboardAuthOIDC  = "oidc"
+	// the default lifetime of an authenticated dashboard session
+	DashboardAuthSessionTTL = 12 * time.Hour
 )
 
 var (
@@ -12,6 +12,15 @@ const (
 
 	// MaxBackups is the maximum number of backups that will be retained
 	MaxBackups = 100
+
+	// DefaultConnectionStateBatchSize is the default number of connections written to the connection_state table
+	// in each transaction when a refresh starts
+	DefaultConnectionStateBatchSize = 500
+
+	// DefaultUpdateSchemaMaxParallel is the default maximum number of connection schemas imported or cloned in parallel
+	// during a refresh - this is half the size of the plugin manager connection pool, leaving sessions for
+	// the connection state table updates made while the schemas are updated
+	DefaultUpdateSchemaMaxParallel = 10
 )
 
 const (
@@ -57,6 +66,8 @@ const (
 	RateLimiterDefinitionTable = "steampipe_plugin_limiter"
 	// PluginInstanceTable is the table used to store plugin configs
 	PluginInstanceTable = "steampipe_plugin"
+	// QueryHistoryTable is the (session temporary) table used to expose the interactive query history
+	QueryHistoryTable = "steampipe_query_history"
 
 	// LegacyConnectionStateTable is the table used to store steampipe connection state
 	LegacyConnectionStateTable       = "steampipe_connection_state"
@@ -68,6 +79,14 @@ const (
 	ConnectionStateDeleting          = "deleting"
 	ConnectionStateDisabled          = "disabled"
 	ConnectionStateError             = "error"
+	// ConnectionStateLazy is the state of a connection with import_schema="lazy" whose schema has not yet been imported
+	ConnectionStateLazy = "lazy"
+
+	// ConnectionHealthViewSuffix is appended to the connection state table name to give the name of the view
+	// exposing the connection health check results, i.e. steampipe_connection_health
+	ConnectionHealthViewSuffix = "_health"
+	ConnectionHealthOk         = "ok"
+	ConnectionHealthError      = "error"
 
 	// foreign tables in internal schema
 	ForeignTableScanMetadata              = "steampipe_scan_metadata"
@@ -9,4 +9,6 @@ var (
 	DBRecoveryTimeout        = 24 * time.Hour
 	DBRecoveryRetryBackoff   = 200 * time.Millisecond
 	ServicePingInterval      = 50 * time.Millisecond
+	RefreshLockTimeout       = 60 * time.Second
+	SchemaQueryTimeout       = 30 * time.Second
 )
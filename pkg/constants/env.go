@@ -42,4 +42,43 @@ const (
 
 	EnvMemoryMaxMb       = "STEAMPIPE_MEMORY_MAX_MB"
 	EnvMemoryMaxMbPlugin = "STEAMPIPE_PLUGIN_MEMORY_MAX_MB"
+
+	// EnvCloneSchema accepts the clone_schema option values (auto, always, never)
+	// for backwards compatibility, true and false are treated as auto and never
+	EnvCloneSchema = "STEAMPIPE_CLONE_SCHEMA"
+	// EnvRefreshTimeout is the maximum duration of a connection refresh in seconds (0 for no limit)
+	EnvRefreshTimeout = "STEAMPIPE_REFRESH_TIMEOUT"
+	// EnvSearchPathLimit is the maximum number of connection schemas in the default search path (0 for no limit)
+	EnvSearchPathLimit = "STEAMPIPE_SEARCH_PATH_LIMIT"
+	// EnvRefreshLockTimeout is the time in seconds to wait for a refresh running in another process to complete
+	// (0 to fail immediately if a refresh is in progress)
+	EnvRefreshLockTimeout = "STEAMPIPE_REFRESH_LOCK_TIMEOUT"
+	// EnvCommentLock accepts the comment_lock option values (none, table, advisory)
+	EnvCommentLock = "STEAMPIPE_COMMENT_LOCK"
+	// EnvConnectionStateBatchSize is the number of connections written to the connection_state table in each
+	// transaction when a refresh starts (0 to write all connections in a single transaction)
+	EnvConnectionStateBatchSize = "STEAMPIPE_CONNECTION_STATE_BATCH_SIZE"
+	// EnvConnectionStateSchema and EnvConnectionStateTable set the schema and name of the connection state table
+	// (this allows multiple Steampipe instances sharing a database to each maintain their own connection state)
+	EnvConnectionStateSchema = "STEAMPIPE_CONNECTION_STATE_SCHEMA"
+	EnvConnectionStateTable  = "STEAMPIPE_CONNECTION_STATE_TABLE"
+	// EnvSchemaQueryTimeout is the time in seconds allowed for reading the foreign schemas from the database
+	// catalog (0 for no limit)
+	EnvSchemaQueryTimeout = "STEAMPIPE_SCHEMA_QUERY_TIMEOUT"
+	// EnvUpdateSchemaMaxParallel is the maximum number of connection schemas updated in parallel during a refresh
+	EnvUpdateSchemaMaxParallel = "STEAMPIPE_UPDATE_SCHEMA_MAX_PARALLEL"
+	// EnvCloneSchemaBatchSize is the number of connection schemas cloned in each batch during a refresh
+	// (0 to clone each schema separately)
+	EnvCloneSchemaBatchSize = "STEAMPIPE_CLONE_SCHEMA_BATCH_SIZE"
+	// dashboard server authentication - these allow secrets to be passed to a dashboard server started by the service
+	EnvDashboardAuth             = "STEAMPIPE_DASHBOARD_AUTH"
+	EnvDashboardAuthToken        = "STEAMPIPE_DASHBOARD_AUTH_TOKEN"
+	EnvDashboardAuthUsers        = "STEAMPIPE_DASHBOARD_AUTH_USERS"
+	EnvDashboardAuthRole         = "STEAMPIPE_DASHBOARD_AUTH_ROLE"
+	EnvDashboardOIDCIssuer       = "STEAMPIPE_DASHBOARD_OIDC_ISSUER"
+	EnvDashboardOIDCClientID     = "STEAMPIPE_DASHBOARD_OIDC_CLIENT_ID"
+	EnvDashboardOIDCClientSecret = "STEAMPIPE_DASHBOARD_OIDC_CLIENT_SECRET"
+	// EnvDashboardSSLCert and EnvDashboardSSLKey set the certificate and key used to serve the dashboard over TLS
+	EnvDashboardSSLCert = "STEAMPIPE_DASHBOARD_SSL_CERT"
+	EnvDashboardSSLKey  = "STEAMPIPE_DASHBOARD_SSL_KEY"
 )
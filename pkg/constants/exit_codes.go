@@ -10,13 +10,17 @@ const (
 	ExitCodePluginInstallFailure        = 14  // plugin - install failed
 	ExitCodeSnapshotCreationFailed      = 21  // snapshot - creation failed
 	ExitCodeSnapshotUploadFailed        = 22  // snapshot - upload failed
+	ExitCodeDashboardPanelsFailed       = 23  // snapshot - 1 or more dashboard panels failed
 	ExitCodeServiceSetupFailure         = 31  // service - setup failed
 	ExitCodeServiceStartupFailure       = 32  // service - start failed
 	ExitCodeServiceStopFailure          = 33  // service - stop failed
+	ExitCodeConnectionVerifyFailure     = 34  // service - 1 or more ready connections are empty or unqueryable
 	ExitCodeQueryExecutionFailed        = 41  // query - 1 or more queries failed - change in behavior(previously the exitCode used to be the number of queries that failed)
 	ExitCodeLoginCloudConnectionFailed  = 51  // login - connecting to cloud failed
 	ExitCodeModInitFailed               = 61  // mod - init failed
 	ExitCodeModInstallFailed            = 62  // mod - install failed
+	ExitCodeConnectionTestFailed        = 71  // connection - test failed
+	ExitCodeConnectionRefreshFailed     = 72  // connection - refresh failed
 	ExitCodeInvalidExecutionEnvironment = 249 // common - when steampipe is run in an unsupported environment
 	ExitCodeInitializationFailed        = 250 // common - initialization failed
 	ExitCodeBindPortUnavailable         = 251 // common(service/dashboard) - port binding failed
@@ -14,6 +14,11 @@ const (
 	CsvExtension           = ".csv"
 	TextExtension          = ".txt"
 	SnapshotExtension      = ".sps"
+	HtmlExtension          = ".html"
+	PdfExtension           = ".pdf"
+	DiffExtension          = ".diff.json"
+	ParquetExtension       = ".parquet"
+	ArrowExtension         = ".arrow"
 	TokenExtension         = ".tptt"
 	LegacyTokenExtension   = ".sptt"
 )
@@ -0,0 +1,10 @@
+package constants
+
+// constants for the grant_privileges database option
+// this controls the privileges granted to steampipe users on the tables of each connection schema
+
+// DefaultGrantPrivileges is the set of privileges granted if grant_privileges is not set
+var DefaultGrantPrivileges = []string{"select"}
+
+// GrantablePrivileges is the set of Postgres table privilege keywords which may be used in grant_privileges
+var GrantablePrivileges = []string{"select", "insert", "update", "delete", "truncate", "references", "trigger"}
@@ -22,6 +22,8 @@ const (
 	CmdCache            = ".cache"              // cache control
 	CmdCacheTtl         = ".cache_ttl"          // set cache ttl
 	CmdAutoComplete     = ".autocomplete"       // enable or disable auto complete
+	CmdEdit             = ".edit"               // edit the query in an external editor
+	CmdHistory          = ".history"            // list, search, name and tag query history
 )
 
 // ArgFromMetaquery converts a metaquery of form '.header' into the config argument used to set the mode, i.e. 'header'
@@ -2,4 +2,7 @@ package constants
 
 const (
 	PostgresNotificationChannel = "steampipe_notification"
+	// PostgresConnectionEventChannel is the channel on which connection refresh progress events are sent
+	// (see steampipeconfig.ConnectionEvent)
+	PostgresConnectionEventChannel = "steampipe_connection_events"
 )
@@ -10,4 +10,10 @@ const (
 	OutputFormatBrief         = "brief"
 	OutputFormatSnapshot      = "snapshot"
 	OutputFormatSnapshotShort = "sps"
+	OutputFormatHTML          = "html"
+	OutputFormatPDF           = "pdf"
+	OutputFormatDiff          = "diff"
+	OutputFormatNdjsonStream  = "ndjson-stream"
+	OutputFormatParquet       = "parquet"
+	OutputFormatArrow         = "arrow"
 )
@@ -5,14 +5,17 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 )
 
+// StartCancelHandler cancels the context when the process is interrupted (SIGINT) or terminated (SIGTERM)
+// - SIGTERM is sent by container orchestrators when stopping a container
 func StartCancelHandler(cancel context.CancelFunc) {
 	sigIntChannel := make(chan os.Signal, 1)
-	signal.Notify(sigIntChannel, os.Interrupt)
+	signal.Notify(sigIntChannel, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		<-sigIntChannel
-		log.Println("[TRACE] cancel handler got SIGINT")
+		sig := <-sigIntChannel
+		log.Printf("[TRACE] cancel handler got %s", sig)
 		// call context cancellation function
 		cancel()
 		// leave the channel open - any subsequent interrupts hits will be ignored
@@ -0,0 +1,252 @@
+package controldisplay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/control/controlexecute"
+)
+
+// the control panel type in a snapshot
+const controlPanelType = "control"
+
+// CheckSnapshotResults are the control results read from a check snapshot, keyed by control name
+type CheckSnapshotResults struct {
+	Path     string
+	Controls map[string]*snapshotControl
+}
+
+// snapshotControl is the subset of a snapshot control panel used to compute a diff
+type snapshotControl struct {
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	PanelType string `json:"panel_type"`
+	Error     string `json:"error"`
+	Data      *struct {
+		Rows []map[string]any `json:"rows"`
+	} `json:"data"`
+}
+
+// LoadCheckSnapshot reads the control results from a snapshot - this may be a check snapshot,
+// or a dashboard snapshot containing benchmarks
+func LoadCheckSnapshot(path string) (*CheckSnapshotResults, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot '%s': %s", path, err.Error())
+	}
+	var snapshot struct {
+		Panels map[string]*snapshotControl `json:"panels"`
+	}
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot '%s': %s", path, err.Error())
+	}
+
+	res := &CheckSnapshotResults{Path: path, Controls: make(map[string]*snapshotControl)}
+	for name, panel := range snapshot.Panels {
+		if panel == nil || panel.PanelType != controlPanelType {
+			continue
+		}
+		res.Controls[name] = panel
+	}
+	if len(res.Controls) == 0 {
+		return nil, fmt.Errorf("snapshot '%s' does not contain any control results", path)
+	}
+	return res, nil
+}
+
+// CheckDiffResult is a control result whose status has changed since the previous snapshot
+type CheckDiffResult struct {
+	Control      string                     `json:"control"`
+	ControlTitle string                     `json:"control_title,omitempty"`
+	Resource     string                     `json:"resource"`
+	Dimensions   []controlexecute.Dimension `json:"dimensions,omitempty"`
+	Reason       string                     `json:"reason,omitempty"`
+	// the previous status - empty if the result was not in the previous snapshot
+	PreviousStatus string `json:"previous_status,omitempty"`
+	// the current status - empty if the result is no longer reported
+	Status string `json:"status,omitempty"`
+}
+
+// CheckDiffSummary counts the alarms and errors which are new or have been resolved
+type CheckDiffSummary struct {
+	NewAlarms      int `json:"new_alarms"`
+	ResolvedAlarms int `json:"resolved_alarms"`
+	NewErrors      int `json:"new_errors"`
+	ResolvedErrors int `json:"resolved_errors"`
+}
+
+// CheckDiff is the difference between the results of a check run and a previous snapshot
+//
+// a regression is a result which is now in alarm or error, having had a different status (or not existed) previously
+// an improvement is a result which was in alarm or error, and now has a passing status (or is no longer reported)
+// controls which are not in the previous snapshot are compared against no results, and controls which
+// were not run are ignored
+type CheckDiff struct {
+	Previous     string             `json:"previous"`
+	Summary      CheckDiffSummary   `json:"summary"`
+	Regressions  []*CheckDiffResult `json:"regressions"`
+	Improvements []*CheckDiffResult `json:"improvements"`
+}
+
+// NewCheckDiff compares the results of an executed tree with those of a previous snapshot
+func NewCheckDiff(previous *CheckSnapshotResults, tree *controlexecute.ExecutionTree) *CheckDiff {
+	res := &CheckDiff{
+		Previous:     previous.Path,
+		Regressions:  []*CheckDiffResult{},
+		Improvements: []*CheckDiffResult{},
+	}
+
+	// a control may be run more than once (e.g. if it is a child of multiple benchmarks) - only compare it once
+	compared := make(map[string]bool)
+	for _, run := range tree.ControlRuns {
+		name := run.Control.Name()
+		if compared[name] {
+			continue
+		}
+		compared[name] = true
+		res.addControlRun(run, previous.Controls[name])
+	}
+
+	sortDiffResults(res.Regressions)
+	sortDiffResults(res.Improvements)
+	return res
+}
+
+func (d *CheckDiff) addControlRun(run *controlexecute.ControlRun, previous *snapshotControl) {
+	controlName := run.Control.Name()
+	title := run.Control.GetTitle()
+
+	// if the control failed to run we do not know the status of its results -
+	// report the error if the control did not previously fail
+	if run.GetError() != nil {
+		if previous == nil || previous.Error == "" {
+			d.addRegression(&CheckDiffResult{
+				Control:      controlName,
+				ControlTitle: title,
+				Reason:       run.GetError().Error(),
+				Status:       constants.ControlError,
+			})
+		}
+		return
+	}
+
+	previousRows := make(map[string]map[string]any)
+	if previous != nil && previous.Data != nil {
+		for _, row := range previous.Data.Rows {
+			key := snapshotRowKey(row)
+			if _, ok := previousRows[key]; !ok {
+				previousRows[key] = row
+			}
+		}
+	}
+
+	for _, row := range run.Rows {
+		key := resultRowKey(row)
+		var previousStatus string
+		if previousRow, ok := previousRows[key]; ok {
+			previousStatus = fmt.Sprintf("%v", previousRow["status"])
+			delete(previousRows, key)
+		}
+		result := &CheckDiffResult{
+			Control:        controlName,
+			ControlTitle:   title,
+			Resource:       row.Resource,
+			Dimensions:     row.Dimensions,
+			Reason:         row.Reason,
+			PreviousStatus: previousStatus,
+			Status:         row.Status,
+		}
+		switch {
+		case isFailedStatus(row.Status) && row.Status != previousStatus:
+			d.addRegression(result)
+		case isFailedStatus(previousStatus) && !isFailedStatus(row.Status):
+			d.addImprovement(result)
+		}
+	}
+
+	// previous results which are no longer reported are resolved
+	for _, row := range previousRows {
+		previousStatus := fmt.Sprintf("%v", row["status"])
+		if !isFailedStatus(previousStatus) {
+			continue
+		}
+		resource, _ := row["resource"].(string)
+		reason, _ := row["reason"].(string)
+		d.addImprovement(&CheckDiffResult{
+			Control:        controlName,
+			ControlTitle:   title,
+			Resource:       resource,
+			Dimensions:     snapshotRowDimensions(row),
+			Reason:         reason,
+			PreviousStatus: previousStatus,
+		})
+	}
+}
+
+func (d *CheckDiff) addRegression(result *CheckDiffResult) {
+	if result.Status == constants.ControlAlarm {
+		d.Summary.NewAlarms++
+	} else {
+		d.Summary.NewErrors++
+	}
+	d.Regressions = append(d.Regressions, result)
+}
+
+func (d *CheckDiff) addImprovement(result *CheckDiffResult) {
+	if result.PreviousStatus == constants.ControlAlarm {
+		d.Summary.ResolvedAlarms++
+	} else {
+		d.Summary.ResolvedErrors++
+	}
+	d.Improvements = append(d.Improvements, result)
+}
+
+func isFailedStatus(status string) bool {
+	return status == constants.ControlAlarm || status == constants.ControlError
+}
+
+// resultRowKey identifies the result of a control - the resource and dimension values
+func resultRowKey(row *controlexecute.ResultRow) string {
+	return diffResultKey(row.Resource, row.Dimensions)
+}
+
+func snapshotRowKey(row map[string]any) string {
+	resource, _ := row["resource"].(string)
+	return diffResultKey(resource, snapshotRowDimensions(row))
+}
+
+func diffResultKey(resource string, dimensions []controlexecute.Dimension) string {
+	parts := make([]string, len(dimensions))
+	for i, d := range dimensions {
+		parts[i] = fmt.Sprintf("%s=%s", d.Key, d.Value)
+	}
+	sort.Strings(parts)
+	return strings.Join(append([]string{resource}, parts...), "\x00")
+}
+
+// snapshotRowDimensions returns the dimensions of a snapshot result row - snapshot rows are flattened,
+// so all columns other than reason, resource and status are dimensions
+func snapshotRowDimensions(row map[string]any) []controlexecute.Dimension {
+	var res []controlexecute.Dimension
+	for key, value := range row {
+		if key == "reason" || key == "resource" || key == "status" {
+			continue
+		}
+		res = append(res, controlexecute.Dimension{Key: key, Value: fmt.Sprintf("%v", value)})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
+
+func sortDiffResults(results []*CheckDiffResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Control != results[j].Control {
+			return results[i].Control < results[j].Control
+		}
+		return diffResultKey(results[i].Resource, results[i].Dimensions) < diffResultKey(results[j].Resource, results[j].Dimensions)
+	})
+}
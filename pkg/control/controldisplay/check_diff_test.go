@@ -0,0 +1,123 @@
+package controldisplay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/control/controlexecute"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+const previousCheckSnapshot = `{
+  "schema_version": "20221222",
+  "panels": {
+    "m.benchmark.b1": {"name": "m.benchmark.b1", "panel_type": "benchmark"},
+    "m.control.c1": {
+      "name": "m.control.c1",
+      "panel_type": "control",
+      "data": {
+        "rows": [
+          {"resource": "r1", "status": "alarm", "reason": "r1 alarm", "region": "us-east-1"},
+          {"resource": "r1", "status": "ok", "reason": "r1 ok", "region": "us-east-2"},
+          {"resource": "r2", "status": "ok", "reason": "r2 ok"},
+          {"resource": "r3", "status": "alarm", "reason": "r3 alarm"},
+          {"resource": "r4", "status": "error", "reason": "r4 error"},
+          {"resource": "r5", "status": "alarm", "reason": "r5 alarm"}
+        ]
+      }
+    },
+    "m.control.c3": {"name": "m.control.c3", "panel_type": "control", "data": {"rows": [{"resource": "r1", "status": "alarm"}]}}
+  }
+}`
+
+func TestCheckDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "previous.sps")
+	if err := os.WriteFile(path, []byte(previousCheckSnapshot), 0600); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := LoadCheckSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(previous.Controls) != 2 {
+		t.Fatalf("expected 2 controls to be loaded from the snapshot, got %d", len(previous.Controls))
+	}
+
+	newControlRun := func(shortName string, rows ...*controlexecute.ResultRow) *controlexecute.ControlRun {
+		control := &modconfig.Control{}
+		control.FullName = "m.control." + shortName
+		control.ShortName = shortName
+		return &controlexecute.ControlRun{Control: control, Rows: rows}
+	}
+	region := func(value string) []controlexecute.Dimension {
+		return []controlexecute.Dimension{{Key: "region", Value: value}}
+	}
+	c1 := newControlRun("c1",
+		// alarm in us-east-1 is unchanged, us-east-2 is a new alarm
+		&controlexecute.ResultRow{Resource: "r1", Status: constants.ControlAlarm, Dimensions: region("us-east-1")},
+		&controlexecute.ResultRow{Resource: "r1", Status: constants.ControlAlarm, Dimensions: region("us-east-2")},
+		&controlexecute.ResultRow{Resource: "r2", Status: constants.ControlError},
+		&controlexecute.ResultRow{Resource: "r3", Status: constants.ControlOk},
+		&controlexecute.ResultRow{Resource: "r4", Status: constants.ControlSkip},
+		// r5 is no longer reported
+		&controlexecute.ResultRow{Resource: "r6", Status: constants.ControlAlarm},
+		&controlexecute.ResultRow{Resource: "r7", Status: constants.ControlOk},
+	)
+	// c2 is not in the previous snapshot
+	c2 := newControlRun("c2", &controlexecute.ResultRow{Resource: "r1", Status: constants.ControlAlarm})
+	// c1 is run twice (e.g. by two benchmarks) and c3 is not run - neither should affect the diff
+	tree := &controlexecute.ExecutionTree{ControlRuns: []*controlexecute.ControlRun{c1, c2, c1}}
+
+	diff := NewCheckDiff(previous, tree)
+
+	type diffResult struct{ control, resource, previousStatus, status string }
+	toDiffResults := func(results []*CheckDiffResult) []diffResult {
+		res := make([]diffResult, len(results))
+		for i, r := range results {
+			res[i] = diffResult{r.Control, r.Resource, r.PreviousStatus, r.Status}
+		}
+		return res
+	}
+	type checkDiffTest struct {
+		actual   []diffResult
+		expected []diffResult
+	}
+	tests := map[string]checkDiffTest{
+		"regressions": {
+			toDiffResults(diff.Regressions),
+			[]diffResult{
+				{"m.control.c1", "r1", "ok", "alarm"},
+				{"m.control.c1", "r2", "ok", "error"},
+				{"m.control.c1", "r6", "", "alarm"},
+				{"m.control.c2", "r1", "", "alarm"},
+			},
+		},
+		"improvements": {
+			toDiffResults(diff.Improvements),
+			[]diffResult{
+				{"m.control.c1", "r3", "alarm", "ok"},
+				{"m.control.c1", "r4", "error", "skip"},
+				{"m.control.c1", "r5", "alarm", ""},
+			},
+		},
+	}
+	for name, test := range tests {
+		if len(test.actual) != len(test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, test.actual)
+			continue
+		}
+		for i := range test.expected {
+			if test.actual[i] != test.expected[i] {
+				t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, test.actual)
+				break
+			}
+		}
+	}
+
+	expectedSummary := CheckDiffSummary{NewAlarms: 3, ResolvedAlarms: 2, NewErrors: 1, ResolvedErrors: 1}
+	if diff.Summary != expectedSummary {
+		t.Errorf("expected summary %+v, got %+v", expectedSummary, diff.Summary)
+	}
+}
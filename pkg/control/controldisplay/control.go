@@ -107,9 +107,13 @@ func (r ControlRenderer) Render() string {
 	// now render the results (if any)
 	var resultStrings []string
 	for _, row := range r.run.Rows {
+		reason := row.Reason
+		if row.Waiver != nil {
+			reason = fmt.Sprintf("Waived (%s): %s", row.Waiver.Reason, row.Reason)
+		}
 		resultRenderer := NewResultRenderer(
 			row.Status,
-			row.Reason,
+			reason,
 			row.Dimensions,
 			r.colorGenerator,
 			r.width,
@@ -0,0 +1,134 @@
+package controldisplay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/control/controlexecute"
+	"github.com/turbot/steampipe/pkg/control/controlstatus"
+)
+
+// the types of line written by NdjsonStreamControlHooks
+const (
+	ndjsonLineResult  = "result"
+	ndjsonLineError   = "error"
+	ndjsonLineSummary = "summary"
+)
+
+// NdjsonStreamControlHooks is a struct which implements ControlHooks, and writes the results of each control
+// as newline delimited JSON as soon as the control completes
+//
+// a line is written for each result row, and for each control which fails to run - when all controls
+// have completed a final summary line is written
+type NdjsonStreamControlHooks struct {
+	writer    io.Writer
+	writeLock sync.Mutex
+}
+
+func NewNdjsonStreamControlHooks(writer io.Writer) *NdjsonStreamControlHooks {
+	return &NdjsonStreamControlHooks{writer: writer}
+}
+
+// ndjsonResultLine is the line written for a control result row, or a control error
+type ndjsonResultLine struct {
+	Type         string                       `json:"type"`
+	Control      string                       `json:"control"`
+	ControlTitle string                       `json:"control_title,omitempty"`
+	Severity     string                       `json:"severity,omitempty"`
+	Status       string                       `json:"status"`
+	Reason       string                       `json:"reason,omitempty"`
+	Resource     string                       `json:"resource,omitempty"`
+	Dimensions   []controlexecute.Dimension   `json:"dimensions,omitempty"`
+	Waiver       *controlexecute.ResultWaiver `json:"waiver,omitempty"`
+	Error        string                       `json:"error,omitempty"`
+}
+
+// ndjsonSummaryLine is the final line, written when all controls have completed
+type ndjsonSummaryLine struct {
+	Type     string                      `json:"type"`
+	Controls int                         `json:"controls"`
+	Errors   int                         `json:"control_errors"`
+	Summary  controlstatus.StatusSummary `json:"summary"`
+}
+
+func (c *NdjsonStreamControlHooks) OnStart(context.Context, *controlstatus.ControlProgress) {
+}
+
+func (c *NdjsonStreamControlHooks) OnControlStart(context.Context, controlstatus.ControlRunStatusProvider, *controlstatus.ControlProgress) {
+}
+
+func (c *NdjsonStreamControlHooks) OnControlComplete(_ context.Context, controlRun controlstatus.ControlRunStatusProvider, _ *controlstatus.ControlProgress) {
+	if run, ok := controlRun.(*controlexecute.ControlRun); ok {
+		c.writeControlRun(run)
+	}
+}
+
+func (c *NdjsonStreamControlHooks) OnControlError(_ context.Context, controlRun controlstatus.ControlRunStatusProvider, _ *controlstatus.ControlProgress) {
+	if run, ok := controlRun.(*controlexecute.ControlRun); ok {
+		c.writeControlRun(run)
+	}
+}
+
+func (c *NdjsonStreamControlHooks) OnComplete(_ context.Context, progress *controlstatus.ControlProgress) {
+	line := &ndjsonSummaryLine{
+		Type:     ndjsonLineSummary,
+		Controls: progress.Total,
+		Errors:   progress.Error,
+	}
+	if progress.StatusSummaries != nil {
+		line.Summary = *progress.StatusSummaries
+	}
+	c.writeLines(line)
+}
+
+// writeControlRun writes a line for each result of the control run, followed by a line for the
+// run error (if any) - a control which times out may have returned some results before failing
+func (c *NdjsonStreamControlHooks) writeControlRun(run *controlexecute.ControlRun) {
+	lines := make([]any, 0, len(run.Rows)+1)
+	for _, row := range run.Rows {
+		lines = append(lines, &ndjsonResultLine{
+			Type:         ndjsonLineResult,
+			Control:      run.ControlId,
+			ControlTitle: run.Title,
+			Severity:     run.Severity,
+			Status:       row.Status,
+			Reason:       row.Reason,
+			Resource:     row.Resource,
+			Dimensions:   row.Dimensions,
+			Waiver:       row.Waiver,
+		})
+	}
+	if err := run.GetError(); err != nil {
+		lines = append(lines, &ndjsonResultLine{
+			Type:         ndjsonLineError,
+			Control:      run.ControlId,
+			ControlTitle: run.Title,
+			Severity:     run.Severity,
+			Status:       constants.ControlError,
+			Error:        err.Error(),
+		})
+	}
+	c.writeLines(lines...)
+}
+
+// writeLines writes the lines of a control together, so the output of controls completing in parallel is not interleaved
+func (c *NdjsonStreamControlHooks) writeLines(lines ...any) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	for _, line := range lines {
+		b, err := json.Marshal(line)
+		if err != nil {
+			log.Printf("[WARN] failed to marshal ndjson-stream line: %s", err.Error())
+			continue
+		}
+		if _, err := c.writer.Write(append(b, '\n')); err != nil {
+			log.Printf("[WARN] failed to write ndjson-stream line: %s", err.Error())
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/control/controlexecute"
+	"github.com/turbot/steampipe/pkg/control/controlstatus"
+)
+
+func TestNdjsonStreamControlHooks(t *testing.T) {
+	var out bytes.Buffer
+	hooks := NewNdjsonStreamControlHooks(&out)
+	ctx := context.Background()
+	progress := controlstatus.NewControlProgress(2)
+
+	runs := []*controlexecute.ControlRun{
+		{
+			ControlId: "control.c1",
+			Title:     "Control 1",
+			Severity:  "high",
+			Summary:   &controlstatus.StatusSummary{Alarm: 1, Ok: 1},
+			Rows: controlexecute.ResultRows{
+				{Resource: "r1", Status: "alarm", Reason: "r1 alarm", Dimensions: []controlexecute.Dimension{{Key: "region", Value: "us-east-1"}}},
+				{Resource: "r2", Status: "skip", Reason: "r2 alarm", Waiver: &controlexecute.ResultWaiver{Name: "w1", Reason: "accepted", OriginalStatus: "alarm"}},
+			},
+		},
+		{
+			ControlId: "control.c2",
+			Summary:   &controlstatus.StatusSummary{},
+		},
+	}
+
+	hooks.OnStart(ctx, progress)
+	for _, run := range runs {
+		progress.Complete++
+		progress.StatusSummaries.Merge(run.Summary)
+		hooks.OnControlComplete(ctx, run, progress)
+		if run.ControlId == "control.c1" && strings.Count(out.String(), "\n") != 2 {
+			t.Fatalf("Test: 'results written on completion' FAILED : expected 2 lines after the first control, got:\n%s", out.String())
+		}
+	}
+	hooks.OnComplete(ctx, progress)
+
+	expected := []map[string]any{
+		{"type": "result", "control": "control.c1", "control_title": "Control 1", "severity": "high", "status": "alarm", "reason": "r1 alarm", "resource": "r1"},
+		{"type": "result", "control": "control.c1", "control_title": "Control 1", "severity": "high", "status": "skip", "reason": "r2 alarm", "resource": "r2"},
+		{"type": "summary", "controls": float64(2), "control_errors": float64(0)},
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Test: 'line count' FAILED : expected %d lines, got %d:\n%s", len(expected), len(lines), out.String())
+	}
+	for i, line := range lines {
+		var actual map[string]any
+		if err := json.Unmarshal([]byte(line), &actual); err != nil {
+			t.Fatalf("Test: 'line %d' FAILED : invalid JSON %s: %s", i, line, err.Error())
+		}
+		for key, value := range expected[i] {
+			if actual[key] != value {
+				t.Errorf("Test: 'line %d' FAILED : expected %s '%v', got '%v'", i, key, value, actual[key])
+			}
+		}
+	}
+
+	if !strings.Contains(lines[0], `"dimensions":[{"key":"region","value":"us-east-1"}]`) {
+		t.Errorf("Test: 'dimensions' FAILED : dimensions missing from %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"waiver":{"name":"w1"`) {
+		t.Errorf("Test: 'waiver' FAILED : waiver missing from %s", lines[1])
+	}
+	if !strings.Contains(lines[2], `"summary":{"alarm":1,"ok":1,"info":0,"skip":0,"error":0}`) {
+		t.Errorf("Test: 'summary' FAILED : unexpected summary %s", lines[2])
+	}
+}
@@ -25,6 +25,8 @@ func NewFormatResolver() (*FormatResolver, error) {
 		&NullFormatter{},
 		&TextFormatter{},
 		&SnapshotFormatter{},
+		&DiffFormatter{},
+		&NdjsonStreamFormatter{},
 	}
 
 	res := &FormatResolver{
@@ -72,8 +74,8 @@ func (r *FormatResolver) registerFormatter(f Formatter) error {
 		}
 		r.formatterByName[alias] = f
 	}
-	// add to exportFormatters list (exclude 'None')
-	if f.Name() != constants.OutputFormatNone {
+	// add to exportFormatters list (exclude 'None' and 'ndjson-stream', which only write to the console)
+	if f.Name() != constants.OutputFormatNone && f.Name() != constants.OutputFormatNdjsonStream {
 		r.exportFormatters = append(r.exportFormatters, f)
 	}
 	return nil
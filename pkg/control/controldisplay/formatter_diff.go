@@ -0,0 +1,126 @@
+package controldisplay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/control/controlexecute"
+)
+
+// DiffFormatter formats the difference between the results of a check run and the snapshot
+// passed using --diff, as JSON
+type DiffFormatter struct {
+	FormatterBase
+}
+
+func (f *DiffFormatter) Format(_ context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	diff, err := checkDiffFromConfig(tree)
+	if err != nil {
+		return nil, err
+	}
+	res, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(fmt.Sprintf("%s\n", string(res))), nil
+}
+
+func (f *DiffFormatter) FileExtension() string {
+	return constants.DiffExtension
+}
+
+func (f *DiffFormatter) Name() string {
+	return constants.OutputFormatDiff
+}
+
+func (f *DiffFormatter) Alias() string {
+	return strings.TrimPrefix(constants.DiffExtension, ".")
+}
+
+// DiffTextFormatter displays the difference between the results of a check run and the snapshot
+// passed using --diff - this replaces the text output when --diff is set
+type DiffTextFormatter struct {
+	TextFormatter
+}
+
+func (f DiffTextFormatter) Format(_ context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	diff, err := checkDiffFromConfig(tree)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(fmt.Sprintf("\n%s\n", renderCheckDiff(diff))), nil
+}
+
+// checkDiffFromConfig compares the results of an executed tree with the snapshot passed using --diff
+func checkDiffFromConfig(tree *controlexecute.ExecutionTree) (*CheckDiff, error) {
+	path := viper.GetString(constants.ArgDiff)
+	if path == "" {
+		return nil, fmt.Errorf("the '%s' format requires a previous snapshot to be passed using '--%s'", constants.OutputFormatDiff, constants.ArgDiff)
+	}
+	previous, err := LoadCheckSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewCheckDiff(previous, tree), nil
+}
+
+func renderCheckDiff(diff *CheckDiff) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s\n", ControlColors.GroupTitle("Changes since"), diff.Previous))
+
+	b.WriteString(fmt.Sprintf("\n%s\n", ControlColors.GroupTitle(fmt.Sprintf("Regressions (%d)", len(diff.Regressions)))))
+	for _, r := range diff.Regressions {
+		b.WriteString(renderDiffResult(r))
+	}
+	b.WriteString(fmt.Sprintf("\n%s\n", ControlColors.GroupTitle(fmt.Sprintf("Improvements (%d)", len(diff.Improvements)))))
+	for _, r := range diff.Improvements {
+		b.WriteString(renderDiffResult(r))
+	}
+
+	s := diff.Summary
+	b.WriteString(fmt.Sprintf("\nNew alarms: %d, resolved alarms: %d, new errors: %d, resolved errors: %d\n",
+		s.NewAlarms, s.ResolvedAlarms, s.NewErrors, s.ResolvedErrors))
+	return b.String()
+}
+
+// renderDiffResult renders a changed result as a single line, e.g.
+// ALARM: aws_compliance.control.s3_bucket_versioning_enabled arn:aws:s3:::my-bucket (ok -> alarm): my-bucket versioning disabled.
+func renderDiffResult(r *CheckDiffResult) string {
+	var statusString string
+	if r.Status == "" {
+		// the result is no longer reported
+		statusString = fmt.Sprintf("%-5s%s ", "GONE", ControlColors.StatusColon(":"))
+	} else {
+		statusString = NewResultStatusRenderer(r.Status).Render()
+	}
+
+	previousStatus := r.PreviousStatus
+	if previousStatus == "" {
+		previousStatus = "new"
+	}
+	currentStatus := r.Status
+	if currentStatus == "" {
+		currentStatus = "gone"
+	}
+
+	var dimensions []string
+	for _, d := range r.Dimensions {
+		dimensions = append(dimensions, d.Value)
+	}
+	target := strings.TrimSpace(strings.Join(append([]string{r.Resource}, dimensions...), " "))
+
+	line := fmt.Sprintf("%s%s", statusString, r.Control)
+	if target != "" {
+		line += " " + target
+	}
+	line += fmt.Sprintf(" (%s -> %s)", previousStatus, currentStatus)
+	if r.Reason != "" {
+		line += ": " + r.Reason
+	}
+	return line + "\n"
+}
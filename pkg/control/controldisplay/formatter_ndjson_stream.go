@@ -0,0 +1,29 @@
+package controldisplay
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/control/controlexecute"
+)
+
+// NdjsonStreamFormatter is used for the 'ndjson-stream' output - the results are written by
+// NdjsonStreamControlHooks as each control completes, so there is nothing left to format
+type NdjsonStreamFormatter struct {
+	FormatterBase
+}
+
+func (f *NdjsonStreamFormatter) Format(context.Context, *controlexecute.ExecutionTree) (io.Reader, error) {
+	return strings.NewReader(""), nil
+}
+
+func (f *NdjsonStreamFormatter) FileExtension() string {
+	// will not be called
+	return ""
+}
+
+func (f *NdjsonStreamFormatter) Name() string {
+	return constants.OutputFormatNdjsonStream
+}
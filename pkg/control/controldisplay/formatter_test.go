@@ -2,13 +2,19 @@ package controldisplay
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"io"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/control/controlexecute"
+	"github.com/turbot/steampipe/pkg/control/controlstatus"
 	"github.com/turbot/steampipe/pkg/filepaths"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
 )
 
 // testFormatter is an implementation of the Formatter interface
@@ -76,6 +82,22 @@ var formatterTestCase = []testCase{
 			name:      "nunit3",
 		},
 	},
+	{
+		input: "junit.xml",
+		expected: testFormatter{
+			alias:     "junit.xml",
+			extension: ".junit.xml",
+			name:      "junit",
+		},
+	},
+	{
+		input: "sarif",
+		expected: testFormatter{
+			alias:     "",
+			extension: ".sarif",
+			name:      "sarif",
+		},
+	},
 }
 
 func TestFormatResolver(t *testing.T) {
@@ -123,3 +145,250 @@ func TestFormatResolver(t *testing.T) {
 		}
 	}
 }
+
+func TestSarifFormatter(t *testing.T) {
+	tmpDir, err := os.MkdirTemp(os.TempDir(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filepaths.SteampipeDir = tmpDir
+	if err := EnsureTemplates(); err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := NewFormatResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter, err := resolver.GetFormatter("sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newControl := func(shortName, severity string) *modconfig.Control {
+		control := &modconfig.Control{Severity: &severity}
+		control.FullName = "m.control." + shortName
+		control.ShortName = shortName
+		control.Title = &shortName
+		return control
+	}
+	c1, c2 := newControl("c1", "high"), newControl("c2", "")
+	run1 := &controlexecute.ControlRun{Control: c1, Severity: "high", Tags: map[string]string{"service": "s3"}}
+	run1.Rows = controlexecute.ResultRows{
+		{Control: c1, Run: run1, Status: constants.ControlAlarm, Reason: "bucket is public", Resource: "arn:aws:s3:::b1",
+			Dimensions: []controlexecute.Dimension{{Key: "region", Value: "us-east-1"}}},
+		{Control: c1, Run: run1, Status: constants.ControlOk, Reason: "bucket is private", Resource: "arn:aws:s3:::b2"},
+	}
+	run2 := &controlexecute.ControlRun{Control: c2}
+	run2.Rows = controlexecute.ResultRows{
+		{Control: c2, Run: run2, Status: constants.ControlAlarm, Reason: "no severity"},
+		{Control: c2, Run: run2, Status: constants.ControlSkip, Reason: "waived", Resource: "r1",
+			Waiver: &controlexecute.ResultWaiver{Name: "w1", Reason: "accepted risk", OriginalStatus: constants.ControlAlarm}},
+	}
+	// c1 is run twice (e.g. by two benchmarks), but should only have one rule
+	tree := &controlexecute.ExecutionTree{ControlRuns: []*controlexecute.ControlRun{run1, run2, run1}}
+
+	reader, err := formatter.Format(context.Background(), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						Id                   string `json:"id"`
+						DefaultConfiguration struct {
+							Level string `json:"level"`
+						} `json:"defaultConfiguration"`
+						Properties map[string]any `json:"properties"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleId    string `json:"ruleId"`
+				Kind      string `json:"kind"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							Uri string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				Suppressions []struct {
+					Kind          string `json:"kind"`
+					Justification string `json:"justification"`
+				} `json:"suppressions"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(output, &sarif); err != nil {
+		t.Fatalf("invalid SARIF output: %v\n%s", err, output)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 {
+		t.Fatalf("unexpected SARIF output: %s", output)
+	}
+	run := sarif.Runs[0]
+
+	rules := run.Tool.Driver.Rules
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+	if rules[0].Id != "m.control.c1" || rules[0].DefaultConfiguration.Level != "error" || rules[0].Properties["security-severity"] != "7.0" {
+		t.Errorf("unexpected rule for a high severity control: %+v", rules[0])
+	}
+	if rules[1].Id != "m.control.c2" || rules[1].DefaultConfiguration.Level != "warning" {
+		t.Errorf("unexpected rule for a control with no severity: %+v", rules[1])
+	}
+
+	if len(run.Results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(run.Results))
+	}
+	type resultTest struct {
+		ruleId   string
+		kind     string
+		level    string
+		location string
+	}
+	expected := []resultTest{
+		{"m.control.c1", "fail", "error", "arn:aws:s3:::b1"},
+		{"m.control.c1", "pass", "none", "arn:aws:s3:::b2"},
+		{"m.control.c2", "fail", "warning", ""},
+	}
+	for i, test := range expected {
+		res := run.Results[i]
+		location := ""
+		if len(res.Locations) > 0 {
+			location = res.Locations[0].PhysicalLocation.ArtifactLocation.Uri
+		}
+		if res.RuleId != test.ruleId || res.Kind != test.kind || res.Level != test.level || location != test.location {
+			t.Errorf("Test: 'result %d' FAILED : expected %+v, got %+v", i, test, res)
+		}
+	}
+
+	// waived results are suppressed
+	if suppressions := run.Results[3].Suppressions; len(suppressions) != 1 || suppressions[0].Kind != "external" || suppressions[0].Justification != "accepted risk" {
+		t.Errorf("expected the waived result to be suppressed, got %+v", run.Results[3])
+	}
+	if len(run.Results[0].Suppressions) != 0 {
+		t.Errorf("expected a result which is not waived to have no suppressions, got %+v", run.Results[0].Suppressions)
+	}
+}
+
+func TestJUnitFormatter(t *testing.T) {
+	tmpDir, err := os.MkdirTemp(os.TempDir(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	filepaths.SteampipeDir = tmpDir
+	if err := EnsureTemplates(); err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := NewFormatResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter, err := resolver.GetFormatter("junit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newControlRun := func(group *controlexecute.ResultGroup, shortName string, summary controlstatus.StatusSummary, rows ...*controlexecute.ResultRow) *controlexecute.ControlRun {
+		run := &controlexecute.ControlRun{
+			ControlId: "m.control." + shortName,
+			Title:     shortName + " <&>",
+			Summary:   &summary,
+			Rows:      rows,
+			Duration:  1500 * time.Millisecond,
+			Group:     group,
+		}
+		group.ControlRuns = append(group.ControlRuns, run)
+		return run
+	}
+	root := &controlexecute.ResultGroup{GroupId: "m.benchmark.root", Title: "Root"}
+	child := &controlexecute.ResultGroup{GroupId: "m.benchmark.child", Title: "Child"}
+	root.Groups = []*controlexecute.ResultGroup{child}
+
+	passed := newControlRun(root, "passed", controlstatus.StatusSummary{Ok: 1}, &controlexecute.ResultRow{Status: constants.ControlOk, Resource: "r1", Reason: "ok"})
+	failed := newControlRun(child, "failed", controlstatus.StatusSummary{Ok: 1, Alarm: 1},
+		&controlexecute.ResultRow{Status: constants.ControlOk, Resource: "r1", Reason: "r1 is ok"},
+		&controlexecute.ResultRow{Status: constants.ControlAlarm, Resource: "r2", Reason: "r2 is <bad>"})
+	errored := newControlRun(child, "errored", controlstatus.StatusSummary{})
+	errored.RunErrorString = "relation does not exist"
+	skipped := newControlRun(child, "skipped", controlstatus.StatusSummary{Skip: 1}, &controlexecute.ResultRow{Status: constants.ControlSkip, Reason: "skipped"})
+	tree := &controlexecute.ExecutionTree{Root: root, ControlRuns: []*controlexecute.ControlRun{passed, failed, errored, skipped}}
+
+	reader, err := formatter.Format(context.Background(), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type junitResult struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+	type junitTestCase struct {
+		Name      string       `xml:"name,attr"`
+		ClassName string       `xml:"classname,attr"`
+		Time      string       `xml:"time,attr"`
+		Failure   *junitResult `xml:"failure"`
+		Error     *junitResult `xml:"error"`
+		Skipped   *struct{}    `xml:"skipped"`
+	}
+	type junitTestSuite struct {
+		Name      string          `xml:"name,attr"`
+		Tests     int             `xml:"tests,attr"`
+		Failures  int             `xml:"failures,attr"`
+		Errors    int             `xml:"errors,attr"`
+		Skipped   int             `xml:"skipped,attr"`
+		TestCases []junitTestCase `xml:"testcase"`
+	}
+	var junit struct {
+		Tests      int              `xml:"tests,attr"`
+		Failures   int              `xml:"failures,attr"`
+		Errors     int              `xml:"errors,attr"`
+		Skipped    int              `xml:"skipped,attr"`
+		TestSuites []junitTestSuite `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(output, &junit); err != nil {
+		t.Fatalf("invalid JUnit output: %v\n%s", err, output)
+	}
+	if junit.Tests != 4 || junit.Failures != 1 || junit.Errors != 1 || junit.Skipped != 1 {
+		t.Errorf("unexpected totals %+v", junit)
+	}
+	if len(junit.TestSuites) != 2 {
+		t.Fatalf("expected a test suite for each group, got %d\n%s", len(junit.TestSuites), output)
+	}
+	if suite := junit.TestSuites[1]; suite.Name != "Child" || suite.Tests != 3 || suite.Failures != 1 || suite.Errors != 1 || suite.Skipped != 1 {
+		t.Errorf("unexpected test suite %+v", suite)
+	}
+
+	testCases := junit.TestSuites[1].TestCases
+	if len(testCases) != 3 {
+		t.Fatalf("expected 3 test cases, got %d", len(testCases))
+	}
+	if tc := testCases[0]; tc.Name != "failed <&>" || tc.ClassName != "m.control.failed" || tc.Time != "1.5" || tc.Failure == nil || !strings.Contains(tc.Failure.Text, "r2: r2 is <bad>") || strings.Contains(tc.Failure.Text, "r1") {
+		t.Errorf("unexpected test case for a control with alarms %+v", tc)
+	}
+	if tc := testCases[1]; tc.Error == nil || tc.Error.Message != "relation does not exist" {
+		t.Errorf("unexpected test case for a control which failed to run %+v", tc)
+	}
+	if tc := testCases[2]; tc.Skipped == nil || tc.Failure != nil || tc.Error != nil {
+		t.Errorf("unexpected test case for a skipped control %+v", tc)
+	}
+	if tc := junit.TestSuites[0].TestCases[0]; tc.Failure != nil || tc.Error != nil || tc.Skipped != nil {
+		t.Errorf("unexpected test case for a passed control %+v", tc)
+	}
+}
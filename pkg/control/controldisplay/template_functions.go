@@ -3,6 +3,7 @@ package controldisplay
 import (
 	"bytes"
 	"encoding/csv"
+	"encoding/xml"
 	"fmt"
 	"strings"
 	"sync"
@@ -15,7 +16,7 @@ import (
 // templateFuncs merges desired functions from sprig with custom functions that we
 // define in steampipe
 func templateFuncs(renderContext TemplateRenderContext) template.FuncMap {
-	useFromSprigMap := []string{"upper", "toJson", "quote", "dict", "add", "now", "toPrettyJson"}
+	useFromSprigMap := []string{"upper", "toJson", "quote", "dict", "add", "now", "toPrettyJson", "hasKey", "set"}
 
 	var funcs template.FuncMap = template.FuncMap{}
 	sprigMap := sprig.TxtFuncMap()
@@ -34,6 +35,7 @@ func templateFuncs(renderContext TemplateRenderContext) template.FuncMap {
 	formatterTemplateFuncMap := template.FuncMap{
 		"durationInSeconds": durationInSeconds,
 		"toCsvCell":         toCSVCellFnFactory(renderContext.Config.Separator),
+		"xmlEscape":         xmlEscape,
 	}
 	for k, v := range formatterTemplateFuncMap {
 		funcs[k] = v
@@ -65,5 +67,12 @@ func toCSVCellFnFactory(comma string) func(interface{}) string {
 	}
 }
 
+// xmlEscape escapes a value for use as xml text or an xml attribute value
+func xmlEscape(v interface{}) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(fmt.Sprintf("%v", v)))
+	return b.String()
+}
+
 // durationInSeconds returns the passed in duration as seconds
 func durationInSeconds(t time.Duration) float64 { return t.Seconds() }
@@ -0,0 +1,175 @@
+package controlexecute
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	typehelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/ociinstaller"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"golang.org/x/sync/semaphore"
+)
+
+// matches (optionally schema qualified) identifiers in control SQL
+var sqlIdentifierRegex = regexp.MustCompile(`[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?`)
+
+// concurrencyLimits limit the number of controls which execute in parallel for a plugin or connection,
+// e.g. '--plugin-max-parallel aws=3' runs at most 3 controls which query aws tables at once
+//
+// the plugins and connections a control uses are determined from the tables referenced by its SQL:
+//   - a table prefixed with the name of a plugin (e.g. aws_s3_bucket) uses the plugin, and the first connection
+//     for the plugin in the search path
+//   - a table qualified with a connection name (e.g. aws_prod.aws_s3_bucket) uses the connection and its plugin
+type concurrencyLimits struct {
+	// semaphores, keyed by plugin or connection name
+	locks map[string]*semaphore.Weighted
+	// the plugin name of each connection
+	connectionPlugins map[string]string
+	// the names of the plugins used by connections
+	plugins map[string]struct{}
+	// the first connection in the search path for each plugin
+	defaultConnections map[string]string
+}
+
+func newConcurrencyLimits(limits map[string]int64, connectionPlugins map[string]string, searchPath []string) *concurrencyLimits {
+	res := &concurrencyLimits{
+		locks:              make(map[string]*semaphore.Weighted, len(limits)),
+		connectionPlugins:  connectionPlugins,
+		plugins:            make(map[string]struct{}),
+		defaultConnections: make(map[string]string),
+	}
+	for _, plugin := range connectionPlugins {
+		res.plugins[plugin] = struct{}{}
+	}
+	for name, limit := range limits {
+		res.locks[name] = semaphore.NewWeighted(limit)
+		_, isConnection := connectionPlugins[name]
+		if _, isPlugin := res.plugins[name]; !isPlugin && !isConnection {
+			// the plugin may not have a connection in the config - still limit tables prefixed with the name
+			log.Printf("[WARN] --%s: '%s' is not the name of a plugin or connection", constants.ArgPluginMaxParallel, name)
+			res.plugins[name] = struct{}{}
+		}
+	}
+	for _, schema := range searchPath {
+		if plugin, ok := connectionPlugins[schema]; ok {
+			if _, ok := res.defaultConnections[plugin]; !ok {
+				res.defaultConnections[plugin] = schema
+			}
+		}
+	}
+	return res
+}
+
+// concurrencyLimitsFromConfig builds the concurrency limits set using --plugin-max-parallel (or the
+// plugin_max_parallel workspace option) - nil is returned if there are no limits
+func concurrencyLimitsFromConfig(searchPath []string) (*concurrencyLimits, error) {
+	limits, err := parsePluginMaxParallel(viper.GetStringSlice(constants.ArgPluginMaxParallel))
+	if err != nil || len(limits) == 0 {
+		return nil, err
+	}
+
+	connectionPlugins := make(map[string]string)
+	if steampipeconfig.GlobalConfig != nil {
+		for name, connection := range steampipeconfig.GlobalConfig.Connections {
+			connectionPlugins[name] = connectionPluginName(connection)
+		}
+	}
+	return newConcurrencyLimits(limits, connectionPlugins, searchPath), nil
+}
+
+// parsePluginMaxParallel parses limits of the form '<plugin or connection>=<max parallel controls>'
+func parsePluginMaxParallel(args []string) (map[string]int64, error) {
+	res := make(map[string]int64)
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		name = strings.TrimSpace(name)
+		limit, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if !ok || name == "" || err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid --%s '%s': expected '<plugin or connection>=<max parallel controls>', with a limit of at least 1", constants.ArgPluginMaxParallel, arg)
+		}
+		res[strings.ToLower(name)] = limit
+	}
+	return res, nil
+}
+
+// connectionPluginName returns the name of the plugin used by a connection, e.g. 'aws' for
+// 'hub.steampipe.io/plugins/turbot/aws@latest'
+func connectionPluginName(connection *modconfig.Connection) string {
+	_, name, _ := ociinstaller.NewSteampipeImageRef(connection.Plugin).GetOrgNameAndStream()
+	return name
+}
+
+// groupsForSQL returns the limited plugins and connections used by the given SQL, sorted by name
+func (l *concurrencyLimits) groupsForSQL(sql string) []string {
+	groups := make(map[string]struct{})
+	addGroup := func(name string) {
+		if _, ok := l.locks[name]; ok {
+			groups[name] = struct{}{}
+		}
+	}
+
+	for _, identifier := range sqlIdentifierRegex.FindAllString(strings.ToLower(sql), -1) {
+		schema, table, qualified := strings.Cut(identifier, ".")
+		if qualified {
+			if plugin, ok := l.connectionPlugins[schema]; ok {
+				addGroup(schema)
+				addGroup(plugin)
+			}
+		} else {
+			table = schema
+		}
+		for plugin := range l.plugins {
+			if strings.HasPrefix(table, plugin+"_") {
+				addGroup(plugin)
+				if connection, ok := l.defaultConnections[plugin]; ok && !qualified {
+					addGroup(connection)
+				}
+			}
+		}
+	}
+
+	res := make([]string, 0, len(groups))
+	for name := range groups {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// acquire waits until a control may run for each of the given groups - the groups must be sorted,
+// so that controls acquire the locks of shared groups in the same order
+func (l *concurrencyLimits) acquire(ctx context.Context, groups []string) error {
+	for i, name := range groups {
+		if err := l.locks[name].Acquire(ctx, 1); err != nil {
+			l.release(groups[:i])
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *concurrencyLimits) release(groups []string) {
+	for _, name := range groups {
+		l.locks[name].Release(1)
+	}
+}
+
+// concurrencyGroups returns the limited plugins and connections used by the control
+func (r *ControlRun) concurrencyGroups() []string {
+	if r.Tree == nil || r.Tree.concurrencyLimits == nil {
+		return nil
+	}
+	sql := typehelpers.SafeString(r.Control.GetSQL())
+	if sql == "" && r.Control.GetQuery() != nil {
+		sql = typehelpers.SafeString(r.Control.GetQuery().GetSQL())
+	}
+	return r.Tree.concurrencyLimits.groupsForSQL(sql)
+}
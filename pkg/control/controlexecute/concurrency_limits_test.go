@@ -0,0 +1,97 @@
+package controlexecute
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePluginMaxParallel(t *testing.T) {
+	type parsePluginMaxParallelTest struct {
+		args          []string
+		expected      map[string]int64
+		expectedError bool
+	}
+	tests := map[string]parsePluginMaxParallelTest{
+		"none":           {nil, map[string]int64{}, false},
+		"plugins":        {[]string{"aws=3", " GCP = 2 "}, map[string]int64{"aws": 3, "gcp": 2}, false},
+		"connection":     {[]string{"aws_prod=1"}, map[string]int64{"aws_prod": 1}, false},
+		"no limit":       {[]string{"aws"}, nil, true},
+		"invalid limit":  {[]string{"aws=many"}, nil, true},
+		"zero limit":     {[]string{"aws=0"}, nil, true},
+		"missing plugin": {[]string{"=3"}, nil, true},
+	}
+	for name, test := range tests {
+		res, err := parsePluginMaxParallel(test.args)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %s", name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, res)
+		}
+	}
+}
+
+func TestConcurrencyLimitsGroupsForSQL(t *testing.T) {
+	connectionPlugins := map[string]string{
+		"aws_prod": "aws",
+		"aws_dev":  "aws",
+		"gcp":      "gcp",
+		"azure":    "azure",
+		"azuread":  "azuread",
+	}
+	limits := newConcurrencyLimits(map[string]int64{"aws": 3, "aws_dev": 1, "azure": 2, "github": 1}, connectionPlugins, []string{"public", "aws_dev", "aws_prod", "gcp"})
+
+	type groupsForSQLTest struct {
+		sql      string
+		expected []string
+	}
+	tests := map[string]groupsForSQLTest{
+		"unqualified table":            {"select arn as resource from aws_s3_bucket", []string{"aws", "aws_dev"}},
+		"qualified table":              {"select arn from aws_prod.aws_s3_bucket", []string{"aws"}},
+		"qualified limited connection": {"select arn from AWS_DEV.aws_s3_bucket", []string{"aws", "aws_dev"}},
+		"unlimited plugin":             {"select name from gcp_storage_bucket", []string{}},
+		"plugin name prefix":           {"select id from azuread_user", []string{}},
+		"multiple plugins":             {"select a.arn from aws_iam_role a join azure_ad_user u on true", []string{"aws", "aws_dev", "azure"}},
+		"plugin with no connection":    {"select name from github_repository", []string{"github"}},
+		"no tables":                    {"select 'ok' as status", []string{}},
+	}
+	for name, test := range tests {
+		res := limits.groupsForSQL(test.sql)
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, res)
+		}
+	}
+}
+
+func TestConcurrencyLimitsAcquire(t *testing.T) {
+	limits := newConcurrencyLimits(map[string]int64{"aws": 1, "gcp": 2}, map[string]string{"aws": "aws", "gcp": "gcp"}, nil)
+	groups := []string{"aws", "gcp"}
+	if err := limits.acquire(context.Background(), groups); err != nil {
+		t.Fatal(err)
+	}
+
+	// the aws limit is reached - a second acquire must wait, and must not retain the gcp lock when it fails
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := limits.acquire(ctx, groups); err == nil {
+		t.Fatalf("expected acquire to fail while the aws limit is reached")
+	}
+	if !limits.locks["gcp"].TryAcquire(1) {
+		t.Errorf("expected the gcp lock to be released when acquire fails")
+	}
+	limits.locks["gcp"].Release(1)
+
+	limits.release(groups)
+	if err := limits.acquire(context.Background(), groups); err != nil {
+		t.Errorf("expected acquire to succeed once the limits are released, got %s", err.Error())
+	}
+}
@@ -2,11 +2,13 @@ package controlexecute
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/spf13/viper"
 	typehelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc"
 	"github.com/turbot/steampipe/pkg/constants"
@@ -245,36 +247,95 @@ func (r *ControlRun) execute(ctx context.Context, client db_common.Client) {
 
 	controlExecutionCtx := r.getControlQueryContext(ctx)
 
-	// execute the control query
-	// NOTE no need to pass an OnComplete callback - we are already closing our session after waiting for results
-	log.Printf("[TRACE] execute start for, %s\n", control.Name())
-	queryResult, err := client.ExecuteInSession(controlExecutionCtx, dbSession, nil, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
-	log.Printf("[TRACE] execute finish for, %s\n", control.Name())
+	// the control timeout applies to all attempts of the control query
+	timeout := r.getTimeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		controlExecutionCtx, cancel = context.WithTimeoutCause(controlExecutionCtx, timeout, fmt.Errorf("control timed out after %s", timeout))
+		defer cancel()
+	}
 
-	if err != nil {
-		r.attempts++
+	defer func() {
+		// preserve the rows returned before any error (e.g. a timeout)
+		r.createdOrderedResultRows()
+		// convert the data to snapshot format
+		r.Data = r.Rows.ToLeafData(r.getDimensionSchema())
+	}()
 
-		// is this an rpc EOF error - meaning that the plugin somehow crashed
-		if grpc.IsGRPCConnectivityError(err) {
-			if r.attempts < constants.MaxControlRunAttempts {
-				log.Printf("[TRACE] control %s query failed with plugin connectivity error %s - retrying…", r.Control.Name(), err)
-				// recurse into this function to retry using the original context - which Execute will use to create it's own timeout context
-				r.execute(ctx, client)
-				return
-			} else {
-				log.Printf("[TRACE] control %s query failed again with plugin connectivity error %s - NOT retrying…", r.Control.Name(), err)
+	retries := control.GetRetries()
+	for {
+		err := r.executeQuery(controlExecutionCtx, client, dbSession, resolvedQuery)
+		if err == nil {
+			// the run may already have finished (e.g. if it was skipped while waiting for results)
+			if !r.Finished() {
+				r.setRunStatus(ctx, dashboardtypes.RunComplete)
 			}
+			return
 		}
-		r.setError(ctx, err)
-		return
+		// if the control timed out, report the timeout rather than the error of the query
+		if ctx.Err() == nil && errors.Is(controlExecutionCtx.Err(), context.DeadlineExceeded) {
+			err = context.Cause(controlExecutionCtx)
+		}
+
+		r.attempts++
+		if !r.shouldRetry(controlExecutionCtx, err, retries) {
+			r.setError(ctx, err)
+			return
+		}
+		log.Printf("[TRACE] control %s query failed with error %s - retrying (attempt %d)…", control.Name(), err, r.attempts+1)
+		// discard the results of the failed attempt
+		r.resetResults()
+	}
+}
+
+// executeQuery executes the control query and waits for the results
+func (r *ControlRun) executeQuery(ctx context.Context, client db_common.Client, dbSession *db_common.DatabaseSession, resolvedQuery *modconfig.ResolvedQuery) error {
+	// execute the control query
+	// NOTE no need to pass an OnComplete callback - we are already closing our session after waiting for results
+	log.Printf("[TRACE] execute start for, %s\n", r.Control.Name())
+	queryResult, err := client.ExecuteInSession(ctx, dbSession, nil, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
+	log.Printf("[TRACE] execute finish for, %s\n", r.Control.Name())
+	if err != nil {
+		return err
 	}
 
 	r.queryResult = queryResult
 
 	// now wait for control completion
-	log.Printf("[TRACE] wait result for, %s\n", control.Name())
-	r.waitForResults(ctx)
-	log.Printf("[TRACE] finish result for, %s\n", control.Name())
+	log.Printf("[TRACE] wait result for, %s\n", r.Control.Name())
+	defer log.Printf("[TRACE] finish result for, %s\n", r.Control.Name())
+	return r.waitForResults(ctx)
+}
+
+// shouldRetry returns whether a failed control query should be retried
+// queries which fail with a plugin connectivity error (i.e. the plugin crashed) are retried even if the control
+// has no retries - queries are never retried if the execution was cancelled or the control timed out
+func (r *ControlRun) shouldRetry(ctx context.Context, err error, retries int) bool {
+	if ctx.Err() != nil || error_helpers.IsCancelledError(err) {
+		return false
+	}
+	if grpc.IsGRPCConnectivityError(err) && r.attempts < constants.MaxControlRunAttempts {
+		return true
+	}
+	return r.attempts <= retries
+}
+
+// getTimeout returns the timeout of the control - this is the timeout set for the control (or the default control
+// timeout of its mod), falling back to the --control-timeout arg (zero if there is no timeout)
+func (r *ControlRun) getTimeout() time.Duration {
+	seconds := viper.GetInt(constants.ArgControlTimeout)
+	if timeout := r.Control.GetTimeout(); timeout != nil {
+		seconds = *timeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// resetResults clears the result rows and summary before the control query is retried
+func (r *ControlRun) resetResults() {
+	r.stateLock.Lock()
+	defer r.stateLock.Unlock()
+	r.rowMap = make(map[string]ResultRows)
+	r.Summary = &controlstatus.StatusSummary{}
 }
 
 // try to acquire a database session - retry up to 4 times if there is an error
@@ -308,42 +369,30 @@ func (r *ControlRun) resolveControlQuery(control *modconfig.Control) (*modconfig
 	return resolvedQuery, nil
 }
 
-func (r *ControlRun) waitForResults(ctx context.Context) {
-	defer func() {
-		dimensionsSchema := r.getDimensionSchema()
-		// convert the data to snapshot format
-		r.Data = r.Rows.ToLeafData(dimensionsSchema)
-	}()
-
+// waitForResults reads the rows of the control query, returning when the query completes or fails
+func (r *ControlRun) waitForResults(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
-			r.setError(ctx, ctx.Err())
-			return
+			return ctx.Err()
 		case row := <-*r.queryResult.RowChan:
 			// nil row means control run is complete
 			if row == nil {
-				// nil row means we are done
-				r.setRunStatus(ctx, dashboardtypes.RunComplete)
-				r.createdOrderedResultRows()
-				return
+				return nil
 			}
 			// if the row is in error then we terminate the run
 			if row.Error != nil {
-				// set error status (parent summary will be set from parent defer)
-				r.setError(ctx, row.Error)
-				return
+				return row.Error
 			}
 
 			// so all is ok - create another result row
 			result, err := NewResultRow(r, row, r.queryResult.Cols)
 			if err != nil {
-				r.setError(ctx, err)
-				return
+				return err
 			}
 			r.addResultRow(result)
 		case <-r.doneChan:
-			return
+			return nil
 		}
 	}
 }
@@ -371,6 +420,11 @@ func (r *ControlRun) getDimensionSchema() map[string]*queryresult.ColumnDef {
 
 // add the result row to our results and update the summary with the row status
 func (r *ControlRun) addResultRow(row *ResultRow) {
+	// failed results may be waived
+	if r.Tree != nil {
+		row.applyWaivers(r.Tree.Waivers)
+	}
+
 	// update results
 	r.rowMap[row.Status] = append(r.rowMap[row.Status], row)
 
@@ -0,0 +1,70 @@
+package controlexecute
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func TestControlRunShouldRetry(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type shouldRetryTest struct {
+		ctx      context.Context
+		err      error
+		attempts int
+		retries  int
+		expected bool
+	}
+	tests := map[string]shouldRetryTest{
+		"no retries":               {context.Background(), errors.New("throttled"), 1, 0, false},
+		"retry":                    {context.Background(), errors.New("throttled"), 1, 2, true},
+		"last retry":               {context.Background(), errors.New("throttled"), 2, 2, true},
+		"retries exhausted":        {context.Background(), errors.New("throttled"), 3, 2, false},
+		"cancelled":                {cancelledCtx, errors.New("throttled"), 1, 2, false},
+		"cancelled error":          {context.Background(), context.Canceled, 1, 2, false},
+		"plugin crashed":           {context.Background(), errors.New("rpc error: code = Unavailable desc = error reading from server: EOF"), 1, 0, true},
+		"plugin crashed exhausted": {context.Background(), errors.New("rpc error: code = Unavailable desc = error reading from server: EOF"), constants.MaxControlRunAttempts, 0, false},
+	}
+	for name, test := range tests {
+		r := &ControlRun{attempts: test.attempts}
+		if res := r.shouldRetry(test.ctx, test.err, test.retries); res != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, res)
+		}
+	}
+}
+
+func TestControlRunGetTimeout(t *testing.T) {
+	defer viper.Reset()
+
+	intPtr := func(i int) *int { return &i }
+	type getTimeoutTest struct {
+		controlTimeout *int
+		modTimeout     *int
+		argTimeout     int
+		expected       time.Duration
+	}
+	tests := map[string]getTimeoutTest{
+		"no timeout":         {nil, nil, 0, 0},
+		"arg":                {nil, nil, 60, time.Minute},
+		"mod default":        {nil, intPtr(30), 60, 30 * time.Second},
+		"control":            {intPtr(10), intPtr(30), 60, 10 * time.Second},
+		"control no timeout": {intPtr(0), intPtr(30), 60, 0},
+	}
+	for name, test := range tests {
+		viper.Set(constants.ArgControlTimeout, test.argTimeout)
+		control := &modconfig.Control{Timeout: test.controlTimeout}
+		control.Mod = &modconfig.Mod{ControlTimeout: test.modTimeout}
+
+		r := &ControlRun{Control: control}
+		if res := r.getTimeout(); res != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, res)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/spf13/viper"
@@ -35,9 +36,15 @@ type ExecutionTree struct {
 	// the current session search path
 	SearchPath []string             `json:"-"`
 	Workspace  *workspace.Workspace `json:"-"`
-	client     db_common.Client
+	// waivers suppressing failed control results
+	Waivers []*modconfig.Waiver `json:"-"`
+	client  db_common.Client
 	// an optional map of control names used to filter the controls which are run
 	controlNameFilterMap map[string]bool
+	// optional limits on the number of controls run in parallel for each plugin or connection
+	concurrencyLimits *concurrencyLimits
+	// the control runs waiting for their plugin or connection concurrency limits
+	pendingRuns sync.WaitGroup
 }
 
 func NewExecutionTree(ctx context.Context, workspace *workspace.Workspace, client db_common.Client, controlFilterWhereClause string, args ...string) (*ExecutionTree, error) {
@@ -140,6 +147,13 @@ func (e *ExecutionTree) Execute(ctx context.Context) error {
 	// to limit the number of parallel controls go routines started
 	parallelismLock := semaphore.NewWeighted(maxParallelGoRoutines)
 
+	// controls may also be limited by the plugins and connections they use
+	concurrencyLimits, err := concurrencyLimitsFromConfig(e.SearchPath)
+	if err != nil {
+		return err
+	}
+	e.concurrencyLimits = concurrencyLimits
+
 	// just execute the root - it will traverse the tree
 	e.Root.execute(ctx, e.client, parallelismLock)
 
@@ -164,6 +178,18 @@ func (e *ExecutionTree) waitForActiveRunsToComplete(ctx context.Context, paralle
 		waitCtx = c
 		defer cancel()
 	}
+	// wait for the runs which are waiting for their plugin or connection concurrency limits to start
+	pendingDone := make(chan struct{})
+	go func() {
+		e.pendingRuns.Wait()
+		close(pendingDone)
+	}()
+	select {
+	case <-pendingDone:
+	case <-waitCtx.Done():
+		return waitCtx.Err()
+	}
+
 	// wait till we can acquire all semaphores - meaning that all active runs have finished
 	return parallelismLock.Acquire(waitCtx, maxParallelGoRoutines)
 }
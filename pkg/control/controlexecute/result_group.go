@@ -313,6 +313,14 @@ func (r *ResultGroup) execute(ctx context.Context, client db_common.Client, para
 			continue
 		}
 
+		// controls limited by the plugins or connections they use wait for these limits in their own goroutine,
+		// so they do not prevent other controls from starting
+		if groups := controlRun.concurrencyGroups(); len(groups) > 0 {
+			controlRun.Tree.pendingRuns.Add(1)
+			go executeLimitedRun(ctx, controlRun, groups, parallelismLock, client)
+			continue
+		}
+
 		err := parallelismLock.Acquire(ctx, 1)
 		if err != nil {
 			controlRun.setError(ctx, err)
@@ -338,3 +346,24 @@ func executeRun(ctx context.Context, run *ControlRun, parallelismLock *semaphore
 
 	run.execute(ctx, client)
 }
+
+// executeLimitedRun executes a control once its plugin and connection concurrency limits, and the parallelism lock,
+// have been acquired
+func executeLimitedRun(ctx context.Context, run *ControlRun, groups []string, parallelismLock *semaphore.Weighted, client db_common.Client) {
+	limits := run.Tree.concurrencyLimits
+	if err := limits.acquire(ctx, groups); err != nil {
+		run.setError(ctx, err)
+		run.Tree.pendingRuns.Done()
+		return
+	}
+	defer limits.release(groups)
+
+	err := parallelismLock.Acquire(ctx, 1)
+	// the run has started (or failed to) - it is no longer pending
+	run.Tree.pendingRuns.Done()
+	if err != nil {
+		run.setError(ctx, err)
+		return
+	}
+	executeRun(ctx, run, parallelismLock, client)
+}
@@ -2,6 +2,7 @@ package controlexecute
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/turbot/go-kit/helpers"
 	typehelpers "github.com/turbot/go-kit/types"
@@ -55,6 +56,37 @@ type ResultRow struct {
 	Run *ControlRun `json:"-"`
 	// source control
 	Control *modconfig.Control `json:"-" csv:"control_id:UnqualifiedName,control_title:Title,control_description:Description"`
+	// the waiver applied to the row (if any)
+	Waiver *ResultWaiver `json:"waiver,omitempty"`
+}
+
+// ResultWaiver describes the waiver applied to a result - the result status is skip,
+// and the status it would otherwise have had is retained
+type ResultWaiver struct {
+	Name           string     `json:"name"`
+	Reason         string     `json:"reason"`
+	Expires        *time.Time `json:"expires,omitempty"`
+	OriginalStatus string     `json:"original_status"`
+}
+
+// applyWaivers applies the first matching waiver to the row - only alarm and error results may be waived
+func (r *ResultRow) applyWaivers(waivers []*modconfig.Waiver) {
+	if r.Status != constants.ControlAlarm && r.Status != constants.ControlError {
+		return
+	}
+	for _, w := range waivers {
+		if !w.Matches(r.Control, r.Resource) {
+			continue
+		}
+		r.Waiver = &ResultWaiver{
+			Name:           w.Name,
+			Reason:         w.Reason,
+			Expires:        w.Expires,
+			OriginalStatus: r.Status,
+		}
+		r.Status = constants.ControlSkip
+		return
+	}
 }
 
 // GetDimensionValue returns the value for a dimension key. Returns an empty string with 'false' if not found
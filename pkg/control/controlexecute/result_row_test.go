@@ -0,0 +1,51 @@
+package controlexecute
+
+import (
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func TestResultRowApplyWaivers(t *testing.T) {
+	control := &modconfig.Control{}
+	control.FullName = "m.control.c1"
+	control.UnqualifiedName = "control.c1"
+
+	b1 := "arn:aws:s3:::b1"
+	waivers := []*modconfig.Waiver{
+		{Name: "other_control", Control: "m.control.c2", Reason: "other"},
+		{Name: "b1", Control: "m.control.c1", Resource: &b1, Reason: "b1 is public"},
+		{Name: "all", Control: "control.c1", Reason: "all resources"},
+	}
+
+	type applyWaiversTest struct {
+		status           string
+		resource         string
+		expectedStatus   string
+		expectedWaiver   string
+		expectedOriginal string
+	}
+	tests := map[string]applyWaiversTest{
+		"resource waiver": {constants.ControlAlarm, b1, constants.ControlSkip, "b1", constants.ControlAlarm},
+		"control waiver":  {constants.ControlError, "arn:aws:s3:::b2", constants.ControlSkip, "all", constants.ControlError},
+		"ok not waived":   {constants.ControlOk, b1, constants.ControlOk, "", ""},
+		"info not waived": {constants.ControlInfo, b1, constants.ControlInfo, "", ""},
+	}
+	for name, test := range tests {
+		row := &ResultRow{Control: control, Status: test.status, Resource: test.resource}
+		row.applyWaivers(waivers)
+		if row.Status != test.expectedStatus {
+			t.Errorf("Test: '%s' FAILED : expected status %s, got %s", name, test.expectedStatus, row.Status)
+		}
+		if test.expectedWaiver == "" {
+			if row.Waiver != nil {
+				t.Errorf("Test: '%s' FAILED : expected no waiver, got %s", name, row.Waiver.Name)
+			}
+			continue
+		}
+		if row.Waiver == nil || row.Waiver.Name != test.expectedWaiver || row.Waiver.OriginalStatus != test.expectedOriginal {
+			t.Errorf("Test: '%s' FAILED : expected waiver %s with original status %s, got %+v", name, test.expectedWaiver, test.expectedOriginal, row.Waiver)
+		}
+	}
+}
@@ -5,13 +5,17 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/control/controldisplay"
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/initialisation"
 	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/parse"
 	"github.com/turbot/steampipe/pkg/workspace"
 )
 
@@ -19,6 +23,7 @@ type InitData struct {
 	initialisation.InitData
 	OutputFormatter          controldisplay.Formatter
 	ControlFilterWhereClause string
+	Waivers                  []*modconfig.Waiver
 }
 
 // NewInitData returns a new InitData object
@@ -45,8 +50,8 @@ func NewInitData(ctx context.Context) *InitData {
 		i.Result.Error = workspace.ErrorNoModDefinition
 	}
 
-	if viper.GetString(constants.ArgOutput) == constants.OutputFormatNone {
-		// set progress to false
+	if output := viper.GetString(constants.ArgOutput); output == constants.OutputFormatNone || output == constants.OutputFormatNdjsonStream {
+		// set progress to false - the progress display would be interleaved with the streamed results
 		viper.Set(constants.ArgProgress, false)
 	}
 	// set color schema
@@ -81,9 +86,18 @@ func NewInitData(ctx context.Context) *InitData {
 		return i
 	}
 	i.OutputFormatter = formatter
+	// when comparing with a previous snapshot, the text output displays only the changed results
+	if viper.GetString(constants.ArgDiff) != "" && (output == constants.OutputFormatText || output == constants.OutputFormatBrief) {
+		i.OutputFormatter = &controldisplay.DiffTextFormatter{}
+	}
 
 	i.setControlFilterClause()
 
+	if err := i.loadWaivers(); err != nil {
+		i.Result.Error = err
+		return i
+	}
+
 	// initialize
 	i.InitData.Init(ctx, constants.InvokerCheck)
 
@@ -108,6 +122,28 @@ func (i *InitData) setControlFilterClause() {
 	}
 }
 
+// loadWaivers loads the waivers declared in the files passed using --waiver-file
+// expired waivers are not applied - a warning is shown for each
+func (i *InitData) loadWaivers() error {
+	waiverFiles := viper.GetStringSlice(constants.ArgWaiverFile)
+	if len(waiverFiles) == 0 {
+		return nil
+	}
+	waivers, diags := parse.ParseWaiverFiles(waiverFiles...)
+	if diags.HasErrors() {
+		return plugin.DiagsToError("failed to load waivers", diags)
+	}
+	now := time.Now()
+	for _, w := range waivers {
+		if w.Expired(now) {
+			i.Result.AddWarnings(fmt.Sprintf("waiver '%s' expired on %s and will not be applied", w.Name, w.Expires.Format(time.DateOnly)))
+			continue
+		}
+		i.Waivers = append(i.Waivers, w)
+	}
+	return nil
+}
+
 func generateWhereClauseFromTags(tags []string) string {
 	whereMap := map[string][]string{}
 
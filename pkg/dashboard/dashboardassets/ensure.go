@@ -3,6 +3,7 @@ package dashboardassets
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 
@@ -14,30 +15,64 @@ import (
 	"github.com/turbot/steampipe/pkg/version"
 )
 
+// installAssets downloads and installs the dashboard assets into the given directory
+// (this is a variable to allow it to be replaced in tests)
+var installAssets = ociinstaller.InstallAssets
+
+// Ensure installs the dashboard assets, unless the assets for this version are already installed and intact
+// the installed assets are verified against the manifest written after they were installed, so assets which
+// were partially installed (e.g. due to a crash) or have since been modified are reinstalled
 func Ensure(ctx context.Context) error {
 	logging.LogTime("dashboardassets.Ensure start")
 	defer logging.LogTime("dashboardassets.Ensure end")
 
-	// load report assets versions.json
-	versionFile, err := loadReportAssetVersionFile()
-	if err != nil {
-		return err
-	}
+	reportAssetsPath := filepaths.EnsureDashboardAssetsDir()
+	manifestPath := filepaths.DashboardAssetsManifestFilePath()
 
-	if versionFile.Version == version.VersionString {
+	if err := verifyInstalledAssets(reportAssetsPath, manifestPath); err == nil {
 		return nil
+	} else {
+		log.Printf("[INFO] installing dashboard assets: %s", err.Error())
 	}
 
 	statushooks.SetStatus(ctx, "Installing dashboard server…")
 
-	reportAssetsPath := filepaths.EnsureDashboardAssetsDir()
-
 	// remove the legacy report folder, if it exists
 	if _, err := os.Stat(filepaths.LegacyDashboardAssetsDir()); !os.IsNotExist(err) {
 		os.RemoveAll(filepaths.LegacyDashboardAssetsDir())
 	}
 
-	return ociinstaller.InstallAssets(ctx, reportAssetsPath)
+	// remove the manifest before installing - if the installation does not complete, the assets will be
+	// reinstalled next time
+	if err := os.Remove(manifestPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := installAssets(ctx, reportAssetsPath); err != nil {
+		return err
+	}
+
+	manifest, err := buildAssetsManifest(reportAssetsPath, version.VersionString)
+	if err != nil {
+		return err
+	}
+	return manifest.save(manifestPath)
+}
+
+// verifyInstalledAssets returns an error if the assets for this version are not installed, or do not match
+// the manifest written when they were installed
+func verifyInstalledAssets(reportAssetsPath, manifestPath string) error {
+	versionFile, err := loadReportAssetVersionFile()
+	if err != nil {
+		return err
+	}
+	if versionFile.Version != version.VersionString {
+		return fmt.Errorf("installed assets version '%s' does not match %s", versionFile.Version, version.VersionString)
+	}
+	manifest, err := loadAssetsManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to load assets manifest: %s", err.Error())
+	}
+	return manifest.verify(reportAssetsPath, version.VersionString)
 }
 
 type ReportAssetsVersionFile struct {
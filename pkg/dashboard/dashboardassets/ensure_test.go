@@ -0,0 +1,123 @@
+package dashboardassets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/filepaths"
+	"github.com/turbot/steampipe/pkg/version"
+)
+
+// stubInstallAssets replaces installAssets with a function which writes a fixed set of asset files
+// and a version file for the given version, returning a pointer to the number of installs
+func stubInstallAssets(t *testing.T, assetsVersion string) *int {
+	installs := 0
+	original := installAssets
+	installAssets = func(_ context.Context, assetsLocation string) error {
+		installs++
+		files := map[string]string{
+			"index.html":        "<html></html>",
+			"static/js/main.js": "console.log('dashboard')",
+			filepath.Base(filepaths.ReportAssetsVersionFilePath()): fmt.Sprintf(`{"version":"%s"}`, assetsVersion),
+		}
+		for name, content := range files {
+			path := filepath.Join(assetsLocation, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	t.Cleanup(func() { installAssets = original })
+	return &installs
+}
+
+func setTestSteampipeDir(t *testing.T) {
+	original := filepaths.SteampipeDir
+	filepaths.SteampipeDir = t.TempDir()
+	t.Cleanup(func() { filepaths.SteampipeDir = original })
+}
+
+func TestEnsureReinstallsCorruptedAssets(t *testing.T) {
+	setTestSteampipeDir(t)
+	installs := stubInstallAssets(t, version.VersionString)
+	ctx := context.Background()
+
+	if err := Ensure(ctx); err != nil {
+		t.Fatalf("Ensure failed: %s", err.Error())
+	}
+	if *installs != 1 {
+		t.Fatalf("expected assets to be installed once, got %d installs", *installs)
+	}
+
+	// the assets are intact - they should not be reinstalled
+	if err := Ensure(ctx); err != nil {
+		t.Fatalf("Ensure failed: %s", err.Error())
+	}
+	if *installs != 1 {
+		t.Fatalf("expected intact assets not to be reinstalled, got %d installs", *installs)
+	}
+
+	// corrupt an asset file
+	assetPath := filepath.Join(filepaths.EnsureDashboardAssetsDir(), "static", "js", "main.js")
+	if err := os.WriteFile(assetPath, []byte("corrupted"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Ensure(ctx); err != nil {
+		t.Fatalf("Ensure failed: %s", err.Error())
+	}
+	if *installs != 2 {
+		t.Fatalf("expected corrupted assets to be reinstalled, got %d installs", *installs)
+	}
+	content, err := os.ReadFile(assetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "console.log('dashboard')" {
+		t.Fatalf("expected corrupted asset to be restored, got '%s'", string(content))
+	}
+
+	// delete an asset file
+	if err := os.Remove(filepath.Join(filepaths.EnsureDashboardAssetsDir(), "index.html")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Ensure(ctx); err != nil {
+		t.Fatalf("Ensure failed: %s", err.Error())
+	}
+	if *installs != 3 {
+		t.Fatalf("expected missing assets to be reinstalled, got %d installs", *installs)
+	}
+}
+
+func TestEnsureReinstallsAssetsForNewVersion(t *testing.T) {
+	setTestSteampipeDir(t)
+	ctx := context.Background()
+
+	// install assets and a manifest for a previous version
+	previous := stubInstallAssets(t, "0.0.1")
+	if err := installAssets(ctx, filepaths.EnsureDashboardAssetsDir()); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := buildAssetsManifest(filepaths.EnsureDashboardAssetsDir(), "0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.save(filepaths.DashboardAssetsManifestFilePath()); err != nil {
+		t.Fatal(err)
+	}
+	*previous = 0
+
+	installs := stubInstallAssets(t, version.VersionString)
+	if err := Ensure(ctx); err != nil {
+		t.Fatalf("Ensure failed: %s", err.Error())
+	}
+	if *installs != 1 {
+		t.Fatalf("expected assets to be reinstalled after a version upgrade, got %d installs", *installs)
+	}
+}
@@ -0,0 +1,102 @@
+package dashboardassets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// assetsManifest records the Steampipe version and per-file checksums of the installed dashboard assets
+// it is written once the assets have been installed, so a missing manifest indicates an incomplete installation
+type assetsManifest struct {
+	Version string `json:"version"`
+	// map of file path (relative to the assets directory, using forward slashes) to sha256 checksum
+	Files map[string]string `json:"files"`
+}
+
+// buildAssetsManifest builds a manifest of all files in the assets directory
+func buildAssetsManifest(assetsPath, version string) (*assetsManifest, error) {
+	manifest := &assetsManifest{
+		Version: version,
+		Files:   make(map[string]string),
+	}
+	err := filepath.WalkDir(assetsPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(assetsPath, path)
+		if err != nil {
+			return err
+		}
+		manifest.Files[filepath.ToSlash(relPath)] = checksum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// verify checks the manifest was written for the given version, and that every file it lists
+// exists in the assets directory with the recorded checksum
+func (m *assetsManifest) verify(assetsPath, version string) error {
+	if m.Version != version {
+		return fmt.Errorf("assets manifest version %s does not match %s", m.Version, version)
+	}
+	if len(m.Files) == 0 {
+		return fmt.Errorf("assets manifest contains no files")
+	}
+	for relPath, expected := range m.Files {
+		checksum, err := fileChecksum(filepath.Join(assetsPath, filepath.FromSlash(relPath)))
+		if err != nil {
+			return fmt.Errorf("failed to read asset %s: %s", relPath, err.Error())
+		}
+		if checksum != expected {
+			return fmt.Errorf("asset %s checksum does not match manifest", relPath)
+		}
+	}
+	return nil
+}
+
+func loadAssetsManifest(manifestPath string) (*assetsManifest, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var manifest assetsManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func (m *assetsManifest) save(manifestPath string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, data, 0600)
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
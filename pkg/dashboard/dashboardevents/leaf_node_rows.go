@@ -0,0 +1,25 @@
+package dashboardevents
+
+import (
+	"time"
+
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+)
+
+// LeafNodeRows is raised as the query of a leaf node returns rows - each event contains the next batch of rows
+// the final event for a query has Complete set (this may contain no rows)
+// NOTE: the LeafNodeUpdated event raised when the leaf node completes still contains the full result
+// (this is used to build snapshots) - the dashboard server strips the rows before sending it to a client which
+// has been streamed them
+type LeafNodeRows struct {
+	Name        string
+	Columns     []*queryresult.ColumnDef
+	Rows        []map[string]any
+	Complete    bool
+	Session     string
+	ExecutionId string
+	Timestamp   time.Time
+}
+
+// IsDashboardEvent implements DashboardEvent interface
+func (*LeafNodeRows) IsDashboardEvent() {}
@@ -242,6 +242,14 @@ func (e *DashboardExecutionTree) SetInputValues(inputValues map[string]any) {
 	}
 }
 
+// GetInputValues returns a copy of the input values which have been set for the execution
+func (e *DashboardExecutionTree) GetInputValues() map[string]any {
+	e.inputLock.Lock()
+	defer e.inputLock.Unlock()
+
+	return maps.Clone(e.inputValues)
+}
+
 // ChildCompleteChan implements DashboardParent
 func (e *DashboardExecutionTree) ChildCompleteChan() chan dashboardtypes.DashboardTreeRun {
 	return e.runComplete
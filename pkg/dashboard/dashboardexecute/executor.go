@@ -38,6 +38,17 @@ func newDashboardExecutor() *DashboardExecutor {
 var Executor = newDashboardExecutor()
 
 func (e *DashboardExecutor) ExecuteDashboard(ctx context.Context, sessionId, dashboardName string, inputs map[string]any, workspace *workspace.Workspace, client db_common.Client) (err error) {
+	return e.executeDashboard(ctx, sessionId, dashboardName, inputs, workspace, client, e.interactive)
+}
+
+// ExecuteDashboardBatch executes a dashboard non-interactively, regardless of the mode of the executor
+// (i.e. all inputs the dashboard depends on must be provided)
+// this allows a dashboard server to execute dashboards for API clients alongside its interactive sessions
+func (e *DashboardExecutor) ExecuteDashboardBatch(ctx context.Context, sessionId, dashboardName string, inputs map[string]any, workspace *workspace.Workspace, client db_common.Client) error {
+	return e.executeDashboard(ctx, sessionId, dashboardName, inputs, workspace, client, false)
+}
+
+func (e *DashboardExecutor) executeDashboard(ctx context.Context, sessionId, dashboardName string, inputs map[string]any, workspace *workspace.Workspace, client db_common.Client, interactive bool) (err error) {
 	var executionTree *DashboardExecutionTree
 	defer func() {
 		if err != nil && ctx.Err() != nil {
@@ -65,8 +76,10 @@ func (e *DashboardExecutor) ExecuteDashboard(ctx context.Context, sessionId, das
 
 	// if inputs must be provided before execution (i.e. this is a batch dashboard execution),
 	// verify all required inputs are provided
-	if err = e.validateInputs(executionTree, inputs); err != nil {
-		return err
+	if !interactive {
+		if err = e.validateInputs(executionTree, inputs); err != nil {
+			return err
+		}
 	}
 
 	// add to execution map
@@ -85,10 +98,6 @@ func (e *DashboardExecutor) ExecuteDashboard(ctx context.Context, sessionId, das
 // if inputs must be provided before execution (i.e. this is a batch dashboard execution),
 // verify all required inputs are provided
 func (e *DashboardExecutor) validateInputs(executionTree *DashboardExecutionTree, inputs map[string]any) error {
-	if e.interactive {
-		// interactive dashboard execution - no need to validate
-		return nil
-	}
 	var missingInputs []string
 	for _, inputName := range executionTree.InputRuntimeDependencies() {
 		if _, ok := inputs[inputName]; !ok {
@@ -168,6 +177,16 @@ func (e *DashboardExecutor) OnInputChanged(ctx context.Context, sessionId string
 	return nil
 }
 
+// GetInputValues returns the name of the dashboard being executed for a session and the input values it is
+// executing with - this includes any dependent inputs which have been cleared since they were set by the client
+func (e *DashboardExecutor) GetInputValues(sessionId string) (string, map[string]any, bool) {
+	executionTree, found := e.getExecution(sessionId)
+	if !found {
+		return "", nil, false
+	}
+	return executionTree.dashboardName, executionTree.GetInputValues(), true
+}
+
 func (e *DashboardExecutor) clearDependentInputs(root dashboardtypes.DashboardTreeRun, changedInput string, inputs map[string]any) []string {
 	dependentInputs := root.GetInputsDependingOn(changedInput)
 	clearedInputs := dependentInputs
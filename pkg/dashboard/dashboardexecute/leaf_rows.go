@@ -0,0 +1,67 @@
+package dashboardexecute
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+)
+
+// leafRowsPublisher is called with each batch of rows read from a leaf node query
+// it is called a final time with complete set once all rows have been read
+type leafRowsPublisher func(rows []map[string]any, complete bool)
+
+// readLeafRows reads all rows of a query result into leaf data, publishing the rows in batches of (at most)
+// batchSize rows as they are read - this allows clients to render the result before the query completes
+//
+// the result is always read to completion, as the database connection is not released until it is
+// if a row contains an error, the first error is returned and the completion is not published
+func readLeafRows(ctx context.Context, result *queryresult.Result, batchSize int, publish leafRowsPublisher) (*dashboardtypes.LeafData, error) {
+	data := &dashboardtypes.LeafData{
+		Columns: result.Cols,
+		Rows:    []map[string]any{},
+	}
+	var err error
+	batch := make([]map[string]any, 0, batchSize)
+
+	for row := range *result.RowChan {
+		// once the context is cancelled, drain the remaining rows
+		if ctx.Err() != nil {
+			continue
+		}
+		if row.Error != nil {
+			if err == nil {
+				err = error_helpers.WrapError(row.Error)
+			}
+			continue
+		}
+		rowData := dashboardtypes.NewLeafDataRow(result.Cols, row.Data)
+		data.Rows = append(data.Rows, rowData)
+		batch = append(batch, rowData)
+		if len(batch) >= batchSize {
+			publish(batch, false)
+			batch = make([]map[string]any, 0, batchSize)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	// publish the final batch along with the completion
+	publish(batch, true)
+	return data, nil
+}
+
+// readTimingResult returns the timing result of a query, if there is one
+// (this must only be called once all rows have been read - the timing result is sent before the rows are closed)
+func readTimingResult(result *queryresult.Result) *queryresult.TimingResult {
+	select {
+	case timingResult := <-result.TimingResult:
+		return timingResult
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+package dashboardexecute
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+)
+
+type publishedBatch struct {
+	rows     int
+	complete bool
+}
+
+// streamTestResult returns a result which streams the given number of rows, followed by an error if one is given
+func streamTestResult(rowCount int, rowErr error) *queryresult.Result {
+	result := queryresult.NewResult([]*queryresult.ColumnDef{{Name: "id", DataType: "INT8"}})
+	go func() {
+		for i := 0; i < rowCount; i++ {
+			result.StreamRow([]interface{}{i})
+		}
+		if rowErr != nil {
+			result.StreamError(rowErr)
+		}
+		result.TimingResult <- &queryresult.TimingResult{}
+		result.Close()
+	}()
+	return result
+}
+
+func TestReadLeafRows(t *testing.T) {
+	testCases := map[string]struct {
+		rowCount        int
+		rowErr          error
+		expectedBatches []publishedBatch
+		expectError     bool
+	}{
+		"multiple batches": {
+			rowCount:        250,
+			expectedBatches: []publishedBatch{{100, false}, {100, false}, {50, true}},
+		},
+		"exact multiple of batch size": {
+			rowCount:        200,
+			expectedBatches: []publishedBatch{{100, false}, {100, false}, {0, true}},
+		},
+		"no rows": {
+			rowCount:        0,
+			expectedBatches: []publishedBatch{{0, true}},
+		},
+		"row error": {
+			rowCount:        150,
+			rowErr:          errors.New("query failed"),
+			expectedBatches: []publishedBatch{{100, false}},
+			expectError:     true,
+		},
+	}
+
+	for name, test := range testCases {
+		var batches []publishedBatch
+		publish := func(rows []map[string]any, complete bool) {
+			batches = append(batches, publishedBatch{len(rows), complete})
+		}
+		result := streamTestResult(test.rowCount, test.rowErr)
+		data, err := readLeafRows(context.Background(), result, 100, publish)
+
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+		} else if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err.Error())
+			continue
+		} else {
+			if len(data.Rows) != test.rowCount {
+				t.Errorf("Test: '%s' FAILED : expected %d rows, got %d", name, test.rowCount, len(data.Rows))
+			}
+			if readTimingResult(result) == nil {
+				t.Errorf("Test: '%s' FAILED : expected a timing result", name)
+			}
+		}
+		if len(batches) != len(test.expectedBatches) {
+			t.Errorf("Test: '%s' FAILED : expected batches %v, got %v", name, test.expectedBatches, batches)
+			continue
+		}
+		for i, expected := range test.expectedBatches {
+			if batches[i] != expected {
+				t.Errorf("Test: '%s' FAILED : expected batches %v, got %v", name, test.expectedBatches, batches)
+				break
+			}
+		}
+	}
+}
+
+func TestReadLeafRowsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	published := 0
+	result := streamTestResult(250, nil)
+	// all rows must be read, even though the context is cancelled - otherwise the stream would block
+	if _, err := readLeafRows(ctx, result, 100, func([]map[string]any, bool) { published++ }); err == nil {
+		t.Errorf("expected error for cancelled context")
+	}
+	if published != 0 {
+		t.Errorf("expected no rows to be published for a cancelled context, got %d batches", published)
+	}
+}
@@ -2,6 +2,8 @@ package dashboardexecute
 
 import (
 	"context"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
 	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/query/queryresult"
@@ -9,6 +11,7 @@ import (
 	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
 	"golang.org/x/exp/maps"
 	"log"
+	"time"
 )
 
 // LeafRun is a struct representing the execution of a leaf dashboard node
@@ -175,22 +178,54 @@ func (r *LeafRun) SetComplete(ctx context.Context) {
 func (*LeafRun) IsSnapshotPanel() {}
 
 // if this leaf run has a query or sql, execute it now
+// the rows are streamed to the client in batches as the query returns them
 func (r *LeafRun) executeQuery(ctx context.Context) error {
 	log.Printf("[TRACE] LeafRun '%s' SQL resolved, executing", r.resource.Name())
 
-	queryResult, err := r.executionTree.client.ExecuteSync(ctx, r.executeSQL, r.Args...)
+	if r.executeSQL == "" {
+		r.Data = dashboardtypes.NewLeafData(&queryresult.SyncQueryResult{})
+		return nil
+	}
+
+	result, err := r.executionTree.client.Execute(ctx, r.executeSQL, r.Args...)
 	if err != nil {
 		log.Printf("[TRACE] LeafRun '%s' query failed: %s", r.resource.Name(), err.Error())
-		return err
+		return error_helpers.WrapError(err)
+	}
 
+	data, err := readLeafRows(ctx, result, constants.DashboardRowBatchSize, r.publishRows(ctx, result.Cols))
+	if err != nil {
+		log.Printf("[TRACE] LeafRun '%s' query failed: %s", r.resource.Name(), err.Error())
+		return err
 	}
 	log.Printf("[TRACE] LeafRun '%s' complete", r.resource.Name())
 
-	r.Data = dashboardtypes.NewLeafData(queryResult)
-	r.TimingResult = queryResult.TimingResult
+	r.Data = data
+	r.TimingResult = readTimingResult(result)
 	return nil
 }
 
+// publishRows returns a function which raises a LeafNodeRows event for each batch of rows read by the query
+// rows are only published to dashboard clients - if there is no client session (i.e. when generating a snapshot),
+// or this is a 'with' run (which is not displayed), the rows are not published
+func (r *LeafRun) publishRows(ctx context.Context, cols []*queryresult.ColumnDef) leafRowsPublisher {
+	sessionId := r.executionTree.sessionId
+	if sessionId == "" || r.resource.BlockType() == modconfig.BlockTypeWith {
+		return func([]map[string]any, bool) {}
+	}
+	return func(rows []map[string]any, complete bool) {
+		r.executionTree.workspace.PublishDashboardEvent(ctx, &dashboardevents.LeafNodeRows{
+			Name:        r.Name,
+			Columns:     cols,
+			Rows:        rows,
+			Complete:    complete,
+			Session:     sessionId,
+			ExecutionId: r.executionTree.id,
+			Timestamp:   time.Now(),
+		})
+	}
+}
+
 func (r *LeafRun) combineChildData() {
 	// we either have children OR a query
 	// if there are no children, do nothing
@@ -73,3 +73,12 @@ func ExecutionCompleteToSnapshot(event *dashboardevents.ExecutionComplete) *dash
 		Title:         event.Root.GetTitle(),
 	}
 }
+
+// GenerateDashboardData executes the target dashboard once and returns the metadata and result rows of each panel
+func GenerateDashboardData(ctx context.Context, target string, initData *initialisation.InitData, inputs map[string]any) (*dashboardtypes.DashboardData, error) {
+	snapshot, err := GenerateSnapshot(ctx, target, initData, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return dashboardtypes.NewDashboardData(snapshot)
+}
@@ -0,0 +1,109 @@
+package dashboardexecute
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+// newTestLeafRun returns a completed leaf run with the given data
+func newTestLeafRun(name, nodeType string, status dashboardtypes.RunStatus, data *dashboardtypes.LeafData) *LeafRun {
+	r := &LeafRun{Data: data}
+	r.Name = name
+	r.NodeType = nodeType
+	r.Title = name + " title"
+	r.Status = status
+	return r
+}
+
+func TestDashboardDataJSON(t *testing.T) {
+	cols := []*queryresult.ColumnDef{{Name: "id", DataType: "INT8"}, {Name: "name", DataType: "TEXT"}}
+	table := newTestLeafRun("mod.table.t", modconfig.BlockTypeTable, dashboardtypes.RunComplete, &dashboardtypes.LeafData{
+		Columns: cols,
+		Rows:    []map[string]any{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}},
+	})
+	failed := newTestLeafRun("mod.chart.c", modconfig.BlockTypeChart, dashboardtypes.RunError, nil)
+	failed.ErrorString = "relation does not exist"
+
+	dashboard := &DashboardRun{}
+	dashboard.Name = "mod.dashboard.d"
+	dashboard.NodeType = modconfig.BlockTypeDashboard
+	dashboard.Status = dashboardtypes.RunError
+	dashboard.ErrorString = failed.ErrorString
+	dashboard.children = []dashboardtypes.DashboardTreeRun{table, failed}
+
+	snapshot := &dashboardtypes.SteampipeSnapshot{
+		Panels: map[string]dashboardtypes.SnapshotPanel{
+			dashboard.Name: dashboard,
+			table.Name:     table,
+			failed.Name:    failed,
+		},
+		Layout:    dashboard.AsTreeNode(),
+		Inputs:    map[string]any{"input.i": "value"},
+		Variables: map[string]string{"region": "us-east-1"},
+	}
+
+	data, err := dashboardtypes.NewDashboardData(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual map[string]any
+	if err := json.Unmarshal(jsonBytes, &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual["dashboard"] != "mod.dashboard.d" {
+		t.Errorf("expected dashboard 'mod.dashboard.d', got %v", actual["dashboard"])
+	}
+	if !reflect.DeepEqual(actual["inputs"], map[string]any{"input.i": "value"}) {
+		t.Errorf("expected inputs to be included, got %v", actual["inputs"])
+	}
+	if !reflect.DeepEqual(actual["variables"], map[string]any{"region": "us-east-1"}) {
+		t.Errorf("expected variables to be included, got %v", actual["variables"])
+	}
+
+	// panels are in layout order, with their parent
+	panels, _ := actual["panels"].([]any)
+	if len(panels) != 3 {
+		t.Fatalf("expected 3 panels, got %d", len(panels))
+	}
+	expectedPanels := []map[string]any{
+		{"name": "mod.dashboard.d", "panel_type": "dashboard", "status": "error", "error": "relation does not exist"},
+		{
+			"name":       "mod.table.t",
+			"parent":     "mod.dashboard.d",
+			"panel_type": "table",
+			"title":      "mod.table.t title",
+			"status":     "complete",
+			"columns":    []any{map[string]any{"name": "id", "data_type": "INT8"}, map[string]any{"name": "name", "data_type": "TEXT"}},
+			"rows":       []any{map[string]any{"id": 1.0, "name": "a"}, map[string]any{"id": 2.0, "name": "b"}},
+		},
+		{
+			"name":       "mod.chart.c",
+			"parent":     "mod.dashboard.d",
+			"panel_type": "chart",
+			"title":      "mod.chart.c title",
+			"status":     "error",
+			"error":      "relation does not exist",
+		},
+	}
+	for i, expected := range expectedPanels {
+		if !reflect.DeepEqual(panels[i], expected) {
+			t.Errorf("Test: 'panel %d' FAILED : expected %v, got %v", i, expected, panels[i])
+		}
+	}
+
+	// the failed leaf panel is reported (but not its container)
+	expectedErrors := []string{"mod.chart.c: relation does not exist"}
+	if panelErrors := data.PanelErrors(); !reflect.DeepEqual(panelErrors, expectedErrors) {
+		t.Errorf("expected panel errors %v, got %v", expectedErrors, panelErrors)
+	}
+}
@@ -2,8 +2,10 @@ package dashboardserver
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"path"
 	"time"
@@ -17,7 +19,7 @@ import (
 	"gopkg.in/olahol/melody.v1"
 )
 
-func startAPIAsync(ctx context.Context, webSocket *melody.Melody) chan struct{} {
+func startAPIAsync(ctx context.Context, webSocket *melody.Melody, listener net.Listener, tlsConfig *tls.Config, auth *authenticator, loadConnectionState connectionStateLoader, reload func(context.Context) error, listWorkspaces workspaceLister, listDashboards dashboardLister, runDashboard dashboardRunner, loadPermalink permalinkLoader) chan struct{} {
 	doneChan := make(chan struct{})
 
 	go func() {
@@ -26,14 +28,41 @@ func startAPIAsync(ctx context.Context, webSocket *melody.Melody) chan struct{}
 		// only add the Recovery middleware
 		router.Use(gin.Recovery())
 
+		// authenticate all requests, including those for assets and the websocket upgrade
+		if auth != nil {
+			router.Use(auth.Handler())
+			auth.addRoutes(router)
+		}
+
 		assetsDirectory := filepaths.EnsureDashboardAssetsDir()
 
+		// serve gzip encoded assets to clients which accept them, falling back to the uncompressed assets
+		router.Use(newAssetCompressor(assetsDirectory).Handler())
 		router.Use(static.Serve("/", static.LocalFile(assetsDirectory, true)))
 
+		// NOTE: the server read and write timeouts do not apply to the websocket - the server clears the connection
+		// deadlines when it is hijacked for the upgrade, and melody then sets a deadline for each message written (WriteWait)
+		// and extends the read deadline each time a pong is received (PongWait)
 		router.GET("/ws", func(c *gin.Context) {
-			webSocket.HandleRequest(c.Writer, c.Request)
+			_ = handleWebSocketRequest(webSocket, c.Writer, c.Request, authSessionFromContext(c))
 		})
 
+		// allow clients to poll the state of connections, which may still be loading
+		router.GET("/api/connection-state", connectionStateHandler(loadConnectionState))
+
+		// allow the mod to be reloaded without restarting the server
+		router.POST("/api/reload", reloadHandler(reload))
+
+		// allow external clients to list and run dashboards without using the websocket protocol
+		// the workspace is selected using the 'workspace' query parameter (see design/dashboard_rest_api.md)
+		router.GET("/api/workspaces", workspaceListHandler(listWorkspaces))
+		router.GET("/api/dashboards", dashboardListHandler(listDashboards))
+		router.POST("/api/dashboards/:name/run", dashboardRunHandler(runDashboard))
+
+		// allow saved dashboard states to be shared - a permalink opens the dashboard with the saved input values
+		router.GET("/api/permalinks/:id", permalinkHandler(loadPermalink))
+		router.GET(permalinkRoutePrefix+":id", permalinkRedirectHandler(loadPermalink))
+
 		router.NoRoute(func(c *gin.Context) {
 			// https://stackoverflow.com/questions/49547/how-do-we-control-web-page-caching-across-all-browsers
 			c.Header("Cache-Control", "no-cache, no-store, must-revalidate") // HTTP 1.1.
@@ -42,26 +71,21 @@ func startAPIAsync(ctx context.Context, webSocket *melody.Melody) chan struct{}
 			c.File(path.Join(assetsDirectory, "index.html"))
 		})
 
-		dashboardServerPort := viper.GetInt(constants.ArgDashboardPort)
-		dashboardServerListen := "localhost"
-		if viper.GetString(constants.ArgDashboardListen) == string(ListenTypeNetwork) {
-			dashboardServerListen = ""
-		}
+		// use the port we actually bound to - if the configured port was 0, this will have been chosen by the OS
+		dashboardServerPort := listener.Addr().(*net.TCPAddr).Port
 
-		srv := &http.Server{
-			Addr:    fmt.Sprintf("%s:%d", dashboardServerListen, dashboardServerPort),
-			Handler: router,
-		}
+		srv := newHTTPServer(router, serverTimeoutsFromConfig())
+		srv.TLSConfig = tlsConfig
 
 		go func() {
 			// service connections
-			if err := srv.ListenAndServe(); err != nil {
+			if err := serve(srv, listener); err != nil {
 				log.Printf("listen: %s\n", err)
 			}
 		}()
 
 		outputReady(ctx, fmt.Sprintf("Dashboard server started on %d and listening on %s", dashboardServerPort, viper.GetString(constants.ArgDashboardListen)))
-		OutputMessage(ctx, fmt.Sprintf("Visit http://localhost:%d", dashboardServerPort))
+		OutputMessage(ctx, fmt.Sprintf("Visit %s", auth.loginURL(serverURL(listener.Addr(), tlsConfig != nil))))
 		OutputMessage(ctx, "Press Ctrl+C to exit")
 		<-ctx.Done()
 		log.Println("Shutdown Server…")
@@ -80,3 +104,12 @@ func startAPIAsync(ctx context.Context, webSocket *melody.Melody) chan struct{}
 
 	return doneChan
 }
+
+// serve accepts connections on the listener, serving TLS if the server has a tls config
+// (the certificates are set in the tls config, so no certificate files are passed to ServeTLS)
+func serve(srv *http.Server, listener net.Listener) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(listener, "", "")
+	}
+	return srv.Serve(listener)
+}
@@ -0,0 +1,384 @@
+package dashboardserver
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/olahol/melody.v1"
+)
+
+const (
+	// the cookie which holds the id of an authenticated session
+	authSessionCookie = "steampipe_dashboard_session"
+	// the gin context key of the authenticated session of a request
+	authSessionKey = "auth_session"
+	// the query parameter used to pass the shared token when browsing to the dashboard
+	authTokenParam = "token"
+	// the websocket session key used to store the authenticated session of a dashboard client
+	sessionAuthKey = "auth"
+)
+
+var authTypes = []string{constants.DashboardAuthNone, constants.DashboardAuthToken, constants.DashboardAuthBasic, constants.DashboardAuthOIDC}
+
+// AuthOptions configure how dashboard clients are authenticated
+type AuthOptions struct {
+	// the type of authentication - one of none, token, basic or oidc
+	Type string
+	// the shared token for token authentication - if this is not set, a random token is generated
+	Token string
+	// the file of 'user:password[:role]' lines for basic authentication
+	// passwords may be plain text or bcrypt hashes
+	UsersFile string
+	// the Postgres role used to run the queries of authenticated sessions (empty to use the steampipe user)
+	Role string
+	// the lifetime of an authenticated session
+	SessionTTL time.Duration
+	OIDC       OIDCOptions
+}
+
+// authOptionsFromConfig returns the configured dashboard authentication options
+func authOptionsFromConfig() AuthOptions {
+	opts := AuthOptions{
+		Type:       viper.GetString(constants.ArgDashboardAuth),
+		Token:      viper.GetString(constants.ArgDashboardAuthToken),
+		UsersFile:  viper.GetString(constants.ArgDashboardAuthUsers),
+		Role:       viper.GetString(constants.ArgDashboardAuthRole),
+		SessionTTL: constants.DashboardAuthSessionTTL,
+		OIDC: OIDCOptions{
+			Issuer:       viper.GetString(constants.ArgDashboardOIDCIssuer),
+			ClientID:     viper.GetString(constants.ArgDashboardOIDCClientID),
+			ClientSecret: viper.GetString(constants.ArgDashboardOIDCClientSecret),
+		},
+	}
+	if opts.Type == "" {
+		opts.Type = constants.DashboardAuthNone
+	}
+	if viper.IsSet(constants.ArgDashboardAuthSessionTTL) {
+		opts.SessionTTL = time.Duration(viper.GetInt(constants.ArgDashboardAuthSessionTTL)) * time.Second
+	}
+	return opts
+}
+
+// authSession is an authenticated dashboard session
+type authSession struct {
+	id      string
+	user    string
+	role    string
+	expires time.Time
+}
+
+// basicAuthUser is a user who may authenticate using basic authentication
+type basicAuthUser struct {
+	password string
+	role     string
+}
+
+// authenticator authenticates the requests made to the dashboard server
+// once a client has authenticated with a token query parameter or OIDC, it is issued a session cookie which
+// authenticates subsequent requests (including the websocket upgrade)
+type authenticator struct {
+	opts  AuthOptions
+	users map[string]basicAuthUser
+	oidc  *oidcProvider
+
+	sessions map[string]*authSession
+	mut      sync.Mutex
+	// the server is served over TLS - session cookies are marked secure
+	secure bool
+	now    func() time.Time
+}
+
+// newAuthenticator returns the authenticator for the given options - nil is returned if authentication is disabled
+func newAuthenticator(ctx context.Context, opts AuthOptions, secure bool) (*authenticator, error) {
+	if !helpers.StringSliceContains(authTypes, opts.Type) {
+		return nil, fmt.Errorf("invalid --%s value '%s', must be one of [%s]", constants.ArgDashboardAuth, opts.Type, strings.Join(authTypes, ", "))
+	}
+	if opts.Type == constants.DashboardAuthNone {
+		return nil, nil
+	}
+	if opts.SessionTTL <= 0 {
+		return nil, fmt.Errorf("--%s must be greater than 0", constants.ArgDashboardAuthSessionTTL)
+	}
+
+	a := &authenticator{
+		opts:     opts,
+		sessions: make(map[string]*authSession),
+		secure:   secure,
+		now:      time.Now,
+	}
+	switch opts.Type {
+	case constants.DashboardAuthToken:
+		if a.opts.Token == "" {
+			token, err := randomId()
+			if err != nil {
+				return nil, err
+			}
+			a.opts.Token = token
+		}
+	case constants.DashboardAuthBasic:
+		if opts.UsersFile == "" {
+			return nil, fmt.Errorf("--%s must be set for basic authentication", constants.ArgDashboardAuthUsers)
+		}
+		users, err := loadBasicAuthUsers(opts.UsersFile)
+		if err != nil {
+			return nil, err
+		}
+		a.users = users
+	case constants.DashboardAuthOIDC:
+		provider, err := newOIDCProvider(ctx, opts.OIDC)
+		if err != nil {
+			return nil, err
+		}
+		a.oidc = provider
+	}
+	return a, nil
+}
+
+// sessionContext returns the context used to execute dashboards for a client
+// if the client authenticated with a Postgres role, the role is added to the context, so queries are run using the role
+func sessionContext(ctx context.Context, session *melody.Session) context.Context {
+	if auth, ok := session.Get(sessionAuthKey); ok && auth.(*authSession).role != "" {
+		return db_common.AddSessionRoleToContext(ctx, auth.(*authSession).role)
+	}
+	return ctx
+}
+
+// loginURL returns the URL to browse to the dashboard server - for token authentication, this includes the token
+func (a *authenticator) loginURL(serverURL string) string {
+	if a == nil || a.opts.Type != constants.DashboardAuthToken {
+		return serverURL
+	}
+	return fmt.Sprintf("%s?%s=%s", serverURL, authTokenParam, url.QueryEscape(a.opts.Token))
+}
+
+// addRoutes adds the login, callback and logout routes
+func (a *authenticator) addRoutes(router *gin.Engine) {
+	router.POST("/auth/logout", a.handleLogout)
+	if a.oidc != nil {
+		router.GET("/auth/login", a.handleOIDCLogin)
+		router.GET("/auth/callback", a.handleOIDCCallback)
+	}
+}
+
+// Handler returns the middleware which rejects requests which are not authenticated
+func (a *authenticator) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// the OIDC login routes are handled before the client has a session
+		if a.oidc != nil && (c.Request.URL.Path == "/auth/login" || c.Request.URL.Path == "/auth/callback") {
+			c.Next()
+			return
+		}
+		if session := a.sessionFromCookie(c.Request); session != nil {
+			c.Set(authSessionKey, session)
+			c.Next()
+			return
+		}
+
+		switch a.opts.Type {
+		case constants.DashboardAuthToken:
+			a.authenticateToken(c)
+		case constants.DashboardAuthBasic:
+			a.authenticateBasic(c)
+		case constants.DashboardAuthOIDC:
+			a.redirectToOIDCLogin(c)
+		}
+	}
+}
+
+// authenticateToken authenticates a request using the shared token
+// the token may be passed as a bearer token (for API clients) or as a query parameter (when browsing to the dashboard)
+func (a *authenticator) authenticateToken(c *gin.Context) {
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && a.validToken(token) {
+		// API clients send the token with each request, so no session is created
+		c.Set(authSessionKey, &authSession{user: constants.DashboardAuthToken, role: a.opts.Role})
+		c.Next()
+		return
+	}
+	query := c.Request.URL.Query()
+	if token := query.Get(authTokenParam); token != "" && a.validToken(token) && c.Request.Method == http.MethodGet {
+		if err := a.startSession(c, constants.DashboardAuthToken, a.opts.Role); err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		// redirect to remove the token from the URL
+		query.Del(authTokenParam)
+		redirect := *c.Request.URL
+		redirect.RawQuery = query.Encode()
+		c.Redirect(http.StatusFound, redirect.RequestURI())
+		c.Abort()
+		return
+	}
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
+
+func (a *authenticator) validToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.opts.Token)) == 1
+}
+
+// authenticateBasic authenticates a request using basic authentication
+// clients (including browsers) send the credentials with each request, so no session is created
+func (a *authenticator) authenticateBasic(c *gin.Context) {
+	username, password, ok := c.Request.BasicAuth()
+	if ok {
+		if user, found := a.users[username]; found && user.verify(password) {
+			c.Set(authSessionKey, &authSession{user: username, role: user.role})
+			c.Next()
+			return
+		}
+		log.Printf("[WARN] dashboard basic authentication failed for user '%s'", username)
+	}
+	c.Header("WWW-Authenticate", `Basic realm="Steampipe Dashboard", charset="UTF-8"`)
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
+
+// redirectToOIDCLogin sends browsers to the OIDC login - other requests are rejected
+func (a *authenticator) redirectToOIDCLogin(c *gin.Context) {
+	if c.Request.Method != http.MethodGet || c.Request.URL.Path == "/ws" || strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	c.Redirect(http.StatusFound, "/auth/login?redirect="+url.QueryEscape(c.Request.URL.RequestURI()))
+	c.Abort()
+}
+
+// handleLogout ends the session of the request
+func (a *authenticator) handleLogout(c *gin.Context) {
+	if cookie, err := c.Request.Cookie(authSessionCookie); err == nil {
+		a.mut.Lock()
+		delete(a.sessions, cookie.Value)
+		a.mut.Unlock()
+	}
+	a.setSessionCookie(c, "", -1)
+	c.Status(http.StatusNoContent)
+}
+
+// startSession creates a session for the authenticated user, and sets the session cookie
+func (a *authenticator) startSession(c *gin.Context, user, role string) error {
+	id, err := randomId()
+	if err != nil {
+		return err
+	}
+	session := &authSession{id: id, user: user, role: role, expires: a.now().Add(a.opts.SessionTTL)}
+
+	a.mut.Lock()
+	// remove expired sessions
+	for sessionId, s := range a.sessions {
+		if a.now().After(s.expires) {
+			delete(a.sessions, sessionId)
+		}
+	}
+	a.sessions[id] = session
+	a.mut.Unlock()
+
+	log.Printf("[INFO] dashboard session started for user '%s'", user)
+	a.setSessionCookie(c, id, int(a.opts.SessionTTL.Seconds()))
+	c.Set(authSessionKey, session)
+	return nil
+}
+
+// sessionFromCookie returns the session identified by the session cookie of the request (if it has not expired)
+func (a *authenticator) sessionFromCookie(r *http.Request) *authSession {
+	cookie, err := r.Cookie(authSessionCookie)
+	if err != nil {
+		return nil
+	}
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	session, ok := a.sessions[cookie.Value]
+	if !ok {
+		return nil
+	}
+	if a.now().After(session.expires) {
+		delete(a.sessions, cookie.Value)
+		return nil
+	}
+	return session
+}
+
+func (a *authenticator) setSessionCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     authSessionCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// authSessionFromContext returns the authenticated session of the request (nil if authentication is disabled)
+func authSessionFromContext(c *gin.Context) *authSession {
+	if session, ok := c.Get(authSessionKey); ok {
+		return session.(*authSession)
+	}
+	return nil
+}
+
+func (u basicAuthUser) verify(password string) bool {
+	if strings.HasPrefix(u.password, "$2") {
+		return bcrypt.CompareHashAndPassword([]byte(u.password), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(password), []byte(u.password)) == 1
+}
+
+// loadBasicAuthUsers loads the users file - each line is 'user:password', optionally followed by ':role'
+// blank lines and lines starting with '#' are ignored
+func loadBasicAuthUsers(path string) (map[string]basicAuthUser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dashboard users: %s", err.Error())
+	}
+	defer f.Close()
+
+	users := make(map[string]basicAuthUser)
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid dashboard user at %s:%d - expected 'user:password[:role]'", path, lineNumber)
+		}
+		user := basicAuthUser{password: parts[1]}
+		if len(parts) == 3 {
+			user.role = parts[2]
+		}
+		users[parts[0]] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no dashboard users found in %s", path)
+	}
+	return users, nil
+}
+
+// randomId returns a random url safe id
+func randomId() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,409 @@
+package dashboardserver
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/steampipe/pkg/constants"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// the time a user has to complete an OIDC login
+	oidcLoginTimeout = 10 * time.Minute
+	// the cookie which holds the (signed) state of an OIDC login, while the user logs in with the provider
+	oidcLoginCookie = "steampipe_dashboard_oidc_login"
+)
+
+// OIDCOptions configure the OIDC provider used to authenticate dashboard users
+type OIDCOptions struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+}
+
+// oidcDiscovery is the subset of the provider configuration (/.well-known/openid-configuration) used by the dashboard server
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// oidcClaims are the id token claims verified by the dashboard server
+type oidcClaims struct {
+	Issuer   string          `json:"iss"`
+	Subject  string          `json:"sub"`
+	Audience json.RawMessage `json:"aud"`
+	Expiry   int64           `json:"exp"`
+	Nonce    string          `json:"nonce"`
+	Email    string          `json:"email"`
+}
+
+// oidcLogin is a login which has been redirected to the provider
+// it is held by the browser which started the login in a signed cookie, so the server keeps no state for
+// pending logins, and the callback can only complete a login started by the same browser
+type oidcLogin struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Redirect string `json:"redirect"`
+	Expires  int64  `json:"expires"`
+}
+
+// oidcProvider authenticates users using the authorization code flow of an OIDC provider
+type oidcProvider struct {
+	opts      OIDCOptions
+	discovery oidcDiscovery
+	client    *http.Client
+
+	// the key used to sign the login cookie
+	loginKey []byte
+	keys     map[string]*rsa.PublicKey
+	mut      sync.Mutex
+}
+
+func newOIDCProvider(ctx context.Context, opts OIDCOptions) (*oidcProvider, error) {
+	if opts.Issuer == "" || opts.ClientID == "" {
+		return nil, fmt.Errorf("--%s and --%s must be set for oidc authentication", constants.ArgDashboardOIDCIssuer, constants.ArgDashboardOIDCClientID)
+	}
+	p := &oidcProvider{
+		opts:     opts,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		loginKey: make([]byte, 32),
+	}
+	if _, err := rand.Read(p.loginKey); err != nil {
+		return nil, err
+	}
+	discoveryURL := strings.TrimSuffix(opts.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, &p.discovery); err != nil {
+		return nil, fmt.Errorf("failed to load the configuration of oidc provider %s: %s", opts.Issuer, err.Error())
+	}
+	if strings.TrimSuffix(p.discovery.Issuer, "/") != strings.TrimSuffix(opts.Issuer, "/") {
+		return nil, fmt.Errorf("oidc provider issuer '%s' does not match the configured issuer '%s'", p.discovery.Issuer, opts.Issuer)
+	}
+	if err := p.loadKeys(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// oauthConfig returns the oauth2 config for the request - the callback URL is derived from the host the client used
+func (p *oidcProvider) oauthConfig(r *http.Request) *oauth2.Config {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return &oauth2.Config{
+		ClientID:     p.opts.ClientID,
+		ClientSecret: p.opts.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  p.discovery.AuthorizationEndpoint,
+			TokenURL: p.discovery.TokenEndpoint,
+		},
+		RedirectURL: fmt.Sprintf("%s://%s/auth/callback", scheme, r.Host),
+		Scopes:      []string{"openid", "email", "profile"},
+	}
+}
+
+// handleOIDCLogin redirects the client to the provider to log in
+func (a *authenticator) handleOIDCLogin(c *gin.Context) {
+	state, err := randomId()
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	nonce, err := randomId()
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	// only allow redirects to paths of this server
+	redirect := c.Query("redirect")
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") {
+		redirect = "/"
+	}
+
+	p := a.oidc
+	cookie, err := p.signLogin(oidcLogin{State: state, Nonce: nonce, Redirect: redirect, Expires: a.now().Add(oidcLoginTimeout).Unix()})
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	a.setOIDCLoginCookie(c, cookie, int(oidcLoginTimeout.Seconds()))
+	c.Redirect(http.StatusFound, p.oauthConfig(c.Request).AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", nonce)))
+}
+
+// handleOIDCCallback completes a login - the authorization code is exchanged for an id token, which is verified
+// before a session is started for the user
+func (a *authenticator) handleOIDCCallback(c *gin.Context) {
+	p := a.oidc
+	// the login cookie may only be used once
+	a.setOIDCLoginCookie(c, "", -1)
+	login, ok := a.oidcLoginFromCookie(c.Request)
+	if !ok || subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(login.State)) != 1 {
+		c.String(http.StatusBadRequest, "invalid or expired login")
+		return
+	}
+	if errorCode := c.Query("error"); errorCode != "" {
+		c.String(http.StatusUnauthorized, "login failed: %s", errorCode)
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), oauth2.HTTPClient, p.client)
+	token, err := p.oauthConfig(c.Request).Exchange(ctx, c.Query("code"))
+	if err != nil {
+		log.Printf("[WARN] dashboard oidc code exchange failed: %s", err.Error())
+		c.String(http.StatusUnauthorized, "login failed")
+		return
+	}
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		c.String(http.StatusUnauthorized, "login failed: no id token was returned")
+		return
+	}
+	claims, err := p.verifyIdToken(ctx, rawIdToken, login.Nonce, a.now())
+	if err != nil {
+		log.Printf("[WARN] dashboard oidc id token verification failed: %s", err.Error())
+		c.String(http.StatusUnauthorized, "login failed")
+		return
+	}
+
+	user := claims.Email
+	if user == "" {
+		user = claims.Subject
+	}
+	if err := a.startSession(c, user, a.opts.Role); err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Redirect(http.StatusFound, login.Redirect)
+}
+
+// oidcLoginFromCookie returns the login of the login cookie of the request, if the cookie is validly signed and has not expired
+func (a *authenticator) oidcLoginFromCookie(r *http.Request) (*oidcLogin, bool) {
+	cookie, err := r.Cookie(oidcLoginCookie)
+	if err != nil {
+		return nil, false
+	}
+	login, err := a.oidc.verifyLogin(cookie.Value)
+	if err != nil {
+		log.Printf("[WARN] dashboard oidc login cookie rejected: %s", err.Error())
+		return nil, false
+	}
+	if login.State == "" || a.now().After(time.Unix(login.Expires, 0)) {
+		return nil, false
+	}
+	return login, true
+}
+
+func (a *authenticator) setOIDCLoginCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcLoginCookie,
+		Value:    value,
+		Path:     "/auth/callback",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   a.secure,
+		// lax, so the cookie is sent when the provider redirects back to the callback
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// signLogin returns the cookie value of a login - the base64 encoded login and its HMAC-SHA256 signature
+func (p *oidcProvider) signLogin(login oidcLogin) (string, error) {
+	payload, err := json.Marshal(login)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(p.loginSignature(encoded)), nil
+}
+
+// verifyLogin verifies the signature of a login cookie value, and returns the login
+func (p *oidcProvider) verifyLogin(value string) (*oidcLogin, error) {
+	encoded, encodedSignature, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, fmt.Errorf("malformed login cookie")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, p.loginSignature(encoded)) {
+		return nil, fmt.Errorf("invalid login cookie signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed login cookie")
+	}
+	var login oidcLogin
+	if err := json.Unmarshal(payload, &login); err != nil {
+		return nil, fmt.Errorf("malformed login cookie")
+	}
+	return &login, nil
+}
+
+func (p *oidcProvider) loginSignature(encoded string) []byte {
+	mac := hmac.New(sha256.New, p.loginKey)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// verifyIdToken verifies the signature and claims of an RS256 signed id token
+func (p *oidcProvider) verifyIdToken(ctx context.Context, rawIdToken, nonce string, now time.Time) (*oidcClaims, error) {
+	parts := strings.Split(rawIdToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed id token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported id token signing algorithm '%s'", header.Alg)
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed id token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("invalid id token signature")
+	}
+
+	var claims oidcClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != p.discovery.Issuer {
+		return nil, fmt.Errorf("unexpected id token issuer '%s'", claims.Issuer)
+	}
+	if !claims.hasAudience(p.opts.ClientID) {
+		return nil, fmt.Errorf("id token was not issued for client '%s'", p.opts.ClientID)
+	}
+	if now.After(time.Unix(claims.Expiry, 0)) {
+		return nil, fmt.Errorf("id token has expired")
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("id token nonce does not match")
+	}
+	return &claims, nil
+}
+
+// hasAudience returns whether the audience claim, which may be a string or an array of strings, contains the client id
+func (c *oidcClaims) hasAudience(clientId string) bool {
+	var audience string
+	if err := json.Unmarshal(c.Audience, &audience); err == nil {
+		return audience == clientId
+	}
+	var audiences []string
+	if err := json.Unmarshal(c.Audience, &audiences); err != nil {
+		return false
+	}
+	for _, a := range audiences {
+		if a == clientId {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the signing key with the given id - if the key is unknown, the keys are reloaded once,
+// as the provider may have rotated its keys
+func (p *oidcProvider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mut.Lock()
+	key, ok := p.keys[kid]
+	p.mut.Unlock()
+	if ok {
+		return key, nil
+	}
+	if err := p.loadKeys(ctx); err != nil {
+		return nil, err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown id token signing key '%s'", kid)
+}
+
+// loadKeys loads the RSA signing keys of the provider
+func (p *oidcProvider) loadKeys(ctx context.Context) error {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.discovery.JwksURI, &jwks); err != nil {
+		return fmt.Errorf("failed to load the signing keys of oidc provider %s: %s", p.opts.Issuer, err.Error())
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.mut.Lock()
+	p.keys = keys
+	p.mut.Unlock()
+	return nil
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, url string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func decodeJWTSegment(segment string, target any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("malformed id token")
+	}
+	if err := json.Unmarshal(b, target); err != nil {
+		return fmt.Errorf("malformed id token")
+	}
+	return nil
+}
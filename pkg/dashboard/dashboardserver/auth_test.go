@@ -0,0 +1,393 @@
+package dashboardserver
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/steampipe/pkg/constants"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func authRouter(t *testing.T, opts AuthOptions) (*gin.Engine, *authenticator) {
+	gin.SetMode(gin.TestMode)
+	auth, err := newAuthenticator(context.Background(), opts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(auth.Handler())
+	auth.addRoutes(router)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, authSessionFromContext(c).user)
+	})
+	return router, auth
+}
+
+func authRequest(router *gin.Engine, target string, setup func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if setup != nil {
+		setup(req)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func sessionCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == authSessionCookie {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestTokenAuthentication(t *testing.T) {
+	router, auth := authRouter(t, AuthOptions{Type: constants.DashboardAuthToken, Token: "secret", SessionTTL: time.Hour})
+
+	if w := authRequest(router, "/", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected an unauthenticated request to return %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := authRequest(router, "/?token=wrong", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected an invalid token to return %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// a bearer token authenticates the request without creating a session
+	w := authRequest(router, "/", func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") })
+	if w.Code != http.StatusOK || sessionCookie(w) != nil {
+		t.Errorf("expected a bearer token to be accepted without a session, got status %d", w.Code)
+	}
+
+	// the token query parameter creates a session, and redirects to remove the token from the URL
+	w = authRequest(router, "/?token=secret&dashboard=a", nil)
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected the token query parameter to redirect, got %d", w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/?dashboard=a" {
+		t.Errorf("expected the redirect to remove the token, got '%s'", location)
+	}
+	cookie := sessionCookie(w)
+	if cookie == nil || !cookie.HttpOnly {
+		t.Fatalf("expected an http only session cookie to be set")
+	}
+	w = authRequest(router, "/", func(r *http.Request) { r.AddCookie(cookie) })
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the session cookie to be accepted, got %d", w.Code)
+	}
+
+	if url := auth.loginURL("http://localhost:9194"); url != "http://localhost:9194?token=secret" {
+		t.Errorf("expected the login URL to contain the token, got '%s'", url)
+	}
+}
+
+func TestGeneratedToken(t *testing.T) {
+	_, auth := authRouter(t, AuthOptions{Type: constants.DashboardAuthToken, SessionTTL: time.Hour})
+	if len(auth.opts.Token) == 0 {
+		t.Errorf("expected a token to be generated")
+	}
+}
+
+func TestBasicAuthentication(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hashed-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usersFile := filepath.Join(t.TempDir(), "users")
+	users := "# dashboard users\nalice:plain-password:reader\n\nbob:" + string(hash) + "\n"
+	if err := os.WriteFile(usersFile, []byte(users), 0600); err != nil {
+		t.Fatal(err)
+	}
+	router, auth := authRouter(t, AuthOptions{Type: constants.DashboardAuthBasic, UsersFile: usersFile, SessionTTL: time.Hour})
+
+	type basicAuthTest struct {
+		user     string
+		password string
+		expected int
+	}
+	tests := map[string]basicAuthTest{
+		"plain text password":   {"alice", "plain-password", http.StatusOK},
+		"bcrypt password":       {"bob", "hashed-password", http.StatusOK},
+		"wrong password":        {"alice", "hashed-password", http.StatusUnauthorized},
+		"unknown user":          {"carol", "plain-password", http.StatusUnauthorized},
+		"bcrypt hash as secret": {"bob", string(hash), http.StatusUnauthorized},
+	}
+	for name, test := range tests {
+		w := authRequest(router, "/", func(r *http.Request) { r.SetBasicAuth(test.user, test.password) })
+		if w.Code != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected status %d, got %d", name, test.expected, w.Code)
+		}
+	}
+
+	if w := authRequest(router, "/", nil); w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected an unauthenticated request to be challenged")
+	}
+
+	// the credentials are sent with each request, so no session is created - the request has the role of the user
+	router.GET("/role", func(c *gin.Context) {
+		c.String(http.StatusOK, authSessionFromContext(c).role)
+	})
+	w := authRequest(router, "/role", func(r *http.Request) { r.SetBasicAuth("alice", "plain-password") })
+	if w.Code != http.StatusOK || w.Body.String() != "reader" {
+		t.Errorf("expected the request to have the role 'reader', got status %d, role '%s'", w.Code, w.Body.String())
+	}
+	if sessionCookie(w) != nil || len(auth.sessions) != 0 {
+		t.Errorf("expected basic authentication not to create a session")
+	}
+}
+
+func TestLoadBasicAuthUsersInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no password": "alice\n",
+		"empty user":  ":password\n",
+		"extra field": "alice:password:role:other\n",
+		"no users":    "# nobody\n",
+	}
+	for name, users := range tests {
+		usersFile := filepath.Join(t.TempDir(), "users")
+		if err := os.WriteFile(usersFile, []byte(users), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadBasicAuthUsers(usersFile); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected an error", name)
+		}
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	router, auth := authRouter(t, AuthOptions{Type: constants.DashboardAuthToken, Token: "secret", SessionTTL: time.Hour})
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	cookie := sessionCookie(authRequest(router, "/?token=secret", nil))
+	if w := authRequest(router, "/", func(r *http.Request) { r.AddCookie(cookie) }); w.Code != http.StatusOK {
+		t.Fatalf("expected the session cookie to be accepted, got %d", w.Code)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if w := authRequest(router, "/", func(r *http.Request) { r.AddCookie(cookie) }); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected an expired session to be rejected, got %d", w.Code)
+	}
+	if len(auth.sessions) != 0 {
+		t.Errorf("expected the expired session to be removed")
+	}
+}
+
+func TestLogout(t *testing.T) {
+	router, _ := authRouter(t, AuthOptions{Type: constants.DashboardAuthToken, Token: "secret", SessionTTL: time.Hour})
+	cookie := sessionCookie(authRequest(router, "/?token=secret", nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.AddCookie(cookie)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if w := authRequest(router, "/", func(r *http.Request) { r.AddCookie(cookie) }); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the session to be ended by logout, got %d", w.Code)
+	}
+}
+
+func TestOIDCLoginState(t *testing.T) {
+	// the token endpoint rejects all codes - a callback which gets as far as the code exchange has passed the state check
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	}))
+	defer tokenServer.Close()
+
+	gin.SetMode(gin.TestMode)
+	auth := &authenticator{
+		opts:     AuthOptions{Type: constants.DashboardAuthOIDC, SessionTTL: time.Hour},
+		sessions: make(map[string]*authSession),
+		now:      time.Now,
+		oidc: &oidcProvider{
+			opts:      OIDCOptions{ClientID: "steampipe"},
+			discovery: oidcDiscovery{AuthorizationEndpoint: "https://issuer/auth", TokenEndpoint: tokenServer.URL},
+			client:    tokenServer.Client(),
+			loginKey:  []byte("login-key"),
+		},
+	}
+	router := gin.New()
+	router.Use(auth.Handler())
+	auth.addRoutes(router)
+
+	// the login sets a signed login cookie, and redirects to the provider with the state of the cookie
+	w := authRequest(router, "/auth/login?redirect=/dashboard", nil)
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected the login to redirect to the provider, got %d", w.Code)
+	}
+	var loginCookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == oidcLoginCookie {
+			loginCookie = c
+		}
+	}
+	if loginCookie == nil || !loginCookie.HttpOnly || loginCookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("expected an http only, same site lax login cookie to be set")
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := location.Query().Get("state")
+
+	forged := *loginCookie
+	forged.Value = loginCookie.Value[:strings.Index(loginCookie.Value, ".")] + ".c2lnbmF0dXJl"
+
+	type callbackTest struct {
+		state    string
+		cookie   *http.Cookie
+		expected int
+	}
+	tests := map[string]callbackTest{
+		"no login cookie":      {state, nil, http.StatusBadRequest},
+		"state does not match": {"other", loginCookie, http.StatusBadRequest},
+		"forged login cookie":  {state, &forged, http.StatusBadRequest},
+		"state matches cookie": {state, loginCookie, http.StatusUnauthorized},
+	}
+	for name, test := range tests {
+		w := authRequest(router, "/auth/callback?code=c&state="+url.QueryEscape(test.state), func(r *http.Request) {
+			if test.cookie != nil {
+				r.AddCookie(test.cookie)
+			}
+		})
+		if w.Code != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected status %d, got %d", name, test.expected, w.Code)
+		}
+	}
+
+	// an expired login is rejected
+	auth.now = func() time.Time { return time.Now().Add(2 * oidcLoginTimeout) }
+	w = authRequest(router, "/auth/callback?code=c&state="+url.QueryEscape(state), func(r *http.Request) { r.AddCookie(loginCookie) })
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected an expired login to be rejected, got %d", w.Code)
+	}
+}
+
+func TestInvalidAuthOptions(t *testing.T) {
+	tests := map[string]AuthOptions{
+		"unknown type":        {Type: "magic", SessionTTL: time.Hour},
+		"no session ttl":      {Type: constants.DashboardAuthToken},
+		"basic without users": {Type: constants.DashboardAuthBasic, SessionTTL: time.Hour},
+		"oidc without issuer": {Type: constants.DashboardAuthOIDC, SessionTTL: time.Hour},
+		"missing users file":  {Type: constants.DashboardAuthBasic, UsersFile: "/does/not/exist", SessionTTL: time.Hour},
+		"oidc without client": {Type: constants.DashboardAuthOIDC, OIDC: OIDCOptions{Issuer: "https://issuer"}, SessionTTL: time.Hour},
+	}
+	for name, opts := range tests {
+		if _, err := newAuthenticator(context.Background(), opts, false); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected an error", name)
+		}
+	}
+
+	auth, err := newAuthenticator(context.Background(), AuthOptions{Type: constants.DashboardAuthNone}, false)
+	if err != nil || auth != nil {
+		t.Errorf("expected no authenticator when authentication is disabled")
+	}
+}
+
+// signedIdToken returns an RS256 id token for the claims, signed with the key
+func signedIdToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	encode := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signingInput := encode(map[string]string{"alg": "RS256", "kid": kid}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyIdToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcDiscovery{Issuer: issuer, JwksURI: issuer + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	issuerServer := httptest.NewServer(mux)
+	defer issuerServer.Close()
+	issuer = issuerServer.URL
+
+	ctx := context.Background()
+	provider, err := newOIDCProvider(ctx, OIDCOptions{Issuer: issuer, ClientID: "steampipe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	validClaims := func() map[string]any {
+		return map[string]any{"iss": issuer, "sub": "123", "aud": "steampipe", "exp": now.Add(time.Hour).Unix(), "nonce": "n", "email": "alice@example.com"}
+	}
+
+	claims, err := provider.verifyIdToken(ctx, signedIdToken(t, key, "key-1", validClaims()), "n", now)
+	if err != nil {
+		t.Fatalf("expected the id token to be valid: %s", err.Error())
+	}
+	if claims.Email != "alice@example.com" {
+		t.Errorf("expected the email claim 'alice@example.com', got '%s'", claims.Email)
+	}
+
+	arrayAudience := validClaims()
+	arrayAudience["aud"] = []string{"other", "steampipe"}
+	if _, err := provider.verifyIdToken(ctx, signedIdToken(t, key, "key-1", arrayAudience), "n", now); err != nil {
+		t.Errorf("expected an audience array containing the client to be valid: %s", err.Error())
+	}
+
+	type idTokenTest struct {
+		token string
+		nonce string
+	}
+	withClaim := func(name string, value any) map[string]any {
+		c := validClaims()
+		c[name] = value
+		return c
+	}
+	tests := map[string]idTokenTest{
+		"wrong key":      {signedIdToken(t, otherKey, "key-1", validClaims()), "n"},
+		"unknown key id": {signedIdToken(t, key, "key-2", validClaims()), "n"},
+		"wrong issuer":   {signedIdToken(t, key, "key-1", withClaim("iss", "https://other")), "n"},
+		"wrong audience": {signedIdToken(t, key, "key-1", withClaim("aud", "other")), "n"},
+		"expired":        {signedIdToken(t, key, "key-1", withClaim("exp", now.Add(-time.Minute).Unix())), "n"},
+		"wrong nonce":    {signedIdToken(t, key, "key-1", validClaims()), "other"},
+		"malformed":      {"not.a-token", "n"},
+	}
+	for name, test := range tests {
+		if _, err := provider.verifyIdToken(ctx, test.token, test.nonce, now); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected the id token to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package dashboardserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// file extensions of dashboard assets which are worth compressing
+// (images and fonts are already compressed)
+var compressibleAssetExtensions = map[string]bool{
+	".html": true,
+	".js":   true,
+	".css":  true,
+	".json": true,
+	".map":  true,
+	".svg":  true,
+	".txt":  true,
+}
+
+// compressedAsset is the gzipped content of a dashboard asset
+type compressedAsset struct {
+	modTime time.Time
+	size    int64
+	etag    string
+	data    []byte
+}
+
+// assetCompressor serves gzip encoded dashboard assets to clients which accept them
+// as the assets are immutable for a given build, the compressed content is cached in memory
+type assetCompressor struct {
+	assetsDirectory string
+	cache           map[string]*compressedAsset
+	cacheMut        sync.Mutex
+}
+
+func newAssetCompressor(assetsDirectory string) *assetCompressor {
+	return &assetCompressor{
+		assetsDirectory: assetsDirectory,
+		cache:           make(map[string]*compressedAsset),
+	}
+}
+
+// Handler returns a middleware which serves compressed assets, passing any other requests
+// (including requests from clients which do not accept gzip) to the next handler
+func (a *assetCompressor) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		filePath, info := a.resolveAsset(c.Request.URL.Path)
+		if info == nil || !compressibleAssetExtensions[strings.ToLower(filepath.Ext(filePath))] {
+			c.Next()
+			return
+		}
+
+		// the response depends on the encodings the client accepts
+		c.Header("Vary", "Accept-Encoding")
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		asset, err := a.getCompressedAsset(filePath, info)
+		if err != nil {
+			// fall back to serving the uncompressed asset
+			c.Next()
+			return
+		}
+
+		c.Header("ETag", asset.etag)
+		c.Header("Last-Modified", asset.modTime.UTC().Format(http.TimeFormat))
+		if etagMatches(c.GetHeader("If-None-Match"), asset.etag) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+
+		// http.ServeContent is not used as it does not set Content-Length for encoded content
+		// (range requests are not supported for the compressed assets)
+		c.Header("Content-Encoding", "gzip")
+		c.Header("Content-Length", strconv.Itoa(len(asset.data)))
+		contentType := mime.TypeByExtension(filepath.Ext(filePath))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		c.Header("Content-Type", contentType)
+		c.Status(http.StatusOK)
+		if c.Request.Method == http.MethodGet {
+			_, _ = c.Writer.Write(asset.data)
+		}
+		c.Abort()
+	}
+}
+
+// resolveAsset maps a request path to a regular file in the assets directory
+// a directory is resolved to its index.html
+func (a *assetCompressor) resolveAsset(urlPath string) (string, os.FileInfo) {
+	filePath := filepath.Join(a.assetsDirectory, filepath.FromSlash(path.Clean("/"+urlPath)))
+	info, err := os.Stat(filePath)
+	if err == nil && info.IsDir() {
+		filePath = filepath.Join(filePath, "index.html")
+		info, err = os.Stat(filePath)
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return "", nil
+	}
+	return filePath, info
+}
+
+// getCompressedAsset returns the cached compressed content of the asset, compressing it if it is not cached
+// or has changed since it was cached (i.e. the assets have been re-extracted)
+func (a *assetCompressor) getCompressedAsset(filePath string, info os.FileInfo) (*compressedAsset, error) {
+	a.cacheMut.Lock()
+	defer a.cacheMut.Unlock()
+
+	if asset, ok := a.cache[filePath]; ok && asset.modTime.Equal(info.ModTime()) && asset.size == info.Size() {
+		return asset, nil
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	asset := &compressedAsset{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		// the etag identifies the encoded representation, so must differ from that of the uncompressed asset
+		etag: fmt.Sprintf(`"%x-gzip"`, sha256.Sum256(content)),
+		data: buf.Bytes(),
+	}
+	a.cache[filePath] = asset
+	return asset, nil
+}
+
+// acceptsGzip returns whether the Accept-Encoding header allows a gzip encoded response
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding != "gzip" && encoding != "*" {
+			continue
+		}
+		// an encoding with a quality of 0 is not acceptable
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if quality, err := strconv.ParseFloat(q, 64); err == nil && quality == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// etagMatches returns whether the If-None-Match header matches the given etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package dashboardserver
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/static"
+	"github.com/gin-gonic/gin"
+)
+
+func assetRequest(router *gin.Engine, target string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompressedAssets(t *testing.T) {
+	assetsDirectory := t.TempDir()
+	content := strings.Repeat("console.log('steampipe dashboard');\n", 100)
+	if err := os.WriteFile(filepath.Join(assetsDirectory, "main.js"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(newAssetCompressor(assetsDirectory).Handler())
+	router.Use(static.Serve("/", static.LocalFile(assetsDirectory, true)))
+
+	w := assetRequest(router, "/main.js", map[string]string{"Accept-Encoding": "gzip, deflate"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if encoding := w.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("expected gzip content encoding, got '%s'", encoding)
+	}
+	if contentLength := w.Header().Get("Content-Length"); contentLength != strconv.Itoa(w.Body.Len()) {
+		t.Errorf("expected Content-Length %d, got %s", w.Body.Len(), contentLength)
+	}
+	if w.Body.Len() >= len(content) {
+		t.Errorf("expected compressed response to be smaller than %d bytes, got %d", len(content), w.Body.Len())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != content {
+		t.Errorf("decoded response does not match the asset content")
+	}
+
+	// a conditional request for the cached asset should not return the content
+	etag := w.Header().Get("ETag")
+	w = assetRequest(router, "/main.js", map[string]string{"Accept-Encoding": "gzip", "If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected status %d for matching ETag, got %d", http.StatusNotModified, w.Code)
+	}
+
+	// clients which do not accept gzip get the uncompressed asset
+	for _, acceptEncoding := range []string{"", "br", "gzip;q=0"} {
+		w = assetRequest(router, "/main.js", map[string]string{"Accept-Encoding": acceptEncoding})
+		if w.Header().Get("Content-Encoding") != "" || w.Body.String() != content {
+			t.Errorf("Accept-Encoding '%s': expected uncompressed asset", acceptEncoding)
+		}
+	}
+}
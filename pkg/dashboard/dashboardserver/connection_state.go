@@ -0,0 +1,88 @@
+package dashboardserver
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	typeHelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// the maximum time to wait for the connection state to be read
+// this is kept short as the endpoint is intended to be polled
+const connectionStateTimeout = 5 * time.Second
+
+// connectionStateLoader is a function which reads the current connection state
+type connectionStateLoader func(ctx context.Context) (steampipeconfig.ConnectionStateMap, error)
+
+type ConnectionStatePayload struct {
+	// true if all connections are either ready, in error or disabled
+	Loaded      bool                      `json:"loaded"`
+	Connections []ConnectionStateResponse `json:"connections"`
+}
+
+type ConnectionStateResponse struct {
+	Name   string `json:"name"`
+	Plugin string `json:"plugin"`
+	State  string `json:"state"`
+	Error  string `json:"error,omitempty"`
+	// the time the connection was last successfully refreshed
+	LastRefreshed *time.Time `json:"last_refreshed,omitempty"`
+	// the time the connection last failed to refresh
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+func buildConnectionStatePayload(connectionStateMap steampipeconfig.ConnectionStateMap) ConnectionStatePayload {
+	payload := ConnectionStatePayload{
+		Loaded:      true,
+		Connections: make([]ConnectionStateResponse, 0, len(connectionStateMap)),
+	}
+	for _, name := range utils.SortedMapKeys(connectionStateMap) {
+		connectionState := connectionStateMap[name]
+		if !connectionState.Loaded() {
+			payload.Loaded = false
+		}
+		payload.Connections = append(payload.Connections, ConnectionStateResponse{
+			Name:          connectionState.ConnectionName,
+			Plugin:        connectionState.Plugin,
+			State:         connectionState.State,
+			Error:         typeHelpers.SafeString(connectionState.ConnectionError),
+			LastRefreshed: connectionState.LastRefreshed,
+			LastErrorAt:   connectionState.LastErrorAt,
+		})
+	}
+	return payload
+}
+
+// loadConnectionState reads the connection state table using a management connection from the pool
+func (s *Server) loadConnectionState(ctx context.Context) (steampipeconfig.ConnectionStateMap, error) {
+	conn, err := s.dbClient.AcquireManagementConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	return steampipeconfig.LoadConnectionState(ctx, conn.Conn())
+}
+
+func connectionStateHandler(loadConnectionState connectionStateLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), connectionStateTimeout)
+		defer cancel()
+
+		connectionStateMap, err := loadConnectionState(ctx)
+		if err != nil {
+			log.Printf("[WARN] failed to load connection state: %s", err.Error())
+			c.JSON(http.StatusServiceUnavailable, ErrorPayload{Action: "connection_state", Error: err.Error()})
+			return
+		}
+
+		// the state may change at any time - never cache
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+		c.JSON(http.StatusOK, buildConnectionStatePayload(connectionStateMap))
+	}
+}
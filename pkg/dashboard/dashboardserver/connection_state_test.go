@@ -0,0 +1,75 @@
+package dashboardserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+func connectionStateRequest(t *testing.T, loader connectionStateLoader) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/connection-state", connectionStateHandler(loader))
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/api/connection-state", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestConnectionStateEndpoint(t *testing.T) {
+	connectionError := "bad credentials"
+	// seeded connection state
+	state := steampipeconfig.ConnectionStateMap{
+		"aws":   {ConnectionName: "aws", Plugin: "aws", State: constants.ConnectionStateReady},
+		"gcp":   {ConnectionName: "gcp", Plugin: "gcp", State: constants.ConnectionStateUpdating},
+		"azure": {ConnectionName: "azure", Plugin: "azure", State: constants.ConnectionStateError, ConnectionError: &connectionError},
+	}
+
+	w := connectionStateRequest(t, func(context.Context) (steampipeconfig.ConnectionStateMap, error) {
+		return state, nil
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var payload ConnectionStatePayload
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Loaded {
+		t.Errorf("expected loaded to be false as a connection is updating")
+	}
+	expected := []ConnectionStateResponse{
+		{Name: "aws", Plugin: "aws", State: constants.ConnectionStateReady},
+		{Name: "azure", Plugin: "azure", State: constants.ConnectionStateError, Error: connectionError},
+		{Name: "gcp", Plugin: "gcp", State: constants.ConnectionStateUpdating},
+	}
+	if len(payload.Connections) != len(expected) {
+		t.Fatalf("expected %d connections, got %d", len(expected), len(payload.Connections))
+	}
+	for i, c := range expected {
+		if payload.Connections[i] != c {
+			t.Errorf("expected connection %d to be %+v, got %+v", i, c, payload.Connections[i])
+		}
+	}
+}
+
+func TestConnectionStateEndpointError(t *testing.T) {
+	w := connectionStateRequest(t, func(context.Context) (steampipeconfig.ConnectionStateMap, error) {
+		return nil, errors.New("connection state table not found")
+	})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
@@ -0,0 +1,158 @@
+package dashboardserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the descriptors which may be used in place of a cron expression
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule is a parsed 5 field cron expression: minute, hour, day of month, month and day of week
+// each field is a set of the values which match
+type cronSchedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+	// if both the day of month and day of week are restricted, a day matches if EITHER matches (as for cron)
+	domRestricted bool
+	dowRestricted bool
+}
+
+// parseCron parses a standard 5 field cron expression (or one of the @ descriptors, e.g. @daily)
+// fields may be '*', a value, a range (1-5), a list (1,3,5) and may have a step (*/15, 0-30/10)
+// days of the week are 0-7, where both 0 and 7 are Sunday
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression '%s': expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+
+	type fieldSpec struct {
+		name     string
+		min, max int
+		target   *map[int]bool
+	}
+	s := &cronSchedule{}
+	specs := []fieldSpec{
+		{"minute", 0, 59, &s.minutes},
+		{"hour", 0, 23, &s.hours},
+		{"day of month", 1, 31, &s.daysOfMonth},
+		{"month", 1, 12, &s.months},
+		{"day of week", 0, 7, &s.daysOfWeek},
+	}
+	for i, spec := range specs {
+		values, err := parseCronField(fields[i], spec.min, spec.max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression '%s': %s %s", expr, spec.name, err.Error())
+		}
+		*spec.target = values
+	}
+	// 7 is an alias for Sunday
+	if s.daysOfWeek[7] {
+		s.daysOfWeek[0] = true
+	}
+	s.domRestricted = !strings.HasPrefix(fields[2], "*")
+	s.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i != -1 {
+			rangePart = part[:i]
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("has an invalid step '%s'", part)
+			}
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || start > end {
+				return nil, fmt.Errorf("has an invalid range '%s'", rangePart)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("has an invalid value '%s'", rangePart)
+			}
+			start = value
+			// a value with a step means from the value to the max (e.g. 5/15)
+			if step == 1 {
+				end = value
+			}
+		}
+		if start < min || end > max {
+			return nil, fmt.Errorf("value '%s' is out of range (%d-%d)", rangePart, min, max)
+		}
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Next returns the first time after t which matches the schedule (to the minute)
+// a zero time is returned if there is no matching time in the next 5 years (e.g. '0 0 31 2 *')
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	// start from the next whole minute
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.months[int(t.Month())] {
+			// move to the start of the next month
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	domMatch := s.daysOfMonth[t.Day()]
+	dowMatch := s.daysOfWeek[int(t.Weekday())]
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
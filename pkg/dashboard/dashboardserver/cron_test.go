@@ -0,0 +1,70 @@
+package dashboardserver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	type cronTest struct {
+		expr          string
+		expectedError bool
+	}
+	tests := map[string]cronTest{
+		"every minute":    {"* * * * *", false},
+		"list and range":  {"0,30 9-17 * * 1-5", false},
+		"step":            {"*/15 * * * *", false},
+		"value with step": {"5/20 * * * *", false},
+		"sunday as 7":     {"0 0 * * 7", false},
+		"descriptor":      {"@daily", false},
+		"too few fields":  {"* * * *", true},
+		"too many fields": {"* * * * * *", true},
+		"out of range":    {"60 * * * *", true},
+		"invalid range":   {"0 5-1 * * *", true},
+		"invalid step":    {"*/0 * * * *", true},
+		"not a number":    {"0 noon * * *", true},
+		"zero day":        {"0 0 0 * *", true},
+	}
+	for name, test := range tests {
+		_, err := parseCron(test.expr)
+		if test.expectedError && err == nil {
+			t.Errorf("Test: '%s' FAILED : expected an error", name)
+		}
+		if !test.expectedError && err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2023, 9, 6, 10, 17, 30, 0, time.UTC)
+
+	type nextTest struct {
+		expr     string
+		expected time.Time
+	}
+	tests := map[string]nextTest{
+		"every minute":        {"* * * * *", time.Date(2023, 9, 6, 10, 18, 0, 0, time.UTC)},
+		"every 15 minutes":    {"*/15 * * * *", time.Date(2023, 9, 6, 10, 30, 0, 0, time.UTC)},
+		"daily, later today":  {"0 18 * * *", time.Date(2023, 9, 6, 18, 0, 0, 0, time.UTC)},
+		"daily, tomorrow":     {"0 6 * * *", time.Date(2023, 9, 7, 6, 0, 0, 0, time.UTC)},
+		"weekly on sunday":    {"0 0 * * 7", time.Date(2023, 9, 10, 0, 0, 0, 0, time.UTC)},
+		"weekdays":            {"0 9 * * 1-5", time.Date(2023, 9, 7, 9, 0, 0, 0, time.UTC)},
+		"monthly":             {"@monthly", time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)},
+		"yearly":              {"@yearly", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		"day of month or dow": {"0 0 15 * 5", time.Date(2023, 9, 8, 0, 0, 0, 0, time.UTC)},
+		"leap day":            {"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		"never":               {"0 0 31 2 *", time.Time{}},
+	}
+	for name, test := range tests {
+		schedule, err := parseCron(test.expr)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if next := schedule.Next(from); !next.Equal(test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, next)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package dashboardserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
+)
+
+// if more than this proportion of the rows of a leaf node have changed, the full rows are sent
+// (the delta would save little, and the client would do more work to apply it)
+const maxDeltaChangeRatio = 0.5
+
+// leafRows are the columns and rows of a leaf node, as serialised in a leaf node update
+type leafRows struct {
+	columns []any
+	rows    []map[string]any
+}
+
+// newLeafRows returns the rows of a leaf node update, or false if the node has no data
+func newLeafRows(node map[string]any) (*leafRows, bool) {
+	data, ok := node["data"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	columns, _ := data["columns"].([]any)
+	rawRows, _ := data["rows"].([]any)
+	res := &leafRows{
+		columns: columns,
+		rows:    make([]map[string]any, len(rawRows)),
+	}
+	for i, rawRow := range rawRows {
+		row, ok := rawRow.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		res.rows[i] = row
+	}
+	return res, true
+}
+
+// deltaUpdatesFromConfig returns whether leaf node rows should be sent as deltas of the rows previously sent
+func deltaUpdatesFromConfig() bool {
+	return viper.GetBool(constants.ArgDashboardDeltaUpdates)
+}
+
+// rowKey returns a comparable key for the value of the key column of a row
+// the value is json encoded so values of different types are distinct (e.g. 1 and "1")
+func rowKey(value any) string {
+	res, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(res)
+}
+
+// findKeyColumn returns the first column whose values are set and unique in both the previous and current rows
+func findKeyColumn(previous, current *leafRows) (string, bool) {
+	for _, c := range current.columns {
+		column, _ := c.(map[string]any)
+		name, _ := column["name"].(string)
+		if name != "" && isKeyColumn(name, previous.rows) && isKeyColumn(name, current.rows) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func isKeyColumn(name string, rows []map[string]any) bool {
+	keys := make(map[string]struct{}, len(rows))
+	for _, row := range rows {
+		value, ok := row[name]
+		if !ok || value == nil {
+			return false
+		}
+		key := rowKey(value)
+		if _, duplicate := keys[key]; duplicate {
+			return false
+		}
+		keys[key] = struct{}{}
+	}
+	return true
+}
+
+// diffLeafRows returns the delta from the previous rows to the current rows
+// false is returned if a delta is not feasible: the columns have changed, there is no key column,
+// the unchanged rows have been reordered or too many rows have changed
+func diffLeafRows(previous, current *leafRows) (*LeafNodeRowsDelta, bool) {
+	if !reflect.DeepEqual(previous.columns, current.columns) {
+		return nil, false
+	}
+	keyColumn, ok := findKeyColumn(previous, current)
+	if !ok {
+		return nil, false
+	}
+
+	previousRows := make(map[string]map[string]any, len(previous.rows))
+	for _, row := range previous.rows {
+		previousRows[rowKey(row[keyColumn])] = row
+	}
+	currentKeys := make(map[string]struct{}, len(current.rows))
+
+	delta := &LeafNodeRowsDelta{KeyColumn: keyColumn, RowCount: len(current.rows)}
+	// the keys of the rows which are unchanged, in their current order
+	var unchanged []string
+	for i, row := range current.rows {
+		key := rowKey(row[keyColumn])
+		currentKeys[key] = struct{}{}
+		if previousRow, ok := previousRows[key]; ok && reflect.DeepEqual(previousRow, row) {
+			unchanged = append(unchanged, key)
+			continue
+		}
+		delta.Upserted = append(delta.Upserted, LeafNodeRowAt{Index: i, Row: row})
+	}
+	for _, row := range previous.rows {
+		if _, ok := currentKeys[rowKey(row[keyColumn])]; !ok {
+			delta.Removed = append(delta.Removed, row[keyColumn])
+		}
+	}
+
+	// the client fills the positions which are not upserted with the remaining previous rows in order,
+	// so the unchanged rows must be in the same order as they were previously
+	next := 0
+	for _, row := range previous.rows {
+		key := rowKey(row[keyColumn])
+		if next < len(unchanged) && unchanged[next] == key {
+			next++
+		}
+	}
+	if next != len(unchanged) {
+		return nil, false
+	}
+
+	changes := len(delta.Upserted) + len(delta.Removed)
+	if float64(changes) > maxDeltaChangeRatio*float64(len(current.rows)) {
+		return nil, false
+	}
+	return delta, true
+}
+
+// leafNodeWithoutRows returns a copy of a serialised leaf node, without the rows of its data
+// the node and its data are copied, so the event the node belongs to is not modified
+func leafNodeWithoutRows(leafNode map[string]any) map[string]any {
+	node := make(map[string]any, len(leafNode))
+	for k, v := range leafNode {
+		node[k] = v
+	}
+	if data, ok := leafNode["data"].(map[string]any); ok {
+		nodeData := make(map[string]any, len(data))
+		for k, v := range data {
+			if k != "rows" {
+				nodeData[k] = v
+			}
+		}
+		node["data"] = nodeData
+	}
+	return node
+}
+
+// buildLeafNodeDeltaPayload builds a payload containing the leaf node without its rows, and the delta to rebuild them
+func buildLeafNodeDeltaPayload(event *dashboardevents.LeafNodeUpdated, delta *LeafNodeRowsDelta) ([]byte, error) {
+	payload := LeafNodeDeltaPayload{
+		SchemaVersion: fmt.Sprintf("%d", LeafNodeDeltaSchemaVersion),
+		Action:        "leaf_node_delta",
+		DashboardNode: leafNodeWithoutRows(event.LeafNode),
+		Delta:         delta,
+		ExecutionId:   event.ExecutionId,
+		Timestamp:     event.Timestamp,
+	}
+	return json.Marshal(payload)
+}
+
+// buildLeafNodeUpdatedPayloadForSession builds the payload for a leaf node update
+// if the rows of the node have been streamed to the client, the node is sent without its rows
+// otherwise, if delta updates are enabled and the client has previously been sent rows for the node, the payload
+// contains only the changes to the rows (if a delta is not feasible, the full node is sent)
+func (s *Server) buildLeafNodeUpdatedPayloadForSession(event *dashboardevents.LeafNodeUpdated) ([]byte, error) {
+	name, _ := event.LeafNode["name"].(string)
+	streamed := s.takeStreamedLeafRows(event.Session, name)
+	if !s.deltaUpdates {
+		if streamed {
+			return buildStreamedLeafNodeUpdatedPayload(event)
+		}
+		return buildLeafNodeUpdatedPayload(event)
+	}
+	current, ok := newLeafRows(event.LeafNode)
+	if !ok || name == "" {
+		return buildLeafNodeUpdatedPayload(event)
+	}
+
+	previous := s.swapSentLeafRows(event.Session, name, current)
+	if streamed {
+		return buildStreamedLeafNodeUpdatedPayload(event)
+	}
+	if previous != nil {
+		if delta, ok := diffLeafRows(previous, current); ok {
+			return buildLeafNodeDeltaPayload(event, delta)
+		}
+	}
+	return buildLeafNodeUpdatedPayload(event)
+}
+
+// swapSentLeafRows records the rows sent to a session for a leaf node, returning the rows previously sent
+func (s *Server) swapSentLeafRows(sessionId, name string, rows *leafRows) *leafRows {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sessionInfo, ok := s.dashboardClients[sessionId]
+	if !ok {
+		return nil
+	}
+	if sessionInfo.sentLeafRows == nil {
+		sessionInfo.sentLeafRows = make(map[string]*leafRows)
+	}
+	previous := sessionInfo.sentLeafRows[name]
+	sessionInfo.sentLeafRows[name] = rows
+	return previous
+}
+
+// setStreamedLeafRows records that the rows of a leaf node have been streamed to a session
+func (s *Server) setStreamedLeafRows(sessionId, name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sessionInfo, ok := s.dashboardClients[sessionId]
+	if !ok {
+		return
+	}
+	if sessionInfo.streamedLeafRows == nil {
+		sessionInfo.streamedLeafRows = make(map[string]struct{})
+	}
+	sessionInfo.streamedLeafRows[name] = struct{}{}
+}
+
+// takeStreamedLeafRows returns whether the rows of a leaf node have been streamed to a session,
+// clearing the record so the next update of the node is sent with its rows unless they are streamed again
+func (s *Server) takeStreamedLeafRows(sessionId, name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sessionInfo, ok := s.dashboardClients[sessionId]
+	if !ok {
+		return false
+	}
+	_, streamed := sessionInfo.streamedLeafRows[name]
+	delete(sessionInfo.streamedLeafRows, name)
+	return streamed
+}
+
+// shouldStreamLeafRows returns whether row batches should be streamed to the session for a leaf node
+// when delta updates are enabled, rows are not streamed for nodes whose rows have previously been sent,
+// as the client will be sent a delta when the node is complete
+func (s *Server) shouldStreamLeafRows(sessionId, name string) bool {
+	if !s.deltaUpdates {
+		return true
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if sessionInfo, ok := s.dashboardClients[sessionId]; ok {
+		_, sent := sessionInfo.sentLeafRows[name]
+		return !sent
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+package dashboardserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
+)
+
+// applyLeafNodeRowsDelta rebuilds the current rows from the previous rows and a delta, as a client does
+func applyLeafNodeRowsDelta(previous []map[string]any, delta *LeafNodeRowsDelta) []map[string]any {
+	dropped := make(map[string]struct{})
+	for _, key := range delta.Removed {
+		dropped[rowKey(key)] = struct{}{}
+	}
+	upserted := make(map[int]map[string]any)
+	for _, r := range delta.Upserted {
+		dropped[rowKey(r.Row[delta.KeyColumn])] = struct{}{}
+		upserted[r.Index] = r.Row
+	}
+	var remaining []map[string]any
+	for _, row := range previous {
+		if _, ok := dropped[rowKey(row[delta.KeyColumn])]; !ok {
+			remaining = append(remaining, row)
+		}
+	}
+	res := make([]map[string]any, delta.RowCount)
+	for i := range res {
+		if row, ok := upserted[i]; ok {
+			res[i] = row
+			continue
+		}
+		res[i], remaining = remaining[0], remaining[1:]
+	}
+	return res
+}
+
+// newTestLeafNode returns a serialised leaf node with the given rows
+// the node is round tripped through json, as leaf node update events are
+func newTestLeafNode(t *testing.T, rows []map[string]any) map[string]any {
+	node := map[string]any{
+		"name":       "dashboard.table",
+		"panel_type": "table",
+		"status":     "complete",
+		"data": map[string]any{
+			"columns": []map[string]any{{"name": "id", "data_type": "INT8"}, {"name": "status", "data_type": "TEXT"}},
+			"rows":    rows,
+		},
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res map[string]any
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func newTestRows(count int) []map[string]any {
+	rows := make([]map[string]any, count)
+	for i := range rows {
+		rows[i] = map[string]any{"id": i, "status": fmt.Sprintf("instance %d is running normally", i)}
+	}
+	return rows
+}
+
+func TestLeafNodeDelta(t *testing.T) {
+	previousRows := newTestRows(100)
+	currentRows := newTestRows(100)
+	// change one row, remove one row and add one row
+	currentRows[10]["status"] = "stopped"
+	currentRows = append(currentRows[:50], currentRows[51:]...)
+	currentRows = append(currentRows, map[string]any{"id": 100, "status": "pending"})
+
+	previousNode := newTestLeafNode(t, previousRows)
+	currentNode := newTestLeafNode(t, currentRows)
+	previous, _ := newLeafRows(previousNode)
+	current, _ := newLeafRows(currentNode)
+
+	delta, ok := diffLeafRows(previous, current)
+	if !ok {
+		t.Fatal("expected a delta")
+	}
+	if len(delta.Upserted) != 2 || len(delta.Removed) != 1 {
+		t.Errorf("expected 2 upserted rows and 1 removed row, got %d upserted and %d removed", len(delta.Upserted), len(delta.Removed))
+	}
+
+	// the delta payload is much smaller than the full payload
+	event := &dashboardevents.LeafNodeUpdated{LeafNode: currentNode, Session: "session", ExecutionId: "execution", Timestamp: time.Now()}
+	fullPayload, err := buildLeafNodeUpdatedPayload(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deltaPayload, err := buildLeafNodeDeltaPayload(event, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deltaPayload)*10 > len(fullPayload) {
+		t.Errorf("expected the delta payload (%d bytes) to be less than a tenth of the full payload (%d bytes)", len(deltaPayload), len(fullPayload))
+	}
+	// building the delta payload does not modify the event
+	if _, ok := newLeafRows(event.LeafNode); !ok || len(event.LeafNode["data"].(map[string]any)["rows"].([]any)) != len(currentRows) {
+		t.Errorf("expected the event rows to be unchanged")
+	}
+
+	// the client can rebuild the full rows from the delta sent
+	var sent LeafNodeDeltaPayload
+	if err := json.Unmarshal(deltaPayload, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sent.DashboardNode["data"].(map[string]any)["rows"]; ok {
+		t.Errorf("expected the delta payload not to include the rows")
+	}
+	if rebuilt := applyLeafNodeRowsDelta(previous.rows, sent.Delta); !reflect.DeepEqual(rebuilt, current.rows) {
+		t.Errorf("expected the rebuilt rows to match the current rows")
+	}
+}
+
+func TestLeafNodeDeltaFallback(t *testing.T) {
+	reordered := newTestRows(10)
+	reordered[0], reordered[9] = reordered[9], reordered[0]
+	noKey := newTestRows(10)
+	for _, row := range noKey {
+		row["id"] = 1
+		row["status"] = "running"
+	}
+	changedColumns := newTestLeafNode(t, newTestRows(10))
+	changedColumns["data"].(map[string]any)["columns"] = []any{map[string]any{"name": "id", "data_type": "TEXT"}}
+
+	testCases := map[string]struct {
+		previous    map[string]any
+		current     map[string]any
+		expectDelta bool
+	}{
+		"unchanged":       {newTestLeafNode(t, newTestRows(10)), newTestLeafNode(t, newTestRows(10)), true},
+		"row added":       {newTestLeafNode(t, newTestRows(10)), newTestLeafNode(t, newTestRows(11)), true},
+		"too many rows":   {newTestLeafNode(t, newTestRows(10)), newTestLeafNode(t, newTestRows(25)), false},
+		"reordered":       {newTestLeafNode(t, newTestRows(10)), newTestLeafNode(t, reordered), false},
+		"no key column":   {newTestLeafNode(t, noKey), newTestLeafNode(t, noKey), false},
+		"changed columns": {newTestLeafNode(t, newTestRows(10)), changedColumns, false},
+	}
+	for name, test := range testCases {
+		previous, _ := newLeafRows(test.previous)
+		current, _ := newLeafRows(test.current)
+		delta, ok := diffLeafRows(previous, current)
+		if ok != test.expectDelta {
+			t.Errorf("Test: '%s' FAILED : expected delta %v, got %v", name, test.expectDelta, ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(applyLeafNodeRowsDelta(previous.rows, delta), current.rows) {
+			t.Errorf("Test: '%s' FAILED : expected the rebuilt rows to match the current rows", name)
+		}
+	}
+}
+
+func TestLeafNodeUpdatedPayloadForSession(t *testing.T) {
+	server := &Server{
+		mutex:            &sync.Mutex{},
+		dashboardClients: map[string]*DashboardClientInfo{"session": {}},
+		deltaUpdates:     true,
+	}
+	send := func(rows []map[string]any) string {
+		payload, err := server.buildLeafNodeUpdatedPayloadForSession(&dashboardevents.LeafNodeUpdated{
+			LeafNode: newTestLeafNode(t, rows),
+			Session:  "session",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res struct {
+			Action string `json:"action"`
+		}
+		if err := json.Unmarshal(payload, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Action
+	}
+
+	// rows are streamed, and the full node sent, until the rows have been sent once
+	if !server.shouldStreamLeafRows("session", "dashboard.table") {
+		t.Errorf("expected rows to be streamed before they have been sent")
+	}
+	if action := send(newTestRows(10)); action != "leaf_node_updated" {
+		t.Errorf("expected the first update to be sent in full, got '%s'", action)
+	}
+	if server.shouldStreamLeafRows("session", "dashboard.table") {
+		t.Errorf("expected rows not to be streamed once they have been sent")
+	}
+	if action := send(newTestRows(11)); action != "leaf_node_delta" {
+		t.Errorf("expected a subsequent update to be sent as a delta, got '%s'", action)
+	}
+
+	// with delta updates disabled, the full node is always sent
+	server.deltaUpdates = false
+	if action := send(newTestRows(12)); action != "leaf_node_updated" {
+		t.Errorf("expected the update to be sent in full when delta updates are disabled, got '%s'", action)
+	}
+}
+
+func TestStreamedLeafNodeUpdatedPayload(t *testing.T) {
+	for _, deltaUpdates := range []bool{false, true} {
+		server := &Server{
+			mutex:            &sync.Mutex{},
+			dashboardClients: map[string]*DashboardClientInfo{"session": {}},
+			deltaUpdates:     deltaUpdates,
+		}
+		send := func(rows []map[string]any) LeafNodeUpdatedPayload {
+			payload, err := server.buildLeafNodeUpdatedPayloadForSession(&dashboardevents.LeafNodeUpdated{
+				LeafNode: newTestLeafNode(t, rows),
+				Session:  "session",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res LeafNodeUpdatedPayload
+			if err := json.Unmarshal(payload, &res); err != nil {
+				t.Fatal(err)
+			}
+			return res
+		}
+		hasRows := func(payload LeafNodeUpdatedPayload) bool {
+			data, _ := payload.DashboardNode["data"].(map[string]any)
+			_, ok := data["rows"]
+			return ok
+		}
+
+		// once the rows have been streamed, the update is sent without them
+		server.setStreamedLeafRows("session", "dashboard.table")
+		if payload := send(newTestRows(10)); payload.Action != "leaf_node_updated" || !payload.RowsStreamed || hasRows(payload) {
+			t.Errorf("Test: deltaUpdates=%v FAILED : expected the update to be sent without its streamed rows, got %+v", deltaUpdates, payload)
+		}
+		// the next update is sent with its rows (or as a delta), as they have not been streamed
+		if payload := send(newTestRows(10)); payload.RowsStreamed {
+			t.Errorf("Test: deltaUpdates=%v FAILED : expected the rows of an update which were not streamed to be sent", deltaUpdates)
+		} else if !deltaUpdates && !hasRows(payload) {
+			t.Errorf("Test: deltaUpdates=%v FAILED : expected the update to include its rows", deltaUpdates)
+		}
+	}
+}
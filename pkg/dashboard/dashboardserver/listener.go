@@ -0,0 +1,56 @@
+package dashboardserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+)
+
+// newListener creates the TCP listener for the dashboard server, using the configured listen type (or host) and port
+// if the port is 0, the OS will select a free port - the chosen port may be retrieved from the listener address
+func newListener() (net.Listener, error) {
+	port := ListenPort(viper.GetInt(constants.ArgDashboardPort))
+	host := ListenType(viper.GetString(constants.ArgDashboardListen)).Host()
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, fmt.Sprintf("%d", port)))
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return nil, PortInUseError(port)
+		}
+		return nil, err
+	}
+	return listener, nil
+}
+
+// PortInUseError builds an error for a dashboard port which is already bound,
+// including a hint as to what may be holding the port
+func PortInUseError(port ListenPort) error {
+	hint := fmt.Sprintf("another process may be listening on it - use '--%s 0' to select a free port", constants.ArgDashboardPort)
+	// if the dashboard service is running on this port, it is the most likely culprit
+	if state, _ := GetDashboardServiceState(); state != nil && state.Port == int(port) {
+		hint = fmt.Sprintf("it is being used by the Steampipe dashboard service (pid %d) - run 'steampipe service stop' or use '--%s 0' to select a free port", state.Pid, constants.ArgDashboardPort)
+	}
+	return fmt.Errorf("port %d is already in use: %s", port, hint)
+}
+
+// serverURL returns the URL for browsing to a server listening on the given address
+// if the server is listening on all interfaces, localhost is used
+func serverURL(addr net.Addr, secure bool) string {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return ""
+	}
+	host := "localhost"
+	if tcpAddr.IP != nil && !tcpAddr.IP.IsUnspecified() && !tcpAddr.IP.IsLoopback() {
+		host = tcpAddr.IP.String()
+	}
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, fmt.Sprintf("%d", tcpAddr.Port)))
+}
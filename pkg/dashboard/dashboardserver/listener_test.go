@@ -0,0 +1,69 @@
+package dashboardserver
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+)
+
+func TestServerAddrForAutoPort(t *testing.T) {
+	viper.Set(constants.ArgDashboardPort, 0)
+	viper.Set(constants.ArgDashboardListen, string(ListenTypeLocal))
+	defer viper.Reset()
+
+	// before the server is started there is no address
+	s := &Server{}
+	if s.Addr() != nil || s.Port() != 0 || s.URL() != "" {
+		t.Fatalf("expected no address before the server is started")
+	}
+
+	listener, err := newListener()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.listener = listener
+	defer listener.Close()
+
+	go func() {
+		_ = http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	}()
+
+	if s.Port() == 0 {
+		t.Fatalf("expected a port to be chosen by the OS")
+	}
+	expectedURL := fmt.Sprintf("http://localhost:%d", s.Port())
+	if s.URL() != expectedURL {
+		t.Errorf("expected URL %s, got %s", expectedURL, s.URL())
+	}
+
+	// the address must be connectable
+	res, err := http.Get(s.URL())
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %s", s.URL(), err)
+	}
+	res.Body.Close()
+}
+
+func TestServerURL(t *testing.T) {
+	testCases := map[string]struct {
+		addr     net.Addr
+		secure   bool
+		expected string
+	}{
+		"loopback":    {&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9194}, false, "http://localhost:9194"},
+		"unspecified": {&net.TCPAddr{IP: net.IPv6unspecified, Port: 9194}, false, "http://localhost:9194"},
+		"ipv4 host":   {&net.TCPAddr{IP: net.IPv4(10, 0, 0, 5), Port: 9194}, false, "http://10.0.0.5:9194"},
+		"ipv6 host":   {&net.TCPAddr{IP: net.ParseIP("fd00::1"), Port: 9194}, false, "http://[fd00::1]:9194"},
+		"tls":         {&net.TCPAddr{IP: net.IPv4(10, 0, 0, 5), Port: 9194}, true, "https://10.0.0.5:9194"},
+		"non tcp":     {&net.UnixAddr{Name: "/tmp/dashboard.sock", Net: "unix"}, false, ""},
+	}
+	for name, test := range testCases {
+		if actual := serverURL(test.addr, test.secure); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, actual)
+		}
+	}
+}
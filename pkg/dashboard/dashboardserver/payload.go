@@ -78,7 +78,19 @@ func addBenchmarkChildren(benchmark *modconfig.Benchmark, recordTrunk bool, trun
 }
 
 func buildAvailableDashboardsPayload(workspaceResources *modconfig.ResourceMaps) ([]byte, error) {
+	return json.Marshal(availableDashboards(workspaceResources))
+}
+
+func buildAvailableWorkspacesPayload(workspaces []WorkspacePayload, current string) ([]byte, error) {
+	return json.Marshal(AvailableWorkspacesPayload{
+		Action:     "available_workspaces",
+		Workspaces: workspaces,
+		Current:    current,
+	})
+}
 
+// availableDashboards returns the dashboards and benchmarks of the workspace (including those of dependency mods)
+func availableDashboards(workspaceResources *modconfig.ResourceMaps) AvailableDashboardsPayload {
 	payload := AvailableDashboardsPayload{
 		Action:     "available_dashboards",
 		Dashboards: make(map[string]ModAvailableDashboard),
@@ -145,6 +157,22 @@ func buildAvailableDashboardsPayload(workspaceResources *modconfig.ResourceMaps)
 		}
 	}
 
+	return payload
+}
+
+func buildPermalinkPayload(action string, p *Permalink) ([]byte, error) {
+	return json.Marshal(PermalinkPayload{
+		Action:    action,
+		Permalink: p,
+		URL:       p.URL(),
+	})
+}
+
+func buildSchemaUpdatedPayload(connections *SchemaUpdatedConnections) ([]byte, error) {
+	payload := SchemaUpdatedPayload{
+		Action:      "schema_updated",
+		Connections: connections,
+	}
 	return json.Marshal(payload)
 }
 
@@ -191,6 +219,64 @@ func buildLeafNodeUpdatedPayload(event *dashboardevents.LeafNodeUpdated) ([]byte
 	return json.Marshal(payload)
 }
 
+// buildStreamedLeafNodeUpdatedPayload builds the payload for a leaf node whose rows have been streamed to the client
+// the node is sent without its rows, so the rows are not sent twice
+func buildStreamedLeafNodeUpdatedPayload(event *dashboardevents.LeafNodeUpdated) ([]byte, error) {
+	payload := LeafNodeUpdatedPayload{
+		SchemaVersion: fmt.Sprintf("%d", LeafNodeUpdatedSchemaVersion),
+		Action:        "leaf_node_updated",
+		DashboardNode: leafNodeWithoutRows(event.LeafNode),
+		RowsStreamed:  true,
+		ExecutionId:   event.ExecutionId,
+		Timestamp:     event.Timestamp,
+	}
+	return json.Marshal(payload)
+}
+
+// buildLeafNodeRowsPayloads builds the payloads for a batch of leaf node rows
+// if maxSize is set, the batch is split into as many payloads as are needed for each to fit within it
+// (only the final payload is marked as complete)
+func buildLeafNodeRowsPayloads(event *dashboardevents.LeafNodeRows, maxSize int) ([][]byte, error) {
+	payload := LeafNodeRowsPayload{
+		SchemaVersion: fmt.Sprintf("%d", LeafNodeRowsSchemaVersion),
+		Action:        "leaf_node_rows",
+		DashboardNode: event.Name,
+		Columns:       event.Columns,
+		Rows:          event.Rows,
+		Complete:      event.Complete,
+		ExecutionId:   event.ExecutionId,
+		Timestamp:     event.Timestamp,
+	}
+	return splitLeafNodeRowsPayload(payload, maxSize)
+}
+
+func splitLeafNodeRowsPayload(payload LeafNodeRowsPayload, maxSize int) ([][]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	// a single row which exceeds the maximum size cannot be split further
+	// - it will be replaced by a truncation notice when it is sent
+	if maxSize <= 0 || len(data) <= maxSize || len(payload.Rows) <= 1 {
+		return [][]byte{data}, nil
+	}
+
+	mid := len(payload.Rows) / 2
+	first, second := payload, payload
+	first.Rows, first.Complete = payload.Rows[:mid], false
+	second.Rows = payload.Rows[mid:]
+
+	firstPayloads, err := splitLeafNodeRowsPayload(first, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	secondPayloads, err := splitLeafNodeRowsPayload(second, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return append(firstPayloads, secondPayloads...), nil
+}
+
 func buildExecutionStartedPayload(event *dashboardevents.ExecutionStarted) ([]byte, error) {
 	payload := ExecutionStartedPayload{
 		SchemaVersion: fmt.Sprintf("%d", ExecutionStartedSchemaVersion),
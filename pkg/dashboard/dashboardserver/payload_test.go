@@ -0,0 +1,65 @@
+package dashboardserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
+)
+
+func TestBuildLeafNodeRowsPayloads(t *testing.T) {
+	rows := make([]map[string]any, 20)
+	for i := range rows {
+		rows[i] = map[string]any{"id": i, "value": "some row data"}
+	}
+
+	testCases := map[string]struct {
+		maxSize          int
+		complete         bool
+		expectedPayloads int
+	}{
+		"no limit":               {0, true, 1},
+		"within limit":           {100000, true, 1},
+		"split to fit":           {500, true, 0},
+		"split incomplete batch": {500, false, 0},
+	}
+	for name, test := range testCases {
+		payloads, err := buildLeafNodeRowsPayloads(&dashboardevents.LeafNodeRows{
+			Name:     "dashboard.table",
+			Rows:     rows,
+			Complete: test.complete,
+		}, test.maxSize)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err.Error())
+			continue
+		}
+		if test.expectedPayloads > 0 && len(payloads) != test.expectedPayloads {
+			t.Errorf("Test: '%s' FAILED : expected %d payloads, got %d", name, test.expectedPayloads, len(payloads))
+		}
+
+		// every row is sent, in order, and only the final payload may be marked as complete
+		var sentRows int
+		for i, data := range payloads {
+			if test.maxSize > 0 && len(data) > test.maxSize {
+				t.Errorf("Test: '%s' FAILED : payload size %d exceeds the maximum of %d", name, len(data), test.maxSize)
+			}
+			var payload LeafNodeRowsPayload
+			if err := json.Unmarshal(data, &payload); err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range payload.Rows {
+				if int(row["id"].(float64)) != sentRows {
+					t.Errorf("Test: '%s' FAILED : expected row %d, got %v", name, sentRows, row["id"])
+				}
+				sentRows++
+			}
+			expectComplete := test.complete && i == len(payloads)-1
+			if payload.Complete != expectComplete {
+				t.Errorf("Test: '%s' FAILED : expected payload %d complete to be %v", name, i, expectComplete)
+			}
+		}
+		if sentRows != len(rows) {
+			t.Errorf("Test: '%s' FAILED : expected %d rows to be sent, got %d", name, len(rows), sentRows)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package dashboardserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	filehelpers "github.com/turbot/go-kit/files"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardexecute"
+	"golang.org/x/exp/maps"
+	"gopkg.in/olahol/melody.v1"
+)
+
+// the route a permalink is opened with - this redirects to the dashboard URL, with the inputs as query parameters
+const permalinkRoutePrefix = "/permalink/"
+
+// errPermalinkNotFound is returned if a requested permalink does not exist
+var errPermalinkNotFound = errors.New("permalink not found")
+
+// Permalink is the saved state of a dashboard - the dashboard and the values of its inputs
+// this allows the state to be shared and re-opened with identical inputs
+type Permalink struct {
+	Id        string         `json:"id"`
+	Workspace string         `json:"workspace,omitempty"`
+	Dashboard string         `json:"dashboard"`
+	Inputs    map[string]any `json:"inputs,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// URL returns the path of the dashboard with the input values (and workspace) as query parameters
+// the query parameters are those read by the dashboard UI, e.g. /aws_insights.dashboard.vpc?input.vpc_id=vpc-123
+func (p *Permalink) URL() string {
+	query := url.Values{}
+	for name, value := range p.Inputs {
+		query.Set(name, permalinkInputValue(value))
+	}
+	if p.Workspace != "" {
+		query.Set(workspaceQueryParam, p.Workspace)
+	}
+	res := "/" + url.PathEscape(p.Dashboard)
+	if len(query) > 0 {
+		res += "?" + query.Encode()
+	}
+	return res
+}
+
+// permalinkInputValue returns the query parameter value of an input - string values are used as is,
+// other values are JSON encoded
+func permalinkInputValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
+
+// PermalinkPayload is sent to a dashboard client in response to a create_permalink request, and before a dashboard is
+// executed for a select_dashboard request which specifies a permalink
+type PermalinkPayload struct {
+	Action    string     `json:"action"`
+	Permalink *Permalink `json:"permalink"`
+	URL       string     `json:"url"`
+}
+
+// permalinkStore stores permalinks, keyed by id
+// if the store has a path, permalinks are persisted to it, so they remain valid when the server is restarted
+type permalinkStore struct {
+	path       string
+	permalinks map[string]*Permalink
+	mut        sync.RWMutex
+}
+
+// newPermalinkStore creates a permalink store, loading the permalinks saved at the path (if any)
+func newPermalinkStore(path string) *permalinkStore {
+	s := &permalinkStore{
+		path:       path,
+		permalinks: make(map[string]*Permalink),
+	}
+	if err := s.load(); err != nil {
+		log.Printf("[WARN] failed to load dashboard permalinks from %s: %s", path, err.Error())
+	}
+	return s
+}
+
+// load reads the permalinks saved at the store path, merging them with the permalinks in memory
+// the caller must hold the lock
+func (s *permalinkStore) load() error {
+	if s.path == "" || !filehelpers.FileExists(s.path) {
+		return nil
+	}
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var permalinks []*Permalink
+	if err := json.Unmarshal(content, &permalinks); err != nil {
+		return err
+	}
+	for _, p := range permalinks {
+		s.permalinks[p.Id] = p
+	}
+	return nil
+}
+
+// save writes the permalinks to the store path - the caller must hold the lock
+// the store file may be shared by several dashboard servers, so it is locked and re-read before writing,
+// to merge the permalinks created by other servers
+func (s *permalinkStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	unlock, err := lockPermalinkFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	permalinks := make([]*Permalink, 0, len(s.permalinks))
+	for _, p := range s.permalinks {
+		permalinks = append(permalinks, p)
+	}
+	sort.Slice(permalinks, func(i, j int) bool { return permalinks[i].CreatedAt.Before(permalinks[j].CreatedAt) })
+
+	content, err := json.MarshalIndent(permalinks, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file and rename, so a failed write does not lose existing permalinks
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// create saves the state of a dashboard as a new permalink
+// inputs with no value (e.g. dependent inputs which have been cleared) are not saved
+func (s *permalinkStore) create(workspaceName, dashboardName string, inputs map[string]any) (*Permalink, error) {
+	id, err := newPermalinkId()
+	if err != nil {
+		return nil, err
+	}
+	p := &Permalink{
+		Id:        id,
+		Workspace: workspaceName,
+		Dashboard: dashboardName,
+		CreatedAt: time.Now().UTC(),
+	}
+	for name, value := range inputs {
+		if value == nil {
+			continue
+		}
+		if p.Inputs == nil {
+			p.Inputs = make(map[string]any)
+		}
+		p.Inputs[name] = value
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.permalinks[id] = p
+	if err := s.save(); err != nil {
+		delete(s.permalinks, id)
+		return nil, fmt.Errorf("failed to save permalink: %s", err.Error())
+	}
+	return p, nil
+}
+
+// get returns the permalink with the given id
+// if it is not in memory, the store is reloaded, as the permalink may have been created by another server
+func (s *permalinkStore) get(id string) (*Permalink, bool) {
+	s.mut.RLock()
+	p, ok := s.permalinks[id]
+	s.mut.RUnlock()
+	if ok || s.path == "" {
+		return p, ok
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if err := s.load(); err != nil {
+		log.Printf("[WARN] failed to load dashboard permalinks from %s: %s", s.path, err.Error())
+	}
+	p, ok = s.permalinks[id]
+	return p, ok
+}
+
+// newPermalinkId returns a random id - this is shorter than the ids used for sessions, as it appears in shared URLs
+func newPermalinkId() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// createPermalink saves the state of the dashboard a client is viewing as a permalink
+// the input values are those the dashboard is executing with, falling back to the values last sent by the client
+func (s *Server) createPermalink(sessionId, workspaceName string) (*Permalink, error) {
+	dashboardName, inputs, ok := dashboardexecute.Executor.GetInputValues(sessionId)
+	if !ok {
+		dashboardClientInfo, found := s.getDashboardClients()[sessionId]
+		if !found || dashboardClientInfo.Dashboard == nil {
+			return nil, errors.New("no dashboard selected")
+		}
+		dashboardName, inputs = *dashboardClientInfo.Dashboard, dashboardClientInfo.DashboardInputs
+	}
+	if workspaceName == "" {
+		workspaceName = s.workspaces.defaultName()
+	}
+	return s.permalinks.create(workspaceName, dashboardName, inputs)
+}
+
+// getPermalink returns the permalink with the given id, verifying its workspace is served by the server
+func (s *Server) getPermalink(id string) (*Permalink, error) {
+	p, ok := s.permalinks.get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", errPermalinkNotFound, id)
+	}
+	if _, ok := s.workspaces.get(p.Workspace); !ok {
+		return nil, fmt.Errorf("%w: '%s'", errWorkspaceNotFound, p.Workspace)
+	}
+	return p, nil
+}
+
+// permalinkLoader returns the permalink with the given id
+type permalinkLoader func(id string) (*Permalink, error)
+
+// permalinkHandler handles GET /api/permalinks/{id}
+func permalinkHandler(loadPermalink permalinkLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, err := loadPermalink(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, ErrorPayload{Action: "get_permalink", Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, PermalinkPayload{Action: "permalink", Permalink: p, URL: p.URL()})
+	}
+}
+
+// permalinkRedirectHandler handles GET /permalink/{id}, redirecting to the dashboard URL of the permalink
+// an unknown permalink redirects to the dashboard list
+func permalinkRedirectHandler(loadPermalink permalinkLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		target := "/"
+		if p, err := loadPermalink(c.Param("id")); err == nil {
+			target = p.URL()
+		} else {
+			log.Printf("[TRACE] failed to open permalink: %s", err.Error())
+		}
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+		c.Redirect(http.StatusFound, target)
+	}
+}
+
+// selectPermalink opens a permalink for a dashboard client - the client is switched to the workspace of the permalink
+// (if necessary), sent the permalink, and the dashboard is executed with the saved input values
+func (s *Server) selectPermalink(ctx context.Context, session *melody.Session, id string) error {
+	p, err := s.getPermalink(id)
+	if err != nil {
+		return err
+	}
+	workspaceName := p.Workspace
+	// (permalinks saved without a workspace open in the default workspace)
+	if workspaceName == "" {
+		workspaceName = s.workspaces.defaultName()
+	}
+	if workspaceName != s.sessionWorkspaceName(session) {
+		if err := s.selectWorkspace(ctx, session, workspaceName); err != nil {
+			return err
+		}
+	}
+	w := s.sessionWorkspace(session)
+
+	sessionId := s.getSessionId(session)
+	payload, err := buildPermalinkPayload("permalink", p)
+	if err != nil {
+		return err
+	}
+	s.writePayloadToSession(sessionId, payload)
+
+	// the executor clears dependent inputs in place - copy the inputs so the saved values are unchanged
+	inputs := maps.Clone(p.Inputs)
+	s.setDashboardForSession(sessionId, p.Dashboard, inputs)
+	// execution errors are sent to the client as execution_error messages
+	_ = dashboardexecute.Executor.ExecuteDashboard(ctx, sessionId, p.Dashboard, inputs, w, s.dbClient)
+	return nil
+}
+
+// writePermalinkError sends a permalink_error message to a dashboard client
+func (s *Server) writePermalinkError(sessionId string, err error) {
+	payload, payloadErr := json.Marshal(ErrorPayload{Action: "permalink_error", Error: err.Error()})
+	if payloadErr != nil {
+		panic(fmt.Errorf("error building payload for permalink_error: %v", payloadErr))
+	}
+	s.writePayloadToSession(sessionId, payload)
+}
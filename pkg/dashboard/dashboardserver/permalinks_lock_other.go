@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package dashboardserver
+
+// lockPermalinkFile is not supported on this platform - concurrent dashboard servers sharing a permalink store
+// may overwrite each other's permalinks
+func lockPermalinkFile(string) (func(), error) {
+	return func() {}, nil
+}
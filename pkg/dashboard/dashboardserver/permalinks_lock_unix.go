@@ -0,0 +1,27 @@
+//go:build linux || darwin
+
+package dashboardserver
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockPermalinkFile takes an exclusive lock on the lock file of the permalink store at the given path,
+// so concurrent dashboard servers sharing the store do not overwrite each other's permalinks
+// the returned function releases the lock
+func lockPermalinkFile(path string) (func(), error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		f.Close()
+	}, nil
+}
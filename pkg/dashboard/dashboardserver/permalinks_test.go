@@ -0,0 +1,145 @@
+package dashboardserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPermalinkURL(t *testing.T) {
+	type permalinkURLTest struct {
+		permalink Permalink
+		expected  string
+	}
+	tests := map[string]permalinkURLTest{
+		"no inputs": {
+			Permalink{Dashboard: "m.dashboard.d1"},
+			"/m.dashboard.d1",
+		},
+		"string inputs": {
+			Permalink{Dashboard: "m.dashboard.d1", Inputs: map[string]any{"input.region": "us-east-1", "input.vpc": "vpc 1"}},
+			"/m.dashboard.d1?input.region=us-east-1&input.vpc=vpc+1",
+		},
+		"non string input": {
+			Permalink{Dashboard: "m.dashboard.d1", Inputs: map[string]any{"input.regions": []any{"a", "b"}}},
+			"/m.dashboard.d1?input.regions=%5B%22a%22%2C%22b%22%5D",
+		},
+		"workspace": {
+			Permalink{Workspace: "other", Dashboard: "other.dashboard.d2", Inputs: map[string]any{"input.a": "1"}},
+			"/other.dashboard.d2?input.a=1&workspace=other",
+		},
+	}
+	for name, test := range tests {
+		if res := test.permalink.URL(); res != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, res)
+		}
+	}
+}
+
+func TestPermalinkStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "permalinks.json")
+	store := newPermalinkStore(path)
+
+	p, err := store.create("other", "other.dashboard.d2", map[string]any{"input.a": "1", "input.cleared": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Id == "" {
+		t.Fatalf("expected the permalink to have an id")
+	}
+	if _, ok := p.Inputs["input.cleared"]; ok {
+		t.Errorf("expected inputs with no value not to be saved, got %v", p.Inputs)
+	}
+	if second, err := store.create("", "m.dashboard.d1", nil); err != nil {
+		t.Fatal(err)
+	} else if second.Id == p.Id {
+		t.Errorf("expected permalinks to have unique ids")
+	}
+
+	// a new store loads the saved permalinks
+	reloaded, ok := newPermalinkStore(path).get(p.Id)
+	if !ok {
+		t.Fatalf("expected the permalink to be persisted")
+	}
+	if reloaded.Workspace != "other" || reloaded.Dashboard != "other.dashboard.d2" || reloaded.Inputs["input.a"] != "1" {
+		t.Errorf("expected the persisted permalink to match %+v, got %+v", p, reloaded)
+	}
+	if _, ok := store.get("unknown"); ok {
+		t.Errorf("expected an unknown permalink not to be found")
+	}
+
+	// stores sharing a path do not overwrite each other's permalinks
+	other := newPermalinkStore(path)
+	fromOther, err := other.create("", "m.dashboard.d3", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromStore, err := store.create("", "m.dashboard.d4", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.get(fromOther.Id); !ok {
+		t.Errorf("expected a permalink created by another store to be found")
+	}
+	merged := newPermalinkStore(path)
+	for _, id := range []string{p.Id, fromOther.Id, fromStore.Id} {
+		if _, ok := merged.get(id); !ok {
+			t.Errorf("expected permalink %s to be persisted", id)
+		}
+	}
+}
+
+func TestPermalinkEndpoints(t *testing.T) {
+	store := newPermalinkStore("")
+	p, err := store.create("", "m.dashboard.d1", map[string]any{"input.a": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadPermalink := func(id string) (*Permalink, error) {
+		if p, ok := store.get(id); ok {
+			return p, nil
+		}
+		return nil, errPermalinkNotFound
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/permalinks/:id", permalinkHandler(loadPermalink))
+	router.GET(permalinkRoutePrefix+":id", permalinkRedirectHandler(loadPermalink))
+
+	type permalinkEndpointTest struct {
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}
+	tests := map[string]permalinkEndpointTest{
+		"get":              {"/api/permalinks/" + p.Id, http.StatusOK, ""},
+		"get unknown":      {"/api/permalinks/unknown", http.StatusNotFound, ""},
+		"redirect":         {permalinkRoutePrefix + p.Id, http.StatusFound, "/m.dashboard.d1?input.a=1"},
+		"redirect unknown": {permalinkRoutePrefix + "unknown", http.StatusFound, "/"},
+	}
+	for name, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.expectedStatus {
+			t.Errorf("Test: '%s' FAILED : expected status %d, got %d", name, test.expectedStatus, w.Code)
+			continue
+		}
+		if test.expectedLocation != "" && w.Header().Get("Location") != test.expectedLocation {
+			t.Errorf("Test: '%s' FAILED : expected redirect to %s, got %s", name, test.expectedLocation, w.Header().Get("Location"))
+		}
+		if w.Code == http.StatusOK {
+			var payload PermalinkPayload
+			if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+				t.Fatal(err)
+			}
+			if payload.Permalink.Id != p.Id || payload.URL != p.URL() {
+				t.Errorf("Test: '%s' FAILED : unexpected payload %+v", name, payload)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package dashboardserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+// workspaceReloader is a function which re-parses the mod of the named workspace, returning the reloaded resources
+type workspaceReloader func(ctx context.Context, workspaceName string) (*modconfig.ResourceMaps, error)
+
+type ReloadPayload struct {
+	Action string `json:"action"`
+	Status string `json:"status"`
+}
+
+// reloadWorkspace re-parses the mod of the named workspace
+func (s *Server) reloadWorkspace(ctx context.Context, workspaceName string) (*modconfig.ResourceMaps, error) {
+	w, ok := s.workspaces.get(workspaceName)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", errWorkspaceNotFound, workspaceName)
+	}
+	resourceMaps, errAndWarnings := w.Reload(ctx)
+	return resourceMaps, errAndWarnings.GetError()
+}
+
+// Reload re-parses the mod of each workspace and broadcasts the dashboard definitions to the clients of the workspace
+// if a mod fails to parse, the error is broadcast to clients as a workspace error and returned
+// - the server continues to serve the previously loaded mod
+func (s *Server) Reload(ctx context.Context) error {
+	var errors []error
+	for _, workspaceName := range s.workspaces.list() {
+		errors = append(errors, s.reloadNamedWorkspace(ctx, workspaceName))
+	}
+	return error_helpers.CombineErrors(errors...)
+}
+
+func (s *Server) reloadNamedWorkspace(ctx context.Context, workspaceName string) error {
+	log.Printf("[TRACE] reloading workspace %s", workspaceName)
+	resourceMaps, err := s.reloader(ctx, workspaceName)
+	if err != nil {
+		log.Printf("[WARN] failed to reload workspace %s: %s", workspaceName, err.Error())
+		payload, payloadErr := buildWorkspaceErrorPayload(&dashboardevents.WorkspaceError{Error: err})
+		if payloadErr != nil {
+			return payloadErr
+		}
+		s.broadcastToWorkspace(workspaceName, payload)
+		OutputError(ctx, err)
+		return err
+	}
+
+	var cloudMetadata *steampipeconfig.CloudMetadata
+	if w, ok := s.workspaces.get(workspaceName); ok && w != nil {
+		cloudMetadata = w.CloudMetadata
+	}
+	// emit dashboard metadata in case the mod has changed - else the UI won't know about it
+	payload, err := buildDashboardMetadataPayload(resourceMaps, cloudMetadata)
+	if err != nil {
+		return err
+	}
+	s.broadcastToWorkspace(workspaceName, payload)
+
+	payload, err = buildAvailableDashboardsPayload(resourceMaps)
+	if err != nil {
+		return err
+	}
+	s.broadcastToWorkspace(workspaceName, payload)
+
+	OutputMessage(ctx, fmt.Sprintf("Workspace reloaded: %s", workspaceName))
+	return nil
+}
+
+// listenForReloadSignal reloads the workspace whenever the process receives SIGHUP, until the context is cancelled
+// NOTE: this is only done in service mode - when running interactively, SIGHUP indicates the terminal has closed
+func (s *Server) listenForReloadSignal(ctx context.Context) {
+	if !viper.GetBool(constants.ArgServiceMode) {
+		return
+	}
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(signalCh)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signalCh:
+				log.Println("[INFO] SIGHUP received - reloading workspace")
+				// any error is sent to clients by Reload
+				_ = s.Reload(ctx)
+			}
+		}
+	}()
+}
+
+func reloadHandler(reload func(context.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := reload(c.Request.Context()); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, ErrorPayload{Action: "reload", Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, ReloadPayload{Action: "reload", Status: "ok"})
+	}
+}
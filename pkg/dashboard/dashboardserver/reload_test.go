@@ -0,0 +1,111 @@
+package dashboardserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"gopkg.in/olahol/melody.v1"
+)
+
+// build the resource maps for an in-memory mod containing the given dashboards
+func newTestModResources(dashboardNames ...string) *modconfig.ResourceMaps {
+	mod := modconfig.NewMod("test", "", hcl.Range{})
+	for _, name := range dashboardNames {
+		dashboard := modconfig.NewDashboard(&hcl.Block{Type: modconfig.BlockTypeDashboard}, mod, name).(*modconfig.Dashboard)
+		mod.ResourceMaps.Dashboards[dashboard.FullName] = dashboard
+	}
+	return mod.ResourceMaps
+}
+
+func TestReloadBroadcastsDashboards(t *testing.T) {
+	// the mod is changed in memory before the reload
+	resourceMaps := newTestModResources("original")
+	var reloadErr error
+	s := &Server{
+		mutex:            &sync.Mutex{},
+		dashboardClients: make(map[string]*DashboardClientInfo),
+		webSocket:        melody.New(),
+		workspaces:       newServerWorkspaces(),
+		reloader: func(context.Context, string) (*modconfig.ResourceMaps, error) {
+			return resourceMaps, reloadErr
+		},
+	}
+	// the workspace is not loaded - the reloader returns its resources
+	if err := s.workspaces.add("test", nil); err != nil {
+		t.Fatal(err)
+	}
+	connected := make(chan struct{})
+	s.webSocket.HandleConnect(func(*melody.Session) { close(connected) })
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = s.webSocket.HandleRequest(w, r)
+	}))
+	defer httpServer.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for client to connect")
+	}
+
+	// read messages until one with the given action is received
+	readAction := func(action string) []byte {
+		_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			_, msg, err := client.ReadMessage()
+			if err != nil {
+				t.Fatalf("expected client to receive '%s' message: %s", action, err)
+			}
+			var payload struct {
+				Action string `json:"action"`
+			}
+			if err := json.Unmarshal(msg, &payload); err != nil {
+				t.Fatal(err)
+			}
+			if payload.Action == action {
+				return msg
+			}
+		}
+	}
+
+	// change the mod and reload
+	resourceMaps = newTestModResources("changed")
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected reload error: %s", err)
+	}
+	var available AvailableDashboardsPayload
+	if err := json.Unmarshal(readAction("available_dashboards"), &available); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := available.Dashboards["test.dashboard.changed"]; !ok || len(available.Dashboards) != 1 {
+		t.Errorf("expected clients to receive the reloaded dashboards, got %v", available.Dashboards)
+	}
+
+	// a parse failure must be sent to clients rather than stopping the server
+	reloadErr = errors.New("failed to parse mod")
+	if err := s.Reload(context.Background()); err == nil {
+		t.Fatal("expected reload to return the parse error")
+	}
+	var errorPayload ErrorPayload
+	if err := json.Unmarshal(readAction("workspace_error"), &errorPayload); err != nil {
+		t.Fatal(err)
+	}
+	if errorPayload.Error != reloadErr.Error() {
+		t.Errorf("expected workspace error '%s', got '%s'", reloadErr, errorPayload.Error)
+	}
+}
@@ -0,0 +1,250 @@
+package dashboardserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardexecute"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+// the prefix of the session ids of dashboard executions requested using the REST API
+// (these executions have no websocket client - their events are delivered to the waiting request)
+const apiSessionPrefix = "api:"
+
+// errDashboardNotFound is returned by a dashboardRunner if the requested dashboard does not exist
+var errDashboardNotFound = errors.New("dashboard not found")
+
+// errInvalidDashboardRun is returned by a dashboardRunner if the dashboard could not be executed with the given
+// inputs - e.g. an input the dashboard depends on was not provided
+var errInvalidDashboardRun = errors.New("invalid dashboard run")
+
+// dashboardLister returns the dashboards and benchmarks of the named workspace (the default workspace if the name is empty)
+type dashboardLister func(workspaceName string) (AvailableDashboardsPayload, error)
+
+// dashboardRunner executes a dashboard (or benchmark) of the named workspace to completion, returning a snapshot of the result
+// if the workspace name is empty, the dashboard of the default workspace is executed
+type dashboardRunner func(ctx context.Context, workspaceName, dashboardName string, inputs map[string]any) (*dashboardtypes.SteampipeSnapshot, error)
+
+// DashboardListPayload is the response of GET /api/dashboards
+type DashboardListPayload struct {
+	Dashboards map[string]ModAvailableDashboard `json:"dashboards"`
+	Benchmarks map[string]ModAvailableBenchmark `json:"benchmarks"`
+}
+
+// DashboardRunRequest is the (optional) body of POST /api/dashboards/{name}/run
+type DashboardRunRequest struct {
+	// the values of the dashboard inputs, keyed by input name (e.g. "input.region")
+	Inputs map[string]any `json:"inputs"`
+}
+
+// DashboardRunPayload is the response of POST /api/dashboards/{name}/run
+// the snapshot contains the execution tree of the dashboard (layout) and the data of each panel (panels)
+type DashboardRunPayload struct {
+	Workspace string                            `json:"workspace,omitempty"`
+	Dashboard string                            `json:"dashboard"`
+	Title     string                            `json:"title,omitempty"`
+	Snapshot  *dashboardtypes.SteampipeSnapshot `json:"snapshot"`
+}
+
+// apiRuns tracks the dashboard executions requested using the REST API, keyed by session id
+type apiRuns struct {
+	results map[string]chan dashboardevents.DashboardEvent
+	mut     sync.Mutex
+}
+
+func newAPIRuns() *apiRuns {
+	return &apiRuns{results: make(map[string]chan dashboardevents.DashboardEvent)}
+}
+
+// add registers an execution, returning the channel its completion (or error) event is sent to
+func (r *apiRuns) add(sessionId string) chan dashboardevents.DashboardEvent {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	// buffered so the event handler never blocks, even if the request has gone away
+	result := make(chan dashboardevents.DashboardEvent, 1)
+	r.results[sessionId] = result
+	return result
+}
+
+func (r *apiRuns) remove(sessionId string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	delete(r.results, sessionId)
+}
+
+// handleEvent delivers completion and error events to the execution which raised them
+// it returns false if the event is not for an API execution
+func (r *apiRuns) handleEvent(event dashboardevents.DashboardEvent) bool {
+	sessionId := eventSession(event)
+	if !strings.HasPrefix(sessionId, apiSessionPrefix) {
+		return false
+	}
+	switch event.(type) {
+	case *dashboardevents.ExecutionComplete, *dashboardevents.ExecutionError:
+		r.mut.Lock()
+		result, ok := r.results[sessionId]
+		r.mut.Unlock()
+		if ok {
+			select {
+			case result <- event:
+			default:
+			}
+		}
+	}
+	return true
+}
+
+// eventSession returns the session of an execution event (empty for workspace events)
+func eventSession(event dashboardevents.DashboardEvent) string {
+	switch e := event.(type) {
+	case *dashboardevents.ExecutionStarted:
+		return e.Session
+	case *dashboardevents.ExecutionComplete:
+		return e.Session
+	case *dashboardevents.ExecutionError:
+		return e.Session
+	case *dashboardevents.ControlComplete:
+		return e.Session
+	case *dashboardevents.ControlError:
+		return e.Session
+	case *dashboardevents.LeafNodeUpdated:
+		return e.Session
+	case *dashboardevents.LeafNodeRows:
+		return e.Session
+	case *dashboardevents.InputValuesCleared:
+		return e.Session
+	}
+	return ""
+}
+
+// listDashboards returns the dashboards and benchmarks of the named workspace
+func (s *Server) listDashboards(workspaceName string) (AvailableDashboardsPayload, error) {
+	w, ok := s.workspaces.get(workspaceName)
+	if !ok {
+		return AvailableDashboardsPayload{}, errWorkspaceNotFound
+	}
+	return availableDashboards(w.GetResourceMaps()), nil
+}
+
+// dashboardExists returns whether the named workspace has a dashboard (or benchmark) with the given name
+func (s *Server) dashboardExists(workspaceName, name string) bool {
+	w, ok := s.workspaces.get(workspaceName)
+	if !ok {
+		return false
+	}
+	parsedName, err := modconfig.ParseResourceName(name)
+	if err != nil || parsedName.ItemType == "" {
+		return false
+	}
+	_, found := w.GetResource(parsedName)
+	return found
+}
+
+// runDashboard executes a dashboard for an API client, waiting for the execution to complete
+// if the context is cancelled (e.g. the client disconnects) the execution is cancelled
+func (s *Server) runDashboard(ctx context.Context, workspaceName, dashboardName string, inputs map[string]any) (*dashboardtypes.SteampipeSnapshot, error) {
+	w, ok := s.workspaces.get(workspaceName)
+	if !ok {
+		return nil, errWorkspaceNotFound
+	}
+	if !s.dashboardExists(workspaceName, dashboardName) {
+		return nil, errDashboardNotFound
+	}
+
+	id, err := randomId()
+	if err != nil {
+		return nil, err
+	}
+	sessionId := apiSessionPrefix + id
+	result := s.apiRuns.add(sessionId)
+	defer s.apiRuns.remove(sessionId)
+	// remove the execution once it has completed (or cancel it if it has not)
+	defer dashboardexecute.Executor.CancelExecutionForSession(ctx, sessionId)
+
+	if err := dashboardexecute.Executor.ExecuteDashboardBatch(ctx, sessionId, dashboardName, inputs, w, s.dbClient); err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidDashboardRun, err.Error())
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case event := <-result:
+		switch e := event.(type) {
+		case *dashboardevents.ExecutionError:
+			return nil, e.Error
+		case *dashboardevents.ExecutionComplete:
+			return dashboardexecute.ExecutionCompleteToSnapshot(e), nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected dashboard event")
+}
+
+// dashboardListHandler handles GET /api/dashboards
+func dashboardListHandler(listDashboards dashboardLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		available, err := listDashboards(c.Query(workspaceQueryParam))
+		if err != nil {
+			c.JSON(http.StatusNotFound, ErrorPayload{Action: "list_dashboards", Error: err.Error()})
+			return
+		}
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+		c.JSON(http.StatusOK, DashboardListPayload{Dashboards: available.Dashboards, Benchmarks: available.Benchmarks})
+	}
+}
+
+// dashboardRunHandler handles POST /api/dashboards/{name}/run
+// a dashboard run may take longer than the server write timeout, so the write deadline is cleared for the request -
+// the run is cancelled if the client disconnects
+func dashboardRunHandler(run dashboardRunner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("[TRACE] could not clear the write deadline of the dashboard run request: %s", err.Error())
+		}
+
+		dashboardName := c.Param("name")
+		workspaceName := c.Query(workspaceQueryParam)
+
+		var request DashboardRunRequest
+		if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, ErrorPayload{Action: "run_dashboard", Error: fmt.Sprintf("invalid request body: %s", err.Error())})
+			return
+		}
+
+		// run queries using the role of the authenticated session (if any)
+		ctx := c.Request.Context()
+		if session := authSessionFromContext(c); session != nil && session.role != "" {
+			ctx = db_common.AddSessionRoleToContext(ctx, session.role)
+		}
+
+		snapshot, err := run(ctx, workspaceName, dashboardName, request.Inputs)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, errDashboardNotFound), errors.Is(err, errWorkspaceNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, errInvalidDashboardRun):
+				status = http.StatusBadRequest
+			case c.Request.Context().Err() != nil:
+				// the client has gone away - there is no one to respond to
+				log.Printf("[TRACE] dashboard run of %s cancelled: %s", dashboardName, err.Error())
+				return
+			}
+			c.JSON(status, ErrorPayload{Action: "run_dashboard", Error: err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, DashboardRunPayload{Workspace: workspaceName, Dashboard: dashboardName, Title: snapshot.Title, Snapshot: snapshot})
+	}
+}
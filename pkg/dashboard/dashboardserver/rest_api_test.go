@@ -0,0 +1,180 @@
+package dashboardserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+)
+
+func restAPIRouter(run dashboardRunner) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/workspaces", workspaceListHandler(func() []WorkspacePayload {
+		return []WorkspacePayload{{Name: "m", Path: "/mods/m", Default: true}, {Name: "other", Path: "/mods/other"}}
+	}))
+	router.GET("/api/dashboards", dashboardListHandler(func(workspaceName string) (AvailableDashboardsPayload, error) {
+		switch workspaceName {
+		case "", "m":
+			return AvailableDashboardsPayload{
+				Dashboards: map[string]ModAvailableDashboard{
+					"m.dashboard.d1": {Title: "D1", FullName: "m.dashboard.d1", ShortName: "d1", ModFullName: "mod.m"},
+				},
+				Benchmarks: map[string]ModAvailableBenchmark{},
+				Snapshots:  map[string]string{"snap": "snap.sps"},
+			}, nil
+		case "other":
+			return AvailableDashboardsPayload{
+				Dashboards: map[string]ModAvailableDashboard{
+					"other.dashboard.d2": {Title: "D2", FullName: "other.dashboard.d2", ShortName: "d2", ModFullName: "mod.other"},
+				},
+			}, nil
+		}
+		return AvailableDashboardsPayload{}, errWorkspaceNotFound
+	}))
+	router.POST("/api/dashboards/:name/run", dashboardRunHandler(run))
+	return router
+}
+
+func TestDashboardListEndpoint(t *testing.T) {
+	router := restAPIRouter(nil)
+
+	type listTest struct {
+		url               string
+		expectedStatus    int
+		expectedDashboard string
+	}
+	tests := map[string]listTest{
+		"default workspace": {"/api/dashboards", http.StatusOK, "m.dashboard.d1"},
+		"named workspace":   {"/api/dashboards?workspace=other", http.StatusOK, "other.dashboard.d2"},
+		"unknown workspace": {"/api/dashboards?workspace=unknown", http.StatusNotFound, ""},
+	}
+	for name, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.url, nil))
+		if w.Code != test.expectedStatus {
+			t.Errorf("Test: '%s' FAILED : expected status %d, got %d", name, test.expectedStatus, w.Code)
+			continue
+		}
+		if test.expectedDashboard == "" {
+			continue
+		}
+		var payload DashboardListPayload
+		if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := payload.Dashboards[test.expectedDashboard]; !ok || len(payload.Dashboards) != 1 {
+			t.Errorf("Test: '%s' FAILED : expected dashboard %s to be listed, got %+v", name, test.expectedDashboard, payload.Dashboards)
+		}
+	}
+}
+
+func TestWorkspaceListEndpoint(t *testing.T) {
+	router := restAPIRouter(nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/workspaces", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var payload WorkspaceListPayload
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Workspaces) != 2 || payload.Workspaces[0].Name != "m" || !payload.Workspaces[0].Default {
+		t.Errorf("unexpected workspaces %+v", payload.Workspaces)
+	}
+}
+
+func TestDashboardRunEndpoint(t *testing.T) {
+	var runInputs map[string]any
+	run := func(_ context.Context, workspaceName, dashboardName string, inputs map[string]any) (*dashboardtypes.SteampipeSnapshot, error) {
+		runInputs = inputs
+		if workspaceName != "" {
+			return nil, errWorkspaceNotFound
+		}
+		switch dashboardName {
+		case "m.dashboard.d1":
+			return &dashboardtypes.SteampipeSnapshot{SchemaVersion: "20221222", Title: "D1"}, nil
+		case "m.dashboard.needs_input":
+			return nil, fmt.Errorf("%w: input 'input.region' must be provided", errInvalidDashboardRun)
+		case "m.dashboard.broken":
+			return nil, errors.New("query failed")
+		}
+		return nil, errDashboardNotFound
+	}
+	router := restAPIRouter(run)
+
+	type runTest struct {
+		name     string
+		body     string
+		expected int
+	}
+	tests := map[string]runTest{
+		"run":               {"m.dashboard.d1", `{"inputs": {"input.region": "us-east-1"}}`, http.StatusOK},
+		"no body":           {"m.dashboard.d1", "", http.StatusOK},
+		"invalid body":      {"m.dashboard.d1", `{"inputs": [}`, http.StatusBadRequest},
+		"missing input":     {"m.dashboard.needs_input", "", http.StatusBadRequest},
+		"unknown":           {"m.dashboard.unknown", "", http.StatusNotFound},
+		"execution error":   {"m.dashboard.broken", "", http.StatusInternalServerError},
+		"unknown workspace": {"m.dashboard.d1?workspace=unknown", "", http.StatusNotFound},
+	}
+	for name, test := range tests {
+		w := httptest.NewRecorder()
+		dashboardName, query, _ := strings.Cut(test.name, "?")
+		req := httptest.NewRequest(http.MethodPost, "/api/dashboards/"+dashboardName+"/run?"+query, strings.NewReader(test.body))
+		router.ServeHTTP(w, req)
+		if w.Code != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected status %d, got %d", name, test.expected, w.Code)
+		}
+	}
+
+	// check the inputs are passed to the runner and the snapshot is returned
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/dashboards/m.dashboard.d1/run", strings.NewReader(`{"inputs": {"input.region": "us-east-1"}}`)))
+	if runInputs["input.region"] != "us-east-1" {
+		t.Errorf("expected the inputs to be passed to the runner, got %v", runInputs)
+	}
+	var payload DashboardRunPayload
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Dashboard != "m.dashboard.d1" || payload.Title != "D1" || payload.Snapshot == nil || payload.Snapshot.SchemaVersion != "20221222" {
+		t.Errorf("unexpected run payload %+v", payload)
+	}
+}
+
+func TestAPIRunsHandleEvent(t *testing.T) {
+	runs := newAPIRuns()
+	result := runs.add(apiSessionPrefix + "1")
+
+	// events of websocket sessions are not handled
+	if runs.handleEvent(&dashboardevents.ExecutionComplete{Session: "0xc000123"}) {
+		t.Errorf("expected the event of a websocket session not to be handled")
+	}
+	// events of API sessions are handled, but only completion and error events are delivered
+	if !runs.handleEvent(&dashboardevents.LeafNodeUpdated{Session: apiSessionPrefix + "1"}) {
+		t.Errorf("expected the event of an API session to be handled")
+	}
+	if len(result) != 0 {
+		t.Errorf("expected a leaf node event not to be delivered")
+	}
+	complete := &dashboardevents.ExecutionComplete{Session: apiSessionPrefix + "1"}
+	runs.handleEvent(complete)
+	if event := <-result; event != complete {
+		t.Errorf("expected the completion event to be delivered")
+	}
+
+	// events for executions which are no longer waiting are dropped
+	runs.remove(apiSessionPrefix + "1")
+	if !runs.handleEvent(&dashboardevents.ExecutionComplete{Session: apiSessionPrefix + "1"}) || len(result) != 0 {
+		t.Errorf("expected the event of a removed execution to be dropped")
+	}
+}
@@ -0,0 +1,218 @@
+package dashboardserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/export"
+)
+
+// the timeout for posting a scheduled run to the webhook
+const scheduleWebhookTimeout = 30 * time.Second
+
+// ScheduleDeliveryOptions configure how the snapshots of scheduled runs are delivered
+type ScheduleDeliveryOptions struct {
+	// the formats the snapshot is exported to - snapshot (sps), html or pdf
+	Exports []string
+	// the directory exported files are written to
+	OutputDir string
+	// if set, the snapshot is POSTed to this URL as JSON
+	Webhook string
+	// if set, exported files are uploaded to this S3 location - s3://<bucket>/<prefix>
+	S3 string
+}
+
+// ScheduledRunPayload is the body POSTed to the webhook when a scheduled run completes
+type ScheduledRunPayload struct {
+	Action    string                            `json:"action"`
+	Dashboard string                            `json:"dashboard"`
+	Title     string                            `json:"title,omitempty"`
+	Files     []string                          `json:"files,omitempty"`
+	Snapshot  *dashboardtypes.SteampipeSnapshot `json:"snapshot"`
+}
+
+// s3Uploader uploads a file to S3
+type s3Uploader interface {
+	upload(ctx context.Context, bucket, key, contentType string, body io.Reader) error
+}
+
+// awsS3Uploader uploads to S3 using the default AWS credential chain (environment, shared config or instance role)
+type awsS3Uploader struct {
+	uploader *s3manager.Uploader
+}
+
+func (u *awsS3Uploader) upload(ctx context.Context, bucket, key, contentType string, body io.Reader) error {
+	_, err := u.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        body,
+	})
+	return err
+}
+
+// scheduleDeliverer exports the snapshots of scheduled runs, and delivers them to the webhook and S3
+type scheduleDeliverer struct {
+	opts      ScheduleDeliveryOptions
+	exporters []export.Exporter
+	client    *http.Client
+	s3Bucket  string
+	s3Prefix  string
+	uploader  s3Uploader
+}
+
+func newScheduleDeliverer(opts ScheduleDeliveryOptions) (*scheduleDeliverer, error) {
+	d := &scheduleDeliverer{
+		opts:   opts,
+		client: &http.Client{Timeout: scheduleWebhookTimeout},
+	}
+
+	available := []export.Exporter{&export.SnapshotExporter{}, &export.HtmlExporter{}, &export.PdfExporter{}}
+	for _, format := range opts.Exports {
+		format = strings.TrimSpace(format)
+		var exporter export.Exporter
+		for _, e := range available {
+			if format == e.Name() || (e.Alias() != "" && format == e.Alias()) {
+				exporter = e
+			}
+		}
+		if exporter == nil {
+			return nil, fmt.Errorf("invalid --%s format '%s', must be one of [snapshot, html, pdf]", constants.ArgDashboardScheduleExport, format)
+		}
+		d.exporters = append(d.exporters, exporter)
+	}
+
+	if opts.Webhook != "" {
+		webhook, err := url.Parse(opts.Webhook)
+		if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+			return nil, fmt.Errorf("invalid --%s '%s': must be an http or https URL", constants.ArgDashboardScheduleWebhook, opts.Webhook)
+		}
+	}
+
+	if opts.S3 != "" {
+		location, err := url.Parse(opts.S3)
+		if err != nil || location.Scheme != "s3" || location.Host == "" {
+			return nil, fmt.Errorf("invalid --%s '%s': must be of the form s3://<bucket>/<prefix>", constants.ArgDashboardScheduleS3, opts.S3)
+		}
+		if len(d.exporters) == 0 {
+			return nil, fmt.Errorf("--%s requires at least one --%s format", constants.ArgDashboardScheduleS3, constants.ArgDashboardScheduleExport)
+		}
+		d.s3Bucket = location.Host
+		d.s3Prefix = strings.Trim(location.Path, "/")
+
+		sess, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create an AWS session for S3 uploads: %s", err.Error())
+		}
+		d.uploader = &awsS3Uploader{uploader: s3manager.NewUploader(sess)}
+	}
+	return d, nil
+}
+
+// deliver exports the snapshot of a scheduled run, uploads the exported files to S3 and posts the snapshot to the webhook
+// every delivery is attempted, even if an earlier one fails
+func (d *scheduleDeliverer) deliver(ctx context.Context, dashboardName string, snapshot *dashboardtypes.SteampipeSnapshot) error {
+	snapshot.FileNameRoot = dashboardName
+
+	files, err := d.export(ctx, dashboardName, snapshot)
+	errors := []error{err}
+
+	if d.uploader != nil {
+		for _, file := range files {
+			errors = append(errors, d.uploadToS3(ctx, file))
+		}
+	}
+
+	if d.opts.Webhook != "" {
+		payload := ScheduledRunPayload{
+			Action:    "scheduled_run",
+			Dashboard: dashboardName,
+			Title:     snapshot.Title,
+			Files:     files,
+			Snapshot:  snapshot,
+		}
+		errors = append(errors, d.postToWebhook(ctx, payload))
+	}
+	return error_helpers.CombineErrors(errors...)
+}
+
+// export writes the snapshot in each of the export formats, returning the paths of the files written
+func (d *scheduleDeliverer) export(ctx context.Context, dashboardName string, snapshot *dashboardtypes.SteampipeSnapshot) ([]string, error) {
+	if len(d.exporters) == 0 {
+		return nil, nil
+	}
+	if d.opts.OutputDir != "" {
+		if err := os.MkdirAll(d.opts.OutputDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	var files []string
+	var errors []error
+	for _, exporter := range d.exporters {
+		file := filepath.Join(d.opts.OutputDir, export.GenerateDefaultExportFileName(dashboardName, exporter.FileExtension()))
+		if err := exporter.Export(ctx, snapshot, file); err != nil {
+			errors = append(errors, fmt.Errorf("failed to export %s: %s", exporter.Name(), err.Error()))
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, error_helpers.CombineErrors(errors...)
+}
+
+func (d *scheduleDeliverer) uploadToS3(ctx context.Context, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	key := path.Join(d.s3Prefix, filepath.Base(file))
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if err := d.uploader.upload(ctx, d.s3Bucket, key, contentType, f); err != nil {
+		return fmt.Errorf("failed to upload %s to s3://%s/%s: %s", filepath.Base(file), d.s3Bucket, key, err.Error())
+	}
+	return nil
+}
+
+func (d *scheduleDeliverer) postToWebhook(ctx context.Context, payload ScheduledRunPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.opts.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
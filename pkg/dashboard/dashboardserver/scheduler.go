@@ -0,0 +1,174 @@
+package dashboardserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// ScheduleOptions configure the scheduled dashboard runs of the dashboard server
+type ScheduleOptions struct {
+	// the schedules, each of the form '<dashboard>=<cron expression>'
+	Schedules []string
+	// the input values used for scheduled runs
+	Inputs   map[string]any
+	Delivery ScheduleDeliveryOptions
+}
+
+// ScheduleOptionsFromConfig returns the configured schedule options, using the given inputs for all scheduled runs
+func ScheduleOptionsFromConfig(inputs map[string]any) ScheduleOptions {
+	return ScheduleOptions{
+		Schedules: viper.GetStringSlice(constants.ArgDashboardSchedule),
+		Inputs:    inputs,
+		Delivery: ScheduleDeliveryOptions{
+			Exports:   viper.GetStringSlice(constants.ArgDashboardScheduleExport),
+			OutputDir: viper.GetString(constants.ArgDashboardScheduleOutputDir),
+			Webhook:   viper.GetString(constants.ArgDashboardScheduleWebhook),
+			S3:        viper.GetString(constants.ArgDashboardScheduleS3),
+		},
+	}
+}
+
+// dashboardSchedule is a dashboard which is run on a cron schedule
+type dashboardSchedule struct {
+	dashboard string
+	cron      string
+	schedule  *cronSchedule
+}
+
+// parseDashboardSchedule parses a schedule of the form '<dashboard>=<cron expression>'
+func parseDashboardSchedule(s string) (*dashboardSchedule, error) {
+	dashboard, cron, ok := strings.Cut(s, "=")
+	dashboard = strings.TrimSpace(dashboard)
+	if !ok || dashboard == "" {
+		return nil, fmt.Errorf("invalid --%s '%s': expected '<dashboard>=<cron expression>'", constants.ArgDashboardSchedule, s)
+	}
+	schedule, err := parseCron(cron)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s for %s: %s", constants.ArgDashboardSchedule, dashboard, err.Error())
+	}
+	return &dashboardSchedule{dashboard: dashboard, cron: strings.TrimSpace(cron), schedule: schedule}, nil
+}
+
+// scheduler runs dashboards headlessly on their schedules, delivering the resulting snapshots
+type scheduler struct {
+	schedules []*dashboardSchedule
+	inputs    map[string]any
+	run       dashboardRunner
+	deliverer *scheduleDeliverer
+	now       func() time.Time
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// newScheduler returns the scheduler for the given options - nil is returned if there are no schedules
+// the dashboards of the schedules are verified using dashboardExists, so a typo fails the server start
+// rather than the first scheduled run
+func newScheduler(opts ScheduleOptions, run dashboardRunner, dashboardExists func(string) bool) (*scheduler, error) {
+	if len(opts.Schedules) == 0 {
+		return nil, nil
+	}
+	s := &scheduler{
+		inputs: opts.Inputs,
+		run:    run,
+		now:    time.Now,
+	}
+	for _, scheduleArg := range opts.Schedules {
+		schedule, err := parseDashboardSchedule(scheduleArg)
+		if err != nil {
+			return nil, err
+		}
+		if !dashboardExists(schedule.dashboard) {
+			return nil, fmt.Errorf("invalid --%s: dashboard '%s' not found", constants.ArgDashboardSchedule, schedule.dashboard)
+		}
+		s.schedules = append(s.schedules, schedule)
+	}
+	deliverer, err := newScheduleDeliverer(opts.Delivery)
+	if err != nil {
+		return nil, err
+	}
+	s.deliverer = deliverer
+	return s, nil
+}
+
+// Start runs each schedule until the context is cancelled or Stop is called
+func (s *scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, schedule := range s.schedules {
+		OutputMessage(ctx, fmt.Sprintf("Scheduled %s (%s)", schedule.dashboard, schedule.cron))
+		s.wg.Add(1)
+		go func(schedule *dashboardSchedule) {
+			defer s.wg.Done()
+			s.runSchedule(ctx, schedule)
+		}(schedule)
+	}
+}
+
+// Stop stops the schedules, cancelling any run in progress, and waits for them to finish
+func (s *scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// runSchedule runs a dashboard each time its schedule is due
+// runs of a schedule never overlap - if a run overruns the next due time, that run is skipped
+func (s *scheduler) runSchedule(ctx context.Context, schedule *dashboardSchedule) {
+	for {
+		next := schedule.schedule.Next(s.now())
+		if next.IsZero() {
+			log.Printf("[WARN] schedule '%s' for %s never runs", schedule.cron, schedule.dashboard)
+			return
+		}
+		log.Printf("[TRACE] next scheduled run of %s at %s", schedule.dashboard, next.Format(time.RFC3339))
+
+		timer := time.NewTimer(next.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.runOnce(ctx, schedule.dashboard)
+	}
+}
+
+// runOnce runs a dashboard and delivers the resulting snapshot
+func (s *scheduler) runOnce(ctx context.Context, dashboardName string) {
+	OutputWait(ctx, fmt.Sprintf("Scheduled run started: %s", dashboardName))
+	// scheduled runs are of dashboards in the default workspace
+	snapshot, err := s.run(ctx, "", dashboardName, s.inputs)
+	if err != nil {
+		if ctx.Err() == nil {
+			OutputError(ctx, fmt.Errorf("scheduled run of %s failed: %s", dashboardName, err.Error()))
+		}
+		return
+	}
+	if err := s.deliverer.deliver(ctx, dashboardName, snapshot); err != nil {
+		OutputError(ctx, fmt.Errorf("failed to deliver scheduled run of %s: %s", dashboardName, err.Error()))
+		return
+	}
+	outputReady(ctx, fmt.Sprintf("Scheduled run complete: %s%s", dashboardName, panelErrorSummary(snapshot)))
+}
+
+// panelErrorSummary returns a summary of the panels of the snapshot which failed (empty if none failed)
+func panelErrorSummary(snapshot *dashboardtypes.SteampipeSnapshot) string {
+	data, err := dashboardtypes.NewDashboardData(snapshot)
+	if err != nil {
+		return ""
+	}
+	failed := len(data.PanelErrors())
+	if failed == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d %s failed)", failed, utils.Pluralize("panel", failed))
+}
@@ -0,0 +1,141 @@
+package dashboardserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+)
+
+func TestParseDashboardSchedule(t *testing.T) {
+	type scheduleTest struct {
+		arg               string
+		expectedDashboard string
+		expectedError     bool
+	}
+	tests := map[string]scheduleTest{
+		"valid":              {"aws.dashboard.report=0 6 * * *", "aws.dashboard.report", false},
+		"descriptor":         {"aws.dashboard.report=@daily", "aws.dashboard.report", false},
+		"whitespace":         {" aws.dashboard.report = */30 * * * * ", "aws.dashboard.report", false},
+		"no cron":            {"aws.dashboard.report", "", true},
+		"no dashboard":       {"=0 6 * * *", "", true},
+		"invalid expression": {"aws.dashboard.report=0 25 * * *", "", true},
+	}
+	for name, test := range tests {
+		schedule, err := parseDashboardSchedule(test.arg)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if schedule.dashboard != test.expectedDashboard {
+			t.Errorf("Test: '%s' FAILED : expected dashboard %s, got %s", name, test.expectedDashboard, schedule.dashboard)
+		}
+	}
+}
+
+func TestNewScheduler(t *testing.T) {
+	exists := func(name string) bool { return name == "m.dashboard.d1" }
+
+	type newSchedulerTest struct {
+		opts          ScheduleOptions
+		expectNil     bool
+		expectedError bool
+	}
+	tests := map[string]newSchedulerTest{
+		"no schedules":      {ScheduleOptions{}, true, false},
+		"valid":             {ScheduleOptions{Schedules: []string{"m.dashboard.d1=@hourly"}, Delivery: ScheduleDeliveryOptions{Exports: []string{"sps", "html"}}}, false, false},
+		"unknown dashboard": {ScheduleOptions{Schedules: []string{"m.dashboard.d2=@hourly"}}, true, true},
+		"invalid export":    {ScheduleOptions{Schedules: []string{"m.dashboard.d1=@hourly"}, Delivery: ScheduleDeliveryOptions{Exports: []string{"csv"}}}, true, true},
+		"invalid webhook":   {ScheduleOptions{Schedules: []string{"m.dashboard.d1=@hourly"}, Delivery: ScheduleDeliveryOptions{Webhook: "ftp://example.com"}}, true, true},
+		"invalid s3":        {ScheduleOptions{Schedules: []string{"m.dashboard.d1=@hourly"}, Delivery: ScheduleDeliveryOptions{Exports: []string{"sps"}, S3: "bucket/prefix"}}, true, true},
+		"s3 without export": {ScheduleOptions{Schedules: []string{"m.dashboard.d1=@hourly"}, Delivery: ScheduleDeliveryOptions{S3: "s3://bucket/prefix"}}, true, true},
+	}
+	for name, test := range tests {
+		s, err := newScheduler(test.opts, nil, exists)
+		if test.expectedError != (err != nil) {
+			t.Errorf("Test: '%s' FAILED : expected error %v, got %v", name, test.expectedError, err)
+		}
+		if test.expectNil != (s == nil) {
+			t.Errorf("Test: '%s' FAILED : expected nil scheduler %v, got %v", name, test.expectNil, s == nil)
+		}
+	}
+}
+
+type testUploader struct {
+	keys []string
+}
+
+func (u *testUploader) upload(_ context.Context, bucket, key, _ string, body io.Reader) error {
+	if _, err := io.ReadAll(body); err != nil {
+		return err
+	}
+	u.keys = append(u.keys, bucket+"/"+key)
+	return nil
+}
+
+func TestScheduleDelivery(t *testing.T) {
+	var received ScheduledRunPayload
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer webhook.Close()
+
+	outputDir := filepath.Join(t.TempDir(), "reports")
+	deliverer, err := newScheduleDeliverer(ScheduleDeliveryOptions{
+		Exports:   []string{"sps"},
+		OutputDir: outputDir,
+		Webhook:   webhook.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploader := &testUploader{}
+	deliverer.s3Bucket, deliverer.s3Prefix, deliverer.uploader = "reports", "nightly", uploader
+
+	snapshot := &dashboardtypes.SteampipeSnapshot{SchemaVersion: "20221222", Title: "D1"}
+	if err := deliverer.deliver(context.Background(), "m.dashboard.d1", snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// the snapshot is exported to the output dir
+	files, _ := os.ReadDir(outputDir)
+	if len(files) != 1 || !strings.HasPrefix(files[0].Name(), "m.dashboard.d1.") || filepath.Ext(files[0].Name()) != ".sps" {
+		t.Errorf("expected a snapshot file to be exported, got %v", files)
+	}
+	// the exported file is uploaded
+	if len(uploader.keys) != 1 || !strings.HasPrefix(uploader.keys[0], "reports/nightly/m.dashboard.d1.") {
+		t.Errorf("expected the snapshot file to be uploaded, got %v", uploader.keys)
+	}
+	// the snapshot is posted to the webhook
+	if received.Dashboard != "m.dashboard.d1" || received.Title != "D1" || len(received.Files) != 1 || received.Snapshot == nil {
+		t.Errorf("unexpected webhook payload %+v", received)
+	}
+
+	// a failing webhook is reported
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	deliverer, err = newScheduleDeliverer(ScheduleDeliveryOptions{Webhook: failing.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deliverer.deliver(context.Background(), "m.dashboard.d1", snapshot); err == nil {
+		t.Errorf("expected an error for a failing webhook")
+	}
+}
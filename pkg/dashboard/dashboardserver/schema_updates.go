@@ -0,0 +1,70 @@
+package dashboardserver
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// listenToSchemaUpdates registers a listener for the postgres notifications sent by the plugin manager
+// when a connection refresh completes, so connected clients may reload their schema metadata
+// NOTE: notifications are only received when connected to the local database
+func (s *Server) listenToSchemaUpdates() {
+	s.dbClient.RegisterNotificationListener(s.handlePostgresNotification)
+}
+
+func (s *Server) handlePostgresNotification(notification *pgconn.Notification) {
+	if notification == nil {
+		return
+	}
+	n := &steampipeconfig.SchemaUpdateNotification{}
+	if err := json.Unmarshal([]byte(notification.Payload), n); err != nil {
+		log.Printf("[WARN] Error unmarshalling notification: %s", err)
+		return
+	}
+	if n.Type != steampipeconfig.PgNotificationSchemaUpdate {
+		return
+	}
+	if !n.HasConnections() {
+		s.NotifySchemaUpdated()
+		return
+	}
+
+	// connections may have been added or removed - reload the search path used by dashboard sessions
+	s.reloadSearchPath(context.Background())
+	s.notifySchemaUpdated(&SchemaUpdatedConnections{
+		Updated: n.UpdatedConnections,
+		Deleted: n.DeletedConnections,
+	})
+}
+
+// reloadSearchPath reloads the user search path, and sets the search path of dashboard sessions
+func (s *Server) reloadSearchPath(ctx context.Context) {
+	if s.dbClient == nil {
+		return
+	}
+	if err := s.dbClient.LoadUserSearchPath(ctx); err != nil {
+		log.Printf("[WARN] failed to load the user search path after a connection refresh: %s", err.Error())
+	} else if err := s.dbClient.SetRequiredSessionSearchPath(ctx); err != nil {
+		log.Printf("[WARN] failed to set the session search path after a connection refresh: %s", err.Error())
+	}
+}
+
+// NotifySchemaUpdated sends a schema_updated message to all connected clients
+func (s *Server) NotifySchemaUpdated() {
+	s.notifySchemaUpdated(nil)
+}
+
+// notifySchemaUpdated sends a schema_updated message, listing the changed connections (if known), to all connected clients
+func (s *Server) notifySchemaUpdated(connections *SchemaUpdatedConnections) {
+	log.Println("[TRACE] schema updated - notifying clients")
+	payload, err := buildSchemaUpdatedPayload(connections)
+	if err != nil {
+		log.Printf("[WARN] failed to build schema updated payload: %s", err)
+		return
+	}
+	s.broadcast(payload)
+}
@@ -0,0 +1,84 @@
+package dashboardserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"gopkg.in/olahol/melody.v1"
+)
+
+func TestSchemaUpdateNotificationBroadcast(t *testing.T) {
+	s := &Server{
+		mutex:            &sync.Mutex{},
+		dashboardClients: make(map[string]*DashboardClientInfo),
+		webSocket:        melody.New(),
+	}
+	connected := make(chan struct{})
+	s.webSocket.HandleConnect(func(*melody.Session) { close(connected) })
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = s.webSocket.HandleRequest(w, r)
+	}))
+	defer httpServer.Close()
+
+	// connect a client
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for client to connect")
+	}
+
+	// simulate the notification sent by the plugin manager when a refresh completes
+	notificationPayload, err := json.Marshal(steampipeconfig.NewSchemaUpdateNotification())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.handlePostgresNotification(&pgconn.Notification{Payload: string(notificationPayload)})
+
+	_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, msg, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("expected client to receive a message: %s", err)
+	}
+	var payload SchemaUpdatedPayload
+	if err := json.Unmarshal(msg, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Action != "schema_updated" {
+		t.Errorf("expected action 'schema_updated', got '%s'", payload.Action)
+	}
+
+	// the connections changed by the refresh are forwarded to clients
+	notification := steampipeconfig.NewSchemaUpdateNotification()
+	notification.SetConnections([]string{"aws"}, []string{"gcp"})
+	notificationPayload, err = json.Marshal(notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.handlePostgresNotification(&pgconn.Notification{Payload: string(notificationPayload)})
+
+	_, msg, err = client.ReadMessage()
+	if err != nil {
+		t.Fatalf("expected client to receive a message: %s", err)
+	}
+	payload = SchemaUpdatedPayload{}
+	if err := json.Unmarshal(msg, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Connections == nil || len(payload.Connections.Updated) != 1 || len(payload.Connections.Deleted) != 1 {
+		t.Errorf("expected the changed connections to be forwarded, got %s", string(msg))
+	}
+}
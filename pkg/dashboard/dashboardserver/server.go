@@ -2,18 +2,23 @@ package dashboardserver
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"github.com/spf13/viper"
 	"github.com/turbot/go-kit/helpers"
 	typeHelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
 	"github.com/turbot/steampipe/pkg/dashboard/dashboardexecute"
 	"github.com/turbot/steampipe/pkg/db/db_common"
 	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/filepaths"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
 	"github.com/turbot/steampipe/pkg/workspace"
 	"gopkg.in/olahol/melody.v1"
 	"log"
+	"net"
 	"os"
 	"reflect"
 	"strings"
@@ -25,7 +30,25 @@ type Server struct {
 	mutex            *sync.Mutex
 	dashboardClients map[string]*DashboardClientInfo
 	webSocket        *melody.Melody
-	workspace        *workspace.Workspace
+	workspaces       *serverWorkspaces
+	// re-parses the workspace mod when a reload is requested
+	reloader workspaceReloader
+	// the maximum size of a message written to a client (0 means no limit)
+	maxMessageSize int
+	// the listener the API server is bound to - this is created by Start
+	listener net.Listener
+	// the tls config used to serve the API - this is nil if the server is not served over TLS
+	tlsConfig *tls.Config
+	// if set, leaf node rows are sent as the changes since the rows were last sent to the client
+	deltaUpdates bool
+	// authenticates clients - this is created by Start, and is nil if authentication is disabled
+	auth *authenticator
+	// the dashboard executions requested using the REST API
+	apiRuns *apiRuns
+	// runs dashboards on a schedule - this is created by StartScheduler, and is nil if there are no schedules
+	scheduler *scheduler
+	// the saved dashboard states which may be shared as permalinks
+	permalinks *permalinkStore
 }
 
 func NewServer(ctx context.Context, dbClient db_common.Client, w *workspace.Workspace) (*Server, error) {
@@ -33,7 +56,7 @@ func NewServer(ctx context.Context, dbClient db_common.Client, w *workspace.Work
 
 	OutputWait(ctx, "Starting Dashboard Server")
 
-	webSocket := melody.New()
+	webSocket := newWebSocket(messageBufferSizeFromConfig())
 
 	var dashboardClients = make(map[string]*DashboardClientInfo)
 
@@ -44,21 +67,87 @@ func NewServer(ctx context.Context, dbClient db_common.Client, w *workspace.Work
 		mutex:            mutex,
 		dashboardClients: dashboardClients,
 		webSocket:        webSocket,
-		workspace:        w,
+		workspaces:       newServerWorkspaces(),
+		maxMessageSize:   maxMessageSizeFromConfig(),
+		deltaUpdates:     deltaUpdatesFromConfig(),
+		apiRuns:          newAPIRuns(),
+		permalinks:       newPermalinkStore(filepaths.DashboardPermalinksFilePath()),
 	}
+	server.reloader = server.reloadWorkspace
 
-	w.RegisterDashboardEventHandler(ctx, server.HandleDashboardEvent)
-	err := w.SetupWatcher(ctx, dbClient, func(c context.Context, e error) {})
+	// the workspace the server is created with is the default workspace
+	err := server.AddWorkspace(ctx, "", w)
 	OutputMessage(ctx, "Workspace loaded")
 
 	return server, err
 }
 
-// Start starts the API server
+// Start binds the configured port and starts the API server
 // it returns a channel which is signalled when the API server terminates
-func (s *Server) Start(ctx context.Context) chan struct{} {
+// if the configured port is 0, a free port is chosen - this may be retrieved by calling Addr or Port once Start has returned
+func (s *Server) Start(ctx context.Context) (chan struct{}, error) {
+	tlsConfig, err := newTLSConfig(tlsOptionsFromConfig())
+	if err != nil {
+		return nil, err
+	}
+	s.tlsConfig = tlsConfig
+
+	auth, err := newAuthenticator(ctx, authOptionsFromConfig(), tlsConfig != nil)
+	if err != nil {
+		return nil, err
+	}
+	s.auth = auth
+	if auth == nil && ListenType(viper.GetString(constants.ArgDashboardListen)) == ListenTypeNetwork {
+		OutputWarning(ctx, fmt.Sprintf("The dashboard server is listening on the network without authentication - set --%s to restrict access", constants.ArgDashboardAuth))
+	}
+
+	listener, err := newListener()
+	if err != nil {
+		return nil, err
+	}
+	s.listener = listener
+
 	s.initAsync(ctx)
-	return startAPIAsync(ctx, s.webSocket)
+	s.listenForReloadSignal(ctx)
+	return startAPIAsync(ctx, s.webSocket, listener, tlsConfig, auth, s.loadConnectionState, s.Reload, s.listWorkspaces, s.listDashboards, s.runDashboard, s.getPermalink), nil
+}
+
+// StartScheduler starts running the scheduled dashboards (if any), until the server is shut down
+// scheduled runs are executed in the same way as runs requested using the REST API
+func (s *Server) StartScheduler(ctx context.Context, opts ScheduleOptions) error {
+	dashboardExists := func(name string) bool { return s.dashboardExists("", name) }
+	scheduler, err := newScheduler(opts, s.runDashboard, dashboardExists)
+	if err != nil || scheduler == nil {
+		return err
+	}
+	s.scheduler = scheduler
+	scheduler.Start(ctx)
+	return nil
+}
+
+// Addr returns the address the API server is listening on
+// (this will only be set after Start has been called)
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Port returns the port the API server is listening on
+// (this will only be set after Start has been called)
+func (s *Server) Port() ListenPort {
+	tcpAddr, ok := s.Addr().(*net.TCPAddr)
+	if !ok {
+		return 0
+	}
+	return ListenPort(tcpAddr.Port)
+}
+
+// URL returns the URL which may be used to browse to the API server
+// (this will only be set after Start has been called)
+func (s *Server) URL() string {
+	return serverURL(s.Addr(), s.tlsConfig != nil)
 }
 
 // Shutdown stops the API server
@@ -73,11 +162,23 @@ func (s *Server) Shutdown(ctx context.Context) {
 		log.Println("[TRACE] closed websocket")
 	}
 
+	if s.scheduler != nil {
+		log.Println("[TRACE] stopping scheduled runs")
+		s.scheduler.Stop()
+	}
+
 	log.Println("[TRACE] Server shutdown complete")
 
 }
 
+// HandleDashboardEvent handles a dashboard event of the default workspace
 func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents.DashboardEvent) {
+	s.handleWorkspaceEvent(ctx, s.workspaces.defaultName(), event)
+}
+
+// handleWorkspaceEvent handles a dashboard event raised by the named workspace
+// workspace events are only sent to the clients which have selected the workspace
+func (s *Server) handleWorkspaceEvent(ctx context.Context, workspaceName string, event dashboardevents.DashboardEvent) {
 	var payloadError error
 	var payload []byte
 	defer func() {
@@ -89,6 +190,11 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 		}
 	}()
 
+	// events of executions requested using the REST API are not sent to websocket clients
+	if s.apiRuns.handleEvent(event) {
+		return
+	}
+
 	switch e := event.(type) {
 
 	case *dashboardevents.WorkspaceError:
@@ -97,7 +203,7 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 		if payloadError != nil {
 			return
 		}
-		_ = s.webSocket.Broadcast(payload)
+		s.broadcastToWorkspace(workspaceName, payload)
 		OutputError(ctx, e.Error)
 
 	case *dashboardevents.ExecutionStarted:
@@ -146,14 +252,35 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 		s.writePayloadToSession(e.Session, payload)
 
 	case *dashboardevents.LeafNodeUpdated:
-		payload, payloadError = buildLeafNodeUpdatedPayload(e)
+		payload, payloadError = s.buildLeafNodeUpdatedPayloadForSession(e)
 		if payloadError != nil {
 			return
 		}
 		s.writePayloadToSession(e.Session, payload)
 
+	case *dashboardevents.LeafNodeRows:
+		if !s.shouldStreamLeafRows(e.Session, e.Name) {
+			return
+		}
+		var payloads [][]byte
+		payloads, payloadError = buildLeafNodeRowsPayloads(e, s.maxMessageSize)
+		if payloadError != nil {
+			return
+		}
+		for _, payload := range payloads {
+			s.writePayloadToSession(e.Session, payload)
+		}
+		// once all rows have been streamed, they are not sent again with the leaf node update
+		if e.Complete {
+			s.setStreamedLeafRows(e.Session, e.Name)
+		}
+
 	case *dashboardevents.DashboardChanged:
 		log.Println("[TRACE] DashboardChanged event")
+		w, ok := s.workspaces.get(workspaceName)
+		if !ok {
+			return
+		}
 		deletedDashboards := e.DeletedDashboards
 		newDashboards := e.NewDashboards
 
@@ -205,24 +332,28 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 			OutputMessage(ctx, "Available Dashboards updated")
 
 			// Emit dashboard metadata event in case there is a new mod - else the UI won't know about this mod
-			payload, payloadError = buildDashboardMetadataPayload(s.workspace.GetResourceMaps(), s.workspace.CloudMetadata)
+			payload, payloadError = buildDashboardMetadataPayload(w.GetResourceMaps(), w.CloudMetadata)
 			if payloadError != nil {
 				return
 			}
-			_ = s.webSocket.Broadcast(payload)
+			s.broadcastToWorkspace(workspaceName, payload)
 
 			// Emit available dashboards event
-			payload, payloadError = buildAvailableDashboardsPayload(s.workspace.GetResourceMaps())
+			payload, payloadError = buildAvailableDashboardsPayload(w.GetResourceMaps())
 			if payloadError != nil {
 				return
 			}
-			_ = s.webSocket.Broadcast(payload)
+			s.broadcastToWorkspace(workspaceName, payload)
 		}
 
 		var dashboardsBeingWatched []string
 
 		dashboardClients := s.getDashboardClients()
 		for _, dashboardClientInfo := range dashboardClients {
+			// only the dashboards of clients which have selected this workspace are affected
+			if s.sessionWorkspaceName(dashboardClientInfo.Session) != workspaceName {
+				continue
+			}
 			dashboardName := typeHelpers.SafeString(dashboardClientInfo.Dashboard)
 			if dashboardClientInfo.Dashboard != nil {
 				if helpers.StringSliceContains(dashboardsBeingWatched, dashboardName) {
@@ -262,8 +393,8 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 		for _, changedDashboardName := range changedDashboardNames {
 			sessionMap := s.getDashboardClients()
 			for sessionId, dashboardClientInfo := range sessionMap {
-				if typeHelpers.SafeString(dashboardClientInfo.Dashboard) == changedDashboardName {
-					_ = dashboardexecute.Executor.ExecuteDashboard(ctx, sessionId, changedDashboardName, dashboardClientInfo.DashboardInputs, s.workspace, s.dbClient)
+				if typeHelpers.SafeString(dashboardClientInfo.Dashboard) == changedDashboardName && s.sessionWorkspaceName(dashboardClientInfo.Session) == workspaceName {
+					_ = dashboardexecute.Executor.ExecuteDashboard(sessionContext(ctx, dashboardClientInfo.Session), sessionId, changedDashboardName, dashboardClientInfo.DashboardInputs, w, s.dbClient)
 				}
 			}
 		}
@@ -281,8 +412,8 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 		sessionMap := s.getDashboardClients()
 		for _, newDashboardName := range newDashboardNames {
 			for sessionId, dashboardClientInfo := range sessionMap {
-				if typeHelpers.SafeString(dashboardClientInfo.Dashboard) == newDashboardName {
-					_ = dashboardexecute.Executor.ExecuteDashboard(ctx, sessionId, newDashboardName, dashboardClientInfo.DashboardInputs, s.workspace, s.dbClient)
+				if typeHelpers.SafeString(dashboardClientInfo.Dashboard) == newDashboardName && s.sessionWorkspaceName(dashboardClientInfo.Session) == workspaceName {
+					_ = dashboardexecute.Executor.ExecuteDashboard(sessionContext(ctx, dashboardClientInfo.Session), sessionId, newDashboardName, dashboardClientInfo.DashboardInputs, w, s.dbClient)
 				}
 			}
 		}
@@ -319,6 +450,12 @@ func (s *Server) initAsync(ctx context.Context) {
 		})
 
 		s.webSocket.HandleMessage(s.handleMessageFunc(ctx))
+
+		// disconnect clients which are too slow to read their messages
+		s.webSocket.HandleError(s.handleWebSocketError)
+
+		// notify clients when a connection refresh updates the schema
+		s.listenToSchemaUpdates()
 		OutputMessage(ctx, "Initialization complete")
 	}()
 }
@@ -327,6 +464,8 @@ func (s *Server) handleMessageFunc(ctx context.Context) func(session *melody.Ses
 	return func(session *melody.Session, msg []byte) {
 
 		sessionId := s.getSessionId(session)
+		// run the queries of authenticated clients using the role of their session
+		ctx := sessionContext(ctx, session)
 
 		var request ClientRequest
 		// if we could not decode message - ignore
@@ -340,26 +479,54 @@ func (s *Server) handleMessageFunc(ctx context.Context) func(session *melody.Ses
 			log.Println("[TRACE] message", string(msg))
 		}
 
+		// the workspace the client has selected
+		w := s.sessionWorkspace(session)
+		if w == nil {
+			log.Printf("[WARN] workspace '%s' of dashboard client %s not found", s.sessionWorkspaceName(session), sessionId)
+			return
+		}
+
 		switch request.Action {
+		case "get_available_workspaces":
+			payload, err := buildAvailableWorkspacesPayload(s.listWorkspaces(), s.sessionWorkspaceName(session))
+			if err != nil {
+				panic(fmt.Errorf("error building payload for get_available_workspaces: %v", err))
+			}
+			s.writeToSession(session, payload)
+		case "select_workspace":
+			if err := s.selectWorkspace(ctx, session, request.Payload.Workspace); err != nil {
+				payload, err := buildWorkspaceErrorPayload(&dashboardevents.WorkspaceError{Error: err})
+				if err != nil {
+					panic(fmt.Errorf("error building payload for select_workspace: %v", err))
+				}
+				s.writeToSession(session, payload)
+			}
 		case "get_dashboard_metadata":
-			payload, err := buildDashboardMetadataPayload(s.workspace.GetResourceMaps(), s.workspace.CloudMetadata)
+			payload, err := buildDashboardMetadataPayload(w.GetResourceMaps(), w.CloudMetadata)
 			if err != nil {
 				panic(fmt.Errorf("error building payload for get_metadata: %v", err))
 			}
-			_ = session.Write(payload)
+			s.writeToSession(session, payload)
 		case "get_available_dashboards":
-			payload, err := buildAvailableDashboardsPayload(s.workspace.GetResourceMaps())
+			payload, err := buildAvailableDashboardsPayload(w.GetResourceMaps())
 			if err != nil {
 				panic(fmt.Errorf("error building payload for get_available_dashboards: %v", err))
 			}
-			_ = session.Write(payload)
+			s.writeToSession(session, payload)
 		case "select_dashboard":
+			// a client opening a permalink requests the dashboard and inputs saved in the permalink
+			if request.Payload.Permalink != "" {
+				if err := s.selectPermalink(ctx, session, request.Payload.Permalink); err != nil {
+					s.writePermalinkError(sessionId, err)
+				}
+				return
+			}
 			s.setDashboardForSession(sessionId, request.Payload.Dashboard.FullName, request.Payload.InputValues)
-			_ = dashboardexecute.Executor.ExecuteDashboard(ctx, sessionId, request.Payload.Dashboard.FullName, request.Payload.InputValues, s.workspace, s.dbClient)
+			_ = dashboardexecute.Executor.ExecuteDashboard(ctx, sessionId, request.Payload.Dashboard.FullName, request.Payload.InputValues, w, s.dbClient)
 		case "select_snapshot":
 			snapshotName := request.Payload.Dashboard.FullName
 			s.setDashboardForSession(sessionId, snapshotName, request.Payload.InputValues)
-			snap, err := dashboardexecute.Executor.LoadSnapshot(ctx, sessionId, snapshotName, s.workspace)
+			snap, err := dashboardexecute.Executor.LoadSnapshot(ctx, sessionId, snapshotName, w)
 			// TACTICAL- handle with error message
 			error_helpers.FailOnError(err)
 			// error handling???
@@ -369,6 +536,17 @@ func (s *Server) handleMessageFunc(ctx context.Context) func(session *melody.Ses
 
 			s.writePayloadToSession(sessionId, payload)
 			outputReady(ctx, fmt.Sprintf("Show snapshot complete: %s", snapshotName))
+		case "create_permalink":
+			p, err := s.createPermalink(sessionId, s.sessionWorkspaceName(session))
+			if err != nil {
+				s.writePermalinkError(sessionId, err)
+				return
+			}
+			payload, err := buildPermalinkPayload("permalink_created", p)
+			if err != nil {
+				panic(fmt.Errorf("error building payload for create_permalink: %v", err))
+			}
+			s.writeToSession(session, payload)
 		case "input_changed":
 			s.setDashboardInputsForSession(sessionId, request.Payload.InputValues)
 			_ = dashboardexecute.Executor.OnInputChanged(ctx, sessionId, request.Payload.InputValues, request.Payload.ChangedInput)
@@ -425,12 +603,25 @@ func (s *Server) setDashboardForSession(sessionId string, dashboardName string,
 	return dashboardClientInfo
 }
 
+// clearDashboardForSession cancels any execution of a client and clears the dashboard it has selected
+func (s *Server) clearDashboardForSession(ctx context.Context, sessionId string) {
+	dashboardexecute.Executor.CancelExecutionForSession(ctx, sessionId)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if dashboardClientInfo, ok := s.dashboardClients[sessionId]; ok {
+		dashboardClientInfo.Dashboard = nil
+		dashboardClientInfo.DashboardInputs = nil
+	}
+}
+
 func (s *Server) writePayloadToSession(sessionId string, payload []byte) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	if sessionInfo, ok := s.dashboardClients[sessionId]; ok {
-		_ = sessionInfo.Session.Write(payload)
+		s.writeToSession(sessionInfo.Session, payload)
 	}
 }
 
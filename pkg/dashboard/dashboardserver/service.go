@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -28,6 +29,8 @@ const (
 	ServiceStateStructVersion              = 20220411
 )
 
+// DashboardServiceState is the state of the dashboard service
+// SSLCert and SSLKey are the certificate and key the dashboard is served with (empty if it is not served over TLS)
 type DashboardServiceState struct {
 	State         ServiceState `json:"state"`
 	Error         string       `json:"error"`
@@ -35,9 +38,20 @@ type DashboardServiceState struct {
 	Port          int          `json:"port"`
 	ListenType    string       `json:"listen_type"`
 	Listen        []string     `json:"listen"`
+	SSLCert       string       `json:"ssl_cert,omitempty"`
+	SSLKey        string       `json:"ssl_key,omitempty"`
 	StructVersion int64        `json:"struct_version"`
 }
 
+// URL returns the URL used to browse to the dashboard service
+func (s *DashboardServiceState) URL() string {
+	scheme := "http"
+	if s.SSLCert != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d/", scheme, s.Listen[0], s.Port)
+}
+
 func loadServiceStateFile() (*DashboardServiceState, error) {
 	state := &DashboardServiceState{}
 	stateBytes, err := os.ReadFile(filepaths.DashboardServiceStateFilePath())
@@ -114,7 +128,8 @@ func StopDashboardService(ctx context.Context) error {
 
 // RunForService spanws an execution of the 'steampipe dashboard' command.
 // It is used when starting/restarting the steampipe service with the --dashboard flag set
-func RunForService(ctx context.Context, serverListen ListenType, serverPort ListenPort) error {
+// if a certificate and key are given, the dashboard is served over TLS
+func RunForService(ctx context.Context, serverListen ListenType, serverPort ListenPort, tlsOpts TLSOptions) error {
 	self, err := os.Executable()
 	if err != nil {
 		return err
@@ -142,6 +157,22 @@ func RunForService(ctx context.Context, serverListen ListenType, serverPort List
 		fmt.Sprintf("--%s=false", constants.ArgInput),
 	}
 
+	// the dashboard process may be restarted from a different directory, so pass absolute certificate paths
+	if tlsOpts.CertFile != "" {
+		certFile, err := filepath.Abs(tlsOpts.CertFile)
+		if err != nil {
+			return err
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", constants.ArgDashboardSSLCert, certFile))
+	}
+	if tlsOpts.KeyFile != "" {
+		keyFile, err := filepath.Abs(tlsOpts.KeyFile)
+		if err != nil {
+			return err
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", constants.ArgDashboardSSLKey, keyFile))
+	}
+
 	for _, variableArg := range viper.GetStringSlice(constants.ArgVariable) {
 		args = append(args, fmt.Sprintf("--%s=%s", constants.ArgVariable, variableArg))
 	}
@@ -0,0 +1,24 @@
+package dashboardserver
+
+import "testing"
+
+func TestDashboardServiceStateURL(t *testing.T) {
+	tests := map[string]struct {
+		state    DashboardServiceState
+		expected string
+	}{
+		"http": {
+			state:    DashboardServiceState{Listen: []string{"localhost"}, Port: 9194},
+			expected: "http://localhost:9194/",
+		},
+		"https": {
+			state:    DashboardServiceState{Listen: []string{"localhost", "10.0.0.1"}, Port: 9194, SSLCert: "/certs/server.crt", SSLKey: "/certs/server.key"},
+			expected: "https://localhost:9194/",
+		},
+	}
+	for name, test := range tests {
+		if actual := test.state.URL(); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package dashboardserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+)
+
+// ServerTimeouts are the timeouts applied to the dashboard http server
+// a zero value means no timeout
+type ServerTimeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// DefaultServerTimeouts returns the default dashboard http server timeouts
+func DefaultServerTimeouts() ServerTimeouts {
+	return ServerTimeouts{
+		ReadHeader: constants.DashboardReadHeaderTimeout,
+		Read:       constants.DashboardReadTimeout,
+		Write:      constants.DashboardWriteTimeout,
+		Idle:       constants.DashboardIdleTimeout,
+	}
+}
+
+// serverTimeoutsFromConfig returns the configured dashboard http server timeouts,
+// using the defaults for any which are not set
+func serverTimeoutsFromConfig() ServerTimeouts {
+	timeouts := DefaultServerTimeouts()
+	for arg, timeout := range map[string]*time.Duration{
+		constants.ArgDashboardReadHeaderTimeout: &timeouts.ReadHeader,
+		constants.ArgDashboardReadTimeout:       &timeouts.Read,
+		constants.ArgDashboardWriteTimeout:      &timeouts.Write,
+		constants.ArgDashboardIdleTimeout:       &timeouts.Idle,
+	} {
+		if viper.IsSet(arg) {
+			*timeout = time.Duration(viper.GetInt(arg)) * time.Second
+		}
+	}
+	return timeouts
+}
+
+// newHTTPServer creates the http server for the dashboard API, applying the given timeouts
+func newHTTPServer(handler http.Handler, timeouts ServerTimeouts) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+}
@@ -0,0 +1,105 @@
+package dashboardserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+)
+
+func serveWithTimeouts(t *testing.T, handler http.Handler, timeouts ServerTimeouts) string {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newHTTPServer(handler, timeouts)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(func() { srv.Close() })
+	return listener.Addr().String()
+}
+
+func TestSlowHeaderClientDisconnected(t *testing.T) {
+	addr := serveWithTimeouts(t, http.NotFoundHandler(), ServerTimeouts{ReadHeader: 100 * time.Millisecond})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// send an incomplete request header and never finish it
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the server should close the connection once the read header timeout has elapsed
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	start := time.Now()
+	_, err = io.ReadAll(conn)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatalf("expected the server to close the connection after the read header timeout")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("connection closed after %s - before the read header timeout", elapsed)
+	}
+}
+
+func TestHijackedConnectionNotClosedByWriteTimeout(t *testing.T) {
+	writeTimeout := 100 * time.Millisecond
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// take over the connection, as for the websocket upgrade
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// write after the server write timeout has elapsed
+		time.Sleep(3 * writeTimeout)
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok")
+		_ = buf.Flush()
+	})
+	addr := serveWithTimeouts(t, handler, ServerTimeouts{Write: writeTimeout})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("expected hijacked connection to outlive the write timeout: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+}
+
+func TestDashboardRunNotCutOffByWriteTimeout(t *testing.T) {
+	writeTimeout := 100 * time.Millisecond
+	run := func(context.Context, string, string, map[string]any) (*dashboardtypes.SteampipeSnapshot, error) {
+		// complete the run after the server write timeout has elapsed
+		time.Sleep(3 * writeTimeout)
+		return &dashboardtypes.SteampipeSnapshot{Title: "D1"}, nil
+	}
+	addr := serveWithTimeouts(t, restAPIRouter(run), ServerTimeouts{Write: writeTimeout})
+
+	res, err := http.Post("http://"+addr+"/api/dashboards/m.dashboard.d1/run", "application/json", nil)
+	if err != nil {
+		t.Fatalf("expected the dashboard run to outlive the write timeout: %s", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+}
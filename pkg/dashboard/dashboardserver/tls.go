@@ -0,0 +1,98 @@
+package dashboardserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+)
+
+// TLSOptions are the certificate files used to serve the dashboard over TLS
+type TLSOptions struct {
+	// the server certificate and key - if these are not set the server is not served over TLS
+	CertFile string
+	KeyFile  string
+	// if set, clients must present a certificate signed by a CA in this file
+	ClientCAFile string
+	// if set, the server certificate must be valid for this host name or IP address
+	ServerName string
+}
+
+// tlsOptionsFromConfig returns the configured dashboard TLS options
+func tlsOptionsFromConfig() TLSOptions {
+	opts := TLSOptions{
+		CertFile:     viper.GetString(constants.ArgDashboardSSLCert),
+		KeyFile:      viper.GetString(constants.ArgDashboardSSLKey),
+		ClientCAFile: viper.GetString(constants.ArgTLSClientCA),
+	}
+	// if the server is bound to a specific host, clients will connect using that host name
+	// so the server certificate must be valid for it
+	switch listen := ListenType(viper.GetString(constants.ArgDashboardListen)); listen {
+	case ListenTypeLocal, ListenTypeNetwork:
+	default:
+		opts.ServerName = listen.Host()
+	}
+	return opts
+}
+
+// newTLSConfig builds the tls config for the dashboard server
+// if no server certificate is configured, nil is returned and the server is not served over TLS
+//
+// if a client CA is configured, clients which do not present a certificate signed by the CA are rejected
+// during the TLS handshake - i.e. before any request (including the websocket upgrade) is handled
+func newTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	if opts.CertFile == "" && opts.KeyFile == "" {
+		if opts.ClientCAFile != "" {
+			return nil, fmt.Errorf("--%s requires --%s and --%s to be set", constants.ArgTLSClientCA, constants.ArgDashboardSSLCert, constants.ArgDashboardSSLKey)
+		}
+		return nil, nil
+	}
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, fmt.Errorf("both --%s and --%s must be set to serve the dashboard over TLS", constants.ArgDashboardSSLCert, constants.ArgDashboardSSLKey)
+	}
+
+	certificate, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dashboard server certificate: %s", err.Error())
+	}
+	if opts.ServerName != "" {
+		leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dashboard server certificate: %s", err.Error())
+		}
+		if err := leaf.VerifyHostname(opts.ServerName); err != nil {
+			return nil, fmt.Errorf("dashboard server certificate is not valid for '%s': %s", opts.ServerName, err.Error())
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	if opts.ClientCAFile != "" {
+		clientCAs, err := loadCertPool(opts.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client CA: %s", err.Error())
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// loadCertPool loads the PEM encoded certificates in the given file into a cert pool
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in %s", path)
+	}
+	return pool, nil
+}
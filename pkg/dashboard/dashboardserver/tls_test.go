@@ -0,0 +1,201 @@
+package dashboardserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCertificate creates a certificate signed by the given parent (or self signed if parent is nil)
+func newTestCertificate(t *testing.T, template *x509.Certificate, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{cert: cert, key: key, der: der}
+}
+
+func newTestCA(t *testing.T) *testCertificate {
+	return newTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "steampipe test ca"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+// writeFiles writes the PEM encoded certificate and key to the given directory, returning their paths
+func (c *testCertificate) writeFiles(t *testing.T, dir, name string) (certFile, keyFile string) {
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile, _ := ca.writeFiles(t, dir, "ca")
+
+	serverCert := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	serverCertFile, serverKeyFile := serverCert.writeFiles(t, dir, "server")
+
+	tlsConfig, err := newTLSConfig(TLSOptions{
+		CertFile:     serverCertFile,
+		KeyFile:      serverKeyFile,
+		ClientCAFile: caFile,
+		ServerName:   "127.0.0.1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newHTTPServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}), DefaultServerTimeouts())
+	srv.TLSConfig = tlsConfig
+	// silence the handshake errors logged for the rejected client
+	srv.ErrorLog = log.New(io.Discard, "", 0)
+	go func() { _ = serve(srv, listener) }()
+	defer srv.Close()
+
+	url := serverURL(listener.Addr(), true)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.cert)
+
+	validClientCert := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "valid client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	untrustedClientCert := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "untrusted client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, newTestCA(t))
+
+	testCases := map[string]struct {
+		clientCerts []tls.Certificate
+		expectError bool
+	}{
+		"valid client certificate":     {[]tls.Certificate{validClientCert.tlsCertificate()}, false},
+		"no client certificate":        {nil, true},
+		"untrusted client certificate": {[]tls.Certificate{untrustedClientCert.tlsCertificate()}, true},
+	}
+	for name, test := range testCases {
+		client := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs:      rootCAs,
+					Certificates: test.clientCerts,
+				},
+			},
+		}
+		res, err := client.Get(url)
+		if err == nil {
+			res.Body.Close()
+		}
+		if test.expectError && err == nil {
+			t.Errorf("Test: '%s' FAILED : expected the client to be rejected", name)
+		}
+		if !test.expectError && err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err.Error())
+		}
+		client.CloseIdleConnections()
+	}
+}
+
+func TestNewTLSConfigValidation(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile, _ := ca.writeFiles(t, dir, "ca")
+	serverCert := newTestCertificate(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "dashboard.example.com"},
+		DNSNames: []string{"dashboard.example.com"},
+	}, ca)
+	certFile, keyFile := serverCert.writeFiles(t, dir, "server")
+
+	testCases := map[string]struct {
+		opts        TLSOptions
+		expectTLS   bool
+		expectError bool
+	}{
+		"no tls":                     {TLSOptions{}, false, false},
+		"server certificate":         {TLSOptions{CertFile: certFile, KeyFile: keyFile}, true, false},
+		"matching server name":       {TLSOptions{CertFile: certFile, KeyFile: keyFile, ServerName: "dashboard.example.com"}, true, false},
+		"mismatched server name":     {TLSOptions{CertFile: certFile, KeyFile: keyFile, ServerName: "10.0.0.5"}, false, true},
+		"missing key":                {TLSOptions{CertFile: certFile}, false, true},
+		"client ca without cert":     {TLSOptions{ClientCAFile: caFile}, false, true},
+		"client ca is not a pem":     {TLSOptions{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile}, false, true},
+		"certificate does not exist": {TLSOptions{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: keyFile}, false, true},
+	}
+	for name, test := range testCases {
+		tlsConfig, err := newTLSConfig(test.opts)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err.Error())
+			continue
+		}
+		if (tlsConfig != nil) != test.expectTLS {
+			t.Errorf("Test: '%s' FAILED : expected tls %v, got %v", name, test.expectTLS, tlsConfig != nil)
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"github.com/turbot/steampipe/pkg/control/controlstatus"
 	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"gopkg.in/olahol/melody.v1"
+	"net"
 	"time"
 )
 
@@ -17,24 +19,67 @@ const (
 )
 
 // IsValid is a validator for ListenType known values
+// as well as the 'local' and 'network' keywords, a listen type may be a host name or IP address to bind to
 func (lt ListenType) IsValid() error {
 	switch lt {
 	case ListenTypeNetwork, ListenTypeLocal:
 		return nil
+	case "":
+		return fmt.Errorf("invalid listen type. Must be one of '%v', '%v' or a host name or IP address", ListenTypeNetwork, ListenTypeLocal)
 	}
-	return fmt.Errorf("invalid listen type. Must be one of '%v' or '%v'", ListenTypeNetwork, ListenTypeLocal)
+
+	if net.ParseIP(string(lt)) != nil {
+		return nil
+	}
+	if _, err := net.LookupHost(string(lt)); err != nil {
+		return fmt.Errorf("invalid listen type '%s': not a valid IP address or resolvable host name. Must be one of '%v', '%v' or a host name or IP address", string(lt), ListenTypeNetwork, ListenTypeLocal)
+	}
+	return nil
+}
+
+// Host returns the host the server should bind to for this listen type
+// 'local' binds to localhost and 'network' binds to all interfaces (an empty host)
+func (lt ListenType) Host() string {
+	switch lt {
+	case ListenTypeLocal:
+		return "localhost"
+	case ListenTypeNetwork:
+		return ""
+	}
+	return string(lt)
 }
 
 type ListenPort int
 
-// IsValid is a validator for ListenType known values
+// IsValid is a validator for ListenPort known values
+// a port of 0 is valid - this indicates that a free port should be chosen by the OS
 func (lp ListenPort) IsValid() error {
-	if lp < 1 || lp > 65535 {
-		return fmt.Errorf("invalid port - must be within range (1:65535)")
+	if lp < 0 || lp > 65535 {
+		return fmt.Errorf("invalid port - must be within range (1:65535), or 0 to select a free port")
 	}
 	return nil
 }
 
+// IsAuto returns whether the port should be chosen automatically
+func (lp ListenPort) IsAuto() bool {
+	return lp == 0
+}
+
+// SchemaUpdatedPayload is sent to clients when a connection refresh changes the schema
+// if the refresh sent them, the changed connections are included
+type SchemaUpdatedPayload struct {
+	Action      string                    `json:"action"`
+	Connections *SchemaUpdatedConnections `json:"connections,omitempty"`
+}
+
+// SchemaUpdatedConnections are the connections changed by a connection refresh
+type SchemaUpdatedConnections struct {
+	// the connections whose schemas were added or updated
+	Updated []string `json:"updated,omitempty"`
+	// the connections whose schemas were deleted
+	Deleted []string `json:"deleted,omitempty"`
+}
+
 type ErrorPayload struct {
 	Action string `json:"action"`
 	Error  string `json:"error"`
@@ -55,14 +100,64 @@ type ExecutionStartedPayload struct {
 
 var LeafNodeUpdatedSchemaVersion int64 = 20221222
 
+// LeafNodeUpdatedPayload is sent when a leaf node changes
+// if the rows of the node have been streamed to the client (see LeafNodeRowsPayload), the node is sent without its rows
+// and RowsStreamed is set - the client must use the streamed rows
 type LeafNodeUpdatedPayload struct {
 	SchemaVersion string         `json:"schema_version"`
 	Action        string         `json:"action"`
 	DashboardNode map[string]any `json:"dashboard_node"`
+	RowsStreamed  bool           `json:"rows_streamed,omitempty"`
 	ExecutionId   string         `json:"execution_id"`
 	Timestamp     time.Time      `json:"timestamp"`
 }
 
+var LeafNodeRowsSchemaVersion int64 = 20231017
+
+// LeafNodeRowsPayload is a batch of rows returned by a leaf node query
+// batches are sent as the query returns rows - the final batch of a query has Complete set
+type LeafNodeRowsPayload struct {
+	SchemaVersion string                   `json:"schema_version"`
+	Action        string                   `json:"action"`
+	DashboardNode string                   `json:"dashboard_node"`
+	Columns       []*queryresult.ColumnDef `json:"columns"`
+	Rows          []map[string]any         `json:"rows"`
+	Complete      bool                     `json:"complete"`
+	ExecutionId   string                   `json:"execution_id"`
+	Timestamp     time.Time                `json:"timestamp"`
+}
+
+var LeafNodeDeltaSchemaVersion int64 = 20231018
+
+// LeafNodeDeltaPayload is sent in place of a leaf_node_updated payload when delta updates are enabled
+// and the rows of the node have changed little since they were last sent to the client
+// the node is sent without its rows, along with the changes needed to rebuild them from the previous rows
+type LeafNodeDeltaPayload struct {
+	SchemaVersion string             `json:"schema_version"`
+	Action        string             `json:"action"`
+	DashboardNode map[string]any     `json:"dashboard_node"`
+	Delta         *LeafNodeRowsDelta `json:"delta"`
+	ExecutionId   string             `json:"execution_id"`
+	Timestamp     time.Time          `json:"timestamp"`
+}
+
+// LeafNodeRowsDelta describes how to rebuild the rows of a leaf node from the rows previously sent:
+//   - remove the previous rows with a key in Removed, or with the key of an upserted row
+//   - build RowCount rows, placing each upserted row at its index, and filling the remaining
+//     positions with the remaining previous rows, in order
+type LeafNodeRowsDelta struct {
+	KeyColumn string          `json:"key_column"`
+	RowCount  int             `json:"row_count"`
+	Removed   []any           `json:"removed,omitempty"`
+	Upserted  []LeafNodeRowAt `json:"upserted,omitempty"`
+}
+
+// LeafNodeRowAt is a row which has been added or changed, and its index in the rows
+type LeafNodeRowAt struct {
+	Index int            `json:"index"`
+	Row   map[string]any `json:"row"`
+}
+
 type ControlEventPayload struct {
 	Action      string                                 `json:"action"`
 	Control     controlstatus.ControlRunStatusProvider `json:"control"`
@@ -80,10 +175,14 @@ type ExecutionErrorPayload struct {
 
 var ExecutionCompletePayloadSchemaVersion int64 = 20221222
 
+// ExecutionCompletePayload is sent when a dashboard execution completes
+// if the payload exceeds the maximum message size, it is sent without the rows of the snapshot panels,
+// with rows_truncated set (see reducePayload)
 type ExecutionCompletePayload struct {
 	Action        string                            `json:"action"`
 	SchemaVersion string                            `json:"schema_version"`
 	Snapshot      *dashboardtypes.SteampipeSnapshot `json:"snapshot"`
+	RowsTruncated bool                              `json:"rows_truncated,omitempty"`
 	ExecutionId   string                            `json:"execution_id"`
 }
 
@@ -106,6 +205,10 @@ type DashboardClientInfo struct {
 	Session         *melody.Session
 	Dashboard       *string
 	DashboardInputs map[string]interface{}
+	// the rows last sent to the client for each leaf node (only populated when delta updates are enabled)
+	sentLeafRows map[string]*leafRows
+	// the leaf nodes whose rows have been streamed to the client since the node was last updated
+	streamedLeafRows map[string]struct{}
 }
 
 type ClientRequestDashboardPayload struct {
@@ -116,6 +219,8 @@ type ClientRequestPayload struct {
 	Dashboard    ClientRequestDashboardPayload `json:"dashboard"`
 	InputValues  map[string]interface{}        `json:"input_values"`
 	ChangedInput string                        `json:"changed_input"`
+	Workspace    string                        `json:"workspace"`
+	Permalink    string                        `json:"permalink"`
 }
 
 type ClientRequest struct {
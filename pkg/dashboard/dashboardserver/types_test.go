@@ -0,0 +1,70 @@
+package dashboardserver
+
+import (
+	"strings"
+	"testing"
+)
+
+type listenTypeTest struct {
+	listenType   ListenType
+	expectedHost string
+	expectValid  bool
+}
+
+var testCasesListenType = map[string]listenTypeTest{
+	"local keyword": {
+		listenType:   ListenTypeLocal,
+		expectedHost: "localhost",
+		expectValid:  true,
+	},
+	"network keyword": {
+		listenType:   ListenTypeNetwork,
+		expectedHost: "",
+		expectValid:  true,
+	},
+	"ipv4 address": {
+		listenType:   "10.0.0.5",
+		expectedHost: "10.0.0.5",
+		expectValid:  true,
+	},
+	"ipv6 address": {
+		listenType:   "::1",
+		expectedHost: "::1",
+		expectValid:  true,
+	},
+	"invalid host": {
+		listenType:  "not a host!",
+		expectValid: false,
+	},
+	"unresolvable host": {
+		listenType:  "steampipe.invalid",
+		expectValid: false,
+	},
+	"empty": {
+		listenType:  "",
+		expectValid: false,
+	},
+}
+
+func TestListenType(t *testing.T) {
+	for name, test := range testCasesListenType {
+		err := test.listenType.IsValid()
+		if test.expectValid {
+			if err != nil {
+				t.Errorf("Test: '%s' FAILED : expected listen type to be valid, got error: %s", name, err)
+				continue
+			}
+			if host := test.listenType.Host(); host != test.expectedHost {
+				t.Errorf("Test: '%s' FAILED : expected host '%s', got '%s'", name, test.expectedHost, host)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Test: '%s' FAILED : expected listen type to be invalid", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), "host name or IP address") {
+			t.Errorf("Test: '%s' FAILED : expected error to describe valid values, got: %s", name, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package dashboardserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"gopkg.in/olahol/melody.v1"
+)
+
+// the error melody reports when a session send buffer is full
+// (melody does not export this error, so we must match on the message)
+const messageBufferFullError = "session message buffer is full"
+
+// the session key used to store the hijackRecorder for a websocket connection
+const sessionConnectionKey = "connection"
+
+type MessageTruncatedPayload struct {
+	Action  string `json:"action"`
+	Size    int    `json:"size"`
+	MaxSize int    `json:"max_size"`
+}
+
+// newWebSocket creates the websocket for the server
+// bufferSize is the number of messages which may be queued for a client before it is disconnected
+func newWebSocket(bufferSize int) *melody.Melody {
+	webSocket := melody.New()
+	if bufferSize > 0 {
+		webSocket.Config.MessageBufferSize = bufferSize
+	}
+	return webSocket
+}
+
+// maxMessageSizeFromConfig returns the configured maximum size of a message sent to a client (0 means no limit)
+func maxMessageSizeFromConfig() int {
+	if viper.IsSet(constants.ArgDashboardMaxMessageSize) {
+		return viper.GetInt(constants.ArgDashboardMaxMessageSize)
+	}
+	return constants.DashboardMaxMessageSize
+}
+
+// messageBufferSizeFromConfig returns the configured number of messages which may be queued for a client
+func messageBufferSizeFromConfig() int {
+	if viper.IsSet(constants.ArgDashboardMessageBufferSize) {
+		return viper.GetInt(constants.ArgDashboardMessageBufferSize)
+	}
+	return constants.DashboardMessageBufferSize
+}
+
+// limitMessageSize returns the payload to send to a client
+// if the payload exceeds the maximum message size, a reduced form of the payload is returned if there is one
+// which is within the limit (see reducePayload) - otherwise a truncation notice is returned in its place
+// NOTE: every payload sent to a client must be passed through this - use writePayloadToSession, writeToSession,
+// broadcast or broadcastFilter rather than writing to the websocket directly
+func (s *Server) limitMessageSize(payload []byte) []byte {
+	if s.maxMessageSize <= 0 || len(payload) <= s.maxMessageSize {
+		return payload
+	}
+	if reduced, ok := reducePayload(payload); ok && len(reduced) <= s.maxMessageSize {
+		log.Printf("[WARN] dashboard message size %d bytes exceeds the maximum of %d bytes - sending reduced message of %d bytes", len(payload), s.maxMessageSize, len(reduced))
+		return reduced
+	}
+	log.Printf("[WARN] dashboard message size %d bytes exceeds the maximum of %d bytes - sending truncation notice", len(payload), s.maxMessageSize)
+	truncated, err := json.Marshal(MessageTruncatedPayload{
+		Action:  "message_truncated",
+		Size:    len(payload),
+		MaxSize: s.maxMessageSize,
+	})
+	if err != nil {
+		// not expected
+		log.Printf("[WARN] failed to build message truncated payload: %s", err.Error())
+		return nil
+	}
+	return truncated
+}
+
+// reducePayload returns a smaller form of a payload which is still usable by the client, or false if there is none
+// an execution_complete payload is reduced by removing the rows of the snapshot panels - the client has already
+// been sent the rows of each panel as it completed (rows_truncated is set, so the client knows to keep them)
+func reducePayload(payload []byte) ([]byte, bool) {
+	var p map[string]any
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, false
+	}
+	if p["action"] != "execution_complete" {
+		return nil, false
+	}
+	snapshot, ok := p["snapshot"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	panels, _ := snapshot["panels"].(map[string]any)
+	for _, panel := range panels {
+		if panel, ok := panel.(map[string]any); ok {
+			if data, ok := panel["data"].(map[string]any); ok {
+				delete(data, "rows")
+			}
+		}
+	}
+	p["rows_truncated"] = true
+
+	res, err := json.Marshal(p)
+	if err != nil {
+		return nil, false
+	}
+	return res, true
+}
+
+// writeToSession sends a payload to a client session, limiting its size
+func (s *Server) writeToSession(session *melody.Session, payload []byte) {
+	_ = session.Write(s.limitMessageSize(payload))
+}
+
+// broadcast sends a payload to all clients, limiting its size
+func (s *Server) broadcast(payload []byte) {
+	_ = s.webSocket.Broadcast(s.limitMessageSize(payload))
+}
+
+// broadcastFilter sends a payload to the clients for which filter returns true, limiting its size
+func (s *Server) broadcastFilter(payload []byte, filter func(*melody.Session) bool) {
+	_ = s.webSocket.BroadcastFilter(s.limitMessageSize(payload), filter)
+}
+
+// hijackRecorder records the connection hijacked when a request is upgraded to a websocket
+// melody does not expose the connection, and closing a session only queues a close message - which cannot be
+// sent if the session send buffer is full - so the connection is needed to forcibly disconnect a client
+type hijackRecorder struct {
+	http.ResponseWriter
+	conn net.Conn
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(h.ResponseWriter).Hijack()
+	h.conn = conn
+	return conn, rw, err
+}
+
+// handleWebSocketRequest upgrades the request to a websocket, recording the underlying connection
+// and the authenticated session (if any) in the session
+func handleWebSocketRequest(webSocket *melody.Melody, w http.ResponseWriter, r *http.Request, auth *authSession) error {
+	recorder := &hijackRecorder{ResponseWriter: w}
+	keys := map[string]interface{}{sessionConnectionKey: recorder}
+	if auth != nil {
+		keys[sessionAuthKey] = auth
+	}
+	return webSocket.HandleRequestWithKeys(recorder, r, keys)
+}
+
+// handleWebSocketError disconnects any client whose send buffer is full
+// this stops a slow client causing the server to buffer an unbounded amount of data
+func (s *Server) handleWebSocketError(session *melody.Session, err error) {
+	if err.Error() != messageBufferFullError {
+		log.Printf("[TRACE] websocket error: %s", err.Error())
+		return
+	}
+	log.Printf("[WARN] dashboard client %s is not reading messages fast enough - disconnecting", s.getSessionId(session))
+	if recorder, ok := session.Get(sessionConnectionKey); ok && recorder.(*hijackRecorder).conn != nil {
+		// closing the connection stops the session read loop, which closes the session
+		_ = recorder.(*hijackRecorder).conn.Close()
+	}
+}
@@ -0,0 +1,157 @@
+package dashboardserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gopkg.in/olahol/melody.v1"
+)
+
+// start a server with a single connected client, returning the server, the client and the client session id
+func newWebSocketLimitsTestServer(t *testing.T, maxMessageSize, bufferSize int) (*Server, *websocket.Conn, string, chan struct{}) {
+	s := &Server{
+		mutex:            &sync.Mutex{},
+		dashboardClients: make(map[string]*DashboardClientInfo),
+		webSocket:        newWebSocket(bufferSize),
+		maxMessageSize:   maxMessageSize,
+	}
+	sessionIds := make(chan string, 1)
+	disconnected := make(chan struct{})
+	s.webSocket.HandleConnect(func(session *melody.Session) {
+		s.addSession(session)
+		sessionIds <- s.getSessionId(session)
+	})
+	s.webSocket.HandleDisconnect(func(*melody.Session) { close(disconnected) })
+	s.webSocket.HandleError(s.handleWebSocketError)
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = handleWebSocketRequest(s.webSocket, w, r, nil)
+	}))
+	t.Cleanup(httpServer.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	select {
+	case sessionId := <-sessionIds:
+		return s, client, sessionId, disconnected
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for client to connect")
+	}
+	return nil, nil, "", nil
+}
+
+func TestOversizedMessageIsTruncated(t *testing.T) {
+	s, client, sessionId, _ := newWebSocketLimitsTestServer(t, 100, 0)
+
+	// a message within the limit is sent unchanged
+	small := []byte(`{"action":"execution_complete"}`)
+	s.writePayloadToSession(sessionId, small)
+	_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, msg, err := client.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(msg, small) {
+		t.Errorf("expected message '%s', got '%s'", small, msg)
+	}
+
+	// a message exceeding the limit is replaced with a truncation notice
+	large := []byte(`{"action":"execution_complete","data":"` + strings.Repeat("x", 200) + `"}`)
+	s.writePayloadToSession(sessionId, large)
+	_, msg, err = client.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload MessageTruncatedPayload
+	if err := json.Unmarshal(msg, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Action != "message_truncated" || payload.Size != len(large) || payload.MaxSize != 100 {
+		t.Errorf("unexpected truncation notice %+v", payload)
+	}
+}
+
+func TestOversizedExecutionCompleteIsReduced(t *testing.T) {
+	s, client, _, _ := newWebSocketLimitsTestServer(t, 300, 0)
+
+	// an execution_complete payload exceeding the limit is broadcast without the panel rows
+	rows := make([]map[string]any, 20)
+	for i := range rows {
+		rows[i] = map[string]any{"id": i}
+	}
+	large, err := json.Marshal(map[string]any{
+		"action": "execution_complete",
+		"snapshot": map[string]any{
+			"panels": map[string]any{
+				"dashboard.table": map[string]any{
+					"name": "dashboard.table",
+					"data": map[string]any{"columns": []map[string]any{{"name": "id"}}, "rows": rows},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(large) <= 300 {
+		t.Fatalf("expected the test payload to exceed the limit, got %d bytes", len(large))
+	}
+	s.broadcast(large)
+
+	_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, msg, err := client.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Action        string `json:"action"`
+		RowsTruncated bool   `json:"rows_truncated"`
+		Snapshot      struct {
+			Panels map[string]struct {
+				Data map[string]any `json:"data"`
+			} `json:"panels"`
+		} `json:"snapshot"`
+	}
+	if err := json.Unmarshal(msg, &payload); err != nil {
+		t.Fatal(err)
+	}
+	panel, ok := payload.Snapshot.Panels["dashboard.table"]
+	if payload.Action != "execution_complete" || !payload.RowsTruncated || !ok {
+		t.Fatalf("expected a reduced execution_complete payload, got '%s'", msg)
+	}
+	if _, hasRows := panel.Data["rows"]; hasRows {
+		t.Errorf("expected the panel rows to be removed, got '%s'", msg)
+	}
+	if _, hasColumns := panel.Data["columns"]; !hasColumns {
+		t.Errorf("expected the panel columns to be kept, got '%s'", msg)
+	}
+}
+
+func TestSlowClientIsDisconnected(t *testing.T) {
+	s, _, sessionId, disconnected := newWebSocketLimitsTestServer(t, 0, 1)
+
+	// the client never reads - keep writing until the send buffer fills and the client is dropped
+	payload := bytes.Repeat([]byte("x"), 1024*1024)
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case <-disconnected:
+			return
+		case <-deadline:
+			t.Fatal("expected slow client to be disconnected")
+		default:
+			s.writePayloadToSession(sessionId, payload)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package dashboardserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	typeHelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardevents"
+	"github.com/turbot/steampipe/pkg/workspace"
+	"gopkg.in/olahol/melody.v1"
+)
+
+// the websocket session key used to store the name of the workspace a dashboard client has selected
+const sessionWorkspaceKey = "workspace"
+
+// the query parameter used to select the workspace of a REST API request
+const workspaceQueryParam = "workspace"
+
+// errWorkspaceNotFound is returned if a requested workspace is not served by the dashboard server
+var errWorkspaceNotFound = errors.New("workspace not found")
+
+// WorkspaceArg is a workspace passed using --dashboard-workspace, of the form '[<name>=]<path>'
+// if no name is given, the workspace is named after its mod
+type WorkspaceArg struct {
+	Name string
+	Path string
+}
+
+// WorkspaceArgsFromConfig returns the additional workspaces the dashboard server should serve
+func WorkspaceArgsFromConfig() ([]WorkspaceArg, error) {
+	var res []WorkspaceArg
+	for _, arg := range viper.GetStringSlice(constants.ArgDashboardWorkspace) {
+		workspaceArg, err := parseWorkspaceArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, workspaceArg)
+	}
+	return res, nil
+}
+
+func parseWorkspaceArg(arg string) (WorkspaceArg, error) {
+	name, path, ok := strings.Cut(arg, "=")
+	// a path may contain '=' - only treat the prefix as a name if it could not be part of a path
+	if !ok || strings.ContainsAny(name, `/\`) {
+		name, path = "", arg
+	}
+	name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+	if path == "" {
+		return WorkspaceArg{}, fmt.Errorf("invalid --%s '%s': expected '[<name>=]<path>'", constants.ArgDashboardWorkspace, arg)
+	}
+	return WorkspaceArg{Name: name, Path: path}, nil
+}
+
+// WorkspacePayload describes a workspace served by the dashboard server
+type WorkspacePayload struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Path    string `json:"path"`
+	Default bool   `json:"default"`
+}
+
+// AvailableWorkspacesPayload is sent to a dashboard client in response to a get_available_workspaces or
+// select_workspace request - current is the workspace the client has selected
+type AvailableWorkspacesPayload struct {
+	Action     string             `json:"action"`
+	Workspaces []WorkspacePayload `json:"workspaces"`
+	Current    string             `json:"current"`
+}
+
+// WorkspaceListPayload is the response of GET /api/workspaces
+type WorkspaceListPayload struct {
+	Workspaces []WorkspacePayload `json:"workspaces"`
+}
+
+// workspaceLister returns the workspaces served by the dashboard server
+type workspaceLister func() []WorkspacePayload
+
+// serverWorkspaces are the workspaces served by the dashboard server, keyed by name
+// the first workspace added is the default - this is used by clients which have not selected a workspace
+type serverWorkspaces struct {
+	names      []string
+	workspaces map[string]*workspace.Workspace
+	mut        sync.RWMutex
+}
+
+func newServerWorkspaces() *serverWorkspaces {
+	return &serverWorkspaces{workspaces: make(map[string]*workspace.Workspace)}
+}
+
+func (w *serverWorkspaces) add(name string, ws *workspace.Workspace) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if _, ok := w.workspaces[name]; ok {
+		return fmt.Errorf("a workspace named '%s' is already being served - name the workspace using --%s <name>=<path>", name, constants.ArgDashboardWorkspace)
+	}
+	w.names = append(w.names, name)
+	w.workspaces[name] = ws
+	return nil
+}
+
+// get returns the named workspace - if the name is empty, the default workspace is returned
+func (w *serverWorkspaces) get(name string) (*workspace.Workspace, bool) {
+	if w == nil {
+		return nil, false
+	}
+	w.mut.RLock()
+	defer w.mut.RUnlock()
+	if name == "" && len(w.names) > 0 {
+		name = w.names[0]
+	}
+	ws, ok := w.workspaces[name]
+	return ws, ok
+}
+
+// defaultName returns the name of the default workspace (empty if there are no workspaces)
+func (w *serverWorkspaces) defaultName() string {
+	if w == nil {
+		return ""
+	}
+	w.mut.RLock()
+	defer w.mut.RUnlock()
+	if len(w.names) == 0 {
+		return ""
+	}
+	return w.names[0]
+}
+
+// list returns the names of the workspaces, in the order they were added
+func (w *serverWorkspaces) list() []string {
+	if w == nil {
+		return nil
+	}
+	w.mut.RLock()
+	defer w.mut.RUnlock()
+	return append([]string(nil), w.names...)
+}
+
+// workspaceName returns the name to serve a workspace as - the mod short name if the workspace has a mod,
+// otherwise the name of the workspace directory
+func workspaceName(w *workspace.Workspace) string {
+	if w.Mod != nil && w.Mod.ShortName != "" {
+		return w.Mod.ShortName
+	}
+	return filepath.Base(w.Path)
+}
+
+// AddWorkspace adds a workspace to be served by the dashboard server - if the name is empty, the workspace is named
+// after its mod. The first workspace added (by NewServer) is the default workspace
+func (s *Server) AddWorkspace(ctx context.Context, name string, w *workspace.Workspace) error {
+	if name == "" {
+		name = workspaceName(w)
+	}
+	if err := s.workspaces.add(name, w); err != nil {
+		return err
+	}
+	w.RegisterDashboardEventHandler(ctx, s.workspaceEventHandler(name))
+	if err := w.SetupWatcher(ctx, s.dbClient, func(c context.Context, e error) {}); err != nil {
+		return err
+	}
+	if len(s.workspaces.list()) > 1 {
+		OutputMessage(ctx, fmt.Sprintf("Workspace loaded: %s", name))
+	}
+	return nil
+}
+
+// workspaceEventHandler returns the handler for the dashboard events of the named workspace
+func (s *Server) workspaceEventHandler(workspaceName string) dashboardevents.DashboardEventHandler {
+	return func(ctx context.Context, event dashboardevents.DashboardEvent) {
+		s.handleWorkspaceEvent(ctx, workspaceName, event)
+	}
+}
+
+// listWorkspaces returns the workspaces served by the dashboard server, the default workspace first
+func (s *Server) listWorkspaces() []WorkspacePayload {
+	var res []WorkspacePayload
+	for i, name := range s.workspaces.list() {
+		w, _ := s.workspaces.get(name)
+		payload := WorkspacePayload{Name: name, Default: i == 0}
+		if w != nil {
+			payload.Path = w.Path
+			if w.Mod != nil {
+				payload.Title = typeHelpers.SafeString(w.Mod.Title)
+			}
+		}
+		res = append(res, payload)
+	}
+	return res
+}
+
+// sessionWorkspaceName returns the name of the workspace selected by a dashboard client
+// (the default workspace if the client has not selected one)
+func (s *Server) sessionWorkspaceName(session *melody.Session) string {
+	if session != nil {
+		if name, ok := session.Get(sessionWorkspaceKey); ok {
+			return name.(string)
+		}
+	}
+	return s.workspaces.defaultName()
+}
+
+// sessionWorkspace returns the workspace selected by a dashboard client
+func (s *Server) sessionWorkspace(session *melody.Session) *workspace.Workspace {
+	w, _ := s.workspaces.get(s.sessionWorkspaceName(session))
+	return w
+}
+
+// selectWorkspace switches a dashboard client to the named workspace, cancelling any execution of the client
+// the client is sent the metadata and available dashboards of the workspace
+func (s *Server) selectWorkspace(ctx context.Context, session *melody.Session, name string) error {
+	w, ok := s.workspaces.get(name)
+	if !ok || name == "" {
+		return fmt.Errorf("%w: '%s'", errWorkspaceNotFound, name)
+	}
+	sessionId := s.getSessionId(session)
+	s.clearDashboardForSession(ctx, sessionId)
+	session.Set(sessionWorkspaceKey, name)
+
+	payload, err := buildAvailableWorkspacesPayload(s.listWorkspaces(), name)
+	if err != nil {
+		return err
+	}
+	s.writePayloadToSession(sessionId, payload)
+
+	payload, err = buildDashboardMetadataPayload(w.GetResourceMaps(), w.CloudMetadata)
+	if err != nil {
+		return err
+	}
+	s.writePayloadToSession(sessionId, payload)
+
+	payload, err = buildAvailableDashboardsPayload(w.GetResourceMaps())
+	if err != nil {
+		return err
+	}
+	s.writePayloadToSession(sessionId, payload)
+	return nil
+}
+
+// broadcastToWorkspace sends a payload to the dashboard clients which have selected the named workspace
+func (s *Server) broadcastToWorkspace(workspaceName string, payload []byte) {
+	s.broadcastFilter(payload, func(session *melody.Session) bool {
+		return s.sessionWorkspaceName(session) == workspaceName
+	})
+}
+
+// workspaceListHandler handles GET /api/workspaces
+func workspaceListHandler(listWorkspaces workspaceLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+		c.JSON(http.StatusOK, WorkspaceListPayload{Workspaces: listWorkspaces()})
+	}
+}
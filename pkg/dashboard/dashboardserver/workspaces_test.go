@@ -0,0 +1,81 @@
+package dashboardserver
+
+import (
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/workspace"
+)
+
+func TestParseWorkspaceArg(t *testing.T) {
+	type workspaceArgTest struct {
+		arg           string
+		expected      WorkspaceArg
+		expectedError bool
+	}
+	tests := map[string]workspaceArgTest{
+		"path":           {"/mods/aws_insights", WorkspaceArg{Path: "/mods/aws_insights"}, false},
+		"named":          {"aws=/mods/aws_insights", WorkspaceArg{Name: "aws", Path: "/mods/aws_insights"}, false},
+		"whitespace":     {" aws = ../aws_insights ", WorkspaceArg{Name: "aws", Path: "../aws_insights"}, false},
+		"equals in path": {"/mods/a=b", WorkspaceArg{Path: "/mods/a=b"}, false},
+		"windows path":   {`C:\mods\a=b`, WorkspaceArg{Path: `C:\mods\a=b`}, false},
+		"no path":        {"aws=", WorkspaceArg{}, true},
+		"empty":          {"", WorkspaceArg{}, true},
+	}
+	for name, test := range tests {
+		res, err := parseWorkspaceArg(test.arg)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if res != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got %+v", name, test.expected, res)
+		}
+	}
+}
+
+func TestServerWorkspaces(t *testing.T) {
+	workspaces := newServerWorkspaces()
+	if _, ok := workspaces.get(""); ok {
+		t.Errorf("expected no default workspace before a workspace is added")
+	}
+
+	first, second := &workspace.Workspace{Path: "/mods/first"}, &workspace.Workspace{Path: "/mods/second"}
+	if err := workspaces.add("first", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspaces.add("second", second); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspaces.add("first", second); err == nil {
+		t.Errorf("expected an error adding a workspace with a duplicate name")
+	}
+
+	if workspaces.defaultName() != "first" {
+		t.Errorf("expected the first workspace added to be the default, got %s", workspaces.defaultName())
+	}
+	if w, _ := workspaces.get(""); w != first {
+		t.Errorf("expected an empty name to return the default workspace")
+	}
+	if w, _ := workspaces.get("second"); w != second {
+		t.Errorf("expected the named workspace to be returned")
+	}
+	if _, ok := workspaces.get("third"); ok {
+		t.Errorf("expected an unknown workspace not to be found")
+	}
+
+	s := &Server{workspaces: workspaces}
+	listed := s.listWorkspaces()
+	if len(listed) != 2 || !listed[0].Default || listed[1].Default || listed[1].Path != "/mods/second" {
+		t.Errorf("unexpected workspaces %+v", listed)
+	}
+	// a client which has not selected a workspace uses the default
+	if s.sessionWorkspace(nil) != first {
+		t.Errorf("expected a client with no selected workspace to use the default workspace")
+	}
+}
@@ -0,0 +1,112 @@
+package dashboardtypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+)
+
+// DashboardData is the data of an executed dashboard - the metadata and result rows of each panel
+// it is a simplified form of a snapshot, intended for consumption by other systems
+type DashboardData struct {
+	Dashboard string            `json:"dashboard"`
+	Inputs    map[string]any    `json:"inputs"`
+	Variables map[string]string `json:"variables"`
+	StartTime time.Time         `json:"start_time"`
+	EndTime   time.Time         `json:"end_time"`
+	// the panels, in layout order
+	Panels []*PanelData `json:"panels"`
+}
+
+// PanelData is the metadata and result rows of a single dashboard panel
+type PanelData struct {
+	Name        string                   `json:"name"`
+	Parent      string                   `json:"parent,omitempty"`
+	PanelType   string                   `json:"panel_type"`
+	Title       string                   `json:"title,omitempty"`
+	DisplayType string                   `json:"display_type,omitempty"`
+	Status      RunStatus                `json:"status"`
+	Error       string                   `json:"error,omitempty"`
+	Columns     []*queryresult.ColumnDef `json:"columns,omitempty"`
+	Rows        []map[string]any         `json:"rows,omitempty"`
+}
+
+// NewDashboardData builds the dashboard data from a snapshot
+func NewDashboardData(snapshot *SteampipeSnapshot) (*DashboardData, error) {
+	if snapshot.Layout == nil {
+		return nil, fmt.Errorf("snapshot has no layout")
+	}
+	res := &DashboardData{
+		Dashboard: snapshot.Layout.Name,
+		Inputs:    snapshot.Inputs,
+		Variables: snapshot.Variables,
+		StartTime: snapshot.StartTime,
+		EndTime:   snapshot.EndTime,
+		Panels:    []*PanelData{},
+	}
+	if err := res.addPanels(snapshot, snapshot.Layout, ""); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// addPanels adds the data of the given layout node and all its descendants
+func (d *DashboardData) addPanels(snapshot *SteampipeSnapshot, node *SnapshotTreeNode, parent string) error {
+	if panel, ok := snapshot.Panels[node.Name]; ok {
+		panelData, err := newPanelData(panel)
+		if err != nil {
+			return fmt.Errorf("failed to read data for panel %s: %s", node.Name, err.Error())
+		}
+		panelData.Parent = parent
+		d.Panels = append(d.Panels, panelData)
+	}
+	for _, child := range node.Children {
+		if err := d.addPanels(snapshot, child, node.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newPanelData reads the panel data from the serialised form of a snapshot panel
+// (this ensures the data matches the snapshot exactly, whatever the underlying run type)
+func newPanelData(panel SnapshotPanel) (*PanelData, error) {
+	jsonBytes, err := json.Marshal(panel)
+	if err != nil {
+		return nil, err
+	}
+	var serialised struct {
+		PanelData
+		Data *LeafData `json:"data"`
+	}
+	if err := json.Unmarshal(jsonBytes, &serialised); err != nil {
+		return nil, err
+	}
+	res := serialised.PanelData
+	if serialised.Data != nil {
+		res.Columns = serialised.Data.Columns
+		res.Rows = serialised.Data.Rows
+	}
+	return &res, nil
+}
+
+// PanelErrors returns the errors of all leaf panels which failed
+// (the errors of container panels are the combined errors of their children, so these are not included)
+func (d *DashboardData) PanelErrors() []string {
+	parents := make(map[string]struct{})
+	for _, panel := range d.Panels {
+		parents[panel.Parent] = struct{}{}
+	}
+	var res []string
+	for _, panel := range d.Panels {
+		if _, isParent := parents[panel.Name]; isParent {
+			continue
+		}
+		if panel.Status == RunError {
+			res = append(res, fmt.Sprintf("%s: %s", panel.Name, panel.Error))
+		}
+	}
+	return res
+}
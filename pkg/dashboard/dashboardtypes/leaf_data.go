@@ -16,13 +16,16 @@ func NewLeafData(result *queryresult.SyncQueryResult) *LeafData {
 	}
 
 	for rowIdx, row := range result.Rows {
-		rowData := make(map[string]interface{}, len(result.Cols))
-		for i, data := range row.(*queryresult.RowResult).Data {
-			columnName := leafData.Columns[i].Name
-			rowData[columnName] = data
-		}
-
-		leafData.Rows[rowIdx] = rowData
+		leafData.Rows[rowIdx] = NewLeafDataRow(result.Cols, row.(*queryresult.RowResult).Data)
 	}
 	return leafData
 }
+
+// NewLeafDataRow converts the column values of a row into a map keyed by column name
+func NewLeafDataRow(cols []*queryresult.ColumnDef, data []interface{}) map[string]interface{} {
+	rowData := make(map[string]interface{}, len(cols))
+	for i, value := range data {
+		rowData[cols[i].Name] = value
+	}
+	return rowData
+}
@@ -60,6 +60,8 @@ type DbClient struct {
 	// disable timing - set whilst in process of querying the timing
 	disableTiming        bool
 	onConnectionCallback DbConnectionCallback
+	// used to import lazy connections when they are first queried
+	lazyConnectionImporter LazyConnectionImporter
 }
 
 func NewDbClient(ctx context.Context, connectionString string, onConnectionCallback DbConnectionCallback, opts ...ClientOption) (_ *DbClient, err error) {
@@ -99,6 +101,7 @@ func NewDbClient(ctx context.Context, connectionString string, onConnectionCallb
 	for _, o := range opts {
 		o(&config)
 	}
+	client.lazyConnectionImporter = config.lazyConnectionImporter
 
 	if err := client.establishConnectionPool(ctx, config); err != nil {
 		return nil, err
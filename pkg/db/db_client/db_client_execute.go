@@ -144,6 +144,8 @@ func (c *DbClient) ExecuteInSession(ctx context.Context, session *db_common.Data
 	if err != nil {
 		return
 	}
+	// the query has been planned and started - the remaining time is spent fetching rows
+	planDuration := time.Since(startTime)
 
 	colDefs := fieldDescriptionsToColumns(rows.FieldDescriptions(), session.Connection.Conn())
 
@@ -154,7 +156,7 @@ func (c *DbClient) ExecuteInSession(ctx context.Context, session *db_common.Data
 		// define a callback which fetches the timing information
 		// this will be invoked after reading rows is complete but BEFORE closing the rows object (which closes the connection)
 		timingCallback := func() {
-			c.getQueryTiming(ctxExecute, startTime, session, result.TimingResult)
+			c.getQueryTiming(ctxExecute, startTime, planDuration, session, result.TimingResult)
 		}
 
 		// read in the rows and stream to the query result object
@@ -183,13 +185,15 @@ func (c *DbClient) getExecuteContext(ctx context.Context) context.Context {
 	return newCtx
 }
 
-func (c *DbClient) getQueryTiming(ctx context.Context, startTime time.Time, session *db_common.DatabaseSession, resultChannel chan *queryresult.TimingResult) {
+func (c *DbClient) getQueryTiming(ctx context.Context, startTime time.Time, planDuration time.Duration, session *db_common.DatabaseSession, resultChannel chan *queryresult.TimingResult) {
 	if !c.shouldShowTiming() {
 		return
 	}
 
 	var timingResult = &queryresult.TimingResult{
-		Duration: time.Since(startTime),
+		Duration:     time.Since(startTime),
+		PlanDuration: planDuration,
+		StartTime:    startTime,
 	}
 	// disable fetching timing information to avoid recursion
 	c.disableTiming = true
@@ -200,33 +204,39 @@ func (c *DbClient) getQueryTiming(ctx context.Context, startTime time.Time, sess
 		resultChannel <- timingResult
 	}()
 
-	var scanRows *ScanMetadataRow
+	var scanRows []*ScanMetadataRow
 	err := db_common.ExecuteSystemClientCall(ctx, session.Connection.Conn(), func(ctx context.Context, tx pgx.Tx) error {
 		query := fmt.Sprintf("select id, rows_fetched, cache_hit, hydrate_calls from %s.%s where id > %d", constants.InternalSchema, constants.ForeignTableScanMetadata, session.ScanMetadataMaxId)
 		rows, err := tx.Query(ctx, query)
 		if err != nil {
 			return err
 		}
-		scanRows, err = pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[ScanMetadataRow])
+		scanRows, err = pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[ScanMetadataRow])
 		return err
 	})
 
 	// if we failed to read scan metadata (either because the query failed or the plugin does not support it) just return
 	// we don't return the error, since we don't want to error out in this case
-	if err != nil || scanRows == nil {
+	if err != nil || len(scanRows) == 0 {
 		return
 	}
 
-	// so we have scan metadata - create the metadata struct
+	// so we have scan metadata - create the metadata struct, combining the scans of all tables used by the query
 	timingResult.Metadata = &queryresult.TimingMetadata{}
-	timingResult.Metadata.HydrateCalls += scanRows.HydrateCalls
-	if scanRows.CacheHit {
-		timingResult.Metadata.CachedRowsFetched += scanRows.RowsFetched
-	} else {
-		timingResult.Metadata.RowsFetched += scanRows.RowsFetched
+	for _, scanRow := range scanRows {
+		timingResult.Metadata.HydrateCalls += scanRow.HydrateCalls
+		timingResult.Metadata.Scans++
+		if scanRow.CacheHit {
+			timingResult.Metadata.CachedRowsFetched += scanRow.RowsFetched
+			timingResult.Metadata.CachedScans++
+		} else {
+			timingResult.Metadata.RowsFetched += scanRow.RowsFetched
+		}
+		// update the max id for this session
+		if scanRow.Id > session.ScanMetadataMaxId {
+			session.ScanMetadataMaxId = scanRow.Id
+		}
 	}
-	// update the max id for this session
-	session.ScanMetadataMaxId = scanRows.Id
 }
 
 func (c *DbClient) updateScanMetadataMaxId(ctx context.Context, session *db_common.DatabaseSession) error {
@@ -375,7 +385,7 @@ func populateRow(columnValues []interface{}, cols []*queryresult.ColumnDef) ([]i
 func isStreamingOutput() bool {
 	outputFormat := viper.GetString(constants.ArgOutput)
 
-	return helpers.StringSliceContains([]string{constants.OutputFormatCSV, constants.OutputFormatLine}, outputFormat)
+	return helpers.StringSliceContains([]string{constants.OutputFormatCSV, constants.OutputFormatLine, constants.OutputFormatParquet, constants.OutputFormatArrow}, outputFormat)
 }
 
 func humanizeRowCount(count int) string {
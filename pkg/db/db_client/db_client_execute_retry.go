@@ -30,6 +30,8 @@ func (c *DbClient) startQueryWithRetries(ctx context.Context, session *db_common
 
 	var res pgx.Rows
 	count := 0
+	// the lazy connections we have imported for this query - each is only imported once
+	importedLazyConnections := make(map[string]struct{})
 	err := retry.Do(ctx, retry.WithMaxDuration(maxDuration, backoff), func(ctx context.Context) error {
 		count++
 		log.Println("[TRACE] starting", count)
@@ -85,6 +87,10 @@ func (c *DbClient) startQueryWithRetries(ctx context.Context, session *db_common
 			// we need the first search path connection for each plugin to be loaded
 			searchPath := c.GetRequiredSessionSearchPath()
 			requiredConnections := connectionStateMap.GetFirstSearchPathConnectionForPlugins(searchPath)
+			// if any of these are lazy connections which have not been imported, import them and retry
+			if lazyConnections := connectionStateMap.LazyConnections(requiredConnections...); len(lazyConnections) > 0 {
+				return c.importLazyConnections(ctx, queryError, importedLazyConnections, lazyConnections...)
+			}
 			// if required connections are ready (and have been for more than the backoff interval) , just return the relation not found error
 			if connectionStateMap.Loaded(requiredConnections...) && time.Since(connectionStateMap.ConnectionModTime()) > backoffInterval {
 				return queryError
@@ -114,6 +120,12 @@ func (c *DbClient) startQueryWithRetries(ctx context.Context, session *db_common
 			return queryError
 		}
 
+		// if the connection is lazy, import it and retry
+		if connectionState.Lazy() {
+			log.Println("[TRACE] schema", missingSchema, "is lazy")
+			return c.importLazyConnections(ctx, queryError, importedLazyConnections, missingSchema)
+		}
+
 		// if the connection is ready (and has been for more than the backoff interval) , just return the relation not found error
 		if connectionState.State == constants.ConnectionStateReady && time.Since(connectionState.ConnectionModTime) > backoffInterval {
 			log.Println("[TRACE] schema", missingSchema, "has been ready for a long time")
@@ -135,3 +147,25 @@ func (c *DbClient) startQueryWithRetries(ctx context.Context, session *db_common
 
 	return res, err
 }
+
+// importLazyConnections imports the given lazy connections and returns a retryable error, so the query is retried
+// if a connection cannot be imported, or has already been imported for this query, the query error is returned
+func (c *DbClient) importLazyConnections(ctx context.Context, queryError error, imported map[string]struct{}, connectionNames ...string) error {
+	if c.lazyConnectionImporter == nil {
+		log.Println("[TRACE] this client cannot import lazy connections")
+		return queryError
+	}
+	for _, connectionName := range connectionNames {
+		if _, ok := imported[connectionName]; ok {
+			log.Println("[TRACE] lazy connection", connectionName, "has already been imported")
+			return queryError
+		}
+		imported[connectionName] = struct{}{}
+
+		statushooks.SetStatus(ctx, fmt.Sprintf("Importing connection '%s'…", connectionName))
+		if err := c.lazyConnectionImporter(ctx, connectionName); err != nil {
+			return fmt.Errorf("failed to import connection %s: %w", connectionName, err)
+		}
+	}
+	return retry.RetryableError(queryError)
+}
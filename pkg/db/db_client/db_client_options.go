@@ -1,6 +1,7 @@
 package db_client
 
 import (
+	"context"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -28,8 +29,12 @@ func (c PoolOverrides) apply(config *pgxpool.Config) {
 type clientConfig struct {
 	userPoolSettings       PoolOverrides
 	managementPoolSettings PoolOverrides
+	lazyConnectionImporter LazyConnectionImporter
 }
 
+// LazyConnectionImporter imports the schema of a connection with import_schema="lazy"
+type LazyConnectionImporter func(ctx context.Context, connectionName string) error
+
 type ClientOption func(*clientConfig)
 
 func WithUserPoolOverride(s PoolOverrides) ClientOption {
@@ -43,3 +48,11 @@ func WithManagementPoolOverride(s PoolOverrides) ClientOption {
 		cc.managementPoolSettings = s
 	}
 }
+
+// WithLazyConnectionImporter sets the function used to import the schema of a lazy connection when it is first queried
+// (if this is not set, queries against lazy connections which have not been imported fail)
+func WithLazyConnectionImporter(importer LazyConnectionImporter) ClientOption {
+	return func(cc *clientConfig) {
+		cc.lazyConnectionImporter = importer
+	}
+}
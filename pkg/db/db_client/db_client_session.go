@@ -90,6 +90,13 @@ func (c *DbClient) AcquireSession(ctx context.Context) (sessionResult *db_common
 		return sessionResult
 	}
 
+	// set the role required by the context (sessions are reused, so this also resets the role of a session
+	// previously acquired with a different role)
+	if err := db_common.EnsureSessionRole(ctx, session); err != nil {
+		sessionResult.Error = err
+		return sessionResult
+	}
+
 	sessionResult.Error = ctx.Err()
 	return sessionResult
 }
@@ -20,6 +20,9 @@ type DatabaseSession struct {
 
 	// the id of the last scan metadata retrieved
 	ScanMetadataMaxId int64 `json:"-"`
+
+	// the role the session is using (empty if the session is using the session user)
+	Role string `json:"-"`
 }
 
 func NewDBSession(backendPid uint32) *DatabaseSession {
@@ -42,9 +42,9 @@ func (r *InitResult) DisplayMessages() {
 			error_helpers.ShowWarning(w)
 		}
 	}
-	// do not display message in json or csv output mode
+	// do not display message in json, csv or binary output mode
 	output := viper.Get(constants.ArgOutput)
-	if output == constants.OutputFormatJSON || output == constants.OutputFormatCSV {
+	if output == constants.OutputFormatJSON || output == constants.OutputFormatCSV || output == constants.OutputFormatParquet || output == constants.OutputFormatArrow {
 		return
 	}
 	for _, w := range r.Warnings {
@@ -2,11 +2,14 @@ package db_common
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/spf13/viper"
 	typeHelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/utils"
@@ -24,11 +27,47 @@ type schemaRecord struct {
 	TableDescription  string
 }
 
-func LoadForeignSchemaNames(ctx context.Context, conn *pgx.Conn) ([]string, error) {
+// queryer is implemented by pgx.Conn, pgx.Tx and pgxpool.Pool
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// schemaQueryTimeout returns the configured maximum duration of a foreign schema catalog query, or 0 if there is no limit
+func schemaQueryTimeout() time.Duration {
+	return time.Duration(viper.GetInt(constants.ArgSchemaQueryTimeout)) * time.Second
+}
+
+// LoadForeignSchemaNames returns the (sorted) names of all schemas containing Steampipe foreign tables
+// the query is subject to the configured schema query timeout, so a hung catalog read fails rather than
+// blocking the caller indefinitely
+func LoadForeignSchemaNames(ctx context.Context, conn queryer) ([]string, error) {
+	timeout := schemaQueryTimeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	foreignSchemaNames, err := loadForeignSchemaNames(ctx, conn)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && timeout > 0 {
+			return nil, fmt.Errorf("timed out after %s loading foreign schema names: %w", timeout, ctx.Err())
+		}
+		if ctx.Err() != nil {
+			// wrap the context error so the caller can detect cancellation
+			return nil, fmt.Errorf("failed to load foreign schema names: %w", ctx.Err())
+		}
+		return nil, err
+	}
+	return foreignSchemaNames, nil
+}
+
+func loadForeignSchemaNames(ctx context.Context, conn queryer) ([]string, error) {
 	res, err := conn.Query(ctx, "SELECT DISTINCT foreign_table_schema FROM information_schema.foreign_tables WHERE foreign_server_name='steampipe'")
 	if err != nil {
 		return nil, err
 	}
+	defer res.Close()
 
 	var foreignSchemaNames []string
 	var schema string
@@ -41,6 +80,9 @@ func LoadForeignSchemaNames(ctx context.Context, conn *pgx.Conn) ([]string, erro
 			foreignSchemaNames = append(foreignSchemaNames, schema)
 		}
 	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
 	sort.Strings(foreignSchemaNames)
 	return foreignSchemaNames, nil
 }
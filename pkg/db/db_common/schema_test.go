@@ -0,0 +1,47 @@
+package db_common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+)
+
+// hungQueryer simulates a catalog query which never completes - like pgx, it returns when the context is done
+type hungQueryer struct{}
+
+func (hungQueryer) Query(ctx context.Context, _ string, _ ...any) (pgx.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestLoadForeignSchemaNamesCancel(t *testing.T) {
+	viper.Set(constants.ArgSchemaQueryTimeout, 0)
+	defer viper.Set(constants.ArgSchemaQueryTimeout, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := LoadForeignSchemaNames(ctx, hungQueryer{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a context cancelled error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the query to be cancelled promptly, took %s", elapsed)
+	}
+}
+
+func TestLoadForeignSchemaNamesTimeout(t *testing.T) {
+	viper.Set(constants.ArgSchemaQueryTimeout, 1)
+	defer viper.Set(constants.ArgSchemaQueryTimeout, nil)
+
+	_, err := LoadForeignSchemaNames(context.Background(), hungQueryer{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline exceeded error, got %v", err)
+	}
+}
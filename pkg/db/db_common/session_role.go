@@ -0,0 +1,52 @@
+package db_common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/turbot/steampipe/pkg/contexthelpers"
+)
+
+var contextKeySessionRole = contexthelpers.ContextKey("session_role")
+
+// AddSessionRoleToContext returns a context which causes database sessions acquired with it to use the given role
+// (the session user must be a member of the role)
+func AddSessionRoleToContext(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, contextKeySessionRole, role)
+}
+
+// SessionRoleFromContext returns the role database sessions acquired with the context must use
+// (an empty string means the session user)
+func SessionRoleFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	role, _ := ctx.Value(contextKeySessionRole).(string)
+	return role
+}
+
+// SessionRoleSql returns the sql to set the role of a session - if no role is given, the role is reset to the session user
+func SessionRoleSql(role string) string {
+	if role == "" {
+		return "reset role"
+	}
+	return fmt.Sprintf("set role %s", PgEscapeName(role))
+}
+
+// EnsureSessionRole sets the role of the session to the role required by the context, if it is not already set
+func EnsureSessionRole(ctx context.Context, session *DatabaseSession) error {
+	role := SessionRoleFromContext(ctx)
+	if session.Role == role {
+		return nil
+	}
+	err := ExecuteSystemClientCall(ctx, session.Connection.Conn(), func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, SessionRoleSql(role))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set session role: %w", err)
+	}
+	session.Role = role
+	return nil
+}
@@ -0,0 +1,29 @@
+package db_common
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSessionRoleSql(t *testing.T) {
+	tests := map[string]string{
+		"":                 "reset role",
+		"dashboard_reader": `set role "dashboard_reader"`,
+		`bad"role`:         `set role "bad""role"`,
+	}
+	for role, expected := range tests {
+		if actual := SessionRoleSql(role); actual != expected {
+			t.Errorf("Test: '%s' FAILED : expected '%s', got '%s'", role, expected, actual)
+		}
+	}
+}
+
+func TestSessionRoleFromContext(t *testing.T) {
+	ctx := context.Background()
+	if role := SessionRoleFromContext(ctx); role != "" {
+		t.Errorf("expected no role, got '%s'", role)
+	}
+	if role := SessionRoleFromContext(AddSessionRoleToContext(ctx, "reader")); role != "reader" {
+		t.Errorf("expected the role 'reader', got '%s'", role)
+	}
+}
@@ -1,14 +1,21 @@
 package db_common
 
 import (
+	"encoding/json"
 	"fmt"
-	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/utils"
 )
 
+// GetCommentsQueryForPlugin returns the sql to set the table and column comments for a connection
+// tables are commented in name order, so the sql for a given schema is always the same
 func GetCommentsQueryForPlugin(connectionName string, p map[string]*proto.TableSchema) string {
 	var statements strings.Builder
-	for t, schema := range p {
+	for _, t := range utils.SortedMapKeys(p) {
+		schema := p[t]
 		table := PgEscapeName(t)
 		schemaName := PgEscapeName(connectionName)
 		if schema.Description != "" {
@@ -26,7 +33,51 @@ func GetCommentsQueryForPlugin(connectionName string, p map[string]*proto.TableS
 	return statements.String()
 }
 
-func GetUpdateConnectionQuery(localSchema, remoteSchema string) string {
+const (
+	// the prefix of the comment set on connection schemas - this is followed by the remote (plugin) schema name
+	schemaCommentPluginPrefix = "steampipe plugin: "
+	// separates the remote schema name from the serialized connection tags in a schema comment
+	schemaCommentTagsSeparator = " | "
+)
+
+// GetSchemaComment returns the comment for a connection schema: the remote (plugin) schema name followed by
+// the connection tags (if any) serialized as JSON, e.g. `steampipe plugin: aws | {"env":"prod","team":"infra"}`
+func GetSchemaComment(remoteSchema string, tags map[string]string) string {
+	comment := schemaCommentPluginPrefix + remoteSchema
+	if len(tags) == 0 {
+		return comment
+	}
+	// NOTE: map keys are sorted when marshalled, so the comment for a given set of tags is always the same
+	// (marshalling a string map cannot fail)
+	tagsJson, _ := json.Marshal(tags)
+	return comment + schemaCommentTagsSeparator + string(tagsJson)
+}
+
+// ParseSchemaComment extracts the remote (plugin) schema name and the connection tags from a connection schema comment
+func ParseSchemaComment(comment string) (remoteSchema string, tags map[string]string, err error) {
+	if !strings.HasPrefix(comment, schemaCommentPluginPrefix) {
+		return "", nil, fmt.Errorf("'%s' is not a connection schema comment", comment)
+	}
+	remoteSchema, tagsJson, hasTags := strings.Cut(strings.TrimPrefix(comment, schemaCommentPluginPrefix), schemaCommentTagsSeparator)
+	if hasTags {
+		if err := json.Unmarshal([]byte(tagsJson), &tags); err != nil {
+			return "", nil, fmt.Errorf("failed to parse connection tags from schema comment: %s", err.Error())
+		}
+	}
+	return remoteSchema, tags, nil
+}
+
+// GetSchemaCommentQuery returns the sql to set the comment on a connection schema (see GetSchemaComment)
+func GetSchemaCommentQuery(localSchema, remoteSchema string, tags map[string]string) string {
+	return fmt.Sprintf("comment on schema %s is %s;\n", PgEscapeName(localSchema), PgEscapeString(GetSchemaComment(remoteSchema, tags)))
+}
+
+// GetUpdateConnectionQuery returns the sql to (re)create the schema for a connection, granting the given privileges
+// on its tables to steampipe users (if no privileges are given, constants.DefaultGrantPrivileges are granted)
+// the schema comment includes the given connection tags
+func GetUpdateConnectionQuery(localSchema, remoteSchema string, privileges []string, tags map[string]string) string {
+	// build the comment query before escaping the name
+	commentQuery := GetSchemaCommentQuery(localSchema, remoteSchema, tags)
 	// escape the name
 	localSchema = PgEscapeName(localSchema)
 
@@ -36,21 +87,22 @@ func GetUpdateConnectionQuery(localSchema, remoteSchema string) string {
 	// are owned by the root user.
 	statements.WriteString(fmt.Sprintf("drop schema if exists %s cascade;\n", localSchema))
 	statements.WriteString(fmt.Sprintf("create schema %s;\n", localSchema))
-	statements.WriteString(fmt.Sprintf("comment on schema %s is 'steampipe plugin: %s';\n", localSchema, remoteSchema))
+	statements.WriteString(commentQuery)
 
 	// Steampipe users are allowed to use the new schema
 	statements.WriteString(fmt.Sprintf("grant usage on schema %s to steampipe_users;\n", localSchema))
 
-	// Permissions are limited to select only, and should be granted for all new
+	// Permissions are limited to the configured privileges (select only by default), and should be granted for all new
 	// objects. Steampipe users cannot create tables or modify data in the
 	// connection schema - they need to use the public schema for that.  These
 	// commands alter the defaults for any objects created in the future.
 	// See https://www.postgresql.org/docs/12/ddl-priv.html
-	statements.WriteString(fmt.Sprintf("alter default privileges in schema %s grant select on tables to steampipe_users;\n", localSchema))
+	grants := grantPrivilegesSQL(privileges)
+	statements.WriteString(fmt.Sprintf("alter default privileges in schema %s grant %s on tables to steampipe_users;\n", localSchema, grants))
 
 	// If there are any objects already then grant their permissions now. (This
 	// should not actually do anything at this point.)
-	statements.WriteString(fmt.Sprintf("grant select on all tables in schema %s to steampipe_users;\n", localSchema))
+	statements.WriteString(fmt.Sprintf("grant %s on all tables in schema %s to steampipe_users;\n", grants, localSchema))
 
 	// Import the foreign schema into this connection.
 	statements.WriteString(fmt.Sprintf("import foreign schema \"%s\" from server steampipe into %s;\n", remoteSchema, localSchema))
@@ -58,6 +110,33 @@ func GetUpdateConnectionQuery(localSchema, remoteSchema string) string {
 	return statements.String()
 }
 
+// GetGrantPrivilegesQuery returns the sql to replace the privileges granted to steampipe users on the tables of
+// an existing connection schema - this is used for cloned schemas, which are created with select privileges
+func GetGrantPrivilegesQuery(localSchema string, privileges []string) string {
+	localSchema = PgEscapeName(localSchema)
+	grants := grantPrivilegesSQL(privileges)
+
+	var statements strings.Builder
+	statements.WriteString(fmt.Sprintf("alter default privileges in schema %s revoke all on tables from steampipe_users;\n", localSchema))
+	statements.WriteString(fmt.Sprintf("alter default privileges in schema %s grant %s on tables to steampipe_users;\n", localSchema, grants))
+	statements.WriteString(fmt.Sprintf("revoke all on all tables in schema %s from steampipe_users;\n", localSchema))
+	statements.WriteString(fmt.Sprintf("grant %s on all tables in schema %s to steampipe_users;\n", grants, localSchema))
+	return statements.String()
+}
+
+// grantPrivilegesSQL renders the privileges for use in a grant statement
+// NOTE: privileges are validated against constants.GrantablePrivileges when the config is loaded
+func grantPrivilegesSQL(privileges []string) string {
+	if len(privileges) == 0 {
+		privileges = constants.DefaultGrantPrivileges
+	}
+	res := make([]string, len(privileges))
+	for i, p := range privileges {
+		res[i] = strings.ToLower(p)
+	}
+	return strings.Join(res, ", ")
+}
+
 func GetDeleteConnectionQuery(name string) string {
 	return fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE;\n", PgEscapeName(name))
 }
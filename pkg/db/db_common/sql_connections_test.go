@@ -0,0 +1,81 @@
+package db_common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type updateConnectionQueryTest struct {
+	privileges []string
+	expected   []string
+}
+
+var testCasesUpdateConnectionQuery = map[string]updateConnectionQueryTest{
+	"default": {
+		privileges: nil,
+		expected: []string{
+			`alter default privileges in schema "aws" grant select on tables to steampipe_users;`,
+			`grant select on all tables in schema "aws" to steampipe_users;`,
+		},
+	},
+	"custom": {
+		privileges: []string{"SELECT", "references", "trigger"},
+		expected: []string{
+			`alter default privileges in schema "aws" grant select, references, trigger on tables to steampipe_users;`,
+			`grant select, references, trigger on all tables in schema "aws" to steampipe_users;`,
+		},
+	},
+}
+
+func TestGetUpdateConnectionQuery(t *testing.T) {
+	for name, test := range testCasesUpdateConnectionQuery {
+		sql := GetUpdateConnectionQuery("aws", "hub.steampipe.io/plugins/turbot/aws@latest", test.privileges, nil)
+		for _, statement := range test.expected {
+			if !strings.Contains(sql, statement) {
+				t.Errorf("Test: '%s' FAILED : expected query to contain:\n%s\ngot:\n%s", name, statement, sql)
+			}
+		}
+	}
+}
+
+type schemaCommentTest struct {
+	tags     map[string]string
+	expected string
+}
+
+var testCasesSchemaComment = map[string]schemaCommentTest{
+	"no tags": {
+		expected: `comment on schema "aws" is $steampipe_escape$steampipe plugin: aws$steampipe_escape$;`,
+	},
+	"tags": {
+		tags:     map[string]string{"team": "infra", "env": "prod"},
+		expected: `comment on schema "aws" is $steampipe_escape$steampipe plugin: aws | {"env":"prod","team":"infra"}$steampipe_escape$;`,
+	},
+	"tags containing separator and quotes": {
+		tags:     map[string]string{"owner": "a | b's"},
+		expected: `comment on schema "aws" is $steampipe_escape$steampipe plugin: aws | {"owner":"a | b's"}$steampipe_escape$;`,
+	},
+}
+
+func TestSchemaComment(t *testing.T) {
+	for name, test := range testCasesSchemaComment {
+		sql := GetUpdateConnectionQuery("aws", "aws", nil, test.tags)
+		if !strings.Contains(sql, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected query to contain:\n%s\ngot:\n%s", name, test.expected, sql)
+		}
+
+		// the plugin and tags must be extractable from the comment
+		remoteSchema, tags, err := ParseSchemaComment(GetSchemaComment("aws", test.tags))
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error parsing comment: %s", name, err)
+			continue
+		}
+		if remoteSchema != "aws" {
+			t.Errorf("Test: '%s' FAILED : expected plugin 'aws', got '%s'", name, remoteSchema)
+		}
+		if len(test.tags) > 0 && !reflect.DeepEqual(tags, test.tags) {
+			t.Errorf("Test: '%s' FAILED : expected tags %v, got %v", name, test.tags, tags)
+		}
+	}
+}
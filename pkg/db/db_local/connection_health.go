@@ -0,0 +1,80 @@
+package db_local
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/introspection"
+	pluginshared "github.com/turbot/steampipe/pkg/pluginmanager_service/grpc/shared"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// connectionProbe tests a connection, returning the name of the table which was queried
+type connectionProbe func(ctx context.Context, connectionName string) (string, error)
+
+// CheckConnectionHealth probes each of the given connections by reading a single row from one of the connection tables,
+// and records the status and latency of each probe in the connection state table
+// (these are exposed by the steampipe_connection_health view)
+//
+// if no connections are given, all connections other than aggregators are checked
+func CheckConnectionHealth(ctx context.Context, pluginManager pluginshared.PluginManager, connectionNames ...string) ([]*steampipeconfig.ConnectionHealth, error) {
+	if len(connectionNames) == 0 {
+		connectionNames = healthCheckConnectionNames(steampipeconfig.GlobalConfig.Connections)
+	}
+
+	probe := func(ctx context.Context, connectionName string) (string, error) {
+		return steampipeconfig.TestConnection(ctx, pluginManager, connectionName)
+	}
+	health := checkConnectionHealth(ctx, probe, connectionNames)
+	if ctx.Err() != nil {
+		return health, ctx.Err()
+	}
+
+	rootConn, err := CreateLocalDbConnection(ctx, &CreateDbOptions{Username: constants.DatabaseSuperUser})
+	if err != nil {
+		return health, err
+	}
+	defer rootConn.Close(ctx)
+
+	table := steampipeconfig.ConnectionStateTableFromConfig()
+	var queries []db_common.QueryWithArgs
+	for _, h := range health {
+		queries = append(queries, introspection.GetSetConnectionHealthSql(table, h)...)
+	}
+	_, err = ExecuteSqlWithArgsInTransaction(ctx, rootConn, queries...)
+	return health, err
+}
+
+// checkConnectionHealth probes each connection in turn, timing the probe
+func checkConnectionHealth(ctx context.Context, probe connectionProbe, connectionNames []string) []*steampipeconfig.ConnectionHealth {
+	health := make([]*steampipeconfig.ConnectionHealth, 0, len(connectionNames))
+	for _, connectionName := range connectionNames {
+		if ctx.Err() != nil {
+			break
+		}
+		start := time.Now()
+		table, err := probe(ctx, connectionName)
+		h := steampipeconfig.NewConnectionHealth(connectionName, table, time.Since(start), err, start)
+		log.Printf("[INFO] connection '%s' health check: %s (%dms)", connectionName, h.Status, h.LatencyMs)
+		health = append(health, h)
+	}
+	return health
+}
+
+// healthCheckConnectionNames returns the names of the connections which can be health checked, in name order
+// aggregators are excluded - their child connections are checked instead
+func healthCheckConnectionNames(connections map[string]*modconfig.Connection) []string {
+	var res []string
+	for _, name := range utils.SortedMapKeys(connections) {
+		if connections[name].Type == modconfig.ConnectionTypeAggregator {
+			continue
+		}
+		res = append(res, name)
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package db_local
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func TestCheckConnectionHealth(t *testing.T) {
+	var probed []string
+	probe := func(_ context.Context, connectionName string) (string, error) {
+		probed = append(probed, connectionName)
+		if connectionName == "b" {
+			return "b_table", errors.New("invalid credentials")
+		}
+		return connectionName + "_table", nil
+	}
+
+	health := checkConnectionHealth(context.Background(), probe, []string{"a", "b"})
+	if !reflect.DeepEqual(probed, []string{"a", "b"}) {
+		t.Fatalf("expected connections a and b to be probed, got %v", probed)
+	}
+	if len(health) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(health))
+	}
+	if h := health[0]; h.ConnectionName != "a" || !h.Healthy() || h.Table != "a_table" || h.Error != "" || h.CheckedAt.IsZero() {
+		t.Errorf("expected connection a to be healthy, got %+v", h)
+	}
+	if h := health[1]; h.ConnectionName != "b" || h.Healthy() || h.Status != constants.ConnectionHealthError || h.Error != "invalid credentials" {
+		t.Errorf("expected connection b to be unhealthy, got %+v", h)
+	}
+
+	// no further connections are probed once the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	probed = nil
+	cancelProbe := func(_ context.Context, connectionName string) (string, error) {
+		probed = append(probed, connectionName)
+		cancel()
+		return "", context.Canceled
+	}
+	health = checkConnectionHealth(ctx, cancelProbe, []string{"a", "b"})
+	if len(health) != 1 || !reflect.DeepEqual(probed, []string{"a"}) {
+		t.Errorf("expected only connection a to be probed, got %v", probed)
+	}
+}
+
+func TestHealthCheckConnectionNames(t *testing.T) {
+	connections := map[string]*modconfig.Connection{
+		"c":   {Name: "c"},
+		"a":   {Name: "a"},
+		"all": {Name: "all", Type: modconfig.ConnectionTypeAggregator},
+		"b":   {Name: "b"},
+	}
+	expected := []string{"a", "b", "c"}
+	if names := healthCheckConnectionNames(connections); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -72,6 +73,70 @@ func getLocalSteampipeConnectionString(opts *CreateDbOptions) (string, error) {
 
 type CreateDbOptions struct {
 	DatabaseName, Username string
+	// the application_name of the connections - if this is not set, the service application name is used
+	ApplicationName string
+}
+
+func (o *CreateDbOptions) applicationName() string {
+	if o == nil || o.ApplicationName == "" {
+		return runtime.ServiceConnectionAppName
+	}
+	return o.ApplicationName
+}
+
+// RefreshConnectionAppName returns the application name of the connections used to refresh connections
+// this is the configured prefix (which is forced to start with the service prefix, so the service recognises
+// these connections as its own) followed by the ID of the refresh, so the sessions of a refresh can be identified
+// in pg_stat_activity - if there is no refresh ID, the execution ID is used
+func RefreshConnectionAppName(refreshID string) string {
+	prefix := viper.GetString(constants.ArgRefreshApplicationName)
+	if prefix == "" {
+		prefix = constants.RefreshConnectionAppNamePrefix
+	}
+	if !strings.HasPrefix(prefix, constants.ServiceConnectionAppNamePrefix) {
+		prefix = fmt.Sprintf("%s_%s", constants.ServiceConnectionAppNamePrefix, prefix)
+	}
+	if refreshID == "" {
+		refreshID = runtime.ExecutionID
+	}
+	return fmt.Sprintf("%s_%s", prefix, refreshID)
+}
+
+type applicationNameContextKey struct{}
+
+// WithApplicationName returns a context which sets the application_name of any connection pool session acquired
+// using it - this allows the sessions used by a single operation (e.g. a refresh) of a long-lived pool to be
+// identified in pg_stat_activity
+func WithApplicationName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, applicationNameContextKey{}, name)
+}
+
+// maxApplicationNameLength is the length Postgres truncates application_name to (NAMEDATALEN - 1)
+const maxApplicationNameLength = 63
+
+// setSessionApplicationName returns the BeforeAcquire hook of a connection pool - this sets the application_name
+// of the acquired session to the name in the acquiring context (see WithApplicationName),
+// or to the application name of the pool if there is none
+func setSessionApplicationName(poolApplicationName string) func(context.Context, *pgx.Conn) bool {
+	return func(ctx context.Context, conn *pgx.Conn) bool {
+		name, ok := ctx.Value(applicationNameContextKey{}).(string)
+		if !ok {
+			name = poolApplicationName
+		}
+		if len(name) > maxApplicationNameLength {
+			name = name[:maxApplicationNameLength]
+		}
+		// (the server reports changes to application_name, so the current value is known)
+		if conn.PgConn().ParameterStatus(constants.RuntimeParamsKeyApplicationName) == name {
+			return true
+		}
+		if _, err := conn.Exec(ctx, "select set_config('application_name', $1, false)", name); err != nil {
+			// discard the session rather than use it with the wrong application name
+			log.Printf("[WARN] failed to set application_name '%s': %s", name, err.Error())
+			return false
+		}
+		return true
+	}
 }
 
 // CreateLocalDbConnection connects and returns a connection to the given database using
@@ -96,7 +161,7 @@ func CreateLocalDbConnection(ctx context.Context, opts *CreateDbOptions) (*pgx.C
 	// set an app name so that we can track database connections from this Steampipe execution
 	// this is used to determine whether the database can safely be closed
 	connConfig.Config.RuntimeParams = map[string]string{
-		constants.RuntimeParamsKeyApplicationName: runtime.ServiceConnectionAppName,
+		constants.RuntimeParamsKeyApplicationName: opts.applicationName(),
 	}
 	err = db_common.AddRootCertToConfig(&connConfig.Config, filepaths.GetRootCertLocation())
 	if err != nil {
@@ -114,7 +179,7 @@ func CreateLocalDbConnection(ctx context.Context, opts *CreateDbOptions) (*pgx.C
 	return conn, nil
 }
 
-// CreateConnectionPool
+// CreateConnectionPool creates a connection pool to the local database, establishing all connections before returning
 func CreateConnectionPool(ctx context.Context, opts *CreateDbOptions, maxConnections int) (*pgxpool.Pool, error) {
 	utils.LogTime("db_client.establishConnectionPool start")
 	defer utils.LogTime("db_client.establishConnectionPool end")
@@ -124,6 +189,39 @@ func CreateConnectionPool(ctx context.Context, opts *CreateDbOptions, maxConnect
 		return nil, err
 	}
 
+	poolConfig, err := newConnectionPoolConfig(psqlInfo, opts, maxConnections)
+	if err != nil {
+		return nil, err
+	}
+
+	// this returns connection pool
+	dbPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db_common.WaitForPool(
+		ctx,
+		dbPool,
+		db_common.WithRetryInterval(constants.DBConnectionRetryBackoff),
+		db_common.WithTimeout(time.Duration(viper.GetInt(constants.ArgDatabaseStartTimeout))*time.Second),
+	)
+	if err != nil {
+		dbPool.Close()
+		return nil, sperr.WrapWithMessage(err, "failed to connect to the database")
+	}
+
+	// establish all the pool connections now, so a problem connecting fails fast here
+	if err := warmConnectionPool(ctx, dbPool); err != nil {
+		dbPool.Close()
+		return nil, err
+	}
+	return dbPool, nil
+}
+
+// newConnectionPoolConfig builds the config for a connection pool using the given connection string
+// the application name is set in the connection config, so every pooled connection carries it
+func newConnectionPoolConfig(psqlInfo string, opts *CreateDbOptions, maxConnections int) (*pgxpool.Config, error) {
 	poolConfig, err := pgxpool.ParseConfig(psqlInfo)
 	if err != nil {
 		return nil, err
@@ -140,25 +238,53 @@ func CreateConnectionPool(ctx context.Context, opts *CreateDbOptions, maxConnect
 	poolConfig.MaxConnIdleTime = connMaxIdleTime
 
 	poolConfig.ConnConfig.Config.RuntimeParams = map[string]string{
-		constants.RuntimeParamsKeyApplicationName: runtime.ServiceConnectionAppName,
+		constants.RuntimeParamsKeyApplicationName: opts.applicationName(),
 	}
+	poolConfig.BeforeAcquire = setSessionApplicationName(opts.applicationName())
+	return poolConfig, nil
+}
 
-	// this returns connection pool
-	dbPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
-	if err != nil {
-		return nil, err
+// warmConnectionPool pings the database, then establishes all pool connections up front by concurrently
+// acquiring (and then releasing) the maximum number of connections
+// this ensures a fundamental problem connecting to the database (e.g. bad config or authentication) fails with
+// a clear error, rather than surfacing as failures scattered across the work which uses the pool
+func warmConnectionPool(ctx context.Context, pool *pgxpool.Pool) error {
+	if err := pool.Ping(ctx); err != nil {
+		return sperr.WrapWithMessage(err, "database connection pool health check failed")
 	}
 
-	err = db_common.WaitForPool(
-		ctx,
-		dbPool,
-		db_common.WithRetryInterval(constants.DBConnectionRetryBackoff),
-		db_common.WithTimeout(time.Duration(viper.GetInt(constants.ArgDatabaseStartTimeout))*time.Second),
-	)
-	if err != nil {
-		return nil, err
+	maxConns := int(pool.Config().MaxConns)
+	conns := make([]*pgxpool.Conn, 0, maxConns)
+	defer func() {
+		for _, conn := range conns {
+			conn.Release()
+		}
+	}()
+
+	var mut sync.Mutex
+	var wg sync.WaitGroup
+	var errs []error
+	for i := 0; i < maxConns; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := pool.Acquire(ctx)
+			mut.Lock()
+			defer mut.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			conns = append(conns, conn)
+		}()
 	}
-	return dbPool, nil
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return sperr.WrapWithMessage(errs[0], "database connection pool health check failed - established %d of %d connections", len(conns), maxConns)
+	}
+	log.Printf("[TRACE] warmed connection pool: %d connections established", len(conns))
+	return nil
 }
 
 // createMaintenanceClient connects to the postgres server using the
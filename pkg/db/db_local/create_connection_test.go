@@ -0,0 +1,90 @@
+package db_local
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/constants/runtime"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+)
+
+func TestWarmConnectionPoolFailsFast(t *testing.T) {
+	// find a port which nothing is listening on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	poolConfig, err := pgxpool.ParseConfig(fmt.Sprintf("host=127.0.0.1 port=%d user=steampipe dbname=steampipe sslmode=disable connect_timeout=5", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolConfig.MaxConns = 5
+	// the pool connects lazily, so creating it succeeds
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	start := time.Now()
+	err = warmConnectionPool(context.Background(), pool)
+	if err == nil {
+		t.Fatal("expected warming a pool which cannot connect to fail")
+	}
+	if !strings.HasPrefix(err.Error(), "database connection pool health check failed") {
+		t.Errorf("expected a clear health check error, got '%s'", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the health check to fail fast, took %s", elapsed)
+	}
+}
+
+func TestConnectionPoolApplicationName(t *testing.T) {
+	defer viper.Reset()
+	psqlInfo := "host=127.0.0.1 port=9193 user=root dbname=steampipe sslmode=disable"
+
+	testCases := map[string]struct {
+		configuredPrefix string
+		opts             *CreateDbOptions
+		expected         string
+	}{
+		"default":           {"", &CreateDbOptions{}, runtime.ServiceConnectionAppName},
+		"no options":        {"", nil, runtime.ServiceConnectionAppName},
+		"refresh":           {"", &CreateDbOptions{ApplicationName: RefreshConnectionAppName("")}, "steampipe_service_refresh_" + runtime.ExecutionID},
+		"refresh id":        {"", &CreateDbOptions{ApplicationName: RefreshConnectionAppName("refresh-1")}, "steampipe_service_refresh_refresh-1"},
+		"configured prefix": {"steampipe_service_nightly", nil, "steampipe_service_nightly_" + runtime.ExecutionID},
+		"unprefixed name":   {"nightly", nil, "steampipe_service_nightly_" + runtime.ExecutionID},
+	}
+	for name, test := range testCases {
+		viper.Set(constants.ArgRefreshApplicationName, test.configuredPrefix)
+		opts := test.opts
+		if test.configuredPrefix != "" {
+			opts = &CreateDbOptions{ApplicationName: RefreshConnectionAppName("")}
+		}
+		poolConfig, err := newConnectionPoolConfig(psqlInfo, opts, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := poolConfig.ConnConfig.RuntimeParams[constants.RuntimeParamsKeyApplicationName]; actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected application_name '%s', got '%s'", name, test.expected, actual)
+		}
+		// sessions are given the application name of the acquiring context (see WithApplicationName) when acquired
+		if poolConfig.BeforeAcquire == nil {
+			t.Errorf("Test: '%s' FAILED : expected the pool to set the application_name of acquired sessions", name)
+		}
+		// refresh sessions must be recognised as service sessions, so they do not prevent the service stopping
+		if !db_common.IsServiceAppName(test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected '%s' to be a service application name", name, test.expected)
+		}
+	}
+}
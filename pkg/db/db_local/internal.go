@@ -207,6 +207,8 @@ func validateFunction(f db_common.SQLFunction) error {
 - write back connection state
 */
 func initializeConnectionStateTable(ctx context.Context, conn *pgx.Conn) error {
+	table := steampipeconfig.ConnectionStateTableFromConfig()
+
 	// load the state (if the table is there)
 	connectionStateMap, err := steampipeconfig.LoadConnectionState(ctx, conn)
 	if err != nil {
@@ -226,13 +228,14 @@ func initializeConnectionStateTable(ctx context.Context, conn *pgx.Conn) error {
 	connectionStateMap.PopulateFilename()
 
 	// drop the table and recreate
-	queries := introspection.GetConnectionStateTableDropSql()
-	queries = append(queries, introspection.GetConnectionStateTableCreateSql()...)
-	queries = append(queries, introspection.GetConnectionStateTableGrantSql()...)
+	queries := introspection.GetConnectionStateTableDropSql(table)
+	queries = append(queries, introspection.GetConnectionStateTableCreateSql(table)...)
+	queries = append(queries, introspection.GetConnectionStateTableGrantSql(table)...)
+	queries = append(queries, introspection.GetConnectionHealthViewCreateSql(table)...)
 
 	// add insert queries for all connection state
 	for _, s := range connectionStateMap {
-		queries = append(queries, introspection.GetUpsertConnectionStateSql(s)...)
+		queries = append(queries, introspection.GetUpsertConnectionStateSql(table, s)...)
 	}
 
 	// for any connection in the connection config but NOT in the connection state table,
@@ -240,7 +243,7 @@ func initializeConnectionStateTable(ctx context.Context, conn *pgx.Conn) error {
 	// we wait for connection state before RefreshConnections has added any new connections into the state table
 	for connection, connectionConfig := range steampipeconfig.GlobalConfig.Connections {
 		if _, ok := connectionStateMap[connection]; !ok {
-			queries = append(queries, introspection.GetNewConnectionStateFromConnectionInsertSql(connectionConfig)...)
+			queries = append(queries, introspection.GetNewConnectionStateFromConnectionInsertSql(table, connectionConfig)...)
 		}
 	}
 	_, err = ExecuteSqlWithArgsInTransaction(ctx, conn, queries...)
@@ -0,0 +1,34 @@
+package db_local
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/turbot/steampipe/pkg/connection/refresh_rpc"
+	"github.com/turbot/steampipe/pkg/filepaths"
+)
+
+// importLazyConnection asks the refresh service of the plugin manager to import the schema of a connection
+// with import_schema="lazy" - it is called by the db client the first time the connection is queried
+func importLazyConnection(_ context.Context, connectionName string) error {
+	log.Printf("[INFO] importing lazy connection '%s'", connectionName)
+
+	client, err := refresh_rpc.NewClient(filepaths.RefreshServiceSocketPath())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	res, err := client.RefreshConnection(connectionName)
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if failure, ok := res.FailedConnections[connectionName]; ok {
+		return errors.New(failure)
+	}
+	return nil
+}
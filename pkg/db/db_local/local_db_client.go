@@ -11,7 +11,7 @@ import (
 	"github.com/turbot/steampipe/pkg/db/db_client"
 	"github.com/turbot/steampipe/pkg/db/db_common"
 	"github.com/turbot/steampipe/pkg/error_helpers"
-	pb "github.com/turbot/steampipe/pkg/pluginmanager_service/grpc/proto"
+	"github.com/turbot/steampipe/pkg/pluginmanager"
 	"github.com/turbot/steampipe/pkg/utils"
 )
 
@@ -53,14 +53,7 @@ func GetLocalClient(ctx context.Context, invoker constants.Invoker, onConnection
 
 	// after creating the client, refresh connections
 	// NOTE: we cannot do this until after creating the client to ensure we do not miss notifications
-	if startResult.Status == ServiceStarted {
-		// ask the plugin manager to refresh connections
-		// this is executed asyncronously by the plugin manager
-		// we ignore this error, since RefreshConnections is async and all errors will flow through
-		// the notification system
-		// we do not expect any I/O errors on this since the PluginManager is running in the same box
-		_, _ = startResult.PluginManager.RefreshConnections(&pb.RefreshConnectionsRequest{})
-	}
+	startResult.RequestConnectionRefresh(pluginmanager.NewRefreshConnectionsRequest())
 
 	return client, &startResult.ErrorAndWarnings
 }
@@ -75,6 +68,9 @@ func newLocalClient(ctx context.Context, invoker constants.Invoker, onConnection
 	if err != nil {
 		return nil, err
 	}
+	// lazy connections are imported by the plugin manager when they are first queried
+	// (this is added first so the caller may override it)
+	opts = append([]db_client.ClientOption{db_client.WithLazyConnectionImporter(importLazyConnection)}, opts...)
 	dbClient, err := db_client.NewDbClient(ctx, connString, onConnectionCallback, opts...)
 	if err != nil {
 		log.Printf("[TRACE] error getting local client %s", err.Error())
@@ -0,0 +1,50 @@
+package db_local
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// ShowRefreshProgress displays the progress of the refresh with the given ID using the status hooks of the context
+// the progress is sent by the plugin manager as refresh progress notifications
+// the returned function stops listening for notifications
+// NOTE: displaying the progress is best effort - if the notifications cannot be listened to, this is logged
+func ShowRefreshProgress(ctx context.Context, refreshID string) func() {
+	conn, err := CreateLocalDbConnection(ctx, &CreateDbOptions{Username: constants.DatabaseSuperUser})
+	if err != nil {
+		log.Printf("[WARN] failed to listen for refresh progress notifications: %s", err.Error())
+		return func() {}
+	}
+	listener, err := db_common.NewNotificationListener(ctx, conn)
+	if err != nil {
+		log.Printf("[WARN] failed to listen for refresh progress notifications: %s", err.Error())
+		return func() {}
+	}
+	listener.RegisterListener(func(notification *pgconn.Notification) {
+		if status, ok := refreshProgressStatus(notification, refreshID); ok {
+			statushooks.SetStatus(ctx, status)
+		}
+	})
+	return func() { listener.Stop(ctx) }
+}
+
+// refreshProgressStatus returns the status reported by the notification, if it is a progress notification
+// of the given refresh
+func refreshProgressStatus(notification *pgconn.Notification, refreshID string) (string, bool) {
+	n := &steampipeconfig.RefreshProgressNotification{}
+	if err := json.Unmarshal([]byte(notification.Payload), n); err != nil {
+		log.Printf("[WARN] Error unmarshalling notification: %s", err)
+		return "", false
+	}
+	if n.Type != steampipeconfig.PgNotificationRefreshProgress || n.RefreshID != refreshID {
+		return "", false
+	}
+	return n.Status, true
+}
@@ -0,0 +1,38 @@
+package db_local
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+func TestRefreshProgressStatus(t *testing.T) {
+	progress := steampipeconfig.NewRefreshProgressNotification("Created 1 of 2 connections")
+	progress.RefreshID = "refresh-1"
+	errors := steampipeconfig.NewErrorsAndWarningsNotification(&error_helpers.ErrorAndWarnings{Warnings: []string{"a warning"}})
+	errors.RefreshID = "refresh-1"
+
+	type progressTest struct {
+		notification   any
+		expectedStatus string
+		expectedOk     bool
+	}
+	tests := map[string]progressTest{
+		"progress of the refresh":         {progress, "Created 1 of 2 connections", true},
+		"other notification type":         {errors, "", false},
+		"progress of a different refresh": {steampipeconfig.NewRefreshProgressNotification("Commenting 1 of 1 connection (aws)"), "", false},
+	}
+	for name, test := range tests {
+		payload, err := json.Marshal(test.notification)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status, ok := refreshProgressStatus(&pgconn.Notification{Payload: string(payload)}, "refresh-1")
+		if status != test.expectedStatus || ok != test.expectedOk {
+			t.Errorf("Test: '%s' FAILED : expected '%s' (%v), got '%s' (%v)", name, test.expectedStatus, test.expectedOk, status, ok)
+		}
+	}
+}
@@ -7,46 +7,65 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/db/db_common"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/turbot/steampipe/pkg/utils"
 )
 
-func SetUserSearchPath(ctx context.Context, pool *pgxpool.Pool) ([]string, error) {
-	var searchPath []string
+// SetUserSearchPath sets the search path for all steampipe users, returning the search path
+// and the connection schemas which were omitted from it due to the configured search path limit
+func SetUserSearchPath(ctx context.Context, pool *pgxpool.Pool) (searchPath []string, omitted []string, err error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Release()
 
-	// is there a user search path in the config?
-	// check ConfigKeyDatabaseSearchPath config (this is the value specified in the database config)
-	if viper.IsSet(constants.ConfigKeyServerSearchPath) {
+	return setUserSearchPath(ctx, conn.Conn())
+}
 
-		searchPath = viper.GetStringSlice(constants.ConfigKeyServerSearchPath)
-		// the Internal Schema should always go at the end
-		searchPath = db_common.EnsureInternalSchemaSuffix(searchPath)
-	} else {
-		// no config set - set user search path to default
-		// - which is all the connection names, book-ended with public and internal
-		searchPath = getDefaultSearchPath()
+// ApplyUserSearchPath sets the search path for all steampipe users of the running service,
+// and notifies connected clients so they reload the search path - connections are not refreshed
+// the search path overrides of the active workspace profile are saved, so the plugin manager
+// continues to apply them when it refreshes connections
+func ApplyUserSearchPath(ctx context.Context) (searchPath []string, omitted []string, err error) {
+	if err := saveSearchPathOverride(steampipeconfig.GlobalWorkspaceProfile); err != nil {
+		return nil, nil, err
 	}
 
-	// escape the schema names
-	escapedSearchPath := db_common.PgEscapeSearchPath(searchPath)
-
-	log.Println("[TRACE] setting user search path to", searchPath)
+	rootConn, err := CreateLocalDbConnection(ctx, &CreateDbOptions{Username: constants.DatabaseSuperUser})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rootConn.Close(ctx)
 
-	// get all roles which are a member of steampipe_users
-	conn, err := pool.Acquire(ctx)
+	searchPath, omitted, err = setUserSearchPath(ctx, rootConn)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer conn.Release()
+	if err := SendPostgresNotification(ctx, rootConn, steampipeconfig.NewSchemaUpdateNotification()); err != nil {
+		// the search path has been set - clients pick it up when they next start a session
+		log.Printf("[WARN] failed to send search path notification: %s", err.Error())
+	}
+	return searchPath, omitted, nil
+}
 
+func setUserSearchPath(ctx context.Context, conn *pgx.Conn) (searchPath []string, omitted []string, err error) {
+	searchPath, omitted = computeServiceSearchPath()
+
+	log.Println("[TRACE] setting user search path to", searchPath)
+
+	// get all roles which are a member of steampipe_users
 	query := fmt.Sprintf(`SELECT USENAME FROM pg_user WHERE pg_has_role(usename, '%s', 'member')`, constants.DatabaseUsersRole)
 	rows, err := conn.Query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// set the search path for all these roles
@@ -57,7 +76,7 @@ func SetUserSearchPath(ctx context.Context, pool *pgxpool.Pool) ([]string, error
 	for rows.Next() {
 		var user string
 		if err := rows.Scan(&user); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if user == "root" {
 			continue
@@ -65,24 +84,101 @@ func SetUserSearchPath(ctx context.Context, pool *pgxpool.Pool) ([]string, error
 		queries = append(queries, fmt.Sprintf(
 			"ALTER USER %s SET SEARCH_PATH TO %s;",
 			db_common.PgEscapeName(user),
-			strings.Join(escapedSearchPath, ","),
+			FormatSearchPath(searchPath),
 		))
 	}
 
 	log.Printf("[TRACE] user search path sql: %v", queries)
-	_, err = ExecuteSqlInTransaction(ctx, conn.Conn(), queries...)
+	_, err = ExecuteSqlInTransaction(ctx, conn, queries...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return searchPath, omitted, nil
+}
+
+// ComputeUserSearchPath returns the search path which SetUserSearchPath sets for steampipe users,
+// and the connection schemas omitted from it due to the configured search path limit
+// this does not connect to the database, so may be used to report the search path without applying it
+func ComputeUserSearchPath() (searchPath []string, omitted []string) {
+	searchPath, omitted = computeConfiguredUserSearchPath()
+	// apply any overrides from the workspace profile (these are managed by 'steampipe search-path')
+	return applySearchPathOverrides(searchPath, omitted, steampipeconfig.GlobalWorkspaceProfile)
+}
+
+// computeServiceSearchPath returns the search path to set for the steampipe users of the running service
+// this applies the search path override saved by the CLI (see saveSearchPathOverride) - if there is none,
+// the overrides of the workspace profile are applied
+func computeServiceSearchPath() (searchPath []string, omitted []string) {
+	searchPath, omitted = computeConfiguredUserSearchPath()
+
+	override, err := loadSearchPathOverride()
 	if err != nil {
-		return nil, err
+		log.Printf("[WARN] failed to load the search path override - using the workspace profile: %s", err.Error())
 	}
-	return searchPath, nil
+	if override == nil {
+		return applySearchPathOverrides(searchPath, omitted, steampipeconfig.GlobalWorkspaceProfile)
+	}
+	log.Printf("[TRACE] applying the search path override of workspace '%s'", override.Workspace)
+	return override.apply(searchPath, omitted)
+}
+
+// computeConfiguredUserSearchPath returns the search path from the database options,
+// or the default search path if none is configured
+func computeConfiguredUserSearchPath() (searchPath []string, omitted []string) {
+	// is there a user search path in the config?
+	// check ConfigKeyDatabaseSearchPath config (this is the value specified in the database config)
+	if viper.IsSet(constants.ConfigKeyServerSearchPath) {
+		searchPath = viper.GetStringSlice(constants.ConfigKeyServerSearchPath)
+		// the Internal Schema should always go at the end
+		return db_common.EnsureInternalSchemaSuffix(searchPath), nil
+	}
+	// no config set - set user search path to default
+	// - which is all the connection names, book-ended with public and internal
+	// apply the search path limit (if any), retaining the schemas of the database search_path_prefix first
+	// schemas which are omitted may still be queried using their schema prefix
+	priority := viper.GetStringSlice(constants.ConfigKeyServerSearchPathPrefix)
+	return limitSearchPath(getDefaultSearchPath(), priority, viper.GetInt(constants.ArgSearchPathLimit))
+}
+
+// applySearchPathOverrides applies the search_path and search_path_prefix of the workspace profile (if any)
+// a search path replaces the computed search path, a prefix is added in front of it
+// the internal schema is always kept at the end
+func applySearchPathOverrides(searchPath, omitted []string, profile *modconfig.WorkspaceProfile) ([]string, []string) {
+	if profile == nil {
+		return searchPath, omitted
+	}
+	return applySearchPathOverride(searchPath, omitted, profile.GetSearchPath(), profile.GetSearchPathPrefix())
+}
+
+func applySearchPathOverride(searchPath, omitted, overrideSearchPath, searchPathPrefix []string) ([]string, []string) {
+	if len(overrideSearchPath)+len(searchPathPrefix) == 0 {
+		return searchPath, omitted
+	}
+
+	if len(overrideSearchPath) > 0 {
+		// the search path is no longer limited, so nothing is omitted
+		searchPath = overrideSearchPath
+		omitted = nil
+	}
+	// schemas in the prefix are no longer omitted
+	omitted = helpers.RemoveFromStringSlice(omitted, searchPathPrefix...)
+	searchPath = db_common.AddSearchPathPrefix(searchPathPrefix, searchPath)
+	return db_common.EnsureInternalSchemaSuffix(searchPath), omitted
+}
+
+// FormatSearchPath returns the search path in the form it is applied to steampipe users,
+// i.e. the escaped schema names separated by commas
+func FormatSearchPath(searchPath []string) string {
+	return strings.Join(db_common.PgEscapeSearchPath(searchPath), ",")
 }
 
 // GetDefaultSearchPath builds default search path from the connection schemas, book-ended with public and internal
 func getDefaultSearchPath() []string {
 	// add all connections to the seatrch path (UNLESS ImportSchema is disabled)
+	// lazy connections are included - a schema in the search path which does not exist yet is ignored by postgres
 	var searchPath []string
 	for connectionName, connection := range steampipeconfig.GlobalConfig.Connections {
-		if connection.ImportSchema == modconfig.ImportSchemaEnabled {
+		if connection.ImportSchema != modconfig.ImportSchemaDisabled {
 			searchPath = append(searchPath, connectionName)
 		}
 	}
@@ -97,3 +193,46 @@ func getDefaultSearchPath() []string {
 
 	return searchPath
 }
+
+// limitSearchPath limits the number of connection schemas in the default search path to the given limit
+// (a limit of 0 means no limit). Connection schemas are retained in priority order - the schemas in the
+// priority list (in that order), followed by the remaining schemas in search path order.
+// The public and internal schemas are always retained.
+// Returns the limited search path and the omitted connection schemas
+func limitSearchPath(searchPath []string, priority []string, limit int) ([]string, []string) {
+	// the default search path is book-ended with public and internal
+	connections := searchPath[1 : len(searchPath)-1]
+	if limit <= 0 || len(connections) <= limit {
+		return searchPath, nil
+	}
+
+	// order the connection schemas by priority
+	ordered := make([]string, 0, len(connections))
+	for _, schema := range priority {
+		if helpers.StringSliceContains(connections, schema) && !helpers.StringSliceContains(ordered, schema) {
+			ordered = append(ordered, schema)
+		}
+	}
+	for _, schema := range connections {
+		if !helpers.StringSliceContains(ordered, schema) {
+			ordered = append(ordered, schema)
+		}
+	}
+
+	omitted := ordered[limit:]
+	res := append([]string{searchPath[0]}, ordered[:limit]...)
+	res = append(res, constants.InternalSchema)
+	return res, omitted
+}
+
+// SearchPathLimitWarning returns the warning to display when connection schemas are omitted from the search path
+func SearchPathLimitWarning(omitted []string) string {
+	if len(omitted) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d %s omitted from the search path (%s is %d) - these may still be queried using the schema name as a prefix",
+		len(omitted),
+		utils.Pluralize("connection", len(omitted)),
+		constants.Bold("search_path_limit"),
+		viper.GetInt(constants.ArgSearchPathLimit))
+}
@@ -0,0 +1,64 @@
+package db_local
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/turbot/steampipe/pkg/filepaths"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+// searchPathOverride is the search path override applied to the running service
+//
+// this is saved by the CLI when the service is started, and when the search path is set with 'steampipe search-path',
+// and is read by the plugin manager each time it sets the search path. This ensures the override is not lost when
+// connections are refreshed, and reflects the workspace of the CLI which applied it
+// (rather than the workspace profile the plugin manager was started with)
+type searchPathOverride struct {
+	Workspace        string   `json:"workspace"`
+	SearchPath       []string `json:"search_path,omitempty"`
+	SearchPathPrefix []string `json:"search_path_prefix,omitempty"`
+}
+
+func newSearchPathOverride(profile *modconfig.WorkspaceProfile) *searchPathOverride {
+	if profile == nil {
+		return &searchPathOverride{}
+	}
+	return &searchPathOverride{
+		Workspace:        profile.ProfileName,
+		SearchPath:       profile.GetSearchPath(),
+		SearchPathPrefix: profile.GetSearchPathPrefix(),
+	}
+}
+
+// apply applies the override to the given search path and omitted schemas
+// a search path replaces the computed search path, a prefix is added in front of it
+func (o *searchPathOverride) apply(searchPath, omitted []string) ([]string, []string) {
+	return applySearchPathOverride(searchPath, omitted, o.SearchPath, o.SearchPathPrefix)
+}
+
+// saveSearchPathOverride saves the search path overrides of the given workspace profile,
+// so they are applied by the plugin manager whenever it sets the search path
+func saveSearchPathOverride(profile *modconfig.WorkspaceProfile) error {
+	content, err := json.MarshalIndent(newSearchPathOverride(profile), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepaths.SearchPathOverrideFilePath(), content, 0644)
+}
+
+// loadSearchPathOverride loads the saved search path override - if none has been saved, nil is returned
+func loadSearchPathOverride() (*searchPathOverride, error) {
+	content, err := os.ReadFile(filepaths.SearchPathOverrideFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var override searchPathOverride
+	if err := json.Unmarshal(content, &override); err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
@@ -0,0 +1,201 @@
+package db_local
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/filepaths"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func TestLimitSearchPath(t *testing.T) {
+	prevConfig := steampipeconfig.GlobalConfig
+	defer func() { steampipeconfig.GlobalConfig = prevConfig }()
+	prevLimit := viper.GetInt(constants.ArgSearchPathLimit)
+	defer viper.Set(constants.ArgSearchPathLimit, prevLimit)
+
+	// create more connections than the limit
+	steampipeconfig.GlobalConfig = steampipeconfig.NewSteampipeConfig("")
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("c%d", i)
+		steampipeconfig.GlobalConfig.Connections[name] = &modconfig.Connection{Name: name, ImportSchema: modconfig.ImportSchemaEnabled}
+	}
+	viper.Set(constants.ArgSearchPathLimit, 3)
+
+	searchPath, omitted := limitSearchPath(getDefaultSearchPath(), nil, 3)
+
+	expectedSearchPath := []string{"public", "c1", "c2", "c3", constants.InternalSchema}
+	if !reflect.DeepEqual(searchPath, expectedSearchPath) {
+		t.Errorf("expected search path %v, got %v", expectedSearchPath, searchPath)
+	}
+	if expectedOmitted := []string{"c4", "c5"}; !reflect.DeepEqual(omitted, expectedOmitted) {
+		t.Errorf("expected omitted schemas %v, got %v", expectedOmitted, omitted)
+	}
+	if warning := SearchPathLimitWarning(omitted); !strings.Contains(warning, "2 connections omitted") {
+		t.Errorf("expected warning naming the number of omitted schemas, got: %s", warning)
+	}
+
+	// schemas in the priority list are retained first, in priority order
+	searchPath, omitted = limitSearchPath(getDefaultSearchPath(), []string{"c5", "unknown", "c2"}, 3)
+	expectedSearchPath = []string{"public", "c5", "c2", "c1", constants.InternalSchema}
+	if !reflect.DeepEqual(searchPath, expectedSearchPath) {
+		t.Errorf("expected search path %v, got %v", expectedSearchPath, searchPath)
+	}
+	if expectedOmitted := []string{"c3", "c4"}; !reflect.DeepEqual(omitted, expectedOmitted) {
+		t.Errorf("expected omitted schemas %v, got %v", expectedOmitted, omitted)
+	}
+
+	// without a limit, nothing is omitted
+	searchPath, omitted = limitSearchPath(getDefaultSearchPath(), nil, 0)
+	if len(searchPath) != 7 || len(omitted) != 0 || SearchPathLimitWarning(omitted) != "" {
+		t.Errorf("expected no truncation with no limit, got search path %v, omitted %v", searchPath, omitted)
+	}
+}
+
+func TestComputeUserSearchPath(t *testing.T) {
+	prevConfig := steampipeconfig.GlobalConfig
+	defer func() { steampipeconfig.GlobalConfig = prevConfig }()
+	prevLimit := viper.Get(constants.ArgSearchPathLimit)
+	defer viper.Set(constants.ArgSearchPathLimit, prevLimit)
+	prevSearchPath := viper.Get(constants.ConfigKeyServerSearchPath)
+	defer viper.Set(constants.ConfigKeyServerSearchPath, prevSearchPath)
+	prevSearchPathPrefix := viper.Get(constants.ConfigKeyServerSearchPathPrefix)
+	defer viper.Set(constants.ConfigKeyServerSearchPathPrefix, prevSearchPathPrefix)
+
+	// c2 does not import its schema, so is excluded from the default search path
+	steampipeconfig.GlobalConfig = steampipeconfig.NewSteampipeConfig("")
+	for _, name := range []string{"c5", "c3", "c1", "c4", "c2"} {
+		importSchema := modconfig.ImportSchemaEnabled
+		if name == "c2" {
+			importSchema = modconfig.ImportSchemaDisabled
+		}
+		steampipeconfig.GlobalConfig.Connections[name] = &modconfig.Connection{Name: name, ImportSchema: importSchema}
+	}
+
+	testCases := map[string]struct {
+		searchPath       []string
+		searchPathPrefix []string
+		limit            int
+		expected         string
+		expectedOmitted  []string
+	}{
+		// connections are sorted, the excluded connection is skipped and the remainder is truncated
+		"default":  {limit: 3, expected: `"public","c1","c3","c4","steampipe_internal"`, expectedOmitted: []string{"c5"}},
+		"no limit": {expected: `"public","c1","c3","c4","c5","steampipe_internal"`},
+		// the schemas of the search path prefix are retained first, in prefix order
+		"prefix": {searchPathPrefix: []string{"c5", "c4"}, limit: 3, expected: `"public","c5","c4","c1","steampipe_internal"`, expectedOmitted: []string{"c3"}},
+		// a configured search path keeps its order and is not truncated - the internal schema is moved to the end
+		"configured": {searchPath: []string{"c5", "steampipe_internal", "c2", "c1"}, limit: 1, expected: `"c5","c2","c1","steampipe_internal"`},
+	}
+	for name, test := range testCases {
+		viper.Set(constants.ArgSearchPathLimit, test.limit)
+		// if the test has no search path, leave it unset
+		var configuredSearchPath any
+		if test.searchPath != nil {
+			configuredSearchPath = test.searchPath
+		}
+		viper.Set(constants.ConfigKeyServerSearchPath, configuredSearchPath)
+		viper.Set(constants.ConfigKeyServerSearchPathPrefix, test.searchPathPrefix)
+
+		searchPath, omitted := ComputeUserSearchPath()
+		if actual := FormatSearchPath(searchPath); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected search path %s, got %s", name, test.expected, actual)
+		}
+		if !reflect.DeepEqual(omitted, test.expectedOmitted) {
+			t.Errorf("Test: '%s' FAILED : expected omitted schemas %v, got %v", name, test.expectedOmitted, omitted)
+		}
+	}
+}
+
+func TestApplySearchPathOverrides(t *testing.T) {
+	stringPtr := func(s string) *string { return &s }
+	searchPath := []string{"public", "c1", "c2", constants.InternalSchema}
+	omitted := []string{"c3", "c4"}
+
+	testCases := map[string]struct {
+		profile         *modconfig.WorkspaceProfile
+		expected        string
+		expectedOmitted []string
+	}{
+		"no profile": {expected: `"public","c1","c2","steampipe_internal"`, expectedOmitted: []string{"c3", "c4"}},
+		"no overrides": {
+			profile:         &modconfig.WorkspaceProfile{},
+			expected:        `"public","c1","c2","steampipe_internal"`,
+			expectedOmitted: []string{"c3", "c4"},
+		},
+		// a search path replaces the computed search path, so nothing is omitted
+		"search path": {
+			profile:  &modconfig.WorkspaceProfile{SearchPath: stringPtr("c4, c1")},
+			expected: `"c4","c1","steampipe_internal"`,
+		},
+		// schemas in the prefix are moved to the front and are no longer omitted
+		"prefix": {
+			profile:         &modconfig.WorkspaceProfile{SearchPathPrefix: stringPtr("c3,c2")},
+			expected:        `"c3","c2","public","c1","steampipe_internal"`,
+			expectedOmitted: []string{"c4"},
+		},
+		"search path and prefix": {
+			profile:  &modconfig.WorkspaceProfile{SearchPath: stringPtr("c1,steampipe_internal,c2"), SearchPathPrefix: stringPtr("c4")},
+			expected: `"c4","c1","c2","steampipe_internal"`,
+		},
+	}
+	for name, test := range testCases {
+		actual, actualOmitted := applySearchPathOverrides(searchPath, omitted, test.profile)
+		if formatted := FormatSearchPath(actual); formatted != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected search path %s, got %s", name, test.expected, formatted)
+		}
+		if !reflect.DeepEqual(actualOmitted, test.expectedOmitted) {
+			t.Errorf("Test: '%s' FAILED : expected omitted schemas %v, got %v", name, test.expectedOmitted, actualOmitted)
+		}
+	}
+}
+
+func TestComputeServiceSearchPath(t *testing.T) {
+	prevDir := filepaths.SteampipeDir
+	defer func() { filepaths.SteampipeDir = prevDir }()
+	filepaths.SteampipeDir = t.TempDir()
+	prevProfile := steampipeconfig.GlobalWorkspaceProfile
+	defer func() { steampipeconfig.GlobalWorkspaceProfile = prevProfile }()
+	prevConfig := steampipeconfig.GlobalConfig
+	defer func() { steampipeconfig.GlobalConfig = prevConfig }()
+	prevSearchPath := viper.Get(constants.ConfigKeyServerSearchPath)
+	defer viper.Set(constants.ConfigKeyServerSearchPath, prevSearchPath)
+	viper.Set(constants.ConfigKeyServerSearchPath, nil)
+
+	steampipeconfig.GlobalConfig = steampipeconfig.NewSteampipeConfig("")
+	for _, name := range []string{"c1", "c2"} {
+		steampipeconfig.GlobalConfig.Connections[name] = &modconfig.Connection{Name: name, ImportSchema: modconfig.ImportSchemaEnabled}
+	}
+	stringPtr := func(s string) *string { return &s }
+	// the workspace profile the plugin manager was started with
+	steampipeconfig.GlobalWorkspaceProfile = &modconfig.WorkspaceProfile{ProfileName: "default", SearchPathPrefix: stringPtr("c2")}
+
+	// with no saved override, the workspace profile is applied
+	searchPath, _ := computeServiceSearchPath()
+	if actual, expected := FormatSearchPath(searchPath), `"c2","public","c1","steampipe_internal"`; actual != expected {
+		t.Errorf("expected search path %s, got %s", expected, actual)
+	}
+
+	// an override saved by the CLI (from another workspace) takes precedence over the workspace profile
+	if err := saveSearchPathOverride(&modconfig.WorkspaceProfile{ProfileName: "other", SearchPath: stringPtr("c1")}); err != nil {
+		t.Fatal(err)
+	}
+	searchPath, _ = computeServiceSearchPath()
+	if actual, expected := FormatSearchPath(searchPath), `"c1","steampipe_internal"`; actual != expected {
+		t.Errorf("expected search path %s, got %s", expected, actual)
+	}
+
+	// resetting the override reverts to the computed search path
+	if err := saveSearchPathOverride(&modconfig.WorkspaceProfile{ProfileName: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	searchPath, _ = computeServiceSearchPath()
+	if actual, expected := FormatSearchPath(searchPath), `"public","c1","c2","steampipe_internal"`; actual != expected {
+		t.Errorf("expected search path %s, got %s", expected, actual)
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/filepaths"
 	"github.com/turbot/steampipe/pkg/pluginmanager"
+	pb "github.com/turbot/steampipe/pkg/pluginmanager_service/grpc/proto"
 	"github.com/turbot/steampipe/pkg/statushooks"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"github.com/turbot/steampipe/pkg/utils"
 )
 
@@ -121,6 +123,13 @@ func StartServices(ctx context.Context, listenAddresses []string, port int, invo
 			return res
 		}
 
+		// save the search path overrides of the active workspace profile - these are applied by the plugin manager
+		// when it sets the search path (it may have been started with a different workspace profile)
+		if err := saveSearchPathOverride(steampipeconfig.GlobalWorkspaceProfile); err != nil {
+			res.Error = err
+			return res
+		}
+
 		// start plugin manager if needed
 		pluginManager, pluginManagerState, err := ensurePluginManager()
 		res.PluginManagerState = pluginManagerState
@@ -661,3 +670,29 @@ func isSteampipePostgresProcess(ctx context.Context, cmdline []string) bool {
 	}
 	return false
 }
+
+// RequestConnectionRefresh asks the plugin manager to refresh connections - this is executed asyncronously by the plugin manager
+// if the service was already running, the running plugin manager keeps the connections up to date,
+// so a refresh is only requested if the request has options which only apply to this refresh
+func (r *StartResult) RequestConnectionRefresh(req *pb.RefreshConnectionsRequest) {
+	pluginManager := r.PluginManager
+	switch r.Status {
+	case ServiceStarted:
+	case ServiceAlreadyRunning:
+		if !pluginmanager.HasOneShotOptions(req) || r.PluginManagerState == nil || !r.PluginManagerState.Running {
+			return
+		}
+		var err error
+		pluginManager, err = pluginmanager.NewPluginManagerClient(r.PluginManagerState)
+		if err != nil {
+			log.Printf("[WARN] failed to connect to the plugin manager to refresh connections: %s", err.Error())
+			return
+		}
+	default:
+		return
+	}
+	// we ignore this error, since RefreshConnections is async and all errors will flow through
+	// the notification system
+	// we do not expect any I/O errors on this since the PluginManager is running in the same box
+	_, _ = pluginManager.RefreshConnections(req)
+}
@@ -0,0 +1,246 @@
+package display
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/ipc"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/apache/arrow/go/v13/parquet"
+	"github.com/apache/arrow/go/v13/parquet/compress"
+	"github.com/apache/arrow/go/v13/parquet/pqarrow"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+)
+
+// the maximum number of rows in each record batch written to arrow output, and in each parquet row group
+const columnarBatchSize = 10000
+
+// IsColumnarOutputFormat returns whether the output format is a binary columnar format (parquet or arrow)
+func IsColumnarOutputFormat(format string) bool {
+	return format == constants.OutputFormatParquet || format == constants.OutputFormatArrow
+}
+
+func displayColumnar(ctx context.Context, result *queryresult.Result, format string) int {
+	rowErrors := 0
+	if err := WriteColumnar(os.Stdout, result, format); err != nil {
+		error_helpers.ShowError(ctx, err)
+		rowErrors++
+	}
+	return rowErrors
+}
+
+// WriteColumnar writes the result in a columnar format - either parquet, or an arrow IPC stream
+// the rows are written in batches as they are read from the result, so at most one batch of rows is held in memory
+// (the result itself may already be held in memory - e.g. a result read from a snapshot)
+func WriteColumnar(w io.Writer, result *queryresult.Result, format string) error {
+	schema := columnarSchema(result.Cols)
+	writer, err := newColumnarWriter(w, schema, format)
+	if err != nil {
+		return err
+	}
+	return writeColumnar(writer, schema, result, format)
+}
+
+// WriteColumnarFile writes the result to a file in a columnar format - either parquet, or an arrow IPC file
+// (unlike an arrow IPC stream, an IPC file has a footer which allows the record batches to be read randomly)
+func WriteColumnarFile(f io.WriteSeeker, result *queryresult.Result, format string) error {
+	if format != constants.OutputFormatArrow {
+		return WriteColumnar(f, result, format)
+	}
+	schema := columnarSchema(result.Cols)
+	writer, err := ipc.NewFileWriter(f, ipc.WithSchema(schema))
+	if err != nil {
+		return err
+	}
+	return writeColumnar(writer, schema, result, format)
+}
+
+func writeColumnar(writer columnarWriter, schema *arrow.Schema, result *queryresult.Result, format string) error {
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	writeBatch := func() error {
+		record := builder.NewRecord()
+		defer record.Release()
+		return writer.Write(record)
+	}
+
+	var writeErr error
+	batchRows := 0
+	rowFunc := func(row []interface{}, result *queryresult.Result) {
+		if writeErr != nil {
+			return
+		}
+		for idx, col := range result.Cols {
+			appendColumnarValue(builder.Field(idx), col, row[idx])
+		}
+		if batchRows++; batchRows == columnarBatchSize {
+			writeErr = writeBatch()
+			batchRows = 0
+		}
+	}
+
+	err := iterateResults(result, rowFunc)
+	if err == nil && writeErr == nil && batchRows > 0 {
+		writeErr = writeBatch()
+	}
+	// always close the writer, to write the file footer (or end of stream)
+	closeErr := writer.Close()
+	switch {
+	case err != nil:
+		return err
+	case writeErr != nil:
+		return fmt.Errorf("failed to write %s output: %s", format, writeErr.Error())
+	case closeErr != nil:
+		return fmt.Errorf("failed to write %s output: %s", format, closeErr.Error())
+	}
+	return nil
+}
+
+// columnarWriter writes record batches to a parquet file, or an arrow IPC stream or file
+type columnarWriter interface {
+	Write(arrow.Record) error
+	Close() error
+}
+
+func newColumnarWriter(w io.Writer, schema *arrow.Schema, format string) (columnarWriter, error) {
+	switch format {
+	case constants.OutputFormatParquet:
+		// each record batch is written (and flushed) as a row group
+		props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy), parquet.WithMaxRowGroupLength(columnarBatchSize))
+		return pqarrow.NewFileWriter(schema, w, props, pqarrow.DefaultWriterProps())
+	case constants.OutputFormatArrow:
+		return ipc.NewWriter(w, ipc.WithSchema(schema)), nil
+	}
+	return nil, fmt.Errorf("unsupported columnar format: '%s'", format)
+}
+
+func columnarSchema(cols []*queryresult.ColumnDef) *arrow.Schema {
+	fields := make([]arrow.Field, len(cols))
+	for idx, col := range cols {
+		fields[idx] = arrow.Field{Name: col.Name, Type: columnarDataType(col), Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// columnarDataType returns the arrow type for a column
+// JSON columns are written as JSON text, and other types without a direct equivalent (e.g. NUMERIC, INET) as text
+func columnarDataType(col *queryresult.ColumnDef) arrow.DataType {
+	switch col.DataType {
+	case "BOOL":
+		return arrow.FixedWidthTypes.Boolean
+	case "INT2":
+		return arrow.PrimitiveTypes.Int16
+	case "INT4":
+		return arrow.PrimitiveTypes.Int32
+	case "INT8":
+		return arrow.PrimitiveTypes.Int64
+	case "FLOAT4":
+		return arrow.PrimitiveTypes.Float32
+	case "FLOAT8":
+		return arrow.PrimitiveTypes.Float64
+	case "TIMESTAMP":
+		return &arrow.TimestampType{Unit: arrow.Microsecond}
+	case "TIMESTAMPTZ":
+		return arrow.FixedWidthTypes.Timestamp_us
+	case "DATE":
+		return arrow.FixedWidthTypes.Date32
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+func appendColumnarValue(builder array.Builder, col *queryresult.ColumnDef, val interface{}) {
+	if val == nil {
+		builder.AppendNull()
+		return
+	}
+
+	switch b := builder.(type) {
+	case *array.StringBuilder:
+		if s, err := ColumnValueAsString(val, col); err == nil {
+			b.Append(s)
+			return
+		}
+	case *array.BooleanBuilder:
+		if v, ok := val.(bool); ok {
+			b.Append(v)
+			return
+		}
+	case *array.Int16Builder:
+		if v, ok := columnarInt(val); ok {
+			b.Append(int16(v))
+			return
+		}
+	case *array.Int32Builder:
+		if v, ok := columnarInt(val); ok {
+			b.Append(int32(v))
+			return
+		}
+	case *array.Int64Builder:
+		if v, ok := columnarInt(val); ok {
+			b.Append(v)
+			return
+		}
+	case *array.Float32Builder:
+		if v, ok := columnarFloat(val); ok {
+			b.Append(float32(v))
+			return
+		}
+	case *array.Float64Builder:
+		if v, ok := columnarFloat(val); ok {
+			b.Append(v)
+			return
+		}
+	case *array.TimestampBuilder:
+		if t, ok := val.(time.Time); ok {
+			b.Append(arrow.Timestamp(t.UnixMicro()))
+			return
+		}
+	case *array.Date32Builder:
+		if t, ok := val.(time.Time); ok {
+			b.Append(arrow.Date32FromTime(t))
+			return
+		}
+	}
+
+	// the value does not have the expected type for the column
+	log.Printf("[WARN] unexpected value of type %T for %s column '%s' - writing null", val, col.DataType, col.Name)
+	builder.AppendNull()
+}
+
+func columnarInt(val interface{}) (int64, bool) {
+	v := reflect.ValueOf(val)
+	switch {
+	case v.CanInt():
+		return v.Int(), true
+	case v.CanUint():
+		return int64(v.Uint()), true
+	case v.CanFloat():
+		return int64(v.Float()), true
+	}
+	return 0, false
+}
+
+func columnarFloat(val interface{}) (float64, bool) {
+	v := reflect.ValueOf(val)
+	switch {
+	case v.CanFloat():
+		return v.Float(), true
+	case v.CanInt():
+		return float64(v.Int()), true
+	case v.CanUint():
+		return float64(v.Uint()), true
+	}
+	return 0, false
+}
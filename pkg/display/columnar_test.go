@@ -0,0 +1,212 @@
+package display
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/ipc"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/apache/arrow/go/v13/parquet"
+	"github.com/apache/arrow/go/v13/parquet/file"
+	"github.com/apache/arrow/go/v13/parquet/pqarrow"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+)
+
+var columnarTestCols = []*queryresult.ColumnDef{
+	{Name: "name", DataType: "TEXT"},
+	{Name: "count", DataType: "INT8"},
+	{Name: "enabled", DataType: "BOOL"},
+	{Name: "size", DataType: "FLOAT8"},
+	{Name: "created", DataType: "TIMESTAMPTZ"},
+	{Name: "tags", DataType: "JSONB"},
+}
+
+var columnarTestRows = [][]interface{}{
+	{"bucket_a", int64(3), true, 1.5, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), map[string]interface{}{"env": "prod"}},
+	{"bucket_b", nil, false, nil, nil, nil},
+}
+
+var columnarTestExpected = [][]string{
+	{"bucket_a", "3", "true", "1.5", "2023-01-02 03:04:05", `{"env":"prod"}`},
+	{"bucket_b", "(null)", "false", "(null)", "(null)", "(null)"},
+}
+
+type columnarTest struct {
+	format string
+	// whether the result is written to a file (using WriteColumnarFile)
+	file bool
+	read func([]byte) (arrow.Table, error)
+}
+
+var testCasesWriteColumnar = map[string]columnarTest{
+	"parquet": {
+		format: constants.OutputFormatParquet,
+		read: func(b []byte) (arrow.Table, error) {
+			return pqarrow.ReadTable(context.Background(), bytes.NewReader(b), parquet.NewReaderProperties(memory.DefaultAllocator), pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+		},
+	},
+	"arrow": {
+		format: constants.OutputFormatArrow,
+		read: func(b []byte) (arrow.Table, error) {
+			reader, err := ipc.NewReader(bytes.NewReader(b))
+			if err != nil {
+				return nil, err
+			}
+			defer reader.Release()
+			var records []arrow.Record
+			for reader.Next() {
+				record := reader.Record()
+				record.Retain()
+				records = append(records, record)
+			}
+			return array.NewTableFromRecords(reader.Schema(), records), reader.Err()
+		},
+	},
+	"arrow file": {
+		format: constants.OutputFormatArrow,
+		file:   true,
+		read: func(b []byte) (arrow.Table, error) {
+			reader, err := ipc.NewFileReader(bytes.NewReader(b))
+			if err != nil {
+				return nil, err
+			}
+			defer reader.Close()
+			var records []arrow.Record
+			for i := 0; i < reader.NumRecords(); i++ {
+				record, err := reader.Record(i)
+				if err != nil {
+					return nil, err
+				}
+				record.Retain()
+				records = append(records, record)
+			}
+			return array.NewTableFromRecords(reader.Schema(), records), nil
+		},
+	},
+}
+
+// writeColumnarTestResult writes the rows using WriteColumnar, or WriteColumnarFile if a file is requested
+func writeColumnarTestResult(t *testing.T, rows [][]interface{}, format string, toFile bool) ([]byte, error) {
+	if !toFile {
+		var out bytes.Buffer
+		err := WriteColumnar(&out, streamColumnarTestResult(rows), format)
+		return out.Bytes(), err
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := WriteColumnarFile(f, streamColumnarTestResult(rows), format); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(f.Name())
+}
+
+func TestWriteColumnar(t *testing.T) {
+	for name, test := range testCasesWriteColumnar {
+		out, err := writeColumnarTestResult(t, columnarTestRows, test.format, test.file)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : write failed: %s", name, err.Error())
+			continue
+		}
+		table, err := test.read(out)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : read failed: %s", name, err.Error())
+			continue
+		}
+		if actual := columnarTableValues(table); !reflect.DeepEqual(actual, columnarTestExpected) {
+			t.Errorf("Test: '%s' FAILED : expected:\n%v\n\ngot:\n%v", name, columnarTestExpected, actual)
+		}
+		if table.Schema().Field(4).Type.ID() != arrow.TIMESTAMP {
+			t.Errorf("Test: '%s' FAILED : expected a timestamp column, got %s", name, table.Schema().Field(4).Type)
+		}
+		table.Release()
+	}
+}
+
+func TestWriteColumnarBatches(t *testing.T) {
+	rowCount := columnarBatchSize*2 + 1
+	rows := make([][]interface{}, rowCount)
+	for i := range rows {
+		rows[i] = []interface{}{fmt.Sprintf("bucket_%d", i), int64(i), true, float64(i), nil, nil}
+	}
+
+	var out bytes.Buffer
+	if err := WriteColumnar(&out, streamColumnarTestResult(rows), constants.OutputFormatArrow); err != nil {
+		t.Fatalf("Test: 'batches' FAILED : write failed: %s", err.Error())
+	}
+	reader, err := ipc.NewReader(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("Test: 'batches' FAILED : read failed: %s", err.Error())
+	}
+	defer reader.Release()
+	var batches, total int
+	for reader.Next() {
+		batches++
+		total += int(reader.Record().NumRows())
+	}
+	if batches != 3 || total != rowCount {
+		t.Errorf("Test: 'batches' FAILED : expected 3 batches of %d rows, got %d batches of %d rows", rowCount, batches, total)
+	}
+
+	// each batch is written to parquet as a row group
+	out.Reset()
+	if err := WriteColumnar(&out, streamColumnarTestResult(rows), constants.OutputFormatParquet); err != nil {
+		t.Fatalf("Test: 'row groups' FAILED : write failed: %s", err.Error())
+	}
+	parquetReader, err := file.NewParquetReader(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("Test: 'row groups' FAILED : read failed: %s", err.Error())
+	}
+	defer parquetReader.Close()
+	if parquetReader.NumRowGroups() != 3 || parquetReader.NumRows() != int64(rowCount) {
+		t.Errorf("Test: 'row groups' FAILED : expected 3 row groups of %d rows, got %d row groups of %d rows", rowCount, parquetReader.NumRowGroups(), parquetReader.NumRows())
+	}
+}
+
+func streamColumnarTestResult(rows [][]interface{}) *queryresult.Result {
+	result := queryresult.NewResult(columnarTestCols)
+	go func() {
+		for _, row := range rows {
+			result.StreamRow(row)
+		}
+		result.Close()
+	}()
+	return result
+}
+
+// columnarTableValues returns the values of a table as strings, by row
+func columnarTableValues(table arrow.Table) [][]string {
+	var res [][]string
+	reader := array.NewTableReader(table, 0)
+	defer reader.Release()
+	for reader.Next() {
+		record := reader.Record()
+		for row := 0; row < int(record.NumRows()); row++ {
+			values := make([]string, record.NumCols())
+			for col := range values {
+				column := record.Column(col)
+				switch {
+				case column.IsNull(row):
+					values[col] = "(null)"
+				case column.DataType().ID() == arrow.TIMESTAMP:
+					values[col] = column.(*array.Timestamp).Value(row).ToTime(arrow.Microsecond).Format(time.DateTime)
+				default:
+					values[col] = column.ValueStr(row)
+				}
+			}
+			res = append(res, values)
+		}
+	}
+	return res
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/turbot/steampipe/pkg/cmdconfig"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/query/queryresult"
+	"github.com/turbot/steampipe/pkg/utils"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
@@ -35,7 +36,8 @@ func ShowOutput(ctx context.Context, result *queryresult.Result, opts ...Display
 		o(config)
 	}
 
-	switch cmdconfig.Viper().GetString(constants.ArgOutput) {
+	outputFormat := cmdconfig.Viper().GetString(constants.ArgOutput)
+	switch outputFormat {
 	case constants.OutputFormatJSON:
 		rowErrors = displayJSON(ctx, result)
 	case constants.OutputFormatCSV:
@@ -43,10 +45,13 @@ func ShowOutput(ctx context.Context, result *queryresult.Result, opts ...Display
 	case constants.OutputFormatLine:
 		rowErrors = displayLine(ctx, result)
 	case constants.OutputFormatTable:
-		rowErrors = displayTable(ctx, result)
+		rowErrors = displayTable(ctx, result, config.rowHighlighter)
+	case constants.OutputFormatParquet, constants.OutputFormatArrow:
+		rowErrors = displayColumnar(ctx, result, outputFormat)
 	}
 
-	if config.timing {
+	// do not write timing after binary output
+	if config.timing && !IsColumnarOutputFormat(outputFormat) {
 		fmt.Println(buildTimingString(result))
 	}
 	// return the number of rows that returned errors
@@ -291,7 +296,7 @@ func displayCSV(ctx context.Context, result *queryresult.Result) int {
 	return rowErrors
 }
 
-func displayTable(ctx context.Context, result *queryresult.Result) int {
+func displayTable(ctx context.Context, result *queryresult.Result, rowHighlighter func(row []string) bool) int {
 	rowErrors := 0
 	// the buffer to put the output data in
 	outbuf := bytes.NewBufferString("")
@@ -319,6 +324,17 @@ func displayTable(ctx context.Context, result *queryresult.Result) int {
 		t.AppendHeader(headers)
 	}
 
+	// the rows to highlight, keyed by their values
+	highlightedRows := make(map[string]struct{})
+	if rowHighlighter != nil {
+		t.SetRowPainter(func(row table.Row) text.Colors {
+			if _, ok := highlightedRows[tableRowKey(row)]; ok {
+				return text.Colors{text.FgHiYellow}
+			}
+			return nil
+		})
+	}
+
 	// define a function to execute for each row
 	rowFunc := func(row []interface{}, result *queryresult.Result) {
 		rowAsString, _ := ColumnValuesAsString(row, result.Cols)
@@ -335,6 +351,9 @@ func displayTable(ctx context.Context, result *queryresult.Result) int {
 			}, col)
 			rowObj = append(rowObj, col)
 		}
+		if rowHighlighter != nil && rowHighlighter(rowAsString) {
+			highlightedRows[tableRowKey(rowObj)] = struct{}{}
+		}
 		t.AppendRow(rowObj)
 	}
 
@@ -355,6 +374,14 @@ func displayTable(ctx context.Context, result *queryresult.Result) int {
 	return rowErrors
 }
 
+func tableRowKey(row table.Row) string {
+	values := make([]string, len(row))
+	for i, value := range row {
+		values[i] = fmt.Sprintf("%v", value)
+	}
+	return strings.Join(values, "\x00")
+}
+
 func buildTimingString(result *queryresult.Result) string {
 	timingResult := <-result.TimingResult
 	if timingResult == nil {
@@ -392,9 +419,51 @@ func buildTimingString(result *queryresult.Result) string {
 		sb.WriteString(p.Sprintf(". Hydrate calls: %d.", timingMetadata.HydrateCalls))
 	}
 
+	if cmdconfig.Viper().GetBool(constants.ConfigKeyTimingVerbose) {
+		// the rows have all been fetched - any time since then has been spent rendering them
+		var renderDuration time.Duration
+		if !timingResult.StartTime.IsZero() {
+			renderDuration = time.Since(timingResult.StartTime) - timingResult.Duration
+		}
+		sb.WriteString(buildTimingBreakdown(timingResult, renderDuration))
+	}
+
 	return sb.String()
 }
 
+// buildTimingBreakdown builds the verbose timing output, which breaks the query duration down into phases:
+//   - plan: planning and starting the query
+//   - fetch: fetching the rows from the plugins (hydrate) or the cache
+//   - render: displaying the rows
+func buildTimingBreakdown(timingResult *queryresult.TimingResult, renderDuration time.Duration) string {
+	var sb strings.Builder
+	p := message.NewPrinter(language.English)
+
+	sb.WriteString(fmt.Sprintf("\n  Plan:   %s", formatTimingDuration(timingResult.PlanDuration)))
+	sb.WriteString(fmt.Sprintf("\n  Fetch:  %s", formatTimingDuration(timingResult.Duration-timingResult.PlanDuration)))
+	if m := timingResult.Metadata; m != nil {
+		if hydrateScans := m.Scans - m.CachedScans; hydrateScans > 0 {
+			sb.WriteString(p.Sprintf("\n    Hydrate: %d rows, %d hydrate calls (%d %s)", m.RowsFetched, m.HydrateCalls, hydrateScans, utils.Pluralize("scan", int(hydrateScans))))
+		}
+		if m.CachedScans > 0 {
+			sb.WriteString(p.Sprintf("\n    Cache:   %d rows (%d %s)", m.CachedRowsFetched, m.CachedScans, utils.Pluralize("scan", int(m.CachedScans))))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n  Render: %s", formatTimingDuration(renderDuration)))
+	return sb.String()
+}
+
+// formatTimingDuration formats a duration in milliseconds, or in seconds if it is at least half a second
+func formatTimingDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d.Seconds() < 0.5 {
+		return message.NewPrinter(language.English).Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
 type displayResultsFunc func(row []interface{}, result *queryresult.Result)
 
 // call func displayResult for each row of results
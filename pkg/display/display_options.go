@@ -7,6 +7,8 @@ import (
 
 type displayConfiguration struct {
 	timing bool
+	// returns whether a row of table output should be highlighted - may be nil
+	rowHighlighter func(row []string) bool
 }
 
 // NewDisplayConfiguration creates a default configuration with timing set to
@@ -31,3 +33,10 @@ func WithTimingDisabled() DisplayOption {
 		o.timing = false
 	}
 }
+
+// WithRowHighlighter highlights the rows of table output for which the given function returns true
+func WithRowHighlighter(highlighter func(row []string) bool) DisplayOption {
+	return func(o *displayConfiguration) {
+		o.rowHighlighter = highlighter
+	}
+}
@@ -0,0 +1,53 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+)
+
+type timingBreakdownTest struct {
+	timing   *queryresult.TimingResult
+	render   time.Duration
+	expected string
+}
+
+var testCasesTimingBreakdown = map[string]timingBreakdownTest{
+	"no scan metadata": {
+		timing:   &queryresult.TimingResult{Duration: 50 * time.Millisecond, PlanDuration: 10 * time.Millisecond},
+		render:   5 * time.Millisecond,
+		expected: "\n  Plan:   10ms\n  Fetch:  40ms\n  Render: 5ms",
+	},
+	"hydrate and cache": {
+		timing: &queryresult.TimingResult{
+			Duration:     2500 * time.Millisecond,
+			PlanDuration: 20 * time.Millisecond,
+			Metadata: &queryresult.TimingMetadata{
+				RowsFetched:       1200,
+				CachedRowsFetched: 10,
+				HydrateCalls:      2400,
+				Scans:             3,
+				CachedScans:       1,
+			},
+		},
+		render:   time.Second,
+		expected: "\n  Plan:   20ms\n  Fetch:  2.5s\n    Hydrate: 1,200 rows, 2,400 hydrate calls (2 scans)\n    Cache:   10 rows (1 scan)\n  Render: 1.0s",
+	},
+	"cached": {
+		timing: &queryresult.TimingResult{
+			Duration:     30 * time.Millisecond,
+			PlanDuration: 10 * time.Millisecond,
+			Metadata:     &queryresult.TimingMetadata{CachedRowsFetched: 5, Scans: 1, CachedScans: 1},
+		},
+		expected: "\n  Plan:   10ms\n  Fetch:  20ms\n    Cache:   5 rows (1 scan)\n  Render: 0ms",
+	},
+}
+
+func TestBuildTimingBreakdown(t *testing.T) {
+	for name, test := range testCasesTimingBreakdown {
+		if actual := buildTimingBreakdown(test.timing, test.render); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %q, got %q", name, test.expected, actual)
+		}
+	}
+}
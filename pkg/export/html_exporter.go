@@ -0,0 +1,109 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+)
+
+// HtmlExporter renders a dashboard snapshot as a self-contained html report
+// the report contains the panel data as html tables, and the (stripped) snapshot json in a script element
+type HtmlExporter struct {
+	ExporterBase
+}
+
+type htmlReport struct {
+	Title    string
+	Data     *dashboardtypes.DashboardData
+	Snapshot template.JS
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"cell": formatReportValue,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f2f2f2; }
+.meta, .panel-type { color: #666; font-size: 0.9em; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{.Data.Dashboard}} - run at {{.Data.StartTime.Format "2006-01-02 15:04:05 MST"}}</p>
+{{range .Data.Panels}}{{if or .Title .Columns .Error}}<section>
+<h2>{{if .Title}}{{.Title}}{{else}}{{.Name}}{{end}} <span class="panel-type">{{.PanelType}}</span></h2>
+{{if .Error}}<p class="error">{{.Error}}</p>
+{{end}}{{if .Columns}}{{$columns := .Columns}}<table>
+<tr>{{range $columns}}<th>{{.Name}}</th>{{end}}</tr>
+{{range $row := .Rows}}<tr>{{range $columns}}<td>{{cell (index $row .Name)}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}</section>
+{{end}}{{end}}<script type="application/json" id="snapshot">{{.Snapshot}}</script>
+</body>
+</html>
+`))
+
+func (e *HtmlExporter) Export(_ context.Context, input ExportSourceData, filePath string) error {
+	snapshot, ok := input.(*dashboardtypes.SteampipeSnapshot)
+	if !ok {
+		return fmt.Errorf("HtmlExporter input must be *dashboardtypes.SteampipeSnapshot")
+	}
+	data, err := dashboardtypes.NewDashboardData(snapshot)
+	if err != nil {
+		return err
+	}
+	// json.Marshal escapes '<' and '>', so the snapshot cannot terminate the script element
+	snapshotBytes, err := snapshot.AsStrippedJson(false)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	report := htmlReport{
+		Title:    reportTitle(snapshot),
+		Data:     data,
+		Snapshot: template.JS(snapshotBytes), //nolint:gosec // the snapshot is json encoded
+	}
+	if err := htmlReportTemplate.Execute(&buf, report); err != nil {
+		return err
+	}
+	return Write(filePath, &buf)
+}
+
+func (e *HtmlExporter) FileExtension() string {
+	return constants.HtmlExtension
+}
+
+func (e *HtmlExporter) Name() string {
+	return constants.OutputFormatHTML
+}
+
+// reportTitle returns the title of the snapshot, falling back to the dashboard name
+func reportTitle(snapshot *dashboardtypes.SteampipeSnapshot) string {
+	if snapshot.Title != "" {
+		return snapshot.Title
+	}
+	if snapshot.Layout != nil {
+		return snapshot.Layout.Name
+	}
+	return snapshot.FileNameRoot
+}
+
+// formatReportValue formats a panel data value for display in a report
+func formatReportValue(value any) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+)
+
+// PdfExporter renders the panel data of a dashboard snapshot as a plain text pdf report
+type PdfExporter struct {
+	ExporterBase
+}
+
+func (e *PdfExporter) Export(_ context.Context, input ExportSourceData, filePath string) error {
+	snapshot, ok := input.(*dashboardtypes.SteampipeSnapshot)
+	if !ok {
+		return fmt.Errorf("PdfExporter input must be *dashboardtypes.SteampipeSnapshot")
+	}
+	data, err := dashboardtypes.NewDashboardData(snapshot)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writePdf(&buf, reportTextLines(reportTitle(snapshot), data))
+	return Write(filePath, &buf)
+}
+
+func (e *PdfExporter) FileExtension() string {
+	return constants.PdfExtension
+}
+
+func (e *PdfExporter) Name() string {
+	return constants.OutputFormatPDF
+}
+
+// reportTextLines returns the text of a report of the dashboard data - a heading for each panel,
+// followed by the panel error and rows, if any
+func reportTextLines(title string, data *dashboardtypes.DashboardData) []string {
+	lines := []string{
+		title,
+		fmt.Sprintf("%s - run at %s", data.Dashboard, data.StartTime.Format("2006-01-02 15:04:05 MST")),
+	}
+	for _, panel := range data.Panels {
+		if panel.Title == "" && len(panel.Columns) == 0 && panel.Error == "" {
+			continue
+		}
+		heading := panel.Title
+		if heading == "" {
+			heading = panel.Name
+		}
+		lines = append(lines, "", fmt.Sprintf("%s (%s)", heading, panel.PanelType))
+		if panel.Error != "" {
+			lines = append(lines, "Error: "+panel.Error)
+		}
+		if len(panel.Columns) == 0 {
+			continue
+		}
+		columns := make([]string, len(panel.Columns))
+		for i, c := range panel.Columns {
+			columns[i] = c.Name
+		}
+		lines = append(lines, strings.Join(columns, " | "), strings.Repeat("-", len(strings.Join(columns, " | "))))
+		for _, row := range panel.Rows {
+			values := make([]string, len(panel.Columns))
+			for i, c := range panel.Columns {
+				values[i] = formatReportValue(row[c.Name])
+			}
+			lines = append(lines, strings.Join(values, " | "))
+		}
+	}
+	return lines
+}
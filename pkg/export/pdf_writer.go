@@ -0,0 +1,101 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// page layout of pdf reports - A4 pages of monospaced text
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 40
+	pdfFontSize     = 9
+	pdfLineHeight   = 11
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	// Courier characters are 0.6 of the font size wide
+	pdfCharsPerLine = (pdfPageWidth - 2*pdfMargin) * 10 / (pdfFontSize * 6)
+)
+
+// writePdf writes a pdf document containing the given lines of text
+// lines which are too long for the page are truncated, and characters which cannot be rendered with the
+// standard Courier font are replaced with '?'
+func writePdf(buf *bytes.Buffer, lines []string) {
+	pages := paginate(lines, pdfLinesPerPage)
+
+	// objects are numbered from 1: the catalog, the page tree, the font, then a page and its content for each page
+	pageCount := len(pages)
+	objectCount := 3 + 2*pageCount
+	offsets := make([]int, objectCount+1)
+	writeObject := func(id int, body string) {
+		offsets[id] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", id, body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	writeObject(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, pageCount)
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	writeObject(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	writeObject(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+	for i, page := range pages {
+		pageId := 4 + 2*i
+		writeObject(pageId, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, pageId+1))
+		content := pdfPageContent(page)
+		writeObject(pageId+1, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", objectCount+1)
+	for id := 1; id <= objectCount; id++ {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offsets[id])
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", objectCount+1, xrefOffset)
+}
+
+// paginate splits the lines into pages - there is always at least one page
+func paginate(lines []string, linesPerPage int) [][]string {
+	var pages [][]string
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	return append(pages, lines)
+}
+
+// pdfPageContent returns the content stream which renders the lines of a page
+func pdfPageContent(lines []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "(%s) Tj T*\n", pdfEscape(line, pdfCharsPerLine))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+// pdfEscape returns the line as the contents of a pdf string literal, truncated to maxChars
+func pdfEscape(line string, maxChars int) string {
+	var b strings.Builder
+	chars := 0
+	for _, r := range line {
+		if chars == maxChars {
+			break
+		}
+		chars++
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > '~':
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
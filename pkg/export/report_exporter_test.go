@@ -0,0 +1,126 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+)
+
+// testPanel is a snapshot panel in its serialised form
+type testPanel map[string]any
+
+func (testPanel) IsSnapshotPanel() {}
+
+func testReportSnapshot() *dashboardtypes.SteampipeSnapshot {
+	return &dashboardtypes.SteampipeSnapshot{
+		Title:     "Account Report",
+		StartTime: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		Panels: map[string]dashboardtypes.SnapshotPanel{
+			"dashboard.report": testPanel{"name": "dashboard.report", "panel_type": "dashboard", "title": "Account Report", "status": "complete"},
+			"table.accounts": testPanel{
+				"name":       "table.accounts",
+				"panel_type": "table",
+				"title":      "Accounts (<all>)",
+				"status":     "complete",
+				"sql":        "select * from accounts",
+				"data": map[string]any{
+					"columns": []map[string]any{{"name": "id", "data_type": "TEXT"}, {"name": "count", "data_type": "INT8"}},
+					"rows":    []map[string]any{{"id": "a(1)", "count": 3}, {"id": "b", "count": nil}},
+				},
+			},
+			"card.failed": testPanel{"name": "card.failed", "panel_type": "card", "status": "error", "error": "relation does not exist"},
+		},
+		Layout: &dashboardtypes.SnapshotTreeNode{
+			Name:     "dashboard.report",
+			NodeType: "dashboard",
+			Children: []*dashboardtypes.SnapshotTreeNode{{Name: "table.accounts", NodeType: "table"}, {Name: "card.failed", NodeType: "card"}},
+		},
+	}
+}
+
+func TestHtmlExporter(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "report.html")
+	if err := (&HtmlExporter{}).Export(context.Background(), testReportSnapshot(), filePath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+
+	for _, expected := range []string{
+		"<title>Account Report</title>",
+		"Accounts (&lt;all&gt;)",
+		"<tr><th>id</th><th>count</th></tr>",
+		"<tr><td>a(1)</td><td>3</td></tr>",
+		"<tr><td>b</td><td></td></tr>",
+		`<p class="error">relation does not exist</p>`,
+		`<script type="application/json" id="snapshot">{`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected the html to contain %s, got:\n%s", expected, html)
+		}
+	}
+	// the embedded snapshot is stripped, and cannot terminate the script element
+	if strings.Contains(html, "select * from accounts") || strings.Contains(html, "<all>") {
+		t.Errorf("expected the embedded snapshot to be stripped and escaped, got:\n%s", html)
+	}
+}
+
+func TestPdfExporter(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "report.pdf")
+	if err := (&PdfExporter{}).Export(context.Background(), testReportSnapshot(), filePath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf := string(data)
+	for _, expected := range []string{"%PDF-1.4\n", "/Count 1", "(Accounts \\(<all>\\) \\(table\\)) Tj", "(a\\(1\\) | 3) Tj", "(Error: relation does not exist) Tj", "%%EOF\n"} {
+		if !strings.Contains(pdf, expected) {
+			t.Errorf("expected the pdf to contain %s, got:\n%s", expected, pdf)
+		}
+	}
+}
+
+func TestWritePdf(t *testing.T) {
+	var lines []string
+	for i := 0; i < pdfLinesPerPage+1; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	lines = append(lines, strings.Repeat("x", pdfCharsPerLine+10), "naïve")
+
+	var buf bytes.Buffer
+	writePdf(&buf, lines)
+	pdf := buf.String()
+
+	// the lines overflow onto a second page
+	if !strings.Contains(pdf, "/Kids [4 0 R 6 0 R] /Count 2") {
+		t.Errorf("expected 2 pages, got:\n%s", pdf)
+	}
+	// long lines are truncated and unsupported characters replaced
+	if !strings.Contains(pdf, "("+strings.Repeat("x", pdfCharsPerLine)+") Tj") || !strings.Contains(pdf, "(na?ve) Tj") {
+		t.Errorf("expected long lines to be truncated and unsupported characters replaced, got:\n%s", pdf)
+	}
+	// the xref offsets point at the objects
+	xref := pdf[strings.Index(pdf, "xref\n"):]
+	for id := 1; id <= 7; id++ {
+		var offset int
+		entry := strings.Split(xref, "\n")[2+id]
+		if _, err := fmt.Sscanf(entry, "%d", &offset); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(pdf[offset:], fmt.Sprintf("%d 0 obj", id)) {
+			t.Errorf("expected the xref entry for object %d to point at the object", id)
+		}
+	}
+}
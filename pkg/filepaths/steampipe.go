@@ -19,10 +19,15 @@ const (
 	databaseRunningInfoFileName  = "steampipe.json"
 	pluginManagerStateFileName   = "plugin_manager.json"
 	dashboardServerStateFileName = "dashboard_service.json"
+	refreshServiceSocketFileName = "refresh.sock"
+	dashboardAssetsManifestName  = "assets_manifest.json"
 	stateFileName                = "update_check.json"
 	legacyStateFileName          = "update-check.json"
 	availableVersionsFileName    = "available_versions.json"
 	legacyNotificationsFileName  = "notifications.json"
+
+	dashboardPermalinksFileName = "dashboard_permalinks.json"
+	searchPathOverrideFileName  = "search_path_override.json"
 )
 
 var SteampipeDir string
@@ -153,6 +158,17 @@ func ReportAssetsVersionFilePath() string {
 	return filepath.Join(EnsureDashboardAssetsDir(), versionFileName)
 }
 
+// DashboardAssetsManifestFilePath returns the path of the manifest of the installed dashboard assets
+// (this is outside the assets directory so it is not served by the dashboard server)
+func DashboardAssetsManifestFilePath() string {
+	return filepath.Join(ensureSteampipeSubDir("dashboard"), dashboardAssetsManifestName)
+}
+
+// DashboardPermalinksFilePath returns the path of the file the dashboard server stores dashboard permalinks in
+func DashboardPermalinksFilePath() string {
+	return filepath.Join(EnsureInternalDir(), dashboardPermalinksFileName)
+}
+
 func RunningInfoFilePath() string {
 	return filepath.Join(EnsureInternalDir(), databaseRunningInfoFileName)
 }
@@ -165,6 +181,16 @@ func DashboardServiceStateFilePath() string {
 	return filepath.Join(EnsureInternalDir(), dashboardServerStateFileName)
 }
 
+// RefreshServiceSocketPath returns the path of the unix socket the plugin manager serves connection refresh requests on
+func RefreshServiceSocketPath() string {
+	return filepath.Join(EnsureInternalDir(), refreshServiceSocketFileName)
+}
+
+// SearchPathOverrideFilePath returns the path of the file containing the search path overrides applied to the running service
+func SearchPathOverrideFilePath() string {
+	return filepath.Join(EnsureInternalDir(), searchPathOverrideFileName)
+}
+
 func StateFileName() string {
 	return stateFileName
 }
@@ -0,0 +1,178 @@
+package interactive
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+)
+
+// the characters which separate the word being completed from the preceding text,
+// e.g. 'where (a.na' completes 'a.na'
+const completionWordSeparator = " (),=<>!"
+
+// matches the tables referenced by a query, and their aliases
+// e.g. 'from aws_s3_bucket as b', 'join aws_prod.aws_iam_user u', ', aws_account'
+var tableReferenceRegex = regexp.MustCompile(`(?:\bfrom|\bjoin|,)\s+((?:[a-z_][a-z0-9_]*\.)?[a-z_][a-z0-9_]*)(?:\s+(?:as\s+)?([a-z_][a-z0-9_]*))?`)
+
+// matches a comparison of a column with the value being completed, e.g. 'where b.versioning_enabled = '
+var columnComparisonRegex = regexp.MustCompile(`([a-z_][a-z0-9_]*(?:\.[a-z_][a-z0-9_]*)*)\s*(?:=|!=|<>|\bis|\bis\s+not)\s*$`)
+
+// keywords which may follow a table name, so are not aliases
+var nonAliasKeywords = map[string]struct{}{
+	"where": {}, "join": {}, "inner": {}, "left": {}, "right": {}, "full": {}, "outer": {}, "cross": {},
+	"natural": {}, "lateral": {}, "on": {}, "using": {}, "group": {}, "order": {}, "limit": {}, "offset": {},
+	"having": {}, "union": {}, "except": {}, "intersect": {}, "window": {}, "fetch": {}, "for": {}, "from": {},
+}
+
+// keywords which are followed by a column
+var columnKeywords = map[string]struct{}{
+	"select": {}, "where": {}, "and": {}, "or": {}, "on": {}, "by": {}, "having": {}, "not": {}, "distinct": {},
+}
+
+// tableReference is a table referenced by a query
+type tableReference struct {
+	// the schema the table is qualified with - empty if the table is unqualified
+	schema string
+	name   string
+	alias  string
+}
+
+// getTableReferences returns the tables referenced in the FROM and JOIN clauses of a (lower case) query
+func getTableReferences(query string) []tableReference {
+	var res []tableReference
+	for _, match := range tableReferenceRegex.FindAllStringSubmatch(query, -1) {
+		ref := tableReference{name: match[1]}
+		if schema, name, qualified := strings.Cut(match[1], "."); qualified {
+			ref.schema, ref.name = schema, name
+		}
+		if _, isKeyword := nonAliasKeywords[match[2]]; !isKeyword {
+			ref.alias = match[2]
+		}
+		res = append(res, ref)
+	}
+	return res
+}
+
+// resolveTableReference returns the schema of a referenced table - unqualified tables are resolved using the search path
+func resolveTableReference(schemaMetadata *db_common.SchemaMetadata, searchPath []string, ref tableReference) (db_common.TableSchema, bool) {
+	if ref.schema != "" {
+		table, ok := schemaMetadata.Schemas[ref.schema][ref.name]
+		return table, ok
+	}
+	// temporary tables take precedence over the search path
+	for _, schema := range append([]string{schemaMetadata.TemporarySchemaName}, searchPath...) {
+		if table, ok := schemaMetadata.Schemas[schema][ref.name]; ok {
+			return table, true
+		}
+	}
+	return db_common.TableSchema{}, false
+}
+
+// getColumnSuggestions returns suggestions for the column (or column value) being entered
+// query is the full (lower case) text of the query, and textBeforeCursor the text of the query before the cursor
+func getColumnSuggestions(schemaMetadata *db_common.SchemaMetadata, searchPath []string, query, textBeforeCursor string) []prompt.Suggest {
+	if schemaMetadata == nil {
+		return nil
+	}
+	word := textBeforeCursor[strings.LastIndexAny(textBeforeCursor, completionWordSeparator)+1:]
+	preceding := strings.TrimRight(textBeforeCursor[:len(textBeforeCursor)-len(word)], " ")
+
+	// resolve the tables referenced by the query, keyed by the name they are referenced by in the query
+	tables := make(map[string]db_common.TableSchema)
+	var tableOrder []string
+	for _, ref := range getTableReferences(query) {
+		table, ok := resolveTableReference(schemaMetadata, searchPath, ref)
+		if !ok {
+			continue
+		}
+		name := ref.alias
+		if name == "" {
+			name = ref.name
+		}
+		if _, ok := tables[name]; !ok {
+			tableOrder = append(tableOrder, name)
+		}
+		tables[name] = table
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	// if the column is qualified with a table name or alias, suggest the columns of that table
+	if qualifier, _, qualified := cutLast(word, "."); qualified {
+		table, ok := tables[qualifier]
+		if !ok {
+			return nil
+		}
+		return columnSuggestions(table, qualifier+".")
+	}
+
+	if !isColumnPosition(preceding) {
+		return nil
+	}
+	var res []prompt.Suggest
+	// if the value being entered is compared with a boolean column, suggest true and false
+	// NOTE: the values of other key columns cannot be suggested - plugins do not send any enum metadata
+	// for key columns (the plugin schema has only the name, type and description of a column)
+	if match := columnComparisonRegex.FindStringSubmatch(preceding); match != nil {
+		if column, ok := findColumn(tables, match[1]); ok && column.Type == "boolean" {
+			res = append(res, prompt.Suggest{Text: "true", Output: "true", Description: "Value"}, prompt.Suggest{Text: "false", Output: "false", Description: "Value"})
+		}
+	}
+	added := make(map[string]struct{})
+	for _, name := range tableOrder {
+		for _, s := range columnSuggestions(tables[name], "") {
+			if _, ok := added[s.Text]; !ok {
+				added[s.Text] = struct{}{}
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
+
+// isColumnPosition returns whether a column may follow the given text
+func isColumnPosition(preceding string) bool {
+	if preceding == "" {
+		return false
+	}
+	if strings.ContainsAny(preceding[len(preceding)-1:], completionWordSeparator) {
+		return true
+	}
+	_, isColumnKeyword := columnKeywords[preceding[strings.LastIndex(preceding, " ")+1:]]
+	return isColumnKeyword
+}
+
+// findColumn finds a (possibly qualified) column in the referenced tables
+func findColumn(tables map[string]db_common.TableSchema, name string) (db_common.ColumnSchema, bool) {
+	if qualifier, columnName, qualified := cutLast(name, "."); qualified {
+		column, ok := tables[qualifier].Columns[columnName]
+		return column, ok
+	}
+	for _, table := range tables {
+		if column, ok := table.Columns[name]; ok {
+			return column, true
+		}
+	}
+	return db_common.ColumnSchema{}, false
+}
+
+func columnSuggestions(table db_common.TableSchema, prefix string) []prompt.Suggest {
+	res := make([]prompt.Suggest, 0, len(table.Columns))
+	for name := range table.Columns {
+		text := prefix + sanitiseTableName(name)
+		res = append(res, prompt.Suggest{Text: text, Output: text, Description: "Column: " + table.Name})
+	}
+	sortSuggestions(res)
+	return res
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
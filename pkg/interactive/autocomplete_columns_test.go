@@ -0,0 +1,134 @@
+package interactive
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/db/db_common"
+)
+
+var testSchemaMetadata = &db_common.SchemaMetadata{
+	Schemas: map[string]map[string]db_common.TableSchema{
+		"aws": {
+			"aws_s3_bucket": {
+				Name: "aws_s3_bucket",
+				Columns: map[string]db_common.ColumnSchema{
+					"name":               {Name: "name", Type: "text"},
+					"versioning_enabled": {Name: "versioning_enabled", Type: "boolean"},
+				},
+			},
+		},
+		"aws_prod": {
+			"aws_s3_bucket": {
+				Name: "aws_s3_bucket",
+				Columns: map[string]db_common.ColumnSchema{
+					"arn": {Name: "arn", Type: "text"},
+				},
+			},
+			"aws_iam_user": {
+				Name: "aws_iam_user",
+				Columns: map[string]db_common.ColumnSchema{
+					"name":       {Name: "name", Type: "text"},
+					"Created At": {Name: "Created At", Type: "timestamp with time zone"},
+				},
+			},
+		},
+	},
+	TemporarySchemaName: "pg_temp_3",
+}
+
+type columnSuggestionsTest struct {
+	query            string
+	textBeforeCursor string
+	expected         []string
+}
+
+var testCasesColumnSuggestions = map[string]columnSuggestionsTest{
+	"alias": {
+		query:            "select b. from aws_s3_bucket as b",
+		textBeforeCursor: "select b.",
+		expected:         []string{"b.name", "b.versioning_enabled"},
+	},
+	"alias without as": {
+		query:            "select * from aws_s3_bucket b where b.na",
+		textBeforeCursor: "select * from aws_s3_bucket b where b.na",
+		expected:         []string{"b.name", "b.versioning_enabled"},
+	},
+	"table name qualifier": {
+		query:            "select * from aws_s3_bucket where aws_s3_bucket.",
+		textBeforeCursor: "select * from aws_s3_bucket where aws_s3_bucket.",
+		expected:         []string{"aws_s3_bucket.name", "aws_s3_bucket.versioning_enabled"},
+	},
+	"qualified table": {
+		query:            "select * from aws_prod.aws_s3_bucket b where b.",
+		textBeforeCursor: "select * from aws_prod.aws_s3_bucket b where b.",
+		expected:         []string{"b.arn"},
+	},
+	"unknown qualifier": {
+		query:            "select * from aws_s3_bucket b where c.",
+		textBeforeCursor: "select * from aws_s3_bucket b where c.",
+	},
+	"where": {
+		query:            "select * from aws_s3_bucket where ",
+		textBeforeCursor: "select * from aws_s3_bucket where ",
+		expected:         []string{"name", "versioning_enabled"},
+	},
+	"after parenthesis": {
+		query:            "select * from aws_s3_bucket where (na",
+		textBeforeCursor: "select * from aws_s3_bucket where (na",
+		expected:         []string{"name", "versioning_enabled"},
+	},
+	"join": {
+		query:            "select * from aws_s3_bucket b join aws_prod.aws_iam_user u on ",
+		textBeforeCursor: "select * from aws_s3_bucket b join aws_prod.aws_iam_user u on ",
+		expected:         []string{"name", "versioning_enabled", `"Created At"`},
+	},
+	"boolean value": {
+		query:            "select * from aws_s3_bucket b where b.versioning_enabled = ",
+		textBeforeCursor: "select * from aws_s3_bucket b where b.versioning_enabled = ",
+		expected:         []string{"true", "false", "name", "versioning_enabled"},
+	},
+	"not a column position": {
+		query:            "select * from aws_s3_bucket where name ",
+		textBeforeCursor: "select * from aws_s3_bucket where name ",
+	},
+	"unknown table": {
+		query:            "select * from gcp_compute_instance where ",
+		textBeforeCursor: "select * from gcp_compute_instance where ",
+	},
+}
+
+func TestGetColumnSuggestions(t *testing.T) {
+	searchPath := []string{"aws", "aws_prod"}
+	for name, test := range testCasesColumnSuggestions {
+		var actual []string
+		for _, s := range getColumnSuggestions(testSchemaMetadata, searchPath, test.query, test.textBeforeCursor) {
+			actual = append(actual, s.Text)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+var testCasesTableReferences = map[string][]tableReference{
+	"select * from aws_s3_bucket": {
+		{name: "aws_s3_bucket"},
+	},
+	"select * from aws_s3_bucket where name = 'x'": {
+		{name: "aws_s3_bucket"},
+	},
+	"select * from aws.aws_s3_bucket as b, aws_iam_user u left join aws_account on true": {
+		{schema: "aws", name: "aws_s3_bucket", alias: "b"},
+		{name: "aws_iam_user", alias: "u"},
+		{name: "aws_account"},
+	},
+}
+
+func TestGetTableReferences(t *testing.T) {
+	for query, expected := range testCasesTableReferences {
+		if actual := getTableReferences(query); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", query, expected, actual)
+		}
+	}
+}
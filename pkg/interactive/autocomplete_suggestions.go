@@ -21,12 +21,6 @@ func newAutocompleteSuggestions() *autoCompleteSuggestions {
 	}
 }
 func (s autoCompleteSuggestions) sort() {
-	sortSuggestions := func(s []prompt.Suggest) {
-		sort.Slice(s, func(i, j int) bool {
-			return s[i].Text < s[j].Text
-		})
-	}
-
 	sortSuggestions(s.schemas)
 	sortSuggestions(s.unqualifiedTables)
 	sortSuggestions(s.unqualifiedQueries)
@@ -37,3 +31,9 @@ func (s autoCompleteSuggestions) sort() {
 		sortSuggestions(queries)
 	}
 }
+
+func sortSuggestions(s []prompt.Suggest) {
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].Text < s[j].Text
+	})
+}
@@ -0,0 +1,55 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// getEditor returns the command used to edit queries - $VISUAL or $EDITOR if set, or the platform default
+// the command may include arguments, e.g. 'code --wait'
+func getEditor() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.Fields(os.Getenv(env)); len(editor) > 0 {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// editInExternalEditor opens the text in the external editor, returning the edited text
+func editInExternalEditor(text string) (string, error) {
+	f, err := os.CreateTemp("", "steampipe-query-*.sql")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file to edit: %s", err.Error())
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(text)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write file to edit: %s", err.Error())
+	}
+
+	editor := getEditor()
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor '%s' failed: %s", strings.Join(editor, " "), err.Error())
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %s", err.Error())
+	}
+	return strings.TrimSpace(string(edited)), nil
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/turbot/steampipe/pkg/interactive/metaquery"
 	"github.com/turbot/steampipe/pkg/query"
 	"github.com/turbot/steampipe/pkg/query/queryhistory"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
 	"github.com/turbot/steampipe/pkg/statushooks"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
@@ -67,6 +68,16 @@ type InteractiveClient struct {
 	highlighter    *Highlighter
 	// hidePrompt is used to render a blank as the prompt prefix
 	hidePrompt bool
+	// the text the next prompt is started with (set after editing a query in an external editor)
+	initialBufferText string
+	// the query to open in the external editor before restarting the prompt (set by Ctrl+X Ctrl+E)
+	pendingEdit *string
+	// whether Ctrl+X was the last key pressed - Ctrl+X Ctrl+E opens the query in the external editor
+	ctrlXPressed bool
+	// the lines of a multi-line query entered before a metaquery
+	metaqueryBuffer []string
+	// the history entry for the query being executed - the duration and row count are recorded in this
+	currentHistoryEntry *queryhistory.HistoryEntry
 
 	suggestions *autoCompleteSuggestions
 }
@@ -150,6 +161,13 @@ func (c *InteractiveClient) InteractivePrompt(parentContext context.Context) {
 			// persist saved history
 			//nolint:golint,errcheck // worst case is history is not persisted - not a failure
 			c.interactiveQueryHistory.Persist()
+			// if the prompt was closed to edit the query, open the editor before restarting the prompt
+			if c.pendingEdit != nil {
+				if err := c.editQuery(*c.pendingEdit); err != nil {
+					error_helpers.ShowError(ctx, err)
+				}
+				c.pendingEdit = nil
+			}
 			// check post-close action
 			if c.afterClose == AfterPromptCloseExit {
 				// clear prompt so any messages/warnings can be displayed without the prompt
@@ -218,15 +236,22 @@ func (c *InteractiveClient) runInteractivePrompt(ctx context.Context) {
 	completer := func(d prompt.Document) []prompt.Suggest {
 		return c.queryCompleter(d)
 	}
+	// start the prompt with the text of an edited query (if any)
+	initialBufferText := c.initialBufferText
+	c.initialBufferText = ""
+	c.ctrlXPressed = false
+
 	c.interactivePrompt = prompt.New(
 		callExecutor,
 		completer,
 		prompt.OptionTitle("steampipe interactive client "),
+		prompt.OptionInitialBufferText(initialBufferText),
 		prompt.OptionLivePrefix(func() (prefix string, useLive bool) {
 			prefix = "> "
 			useLive = true
 			if len(c.interactiveBuffer) > 0 {
-				prefix = ">>  "
+				// show what is left open (e.g. a string or parenthesis) by the lines entered so far
+				prefix = continuationPrefix(strings.Join(c.interactiveBuffer, "\n"))
 			}
 			if c.hidePrompt {
 				prefix = ""
@@ -238,6 +263,7 @@ func (c *InteractiveClient) runInteractivePrompt(ctx context.Context) {
 		prompt.OptionInputTextColor(prompt.DefaultColor),
 		prompt.OptionPrefixTextColor(prompt.DefaultColor),
 		prompt.OptionMaxSuggestion(20),
+		prompt.OptionCompletionWordSeparator(completionWordSeparator),
 		// Known Key Bindings
 		prompt.OptionAddKeyBind(prompt.KeyBind{
 			Key: prompt.ControlC,
@@ -269,6 +295,19 @@ func (c *InteractiveClient) runInteractivePrompt(ctx context.Context) {
 				}
 			},
 		}),
+		// Ctrl+X Ctrl+E opens the query in the external editor
+		prompt.OptionAddKeyBind(prompt.KeyBind{
+			Key: prompt.ControlX,
+			Fn:  func(b *prompt.Buffer) { c.ctrlXPressed = true },
+		}),
+		prompt.OptionAddKeyBind(prompt.KeyBind{
+			Key: prompt.ControlE,
+			Fn: func(b *prompt.Buffer) {
+				if c.ctrlXPressed {
+					c.editPromptBuffer(b)
+				}
+			},
+		}),
 		prompt.OptionAddKeyBind(prompt.KeyBind{
 			Key: prompt.ShiftLeft,
 			Fn:  prompt.GoLeftChar,
@@ -306,6 +345,8 @@ func (c *InteractiveClient) runInteractivePrompt(ctx context.Context) {
 			Fn:        prompt.GoRightWord,
 		}),
 		prompt.OptionBufferPreHook(func(input string) (modifiedInput string, ignore bool) {
+			// typing text after Ctrl+X cancels the Ctrl+X Ctrl+E sequence
+			c.ctrlXPressed = false
 			// if this is not WSL, return as-is
 			if !utils.IsWSL() {
 				return input, false
@@ -336,6 +377,36 @@ func (c *InteractiveClient) breakMultilinePrompt(buffer *prompt.Buffer) {
 	c.interactiveBuffer = []string{}
 }
 
+// editPromptBuffer closes the prompt to edit the query being entered - the lines of a multi-line query
+// and the text of the prompt - in the external editor
+func (c *InteractiveClient) editPromptBuffer(buffer *prompt.Buffer) {
+	c.ctrlXPressed = false
+	query := strings.Join(append(c.interactiveBuffer, buffer.Text()), "\n")
+	c.interactiveBuffer = nil
+	c.pendingEdit = &query
+	c.ClosePrompt(AfterPromptCloseRestart)
+}
+
+// editQuery opens the query in the external editor - if the query is empty, the previous query is edited
+// the next prompt is started with the edited query, so it may be reviewed before it is executed
+func (c *InteractiveClient) editQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		history := c.interactiveQueryHistory.Get()
+		for i := len(history) - 1; i >= 0; i-- {
+			if !metaquery.IsMetaQuery(history[i]) {
+				query = history[i]
+				break
+			}
+		}
+	}
+	edited, err := editInExternalEditor(query)
+	if err != nil {
+		return err
+	}
+	c.initialBufferText = edited
+	return nil
+}
+
 func (c *InteractiveClient) executor(ctx context.Context, line string) {
 	// take an execution lock, so that errors and warnings don't show up while
 	// we are underway
@@ -393,7 +464,13 @@ func (c *InteractiveClient) executeQuery(ctx context.Context, queryCtx context.C
 	}
 
 	t := time.Now()
-	result, err := c.client().Execute(queryCtx, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
+	var result *queryresult.Result
+	var err error
+	if queryHistoryTableRegex.MatchString(resolvedQuery.ExecuteSQL) {
+		result, err = c.executeWithQueryHistoryTable(queryCtx, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
+	} else {
+		result, err = c.client().Execute(queryCtx, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
+	}
 	if err != nil {
 		error_helpers.ShowError(ctx, error_helpers.HandleCancelError(err))
 		// if timing flag is enabled, show the time taken for the query to fail
@@ -401,7 +478,7 @@ func (c *InteractiveClient) executeQuery(ctx context.Context, queryCtx context.C
 			display.DisplayErrorTiming(t)
 		}
 	} else {
-		c.promptResult.Streamer.StreamResult(result)
+		c.promptResult.Streamer.StreamResult(recordQueryHistoryResult(result, c.currentHistoryEntry, t))
 	}
 }
 
@@ -413,10 +490,11 @@ func (c *InteractiveClient) getQuery(ctx context.Context, line string) *modconfi
 
 	// store the history (the raw line which was entered)
 	historyEntry := line
+	c.currentHistoryEntry = nil
 	defer func() {
 		if len(historyEntry) > 0 {
 			// we want to store even if we fail to resolve a query
-			c.interactiveQueryHistory.Push(historyEntry)
+			c.currentHistoryEntry = c.interactiveQueryHistory.Push(historyEntry)
 		}
 
 	}()
@@ -455,6 +533,8 @@ func (c *InteractiveClient) getQuery(ctx context.Context, line string) *modconfi
 	// check if the contents in the buffer evaluates to a metaquery
 	if metaquery.IsMetaQuery(line) {
 		// this is a metaquery
+		// save any lines of a multi-line query entered before the metaquery (these are edited by '.edit')
+		c.metaqueryBuffer = c.interactiveBuffer[:len(c.interactiveBuffer)-1]
 		// clear the interactive buffer
 		c.interactiveBuffer = nil
 		return &modconfig.ResolvedQuery{
@@ -540,6 +620,8 @@ func (c *InteractiveClient) executeMetaquery(ctx context.Context, query string)
 		SearchPath:      client.GetRequiredSessionSearchPath(),
 		Prompt:          c.interactivePrompt,
 		ClosePrompt:     func() { c.afterClose = AfterPromptCloseExit },
+		EditQuery:       func() error { return c.editQuery(strings.Join(c.metaqueryBuffer, "\n")) },
+		History:         c.interactiveQueryHistory,
 		ConnectionState: connectionState,
 	})
 }
@@ -577,8 +659,8 @@ func (c *InteractiveClient) shouldExecute(line string, namedQuery bool) bool {
 		// execute metaqueries with no ';' even in multiline mode
 		return true
 	}
-	if strings.HasSuffix(line, ";") {
-		// statement has terminating ';'
+	if isCompleteStatement(line) {
+		// statement has terminating ';' (which is not inside a string or comment)
 		return true
 	}
 
@@ -616,10 +698,16 @@ func (c *InteractiveClient) queryCompleter(d prompt.Document) []prompt.Suggest {
 		if queryInfo := getQueryInfo(text); queryInfo.EditingTable {
 			tableSuggestions := c.getTableAndConnectionSuggestions(lastWord(text))
 			s = append(s, tableSuggestions...)
+		} else {
+			// include the lines of a multi-line query which have already been entered
+			query := strings.ToLower(strings.Join(append(c.interactiveBuffer, d.Text), "\n"))
+			textBeforeCursor := strings.ToLower(d.CurrentLineBeforeCursor())
+			columnSuggestions := getColumnSuggestions(c.schemaMetadata, c.client().GetRequiredSessionSearchPath(), query, textBeforeCursor)
+			s = append(s, columnSuggestions...)
 		}
 	}
 
-	return prompt.FilterHasPrefix(s, d.GetWordBeforeCursor(), true)
+	return prompt.FilterHasPrefix(s, d.GetWordBeforeCursorUntilSeparator(completionWordSeparator), true)
 }
 
 func (c *InteractiveClient) getFirstWordSuggestions(word string) []prompt.Suggest {
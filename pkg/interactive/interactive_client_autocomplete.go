@@ -51,10 +51,11 @@ func (c *InteractiveClient) initialiseSchemaAndTableSuggestions(connectionStateM
 	// (this is needed as GetFirstSearchPathConnectionForPlugins will return ALL dynamic connections)
 	var unqualifiedTablesToAdd = getIntrospectionTableSuggestions()
 
-	// add connection state and rate limit
+	// add connection state, rate limit and query history
 	unqualifiedTablesToAdd[constants.ConnectionTable] = struct{}{}
 	unqualifiedTablesToAdd[constants.PluginInstanceTable] = struct{}{}
 	unqualifiedTablesToAdd[constants.RateLimiterDefinitionTable] = struct{}{}
+	unqualifiedTablesToAdd[constants.QueryHistoryTable] = struct{}{}
 
 	// get the first search path connection for each plugin
 	firstConnectionPerPlugin := connectionStateMap.GetFirstSearchPathConnectionForPlugins(c.client().GetRequiredSessionSearchPath())
@@ -0,0 +1,86 @@
+package interactive
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/introspection"
+	"github.com/turbot/steampipe/pkg/query/queryhistory"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+)
+
+var queryHistoryTableRegex = regexp.MustCompile(`(?i)\b` + constants.QueryHistoryTable + `\b`)
+
+// executeWithQueryHistoryTable executes a query which uses the steampipe_query_history table
+// the table is a temporary table, so it is populated with the current history in the session the query is executed in
+func (c *InteractiveClient) executeWithQueryHistoryTable(ctx context.Context, query string, args ...any) (*queryresult.Result, error) {
+	sessionResult := c.client().AcquireSession(ctx)
+	if sessionResult.Error != nil {
+		return nil, sessionResult.Error
+	}
+	session := sessionResult.Session
+	closeSession := func() { session.Close(error_helpers.IsContextCanceled(ctx)) }
+
+	if err := c.populateQueryHistoryTable(ctx, session.Connection.Conn()); err != nil {
+		closeSession()
+		return nil, err
+	}
+	result, err := c.client().ExecuteInSession(ctx, session, closeSession, query, args...)
+	if err != nil {
+		closeSession()
+	}
+	return result, err
+}
+
+// populateQueryHistoryTable (re)creates the steampipe_query_history table for the connection
+func (c *InteractiveClient) populateQueryHistoryTable(ctx context.Context, conn *pgx.Conn) error {
+	queries := []db_common.QueryWithArgs{
+		introspection.GetQueryHistoryTableDropSql(),
+		introspection.GetQueryHistoryTableCreateSql(),
+	}
+	for _, entry := range c.interactiveQueryHistory.Entries() {
+		queries = append(queries, introspection.GetQueryHistoryTablePopulateSql(entry))
+	}
+	return db_common.ExecuteSystemClientCall(ctx, conn, func(ctx context.Context, tx pgx.Tx) error {
+		for _, q := range queries {
+			if _, err := tx.Exec(ctx, q.Query, q.Args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// recordQueryHistoryResult returns a result which streams the rows of the source result, and records the
+// duration and row count of the query in the history entry once all rows have been read
+func recordQueryHistoryResult(source *queryresult.Result, entry *queryhistory.HistoryEntry, startTime time.Time) *queryresult.Result {
+	if entry == nil {
+		return source
+	}
+	rowChan := make(chan *queryresult.RowResult)
+	res := &queryresult.Result{
+		RowChan:      &rowChan,
+		Cols:         source.Cols,
+		TimingResult: source.TimingResult,
+	}
+
+	go func() {
+		defer res.Close()
+
+		var rowCount int64
+		for row := range *source.RowChan {
+			if row.Error == nil {
+				rowCount++
+			}
+			rowChan <- row
+		}
+		// record the result before closing the channel, so it is set once the result has been displayed
+		entry.SetResult(time.Since(startTime), rowCount)
+	}()
+	return res
+}
@@ -82,6 +82,7 @@ func init() {
 			args: []metaQueryArg{
 				{value: constants.ArgOn, description: "Display time elapsed after every query"},
 				{value: constants.ArgOff, description: "Turn off query timer"},
+				{value: constants.ArgVerbose, description: "Display time elapsed, broken down into plan, fetch and render phases"},
 			},
 			completer: completerFromArgsOf(constants.CmdTiming),
 		},
@@ -138,6 +139,24 @@ func init() {
 			validator:   noArgs,
 			description: "Clear the console",
 		},
+		constants.CmdEdit: {
+			title:       constants.CmdEdit,
+			handler:     doEdit,
+			validator:   noArgs,
+			description: "Edit the current (or previous) query in $EDITOR",
+		},
+		constants.CmdHistory: {
+			title:       constants.CmdHistory,
+			handler:     history,
+			validator:   historyValidator,
+			description: "List or search the query history, or name or tag the previous query",
+			args: []metaQueryArg{
+				{value: constants.ArgSearch, description: "Search the history for queries, names or tags containing a term"},
+				{value: constants.ArgName, description: "Name the previous query"},
+				{value: constants.ArgTag, description: "Add tags to the previous query"},
+			},
+			completer: completerFromArgsOf(constants.CmdHistory),
+		},
 		constants.CmdSearchPath: {
 			title:       constants.CmdSearchPath,
 			handler:     setOrGetSearchPath,
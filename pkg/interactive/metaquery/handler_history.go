@@ -0,0 +1,83 @@
+package metaquery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/query/queryhistory"
+)
+
+// the number of queries listed by '.history'
+const historyListSize = 20
+
+// .history
+func history(_ context.Context, input *HandlerInput) error {
+	if input.History == nil {
+		return fmt.Errorf("query history is not available")
+	}
+	args := input.args()
+	if len(args) == 0 {
+		entries := historyQueries(input.History.Entries())
+		if len(entries) > historyListSize {
+			entries = entries[len(entries)-historyListSize:]
+		}
+		showHistory(entries)
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case constants.ArgSearch:
+		showHistory(historyQueries(input.History.Search(strings.Join(args[1:], " "))))
+		return nil
+	case constants.ArgName, constants.ArgTag:
+		// name or tag the most recent query
+		entries := historyQueries(input.History.Entries())
+		if len(entries) == 0 {
+			return fmt.Errorf("there is no previous query to %s", strings.ToLower(args[0]))
+		}
+		entry := entries[len(entries)-1]
+		if strings.ToLower(args[0]) == constants.ArgName {
+			entry.Name = args[1]
+		} else {
+			entry.AddTags(args[1:]...)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid command")
+}
+
+// historyQueries returns the history entries which are not metaqueries
+func historyQueries(entries []*queryhistory.HistoryEntry) []*queryhistory.HistoryEntry {
+	var res []*queryhistory.HistoryEntry
+	for _, entry := range entries {
+		if !IsMetaQuery(entry.Query) {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+func showHistory(entries []*queryhistory.HistoryEntry) {
+	if len(entries) == 0 {
+		fmt.Println("No queries found.")
+		return
+	}
+	rows := [][]string{{"Timestamp", "Duration", "Rows", "Name", "Tags", "Query"}}
+	for _, entry := range entries {
+		var timestamp, duration, rowCount string
+		if !entry.Timestamp.IsZero() {
+			timestamp = entry.Timestamp.Local().Format(time.DateTime)
+		}
+		if entry.Duration != nil {
+			duration = entry.Duration.Round(time.Millisecond).String()
+		}
+		if entry.RowCount != nil {
+			rowCount = fmt.Sprintf("%d", *entry.RowCount)
+		}
+		rows = append(rows, []string{timestamp, duration, rowCount, entry.Name, strings.Join(entry.Tags, ", "), entry.Query})
+	}
+	fmt.Println(buildTable(rows, false))
+}
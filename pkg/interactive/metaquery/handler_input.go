@@ -3,6 +3,7 @@ package metaquery
 import (
 	"github.com/c-bata/go-prompt"
 	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/query/queryhistory"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 )
 
@@ -11,8 +12,11 @@ type HandlerInput struct {
 	Client db_common.Client
 	Schema *db_common.SchemaMetadata
 
-	Prompt          *prompt.Prompt
-	ClosePrompt     func()
+	Prompt      *prompt.Prompt
+	ClosePrompt func()
+	// EditQuery opens the query being entered (or the previous query) in an external editor
+	EditQuery       func() error
+	History         *queryhistory.QueryHistory
 	Query           string
 	ConnectionState steampipeconfig.ConnectionStateMap
 	SearchPath      []string
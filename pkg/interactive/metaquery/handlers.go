@@ -3,6 +3,8 @@ package metaquery
 import (
 	"context"
 	"fmt"
+	"strings"
+
 	typeHelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/steampipe/pkg/cmdconfig"
 	"github.com/turbot/steampipe/pkg/constants"
@@ -36,9 +38,12 @@ func setMultiLine(_ context.Context, input *HandlerInput) error {
 }
 
 // .timing
-// set the ArgHeader viper key with the boolean value evaluated from arg[0]
+// set the ArgTiming viper key with the boolean value evaluated from arg[0]
+// 'verbose' turns on timing, with a breakdown of the time taken by each phase of the query
 func setTiming(_ context.Context, input *HandlerInput) error {
-	cmdconfig.Viper().Set(constants.ArgTiming, typeHelpers.StringToBool(input.args()[0]))
+	verbose := strings.ToLower(input.args()[0]) == constants.ArgVerbose
+	cmdconfig.Viper().Set(constants.ArgTiming, verbose || typeHelpers.StringToBool(input.args()[0]))
+	cmdconfig.Viper().Set(constants.ConfigKeyTimingVerbose, verbose)
 	return nil
 }
 
@@ -57,6 +62,11 @@ func doExit(_ context.Context, input *HandlerInput) error {
 	return nil
 }
 
+// .edit
+func doEdit(_ context.Context, input *HandlerInput) error {
+	return input.EditQuery()
+}
+
 // .clear
 func clearScreen(_ context.Context, input *HandlerInput) error {
 	input.Prompt.ClearScreen()
@@ -143,6 +143,22 @@ var exactlyNArgs = func(n int) validator {
 
 var noArgs = exactlyNArgs(0)
 
+// historyValidator validates '.history', '.history search <term>', '.history name <name>' and '.history tag <tag>...'
+func historyValidator(args []string) ValidationResult {
+	if len(args) == 0 {
+		return ValidationResult{ShouldRun: true}
+	}
+	if res := validatorFromArgsOf(constants.CmdHistory)(args[:1]); res.Err != nil {
+		return res
+	}
+	switch args[0] {
+	case constants.ArgName:
+		return exactlyNArgs(2)(args)
+	default:
+		return atLeastNArgs(2)(args)
+	}
+}
+
 var allowedArgValues = func(caseSensitive bool, allowedValues ...string) validator {
 	return func(args []string) ValidationResult {
 		if !caseSensitive {
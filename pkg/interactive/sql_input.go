@@ -0,0 +1,102 @@
+package interactive
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// matches the opening tag of a dollar quoted string, e.g. $$ or $body$
+var dollarQuoteTagRegex = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// sqlInputState is the lexical state at the end of a (possibly partially entered) SQL statement
+type sqlInputState struct {
+	// the delimiter which closes an unterminated string, quoted identifier, dollar quoted string or block comment
+	openDelimiter string
+	// the number of unclosed parentheses
+	parenDepth int
+	// whether the statement ends with a semicolon (outside any string or comment)
+	terminated bool
+}
+
+func scanSQLInput(sql string) sqlInputState {
+	var s sqlInputState
+	for i := 0; i < len(sql); {
+		if s.openDelimiter != "" {
+			end := strings.Index(sql[i:], s.openDelimiter)
+			if end == -1 {
+				return s
+			}
+			// NOTE: an escaped quote ('') closes and reopens the string, so needs no special handling
+			i += end + len(s.openDelimiter)
+			s.openDelimiter = ""
+			continue
+		}
+
+		rest := sql[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			// line comments do not change whether the statement is terminated
+			end := strings.Index(rest, "\n")
+			if end == -1 {
+				return s
+			}
+			i += end
+			continue
+		case strings.HasPrefix(rest, "/*"):
+			s.openDelimiter = "*/"
+			i += 2
+			continue
+		case rest[0] == '\'' || rest[0] == '"':
+			s.openDelimiter = rest[:1]
+			s.terminated = false
+		case rest[0] == '$':
+			if tag := dollarQuoteTagRegex.FindString(rest); tag != "" {
+				s.openDelimiter = tag
+				s.terminated = false
+				i += len(tag)
+				continue
+			}
+			s.terminated = false
+		case rest[0] == '(':
+			s.parenDepth++
+			s.terminated = false
+		case rest[0] == ')':
+			if s.parenDepth > 0 {
+				s.parenDepth--
+			}
+			s.terminated = false
+		case rest[0] == ';':
+			s.terminated = true
+		case !unicode.IsSpace(rune(rest[0])):
+			s.terminated = false
+		}
+		i++
+	}
+	return s
+}
+
+// isCompleteStatement returns whether the SQL is terminated by a semicolon which is not inside a string or comment
+func isCompleteStatement(sql string) bool {
+	s := scanSQLInput(sql)
+	return s.terminated && s.openDelimiter == ""
+}
+
+// continuationPrefix returns the prompt prefix displayed while a multi-line statement is entered, showing
+// what is open at the end of the statement so far, e.g. "'>  " when inside a string
+func continuationPrefix(sql string) string {
+	s := scanSQLInput(sql)
+	switch {
+	case s.openDelimiter == "'":
+		return "'>  "
+	case s.openDelimiter == `"`:
+		return `">  `
+	case s.openDelimiter == "*/":
+		return "*>  "
+	case s.openDelimiter != "":
+		return "$>  "
+	case s.parenDepth > 0:
+		return "(>  "
+	}
+	return ">>  "
+}
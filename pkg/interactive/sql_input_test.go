@@ -0,0 +1,85 @@
+package interactive
+
+import "testing"
+
+type sqlInputTest struct {
+	sql                string
+	complete           bool
+	continuationPrefix string
+}
+
+var testCasesSQLInput = map[string]sqlInputTest{
+	"empty": {
+		sql:                "",
+		continuationPrefix: ">>  ",
+	},
+	"unterminated": {
+		sql:                "select *\nfrom aws_s3_bucket",
+		continuationPrefix: ">>  ",
+	},
+	"terminated": {
+		sql:                "select *\nfrom aws_s3_bucket;",
+		complete:           true,
+		continuationPrefix: ">>  ",
+	},
+	"terminated with trailing space and comment": {
+		sql:                "select 1; -- comment\n ",
+		complete:           true,
+		continuationPrefix: ">>  ",
+	},
+	"semicolon in string": {
+		sql:                "select 'a;",
+		continuationPrefix: "'>  ",
+	},
+	"escaped quote": {
+		sql:                "select 'it''s';",
+		complete:           true,
+		continuationPrefix: ">>  ",
+	},
+	"semicolon in quoted identifier": {
+		sql:                `select "a;`,
+		continuationPrefix: `">  `,
+	},
+	"semicolon in line comment": {
+		sql:                "select 1 -- done;",
+		continuationPrefix: ">>  ",
+	},
+	"semicolon in block comment": {
+		sql:                "select 1 /* done;",
+		continuationPrefix: "*>  ",
+	},
+	"closed block comment": {
+		sql:                "select 1 /* a */;",
+		complete:           true,
+		continuationPrefix: ">>  ",
+	},
+	"dollar quoted": {
+		sql:                "select $body$ a;",
+		continuationPrefix: "$>  ",
+	},
+	"closed dollar quoted": {
+		sql:                "select $$ a; $$;",
+		complete:           true,
+		continuationPrefix: ">>  ",
+	},
+	"parameter is not a dollar quote": {
+		sql:                "select $1;",
+		complete:           true,
+		continuationPrefix: ">>  ",
+	},
+	"open parenthesis": {
+		sql:                "select count(*) from (select 1",
+		continuationPrefix: "(>  ",
+	},
+}
+
+func TestSQLInput(t *testing.T) {
+	for name, test := range testCasesSQLInput {
+		if complete := isCompleteStatement(test.sql); complete != test.complete {
+			t.Errorf("Test: '%s' FAILED : expected complete %v, got %v", name, test.complete, complete)
+		}
+		if prefix := continuationPrefix(test.sql); prefix != test.continuationPrefix {
+			t.Errorf("Test: '%s' FAILED : expected continuation prefix '%s', got '%s'", name, test.continuationPrefix, prefix)
+		}
+	}
+}
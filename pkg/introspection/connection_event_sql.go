@@ -0,0 +1,16 @@
+package introspection
+
+import (
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/steampipeconfig"
+)
+
+// GetConnectionEventSql returns the sql to send a connection event on the connection events channel
+// (if this is executed in a transaction, the event is only sent when the transaction commits)
+func GetConnectionEventSql(event *steampipeconfig.ConnectionEvent) db_common.QueryWithArgs {
+	return db_common.QueryWithArgs{
+		Query: `select pg_notify($1, $2)`,
+		Args:  []any{constants.PostgresConnectionEventChannel, event.NotificationPayload()},
+	}
+}
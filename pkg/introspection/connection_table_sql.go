@@ -2,6 +2,7 @@ package introspection
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/db/db_common"
@@ -10,13 +11,15 @@ import (
 	"golang.org/x/exp/maps"
 )
 
-func GetConnectionStateTableDropSql() []db_common.QueryWithArgs {
-	queryFormat := `DROP TABLE IF EXISTS %s.%s;`
-	return getConnectionStateQueries(queryFormat, nil)
+func GetConnectionStateTableDropSql(table steampipeconfig.ConnectionStateTable) []db_common.QueryWithArgs {
+	queryFormat := `DROP TABLE IF EXISTS %s;`
+	// the health view depends on the table so must be dropped first
+	dropHealthView := db_common.QueryWithArgs{Query: fmt.Sprintf(`DROP VIEW IF EXISTS %s;`, table.HealthViewIdentifier())}
+	return append([]db_common.QueryWithArgs{dropHealthView}, getConnectionStateQueries(table, queryFormat, nil)...)
 }
 
-func GetConnectionStateTableCreateSql() []db_common.QueryWithArgs {
-	queryFormat := `CREATE TABLE IF NOT EXISTS %s.%s (
+func GetConnectionStateTableCreateSql(table steampipeconfig.ConnectionStateTable) []db_common.QueryWithArgs {
+	queryFormat := `CREATE TABLE IF NOT EXISTS %s (
 	name TEXT PRIMARY KEY,
 	state TEXT,
 	type TEXT NULL,
@@ -27,45 +30,101 @@ func GetConnectionStateTableCreateSql() []db_common.QueryWithArgs {
 	plugin_instance TEXT NULL,
 	schema_mode TEXT,
 	schema_hash TEXT NULL,
+	schema_fingerprint TEXT NULL,
 	comments_set BOOL DEFAULT FALSE,
 	connection_mod_time TIMESTAMPTZ,
 	plugin_mod_time TIMESTAMPTZ,
 	file_name TEXT, 
 	start_line_number INTEGER, 
-	end_line_number INTEGER
+	end_line_number INTEGER,
+	last_refreshed TIMESTAMPTZ NULL,
+	last_error_at TIMESTAMPTZ NULL,
+	max_concurrency INTEGER NULL,
+	rate_limit REAL NULL,
+	health_status TEXT NULL,
+	health_latency_ms INTEGER NULL,
+	health_error TEXT NULL,
+	health_checked_at TIMESTAMPTZ NULL
 );`
-	return getConnectionStateQueries(queryFormat, nil)
+	queries := getConnectionStateQueries(table, queryFormat, nil)
+	// if a custom schema is configured for the table, ensure it exists
+	if table.Schema != constants.InternalSchema {
+		createSchema := db_common.QueryWithArgs{Query: fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s;`, db_common.PgEscapeName(table.Schema))}
+		queries = append([]db_common.QueryWithArgs{createSchema}, queries...)
+	}
+	return queries
 }
 
 // GetConnectionStateTableGrantSql returns the sql to setup SELECT permission for the 'steampipe_users' role
-func GetConnectionStateTableGrantSql() []db_common.QueryWithArgs {
+func GetConnectionStateTableGrantSql(table steampipeconfig.ConnectionStateTable) []db_common.QueryWithArgs {
 	queryFormat := fmt.Sprintf(
-		`GRANT SELECT ON TABLE %%s.%%s TO %s;`,
+		`GRANT SELECT ON TABLE %%s TO %s;`,
 		constants.DatabaseUsersRole,
 	)
-	return getConnectionStateQueries(queryFormat, nil)
+	queries := getConnectionStateQueries(table, queryFormat, nil)
+	// if a custom schema is configured for the table, the users role must be able to use it
+	if table.Schema != constants.InternalSchema {
+		grantUsage := db_common.QueryWithArgs{Query: fmt.Sprintf(`GRANT USAGE ON SCHEMA %s TO %s;`, db_common.PgEscapeName(table.Schema), constants.DatabaseUsersRole)}
+		queries = append(queries, grantUsage)
+	}
+	return queries
+}
+
+// GetConnectionHealthViewCreateSql returns the sql to create the view exposing the connection health check results
+// and setup SELECT permission for the 'steampipe_users' role
+func GetConnectionHealthViewCreateSql(table steampipeconfig.ConnectionStateTable) []db_common.QueryWithArgs {
+	createView := fmt.Sprintf(`CREATE OR REPLACE VIEW %s AS
+SELECT
+	name AS connection,
+	state,
+	health_status AS status,
+	health_latency_ms AS latency_ms,
+	health_error AS error,
+	health_checked_at AS checked_at
+FROM %s;`, table.HealthViewIdentifier(), table.Identifier())
+	grant := fmt.Sprintf(`GRANT SELECT ON TABLE %s TO %s;`, table.HealthViewIdentifier(), constants.DatabaseUsersRole)
+	return []db_common.QueryWithArgs{{Query: createView}, {Query: grant}}
+}
+
+// GetSetConnectionHealthSql returns the sql to record the result of a connection health check
+func GetSetConnectionHealthSql(table steampipeconfig.ConnectionStateTable, health *steampipeconfig.ConnectionHealth) []db_common.QueryWithArgs {
+	queryFormat := `UPDATE %s
+SET health_status = $1,
+	health_latency_ms = $2,
+	health_error = $3,
+	health_checked_at = $4
+WHERE
+	name = $5`
+	var healthError *string
+	if health.Error != "" {
+		healthError = &health.Error
+	}
+	args := []any{health.Status, health.LatencyMs, healthError, health.CheckedAt, health.ConnectionName}
+	return getConnectionStateQueries(table, queryFormat, args)
 }
 
 // GetConnectionStateErrorSql returns the sql to set a connection to 'error'
-func GetConnectionStateErrorSql(connectionName string, err error) []db_common.QueryWithArgs {
-	queryFormat := fmt.Sprintf(`UPDATE %%s.%%s
+func GetConnectionStateErrorSql(table steampipeconfig.ConnectionStateTable, connectionName string, err error) []db_common.QueryWithArgs {
+	queryFormat := fmt.Sprintf(`UPDATE %%s
 SET state = '%s',
 	error = $1,
-	connection_mod_time = now()
+	connection_mod_time = now(),
+	last_error_at = now()
 WHERE
 	name = $2
 	`, constants.ConnectionStateError)
 
 	args := []any{err.Error(), connectionName}
-	return getConnectionStateQueries(queryFormat, args)
+	return getConnectionStateQueries(table, queryFormat, args)
 }
 
 // GetIncompleteConnectionStateErrorSql returns the sql to set all incomplete connections to 'error' (unless they alre already in error)
-func GetIncompleteConnectionStateErrorSql(err error) []db_common.QueryWithArgs {
-	queryFormat := fmt.Sprintf(`UPDATE %%s.%%s
+func GetIncompleteConnectionStateErrorSql(table steampipeconfig.ConnectionStateTable, err error) []db_common.QueryWithArgs {
+	queryFormat := fmt.Sprintf(`UPDATE %%s
 SET state = '%s',
 	error = $1,
-	connection_mod_time = now()
+	connection_mod_time = now(),
+	last_error_at = now()
 WHERE
 	state <> 'ready' 
 AND state <> 'disabled' 
@@ -73,13 +132,14 @@ AND state <> 'error'
 	`,
 		constants.ConnectionStateError)
 	args := []any{err.Error()}
-	return getConnectionStateQueries(queryFormat, args)
+	return getConnectionStateQueries(table, queryFormat, args)
 }
 
 // GetUpsertConnectionStateSql returns the sql to update the connection state in the able with the current properties
-func GetUpsertConnectionStateSql(c *steampipeconfig.ConnectionState) []db_common.QueryWithArgs {
+// (the last refreshed and last error times are only updated if set - otherwise the existing values are retained)
+func GetUpsertConnectionStateSql(table steampipeconfig.ConnectionStateTable, c *steampipeconfig.ConnectionState) []db_common.QueryWithArgs {
 	// upsert
-	queryFormat := `INSERT INTO %s.%s (name, 
+	queryFormat := `INSERT INTO %s AS cs (name, 
 		state,
 		type,
  		connections,
@@ -94,8 +154,13 @@ func GetUpsertConnectionStateSql(c *steampipeconfig.ConnectionState) []db_common
 		plugin_mod_time,
 	    file_name,
 	    start_line_number,
-	    end_line_number)
-VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,now(),$12,$13,$14,$15) 
+	    end_line_number,
+	    last_refreshed,
+	    last_error_at,
+	    schema_fingerprint,
+	    max_concurrency,
+	    rate_limit)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,now(),$12,$13,$14,$15,$16,$17,$18,$19,$20) 
 ON CONFLICT (name) 
 DO 
    UPDATE SET 
@@ -113,7 +178,12 @@ DO
 			  plugin_mod_time = $12,
 			  file_name = $13,
 	    	  start_line_number = $14,
-	     	  end_line_number = $15
+	     	  end_line_number = $15,
+			  last_refreshed = COALESCE($16, cs.last_refreshed),
+			  last_error_at = COALESCE($17, cs.last_error_at),
+			  schema_fingerprint = $18,
+			  max_concurrency = $19,
+			  rate_limit = $20
 			  
 `
 	args := []any{
@@ -132,12 +202,17 @@ DO
 		c.FileName,
 		c.StartLineNumber,
 		c.EndLineNumber,
+		c.LastRefreshed,
+		c.LastErrorAt,
+		c.SchemaFingerprint,
+		c.MaxConcurrency,
+		c.RateLimit,
 	}
-	return getConnectionStateQueries(queryFormat, args)
+	return getConnectionStateQueries(table, queryFormat, args)
 }
 
-func GetNewConnectionStateFromConnectionInsertSql(c *modconfig.Connection) []db_common.QueryWithArgs {
-	queryFormat := `INSERT INTO %s.%s (name, 
+func GetNewConnectionStateFromConnectionInsertSql(table steampipeconfig.ConnectionStateTable, c *modconfig.Connection) []db_common.QueryWithArgs {
+	queryFormat := `INSERT INTO %s (name, 
 		state,
 		type,
 	    connections,
@@ -152,12 +227,15 @@ func GetNewConnectionStateFromConnectionInsertSql(c *modconfig.Connection) []db_
 		plugin_mod_time,
 		file_name,
 	    start_line_number,
-	    end_line_number)
-VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,now(),now(),$12,$13,$14) 
+	    end_line_number,
+	    schema_fingerprint)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,now(),now(),$12,$13,$14,$15) 
 `
 	schemaMode := ""
 	commentsSet := false
 	schemaHash := ""
+	// the connection is pending_incomplete so will be updated regardless of its fingerprint
+	schemaFingerprint := ""
 
 	args := []any{
 		c.Name,
@@ -174,13 +252,14 @@ VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,now(),now(),$12,$13,$14)
 		c.DeclRange.Filename,
 		c.DeclRange.Start.Line,
 		c.DeclRange.End.Line,
+		schemaFingerprint,
 	}
 
-	return getConnectionStateQueries(queryFormat, args)
+	return getConnectionStateQueries(table, queryFormat, args)
 }
 
-func GetSetConnectionStateSql(connectionName string, state string) []db_common.QueryWithArgs {
-	queryFormat := fmt.Sprintf(`UPDATE %%s.%%s 
+func GetSetConnectionStateSql(table steampipeconfig.ConnectionStateTable, connectionName string, state string) []db_common.QueryWithArgs {
+	queryFormat := fmt.Sprintf(`UPDATE %%s 
     SET	state = '%s', 
 	 	connection_mod_time = now()
     WHERE 
@@ -188,28 +267,46 @@ func GetSetConnectionStateSql(connectionName string, state string) []db_common.Q
 `, state)
 
 	args := []any{connectionName}
-	return getConnectionStateQueries(queryFormat, args)
+	return getConnectionStateQueries(table, queryFormat, args)
 }
 
-func GetDeleteConnectionStateSql(connectionName string) []db_common.QueryWithArgs {
-	queryFormat := `DELETE FROM %s.%s WHERE NAME=$1`
+// GetSetConnectionStateReadySql returns the sql to set a connection to 'ready', recording the time it was refreshed
+func GetSetConnectionStateReadySql(table steampipeconfig.ConnectionStateTable, connectionName string, refreshTime time.Time) []db_common.QueryWithArgs {
+	queryFormat := fmt.Sprintf(`UPDATE %%s 
+    SET	state = '%s', 
+	 	connection_mod_time = now(),
+	 	last_refreshed = $1
+    WHERE 
+        name = $2
+`, constants.ConnectionStateReady)
+
+	args := []any{refreshTime, connectionName}
+	return getConnectionStateQueries(table, queryFormat, args)
+}
+
+func GetDeleteConnectionStateSql(table steampipeconfig.ConnectionStateTable, connectionName string) []db_common.QueryWithArgs {
+	queryFormat := `DELETE FROM %s WHERE NAME=$1`
 	args := []any{connectionName}
-	return getConnectionStateQueries(queryFormat, args)
+	return getConnectionStateQueries(table, queryFormat, args)
 }
 
-func GetSetConnectionStateCommentLoadedSql(connectionName string, commentsLoaded bool) []db_common.QueryWithArgs {
-	queryFormat := `UPDATE  %s.%s
+func GetSetConnectionStateCommentLoadedSql(table steampipeconfig.ConnectionStateTable, connectionName string, commentsLoaded bool) []db_common.QueryWithArgs {
+	queryFormat := `UPDATE  %s
 SET comments_set = $1
 WHERE NAME=$2`
 	args := []any{commentsLoaded, connectionName}
-	return getConnectionStateQueries(queryFormat, args)
+	return getConnectionStateQueries(table, queryFormat, args)
 }
 
-func getConnectionStateQueries(queryFormat string, args []any) []db_common.QueryWithArgs {
-	query := fmt.Sprintf(queryFormat, constants.InternalSchema, constants.ConnectionTable)
-	legacyQuery := fmt.Sprintf(queryFormat, constants.InternalSchema, constants.LegacyConnectionStateTable)
-	return []db_common.QueryWithArgs{
-		{Query: query, Args: args},
-		{Query: legacyQuery, Args: args},
+// getConnectionStateQueries returns the queries to execute the given query against the connection state table
+// if the default connection state table is used, the query is also executed against the legacy connection state table
+func getConnectionStateQueries(table steampipeconfig.ConnectionStateTable, queryFormat string, args []any) []db_common.QueryWithArgs {
+	queries := []db_common.QueryWithArgs{
+		{Query: fmt.Sprintf(queryFormat, table.Identifier()), Args: args},
+	}
+	if table.IsDefault() {
+		legacyTable := steampipeconfig.ConnectionStateTable{Schema: constants.InternalSchema, Name: constants.LegacyConnectionStateTable}
+		queries = append(queries, db_common.QueryWithArgs{Query: fmt.Sprintf(queryFormat, legacyTable.Identifier()), Args: args})
 	}
+	return queries
 }
@@ -0,0 +1,67 @@
+package introspection
+
+import (
+	"fmt"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+	"github.com/turbot/steampipe/pkg/query/queryhistory"
+)
+
+// the query history table is a temporary table, as the history belongs to the client rather than the service
+
+func GetQueryHistoryTableCreateSql() db_common.QueryWithArgs {
+	return db_common.QueryWithArgs{
+		Query: fmt.Sprintf(`CREATE TEMPORARY TABLE IF NOT EXISTS %s (
+				query TEXT NOT NULL,
+				name TEXT NULL,
+				tags JSONB NULL,
+				timestamp TIMESTAMPTZ NULL,
+				duration_ms DOUBLE PRECISION NULL,
+				row_count BIGINT NULL
+		);`, constants.QueryHistoryTable),
+	}
+}
+
+func GetQueryHistoryTablePopulateSql(entry *queryhistory.HistoryEntry) db_common.QueryWithArgs {
+	// entries loaded from an old history file have no timestamp
+	var timestamp, durationMs any
+	if !entry.Timestamp.IsZero() {
+		timestamp = entry.Timestamp
+	}
+	if entry.Duration != nil {
+		durationMs = float64(entry.Duration.Microseconds()) / 1000
+	}
+	var name any
+	if entry.Name != "" {
+		name = entry.Name
+	}
+	return db_common.QueryWithArgs{
+		Query: fmt.Sprintf(`INSERT INTO %s (
+query,
+name,
+tags,
+timestamp,
+duration_ms,
+row_count
+)
+	VALUES($1,$2,$3,$4,$5,$6)`, constants.QueryHistoryTable),
+		Args: []any{
+			entry.Query,
+			name,
+			entry.Tags,
+			timestamp,
+			durationMs,
+			entry.RowCount,
+		},
+	}
+}
+
+func GetQueryHistoryTableDropSql() db_common.QueryWithArgs {
+	return db_common.QueryWithArgs{
+		Query: fmt.Sprintf(
+			`DROP TABLE IF EXISTS pg_temp.%s;`,
+			constants.QueryHistoryTable,
+		),
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"io"
 	"log"
 	"os/exec"
+	"strconv"
 	"syscall"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
+	"github.com/spf13/viper"
 	"github.com/turbot/steampipe-plugin-sdk/v5/logging"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 	"github.com/turbot/steampipe/pkg/constants"
@@ -45,9 +47,30 @@ func StartNewInstance(steampipeExecutablePath string) (*State, error) {
 func start(steampipeExecutablePath string) (*State, error) {
 	// note: we assume the install dir has been assigned to file_paths.SteampipeDir
 	// - this is done both by the FDW and Steampipe
-	pluginManagerCmd := exec.Command(steampipeExecutablePath,
-		"plugin-manager",
-		"--"+constants.ArgInstallDir, filepaths.SteampipeDir)
+	args := []string{"plugin-manager", "--" + constants.ArgInstallDir, filepaths.SteampipeDir}
+	// the plugin manager performs connection refresh, so must be told if plugin validation should be skipped
+	if viper.GetBool(constants.ArgSkipPluginValidation) {
+		args = append(args, "--"+constants.ArgSkipPluginValidation)
+	}
+	// ...and the application name of the refresh database sessions
+	if viper.IsSet(constants.ArgRefreshApplicationName) {
+		args = append(args, "--"+constants.ArgRefreshApplicationName, viper.GetString(constants.ArgRefreshApplicationName))
+	}
+	// ...and if the catalog usage of connection schemas should be reported
+	if viper.GetBool(constants.ArgRefreshCatalogStats) {
+		args = append(args, "--"+constants.ArgRefreshCatalogStats)
+	}
+	// ...and the number of connection schemas to update in parallel
+	if viper.IsSet(constants.ArgUpdateSchemaMaxParallel) {
+		args = append(args, "--"+constants.ArgUpdateSchemaMaxParallel, strconv.Itoa(viper.GetInt(constants.ArgUpdateSchemaMaxParallel)))
+	}
+	// ...and if status output should be suppressed during refresh
+	if viper.GetBool(constants.ArgQuiet) {
+		args = append(args, "--"+constants.ArgQuiet)
+	}
+	// NOTE: one-shot refresh options (e.g. --prune-schemas) are not passed here -
+	// they are sent with each refresh request (see NewRefreshConnectionsRequest)
+	pluginManagerCmd := exec.Command(steampipeExecutablePath, args...)
 	// set attributes on the command to ensure the process is not shutdown when its parent terminates
 	pluginManagerCmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid: true,
@@ -0,0 +1,31 @@
+package pluginmanager
+
+import (
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	pb "github.com/turbot/steampipe/pkg/pluginmanager_service/grpc/proto"
+)
+
+// NewRefreshConnectionsRequest builds a request to refresh connections, force updating all connections for the given plugins
+// the one-shot refresh options (e.g. --prune-schemas) are read from config - these are sent with the request,
+// rather than passed to the plugin manager when it is started, so they only apply to this refresh
+func NewRefreshConnectionsRequest(plugins ...string) *pb.RefreshConnectionsRequest {
+	req := &pb.RefreshConnectionsRequest{
+		Plugins:        plugins,
+		ForceUpdateAll: viper.GetBool(constants.ArgForceUpdateAll),
+		NoRefresh:      viper.GetBool(constants.ArgNoRefresh),
+	}
+	// --dry-run only applies to pruning
+	if viper.GetBool(constants.ArgPruneSchemas) {
+		req.PruneSchemas = true
+		req.DryRun = viper.GetBool(constants.ArgDryRun)
+	}
+	return req
+}
+
+// HasOneShotOptions returns whether the request has any options which only apply to this refresh
+// if so, the refresh must be requested even if the service is already running
+// (NoRefresh is not included - if the service is already running, no refresh is requested anyway)
+func HasOneShotOptions(req *pb.RefreshConnectionsRequest) bool {
+	return len(req.Plugins) > 0 || req.PruneSchemas || req.ForceUpdateAll
+}
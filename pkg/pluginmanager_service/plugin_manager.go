@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -56,7 +57,9 @@ type PluginManager struct {
 
 	// shutdown syncronozation
 	// do not start any plugins while shutting down
-	shutdownMut sync.Mutex
+	shutdownFlag atomic.Bool
+	// ensure shutdown only runs once (it may be called by both the Shutdown RPC and the SIGTERM handler)
+	shutdownOnce sync.Once
 	// do not shutdown until all plugins have loaded
 	startPluginWg sync.WaitGroup
 
@@ -64,7 +67,7 @@ type PluginManager struct {
 	messageServer *PluginMessageServer
 
 	// map of user configured rate limiter maps, keyed by plugin instance
-	// NOTE: this is populated from config
+	// NOTE: this is populated from config - it includes the limiters for connections with max_concurrency or rate_limit set
 	userLimiters connection.PluginLimiterMap
 	// map of plugin configured rate limiter maps  (keyed by plugin instance)
 	// NOTE: if this is nil, that means the steampipe_rate_limiter tables has not been populated yet -
@@ -74,20 +77,28 @@ type PluginManager struct {
 	// map of plugin configs (keyed by plugin instance)
 	plugins connection.PluginMap
 
-	pool *pgxpool.Pool
+	pool    *pgxpool.Pool
+	poolMut sync.RWMutex
+
+	// the context used for connection refreshes - this is cancelled by InterruptRefresh
+	refreshCtx    context.Context
+	cancelRefresh context.CancelFunc
+	// in-flight connection refreshes
+	refreshWg sync.WaitGroup
 }
 
-func NewPluginManager(ctx context.Context, connectionConfig map[string]*sdkproto.ConnectionConfig, pluginConfigs connection.PluginMap, logger hclog.Logger) (*PluginManager, error) {
+func NewPluginManager(ctx context.Context, connectionConfig map[string]*sdkproto.ConnectionConfig, pluginConfigs connection.PluginMap, connectionLimiters connection.PluginLimiterMap, logger hclog.Logger) (*PluginManager, error) {
 	log.Printf("[INFO] NewPluginManager")
 	pluginManager := &PluginManager{
 		logger:              logger,
 		runningPluginMap:    make(map[string]*runningPlugin),
 		connectionConfigMap: connectionConfig,
-		userLimiters:        pluginConfigs.ToPluginLimiterMap(),
+		userLimiters:        pluginConfigs.ToPluginLimiterMap().Merge(connectionLimiters),
 		plugins:             pluginConfigs,
 	}
 
 	pluginManager.messageServer = &PluginMessageServer{pluginManager: pluginManager}
+	pluginManager.refreshCtx, pluginManager.cancelRefresh = context.WithCancel(context.Background())
 
 	// populate plugin connection config map
 	pluginManager.populatePluginConnectionConfigs()
@@ -95,9 +106,7 @@ func NewPluginManager(ctx context.Context, connectionConfig map[string]*sdkproto
 	pluginManager.setPluginCacheSizeMap()
 
 	// create a connection pool to connection refresh
-	// in testing, a size of 20 seemed optimal
-	poolsize := 20
-	pool, err := db_local.CreateConnectionPool(ctx, &db_local.CreateDbOptions{Username: constants.DatabaseSuperUser}, poolsize)
+	pool, err := createPluginManagerPool(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -194,30 +203,115 @@ func (m *PluginManager) buildRequiredPluginMap(req *pb.GetRequest) (map[string][
 }
 
 func (m *PluginManager) Pool() *pgxpool.Pool {
+	m.poolMut.RLock()
+	defer m.poolMut.RUnlock()
 	return m.pool
 }
 
-func (m *PluginManager) RefreshConnections(*pb.RefreshConnectionsRequest) (*pb.RefreshConnectionsResponse, error) {
+// RecreatePool closes the connection pool and creates a new one
+// this is used to recover if the database drops all connections (e.g. if it is restarted)
+// NOTE: the old pool is closed after releasing poolMut - Close waits for acquired connections to be released,
+// and the holders of those connections may need poolMut to do so
+func (m *PluginManager) RecreatePool(ctx context.Context) (*pgxpool.Pool, error) {
+	pool, err := createPluginManagerPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.poolMut.Lock()
+	oldPool := m.pool
+	m.pool = pool
+	m.poolMut.Unlock()
+
+	oldPool.Close()
+	return pool, nil
+}
+
+func createPluginManagerPool(ctx context.Context) (*pgxpool.Pool, error) {
+	// in testing, a size of 20 seemed optimal
+	poolsize := 20
+	// the pool is used to refresh connections - use the refresh application name so the refresh sessions
+	// can be identified in pg_stat_activity (each refresh sets the application name of the sessions it uses
+	// to include its refresh ID)
+	opts := &db_local.CreateDbOptions{
+		Username:        constants.DatabaseSuperUser,
+		ApplicationName: db_local.RefreshConnectionAppName(""),
+	}
+	return db_local.CreateConnectionPool(ctx, opts, poolsize)
+}
+
+func (m *PluginManager) RefreshConnections(req *pb.RefreshConnectionsRequest) (*pb.RefreshConnectionsResponse, error) {
 	log.Printf("[INFO] PluginManager RefreshConnections")
 
 	resp := &pb.RefreshConnectionsResponse{}
 
 	log.Printf("[INFO] calling RefreshConnections asyncronously")
 
-	go m.doRefresh()
+	go m.doRefresh(req)
 	return resp, nil
 }
 
-func (m *PluginManager) doRefresh() {
-	refreshResult := connection.RefreshConnections(context.Background(), m)
+// doRefresh refreshes connections - if the request has plugins, all connections for these plugins are force updated
+// the one-shot options in the request only apply to this refresh
+func (m *PluginManager) doRefresh(req *pb.RefreshConnectionsRequest) {
+	ctx, done := m.StartRefresh()
+	defer done()
+
+	opts, warnings := connection.LoadRefreshOptions(ctx)
+	if len(warnings) > 0 {
+		m.SendPostgresErrorsAndWarningsNotification(ctx, error_helpers.NewErrorsAndWarning(nil, warnings...))
+	}
+	opts = opts.WithRequestOptions(connection.RefreshRequestOptions{
+		NoRefresh:      req.GetNoRefresh(),
+		ForceUpdateAll: req.GetForceUpdateAll(),
+		PruneSchemas:   req.GetPruneSchemas(),
+		DryRun:         req.GetDryRun(),
+	})
+
+	plugins := req.GetPlugins()
+
+	var refreshResult *steampipeconfig.RefreshConnectionResult
+	if len(plugins) > 0 {
+		refreshResult = connection.RefreshConnectionsForPlugins(ctx, m, opts, plugins...)
+	} else {
+		refreshResult = connection.RefreshConnections(ctx, m, opts)
+	}
 	if refreshResult.Error != nil {
 		// NOTE: the RefreshConnectionState will already have sent a notification to the CLI
 		log.Printf("[WARN] RefreshConnections failed with error: %s", refreshResult.Error.Error())
 	}
 }
 
+// StartRefresh registers an in-flight connection refresh, returning the context the refresh must use
+// (this is cancelled by InterruptRefresh) and a function which must be called when the refresh is complete
+func (m *PluginManager) StartRefresh() (context.Context, func()) {
+	m.refreshWg.Add(1)
+	return m.refreshCtx, m.refreshWg.Done
+}
+
+// the time allowed for an interrupted refresh to update the connection state table during shutdown
+const refreshInterruptTimeout = 15 * time.Second
+
+// InterruptRefresh cancels any in-flight connection refresh and waits (up to the given timeout) for it to complete
+// an interrupted refresh sets the state of any connections it has not updated to error,
+// so connections are not left in the 'updating' state
+func (m *PluginManager) InterruptRefresh(timeout time.Duration) {
+	m.cancelRefresh()
+
+	done := make(chan struct{})
+	go func() {
+		m.refreshWg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("[WARN] timed out waiting for interrupted connection refresh to complete")
+	}
+}
+
 // OnConnectionConfigChanged is the callback function invoked by the connection watcher when the config changed
-func (m *PluginManager) OnConnectionConfigChanged(ctx context.Context, configMap connection.ConnectionConfigMap, plugins map[string]*modconfig.Plugin) {
+func (m *PluginManager) OnConnectionConfigChanged(ctx context.Context, configMap connection.ConnectionConfigMap, plugins map[string]*modconfig.Plugin, connectionLimiters connection.PluginLimiterMap) {
 	m.mut.Lock()
 	defer m.mut.Unlock()
 
@@ -232,7 +326,7 @@ func (m *PluginManager) OnConnectionConfigChanged(ctx context.Context, configMap
 		log.Printf("[WARN] handlePluginInstanceChanges failed: %s", err.Error())
 	}
 
-	if err := m.handleUserLimiterChanges(ctx, plugins); err != nil {
+	if err := m.handleUserLimiterChanges(ctx, plugins, connectionLimiters); err != nil {
 		log.Printf("[WARN] handleUserLimiterChanges failed: %s", err.Error())
 	}
 }
@@ -241,18 +335,31 @@ func (m *PluginManager) GetConnectionConfig() connection.ConnectionConfigMap {
 	return m.connectionConfigMap
 }
 
+// Shutdown interrupts any in-flight refresh, closes the pool and kills all running plugins
+// it is safe to call more than once - subsequent calls wait for the first to complete and then return
 func (m *PluginManager) Shutdown(*pb.ShutdownRequest) (resp *pb.ShutdownResponse, err error) {
+	m.shutdownOnce.Do(func() {
+		err = m.shutdown()
+	})
+	return &pb.ShutdownResponse{}, err
+}
+
+func (m *PluginManager) shutdown() (err error) {
 	log.Printf("[INFO] PluginManager Shutdown")
 	defer log.Printf("[INFO] PluginManager Shutdown complete")
 
-	// lock shutdownMut before waiting for startPluginWg
+	// set the shutdown flag before waiting for startPluginWg
 	// this enables us to exit from ensurePlugin early if needed
-	m.shutdownMut.Lock()
+	m.shutdownFlag.Store(true)
 	m.startPluginWg.Wait()
 
+	// interrupt any in-flight refresh before closing the pool it is using,
+	// so it can set the state of the connections it has not updated
+	m.InterruptRefresh(refreshInterruptTimeout)
+
 	// close our pool
 	log.Printf("[INFO] PluginManager closing pool")
-	m.pool.Close()
+	m.Pool().Close()
 
 	m.mut.RLock()
 	defer func() {
@@ -268,7 +375,7 @@ func (m *PluginManager) Shutdown(*pb.ShutdownRequest) (resp *pb.ShutdownResponse
 		m.killPlugin(p)
 	}
 
-	return &pb.ShutdownResponse{}, nil
+	return nil
 }
 
 func (m *PluginManager) killPlugin(p *runningPlugin) {
@@ -608,11 +715,7 @@ func (m *PluginManager) initializePlugin(connectionConfigs []*sdkproto.Connectio
 
 // return whether the plugin manager is shutting down
 func (m *PluginManager) shuttingDown() bool {
-	if !m.shutdownMut.TryLock() {
-		return true
-	}
-	m.shutdownMut.Unlock()
-	return false
+	return m.shutdownFlag.Load()
 }
 
 // populate map of connection configs for each plugin instance
@@ -769,7 +872,9 @@ func (m *PluginManager) setRateLimiters(pluginInstance string, pluginClient *sdk
 func (m *PluginManager) updateConnectionSchema(ctx context.Context, connectionName string) {
 	log.Printf("[INFO] updateConnectionSchema connection %s", connectionName)
 
-	refreshResult := connection.RefreshConnections(ctx, m, connectionName)
+	// (any invalid refresh options are logged when they are loaded)
+	opts, _ := connection.LoadRefreshOptions(ctx)
+	refreshResult := connection.RefreshConnections(ctx, m, opts, connectionName)
 	if refreshResult.Error != nil {
 		log.Printf("[TRACE] error refreshing connections: %s", refreshResult.Error)
 		return
@@ -777,8 +882,9 @@ func (m *PluginManager) updateConnectionSchema(ctx context.Context, connectionNa
 
 	// also send a postgres notification
 	notification := steampipeconfig.NewSchemaUpdateNotification()
+	notification.SetConnections([]string{connectionName}, nil)
 
-	conn, err := m.pool.Acquire(ctx)
+	conn, err := m.Pool().Acquire(ctx)
 	if err != nil {
 		log.Printf("[WARN] failed to send schema update notification: %s", err)
 	}
@@ -2,29 +2,47 @@ package pluginmanager_service
 
 import (
 	"context"
+	"github.com/turbot/steampipe/pkg/connection"
 	"github.com/turbot/steampipe/pkg/db/db_local"
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/steampipeconfig"
 	"log"
 )
 
-func (m *PluginManager) SendPostgresSchemaNotification(ctx context.Context) error {
+// SendPostgresSchemaNotification sends a schema update notification, listing the updated and deleted connections
+func (m *PluginManager) SendPostgresSchemaNotification(ctx context.Context, updatedConnections, deletedConnections []string) error {
 	log.Println("[DEBUG] refreshConnectionState.sendPostgreSchemaNotification start")
 	defer log.Println("[DEBUG] refreshConnectionState.sendPostgreSchemaNotification end")
 
-	return m.sendPostgresNotification(ctx, steampipeconfig.NewSchemaUpdateNotification())
+	notification := steampipeconfig.NewSchemaUpdateNotification()
+	notification.RefreshID = connection.RefreshIDFromContext(ctx)
+	notification.SetConnections(updatedConnections, deletedConnections)
+	return m.sendPostgresNotification(ctx, notification)
 
 }
 
 func (m *PluginManager) SendPostgresErrorsAndWarningsNotification(ctx context.Context, errorAndWarnings *error_helpers.ErrorAndWarnings) {
-	if err := m.sendPostgresNotification(ctx, steampipeconfig.NewErrorsAndWarningsNotification(errorAndWarnings)); err != nil {
+	notification := steampipeconfig.NewErrorsAndWarningsNotification(errorAndWarnings)
+	notification.RefreshID = connection.RefreshIDFromContext(ctx)
+	if err := m.sendPostgresNotification(ctx, notification); err != nil {
 
 		log.Printf("[WARN] failed to send error notification, error")
 	}
 
 }
+
+// SendPostgresRefreshProgressNotification sends the status of the refresh, so it may be displayed by clients
+// waiting for the refresh
+func (m *PluginManager) SendPostgresRefreshProgressNotification(ctx context.Context, status string) {
+	notification := steampipeconfig.NewRefreshProgressNotification(status)
+	notification.RefreshID = connection.RefreshIDFromContext(ctx)
+	if err := m.sendPostgresNotification(ctx, notification); err != nil {
+		log.Printf("[WARN] failed to send refresh progress notification: %s", err.Error())
+	}
+}
+
 func (m *PluginManager) sendPostgresNotification(ctx context.Context, notification any) error {
-	conn, err := m.pool.Acquire(ctx)
+	conn, err := m.Pool().Acquire(ctx)
 	if err != nil {
 		return err
 	}
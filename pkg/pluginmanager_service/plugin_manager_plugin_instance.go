@@ -27,7 +27,7 @@ func (m *PluginManager) handlePluginInstanceChanges(ctx context.Context, newPlug
 	m.plugins = newPlugins
 
 	// repopulate the plugin table
-	conn, err := m.pool.Acquire(ctx)
+	conn, err := m.Pool().Acquire(ctx)
 	if err != nil {
 		return err
 	}
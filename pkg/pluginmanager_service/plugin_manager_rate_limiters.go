@@ -70,7 +70,7 @@ func (m *PluginManager) refreshRateLimiterTable(ctx context.Context) error {
 		}
 	}
 
-	conn, err := m.pool.Acquire(ctx)
+	conn, err := m.Pool().Acquire(ctx)
 	if err != nil {
 		return err
 	}
@@ -80,11 +80,11 @@ func (m *PluginManager) refreshRateLimiterTable(ctx context.Context) error {
 	return err
 }
 
-// respond to changes in the HCL rate limiter config
+// respond to changes in the HCL rate limiter config (including the max_concurrency and rate_limit of connections)
 // update the stored limiters, refresh the rate limiter table and call `setRateLimiters`
 // for all plugins with changed limiters
-func (m *PluginManager) handleUserLimiterChanges(_ context.Context, plugins connection.PluginMap) error {
-	limiterPluginMap := plugins.ToPluginLimiterMap()
+func (m *PluginManager) handleUserLimiterChanges(_ context.Context, plugins connection.PluginMap, connectionLimiters connection.PluginLimiterMap) error {
+	limiterPluginMap := plugins.ToPluginLimiterMap().Merge(connectionLimiters)
 	pluginsWithChangedLimiters := m.getPluginsWithChangedLimiters(limiterPluginMap)
 
 	if len(pluginsWithChangedLimiters) == 0 {
@@ -189,7 +189,7 @@ func (m *PluginManager) rateLimiterTableExists(ctx context.Context) (bool, error
         tablename  = '%s'
     );`, constants.InternalSchema, constants.RateLimiterDefinitionTable)
 
-	row := m.pool.QueryRow(ctx, query)
+	row := m.Pool().QueryRow(ctx, query)
 	var exists bool
 	err := row.Scan(&exists)
 
@@ -246,7 +246,7 @@ func (m *PluginManager) bootstrapRateLimiterTable(ctx context.Context) error {
 }
 
 func (m *PluginManager) loadRateLimitersFromTable(ctx context.Context) ([]*modconfig.RateLimiter, error) {
-	rows, err := m.pool.Query(ctx, fmt.Sprintf("SELECT * FROM %s.%s", constants.InternalSchema, constants.RateLimiterDefinitionTable))
+	rows, err := m.Pool().Query(ctx, fmt.Sprintf("SELECT * FROM %s.%s", constants.InternalSchema, constants.RateLimiterDefinitionTable))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,55 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+	"github.com/turbot/steampipe/pkg/display"
+	"github.com/turbot/steampipe/pkg/export"
+)
+
+// ColumnarExporter exports the result of a query as parquet, or as an arrow IPC file
+// NOTE: the result is read from the snapshot of the query, so it is held in memory while it is exported
+type ColumnarExporter struct {
+	export.ExporterBase
+	format    string
+	extension string
+}
+
+func NewParquetExporter() *ColumnarExporter {
+	return &ColumnarExporter{format: constants.OutputFormatParquet, extension: constants.ParquetExtension}
+}
+
+func NewArrowExporter() *ColumnarExporter {
+	return &ColumnarExporter{format: constants.OutputFormatArrow, extension: constants.ArrowExtension}
+}
+
+func (e *ColumnarExporter) Export(_ context.Context, input export.ExportSourceData, filePath string) error {
+	snapshot, ok := input.(*dashboardtypes.SteampipeSnapshot)
+	if !ok {
+		return fmt.Errorf("ColumnarExporter input must be *dashboardtypes.SteampipeSnapshot")
+	}
+	result, err := SnapshotToQueryResult(snapshot)
+	if err != nil {
+		return err
+	}
+
+	destination, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+
+	return display.WriteColumnarFile(destination, result, e.format)
+}
+
+func (e *ColumnarExporter) FileExtension() string {
+	return e.extension
+}
+
+func (e *ColumnarExporter) Name() string {
+	return e.format
+}
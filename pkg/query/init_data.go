@@ -54,7 +54,7 @@ func NewInitData(ctx context.Context, args []string) *InitData {
 }
 
 func queryExporters() []export.Exporter {
-	return []export.Exporter{&export.SnapshotExporter{}}
+	return []export.Exporter{&export.SnapshotExporter{}, NewParquetExporter(), NewArrowExporter()}
 }
 
 func (i *InitData) Cancel() {
@@ -57,16 +57,27 @@ func RunBatchSession(ctx context.Context, initData *query.InitData) (int, error)
 		}
 	}
 
+	// if '--watch=<interval>' is set, the queries are re-run at the interval
+	interval, err := WatchInterval()
+	if err != nil {
+		return 0, err
+	}
+
 	failures := 0
 	if len(initData.Queries) > 0 {
 		// if we have resolved any queries, run them
-		failures = executeQueries(ctx, initData)
+		if interval > 0 {
+			failures = watchQueries(ctx, initData, interval)
+		} else {
+			failures = executeQueries(ctx, initData, nil)
+		}
 	}
 	// return the number of query failures and the number of rows that returned errors
 	return failures, nil
 }
 
-func executeQueries(ctx context.Context, initData *query.InitData) int {
+// executeQueries runs the queries - watcher is nil unless the queries are re-run using '--watch=<interval>'
+func executeQueries(ctx context.Context, initData *query.InitData, watcher *queryWatcher) int {
 	utils.LogTime("queryexecute.executeQueries start")
 	defer utils.LogTime("queryexecute.executeQueries end")
 
@@ -82,7 +93,7 @@ func executeQueries(ctx context.Context, initData *query.InitData) int {
 	for i, name := range queryNames {
 		q := initData.Queries[name]
 		// if executeQuery fails it returns err, else it returns the number of rows that returned errors while execution
-		if err, failures = executeQuery(ctx, initData.Client, q); err != nil {
+		if err, failures = executeQuery(ctx, initData.Client, q, watcher.displayOptions(name)...); err != nil {
 			failures++
 			error_helpers.ShowWarning(fmt.Sprintf("executeQueries: query %d of %d failed: %v", i+1, len(queryNames), error_helpers.DecodePgError(err)))
 			// if timing flag is enabled, show the time taken for the query to fail
@@ -100,12 +111,21 @@ func executeQueries(ctx context.Context, initData *query.InitData) int {
 	return failures
 }
 
-func executeQuery(ctx context.Context, client db_common.Client, resolvedQuery *modconfig.ResolvedQuery) (error, int) {
+func executeQuery(ctx context.Context, client db_common.Client, resolvedQuery *modconfig.ResolvedQuery, displayOpts ...display.DisplayOption) (error, int) {
 	utils.LogTime("query.execute.executeQuery start")
 	defer utils.LogTime("query.execute.executeQuery end")
 
+	// if the results are paged, only the rows of the page are displayed
+	page, err := newResultPage(resolvedQuery)
+	if err != nil {
+		return err, 0
+	}
+	// the query is cancelled once all the rows of the page have been read
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// the db executor sends result data over resultsStreamer
-	resultsStreamer, err := db_common.ExecuteQuery(ctx, client, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
+	resultsStreamer, err := db_common.ExecuteQuery(queryCtx, client, resolvedQuery.ExecuteSQL, resolvedQuery.Args...)
 	if err != nil {
 		return err, 0
 	}
@@ -113,10 +133,16 @@ func executeQuery(ctx context.Context, client db_common.Client, resolvedQuery *m
 	rowErrors := 0 // get the number of rows that returned an error
 	// print the data as it comes
 	for r := range resultsStreamer.Results {
-		rowErrors = display.ShowOutput(ctx, r)
+		if page != nil {
+			r = page.apply(r, cancel)
+		}
+		rowErrors = display.ShowOutput(ctx, r, displayOpts...)
 		// signal to the resultStreamer that we are done with this result
 		resultsStreamer.AllResultsRead()
 	}
+	if page != nil {
+		page.showNextCursor()
+	}
 	return nil, rowErrors
 }
 
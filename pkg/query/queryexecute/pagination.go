@@ -0,0 +1,170 @@
+package queryexecute
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+// QueryCursor identifies the position in the results of a query at which the next page of results starts
+//
+// the cursor is resumed by running the query again and skipping the rows before the offset - the rows
+// are only returned in the same order if the query has an 'order by' clause
+type QueryCursor struct {
+	// a hash of the query, used to check the cursor is used with the same query
+	Query  string `json:"q"`
+	Offset int    `json:"o"`
+}
+
+// ParseQueryCursor decodes a cursor token
+func ParseQueryCursor(token string) (*QueryCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, sperr.New("invalid cursor '%s'", token)
+	}
+	cursor := &QueryCursor{}
+	if err := json.Unmarshal(b, cursor); err != nil || cursor.Query == "" || cursor.Offset < 0 {
+		return nil, sperr.New("invalid cursor '%s'", token)
+	}
+	return cursor, nil
+}
+
+// Token encodes the cursor as a URL safe string
+func (c *QueryCursor) Token() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// queryCursorHash returns the hash identifying a query in a cursor
+func queryCursorHash(resolvedQuery *modconfig.ResolvedQuery) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%v", resolvedQuery.ExecuteSQL, resolvedQuery.Args)))
+	return hex.EncodeToString(hash[:8])
+}
+
+// resultPage selects a page of the rows of a query result - rows before the offset are skipped and, once the
+// limit is reached, the query is cancelled so the remaining rows are never fetched
+type resultPage struct {
+	query  string
+	offset int
+	// the maximum number of rows to return (zero for no limit)
+	limit int
+	// whether the next page should be identified with a cursor
+	paged bool
+	// set if there are more rows after the page
+	more bool
+	done chan struct{}
+}
+
+// newResultPage returns the page of results to display for a query, based on the --page-size, --max-rows
+// and --cursor args - nil is returned if the results are not paged
+func newResultPage(resolvedQuery *modconfig.ResolvedQuery) (*resultPage, error) {
+	pageSize := viper.GetInt(constants.ArgPageSize)
+	maxRows := viper.GetInt(constants.ArgMaxRows)
+	token := viper.GetString(constants.ArgCursor)
+	if pageSize == 0 && maxRows == 0 && token == "" {
+		return nil, nil
+	}
+
+	page := &resultPage{
+		query: queryCursorHash(resolvedQuery),
+		limit: pageSize,
+		paged: pageSize > 0,
+		done:  make(chan struct{}),
+	}
+	if token != "" {
+		cursor, err := ParseQueryCursor(token)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Query != page.query {
+			return nil, sperr.New("the cursor was not created for this query")
+		}
+		page.offset = cursor.Offset
+	}
+	// --max-rows limits the total number of rows returned for the query, across all pages
+	if maxRows > 0 {
+		remaining := max(maxRows-page.offset, 0)
+		if page.limit == 0 || remaining <= page.limit {
+			// there is no page after this one
+			page.limit = remaining
+			page.paged = false
+		}
+		if remaining == 0 {
+			// there are no rows left to return - the result must still be read, but no rows are displayed
+			page.limit = -1
+		}
+	}
+	return page, nil
+}
+
+// apply returns a result which streams the page of rows of the source result
+// cancel is called to cancel the query once all rows of the page have been read
+func (p *resultPage) apply(source *queryresult.Result, cancel context.CancelFunc) *queryresult.Result {
+	rowChan := make(chan *queryresult.RowResult)
+	res := &queryresult.Result{
+		RowChan:      &rowChan,
+		Cols:         source.Cols,
+		TimingResult: source.TimingResult,
+	}
+
+	go func() {
+		defer close(p.done)
+		defer res.Close()
+
+		index, returned := 0, 0
+		cancelled := false
+		for row := range *source.RowChan {
+			// once the query is cancelled, wait for the source to close - ignoring the cancellation error
+			if cancelled {
+				continue
+			}
+			if row.Error != nil {
+				rowChan <- row
+				continue
+			}
+			if index < p.offset {
+				index++
+				continue
+			}
+			index++
+			if p.limit != 0 && returned >= p.limit {
+				// there is at least one more row - we have reached the end of the page
+				p.more = true
+				cancelled = true
+				cancel()
+				continue
+			}
+			rowChan <- row
+			returned++
+		}
+	}()
+	return res
+}
+
+// nextCursor returns the cursor for the page after this one - nil if this is the last page
+// this must be called after all rows of the page have been read
+func (p *resultPage) nextCursor() *QueryCursor {
+	<-p.done
+	if !p.paged || !p.more {
+		return nil
+	}
+	return &QueryCursor{Query: p.query, Offset: p.offset + p.limit}
+}
+
+// showNextCursor displays the cursor used to fetch the next page of results (if any) on stderr,
+// so it is not mixed with the query output
+func (p *resultPage) showNextCursor() {
+	if cursor := p.nextCursor(); cursor != nil {
+		fmt.Fprintf(os.Stderr, "Next page cursor: %s\n", cursor.Token())
+	}
+}
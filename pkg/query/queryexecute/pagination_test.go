@@ -0,0 +1,143 @@
+package queryexecute
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func TestQueryCursor(t *testing.T) {
+	cursor := &QueryCursor{Query: "0123456789abcdef", Offset: 100}
+	parsed, err := ParseQueryCursor(cursor.Token())
+	if err != nil {
+		t.Fatalf("Test: 'round trip' FAILED : unexpected error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(parsed, cursor) {
+		t.Errorf("Test: 'round trip' FAILED : expected %v, got %v", cursor, parsed)
+	}
+
+	for _, token := range []string{"not a cursor!", "e30", (&QueryCursor{Query: "q", Offset: -1}).Token()} {
+		if _, err := ParseQueryCursor(token); err == nil {
+			t.Errorf("Test: 'invalid cursor %s' FAILED : expected an error", token)
+		}
+	}
+}
+
+type resultPageTest struct {
+	pageSize int
+	maxRows  int
+	offset   int
+	// the number of rows returned by the query
+	rows int
+	// expected
+	returned   []int
+	nextOffset int
+	cancelled  bool
+}
+
+var testCasesResultPage = map[string]resultPageTest{
+	"first page": {
+		pageSize: 2, rows: 5,
+		returned: []int{0, 1}, nextOffset: 2, cancelled: true,
+	},
+	"middle page": {
+		pageSize: 2, offset: 2, rows: 5,
+		returned: []int{2, 3}, nextOffset: 4, cancelled: true,
+	},
+	"last page": {
+		pageSize: 2, offset: 4, rows: 5,
+		returned: []int{4}, nextOffset: -1,
+	},
+	"full last page": {
+		pageSize: 2, offset: 2, rows: 4,
+		returned: []int{2, 3}, nextOffset: -1,
+	},
+	"max rows": {
+		maxRows: 3, rows: 5,
+		returned: []int{0, 1, 2}, nextOffset: -1, cancelled: true,
+	},
+	"max rows limits the last page": {
+		pageSize: 2, maxRows: 3, offset: 2, rows: 5,
+		returned: []int{2}, nextOffset: -1, cancelled: true,
+	},
+	"max rows reached": {
+		pageSize: 2, maxRows: 2, offset: 2, rows: 5,
+		returned: nil, nextOffset: -1, cancelled: true,
+	},
+	"cursor without page size": {
+		offset: 3, rows: 5,
+		returned: []int{3, 4}, nextOffset: -1,
+	},
+}
+
+func TestResultPage(t *testing.T) {
+	defer viper.Reset()
+	resolvedQuery := &modconfig.ResolvedQuery{ExecuteSQL: "select id from t order by id"}
+
+	for name, test := range testCasesResultPage {
+		viper.Set(constants.ArgPageSize, test.pageSize)
+		viper.Set(constants.ArgMaxRows, test.maxRows)
+		viper.Set(constants.ArgCursor, "")
+		if test.offset > 0 {
+			viper.Set(constants.ArgCursor, (&QueryCursor{Query: queryCursorHash(resolvedQuery), Offset: test.offset}).Token())
+		}
+
+		page, err := newResultPage(resolvedQuery)
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err.Error())
+			continue
+		}
+
+		source := queryresult.NewResult([]*queryresult.ColumnDef{{Name: "id", DataType: "INT8"}})
+		cancelled := make(chan struct{})
+		go func() {
+			for i := 0; i < test.rows; i++ {
+				select {
+				case <-cancelled:
+				default:
+					source.StreamRow([]interface{}{i})
+				}
+			}
+			source.Close()
+		}()
+
+		var returned []int
+		for row := range *page.apply(source, func() { close(cancelled) }).RowChan {
+			returned = append(returned, row.Data[0].(int))
+		}
+		if !reflect.DeepEqual(returned, test.returned) {
+			t.Errorf("Test: '%s' FAILED : expected rows %v, got %v", name, test.returned, returned)
+		}
+
+		nextOffset := -1
+		if cursor := page.nextCursor(); cursor != nil {
+			nextOffset = cursor.Offset
+		}
+		if nextOffset != test.nextOffset {
+			t.Errorf("Test: '%s' FAILED : expected next offset %d, got %d", name, test.nextOffset, nextOffset)
+		}
+		select {
+		case <-cancelled:
+			if !test.cancelled {
+				t.Errorf("Test: '%s' FAILED : the query should not be cancelled", name)
+			}
+		default:
+			if test.cancelled {
+				t.Errorf("Test: '%s' FAILED : expected the query to be cancelled", name)
+			}
+		}
+	}
+}
+
+func TestResultPageWrongQuery(t *testing.T) {
+	defer viper.Reset()
+	viper.Set(constants.ArgCursor, (&QueryCursor{Query: queryCursorHash(&modconfig.ResolvedQuery{ExecuteSQL: "select 1"}), Offset: 2}).Token())
+
+	if _, err := newResultPage(&modconfig.ResolvedQuery{ExecuteSQL: "select 2"}); err == nil {
+		t.Errorf("Test: 'wrong query' FAILED : expected an error")
+	}
+}
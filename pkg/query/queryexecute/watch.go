@@ -0,0 +1,154 @@
+package queryexecute
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/contexthelpers"
+	"github.com/turbot/steampipe/pkg/display"
+	"github.com/turbot/steampipe/pkg/query"
+)
+
+// the minimum interval queries may be re-run at
+const minWatchInterval = time.Second
+
+// ansi escape sequence to move the cursor to the top left of the terminal and clear it
+const clearTerminal = "\033[H\033[2J"
+
+// WatchInterval returns the interval to re-run batch queries at, set using '--watch=<interval>', e.g. '--watch=30s'
+// zero is returned if the queries are not re-run - '--watch' may also be a boolean, which sets whether the
+// files of the workspace are watched in interactive mode
+func WatchInterval() (time.Duration, error) {
+	value := viper.GetString(constants.ArgWatch)
+	if _, err := strconv.ParseBool(value); err == nil || value == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < minWatchInterval {
+		return 0, sperr.New("invalid --%s '%s': expected true, false or an interval of at least %s, e.g. '30s'", constants.ArgWatch, value, minWatchInterval)
+	}
+	return interval, nil
+}
+
+// WatchIntervalArg supports setting the watch interval as the value following '--watch', e.g. '--watch 30s'
+// a bare '--watch' is 'true', so the interval is parsed as a query argument (flags and args may be interspersed,
+// so this may be any argument) - if '--watch' was set to 'true', the first argument which is a duration is used
+// as the watch interval and removed from the args
+func WatchIntervalArg(watchChanged bool, args []string) []string {
+	if !watchChanged || viper.GetString(constants.ArgWatch) != "true" {
+		return args
+	}
+	for i, arg := range args {
+		if _, err := time.ParseDuration(arg); err == nil {
+			viper.Set(constants.ArgWatch, arg)
+			return append(args[:i:i], args[i+1:]...)
+		}
+	}
+	return args
+}
+
+// queryWatcher re-runs batch queries at an interval
+type queryWatcher struct {
+	interval time.Duration
+	// whether to clear the terminal before each run, so the results are re-rendered in place
+	inPlace bool
+	// the rows returned by each query, keyed by query name - nil if changed rows are not highlighted
+	changedRows map[string]*changedRows
+}
+
+func newQueryWatcher(interval time.Duration) *queryWatcher {
+	w := &queryWatcher{
+		interval: interval,
+		inPlace:  viper.GetString(constants.ArgOutput) == constants.OutputFormatTable && viper.GetBool(constants.ConfigKeyIsTerminalTTY),
+	}
+	if viper.GetBool(constants.ArgWatchHighlight) {
+		w.changedRows = make(map[string]*changedRows)
+	}
+	return w
+}
+
+// watchQueries runs the queries, then re-runs them once the interval has elapsed after each run, until cancelled
+// the number of failures of the last completed run is returned
+func watchQueries(ctx context.Context, initData *query.InitData, interval time.Duration) int {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	contexthelpers.StartCancelHandler(cancel)
+
+	w := newQueryWatcher(interval)
+	failures := 0
+	for {
+		if w.inPlace {
+			fmt.Print(clearTerminal)
+			fmt.Printf("Every %s: %s\n\n", w.interval, time.Now().Format(time.DateTime))
+		}
+		runFailures := executeQueries(ctx, initData, w)
+		if ctx.Err() != nil {
+			// the run was interrupted
+			return failures
+		}
+		failures = runFailures
+		w.nextRun()
+
+		select {
+		case <-ctx.Done():
+			return failures
+		case <-time.After(w.interval):
+		}
+		if !w.inPlace {
+			fmt.Println()
+		}
+	}
+}
+
+// displayOptions returns the options used to display the results of a query
+func (w *queryWatcher) displayOptions(queryName string) []display.DisplayOption {
+	if w == nil || w.changedRows == nil {
+		return nil
+	}
+	rows, ok := w.changedRows[queryName]
+	if !ok {
+		rows = newChangedRows()
+		w.changedRows[queryName] = rows
+	}
+	return []display.DisplayOption{display.WithRowHighlighter(rows.isChanged)}
+}
+
+func (w *queryWatcher) nextRun() {
+	for _, rows := range w.changedRows {
+		rows.nextRun()
+	}
+}
+
+// changedRows tracks the rows returned by each run of a query, to determine which rows have changed
+// since the previous run - a changed row is one which was not returned by the previous run
+type changedRows struct {
+	// the rows returned by the previous run - nil before the first run has completed
+	previous map[string]struct{}
+	current  map[string]struct{}
+}
+
+func newChangedRows() *changedRows {
+	return &changedRows{current: make(map[string]struct{})}
+}
+
+// isChanged records a row returned by the current run, and returns whether it was not returned by the previous run
+func (c *changedRows) isChanged(row []string) bool {
+	key := strings.Join(row, "\x00")
+	c.current[key] = struct{}{}
+	if c.previous == nil {
+		return false
+	}
+	_, ok := c.previous[key]
+	return !ok
+}
+
+func (c *changedRows) nextRun() {
+	c.previous = c.current
+	c.current = make(map[string]struct{})
+}
@@ -0,0 +1,105 @@
+package queryexecute
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+)
+
+type watchIntervalTest struct {
+	watch    string
+	expected time.Duration
+	err      bool
+}
+
+var testCasesWatchInterval = map[string]watchIntervalTest{
+	"default":      {watch: "true"},
+	"disabled":     {watch: "false"},
+	"empty":        {watch: ""},
+	"interval":     {watch: "30s", expected: 30 * time.Second},
+	"minutes":      {watch: "1m30s", expected: 90 * time.Second},
+	"too short":    {watch: "500ms", err: true},
+	"not duration": {watch: "often", err: true},
+}
+
+func TestWatchInterval(t *testing.T) {
+	defer viper.Set(constants.ArgWatch, nil)
+	for name, test := range testCasesWatchInterval {
+		viper.Set(constants.ArgWatch, test.watch)
+		interval, err := WatchInterval()
+		if test.err {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err.Error())
+			continue
+		}
+		if interval != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %s, got %s", name, test.expected, interval)
+		}
+	}
+}
+
+type watchIntervalArgTest struct {
+	watchChanged bool
+	watch        string
+	args         []string
+	expectedArgs []string
+	expected     string
+}
+
+var testCasesWatchIntervalArg = map[string]watchIntervalArgTest{
+	"interval after watch":  {watchChanged: true, watch: "true", args: []string{"30s", "select 1"}, expectedArgs: []string{"select 1"}, expected: "30s"},
+	"interval after query":  {watchChanged: true, watch: "true", args: []string{"select 1", "1m", "select 2"}, expectedArgs: []string{"select 1", "select 2"}, expected: "1m"},
+	"query after watch":     {watchChanged: true, watch: "true", args: []string{"select 1"}, expectedArgs: []string{"select 1"}, expected: "true"},
+	"interval set with '='": {watchChanged: true, watch: "1m", args: []string{"select 1"}, expectedArgs: []string{"select 1"}, expected: "1m"},
+	"watch not set":         {watch: "true", args: []string{"30s"}, expectedArgs: []string{"30s"}, expected: "true"},
+	"watch disabled":        {watchChanged: true, watch: "false", args: []string{"30s"}, expectedArgs: []string{"30s"}, expected: "false"},
+	"no args":               {watchChanged: true, watch: "true", expected: "true"},
+}
+
+func TestWatchIntervalArg(t *testing.T) {
+	defer viper.Set(constants.ArgWatch, nil)
+	for name, test := range testCasesWatchIntervalArg {
+		viper.Set(constants.ArgWatch, test.watch)
+		args := WatchIntervalArg(test.watchChanged, test.args)
+		if !reflect.DeepEqual(args, test.expectedArgs) {
+			t.Errorf("Test: '%s' FAILED : expected args %v, got %v", name, test.expectedArgs, args)
+		}
+		if watch := viper.GetString(constants.ArgWatch); watch != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected --watch '%s', got '%s'", name, test.expected, watch)
+		}
+	}
+}
+
+func TestChangedRows(t *testing.T) {
+	runs := [][][]string{
+		{{"a", "1"}, {"b", "2"}},
+		{{"a", "1"}, {"b", "3"}, {"c", "4"}},
+		{{"a", "1"}, {"b", "3"}, {"c", "4"}},
+	}
+	expected := [][]bool{
+		// nothing is highlighted in the first run
+		{false, false},
+		{false, true, true},
+		{false, false, false},
+	}
+
+	rows := newChangedRows()
+	for i, run := range runs {
+		var actual []bool
+		for _, row := range run {
+			actual = append(actual, rows.isChanged(row))
+		}
+		if !reflect.DeepEqual(actual, expected[i]) {
+			t.Errorf("Test: 'run %d' FAILED : expected %v, got %v", i+1, expected[i], actual)
+		}
+		rows.nextRun()
+	}
+}
@@ -13,12 +13,12 @@ import (
 
 // QueryHistory :: struct for working with history in the interactive mode
 type QueryHistory struct {
-	history []string
+	history []*HistoryEntry
 }
 
 // New creates a new QueryHistory object
 func New() (*QueryHistory, error) {
-	history := &QueryHistory{history: []string{}}
+	history := &QueryHistory{history: []*HistoryEntry{}}
 	err := history.load()
 	if err != nil {
 		return nil, err
@@ -26,16 +26,19 @@ func New() (*QueryHistory, error) {
 	return history, nil
 }
 
-// Push adds a string to the history queue trimming to maxHistorySize if necessary
-func (q *QueryHistory) Push(query string) {
+// Push adds a query to the history queue trimming to maxHistorySize if necessary
+// returns the history entry for the query (nil if the query is blank)
+func (q *QueryHistory) Push(query string) *HistoryEntry {
 	if len(strings.TrimSpace(query)) == 0 {
 		// do not store a blank query
-		return
+		return nil
 	}
 
 	// do a strict compare to see if we have this same exact query as the most recent history item
-	if lastElement := q.Peek(); lastElement != nil && (*lastElement) == query {
-		return
+	// if so, just update the timestamp of the existing entry
+	if lastElement := q.Peek(); lastElement != nil && lastElement.Query == query {
+		lastElement.rerun()
+		return lastElement
 	}
 
 	// limit the history length to HistorySize
@@ -45,16 +48,18 @@ func (q *QueryHistory) Push(query string) {
 	}
 
 	// append the new entry
-	q.history = append(q.history, query)
+	entry := newHistoryEntry(query)
+	q.history = append(q.history, entry)
+	return entry
 }
 
 // Peek returns the last element of the history stack.
 // returns nil if there is no history
-func (q *QueryHistory) Peek() *string {
+func (q *QueryHistory) Peek() *HistoryEntry {
 	if len(q.history) == 0 {
 		return nil
 	}
-	return &q.history[len(q.history)-1]
+	return q.history[len(q.history)-1]
 }
 
 // Persist writes the history to the filesystem
@@ -78,11 +83,31 @@ func (q *QueryHistory) Persist() error {
 	return jsonEncoder.Encode(q.history)
 }
 
-// Get returns the full history
+// Get returns the text of the queries in the history
 func (q *QueryHistory) Get() []string {
+	res := make([]string, len(q.history))
+	for i, entry := range q.history {
+		res[i] = entry.Query
+	}
+	return res
+}
+
+// Entries returns the full history, oldest first
+func (q *QueryHistory) Entries() []*HistoryEntry {
 	return q.history
 }
 
+// Search returns the history entries whose query, name or tags contain the given term (case insensitive), oldest first
+func (q *QueryHistory) Search(term string) []*HistoryEntry {
+	var res []*HistoryEntry
+	for _, entry := range q.history {
+		if entry.matches(term) {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
 // loads up the history from the file where it is persisted
 func (q *QueryHistory) load() error {
 	path := filepath.Join(filepaths.EnsureInternalDir(), constants.HistoryFile)
@@ -97,8 +122,12 @@ func (q *QueryHistory) load() error {
 	}
 	defer file.Close()
 
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&q.history)
+	return q.decode(file)
+}
+
+func (q *QueryHistory) decode(r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	err := decoder.Decode(&q.history)
 	// ignore EOF (caused by empty file)
 	if err == io.EOF {
 		return nil
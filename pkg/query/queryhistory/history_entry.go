@@ -0,0 +1,86 @@
+package queryhistory
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// HistoryEntry is a query which has been entered in the interactive prompt
+type HistoryEntry struct {
+	Query string `json:"query"`
+	// an optional name given to the query with '.history name'
+	Name string   `json:"name,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+	// the time the query was last entered
+	Timestamp time.Time `json:"timestamp"`
+	// the duration and row count of the last execution - nil if the query has not been executed
+	// (e.g. for metaqueries, or queries which failed to start)
+	Duration *time.Duration `json:"duration,omitempty"`
+	RowCount *int64         `json:"row_count,omitempty"`
+}
+
+func newHistoryEntry(query string) *HistoryEntry {
+	return &HistoryEntry{
+		Query:     query,
+		Timestamp: time.Now(),
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+// older versions of steampipe persisted the history as an array of query strings - these are loaded
+// as entries with no timestamp
+func (e *HistoryEntry) UnmarshalJSON(data []byte) error {
+	var query string
+	if err := json.Unmarshal(data, &query); err == nil {
+		*e = HistoryEntry{Query: query}
+		return nil
+	}
+	// use an alias type to avoid recursing into this function
+	type entry HistoryEntry
+	return json.Unmarshal(data, (*entry)(e))
+}
+
+// SetResult records the duration and row count of an execution of the query
+func (e *HistoryEntry) SetResult(duration time.Duration, rowCount int64) {
+	e.Duration = &duration
+	e.RowCount = &rowCount
+}
+
+// AddTags adds the given tags to the entry, ignoring any the entry already has
+func (e *HistoryEntry) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if !e.hasTag(tag) {
+			e.Tags = append(e.Tags, tag)
+		}
+	}
+}
+
+// rerun updates the entry when the same query is entered again
+func (e *HistoryEntry) rerun() {
+	e.Timestamp = time.Now()
+	e.Duration = nil
+	e.RowCount = nil
+}
+
+func (e *HistoryEntry) hasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *HistoryEntry) matches(term string) bool {
+	term = strings.ToLower(term)
+	if strings.Contains(strings.ToLower(e.Query), term) || strings.Contains(strings.ToLower(e.Name), term) {
+		return true
+	}
+	for _, tag := range e.Tags {
+		if strings.Contains(strings.ToLower(tag), term) {
+			return true
+		}
+	}
+	return false
+}
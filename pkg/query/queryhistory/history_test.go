@@ -0,0 +1,106 @@
+package queryhistory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type decodeTest struct {
+	file     string
+	expected []string
+}
+
+var decodeTests = map[string]decodeTest{
+	"empty file": {
+		file:     "",
+		expected: []string{},
+	},
+	"legacy string array": {
+		file:     `["select 1","select 2"]`,
+		expected: []string{"select 1", "select 2"},
+	},
+	"entries": {
+		file:     `[{"query":"select 1","timestamp":"2024-01-01T00:00:00Z","duration":1000000,"row_count":1},{"query":"select 2","name":"two","tags":["a"],"timestamp":"2024-01-01T00:00:01Z"}]`,
+		expected: []string{"select 1", "select 2"},
+	},
+	"mixed": {
+		file:     `["select 1",{"query":"select 2","timestamp":"2024-01-01T00:00:01Z"}]`,
+		expected: []string{"select 1", "select 2"},
+	},
+}
+
+func TestDecodeHistory(t *testing.T) {
+	for name, test := range decodeTests {
+		q := &QueryHistory{history: []*HistoryEntry{}}
+		if err := q.decode(strings.NewReader(test.file)); err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error %v", name, err)
+			continue
+		}
+		if actual := q.Get(); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestPushRepeatedQuery(t *testing.T) {
+	q := &QueryHistory{history: []*HistoryEntry{}}
+	first := q.Push("select 1")
+	first.SetResult(0, 10)
+	if second := q.Push("select 1"); second != first {
+		t.Errorf("Test: 'push repeated query' FAILED : expected the existing entry to be returned")
+	}
+	if first.RowCount != nil {
+		t.Errorf("Test: 'push repeated query' FAILED : expected the result of the previous execution to be cleared")
+	}
+	if q.Push("  ") != nil || len(q.Get()) != 1 {
+		t.Errorf("Test: 'push blank query' FAILED : expected a blank query not to be stored")
+	}
+}
+
+type searchTest struct {
+	term     string
+	expected []string
+}
+
+var searchTests = map[string]searchTest{
+	"query text": {
+		term:     "S3_BUCKET",
+		expected: []string{"select * from aws_s3_bucket"},
+	},
+	"name": {
+		term:     "users",
+		expected: []string{"select * from aws_iam_user"},
+	},
+	"tag": {
+		term:     "audit",
+		expected: []string{"select * from aws_s3_bucket", "select * from aws_iam_user"},
+	},
+	"no match": {
+		term:     "gcp",
+		expected: []string{},
+	},
+}
+
+func TestSearchHistory(t *testing.T) {
+	q := &QueryHistory{history: []*HistoryEntry{}}
+	q.Push("select * from aws_s3_bucket").AddTags("audit")
+	user := q.Push("select * from aws_iam_user")
+	user.Name = "all_users"
+	user.AddTags("audit", "audit")
+	q.Push("select 1")
+
+	if len(user.Tags) != 1 {
+		t.Errorf("Test: 'duplicate tags' FAILED : expected 1 tag, got %v", user.Tags)
+	}
+
+	for name, test := range searchTests {
+		actual := []string{}
+		for _, entry := range q.Search(test.term) {
+			actual = append(actual, entry.Query)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
@@ -8,11 +8,18 @@ type TimingMetadata struct {
 	RowsFetched       int64
 	CachedRowsFetched int64
 	HydrateCalls      int64
+	// the number of scans of foreign tables, and how many of these were served from the cache
+	Scans       int64
+	CachedScans int64
 }
 
 type TimingResult struct {
 	Duration time.Duration
 	Metadata *TimingMetadata
+	// the time taken to plan and start the query - the remainder of the duration is spent fetching rows
+	PlanDuration time.Duration
+	// the time the query was started - used to determine the time taken to render the rows
+	StartTime time.Time
 }
 type RowResult struct {
 	Data  []interface{}
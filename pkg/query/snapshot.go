@@ -0,0 +1,47 @@
+package query
+
+import (
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardexecute"
+	"github.com/turbot/steampipe/pkg/dashboard/dashboardtypes"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+	"github.com/turbot/steampipe/pkg/query/queryresult"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+// SnapshotToQueryResult converts the table of a query snapshot into a query result
+func SnapshotToQueryResult(snap *dashboardtypes.SteampipeSnapshot) (*queryresult.Result, error) {
+	// the table of a snapshot query has a fixed name
+	tablePanel, ok := snap.Panels[modconfig.SnapshotQueryTableName]
+	if !ok {
+		return nil, sperr.New("dashboard does not contain table result for query")
+	}
+	chartRun := tablePanel.(*dashboardexecute.LeafRun)
+	if !ok {
+		return nil, sperr.New("failed to read query result from snapshot")
+	}
+	// check for error
+	if err := chartRun.GetError(); err != nil {
+		return nil, error_helpers.DecodePgError(err)
+	}
+
+	res := queryresult.NewResult(chartRun.Data.Columns)
+
+	// start a goroutine to stream the results as rows
+	go func() {
+		for _, d := range chartRun.Data.Rows {
+			// we need to allocate a new slice everytime, since this gets read
+			// asynchronously on the other end and we need to make sure that we don't overwrite
+			// data already sent
+			rowVals := make([]interface{}, len(chartRun.Data.Columns))
+			for i, c := range chartRun.Data.Columns {
+				rowVals[i] = d[c.Name]
+			}
+			res.StreamRow(rowVals)
+		}
+		res.TimingResult <- chartRun.TimingResult
+		res.Close()
+	}()
+
+	return res, nil
+}
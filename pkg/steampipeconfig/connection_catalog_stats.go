@@ -0,0 +1,9 @@
+package steampipeconfig
+
+// ConnectionCatalogStats is the catalog usage of a connection schema
+// NOTE: the json form of this struct is included in the refresh summary - do not rename fields
+type ConnectionCatalogStats struct {
+	ForeignTables int `json:"foreign_tables"`
+	// the approximate size of the catalog rows (pg_class, pg_attribute and pg_description) describing the schema
+	CatalogBytes int64 `json:"catalog_bytes"`
+}
@@ -0,0 +1,88 @@
+package steampipeconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// AddConnectionConfig writes a connection block for the given connection to a new file '<name>.spc' in configDir
+// config is the (unparsed) HCL of the plugin specific connection config - it is written into the block body as is
+// the path of the file is returned
+func AddConnectionConfig(configDir, connectionName, pluginName, config string) (string, error) {
+	if !hclsyntax.ValidIdentifier(connectionName) {
+		return "", fmt.Errorf("invalid connection name '%s'", connectionName)
+	}
+	if err := ValidateConnectionName(connectionName); err != nil {
+		return "", err
+	}
+	if pluginName == "" {
+		return "", fmt.Errorf("a plugin must be specified for connection '%s'", connectionName)
+	}
+
+	// check the config is valid HCL before writing it
+	if config != "" && !strings.HasSuffix(config, "\n") {
+		config += "\n"
+	}
+	configBody, diags := hclwrite.ParseConfig([]byte(config), "config", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("invalid config for connection '%s': %s", connectionName, diags.Error())
+	}
+
+	file := hclwrite.NewEmptyFile()
+	block := file.Body().AppendNewBlock(modconfig.BlockTypeConnection, []string{connectionName})
+	block.Body().SetAttributeValue("plugin", cty.StringVal(pluginName))
+	if len(configBody.Body().Attributes()) > 0 || len(configBody.Body().Blocks()) > 0 {
+		block.Body().AppendNewline()
+		block.Body().AppendUnstructuredTokens(configBody.BuildTokens(nil))
+	}
+
+	configPath := filepath.Join(configDir, connectionName+constants.ConfigExtension)
+	// never overwrite an existing file
+	f, err := os.OpenFile(configPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(hclwrite.Format(file.Bytes())); err != nil {
+		return "", err
+	}
+	return configPath, nil
+}
+
+// RemoveConnectionConfig removes the block for the given connection from the file it is declared in
+// if the file contains no other config, it is deleted
+func RemoveConnectionConfig(connection *modconfig.Connection) error {
+	configPath := connection.DeclRange.Filename
+	if configPath == "" {
+		return fmt.Errorf("the config file for connection '%s' is unknown", connection.Name)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	file, diags := hclwrite.ParseConfig(data, configPath, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", configPath, diags.Error())
+	}
+
+	block := file.Body().FirstMatchingBlock(modconfig.BlockTypeConnection, []string{connection.Name})
+	if block == nil {
+		return fmt.Errorf("connection '%s' is not declared in %s", connection.Name, configPath)
+	}
+	file.Body().RemoveBlock(block)
+
+	// if nothing is left in the file, remove it
+	if len(file.Body().Attributes()) == 0 && len(file.Body().Blocks()) == 0 {
+		return os.Remove(configPath)
+	}
+	return os.WriteFile(configPath, file.Bytes(), 0644)
+}
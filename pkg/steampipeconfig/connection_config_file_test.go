@@ -0,0 +1,145 @@
+package steampipeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+type addConnectionConfigTest struct {
+	name           string
+	connectionName string
+	plugin         string
+	config         string
+	expected       string
+	expectError    bool
+}
+
+var addConnectionConfigTests = []addConnectionConfigTest{
+	{
+		name:           "plugin only",
+		connectionName: "aws",
+		plugin:         "aws",
+		expected: `connection "aws" {
+  plugin = "aws"
+}
+`,
+	},
+	{
+		name:           "with config",
+		connectionName: "aws_dev",
+		plugin:         "turbot/aws@^0.1",
+		config:         `regions = ["us-east-1"]` + "\n" + `profile="dev"`,
+		expected: `connection "aws_dev" {
+  plugin = "turbot/aws@^0.1"
+
+  regions = ["us-east-1"]
+  profile = "dev"
+}
+`,
+	},
+	{
+		name:           "invalid config",
+		connectionName: "aws",
+		plugin:         "aws",
+		config:         `regions = [`,
+		expectError:    true,
+	},
+	{
+		name:           "invalid name",
+		connectionName: "aws dev",
+		plugin:         "aws",
+		expectError:    true,
+	},
+	{
+		name:           "reserved name",
+		connectionName: "public",
+		plugin:         "aws",
+		expectError:    true,
+	},
+	{
+		name:           "no plugin",
+		connectionName: "aws",
+		expectError:    true,
+	},
+}
+
+func TestAddConnectionConfig(t *testing.T) {
+	for _, test := range addConnectionConfigTests {
+		configDir := t.TempDir()
+		configPath, err := AddConnectionConfig(configDir, test.connectionName, test.plugin, test.config)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Test: '%s' FAILED : expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", test.name, err)
+			continue
+		}
+		if configPath != filepath.Join(configDir, test.connectionName+".spc") {
+			t.Errorf("Test: '%s' FAILED : unexpected config path %s", test.name, configPath)
+		}
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected:\n%s\ngot:\n%s", test.name, test.expected, string(data))
+		}
+
+		// an existing file is never overwritten
+		if _, err := AddConnectionConfig(configDir, test.connectionName, test.plugin, test.config); err == nil {
+			t.Errorf("Test: '%s' FAILED : expected an error adding the connection twice", test.name)
+		}
+	}
+}
+
+func TestRemoveConnectionConfig(t *testing.T) {
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "aws.spc")
+	config := `connection "aws_dev" {
+  plugin = "aws"
+}
+
+connection "aws_prod" {
+  plugin  = "aws"
+  profile = "prod"
+}
+`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	connection := func(name string) *modconfig.Connection {
+		return &modconfig.Connection{Name: name, DeclRange: modconfig.Range{Filename: configPath}}
+	}
+
+	// removing one of several connections leaves the rest of the file in place
+	if err := RemoveConnectionConfig(connection("aws_dev")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "aws_dev") || !strings.Contains(string(data), `connection "aws_prod"`) || !strings.Contains(string(data), `profile = "prod"`) {
+		t.Errorf("expected only aws_dev to be removed, got:\n%s", string(data))
+	}
+
+	// a connection which is not in the file is an error
+	if err := RemoveConnectionConfig(connection("aws_dev")); err == nil {
+		t.Error("expected an error removing a connection which is not declared in the file")
+	}
+
+	// removing the last connection removes the file
+	if err := RemoveConnectionConfig(connection("aws_prod")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", configPath)
+	}
+}
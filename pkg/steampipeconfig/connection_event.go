@@ -0,0 +1,69 @@
+package steampipeconfig
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const ConnectionEventStructVersion = 20261017
+
+type ConnectionEventType string
+
+const (
+	ConnectionEventUpdating ConnectionEventType = "updating"
+	ConnectionEventUpdated  ConnectionEventType = "updated"
+	ConnectionEventError    ConnectionEventType = "error"
+	ConnectionEventDeleted  ConnectionEventType = "deleted"
+)
+
+// ConnectionEvent is sent on the constants.PostgresConnectionEventChannel channel as the state of a connection
+// changes during a refresh - the event is sent in the transaction which updates the connection state table,
+// so is only received if the state change is committed
+type ConnectionEvent struct {
+	StructVersion int                 `json:"struct_version"`
+	Event         ConnectionEventType `json:"event"`
+	Connection    string              `json:"connection"`
+	// the ID of the refresh which sent the event
+	RefreshID string    `json:"refresh_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func NewConnectionEvent(event ConnectionEventType, connectionName, refreshID string) *ConnectionEvent {
+	return &ConnectionEvent{
+		StructVersion: ConnectionEventStructVersion,
+		Event:         event,
+		Connection:    connectionName,
+		RefreshID:     refreshID,
+		Timestamp:     time.Now(),
+	}
+}
+
+func NewConnectionErrorEvent(connectionName, refreshID string, err string) *ConnectionEvent {
+	res := NewConnectionEvent(ConnectionEventError, connectionName, refreshID)
+	res.Error = err
+	return res
+}
+
+// NotificationPayload returns the json payload to send for the event
+// if the marshalled event exceeds the maximum notification payload size, the error is truncated to fit
+// (the size is checked after marshalling as json escaping may expand the error)
+func (e *ConnectionEvent) NotificationPayload() string {
+	// (a ConnectionEvent only has string and time fields, so cannot fail to marshal)
+	payload, _ := json.Marshal(e)
+	truncated := *e
+	for len(payload) > maxNotificationPayloadSize && truncated.Error != "" {
+		excess := len(payload) - maxNotificationPayloadSize
+		// trim at least the excess from the error, plus room for the ellipsis
+		errorLength := len(truncated.Error) - excess - len("...")
+		if errorLength <= 0 {
+			truncated.Error = ""
+		} else {
+			// (drop any partial character left by the truncation)
+			truncated.Error = strings.ToValidUTF8(truncated.Error[:errorLength], "") + "..."
+		}
+		payload, _ = json.Marshal(truncated)
+	}
+	return string(payload)
+}
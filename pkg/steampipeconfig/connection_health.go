@@ -0,0 +1,41 @@
+package steampipeconfig
+
+import (
+	"time"
+
+	"github.com/turbot/steampipe/pkg/constants"
+)
+
+// ConnectionHealth is the result of a health check of a connection
+// a health check reads a single row from one of the connection tables (see TestConnection)
+type ConnectionHealth struct {
+	ConnectionName string        `json:"connection"`
+	Status         string        `json:"status"`
+	Table          string        `json:"table,omitempty"`
+	Latency        time.Duration `json:"-"`
+	LatencyMs      int64         `json:"latency_ms"`
+	Error          string        `json:"error,omitempty"`
+	CheckedAt      time.Time     `json:"checked_at"`
+}
+
+// NewConnectionHealth returns the health of a connection from the result of probing it
+func NewConnectionHealth(connectionName, table string, latency time.Duration, err error, checkedAt time.Time) *ConnectionHealth {
+	h := &ConnectionHealth{
+		ConnectionName: connectionName,
+		Status:         constants.ConnectionHealthOk,
+		Table:          table,
+		Latency:        latency,
+		LatencyMs:      latency.Milliseconds(),
+		CheckedAt:      checkedAt,
+	}
+	if err != nil {
+		h.Status = constants.ConnectionHealthError
+		h.Error = err.Error()
+	}
+	return h
+}
+
+// Healthy returns whether the connection probe succeeded
+func (h *ConnectionHealth) Healthy() bool {
+	return h.Status == constants.ConnectionHealthOk
+}
@@ -26,6 +26,10 @@ type ConnectionPluginData struct {
 	Type    string
 	Options *options.Connection
 	Schema  *sdkproto.Schema
+	// the error returned by the plugin when fetching the schema of the connection
+	// the plugin parses the connection config using its config schema - if the config is rejected,
+	// the plugin does not load the connection, so returns this error instead of a schema
+	ConfigError string
 }
 
 // ConnectionPlugin is a structure representing an instance of a plugin
@@ -59,7 +63,7 @@ func (p ConnectionPlugin) GetSchema(connectionName string) (schema *sdkproto.Sch
 	}()
 	log.Printf("[TRACE] GetSchema for connection '%s'", connectionName)
 	connectionData, ok := p.ConnectionMap[connectionName]
-	if ok {
+	if ok && connectionData.Schema != nil {
 		// if the schema mode is static, return the cached schema
 		if connectionData.Schema.Mode == sdkplugin.SchemaModeStatic {
 			log.Printf("[TRACE] connection data for connection '%s' is already loaded and schema is static - returning cached schema", connectionName)
@@ -245,7 +249,14 @@ func populateConnectionPluginSchemas(requestedConnectionPluginMap map[string]*Co
 			schema, err = connectionPlugin.PluginClient.GetSchema(connectionName)
 			if err != nil {
 				log.Printf("[TRACE] failed to get schema for connection '%s': %s", connectionName, err)
-				errors = append(errors, err)
+				// an aggregator schema is built from its children, so a failure is not specific to its config
+				if isAggregator {
+					errors = append(errors, err)
+					continue
+				}
+				// the plugin has rejected the connection config - record the error so the connection fails
+				// validation (see validateConnectionConfig), rather than failing the schema load for every connection
+				connectionPlugin.ConnectionMap[connectionName].ConfigError = err.Error()
 				continue
 			}
 
@@ -0,0 +1,147 @@
+package steampipeconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	sdkgrpc "github.com/turbot/steampipe-plugin-sdk/v5/grpc"
+	sdkproto "github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	pluginshared "github.com/turbot/steampipe/pkg/pluginmanager_service/grpc/shared"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/turbot/steampipe/pkg/utils"
+	"google.golang.org/grpc/status"
+)
+
+// executeStream is implemented by the stream returned by a plugin Execute call
+type executeStream interface {
+	Recv() (*sdkproto.ExecuteResponse, error)
+}
+
+// probeExecutor executes a request against the plugin for a connection
+type probeExecutor func(req *sdkproto.ExecuteRequest) (executeStream, context.CancelFunc, error)
+
+// TestConnection verifies that the plugin for a connection can connect using the connection config,
+// by reading a single row from one of the connection tables. The name of the table which was queried is returned.
+//
+// the query is executed directly against the plugin - no schema is imported into the database,
+// and the connection schema and connection state are not changed
+func TestConnection(ctx context.Context, pluginManager pluginshared.PluginManager, connectionName string) (string, error) {
+	connection, ok := GlobalConfig.Connections[connectionName]
+	if !ok {
+		return "", fmt.Errorf("connection '%s' does not exist", connectionName)
+	}
+	if connection.Type == modconfig.ConnectionTypeAggregator {
+		return "", fmt.Errorf("connection '%s' is an aggregator - test its child connections instead", connectionName)
+	}
+
+	connectionPlugins, res := CreateConnectionPlugins(pluginManager, []string{connectionName})
+	if res.Error != nil {
+		return "", res.Error
+	}
+	connectionPlugin, ok := connectionPlugins[connectionName]
+	if !ok {
+		// the reason the plugin failed to start will have been added as a warning
+		if len(res.Warnings) > 0 {
+			return "", errors.New(strings.Join(res.Warnings, "\n"))
+		}
+		return "", fmt.Errorf("failed to start plugin for connection '%s'", connectionName)
+	}
+	pluginData, ok := connectionPlugin.ConnectionMap[connectionName]
+	if ok && pluginData.ConfigError != "" {
+		return "", fmt.Errorf("plugin rejected the config for connection '%s': %s", connectionName, pluginData.ConfigError)
+	}
+	if !ok || pluginData.Schema == nil {
+		return "", fmt.Errorf("plugin did not return a schema for connection '%s'", connectionName)
+	}
+
+	execute := func(req *sdkproto.ExecuteRequest) (executeStream, context.CancelFunc, error) {
+		stream, _, cancel, err := connectionPlugin.PluginClient.Execute(req)
+		return stream, cancel, err
+	}
+	return probeConnection(ctx, execute, connectionName, pluginData.Schema)
+}
+
+// probeConnection reads a single row from a connection table which can be listed without key column quals
+// the connection is considered to be working if the plugin returns a row, or completes without error
+func probeConnection(ctx context.Context, execute probeExecutor, connectionName string, schema *sdkproto.Schema) (string, error) {
+	table, column, err := probeTable(schema)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("[INFO] testing connection '%s' by querying table '%s'", connectionName, table)
+
+	var limit int64 = 1
+	req := &sdkproto.ExecuteRequest{
+		Table:        table,
+		QueryContext: sdkproto.NewQueryContext([]string{column}, nil, limit),
+		Connection:   connectionName,
+		CallId:       sdkgrpc.BuildCallId(),
+		ExecuteConnectionData: map[string]*sdkproto.ExecuteConnectionData{
+			connectionName: {
+				Limit: &sdkproto.NullableInt{Value: limit},
+				// always query the plugin - a cached result does not prove the connection works
+				CacheEnabled: false,
+			},
+		},
+	}
+	stream, cancel, err := execute(req)
+	if err != nil {
+		return table, probeError(err)
+	}
+	defer cancel()
+	// cancel the query if the context is cancelled
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			// the query completed without returning a row - the connection is working
+			return table, nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return table, ctx.Err()
+			}
+			return table, probeError(err)
+		}
+		if res.Row != nil {
+			return table, nil
+		}
+	}
+}
+
+// probeTable returns the first table (in name order) which can be listed without key column quals,
+// along with a column to select from it
+func probeTable(schema *sdkproto.Schema) (table, column string, err error) {
+	for _, name := range utils.SortedMapKeys(schema.Schema) {
+		tableSchema := schema.Schema[name]
+		if len(tableSchema.Columns) == 0 || requiresListQuals(tableSchema) {
+			continue
+		}
+		return name, tableSchema.Columns[0].Name, nil
+	}
+	return "", "", fmt.Errorf("the plugin has no tables which can be queried without a where clause")
+}
+
+func requiresListQuals(tableSchema *sdkproto.TableSchema) bool {
+	for _, keyColumn := range tableSchema.ListCallKeyColumnList {
+		if keyColumn.Require == plugin.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// probeError removes the rpc error prefix from an error returned by the plugin
+func probeError(err error) error {
+	if s, ok := status.FromError(err); ok {
+		return errors.New(s.Message())
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package steampipeconfig
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	sdkproto "github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeExecuteStream returns the given responses, followed by err (or io.EOF if err is nil)
+type fakeExecuteStream struct {
+	responses []*sdkproto.ExecuteResponse
+	err       error
+}
+
+func (s *fakeExecuteStream) Recv() (*sdkproto.ExecuteResponse, error) {
+	if len(s.responses) > 0 {
+		res := s.responses[0]
+		s.responses = s.responses[1:]
+		return res, nil
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return nil, io.EOF
+}
+
+func newProbeTestSchema() *sdkproto.Schema {
+	column := []*sdkproto.ColumnDefinition{{Name: "id", Type: sdkproto.ColumnType_STRING}}
+	return &sdkproto.Schema{Schema: map[string]*sdkproto.TableSchema{
+		// requires a qual to list so cannot be used to probe the connection
+		"aws_a_get_only": {
+			Columns:               column,
+			ListCallKeyColumnList: []*sdkproto.KeyColumn{{Name: "id", Require: plugin.Required}},
+		},
+		"aws_b_account": {Columns: column},
+	}}
+}
+
+type connectionProbeTest struct {
+	stream      *fakeExecuteStream
+	expectedErr string
+}
+
+var testCasesConnectionProbe = map[string]connectionProbeTest{
+	"row returned": {
+		stream: &fakeExecuteStream{responses: []*sdkproto.ExecuteResponse{{Row: &sdkproto.Row{}}}},
+	},
+	"no rows": {
+		stream: &fakeExecuteStream{},
+	},
+	"invalid credentials": {
+		stream:      &fakeExecuteStream{err: status.Error(codes.Unknown, "InvalidClientTokenId: the security token included in the request is invalid")},
+		expectedErr: "InvalidClientTokenId: the security token included in the request is invalid",
+	},
+}
+
+func TestProbeConnection(t *testing.T) {
+	for name, test := range testCasesConnectionProbe {
+		var req *sdkproto.ExecuteRequest
+		execute := func(r *sdkproto.ExecuteRequest) (executeStream, context.CancelFunc, error) {
+			req = r
+			return test.stream, func() {}, nil
+		}
+
+		table, err := probeConnection(context.Background(), execute, "aws", newProbeTestSchema())
+		if test.expectedErr == "" && err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, err)
+		}
+		if test.expectedErr != "" && (err == nil || err.Error() != test.expectedErr) {
+			t.Errorf("Test: '%s' FAILED : expected error '%s', got '%v'", name, test.expectedErr, err)
+		}
+		if table != "aws_b_account" || req.Table != table {
+			t.Errorf("Test: '%s' FAILED : expected table 'aws_b_account' to be queried, got '%s'", name, req.Table)
+		}
+		if req.Connection != "aws" || req.ExecuteConnectionData["aws"].Limit.Value != 1 || req.ExecuteConnectionData["aws"].CacheEnabled {
+			t.Errorf("Test: '%s' FAILED : expected an uncached single row query of connection 'aws', got %v", name, req)
+		}
+	}
+}
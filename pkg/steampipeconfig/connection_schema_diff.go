@@ -0,0 +1,180 @@
+package steampipeconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// ConnectionSchemaDiff describes the tables and columns which a refresh would add to or remove from a connection schema
+// NOTE: the json form of this struct is intended to be consumed by scripts - do not rename fields
+type ConnectionSchemaDiff struct {
+	Connection    string   `json:"connection"`
+	AddedTables   []string `json:"added_tables,omitempty"`
+	RemovedTables []string `json:"removed_tables,omitempty"`
+	// maps of table name to added/removed columns (for tables which exist in both schemas)
+	AddedColumns   map[string][]string `json:"added_columns,omitempty"`
+	RemovedColumns map[string][]string `json:"removed_columns,omitempty"`
+}
+
+// NewConnectionSchemaDiff compares the desired plugin schema for a connection with the current database schema,
+// (a map of table name to column names)
+func NewConnectionSchemaDiff(connectionName string, desired *proto.Schema, current map[string][]string) *ConnectionSchemaDiff {
+	diff := &ConnectionSchemaDiff{
+		Connection:     connectionName,
+		AddedColumns:   make(map[string][]string),
+		RemovedColumns: make(map[string][]string),
+	}
+
+	desiredTables := make(map[string][]string)
+	if desired != nil {
+		for tableName, tableSchema := range desired.Schema {
+			columns := make([]string, len(tableSchema.Columns))
+			for i, c := range tableSchema.Columns {
+				columns[i] = c.Name
+			}
+			desiredTables[tableName] = columns
+		}
+	}
+
+	for _, tableName := range utils.SortedMapKeys(desiredTables) {
+		currentColumns, ok := current[tableName]
+		if !ok {
+			diff.AddedTables = append(diff.AddedTables, tableName)
+			continue
+		}
+		if added := stringsNotIn(desiredTables[tableName], currentColumns); len(added) > 0 {
+			diff.AddedColumns[tableName] = added
+		}
+		if removed := stringsNotIn(currentColumns, desiredTables[tableName]); len(removed) > 0 {
+			diff.RemovedColumns[tableName] = removed
+		}
+	}
+	for _, tableName := range utils.SortedMapKeys(current) {
+		if _, ok := desiredTables[tableName]; !ok {
+			diff.RemovedTables = append(diff.RemovedTables, tableName)
+		}
+	}
+	return diff
+}
+
+// HasChanges returns whether the desired schema differs from the current schema
+func (d *ConnectionSchemaDiff) HasChanges() bool {
+	return len(d.AddedTables)+len(d.RemovedTables)+len(d.AddedColumns)+len(d.RemovedColumns) > 0
+}
+
+// String returns a human-readable description of the diff, e.g.
+//
+//	aws
+//	  + table aws_s3_bucket
+//	  - table aws_legacy
+//	  + column aws_ec2_instance.tags
+func (d *ConnectionSchemaDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.Connection)
+	sb.WriteString("\n")
+	if !d.HasChanges() {
+		sb.WriteString("  no changes\n")
+		return sb.String()
+	}
+	for _, t := range d.AddedTables {
+		fmt.Fprintf(&sb, "  + table %s\n", t)
+	}
+	for _, t := range d.RemovedTables {
+		fmt.Fprintf(&sb, "  - table %s\n", t)
+	}
+	for _, t := range utils.SortedMapKeys(d.AddedColumns) {
+		for _, c := range d.AddedColumns[t] {
+			fmt.Fprintf(&sb, "  + column %s.%s\n", t, c)
+		}
+	}
+	for _, t := range utils.SortedMapKeys(d.RemovedColumns) {
+		for _, c := range d.RemovedColumns[t] {
+			fmt.Fprintf(&sb, "  - column %s.%s\n", t, c)
+		}
+	}
+	return sb.String()
+}
+
+// ConnectionSchemaDiffs is a list of schema diffs, one per connection
+type ConnectionSchemaDiffs []*ConnectionSchemaDiff
+
+// String returns a human-readable description of all diffs
+func (d ConnectionSchemaDiffs) String() string {
+	str := make([]string, len(d))
+	for i, diff := range d {
+		str[i] = diff.String()
+	}
+	return strings.Join(str, "\n")
+}
+
+// JSON returns the diffs as indented json
+func (d ConnectionSchemaDiffs) JSON() (string, error) {
+	// ensure an empty list is rendered as [] rather than null
+	if d == nil {
+		d = ConnectionSchemaDiffs{}
+	}
+	res, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// Render returns the diffs in the given output format (table or json)
+func (d ConnectionSchemaDiffs) Render(outputFormat string) (string, error) {
+	switch outputFormat {
+	case constants.OutputFormatJSON:
+		return d.JSON()
+	case constants.OutputFormatTable, "":
+		return d.String(), nil
+	}
+	return "", fmt.Errorf("unsupported output format '%s' - must be one of '%s' or '%s'", outputFormat, constants.OutputFormatTable, constants.OutputFormatJSON)
+}
+
+// LoadConnectionSchemaColumns loads the current database schema for the given connections
+// returns a map of connection name to a map of table name to column names
+func LoadConnectionSchemaColumns(ctx context.Context, conn *pgx.Conn, connectionNames []string) (map[string]map[string][]string, error) {
+	query := `select table_schema, table_name, column_name from information_schema.columns where table_schema = any($1) order by table_schema, table_name, ordinal_position`
+	rows, err := conn.Query(ctx, query, connectionNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]map[string][]string, len(connectionNames))
+	for rows.Next() {
+		var schemaName, tableName, columnName string
+		if err := rows.Scan(&schemaName, &tableName, &columnName); err != nil {
+			return nil, err
+		}
+		if res[schemaName] == nil {
+			res[schemaName] = make(map[string][]string)
+		}
+		res[schemaName][tableName] = append(res[schemaName][tableName], columnName)
+	}
+	return res, rows.Err()
+}
+
+// stringsNotIn returns the (sorted) items of a which are not in b
+func stringsNotIn(a, b []string) []string {
+	lookup := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		lookup[s] = struct{}{}
+	}
+	var res []string
+	for _, s := range a {
+		if _, ok := lookup[s]; !ok {
+			res = append(res, s)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
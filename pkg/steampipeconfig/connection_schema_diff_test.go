@@ -0,0 +1,116 @@
+package steampipeconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+)
+
+func testPluginSchema(tables map[string][]string) *proto.Schema {
+	schema := &proto.Schema{Schema: make(map[string]*proto.TableSchema)}
+	for tableName, columnNames := range tables {
+		tableSchema := &proto.TableSchema{}
+		for _, c := range columnNames {
+			tableSchema.Columns = append(tableSchema.Columns, &proto.ColumnDefinition{Name: c})
+		}
+		schema.Schema[tableName] = tableSchema
+	}
+	return schema
+}
+
+type connectionSchemaDiffTest struct {
+	desired  map[string][]string
+	current  map[string][]string
+	expected *ConnectionSchemaDiff
+}
+
+var testCasesConnectionSchemaDiff = map[string]connectionSchemaDiffTest{
+	"no changes": {
+		desired:  map[string][]string{"t1": {"a", "b"}},
+		current:  map[string][]string{"t1": {"b", "a"}},
+		expected: &ConnectionSchemaDiff{},
+	},
+	"add table": {
+		desired:  map[string][]string{"t1": {"a"}, "t2": {"a"}},
+		current:  map[string][]string{"t1": {"a"}},
+		expected: &ConnectionSchemaDiff{AddedTables: []string{"t2"}},
+	},
+	"remove table": {
+		desired:  map[string][]string{"t1": {"a"}},
+		current:  map[string][]string{"t1": {"a"}, "t2": {"a"}},
+		expected: &ConnectionSchemaDiff{RemovedTables: []string{"t2"}},
+	},
+	"add column": {
+		desired:  map[string][]string{"t1": {"a", "c", "b"}},
+		current:  map[string][]string{"t1": {"a"}},
+		expected: &ConnectionSchemaDiff{AddedColumns: map[string][]string{"t1": {"b", "c"}}},
+	},
+	"remove column": {
+		desired:  map[string][]string{"t1": {"a"}},
+		current:  map[string][]string{"t1": {"a", "b"}},
+		expected: &ConnectionSchemaDiff{RemovedColumns: map[string][]string{"t1": {"b"}}},
+	},
+	"new connection": {
+		desired:  map[string][]string{"t1": {"a"}},
+		current:  nil,
+		expected: &ConnectionSchemaDiff{AddedTables: []string{"t1"}},
+	},
+}
+
+func TestConnectionSchemaDiff(t *testing.T) {
+	for name, test := range testCasesConnectionSchemaDiff {
+		diff := NewConnectionSchemaDiff("test", testPluginSchema(test.desired), test.current)
+
+		expected := test.expected
+		expected.Connection = "test"
+		if expected.AddedColumns == nil {
+			expected.AddedColumns = map[string][]string{}
+		}
+		if expected.RemovedColumns == nil {
+			expected.RemovedColumns = map[string][]string{}
+		}
+		if !reflect.DeepEqual(diff, expected) {
+			t.Errorf("Test: '%s' FAILED : expected %+v, got %+v", name, expected, diff)
+		}
+		if diff.HasChanges() == (name == "no changes") {
+			t.Errorf("Test: '%s' FAILED : unexpected HasChanges %v", name, diff.HasChanges())
+		}
+	}
+}
+
+func TestConnectionSchemaDiffRender(t *testing.T) {
+	diffs := ConnectionSchemaDiffs{
+		NewConnectionSchemaDiff("aws",
+			testPluginSchema(map[string][]string{"aws_s3_bucket": {"name", "tags"}, "aws_vpc": {"id"}}),
+			map[string][]string{"aws_s3_bucket": {"name"}, "aws_legacy": {"id"}}),
+	}
+
+	text, err := diffs.Render("table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"aws\n", "+ table aws_vpc", "- table aws_legacy", "+ column aws_s3_bucket.tags"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected text output to contain '%s', got:\n%s", expected, text)
+		}
+	}
+
+	jsonString, err := diffs.Render("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*ConnectionSchemaDiff
+	if err := json.Unmarshal([]byte(jsonString), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || !reflect.DeepEqual(decoded[0].AddedColumns, map[string][]string{"aws_s3_bucket": {"tags"}}) {
+		t.Errorf("unexpected json output: %s", jsonString)
+	}
+
+	if _, err := diffs.Render("csv"); err == nil {
+		t.Errorf("expected an error for an unsupported output format")
+	}
+}
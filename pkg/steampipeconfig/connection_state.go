@@ -5,10 +5,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/turbot/go-kit/helpers"
 	typehelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 // ConnectionState is a struct containing all details for a connection
@@ -33,6 +36,9 @@ type ConnectionState struct {
 	SchemaMode string `json:"schema_mode" db:"schema_mode"`
 	// the hash of the connection schema - this is used to determine if a dynamic schema has changed
 	SchemaHash string `json:"schema_hash,omitempty" db:"schema_hash"`
+	// the hash of the connection config which determines the connection schema (see connectionSchemaFingerprint)
+	// this is used to determine if the connection config has changed since the schema was imported
+	SchemaFingerprint string `json:"schema_fingerprint,omitempty" db:"schema_fingerprint"`
 	// are the comments set
 	CommentsSet bool `json:"comments_set" db:"comments_set"`
 	// the creation time of the plugin file
@@ -44,6 +50,20 @@ type ConnectionState struct {
 	FileName        string   `json:"file_name" db:"file_name"`
 	StartLineNumber int      `json:"start_line_number" db:"start_line_number"`
 	EndLineNumber   int      `json:"end_line_number" db:"end_line_number"`
+	// the time the connection was last successfully refreshed
+	LastRefreshed *time.Time `json:"last_refreshed,omitempty" db:"last_refreshed"`
+	// the time the connection last failed to refresh
+	LastErrorAt *time.Time `json:"last_error_at,omitempty" db:"last_error_at"`
+	// the max_concurrency and rate_limit of the connection - these are applied by the plugin, so changing them
+	// does not require the schema to be reimported
+	MaxConcurrency *int64   `json:"max_concurrency,omitempty" db:"max_concurrency"`
+	RateLimit      *float32 `json:"rate_limit,omitempty" db:"rate_limit"`
+	// the result of the last health check of the connection (see ConnectionHealth)
+	// these are only written by a health check - refreshing the connection does not change them
+	HealthStatus    *string    `json:"health_status,omitempty" db:"health_status"`
+	HealthLatencyMs *int64     `json:"health_latency_ms,omitempty" db:"health_latency_ms"`
+	HealthError     *string    `json:"health_error,omitempty" db:"health_error"`
+	HealthCheckedAt *time.Time `json:"health_checked_at,omitempty" db:"health_checked_at"`
 }
 
 func NewConnectionState(connection *modconfig.Connection, creationTime time.Time) *ConnectionState {
@@ -56,6 +76,10 @@ func NewConnectionState(connection *modconfig.Connection, creationTime time.Time
 		Type:           &connection.Type,
 		ImportSchema:   connection.ImportSchema,
 		Connections:    connection.ConnectionNames,
+		MaxConcurrency: connection.MaxConcurrency,
+		RateLimit:      connection.RateLimit,
+		// the plugin schema itself is accounted for by the plugin mod time (and the schema hash for dynamic schemas)
+		SchemaFingerprint: connectionSchemaFingerprint(connection),
 	}
 	state.setFilename(connection)
 	if connection.Error != nil {
@@ -64,6 +88,28 @@ func NewConnectionState(connection *modconfig.Connection, creationTime time.Time
 	return state
 }
 
+// connectionSchemaFingerprint returns a hash of the connection properties which determine its schema:
+// the plugin, the plugin instance and, for aggregators, the resolved child connections
+// NOTE: the plugin specific config and the tags are deliberately excluded:
+// - a config change is sent to the plugin, and any resulting schema change is detected from the schema hash
+// (a static schema does not depend on the config)
+// - the tags only affect the schema comments, which are updated without reimporting the schema
+func connectionSchemaFingerprint(connection *modconfig.Connection) string {
+	var sb strings.Builder
+	sb.WriteString(connection.Plugin)
+	sb.WriteString("\n")
+	sb.WriteString(typehelpers.SafeString(connection.PluginInstance))
+	// the members of an aggregator may change without its config changing (e.g. when a connection matching one
+	// of its patterns is added) - include the resolved members so the aggregator is updated when they change
+	if connection.Type == modconfig.ConnectionTypeAggregator {
+		childNames := maps.Keys(connection.Connections)
+		sort.Strings(childNames)
+		sb.WriteString("\n")
+		sb.WriteString(strings.Join(childNames, ","))
+	}
+	return helpers.GetMD5Hash(sb.String())
+}
+
 func (d *ConnectionState) setFilename(connection *modconfig.Connection) {
 	d.FileName = connection.DeclRange.Filename
 	d.StartLineNumber = connection.DeclRange.Start.Line
@@ -84,11 +130,13 @@ func (d *ConnectionState) Equals(other *ConnectionState) bool {
 		return false
 	}
 
-	names := d.Connections
-	sort.Strings(names)
-	otherNames := other.Connections
-	sort.Strings(otherNames)
-	if strings.Join(names, ",") != strings.Join(otherNames, "'") {
+	if !connectionNamesEqual(d.Connections, other.Connections) {
+		return false
+	}
+
+	// connection state written before fingerprints were introduced has no fingerprint -
+	// do not treat this as a change, the fingerprint will be written when the state is next saved
+	if d.SchemaFingerprint != "" && other.SchemaFingerprint != "" && d.SchemaFingerprint != other.SchemaFingerprint {
 		return false
 	}
 
@@ -100,6 +148,19 @@ func (d *ConnectionState) Equals(other *ConnectionState) bool {
 	return true
 }
 
+// connectionNamesEqual returns whether two lists of connection names contain the same names, in any order
+// the lists are copied before sorting, so the connection states are not modified
+func connectionNamesEqual(names, otherNames []string) bool {
+	if len(names) != len(otherNames) {
+		return false
+	}
+	names = slices.Clone(names)
+	sort.Strings(names)
+	otherNames = slices.Clone(otherNames)
+	sort.Strings(otherNames)
+	return slices.Equal(names, otherNames)
+}
+
 // allow for sub ms rounding errors when converting from PG
 func (d *ConnectionState) pluginModTimeChanged(other *ConnectionState) bool {
 	if d.PluginModTime.Sub(other.PluginModTime).Abs() > 1*time.Millisecond {
@@ -122,16 +183,24 @@ func (d *ConnectionState) SetError(err string) {
 	d.ConnectionError = &err
 }
 
-// Loaded returns true if the connection state is 'ready' or 'error'
+// Loaded returns whether the connection has finished loading
 // Disabled connections are considered as 'loaded'
+// a lazy connection is also considered loaded - its schema is only imported when it is first queried
+// NOTE: the import is triggered by the steampipe query client (DbClient.importLazyConnections),
+// so a lazy connection queried by any other Postgres client reports a missing schema until it is imported
 func (d *ConnectionState) Loaded() bool {
-	return d.Disabled() || d.State == constants.ConnectionStateReady || d.State == constants.ConnectionStateError
+	return d.Disabled() || d.Lazy() || d.State == constants.ConnectionStateReady || d.State == constants.ConnectionStateError
 }
 
 func (d *ConnectionState) Disabled() bool {
 	return d.State == constants.ConnectionStateDisabled
 }
 
+// Lazy returns whether the connection has import_schema="lazy" and its schema has not yet been imported
+func (d *ConnectionState) Lazy() bool {
+	return d.State == constants.ConnectionStateLazy
+}
+
 func (d *ConnectionState) GetType() string {
 	return typehelpers.SafeString(d.Type)
 }
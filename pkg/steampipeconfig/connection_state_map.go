@@ -134,6 +134,17 @@ func (m ConnectionStateMap) Loaded(connections ...string) bool {
 	return true
 }
 
+// LazyConnections returns the given connections which are lazy and have not been imported
+func (m ConnectionStateMap) LazyConnections(connections ...string) []string {
+	var res []string
+	for _, connectionName := range connections {
+		if connectionState, ok := m[connectionName]; ok && connectionState.Lazy() {
+			res = append(res, connectionName)
+		}
+	}
+	return res
+}
+
 // ConnectionsInState returns whether there are any connections one of the given states
 func (m ConnectionStateMap) ConnectionsInState(states ...string) bool {
 	for _, c := range m {
@@ -237,7 +248,7 @@ func (m ConnectionStateMap) SetConnectionsToPendingOrIncomplete() {
 		if state.State == constants.ConnectionStateReady {
 			state.State = constants.ConnectionStatePending
 			state.ConnectionModTime = time.Now()
-		} else if state.State != constants.ConnectionStateDisabled {
+		} else if !state.Disabled() && !state.Lazy() {
 			state.State = constants.ConnectionStatePendingIncomplete
 			state.ConnectionModTime = time.Now()
 		}
@@ -0,0 +1,56 @@
+package steampipeconfig
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/db/db_common"
+)
+
+// ConnectionStateTable identifies the table used to store the connection state
+// by default this is steampipe_internal.steampipe_connection - the schema and name may be configured
+// so that multiple Steampipe instances sharing a database each maintain their own connection state
+type ConnectionStateTable struct {
+	Schema string
+	Name   string
+}
+
+// DefaultConnectionStateTable returns the default connection state table
+func DefaultConnectionStateTable() ConnectionStateTable {
+	return ConnectionStateTable{Schema: constants.InternalSchema, Name: constants.ConnectionTable}
+}
+
+// ConnectionStateTableFromConfig returns the configured connection state table,
+// using the default schema and name for any which are not set
+func ConnectionStateTableFromConfig() ConnectionStateTable {
+	table := DefaultConnectionStateTable()
+	if schema := viper.GetString(constants.ArgConnectionStateSchema); schema != "" {
+		table.Schema = schema
+	}
+	if name := viper.GetString(constants.ArgConnectionStateTable); name != "" {
+		table.Name = name
+	}
+	return table
+}
+
+// IsDefault returns whether this is the default connection state table
+// (only the default table has a legacy table which must also be maintained)
+func (t ConnectionStateTable) IsDefault() bool {
+	return t == DefaultConnectionStateTable()
+}
+
+// Identifier returns the escaped, schema qualified table identifier
+func (t ConnectionStateTable) Identifier() string {
+	return fmt.Sprintf("%s.%s", db_common.PgEscapeName(t.Schema), db_common.PgEscapeName(t.Name))
+}
+
+// HealthViewIdentifier returns the escaped, schema qualified identifier of the view exposing the connection health
+// check results recorded in this table
+func (t ConnectionStateTable) HealthViewIdentifier() string {
+	return fmt.Sprintf("%s.%s", db_common.PgEscapeName(t.Schema), db_common.PgEscapeName(t.Name+constants.ConnectionHealthViewSuffix))
+}
+
+func (t ConnectionStateTable) String() string {
+	return fmt.Sprintf("%s.%s", t.Schema, t.Name)
+}
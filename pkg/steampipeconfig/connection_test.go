@@ -11,7 +11,9 @@ import (
 	"github.com/otiai10/copy"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/filepaths"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
 	"github.com/turbot/steampipe/pkg/utils"
+	"golang.org/x/exp/slices"
 )
 
 type getConnectionsToUpdateTest struct {
@@ -433,9 +435,66 @@ var data2 = ConnectionState{
 	PluginModTime: time.Now().Add(-1 * time.Hour),
 }
 
+var pluginInstance1 = "plugin_1"
+var pluginInstance2 = "plugin_2"
+var dataPluginInstanceChanged = ConnectionState{
+	Plugin:            "plugin",
+	PluginModTime:     data1.PluginModTime,
+	SchemaFingerprint: connectionSchemaFingerprint(&modconfig.Connection{Plugin: "plugin", PluginInstance: &pluginInstance2}),
+}
+var dataConfigChanged = ConnectionState{
+	Plugin:        "plugin",
+	PluginModTime: data1.PluginModTime,
+	SchemaFingerprint: connectionSchemaFingerprint(&modconfig.Connection{Plugin: "plugin", PluginInstance: &pluginInstance1,
+		Config: `regions = ["us-east-1"]`, Tags: map[string]string{"env": "prod"}}),
+}
+var dataConfigUnchanged = ConnectionState{
+	Plugin:            "plugin",
+	PluginModTime:     data1.PluginModTime,
+	SchemaFingerprint: connectionSchemaFingerprint(&modconfig.Connection{Plugin: "plugin", PluginInstance: &pluginInstance1, Config: `regions = ["*"]`}),
+}
+var dataConfigUnchangedDuplicate = ConnectionState{
+	Plugin:            "plugin",
+	PluginModTime:     data1.PluginModTime,
+	SchemaFingerprint: connectionSchemaFingerprint(&modconfig.Connection{Plugin: "plugin", PluginInstance: &pluginInstance1, Config: `regions = ["*"]`}),
+}
+var dataAggregator = ConnectionState{
+	Plugin:        "plugin",
+	PluginModTime: data1.PluginModTime,
+	Connections:   []string{"b", "a"},
+}
+var dataAggregatorDuplicate = ConnectionState{
+	Plugin:        "plugin",
+	PluginModTime: data1.PluginModTime,
+	Connections:   []string{"a", "b"},
+}
+var dataAggregatorMembers = ConnectionState{
+	Plugin:        "plugin",
+	PluginModTime: data1.PluginModTime,
+	Connections:   []string{"aws_*"},
+	SchemaFingerprint: connectionSchemaFingerprint(&modconfig.Connection{Plugin: "plugin", Type: modconfig.ConnectionTypeAggregator,
+		Connections: map[string]*modconfig.Connection{"aws_1": {}}}),
+}
+var dataAggregatorMembersAdded = ConnectionState{
+	Plugin:        "plugin",
+	PluginModTime: data1.PluginModTime,
+	Connections:   []string{"aws_*"},
+	SchemaFingerprint: connectionSchemaFingerprint(&modconfig.Connection{Plugin: "plugin", Type: modconfig.ConnectionTypeAggregator,
+		Connections: map[string]*modconfig.Connection{"aws_1": {}, "aws_2": {}}}),
+}
+
 var connectionDataEqualCases map[string]connectionDataEqual = map[string]connectionDataEqual{
 	"expected_equal":     {data1: &data1, data2: &data1_duplicate, expectation: true},
 	"not_expected_equal": {data1: &data1, data2: &data2, expectation: false},
+	"config_unchanged":   {data1: &dataConfigUnchanged, data2: &dataConfigUnchangedDuplicate, expectation: true},
+	// config and tag changes do not change the schema fingerprint (see connectionSchemaFingerprint)
+	"config_changed":          {data1: &dataConfigUnchanged, data2: &dataConfigChanged, expectation: true},
+	"plugin_instance_changed": {data1: &dataConfigUnchanged, data2: &dataPluginInstanceChanged, expectation: false},
+	// state written before fingerprints were introduced is not treated as changed
+	"no_fingerprint":      {data1: &data1, data2: &dataPluginInstanceChanged, expectation: true},
+	"aggregator_children": {data1: &dataAggregator, data2: &dataAggregatorDuplicate, expectation: true},
+	// a change to the resolved members of an aggregator is a change, even if its patterns are unchanged
+	"aggregator_members_changed": {data1: &dataAggregatorMembers, data2: &dataAggregatorMembersAdded, expectation: false},
 }
 
 func TestConnectionsUpdateEqual(t *testing.T) {
@@ -538,3 +597,27 @@ func getTestFileModTime(file string) time.Time {
 	modTime, _ := utils.FileModTime(file)
 	return modTime
 }
+
+func TestConnectionNamesEqual(t *testing.T) {
+	testCases := map[string]struct {
+		names      []string
+		otherNames []string
+		expected   bool
+	}{
+		"empty":           {expected: true},
+		"same order":      {names: []string{"a", "b"}, otherNames: []string{"a", "b"}, expected: true},
+		"different order": {names: []string{"b", "a"}, otherNames: []string{"a", "b"}, expected: true},
+		"different names": {names: []string{"a", "b"}, otherNames: []string{"a", "c"}, expected: false},
+		"different count": {names: []string{"a", "b"}, otherNames: []string{"a"}, expected: false},
+	}
+	for name, test := range testCases {
+		names := slices.Clone(test.names)
+		if actual := connectionNamesEqual(names, test.otherNames); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, actual)
+		}
+		// the names must not be sorted in place
+		if !slices.Equal(names, test.names) {
+			t.Errorf("Test: '%s' FAILED : expected the names not to be modified, got %v", name, names)
+		}
+	}
+}
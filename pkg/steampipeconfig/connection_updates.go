@@ -21,10 +21,12 @@ import (
 )
 
 type ConnectionUpdates struct {
-	Update          ConnectionStateMap
-	Delete          map[string]struct{}
-	Error           map[string]struct{}
-	Disabled        map[string]struct{}
+	Update   ConnectionStateMap
+	Delete   map[string]struct{}
+	Error    map[string]struct{}
+	Disabled map[string]struct{}
+	// connections with import_schema="lazy" whose import has been deferred until they are first queried
+	Lazy            map[string]struct{}
 	MissingComments ConnectionStateMap
 	// map of missing plugins, keyed by plugin ALIAS
 	// NOTE: we key by alias so the error message refers to the string which was used to specify the plugin
@@ -36,10 +38,13 @@ type ConnectionUpdates struct {
 
 	CurrentConnectionState ConnectionStateMap
 	InvalidConnections     map[string]*ValidationFailure
+	// validation failures which were ignored as plugin validation is disabled, keyed by connection name
+	IgnoredValidationFailures map[string]*ValidationFailure
 	// map of plugin to connection for which we must refetch the rate limiter definitions
 	PluginsWithUpdatedBinary map[string]string
 
 	forceUpdateConnectionNames []string
+	skipPluginValidation       bool
 	pluginManager              pluginshared.PluginManager
 }
 
@@ -54,8 +59,18 @@ func NewConnectionUpdates(ctx context.Context, pool *pgxpool.Pool, pluginManager
 	}
 
 	// validate the updates
-	// this will validate all plugins and connection names  and remove any updates which use invalid connections
+	// this will validate all plugins, connection names and connection config and remove any updates which use invalid connections
 	updates.validate()
+	if warning := updates.InvalidConnectionWarning(); warning != "" {
+		res.AddWarning(warning)
+	}
+	if warning := updates.IgnoredValidationWarning(); warning != "" {
+		res.AddWarning(warning)
+	}
+	if warning := updates.UnknownForceUpdateConnectionWarning(); warning != "" {
+		log.Printf("[WARN] %s", warning)
+		res.AddWarning(warning)
+	}
 
 	return updates, res
 }
@@ -107,13 +122,16 @@ func populateConnectionUpdates(ctx context.Context, pool *pgxpool.Pool, pluginMa
 		Delete:                     make(map[string]struct{}),
 		Error:                      make(map[string]struct{}),
 		Disabled:                   disabled,
+		Lazy:                       make(map[string]struct{}),
 		Update:                     ConnectionStateMap{},
 		MissingComments:            ConnectionStateMap{},
 		MissingPlugins:             missingPlugins,
 		FinalConnectionState:       requiredConnectionStateMap,
 		InvalidConnections:         make(map[string]*ValidationFailure),
+		IgnoredValidationFailures:  make(map[string]*ValidationFailure),
 		PluginsWithUpdatedBinary:   make(map[string]string),
 		forceUpdateConnectionNames: config.ForceUpdateConnectionNames,
+		skipPluginValidation:       config.SkipPluginValidation,
 		pluginManager:              pluginManager,
 	}
 
@@ -137,28 +155,8 @@ func populateConnectionUpdates(ctx context.Context, pool *pgxpool.Pool, pluginMa
 	}
 	log.Printf("[INFO] identify connections to update")
 
-	modTime := time.Now()
-
 	// connections to create/update
-	for name, requiredConnectionState := range requiredConnectionStateMap {
-		// if the connection requires update, add to list
-		res := connectionRequiresUpdate(config.ForceUpdateConnectionNames, name, currentConnectionStateMap, requiredConnectionState)
-		if res.requiresUpdate {
-			log.Printf("[INFO] connection %s is out of date or missing. updates: %v", name, maps.Keys(updates.Update))
-			updates.Update[name] = requiredConnectionState
-
-			// set the connection mod time of required connection data to now
-			requiredConnectionState.ConnectionModTime = modTime
-
-			// if the plugin mod time has changed, add this to the map of connections
-			// we need to refetch the rate limiters for this plugin
-			if res.pluginBinaryChanged {
-				// store map item of plugin name to connection name (so we only have one entry per plugin)
-				pluginShortName := GlobalConfig.Connections[requiredConnectionState.ConnectionName].PluginAlias
-				updates.PluginsWithUpdatedBinary[pluginShortName] = requiredConnectionState.ConnectionName
-			}
-		}
-	}
+	updates.identifyConnectionsToUpdate(config, time.Now())
 
 	// TODO KAI TIDY INTO FUNCTION
 
@@ -172,6 +170,10 @@ func populateConnectionUpdates(ctx context.Context, pool *pgxpool.Pool, pluginMa
 			// if required connection state is disabled and it is not currently disabled, mark for deletion
 			log.Printf("[TRACE] connection %s is disabled - marking for deletion\n", name)
 			updates.Delete[name] = struct{}{}
+		} else if updates.FinalConnectionState[name].Lazy() && !currentState.Lazy() && !currentState.Disabled() {
+			// the import of this lazy connection has been deferred - drop the out of date schema
+			log.Printf("[TRACE] connection %s import is deferred - marking for deletion\n", name)
+			updates.Delete[name] = struct{}{}
 		} else if updates.FinalConnectionState[name].State == constants.ConnectionStateError && currentState.State != constants.ConnectionStateError {
 			// if required connection state is disabled and it is not currently disabled, add to error map
 			// the schema will be deleted by the connection will remain in the table
@@ -198,10 +200,20 @@ func populateConnectionUpdates(ctx context.Context, pool *pgxpool.Pool, pluginMa
 		}
 	}
 
+	// if pruning is enabled, drop any foreign schemas which do not correspond to a configured connection
+	var pruneWarning string
+	if config.Prune {
+		pruneWarning = updates.pruneOrphanedSchemas(foreignSchemaNames, GlobalConfig.Connections, config.DryRun)
+	}
+
 	// now for every connection with dynamic schema,
 	// check whether the schema we have just fetched matches the existing db schema
 	// if not, add to updates
 	for name, requiredHash := range dynamicSchemaHashMap {
+		// lazy connections are imported when they are first queried
+		if _, lazy := updates.Lazy[name]; lazy {
+			continue
+		}
 		// get the connection data from the loaded connection state
 		connectionData, ok := currentConnectionStateMap[name]
 		// if the connection exists in the state, does the schemas hash match?
@@ -230,10 +242,69 @@ func populateConnectionUpdates(ctx context.Context, pool *pgxpool.Pool, pluginMa
 
 	// before we return, merge in connection state warnings
 	res.AddWarning(connectionStateResult.Warnings...)
+	if pruneWarning != "" {
+		res.AddWarning(pruneWarning)
+	}
 
 	return updates, res
 }
 
+// identifyConnectionsToUpdate adds any required connections which are new or out of date to the update set
+// if ForceUpdateAll is set, every required connection is treated as a forced update
+// (connections which are disabled or in error are still excluded, and the updates are still validated)
+func (u *ConnectionUpdates) identifyConnectionsToUpdate(config *connectionUpdatesConfig, modTime time.Time) {
+	forceUpdateConnectionNames := config.ForceUpdateConnectionNames
+	if config.ForceUpdateAll {
+		log.Printf("[INFO] forcing update of all %d connections - every connection schema will be dropped and reimported, this may take some time", len(u.FinalConnectionState))
+		forceUpdateConnectionNames = maps.Keys(u.FinalConnectionState)
+	}
+
+	for name, requiredConnectionState := range u.FinalConnectionState {
+		// if the connection requires update, add to list
+		res := connectionRequiresUpdate(forceUpdateConnectionNames, name, u.CurrentConnectionState, requiredConnectionState)
+		if u.deferLazyImport(config.ForceUpdateConnectionNames, name, requiredConnectionState, res.requiresUpdate) {
+			continue
+		}
+		if res.requiresUpdate {
+			log.Printf("[INFO] connection %s is out of date or missing. updates: %v", name, maps.Keys(u.Update))
+			u.Update[name] = requiredConnectionState
+
+			// set the connection mod time of required connection data to now
+			requiredConnectionState.ConnectionModTime = modTime
+
+			// if the plugin mod time has changed, add this to the map of connections
+			// we need to refetch the rate limiters for this plugin
+			if res.pluginBinaryChanged {
+				// store map item of plugin name to connection name (so we only have one entry per plugin)
+				pluginShortName := GlobalConfig.Connections[requiredConnectionState.ConnectionName].PluginAlias
+				u.PluginsWithUpdatedBinary[pluginShortName] = requiredConnectionState.ConnectionName
+			}
+		}
+	}
+}
+
+// deferLazyImport determines whether the import of a connection with import_schema="lazy" should be deferred,
+// and if so sets its required state to lazy
+// the import is deferred if the connection requires an update, or has not yet been imported, unless
+// an update of the connection has been explicitly requested (which is how the import is triggered on first use)
+func (u *ConnectionUpdates) deferLazyImport(forceUpdateConnectionNames []string, name string, requiredConnectionState *ConnectionState, requiresUpdate bool) bool {
+	if requiredConnectionState.ImportSchema != modconfig.ImportSchemaLazy || requiredConnectionState.State == constants.ConnectionStateError {
+		return false
+	}
+	if helpers.StringSliceContains(forceUpdateConnectionNames, name) {
+		return false
+	}
+	currentConnectionState, existsInCurrentState := u.CurrentConnectionState[name]
+	if !requiresUpdate && existsInCurrentState && !currentConnectionState.Lazy() {
+		// the schema has been imported and is up to date
+		return false
+	}
+	log.Printf("[INFO] connection %s has import_schema=lazy - deferring import until it is queried", name)
+	requiredConnectionState.State = constants.ConnectionStateLazy
+	u.Lazy[name] = struct{}{}
+	return true
+}
+
 type connectionRequiresUpdateResult struct {
 	requiresUpdate      bool
 	pluginBinaryChanged bool
@@ -306,6 +377,10 @@ func (u *ConnectionUpdates) updateRequiredStateWithSchemaProperties(dynamicSchem
 			if connectionPlugin.ConnectionMap[k] == nil {
 				panic(fmt.Sprintf("reattach config for connection '%s' does not contain the config for '%s in its connection map", k, k))
 			}
+			// the schema is not set if the plugin rejected the connection config (the connection will fail validation)
+			if connectionPlugin.ConnectionMap[k].Schema == nil {
+				continue
+			}
 			v.SchemaMode = connectionPlugin.ConnectionMap[k].Schema.Mode
 			// if the schema mode is dynamic and the hash is not set yet, calculate the value from the connection plugin schema
 			// this will happen the first time we load a plugin - as schemaHashMap will NOT include the hash
@@ -414,8 +489,8 @@ func (u *ConnectionUpdates) setError(connectionName string, error string) {
 // NOTE: this mutates FinalConnectionState to set comment_set (if needed)
 func (u *ConnectionUpdates) IdentifyMissingComments() {
 	for name, state := range u.FinalConnectionState {
-		// if the state is in error, skip
-		if state.State == constants.ConnectionStateError {
+		// if the state is in error, or the connection has not been imported, skip
+		if state.State == constants.ConnectionStateError || state.Lazy() {
 			continue
 		}
 		if currentState, existsInCurrentState := u.CurrentConnectionState[name]; existsInCurrentState {
@@ -505,6 +580,10 @@ func (u *ConnectionUpdates) getSchemaHashesForDynamicSchemas(requiredConnectionD
 
 	hashMap := make(map[string]string)
 	for name, c := range connectionsPluginsWithDynamicSchema {
+		// the schema is not set if the plugin rejected the connection config (the connection will fail validation)
+		if c.ConnectionMap[name].Schema == nil {
+			continue
+		}
 		// update schema hash stored in required connections so it is persisted in the state if updates are made
 		schemaHash := pluginSchemaHash(c.ConnectionMap[name].Schema)
 		hashMap[name] = schemaHash
@@ -512,6 +591,41 @@ func (u *ConnectionUpdates) getSchemaHashesForDynamicSchemas(requiredConnectionD
 	return hashMap, connectionsPluginsWithDynamicSchema, nil
 }
 
+// ExemplarSchemas returns a map, keyed by plugin, of existing connection schemas which may be cloned
+// when creating new connections for the plugin
+// this is derived from the persisted connection state, so a schema imported by a previous refresh can be used
+// - connections which are being deleted, updated or are in error are never used as an exemplar
+// canClone determines whether a connection schema may be cloned
+func (u *ConnectionUpdates) ExemplarSchemas(canClone func(*ConnectionState) bool) map[string]string {
+	res := make(map[string]string)
+	// iterate in sorted order so the exemplar chosen is deterministic
+	for _, connectionName := range utils.SortedMapKeys(u.CurrentConnectionState) {
+		current := u.CurrentConnectionState[connectionName]
+		if _, exists := res[current.Plugin]; exists {
+			continue
+		}
+		if current.State != constants.ConnectionStateReady || !canClone(current) {
+			continue
+		}
+		if _, deleting := u.Delete[connectionName]; deleting {
+			continue
+		}
+		if _, inError := u.Error[connectionName]; inError {
+			continue
+		}
+		if _, updating := u.Update[connectionName]; updating {
+			continue
+		}
+		// the exemplar must use the same plugin binary as the final connection state
+		final, ok := u.FinalConnectionState[connectionName]
+		if !ok || final.Plugin != current.Plugin || !final.PluginModTime.Equal(current.PluginModTime) {
+			continue
+		}
+		res[current.Plugin] = connectionName
+	}
+	return res
+}
+
 func (u *ConnectionUpdates) GetConnectionsToDelete() []string {
 	return append(maps.Keys(u.Delete), maps.Keys(u.Error)...)
 }
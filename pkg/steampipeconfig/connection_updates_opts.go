@@ -2,6 +2,10 @@ package steampipeconfig
 
 type connectionUpdatesConfig struct {
 	ForceUpdateConnectionNames []string
+	ForceUpdateAll             bool
+	SkipPluginValidation       bool
+	Prune                      bool
+	DryRun                     bool
 }
 
 type ConnectionUpdatesOption func(opt *connectionUpdatesConfig)
@@ -11,3 +15,28 @@ func WithForceUpdate(connections []string) ConnectionUpdatesOption {
 		opt.ForceUpdateConnectionNames = connections
 	}
 }
+
+// WithForceUpdateAll forces an update of every configured connection, regardless of whether it has changed
+// NOTE: this drops and reimports the schema of every connection, so is an expensive operation
+func WithForceUpdateAll(force bool) ConnectionUpdatesOption {
+	return func(opt *connectionUpdatesConfig) {
+		opt.ForceUpdateAll = force
+	}
+}
+
+// WithSkipPluginValidation disables the plugin sdk version check - connections for plugins using a newer
+// sdk version than Steampipe will be imported (a warning is still reported)
+func WithSkipPluginValidation(skip bool) ConnectionUpdatesOption {
+	return func(opt *connectionUpdatesConfig) {
+		opt.SkipPluginValidation = skip
+	}
+}
+
+// WithPrune drops any foreign schemas which do not correspond to a configured connection
+// if dryRun is set, the schemas which would be dropped are reported but not dropped
+func WithPrune(prune, dryRun bool) ConnectionUpdatesOption {
+	return func(opt *connectionUpdatesConfig) {
+		opt.Prune = prune
+		opt.DryRun = dryRun
+	}
+}
@@ -0,0 +1,79 @@
+package steampipeconfig
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+// orphanedSchemas returns the (sorted) foreign schemas which do not correspond to any configured connection
+// which imports its schema
+// this only considers the connection config - NOT the connection state table - so it finds schemas left behind
+// when the state table is out of sync with the database (e.g. after a crash)
+func orphanedSchemas(foreignSchemaNames []string, connections map[string]*modconfig.Connection) []string {
+	var res []string
+	for _, name := range foreignSchemaNames {
+		if isSystemSchema(name) {
+			continue
+		}
+		if connection, ok := connections[name]; ok && connection.ImportSchema != modconfig.ImportSchemaDisabled {
+			continue
+		}
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// isSystemSchema returns whether the schema is created by Postgres or Steampipe (and so must never be pruned)
+func isSystemSchema(name string) bool {
+	return name == constants.InternalSchema ||
+		name == constants.LegacyCommandSchema ||
+		name == "information_schema" ||
+		strings.HasPrefix(name, "pg_") ||
+		helpers.StringSliceContains(constants.ReservedConnectionNames, name)
+}
+
+// pruneOrphanedSchemas marks any orphaned schemas for deletion, returning a warning listing the pruned schemas
+// if dryRun is set, the schemas are NOT deleted - the warning lists the schemas which would be pruned
+func (u *ConnectionUpdates) pruneOrphanedSchemas(foreignSchemaNames []string, connections map[string]*modconfig.Connection, dryRun bool) string {
+	orphaned := orphanedSchemas(foreignSchemaNames, connections)
+	if len(orphaned) == 0 {
+		return ""
+	}
+
+	if dryRun {
+		// ensure the orphans are left in place, even if they would otherwise have been deleted
+		for _, name := range orphaned {
+			delete(u.Delete, name)
+		}
+		log.Printf("[INFO] dry run - not pruning orphaned schemas: %s", strings.Join(orphaned, ","))
+		return fmt.Sprintf("dry run: %d orphaned %s would be pruned: %s",
+			len(orphaned),
+			schemaNoun(len(orphaned)),
+			strings.Join(orphaned, ", "))
+	}
+
+	for _, name := range orphaned {
+		log.Printf("[INFO] pruning orphaned schema %s", name)
+		u.Delete[name] = struct{}{}
+	}
+	return fmt.Sprintf("pruning %d orphaned %s: %s",
+		len(orphaned),
+		schemaNoun(len(orphaned)),
+		strings.Join(orphaned, ", "))
+}
+
+// schemaNoun returns 'schema' or 'schemas' for the given count
+// (utils.Pluralize would give 'schemata')
+func schemaNoun(count int) string {
+	if count == 1 {
+		return "schema"
+	}
+	return "schemas"
+}
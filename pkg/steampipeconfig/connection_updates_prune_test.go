@@ -0,0 +1,50 @@
+package steampipeconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func newPruneTestConnections() map[string]*modconfig.Connection {
+	return map[string]*modconfig.Connection{
+		"aws":      {Name: "aws", ImportSchema: modconfig.ImportSchemaEnabled},
+		"disabled": {Name: "disabled", ImportSchema: modconfig.ImportSchemaDisabled},
+	}
+}
+
+func TestPruneOrphanedSchemas(t *testing.T) {
+	foreignSchemaNames := []string{"aws", "disabled", "orphan", "public", constants.InternalSchema, "pg_temp_1"}
+	expected := []string{"disabled", "orphan"}
+
+	updates := &ConnectionUpdates{Delete: make(map[string]struct{})}
+	if warning := updates.pruneOrphanedSchemas(foreignSchemaNames, newPruneTestConnections(), false); warning != "pruning 2 orphaned schemas: disabled, orphan" {
+		t.Errorf("unexpected prune warning '%s'", warning)
+	}
+	for _, name := range expected {
+		if _, ok := updates.Delete[name]; !ok {
+			t.Errorf("expected schema '%s' to be scheduled for pruning", name)
+		}
+	}
+	if len(updates.Delete) != len(expected) {
+		t.Errorf("expected %v to be scheduled for pruning, got %v", expected, updates.Delete)
+	}
+
+	// a dry run reports the schemas but does not delete them
+	updates = &ConnectionUpdates{Delete: map[string]struct{}{"orphan": {}}}
+	if warning := updates.pruneOrphanedSchemas(foreignSchemaNames, newPruneTestConnections(), true); warning != "dry run: 2 orphaned schemas would be pruned: disabled, orphan" {
+		t.Errorf("unexpected dry run warning '%s'", warning)
+	}
+	if len(updates.Delete) != 0 {
+		t.Errorf("expected dry run not to schedule any deletions, got %v", updates.Delete)
+	}
+
+	if orphaned := orphanedSchemas([]string{"aws"}, newPruneTestConnections()); orphaned != nil {
+		t.Errorf("expected no orphaned schemas, got %v", orphaned)
+	}
+	if orphaned := orphanedSchemas(foreignSchemaNames, newPruneTestConnections()); !reflect.DeepEqual(orphaned, expected) {
+		t.Errorf("expected orphaned schemas %v, got %v", expected, orphaned)
+	}
+}
@@ -0,0 +1,128 @@
+package steampipeconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func newForceUpdateTestConnectionUpdates(forceUpdateAll bool) (*ConnectionUpdates, *connectionUpdatesConfig) {
+	pluginModTime := time.Now()
+	newState := func(name, state string) *ConnectionState {
+		return &ConnectionState{
+			ConnectionName: name,
+			Plugin:         "hub.steampipe.io/plugins/turbot/aws@latest",
+			State:          state,
+			PluginModTime:  pluginModTime,
+		}
+	}
+	// the current state matches the required state, so the diff is empty
+	current := ConnectionStateMap{
+		"aws1":     newState("aws1", constants.ConnectionStateReady),
+		"aws2":     newState("aws2", constants.ConnectionStateReady),
+		"disabled": newState("disabled", constants.ConnectionStateDisabled),
+	}
+	required := ConnectionStateMap{
+		"aws1":     newState("aws1", constants.ConnectionStateReady),
+		"aws2":     newState("aws2", constants.ConnectionStateReady),
+		"disabled": newState("disabled", constants.ConnectionStateDisabled),
+	}
+	updates := &ConnectionUpdates{
+		Update:                   ConnectionStateMap{},
+		CurrentConnectionState:   current,
+		FinalConnectionState:     required,
+		PluginsWithUpdatedBinary: make(map[string]string),
+	}
+	return updates, &connectionUpdatesConfig{ForceUpdateAll: forceUpdateAll}
+}
+
+func TestIdentifyConnectionsToUpdateForceUpdateAll(t *testing.T) {
+	// without forcing, there is nothing to update
+	updates, config := newForceUpdateTestConnectionUpdates(false)
+	updates.identifyConnectionsToUpdate(config, time.Now())
+	if len(updates.Update) != 0 {
+		t.Errorf("expected no updates, got %v", updates.Update)
+	}
+
+	// when forcing all updates, every enabled connection is updated
+	updates, config = newForceUpdateTestConnectionUpdates(true)
+	updates.identifyConnectionsToUpdate(config, time.Now())
+	for _, name := range []string{"aws1", "aws2"} {
+		if _, ok := updates.Update[name]; !ok {
+			t.Errorf("expected connection '%s' to be updated", name)
+		}
+	}
+	if _, ok := updates.Update["disabled"]; ok {
+		t.Errorf("expected disabled connection not to be updated")
+	}
+}
+
+func TestUnknownForceUpdateConnectionWarning(t *testing.T) {
+	testCases := map[string]struct {
+		forceUpdateConnectionNames []string
+		expected                   string
+	}{
+		"no forced updates":   {nil, ""},
+		"all names valid":     {[]string{"aws1", "aws2"}, ""},
+		"disabled connection": {[]string{"disabled"}, ""},
+		"one bogus name":      {[]string{"aws1", "aws_1"}, "ignoring forced update of 1 unknown connection: aws_1"},
+		"repeated bogus name": {[]string{"aws3", "aws4", "aws3"}, "ignoring forced update of 2 unknown connections: aws3, aws4"},
+	}
+	for name, test := range testCases {
+		updates, _ := newForceUpdateTestConnectionUpdates(false)
+		updates.forceUpdateConnectionNames = test.forceUpdateConnectionNames
+		if actual := updates.UnknownForceUpdateConnectionWarning(); actual != test.expected {
+			t.Errorf("Test: '%s' FAILED : expected warning '%s', got '%s'", name, test.expected, actual)
+		}
+	}
+}
+
+func TestIdentifyConnectionsToUpdateLazy(t *testing.T) {
+	pluginModTime := time.Now()
+	newState := func(name, state string) *ConnectionState {
+		return &ConnectionState{
+			ConnectionName: name,
+			Plugin:         "hub.steampipe.io/plugins/turbot/aws@latest",
+			State:          state,
+			ImportSchema:   modconfig.ImportSchemaLazy,
+			PluginModTime:  pluginModTime,
+		}
+	}
+	testCases := map[string]struct {
+		current                    *ConnectionState
+		forceUpdateConnectionNames []string
+		expectUpdate               bool
+		expectedState              string
+	}{
+		"new connection is deferred":       {nil, nil, false, constants.ConnectionStateLazy},
+		"deferred connection stays lazy":   {newState("aws", constants.ConnectionStateLazy), nil, false, constants.ConnectionStateLazy},
+		"forced connection is imported":    {newState("aws", constants.ConnectionStateLazy), []string{"aws"}, true, constants.ConnectionStateReady},
+		"imported connection is unchanged": {newState("aws", constants.ConnectionStateReady), nil, false, constants.ConnectionStateReady},
+	}
+	for name, test := range testCases {
+		current := ConnectionStateMap{}
+		if test.current != nil {
+			current["aws"] = test.current
+		}
+		updates := &ConnectionUpdates{
+			Update:                   ConnectionStateMap{},
+			Lazy:                     make(map[string]struct{}),
+			CurrentConnectionState:   current,
+			FinalConnectionState:     ConnectionStateMap{"aws": newState("aws", constants.ConnectionStateReady)},
+			PluginsWithUpdatedBinary: make(map[string]string),
+		}
+		updates.identifyConnectionsToUpdate(&connectionUpdatesConfig{ForceUpdateConnectionNames: test.forceUpdateConnectionNames}, time.Now())
+
+		if _, ok := updates.Update["aws"]; ok != test.expectUpdate {
+			t.Errorf("Test: '%s' FAILED : expected update %v, got %v", name, test.expectUpdate, ok)
+		}
+		if _, ok := updates.Lazy["aws"]; ok != (test.expectedState == constants.ConnectionStateLazy) {
+			t.Errorf("Test: '%s' FAILED : unexpected lazy connections %v", name, updates.Lazy)
+		}
+		if actual := updates.FinalConnectionState["aws"].State; actual != test.expectedState {
+			t.Errorf("Test: '%s' FAILED : expected state '%s', got '%s'", name, test.expectedState, actual)
+		}
+	}
+}
@@ -5,26 +5,94 @@ import (
 	"log"
 	"strings"
 
+	"github.com/turbot/go-kit/helpers"
 	sdkversion "github.com/turbot/steampipe-plugin-sdk/v5/version"
 	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
 	"github.com/turbot/steampipe/pkg/utils"
 )
 
 func (u *ConnectionUpdates) validate() {
-	// find any plugins which use a newer sdk version than steampipe, and any connections with an invalid name
+	// find any plugins which use a newer sdk version than steampipe, any connections with an invalid name
+	// and any connections with invalid config
 	u.validatePluginsAndConnections()
 	u.validateUpdates()
 }
 
+// InvalidConnectionWarning returns a warning string describing all connections which failed validation,
+// or an empty string if there are none
+func (u *ConnectionUpdates) InvalidConnectionWarning() string {
+	failures := make([]*ValidationFailure, 0, len(u.InvalidConnections))
+	for _, connectionName := range utils.SortedMapKeys(u.InvalidConnections) {
+		failures = append(failures, u.InvalidConnections[connectionName])
+	}
+	return BuildValidationWarningString(failures)
+}
+
+// IgnoredValidationWarning returns a warning string describing all validation failures which were ignored
+// because plugin validation was skipped, or an empty string if there are none
+func (u *ConnectionUpdates) IgnoredValidationWarning() string {
+	if len(u.IgnoredValidationFailures) == 0 {
+		return ""
+	}
+	warningsStrings := []string{}
+	for _, connectionName := range utils.SortedMapKeys(u.IgnoredValidationFailures) {
+		warningsStrings = append(warningsStrings, u.IgnoredValidationFailures[connectionName].String())
+	}
+	failureCount := len(u.IgnoredValidationFailures)
+	return fmt.Sprintf(`
+
+%s
+
+%s
+
+%d %s imported as '--%s' is set.
+`,
+		constants.Yellow(fmt.Sprintf("%d Connection Validation %s Ignored", failureCount, utils.Pluralize("Error", failureCount))),
+		strings.Join(warningsStrings, "\n\n"),
+		failureCount,
+		utils.Pluralize("connection", failureCount),
+		constants.ArgSkipPluginValidation)
+}
+
+// UnknownForceUpdateConnectionWarning returns a warning listing any connections passed to WithForceUpdate
+// which are not configured, or an empty string if there are none
+// (these are ignored - usually because the connection name was misspelled)
+func (u *ConnectionUpdates) UnknownForceUpdateConnectionWarning() string {
+	var unknown []string
+	for _, name := range u.forceUpdateConnectionNames {
+		if _, ok := u.FinalConnectionState[name]; !ok && !helpers.StringSliceContains(unknown, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ignoring forced update of %d unknown %s: %s",
+		len(unknown),
+		utils.Pluralize("connection", len(unknown)),
+		strings.Join(unknown, ", "))
+}
+
 func (u *ConnectionUpdates) validatePluginsAndConnections() {
 	// TODO should plugin manager do this when starting the plugin???
 	var validatedPlugins = make(map[string]*ConnectionPlugin)
 
 	for connectionName, connectionPlugin := range u.ConnectionPlugins {
-		if validationFailure := validateProtocolVersion(connectionName, connectionPlugin); validationFailure != nil {
+		validationFailure := validateProtocolVersion(connectionName, connectionPlugin)
+		if validationFailure != nil && u.skipPluginValidation {
+			// plugin validation is disabled - record the failure so it can be reported, but still import the connection
+			log.Printf("[WARN] ignoring validation failure for connection '%s' as plugin validation is disabled: %s", connectionName, validationFailure.Message)
+			u.IgnoredValidationFailures[connectionName] = validationFailure
+			validationFailure = nil
+		}
+
+		if validationFailure != nil {
 			u.InvalidConnections[connectionName] = validationFailure
 		} else if validationFailure := validateConnectionName(connectionName, connectionPlugin); validationFailure != nil {
 			u.InvalidConnections[connectionName] = validationFailure
+		} else if validationFailure := validateConnectionConfig(GlobalConfig.Connections[connectionName], connectionPlugin); validationFailure != nil {
+			u.InvalidConnections[connectionName] = validationFailure
 		} else {
 			validatedPlugins[connectionName] = connectionPlugin
 		}
@@ -83,6 +151,38 @@ func validateConnectionName(connectionName string, p *ConnectionPlugin) *Validat
 	return nil
 }
 
+// validateConnectionConfig checks the connection options declared for a connection, and whether the plugin accepted
+// the connection config
+// the config is validated against the plugin config schema by the plugin itself - if it is rejected, the plugin
+// returns an error rather than a schema for the connection (see populateConnectionPluginSchemas)
+// this is done before any schema is imported, so an invalid connection is skipped rather than failing mid-import
+func validateConnectionConfig(connection *modconfig.Connection, p *ConnectionPlugin) *ValidationFailure {
+	// aggregators are validated by validating their children
+	if connection == nil || connection.Type == modconfig.ConnectionTypeAggregator {
+		return nil
+	}
+
+	var problems []string
+	if opts := connection.Options; opts != nil && opts.CacheTTL != nil && *opts.CacheTTL < 0 {
+		problems = append(problems, fmt.Sprintf("invalid value %d for option 'cache_ttl', must not be negative", *opts.CacheTTL))
+	}
+
+	if connectionData, ok := p.ConnectionMap[connection.Name]; ok && connectionData.ConfigError != "" {
+		problems = append(problems, fmt.Sprintf("plugin rejected config: %s", connectionData.ConfigError))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationFailure{
+		Plugin:         p.PluginName,
+		ConnectionName: connection.Name,
+		Message:        strings.Join(problems, "; "),
+		// drop this connection if it exists - its schema may be based on stale config
+		ShouldDropIfExists: true,
+	}
+}
+
 func validateProtocolVersion(connectionName string, p *ConnectionPlugin) *ValidationFailure {
 	pluginProtocolVersion := p.ConnectionMap[connectionName].Schema.GetProtocolVersion()
 	// if this is 0, the plugin does not define a protocol version
@@ -0,0 +1,158 @@
+package steampipeconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	sdkversion "github.com/turbot/steampipe-plugin-sdk/v5/version"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/options"
+)
+
+type validateConnectionConfigTest struct {
+	connection *modconfig.Connection
+	// the error returned by the plugin if it rejected the connection config
+	configError string
+	expectValid bool
+}
+
+var negativeCacheTTL = -1
+
+var testCasesValidateConnectionConfig = map[string]validateConnectionConfigTest{
+	"valid": {
+		connection: &modconfig.Connection{
+			Name:   "good",
+			Config: `regions = ["us-east-1"]`,
+		},
+		expectValid: true,
+	},
+	"invalid option": {
+		connection: &modconfig.Connection{
+			Name:    "bad_option",
+			Options: &options.Connection{CacheTTL: &negativeCacheTTL},
+		},
+		expectValid: false,
+	},
+	"plugin rejected config": {
+		connection: &modconfig.Connection{
+			Name:   "rejected",
+			Config: `regions = "us-east-1"`,
+		},
+		configError: `Unsuitable value type: list of string required`,
+		expectValid: false,
+	},
+	"aggregator": {
+		connection: &modconfig.Connection{
+			Name: "all",
+			Type: modconfig.ConnectionTypeAggregator,
+		},
+		configError: "no connection data loaded for connection 'all'",
+		expectValid: true,
+	},
+}
+
+func TestValidateConnectionConfig(t *testing.T) {
+	for name, test := range testCasesValidateConnectionConfig {
+		connectionData := &ConnectionPluginData{Name: test.connection.Name, ConfigError: test.configError}
+		if test.configError == "" {
+			connectionData.Schema = &proto.Schema{}
+		}
+		p := &ConnectionPlugin{
+			PluginName:    "test",
+			ConnectionMap: map[string]*ConnectionPluginData{test.connection.Name: connectionData},
+		}
+
+		failure := validateConnectionConfig(test.connection, p)
+		if test.expectValid && failure != nil {
+			t.Errorf("Test: '%s' FAILED : expected connection to be valid, got failure: %s", name, failure.Message)
+			continue
+		}
+		if !test.expectValid {
+			if failure == nil {
+				t.Errorf("Test: '%s' FAILED : expected validation failure", name)
+				continue
+			}
+			if failure.ConnectionName != test.connection.Name || !failure.ShouldDropIfExists {
+				t.Errorf("Test: '%s' FAILED : unexpected validation failure %+v", name, failure)
+			}
+			if test.configError != "" && !strings.Contains(failure.Message, test.configError) {
+				t.Errorf("Test: '%s' FAILED : expected the failure to include the plugin error, got: %s", name, failure.Message)
+			}
+		}
+	}
+}
+
+func TestValidateSkipPluginValidation(t *testing.T) {
+	// set global config to contain a connection for a plugin using a newer sdk than Steampipe
+	prevConfig := GlobalConfig
+	defer func() { GlobalConfig = prevConfig }()
+	GlobalConfig = &SteampipeConfig{
+		Connections: map[string]*modconfig.Connection{"newer_sdk": {Name: "newer_sdk"}},
+	}
+
+	for _, skip := range []bool{false, true} {
+		updates := &ConnectionUpdates{
+			Update:          ConnectionStateMap{"newer_sdk": {ConnectionName: "newer_sdk"}},
+			MissingComments: ConnectionStateMap{},
+			ConnectionPlugins: map[string]*ConnectionPlugin{
+				"newer_sdk": {
+					PluginName: "test",
+					ConnectionMap: map[string]*ConnectionPluginData{
+						"newer_sdk": {Name: "newer_sdk", Schema: &proto.Schema{ProtocolVersion: sdkversion.ProtocolVersion + 1}},
+					},
+				},
+			},
+			InvalidConnections:        make(map[string]*ValidationFailure),
+			IgnoredValidationFailures: make(map[string]*ValidationFailure),
+			skipPluginValidation:      skip,
+		}
+		updates.validate()
+
+		_, imported := updates.Update["newer_sdk"]
+		if imported != skip {
+			t.Errorf("Test: skipPluginValidation=%v FAILED : expected imported=%v, got %v", skip, skip, imported)
+		}
+		// the failure must always be reported
+		if len(updates.InvalidConnections)+len(updates.IgnoredValidationFailures) != 1 {
+			t.Errorf("Test: skipPluginValidation=%v FAILED : expected a single validation failure to be reported", skip)
+		}
+		if skip && updates.IgnoredValidationWarning() == "" {
+			t.Errorf("Test: skipPluginValidation=%v FAILED : expected a warning for the ignored validation failure", skip)
+		}
+	}
+}
+
+func TestValidateRejectedConfig(t *testing.T) {
+	// the plugin returns no schema for a connection whose config it rejected
+	prevConfig := GlobalConfig
+	defer func() { GlobalConfig = prevConfig }()
+	GlobalConfig = &SteampipeConfig{
+		Connections: map[string]*modconfig.Connection{"good": {Name: "good"}, "rejected": {Name: "rejected"}},
+	}
+	connectionPlugin := &ConnectionPlugin{
+		PluginName: "test",
+		ConnectionMap: map[string]*ConnectionPluginData{
+			"good":     {Name: "good", Schema: &proto.Schema{}},
+			"rejected": {Name: "rejected", ConfigError: "invalid config"},
+		},
+	}
+	updates := &ConnectionUpdates{
+		Update:                    ConnectionStateMap{"good": {ConnectionName: "good"}, "rejected": {ConnectionName: "rejected"}},
+		MissingComments:           ConnectionStateMap{},
+		ConnectionPlugins:         map[string]*ConnectionPlugin{"good": connectionPlugin, "rejected": connectionPlugin},
+		InvalidConnections:        make(map[string]*ValidationFailure),
+		IgnoredValidationFailures: make(map[string]*ValidationFailure),
+	}
+	updates.validate()
+
+	if _, ok := updates.Update["rejected"]; ok {
+		t.Error("expected the connection with rejected config not to be imported")
+	}
+	if _, ok := updates.Update["good"]; !ok {
+		t.Error("expected the valid connection to be imported")
+	}
+	if failure, ok := updates.InvalidConnections["rejected"]; !ok || !strings.Contains(failure.Message, "invalid config") {
+		t.Errorf("expected a validation failure including the plugin error, got %+v", failure)
+	}
+}
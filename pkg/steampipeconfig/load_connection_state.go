@@ -28,6 +28,10 @@ func LoadConnectionState(ctx context.Context, conn *pgx.Conn, opts ...LoadConnec
 	for _, opt := range opts {
 		opt(config)
 	}
+	table := ConnectionStateTableFromConfig()
+	if config.Table != nil {
+		table = *config.Table
+	}
 
 	// max duration depends on if waiting for ready or just pending
 	// default value is if we are waiting for pending
@@ -45,7 +49,7 @@ func LoadConnectionState(ctx context.Context, conn *pgx.Conn, opts ...LoadConnec
 
 	err := retry.Do(ctx, retry.WithMaxDuration(maxDuration, backoff), func(ctx context.Context) error {
 		var loadErr error
-		connectionStateMap, loadErr = loadConnectionState(ctx, conn)
+		connectionStateMap, loadErr = loadConnectionState(ctx, conn, table)
 		if loadErr != nil {
 			return loadErr
 		}
@@ -92,7 +96,7 @@ func LoadConnectionState(ctx context.Context, conn *pgx.Conn, opts ...LoadConnec
 	return connectionStateMap, err
 }
 
-func loadConnectionState(ctx context.Context, conn *pgx.Conn, opts ...loadConnectionStateOption) (ConnectionStateMap, error) {
+func loadConnectionState(ctx context.Context, conn *pgx.Conn, table ConnectionStateTable, opts ...loadConnectionStateOption) (ConnectionStateMap, error) {
 	config := &loadConnectionStateConfig{}
 	for _, configOption := range opts {
 		configOption(config)
@@ -101,11 +105,7 @@ func loadConnectionState(ctx context.Context, conn *pgx.Conn, opts ...loadConnec
 
 	var res = make(ConnectionStateMap)
 
-	query := fmt.Sprintf(
-		`select * FROM %s.%s `,
-		constants.InternalSchema,
-		constants.ConnectionTable,
-	)
+	query := fmt.Sprintf(`select * FROM %s `, table.Identifier())
 	legacyQuery := fmt.Sprintf(
 		`select * FROM %s.%s `,
 		constants.InternalSchema,
@@ -114,7 +114,8 @@ func loadConnectionState(ctx context.Context, conn *pgx.Conn, opts ...loadConnec
 
 	rows, err := conn.Query(ctx, query)
 	if err != nil {
-		if !db_common.IsRelationNotFoundError(err) {
+		// only the default table has a legacy table to fall back to
+		if !db_common.IsRelationNotFoundError(err) || !table.IsDefault() {
 			return nil, err
 		}
 		// so it was a relation not found - try with legacy table
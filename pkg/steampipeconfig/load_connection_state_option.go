@@ -13,6 +13,8 @@ type LoadConnectionStateConfiguration struct {
 	WaitMode    WaitModeValue
 	Connections []string
 	SearchPath  []string
+	// the table to load the connection state from - if not set, the configured table is used
+	Table *ConnectionStateTable
 }
 
 type LoadConnectionStateOption = func(config *LoadConnectionStateConfiguration)
@@ -38,3 +40,10 @@ var WithWaitUntilReady = func(connections ...string) func(config *LoadConnection
 		config.WaitMode = WaitForReady
 	}
 }
+
+// WithConnectionStateTable loads the connection state from the given table, rather than the configured table
+var WithConnectionStateTable = func(table ConnectionStateTable) func(config *LoadConnectionStateConfiguration) {
+	return func(config *LoadConnectionStateConfiguration) {
+		config.Table = &table
+	}
+}
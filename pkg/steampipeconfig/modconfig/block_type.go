@@ -38,6 +38,7 @@ const (
 	BlockTypeConnection       = "connection"
 	BlockTypeOptions          = "options"
 	BlockTypeWorkspaceProfile = "workspace"
+	BlockTypeWaiver           = "waiver"
 
 	ResourceTypeSnapshot = "snapshot"
 	AttributeArgs        = "args"
@@ -5,6 +5,8 @@ import (
 	"log"
 	"path"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
@@ -21,9 +23,11 @@ const (
 	ConnectionTypeAggregator = "aggregator"
 	ImportSchemaEnabled      = "enabled"
 	ImportSchemaDisabled     = "disabled"
+	// ImportSchemaLazy defers importing the schema until the connection is first queried
+	ImportSchemaLazy = "lazy"
 )
 
-var ValidImportSchemaValues = []string{ImportSchemaEnabled, ImportSchemaDisabled}
+var ValidImportSchemaValues = []string{ImportSchemaEnabled, ImportSchemaDisabled, ImportSchemaLazy}
 
 // Connection is a struct representing the partially parsed connection
 //
@@ -45,17 +49,35 @@ type Connection struct {
 	PluginPath *string
 	// connection type - supported values: "aggregator"
 	Type string `json:"type,omitempty"`
-	// should a schema be created for this connection - supported values: "enabled", "disabled"
+	// should a schema be created for this connection - supported values: "enabled", "disabled", "lazy"
 	ImportSchema string `json:"import_schema"`
+	// should comments be set on the schema tables and columns - if not set, the global schema comments setting is used
+	// (set with the steampipe_schema_comments attribute)
+	SchemaComments *bool `json:"schema_comments,omitempty"`
+	// the maximum number of concurrent plugin calls made for this connection
+	MaxConcurrency *int64 `json:"max_concurrency,omitempty"`
+	// the maximum number of plugin calls per second made for this connection
+	RateLimit *float32 `json:"rate_limit,omitempty"`
 	// list of names or wildcards which are resolved to connections
 	// (only valid for "aggregator" type)
 	ConnectionNames []string `json:"connections,omitempty"`
+	// list of regular expressions - connections with a name matching any of these are children of the aggregator
+	// (only valid for "aggregator" type)
+	ConnectionRegexes []string `json:"connections_regex,omitempty"`
+	// map of tags - connections with all of these tags (see Tags) are children of the aggregator
+	// (only valid for "aggregator" type)
+	ConnectionTags map[string]string `json:"connections_tags,omitempty"`
 	// a map of the resolved child connections
 	// (only valid for "aggregator" type)
 	Connections map[string]*Connection `json:"-"`
 	// a list of the names resolved child connections
 	// (only valid for "aggregator" type)
 	ResolvedConnectionNames []string `json:"resolved_connections,omitempty"`
+	// names of connections which must be updated before this connection
+	DependsOn []string `json:"depends_on,omitempty"`
+	// arbitrary key/value metadata - this is included in the schema comment
+	// (set with the steampipe_tags attribute, so plugins may still have a tags config property)
+	Tags map[string]string `json:"tags,omitempty"`
 	// unparsed HCL of plugin specific connection config
 	Config string `json:"config,omitempty"`
 
@@ -140,9 +162,15 @@ func (c *Connection) Equals(other *Connection) bool {
 		c.Plugin == other.Plugin &&
 		c.Type == other.Type &&
 		strings.Join(c.ConnectionNames, ",") == strings.Join(other.ConnectionNames, ",") &&
+		strings.Join(c.ConnectionRegexes, ",") == strings.Join(other.ConnectionRegexes, ",") &&
+		maps.Equal(c.ConnectionTags, other.ConnectionTags) &&
 		connectionOptionsEqual &&
 		c.Config == other.Config &&
-		c.ImportSchema == other.ImportSchema
+		c.ImportSchema == other.ImportSchema &&
+		reflect.DeepEqual(c.SchemaComments, other.SchemaComments) &&
+		pointersHaveSameValue(c.MaxConcurrency, other.MaxConcurrency) &&
+		pointersHaveSameValue(c.RateLimit, other.RateLimit) &&
+		maps.Equal(c.Tags, other.Tags)
 
 }
 
@@ -186,16 +214,34 @@ func (c *Connection) Validate(map[string]*Connection) (warnings []string, errors
 	if len(c.ConnectionNames) != 0 {
 		validationErrors = append(validationErrors, fmt.Sprintf("connection '%s' has %d children, but is not of type 'aggregator'", c.Name, len(c.ConnectionNames)))
 	}
+	if len(c.ConnectionRegexes) != 0 || len(c.ConnectionTags) != 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("connection '%s' sets connections_regex or connections_tags, but is not of type 'aggregator'", c.Name))
+	}
 	validImportSchemaValues := utils.SliceToLookup(ValidImportSchemaValues)
 	if _, isValid := validImportSchemaValues[c.ImportSchema]; !isValid {
 		validationErrors = append(validationErrors, fmt.Sprintf("invalid value '%s'for import_schema, must be one of ['%s']", c.ImportSchema, strings.Join(ValidImportSchemaValues, "','")))
 	}
+	validationErrors = append(validationErrors, c.validateRateLimits()...)
 
 	return nil, validationErrors
 
 }
 
 func (c *Connection) ValidateAggregatorConnection() (warnings, errors []string) {
+	// invalid regular expressions are an error - an aggregator which silently matched nothing would be confusing
+	for _, childRegex := range c.ConnectionRegexes {
+		if _, err := regexp.Compile(childRegex); err != nil {
+			errors = append(errors, fmt.Sprintf("aggregator connection '%s' has invalid connections_regex '%s': %s", c.Name, childRegex, err.Error()))
+		}
+	}
+	// the plugin calls for an aggregator are made for its children, so limits must be set on the child connections
+	if c.MaxConcurrency != nil || c.RateLimit != nil {
+		errors = append(errors, fmt.Sprintf("aggregator connection '%s' sets max_concurrency or rate_limit - these must be set on its child connections", c.Name))
+	}
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
 	if len(c.Connections) == 0 {
 		/// there should be at least one connection - raise as warning
 		return []string{c.GetEmptyAggregatorError()}, nil
@@ -221,6 +267,9 @@ func (c *Connection) ValidateAggregatorConnection() (warnings, errors []string)
 
 func (c *Connection) GetEmptyAggregatorError() string {
 	patterns := c.ConnectionNames
+	if len(c.ConnectionRegexes) > 0 || len(c.ConnectionTags) > 0 {
+		return fmt.Sprintf("aggregator '%s' with %s matches no connections", c.Name, c.childMatchersString())
+	}
 	if len(patterns) == 0 {
 		return fmt.Sprintf("aggregator '%s' defines no child connections", c.Name)
 	}
@@ -274,10 +323,88 @@ func (c *Connection) PopulateChildren(connectionMap map[string]*Connection) []st
 			}
 		}
 	}
+	// add connections with a name matching a regex, or with matching tags
+	if len(c.ConnectionRegexes) > 0 || len(c.ConnectionTags) > 0 {
+		failures = append(failures, c.populateMatchingChildren(connectionMap)...)
+	}
+
 	c.ResolvedConnectionNames = maps.Keys(c.Connections)
+	sort.Strings(c.ResolvedConnectionNames)
+	return failures
+}
+
+// populateMatchingChildren adds all connections whose name matches any of the connections_regex expressions,
+// or which have all of the connections_tags tags
+// as with wildcards, aggregators and connections using a different plugin instance are never matched
+func (c *Connection) populateMatchingChildren(connectionMap map[string]*Connection) []string {
+	var failures []string
+	var regexes []*regexp.Regexp
+	for _, childRegex := range c.ConnectionRegexes {
+		re, err := regexp.Compile(childRegex)
+		if err != nil {
+			// this is reported as a validation error
+			failures = append(failures, fmt.Sprintf("aggregator connection %s has invalid connections_regex '%s'", c.Name, childRegex))
+			continue
+		}
+		regexes = append(regexes, re)
+	}
+
+	for name, connection := range connectionMap {
+		if connection.Type == ConnectionTypeAggregator || connection.PluginInstance != c.PluginInstance {
+			continue
+		}
+		if _, ok := c.Connections[name]; ok {
+			continue
+		}
+		if matchesAnyRegex(name, regexes) || c.matchesConnectionTags(connection) {
+			c.Connections[name] = connection
+			log.Printf("[TRACE] connection '%s' matches aggregator '%s'", name, c.Name)
+		}
+	}
 	return failures
 }
 
+func matchesAnyRegex(name string, regexes []*regexp.Regexp) bool {
+	for _, re := range regexes {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesConnectionTags returns whether the connection has all of the connections_tags tags
+func (c *Connection) matchesConnectionTags(connection *Connection) bool {
+	if len(c.ConnectionTags) == 0 {
+		return false
+	}
+	for k, v := range c.ConnectionTags {
+		if tag, ok := connection.Tags[k]; !ok || tag != v {
+			return false
+		}
+	}
+	return true
+}
+
+// childMatchersString returns a description of all the ways this aggregator matches child connections
+func (c *Connection) childMatchersString() string {
+	var matchers []string
+	if len(c.ConnectionNames) > 0 {
+		matchers = append(matchers, fmt.Sprintf("connections ['%s']", strings.Join(c.ConnectionNames, "','")))
+	}
+	if len(c.ConnectionRegexes) > 0 {
+		matchers = append(matchers, fmt.Sprintf("connections_regex ['%s']", strings.Join(c.ConnectionRegexes, "','")))
+	}
+	if len(c.ConnectionTags) > 0 {
+		var tags []string
+		for _, k := range utils.SortedMapKeys(c.ConnectionTags) {
+			tags = append(tags, fmt.Sprintf("%s=%s", k, c.ConnectionTags[k]))
+		}
+		matchers = append(matchers, fmt.Sprintf("connections_tags [%s]", strings.Join(tags, ",")))
+	}
+	return strings.Join(matchers, " and ")
+}
+
 // GetResolveConnectionNames return the names of all child connections
 // (will only be non-empty for aggregator connections)
 func (c *Connection) GetResolveConnectionNames() []string {
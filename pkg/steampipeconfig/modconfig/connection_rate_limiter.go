@@ -0,0 +1,56 @@
+package modconfig
+
+import (
+	"fmt"
+	"math"
+
+	typehelpers "github.com/turbot/go-kit/types"
+)
+
+// ConnectionLimiterName returns the name of the limiter created for a connection with max_concurrency or rate_limit set
+func ConnectionLimiterName(connectionName string) string {
+	return fmt.Sprintf("connection_%s", connectionName)
+}
+
+// RateLimiter returns a limiter which applies the max_concurrency and rate_limit of the connection to the plugin calls
+// made for the connection, or nil if neither is set
+//
+// the limiter is scoped to the connection, so the plugin applies it to this connection only
+func (c *Connection) RateLimiter() *RateLimiter {
+	if c.MaxConcurrency == nil && c.RateLimit == nil {
+		return nil
+	}
+	where := fmt.Sprintf("connection = '%s'", c.Name)
+	l := &RateLimiter{
+		Name:            ConnectionLimiterName(c.Name),
+		MaxConcurrency:  c.MaxConcurrency,
+		Scope:           []string{"connection"},
+		Where:           &where,
+		PluginInstance:  typehelpers.SafeString(c.PluginInstance),
+		FileName:        &c.DeclRange.Filename,
+		StartLineNumber: &c.DeclRange.Start.Line,
+		EndLineNumber:   &c.DeclRange.End.Line,
+		Status:          LimiterStatusActive,
+		Source:          LimiterSourceConnection,
+	}
+	if c.RateLimit != nil {
+		fillRate := *c.RateLimit
+		// allow a burst of up to one second of calls
+		bucketSize := int64(math.Max(1, math.Ceil(float64(fillRate))))
+		l.FillRate = &fillRate
+		l.BucketSize = &bucketSize
+	}
+	l.setPluginImageRef(c.Plugin)
+	return l
+}
+
+func (c *Connection) validateRateLimits() []string {
+	var validationErrors []string
+	if c.MaxConcurrency != nil && *c.MaxConcurrency <= 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("connection '%s' has invalid max_concurrency %d, must be greater than zero", c.Name, *c.MaxConcurrency))
+	}
+	if c.RateLimit != nil && *c.RateLimit <= 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("connection '%s' has invalid rate_limit %v, must be greater than zero", c.Name, *c.RateLimit))
+	}
+	return validationErrors
+}
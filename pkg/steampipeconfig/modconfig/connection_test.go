@@ -1,6 +1,9 @@
 package modconfig
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 type connectionEquality struct {
 	connection1 *Connection
@@ -36,3 +39,109 @@ func TestConnectionEquals(t *testing.T) {
 		}
 	}
 }
+
+type populateChildrenTest struct {
+	aggregator *Connection
+	expected   []string
+	// expected validation error count
+	expectedErrors int
+}
+
+var populateChildrenConnections = map[string]*Connection{
+	"aws_dev":   {Name: "aws_dev", Type: ConnectionTypePlugin, Tags: map[string]string{"env": "dev"}},
+	"aws_prod":  {Name: "aws_prod", Type: ConnectionTypePlugin, Tags: map[string]string{"env": "prod", "team": "ops"}},
+	"aws_prod2": {Name: "aws_prod2", Type: ConnectionTypePlugin, Tags: map[string]string{"env": "prod"}},
+	"gcp_prod":  {Name: "gcp_prod", Type: ConnectionTypePlugin, PluginInstance: &gcpInstance, Tags: map[string]string{"env": "prod"}},
+	"aws_all":   {Name: "aws_all", Type: ConnectionTypeAggregator, ConnectionNames: []string{"*"}},
+}
+
+var gcpInstance = "gcp"
+
+var populateChildrenCases = map[string]populateChildrenTest{
+	"wildcard": {
+		aggregator: &Connection{Name: "agg", Type: ConnectionTypeAggregator, ConnectionNames: []string{"aws_prod*"}},
+		expected:   []string{"aws_prod", "aws_prod2"},
+	},
+	"regex": {
+		aggregator: &Connection{Name: "agg", Type: ConnectionTypeAggregator, ConnectionRegexes: []string{"^aws_(dev|prod)$"}},
+		expected:   []string{"aws_dev", "aws_prod"},
+	},
+	"tags": {
+		aggregator: &Connection{Name: "agg", Type: ConnectionTypeAggregator, ConnectionTags: map[string]string{"env": "prod"}},
+		// gcp_prod uses a different plugin instance
+		expected: []string{"aws_prod", "aws_prod2"},
+	},
+	"all tags must match": {
+		aggregator: &Connection{Name: "agg", Type: ConnectionTypeAggregator, ConnectionTags: map[string]string{"env": "prod", "team": "ops"}},
+		expected:   []string{"aws_prod"},
+	},
+	"names, regex and tags combined": {
+		aggregator: &Connection{Name: "agg", Type: ConnectionTypeAggregator, ConnectionNames: []string{"aws_dev"}, ConnectionRegexes: []string{"prod2$"}, ConnectionTags: map[string]string{"team": "ops"}},
+		expected:   []string{"aws_dev", "aws_prod", "aws_prod2"},
+	},
+	"invalid regex": {
+		aggregator:     &Connection{Name: "agg", Type: ConnectionTypeAggregator, ConnectionRegexes: []string{"aws_("}},
+		expected:       []string{},
+		expectedErrors: 1,
+	},
+}
+
+func TestPopulateChildren(t *testing.T) {
+	for caseName, caseData := range populateChildrenCases {
+		caseData.aggregator.PopulateChildren(populateChildrenConnections)
+		if strings.Join(caseData.aggregator.ResolvedConnectionNames, ",") != strings.Join(caseData.expected, ",") {
+			t.Errorf(`Test: '%s' FAILED: expected: %v, actual: %v`, caseName, caseData.expected, caseData.aggregator.ResolvedConnectionNames)
+		}
+		_, errors := caseData.aggregator.Validate(populateChildrenConnections)
+		if len(errors) != caseData.expectedErrors {
+			t.Errorf(`Test: '%s' FAILED: expected %d validation errors, actual: %v`, caseName, caseData.expectedErrors, errors)
+		}
+	}
+}
+
+func TestValidateMatchersOnPluginConnection(t *testing.T) {
+	c := &Connection{Name: "aws", Type: ConnectionTypePlugin, ImportSchema: ImportSchemaEnabled, ConnectionTags: map[string]string{"env": "prod"}}
+	if _, errors := c.Validate(nil); len(errors) != 1 {
+		t.Errorf("expected connections_tags on a plugin connection to be an error, got %v", errors)
+	}
+}
+
+func TestConnectionRateLimiter(t *testing.T) {
+	maxConcurrency := int64(5)
+	rateLimit := float32(2.5)
+	pluginInstance := "aws"
+
+	if l := (&Connection{Name: "aws_dev"}).RateLimiter(); l != nil {
+		t.Errorf("expected no limiter for a connection without limits, got %+v", l)
+	}
+
+	c := &Connection{Name: "aws_dev", Plugin: "hub.steampipe.io/plugins/turbot/aws@latest", PluginInstance: &pluginInstance, MaxConcurrency: &maxConcurrency, RateLimit: &rateLimit}
+	l := c.RateLimiter()
+	if l == nil {
+		t.Fatal("expected a limiter")
+	}
+	if l.Name != "connection_aws_dev" || l.Source != LimiterSourceConnection || l.PluginInstance != "aws" || l.Plugin != c.Plugin {
+		t.Errorf("unexpected limiter %+v", l)
+	}
+	if l.Where == nil || *l.Where != "connection = 'aws_dev'" || strings.Join(l.Scope, ",") != "connection" {
+		t.Errorf("expected the limiter to be scoped to the connection, got scope %v where %v", l.Scope, l.Where)
+	}
+	if *l.MaxConcurrency != 5 || *l.FillRate != 2.5 || *l.BucketSize != 3 {
+		t.Errorf("expected max concurrency 5, fill rate 2.5 and bucket size 3, got %d, %v and %d", *l.MaxConcurrency, *l.FillRate, *l.BucketSize)
+	}
+}
+
+func TestValidateRateLimits(t *testing.T) {
+	zero := int64(0)
+	negative := float32(-1)
+	c := &Connection{Name: "aws", Type: ConnectionTypePlugin, ImportSchema: ImportSchemaEnabled, MaxConcurrency: &zero, RateLimit: &negative}
+	if _, errors := c.Validate(nil); len(errors) != 2 {
+		t.Errorf("expected invalid max_concurrency and rate_limit to be errors, got %v", errors)
+	}
+
+	maxConcurrency := int64(5)
+	aggregator := &Connection{Name: "aws_all", Type: ConnectionTypeAggregator, MaxConcurrency: &maxConcurrency}
+	if _, errors := aggregator.Validate(nil); len(errors) != 1 {
+		t.Errorf("expected max_concurrency on an aggregator to be an error, got %v", errors)
+	}
+}
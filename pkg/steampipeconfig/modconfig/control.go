@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/turbot/go-kit/types"
 	typehelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/hclhelpers"
@@ -21,6 +22,10 @@ type Control struct {
 	Remain hcl.Body `hcl:",remain" json:"-"`
 
 	Severity *string `cty:"severity" hcl:"severity"  column:"severity,text" json:"severity,omitempty"`
+	// the maximum time to run the control query for, in seconds (including retries)
+	Timeout *int `cty:"timeout" hcl:"timeout" column:"timeout,integer" json:"timeout,omitempty"`
+	// the number of times to retry the control query if it fails
+	Retries *int `cty:"retries" hcl:"retries" column:"retries,integer" json:"retries,omitempty"`
 
 	// dashboard specific properties
 	Base    *Control `hcl:"base" json:"-"`
@@ -164,7 +169,26 @@ func (c *Control) GetParentNames() []string {
 func (c *Control) OnDecoded(block *hcl.Block, resourceMapProvider ResourceMapsProvider) hcl.Diagnostics {
 	c.setBaseProperties()
 
-	return c.QueryProviderImpl.OnDecoded(block, resourceMapProvider)
+	diags := validateControlRunPolicy(block, "timeout", c.Timeout, "retries", c.Retries)
+	return append(diags, c.QueryProviderImpl.OnDecoded(block, resourceMapProvider)...)
+}
+
+// GetTimeout returns the timeout of the control in seconds - if the control does not set a timeout,
+// the default control timeout of its mod is used (nil if neither is set)
+func (c *Control) GetTimeout() *int {
+	if c.Timeout == nil && c.Mod != nil {
+		return c.Mod.ControlTimeout
+	}
+	return c.Timeout
+}
+
+// GetRetries returns the number of times to retry the control query - if the control does not set retries,
+// the default control retries of its mod is used (0 if neither is set)
+func (c *Control) GetRetries() int {
+	if c.Retries == nil && c.Mod != nil {
+		return typehelpers.IntValue(c.Mod.ControlRetries)
+	}
+	return typehelpers.IntValue(c.Retries)
 }
 
 // GetWidth implements DashboardLeafNode
@@ -200,6 +224,12 @@ func (c *Control) Diff(other *Control) *DashboardTreeItemDiffs {
 	if !utils.SafeStringsEqual(c.Severity, other.Severity) {
 		res.AddPropertyDiff("Severity")
 	}
+	if !utils.SafeIntEqual(c.Timeout, other.Timeout) {
+		res.AddPropertyDiff("Timeout")
+	}
+	if !utils.SafeIntEqual(c.Retries, other.Retries) {
+		res.AddPropertyDiff("Retries")
+	}
 	if len(c.Tags) != len(other.Tags) {
 		res.AddPropertyDiff("Tags")
 	} else {
@@ -233,6 +263,12 @@ func (c *Control) setBaseProperties() {
 	if c.Severity == nil {
 		c.Severity = c.Base.Severity
 	}
+	if c.Timeout == nil {
+		c.Timeout = c.Base.Timeout
+	}
+	if c.Retries == nil {
+		c.Retries = c.Base.Retries
+	}
 
 	if c.Width == nil {
 		c.Width = c.Base.Width
@@ -244,3 +280,29 @@ func (c *Control) setBaseProperties() {
 		c.Display = c.Base.Display
 	}
 }
+
+// validateControlRunPolicy verifies the timeout and retries of a control (or the control defaults of a mod) are not negative
+func validateControlRunPolicy(block *hcl.Block, timeoutAttr string, timeout *int, retriesAttr string, retries *int) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	attrs := []struct {
+		name  string
+		value *int
+	}{{timeoutAttr, timeout}, {retriesAttr, retries}}
+	for _, attr := range attrs {
+		if attr.value == nil || *attr.value >= 0 {
+			continue
+		}
+		subject := hclhelpers.BlockRangePointer(block)
+		if body, ok := block.Body.(*hclsyntax.Body); ok {
+			if a, ok := body.Attributes[attr.name]; ok {
+				subject = &a.SrcRange
+			}
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("'%s' must not be negative", attr.name),
+			Subject:  subject,
+		})
+	}
+	return diags
+}
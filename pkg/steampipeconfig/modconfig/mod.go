@@ -32,6 +32,9 @@ type Mod struct {
 	Categories []string `cty:"categories" hcl:"categories,optional" column:"categories,jsonb"`
 	Color      *string  `cty:"color" hcl:"color" column:"color,text"`
 	Icon       *string  `cty:"icon" hcl:"icon" column:"icon,text"`
+	// the defaults for controls which do not set a timeout (in seconds) or retries
+	ControlTimeout *int `cty:"control_timeout" hcl:"control_timeout" column:"control_timeout,integer"`
+	ControlRetries *int `cty:"control_retries" hcl:"control_retries" column:"control_retries,integer"`
 
 	// blocks
 	Require       *Require   `hcl:"require,block"`
@@ -151,6 +154,10 @@ func (m *Mod) SetPaths() {}
 
 // OnDecoded implements HclResource
 func (m *Mod) OnDecoded(block *hcl.Block, _ ResourceMapsProvider) hcl.Diagnostics {
+	if diags := validateControlRunPolicy(block, "control_timeout", m.ControlTimeout, "control_retries", m.ControlRetries); diags.HasErrors() {
+		return diags
+	}
+
 	// handle legacy requires block
 	if m.LegacyRequire != nil && !m.LegacyRequire.Empty() {
 		// ensure that both 'require' and 'requires' were not set
@@ -13,6 +13,7 @@ import (
 const (
 	LimiterSourceConfig     = "config"
 	LimiterSourcePlugin     = "plugin"
+	LimiterSourceConnection = "connection"
 	LimiterStatusActive     = "active"
 	LimiterStatusOverridden = "overridden"
 )
@@ -0,0 +1,59 @@
+package modconfig
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/hclhelpers"
+)
+
+// the formats accepted for the expiry of a waiver - a date waiver expires at the start of the day (UTC)
+var waiverExpiryFormats = []string{time.RFC3339, time.DateOnly}
+
+// Waiver suppresses the failed results of a control, for a single resource or (if no resource is given)
+// all resources - the results are reported as skipped, with the waiver reason
+// a waiver with an expiry is no longer applied once it has expired
+type Waiver struct {
+	Name          string     `hcl:"name,label" json:"name"`
+	Control       string     `hcl:"control" json:"control"`
+	Resource      *string    `hcl:"resource,optional" json:"resource,omitempty"`
+	Reason        string     `hcl:"reason" json:"reason"`
+	ExpiresString *string    `hcl:"expires,optional" json:"-"`
+	Expires       *time.Time `json:"expires,omitempty"`
+	DeclRange     hcl.Range  `json:"-"`
+}
+
+// OnDecoded parses the expiry of the waiver
+func (w *Waiver) OnDecoded(block *hcl.Block) hcl.Diagnostics {
+	w.DeclRange = hclhelpers.BlockRange(block)
+	if w.ExpiresString == nil {
+		return nil
+	}
+	for _, format := range waiverExpiryFormats {
+		if expires, err := time.Parse(format, *w.ExpiresString); err == nil {
+			w.Expires = &expires
+			return nil
+		}
+	}
+	return hcl.Diagnostics{&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("invalid expiry '%s' for waiver '%s' - expected a date (YYYY-MM-DD) or RFC3339 timestamp", *w.ExpiresString, w.Name),
+		Subject:  &w.DeclRange,
+	}}
+}
+
+// Expired returns whether the waiver has expired at the given time
+func (w *Waiver) Expired(now time.Time) bool {
+	return w.Expires != nil && !now.Before(*w.Expires)
+}
+
+// Matches returns whether the waiver applies to the result of a control for a resource
+// the control may be given by its full name (e.g. 'aws_compliance.control.s3_bucket_versioning_enabled')
+// or unqualified name (e.g. 'control.s3_bucket_versioning_enabled')
+func (w *Waiver) Matches(control *Control, resource string) bool {
+	if w.Control != control.Name() && w.Control != control.GetUnqualifiedName() {
+		return false
+	}
+	return w.Resource == nil || *w.Resource == resource
+}
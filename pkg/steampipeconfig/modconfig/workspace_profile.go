@@ -3,10 +3,12 @@ package modconfig
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/spf13/cobra"
+	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/steampipe/pkg/constants"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/hclhelpers"
 	"github.com/turbot/steampipe/pkg/steampipeconfig/options"
@@ -26,6 +28,7 @@ type WorkspaceProfile struct {
 	SearchPathPrefix  *string           `hcl:"search_path_prefix" cty:"search_path_prefix"`
 	Watch             *bool             `hcl:"watch" cty:"watch"`
 	MaxParallel       *int              `hcl:"max_parallel" cty:"max-parallel"`
+	PluginMaxParallel map[string]int    `hcl:"plugin_max_parallel,optional" cty:"plugin-max-parallel"`
 	Introspection     *string           `hcl:"introspection" cty:"introspection"`
 	Input             *bool             `hcl:"input" cty:"input"`
 	Progress          *bool             `hcl:"progress" cty:"progress"`
@@ -137,6 +140,9 @@ func (p *WorkspaceProfile) setBaseProperties() {
 	if p.MaxParallel == nil {
 		p.MaxParallel = p.Base.MaxParallel
 	}
+	if p.PluginMaxParallel == nil {
+		p.PluginMaxParallel = p.Base.PluginMaxParallel
+	}
 	if p.Introspection == nil {
 		p.Introspection = p.Base.Introspection
 	}
@@ -195,6 +201,7 @@ func (p *WorkspaceProfile) ConfigMap(cmd *cobra.Command) map[string]interface{}
 	res.SetIntItem(p.QueryTimeout, constants.ArgDatabaseQueryTimeout)
 	res.SetBoolItem(p.Watch, constants.ArgWatch)
 	res.SetIntItem(p.MaxParallel, constants.ArgMaxParallel)
+	res.SetStringSliceItem(pluginMaxParallelArgs(p.PluginMaxParallel), constants.ArgPluginMaxParallel)
 	res.SetStringSliceItem(searchPathFromString(p.SearchPath, ","), constants.ArgSearchPath)
 	res.SetStringSliceItem(searchPathFromString(p.SearchPathPrefix, ","), constants.ArgSearchPathPrefix)
 	res.SetStringItem(p.Introspection, constants.ArgIntrospection)
@@ -217,6 +224,30 @@ func (p *WorkspaceProfile) ConfigMap(cmd *cobra.Command) map[string]interface{}
 	return res
 }
 
+// pluginMaxParallelArgs converts the plugin_max_parallel map to '<name>=<limit>' args, sorted by name
+// (nil if it is not set)
+func pluginMaxParallelArgs(pluginMaxParallel map[string]int) []string {
+	if pluginMaxParallel == nil {
+		return nil
+	}
+	var res []string
+	for name, limit := range pluginMaxParallel {
+		res = append(res, fmt.Sprintf("%s=%d", name, limit))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// GetSearchPath returns the search_path of the profile as a list of schemas (nil if it is not set)
+func (p *WorkspaceProfile) GetSearchPath() []string {
+	return helpers.RemoveFromStringSlice(searchPathFromString(p.SearchPath, ","), "")
+}
+
+// GetSearchPathPrefix returns the search_path_prefix of the profile as a list of schemas (nil if it is not set)
+func (p *WorkspaceProfile) GetSearchPathPrefix() []string {
+	return helpers.RemoveFromStringSlice(searchPathFromString(p.SearchPathPrefix, ","), "")
+}
+
 // searchPathFromString checks that `str` is `nil` and returns a string slice with `str`
 // separated with `separator`
 // If `str` is `nil`, this returns a `nil`
@@ -18,6 +18,9 @@ type GlobalDashboard struct {
 	Port         *int    `hcl:"port"`
 	Listen       *string `hcl:"listen"`
 	StartTimeout *int    `hcl:"start_timeout"`
+	// the certificate and key used to serve the dashboard over TLS
+	SSLCert *string `hcl:"ssl_cert"`
+	SSLKey  *string `hcl:"ssl_key"`
 }
 
 func (t *WorkspaceProfileDashboard) SetBaseProperties(otherOptions Options) {
@@ -83,6 +86,12 @@ func (d *GlobalDashboard) ConfigMap() map[string]interface{} {
 	} else {
 		res[constants.ArgDashboardStartTimeout] = constants.DashboardStartTimeout.Seconds()
 	}
+	if d.SSLCert != nil {
+		res[constants.ArgDashboardSSLCert] = d.SSLCert
+	}
+	if d.SSLKey != nil {
+		res[constants.ArgDashboardSSLKey] = d.SSLKey
+	}
 	return res
 }
 
@@ -103,6 +112,12 @@ func (d *GlobalDashboard) Merge(otherOptions Options) {
 		if o.StartTimeout != nil {
 			d.StartTimeout = o.StartTimeout
 		}
+		if o.SSLCert != nil {
+			d.SSLCert = o.SSLCert
+		}
+		if o.SSLKey != nil {
+			d.SSLKey = o.SSLKey
+		}
 	}
 }
 
@@ -126,5 +141,15 @@ func (d *GlobalDashboard) String() string {
 	} else {
 		str = append(str, fmt.Sprintf("  StartTimeout: %d", *d.StartTimeout))
 	}
+	if d.SSLCert == nil {
+		str = append(str, "  SSLCert: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  SSLCert: %s", *d.SSLCert))
+	}
+	if d.SSLKey == nil {
+		str = append(str, "  SSLKey: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  SSLKey: %s", *d.SSLKey))
+	}
 	return strings.Join(str, "\n")
 }
@@ -4,18 +4,29 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/steampipe/pkg/constants"
 )
 
 type Database struct {
-	Cache            *bool   `hcl:"cache"`
-	CacheMaxTtl      *int    `hcl:"cache_max_ttl"`
-	CacheMaxSizeMb   *int    `hcl:"cache_max_size_mb"`
-	Listen           *string `hcl:"listen"`
-	Port             *int    `hcl:"port"`
-	SearchPath       *string `hcl:"search_path"`
-	SearchPathPrefix *string `hcl:"search_path_prefix"`
-	StartTimeout     *int    `hcl:"start_timeout"`
+	Cache              *bool    `hcl:"cache"`
+	CacheMaxTtl        *int     `hcl:"cache_max_ttl"`
+	CacheMaxSizeMb     *int     `hcl:"cache_max_size_mb"`
+	Listen             *string  `hcl:"listen"`
+	Port               *int     `hcl:"port"`
+	SearchPath         *string  `hcl:"search_path"`
+	SearchPathPrefix   *string  `hcl:"search_path_prefix"`
+	StartTimeout       *int     `hcl:"start_timeout"`
+	CloneSchema        *string  `hcl:"clone_schema"`
+	RefreshTimeout     *int     `hcl:"refresh_timeout"`
+	GrantPrivileges    []string `hcl:"grant_privileges,optional"`
+	SearchPathLimit    *int     `hcl:"search_path_limit"`
+	RefreshLockTimeout *int     `hcl:"refresh_lock_timeout"`
+	CommentLock        *string  `hcl:"comment_lock"`
+	SchemaQueryTimeout *int     `hcl:"schema_query_timeout"`
+	// the schema and name of the connection state table
+	ConnectionStateSchema *string `hcl:"connection_state_schema"`
+	ConnectionStateTable  *string `hcl:"connection_state_table"`
 }
 
 // ConfigMap creates a config map that can be merged with viper
@@ -51,6 +62,33 @@ func (d *Database) ConfigMap() map[string]interface{} {
 	if d.CacheMaxSizeMb != nil {
 		res[constants.ArgMaxCacheSizeMb] = d.CacheMaxSizeMb
 	}
+	if d.CloneSchema != nil {
+		res[constants.ArgCloneSchema] = d.CloneSchema
+	}
+	if d.RefreshTimeout != nil {
+		res[constants.ArgRefreshTimeout] = d.RefreshTimeout
+	}
+	if d.GrantPrivileges != nil {
+		res[constants.ArgGrantPrivileges] = d.GrantPrivileges
+	}
+	if d.SearchPathLimit != nil {
+		res[constants.ArgSearchPathLimit] = d.SearchPathLimit
+	}
+	if d.RefreshLockTimeout != nil {
+		res[constants.ArgRefreshLockTimeout] = d.RefreshLockTimeout
+	}
+	if d.CommentLock != nil {
+		res[constants.ArgCommentLock] = d.CommentLock
+	}
+	if d.SchemaQueryTimeout != nil {
+		res[constants.ArgSchemaQueryTimeout] = d.SchemaQueryTimeout
+	}
+	if d.ConnectionStateSchema != nil {
+		res[constants.ArgConnectionStateSchema] = d.ConnectionStateSchema
+	}
+	if d.ConnectionStateTable != nil {
+		res[constants.ArgConnectionStateTable] = d.ConnectionStateTable
+	}
 	return res
 }
 
@@ -83,6 +121,33 @@ func (d *Database) Merge(otherOptions Options) {
 		if o.CacheMaxTtl != nil {
 			d.CacheMaxTtl = o.CacheMaxTtl
 		}
+		if o.CloneSchema != nil {
+			d.CloneSchema = o.CloneSchema
+		}
+		if o.RefreshTimeout != nil {
+			d.RefreshTimeout = o.RefreshTimeout
+		}
+		if o.GrantPrivileges != nil {
+			d.GrantPrivileges = o.GrantPrivileges
+		}
+		if o.SearchPathLimit != nil {
+			d.SearchPathLimit = o.SearchPathLimit
+		}
+		if o.RefreshLockTimeout != nil {
+			d.RefreshLockTimeout = o.RefreshLockTimeout
+		}
+		if o.CommentLock != nil {
+			d.CommentLock = o.CommentLock
+		}
+		if o.SchemaQueryTimeout != nil {
+			d.SchemaQueryTimeout = o.SchemaQueryTimeout
+		}
+		if o.ConnectionStateSchema != nil {
+			d.ConnectionStateSchema = o.ConnectionStateSchema
+		}
+		if o.ConnectionStateTable != nil {
+			d.ConnectionStateTable = o.ConnectionStateTable
+		}
 	}
 }
 
@@ -131,5 +196,111 @@ func (d *Database) String() string {
 	} else {
 		str = append(str, fmt.Sprintf("  CacheMaxTtl: %d", *d.CacheMaxTtl))
 	}
+	if d.CloneSchema == nil {
+		str = append(str, "  CloneSchema: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  CloneSchema: %s", *d.CloneSchema))
+	}
+	if d.RefreshTimeout == nil {
+		str = append(str, "  RefreshTimeout: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  RefreshTimeout: %d", *d.RefreshTimeout))
+	}
+	if d.GrantPrivileges == nil {
+		str = append(str, "  GrantPrivileges: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  GrantPrivileges: %s", strings.Join(d.GrantPrivileges, ",")))
+	}
+	if d.SearchPathLimit == nil {
+		str = append(str, "  SearchPathLimit: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  SearchPathLimit: %d", *d.SearchPathLimit))
+	}
+	if d.RefreshLockTimeout == nil {
+		str = append(str, "  RefreshLockTimeout: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  RefreshLockTimeout: %d", *d.RefreshLockTimeout))
+	}
+	if d.CommentLock == nil {
+		str = append(str, "  CommentLock: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  CommentLock: %s", *d.CommentLock))
+	}
+	if d.SchemaQueryTimeout == nil {
+		str = append(str, "  SchemaQueryTimeout: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  SchemaQueryTimeout: %d", *d.SchemaQueryTimeout))
+	}
+	if d.ConnectionStateSchema == nil {
+		str = append(str, "  ConnectionStateSchema: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  ConnectionStateSchema: %s", *d.ConnectionStateSchema))
+	}
+	if d.ConnectionStateTable == nil {
+		str = append(str, "  ConnectionStateTable: nil")
+	} else {
+		str = append(str, fmt.Sprintf("  ConnectionStateTable: %s", *d.ConnectionStateTable))
+	}
 	return strings.Join(str, "\n")
 }
+
+// ExpandEnv expands environment variable references in the search path options
+// references may be ${VAR} or ${VAR:-default} - NOTE: in HCL these must be escaped as $${VAR}
+func (d *Database) ExpandEnv() error {
+	names := []string{"search_path", "search_path_prefix"}
+	for i, value := range []*string{d.SearchPath, d.SearchPathPrefix} {
+		if value == nil {
+			continue
+		}
+		expanded, err := expandEnv(*value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", names[i], err.Error())
+		}
+		*value = expanded
+	}
+	return nil
+}
+
+// Validate validates the database options
+func (d *Database) Validate() error {
+	if d.GrantPrivileges != nil {
+		if err := ValidateGrantPrivileges(d.GrantPrivileges); err != nil {
+			return err
+		}
+	}
+	if d.SearchPathLimit != nil && *d.SearchPathLimit < 0 {
+		return fmt.Errorf("search_path_limit must not be negative")
+	}
+	if d.CloneSchema != nil {
+		switch strings.ToLower(*d.CloneSchema) {
+		case constants.CloneSchemaAuto, constants.CloneSchemaAlways, constants.CloneSchemaNever:
+		default:
+			return fmt.Errorf("invalid clone_schema '%s': must be one of %s, %s, %s", *d.CloneSchema, constants.CloneSchemaAuto, constants.CloneSchemaAlways, constants.CloneSchemaNever)
+		}
+	}
+	if d.CommentLock != nil {
+		switch strings.ToLower(*d.CommentLock) {
+		case constants.CommentLockNone, constants.CommentLockTable, constants.CommentLockAdvisory:
+		default:
+			return fmt.Errorf("invalid comment_lock '%s': must be one of %s, %s, %s", *d.CommentLock, constants.CommentLockNone, constants.CommentLockTable, constants.CommentLockAdvisory)
+		}
+	}
+	return nil
+}
+
+// ValidateGrantPrivileges checks that the given privileges are all grantable Postgres table privileges
+func ValidateGrantPrivileges(privileges []string) error {
+	if len(privileges) == 0 {
+		return fmt.Errorf("grant_privileges must contain at least one privilege")
+	}
+	var invalid []string
+	for _, p := range privileges {
+		if !helpers.StringSliceContains(constants.GrantablePrivileges, strings.ToLower(p)) {
+			invalid = append(invalid, fmt.Sprintf("'%s'", p))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid grant_privileges %s: must be one of %s", strings.Join(invalid, ", "), strings.Join(constants.GrantablePrivileges, ", "))
+	}
+	return nil
+}
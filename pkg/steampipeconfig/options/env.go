@@ -0,0 +1,33 @@
+package options
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// matches ${VAR} and ${VAR:-default}
+var envReferenceRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv expands ${VAR} and ${VAR:-default} references in s using the process environment
+// the default is used if the variable is unset or empty - if a variable is unset and has no default, an error is returned
+func expandEnv(s string) (string, error) {
+	var missing []string
+	res := envReferenceRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		match := envReferenceRegex.FindStringSubmatch(ref)
+		name, hasDefault, defaultValue := match[1], match[2] != "", match[3]
+		value, ok := os.LookupEnv(name)
+		if hasDefault && value == "" {
+			return defaultValue
+		}
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set and has no default", strings.Join(missing, ", "))
+	}
+	return res, nil
+}
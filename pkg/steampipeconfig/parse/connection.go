@@ -48,6 +48,31 @@ func DecodeConnection(block *hcl.Block) (*modconfig.Connection, hcl.Diagnostics)
 		}
 		connection.ImportSchema = importSchema
 	}
+	// steampipe_schema_comments and steampipe_tags are prefixed so they do not hide plugin config of the same name
+	if connectionContent.Attributes["steampipe_schema_comments"] != nil {
+		var schemaComments bool
+		diags = gohcl.DecodeExpression(connectionContent.Attributes["steampipe_schema_comments"].Expr, nil, &schemaComments)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		connection.SchemaComments = &schemaComments
+	}
+	if connectionContent.Attributes["max_concurrency"] != nil {
+		var maxConcurrency int64
+		diags = gohcl.DecodeExpression(connectionContent.Attributes["max_concurrency"].Expr, nil, &maxConcurrency)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		connection.MaxConcurrency = &maxConcurrency
+	}
+	if connectionContent.Attributes["rate_limit"] != nil {
+		var rateLimit float32
+		diags = gohcl.DecodeExpression(connectionContent.Attributes["rate_limit"].Expr, nil, &rateLimit)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		connection.RateLimit = &rateLimit
+	}
 	if connectionContent.Attributes["connections"] != nil {
 		var connections []string
 		diags = gohcl.DecodeExpression(connectionContent.Attributes["connections"].Expr, nil, &connections)
@@ -56,6 +81,38 @@ func DecodeConnection(block *hcl.Block) (*modconfig.Connection, hcl.Diagnostics)
 		}
 		connection.ConnectionNames = connections
 	}
+	if connectionContent.Attributes["connections_regex"] != nil {
+		var connectionRegexes []string
+		diags = gohcl.DecodeExpression(connectionContent.Attributes["connections_regex"].Expr, nil, &connectionRegexes)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		connection.ConnectionRegexes = connectionRegexes
+	}
+	if connectionContent.Attributes["connections_tags"] != nil {
+		var connectionTags map[string]string
+		diags = gohcl.DecodeExpression(connectionContent.Attributes["connections_tags"].Expr, nil, &connectionTags)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		connection.ConnectionTags = connectionTags
+	}
+	if connectionContent.Attributes["depends_on"] != nil {
+		var dependsOn []string
+		diags = gohcl.DecodeExpression(connectionContent.Attributes["depends_on"].Expr, nil, &dependsOn)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		connection.DependsOn = dependsOn
+	}
+	if connectionContent.Attributes["steampipe_tags"] != nil {
+		var tags map[string]string
+		diags = gohcl.DecodeExpression(connectionContent.Attributes["steampipe_tags"].Expr, nil, &tags)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		connection.Tags = tags
+	}
 
 	// check for nested options
 	for _, connectionBlock := range connectionContent.Blocks {
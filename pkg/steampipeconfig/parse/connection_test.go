@@ -0,0 +1,44 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestDecodeConnectionSteampipeAttributes(t *testing.T) {
+	config := `connection "aws" {
+  plugin                    = "aws"
+  steampipe_schema_comments = false
+  steampipe_tags            = { env = "prod" }
+  tags                      = { owner = "plugin" }
+  schema_comments           = "plugin"
+}`
+	file, diags := hclsyntax.ParseConfig([]byte(config), "test.spc", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("failed to parse config: %s", diags.Error())
+	}
+	connection, diags := DecodeConnection(file.Body.(*hclsyntax.Body).Blocks[0].AsHCLBlock())
+	if diags.HasErrors() {
+		t.Fatalf("failed to decode connection: %s", diags.Error())
+	}
+
+	if connection.SchemaComments == nil || *connection.SchemaComments {
+		t.Errorf("expected schema comments to be disabled, got %v", connection.SchemaComments)
+	}
+	if expected := map[string]string{"env": "prod"}; !reflect.DeepEqual(connection.Tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, connection.Tags)
+	}
+	// plugin config with the same names as the steampipe attributes is passed to the plugin
+	for _, attribute := range []string{"tags", "schema_comments"} {
+		if !strings.Contains(connection.Config, attribute) {
+			t.Errorf("expected %s to be included in the plugin config, got: %s", attribute, connection.Config)
+		}
+	}
+	if strings.Contains(connection.Config, "steampipe_") {
+		t.Errorf("expected the steampipe attributes not to be included in the plugin config, got: %s", connection.Config)
+	}
+}
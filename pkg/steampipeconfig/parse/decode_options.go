@@ -32,6 +32,22 @@ func DecodeOptions(block *hcl.Block, overrides ...BlockMappingOverride) (options
 		return nil, diags
 	}
 
+	// database options are expanded and validated here so invalid values are rejected when the config is loaded
+	if databaseOptions, ok := destination.(*options.Database); ok {
+		err := databaseOptions.ExpandEnv()
+		if err == nil {
+			err = databaseOptions.Validate()
+		}
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  err.Error(),
+				Subject:  hclhelpers.BlockRangePointer(block),
+			})
+			return nil, diags
+		}
+	}
+
 	return destination, nil
 }
 
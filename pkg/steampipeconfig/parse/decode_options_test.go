@@ -0,0 +1,131 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/options"
+)
+
+type decodeDatabaseOptionsTest struct {
+	config      string
+	expected    []string
+	expectError bool
+}
+
+var testCasesDecodeDatabaseOptions = map[string]decodeDatabaseOptionsTest{
+	"not set": {
+		config: `options "database" {}`,
+	},
+	"custom privileges": {
+		config:   `options "database" { grant_privileges = ["select", "references", "TRIGGER"] }`,
+		expected: []string{"select", "references", "TRIGGER"},
+	},
+	"invalid privilege": {
+		config:      `options "database" { grant_privileges = ["select", "drop"] }`,
+		expectError: true,
+	},
+	"empty privileges": {
+		config:      `options "database" { grant_privileges = [] }`,
+		expectError: true,
+	},
+	"clone schema": {
+		config: `options "database" { clone_schema = "Never" }`,
+	},
+	"invalid clone schema": {
+		config:      `options "database" { clone_schema = "sometimes" }`,
+		expectError: true,
+	},
+}
+
+func TestDecodeDatabaseOptions(t *testing.T) {
+	for name, test := range testCasesDecodeDatabaseOptions {
+		file, diags := hclsyntax.ParseConfig([]byte(test.config), "test.spc", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatalf("Test: '%s' FAILED : failed to parse config: %s", name, diags.Error())
+		}
+		block := file.Body.(*hclsyntax.Body).Blocks[0].AsHCLBlock()
+
+		opts, diags := DecodeOptions(block)
+		if test.expectError {
+			if !diags.HasErrors() {
+				t.Errorf("Test: '%s' FAILED : expected an error", name)
+			}
+			continue
+		}
+		if diags.HasErrors() {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %s", name, diags.Error())
+			continue
+		}
+		if privileges := opts.(*options.Database).GrantPrivileges; !reflect.DeepEqual(privileges, test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected privileges %v, got %v", name, test.expected, privileges)
+		}
+	}
+}
+
+type decodeDatabaseOptionsExpandEnvTest struct {
+	config      string
+	env         map[string]string
+	expected    string
+	expectError bool
+}
+
+var testCasesDecodeDatabaseOptionsExpandEnv = map[string]decodeDatabaseOptionsExpandEnvTest{
+	"no references": {
+		config:   `options "database" { search_path = "aws, gcp" }`,
+		expected: "aws, gcp",
+	},
+	"set": {
+		config:   `options "database" { search_path = "$${SP_TEST_CONNECTION}, gcp" }`,
+		env:      map[string]string{"SP_TEST_CONNECTION": "aws_prod"},
+		expected: "aws_prod, gcp",
+	},
+	"set with default": {
+		config:   `options "database" { search_path = "$${SP_TEST_CONNECTION:-aws_dev}, gcp" }`,
+		env:      map[string]string{"SP_TEST_CONNECTION": "aws_prod"},
+		expected: "aws_prod, gcp",
+	},
+	"unset with default": {
+		config:   `options "database" { search_path = "$${SP_TEST_CONNECTION:-aws_dev}, gcp" }`,
+		expected: "aws_dev, gcp",
+	},
+	"unset without default": {
+		config:      `options "database" { search_path = "$${SP_TEST_CONNECTION}, gcp" }`,
+		expectError: true,
+	},
+	"unset without default in prefix": {
+		config:      `options "database" { search_path_prefix = "$${SP_TEST_CONNECTION}" }`,
+		expectError: true,
+	},
+}
+
+func TestDecodeDatabaseOptionsExpandEnv(t *testing.T) {
+	for name, test := range testCasesDecodeDatabaseOptionsExpandEnv {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			file, diags := hclsyntax.ParseConfig([]byte(test.config), "test.spc", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("failed to parse config: %s", diags.Error())
+			}
+			block := file.Body.(*hclsyntax.Body).Blocks[0].AsHCLBlock()
+
+			opts, diags := DecodeOptions(block)
+			if test.expectError {
+				if !diags.HasErrors() {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if diags.HasErrors() {
+				t.Fatalf("unexpected error: %s", diags.Error())
+			}
+			if searchPath := opts.(*options.Database).SearchPath; searchPath == nil || *searchPath != test.expected {
+				t.Errorf("expected search path '%s', got %v", test.expected, searchPath)
+			}
+		})
+	}
+}
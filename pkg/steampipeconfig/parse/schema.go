@@ -39,6 +39,15 @@ var PluginBlockSchema = &hcl.BodySchema{
 	},
 }
 
+var WaiverFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       modconfig.BlockTypeWaiver,
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
 var WorkspaceProfileBlockSchema = &hcl.BodySchema{
 
 	Blocks: []hcl.BlockHeaderSchema{
@@ -61,9 +70,30 @@ var ConnectionBlockSchema = &hcl.BodySchema{
 		{
 			Name: "connections",
 		},
+		{
+			Name: "connections_regex",
+		},
+		{
+			Name: "connections_tags",
+		},
 		{
 			Name: "import_schema",
 		},
+		{
+			Name: "steampipe_schema_comments",
+		},
+		{
+			Name: "max_concurrency",
+		},
+		{
+			Name: "rate_limit",
+		},
+		{
+			Name: "depends_on",
+		},
+		{
+			Name: "steampipe_tags",
+		},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
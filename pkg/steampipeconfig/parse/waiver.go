@@ -0,0 +1,58 @@
+package parse
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+// ParseWaiverFiles parses the waivers declared in the given files - these may be HCL, JSON or YAML files
+func ParseWaiverFiles(paths ...string) ([]*modconfig.Waiver, hcl.Diagnostics) {
+	fileData, diags := LoadFileData(paths...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, diags := ParseHclFiles(fileData)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	content, diags := body.Content(WaiverFileSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var res []*modconfig.Waiver
+	names := make(map[string]*hcl.Range)
+	for _, block := range content.Blocks {
+		waiver, moreDiags := DecodeWaiver(block)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		if existing, ok := names[waiver.Name]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("duplicate waiver name '%s' - also declared at %s", waiver.Name, existing.String()),
+				Subject:  &waiver.DeclRange,
+			})
+			continue
+		}
+		names[waiver.Name] = &waiver.DeclRange
+		res = append(res, waiver)
+	}
+	return res, diags
+}
+
+func DecodeWaiver(block *hcl.Block) (*modconfig.Waiver, hcl.Diagnostics) {
+	var waiver = &modconfig.Waiver{
+		// populate name from label
+		Name: block.Labels[0],
+	}
+	diags := gohcl.DecodeBody(block.Body, nil, waiver)
+	if !diags.HasErrors() {
+		diags = append(diags, waiver.OnDecoded(block)...)
+	}
+	return waiver, diags
+}
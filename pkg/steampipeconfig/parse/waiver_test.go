@@ -0,0 +1,116 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseWaiverFiles(t *testing.T) {
+	type parseWaiverTest struct {
+		fileName string
+		content  string
+		// expected waiver names, resources and expiries
+		expected      map[string]string
+		expectedError bool
+	}
+	tests := map[string]parseWaiverTest{
+		"hcl": {
+			fileName: "waivers.hcl",
+			content: `
+waiver "public_bucket" {
+  control  = "aws_compliance.control.s3_bucket_public"
+  resource = "arn:aws:s3:::b1"
+  reason   = "website bucket"
+  expires  = "2030-01-31"
+}
+waiver "all_buckets" {
+  control = "control.s3_bucket_versioning"
+  reason  = "versioning is not required"
+  expires = "2030-01-31T12:00:00Z"
+}`,
+			expected: map[string]string{
+				"public_bucket": "arn:aws:s3:::b1 2030-01-31T00:00:00Z",
+				"all_buckets":   " 2030-01-31T12:00:00Z",
+			},
+		},
+		"yaml": {
+			fileName: "waivers.yml",
+			content: `
+waiver:
+  public_bucket:
+    control: aws_compliance.control.s3_bucket_public
+    resource: arn:aws:s3:::b1
+    reason: website bucket
+`,
+			expected: map[string]string{"public_bucket": "arn:aws:s3:::b1 "},
+		},
+		"invalid expiry": {
+			fileName: "waivers.hcl",
+			content: `
+waiver "w1" {
+  control = "control.c1"
+  reason  = "r"
+  expires = "next week"
+}`,
+			expectedError: true,
+		},
+		"missing reason": {
+			fileName:      "waivers.hcl",
+			content:       `waiver "w1" { control = "control.c1" }`,
+			expectedError: true,
+		},
+		"duplicate name": {
+			fileName: "waivers.hcl",
+			content: `
+waiver "w1" {
+  control = "control.c1"
+  reason  = "r"
+}
+waiver "w1" {
+  control = "control.c2"
+  reason  = "r"
+}`,
+			expectedError: true,
+		},
+	}
+	for name, test := range tests {
+		path := filepath.Join(t.TempDir(), test.fileName)
+		if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		waivers, diags := ParseWaiverFiles(path)
+		if test.expectedError {
+			if !diags.HasErrors() {
+				t.Errorf("Test: '%s' FAILED : expected an error", name)
+			}
+			continue
+		}
+		if diags.HasErrors() {
+			t.Errorf("Test: '%s' FAILED : unexpected error %s", name, diags.Error())
+			continue
+		}
+		res := make(map[string]string)
+		for _, w := range waivers {
+			var resource, expires string
+			if w.Resource != nil {
+				resource = *w.Resource
+			}
+			if w.Expires != nil {
+				expires = w.Expires.Format(time.RFC3339)
+			}
+			res[w.Name] = resource + " " + expires
+		}
+		if len(res) != len(test.expected) {
+			t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, res)
+			continue
+		}
+		for waiverName, expected := range test.expected {
+			if res[waiverName] != expected {
+				t.Errorf("Test: '%s' FAILED : expected %v, got %v", name, test.expected, res)
+				break
+			}
+		}
+	}
+}
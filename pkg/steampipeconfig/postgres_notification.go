@@ -1,21 +1,30 @@
 package steampipeconfig
 
 import (
+	"encoding/json"
+
 	"github.com/turbot/steampipe/pkg/error_helpers"
 )
 
 const PostgresNotificationStructVersion = 20230306
 
+// maxNotificationPayloadSize is the maximum size of a Postgres notification payload
+// (pg_notify payloads must be shorter than 8000 bytes)
+const maxNotificationPayloadSize = 7999
+
 type PostgresNotificationType int
 
 const (
 	PgNotificationSchemaUpdate PostgresNotificationType = iota + 1
 	PgNotificationConnectionError
+	PgNotificationRefreshProgress
 )
 
 type PostgresNotification struct {
 	StructVersion int
 	Type          PostgresNotificationType
+	// the ID of the refresh which sent the notification (if it was sent by a refresh)
+	RefreshID string `json:",omitempty"`
 }
 
 type ErrorsAndWarningsNotification struct {
@@ -24,10 +33,53 @@ type ErrorsAndWarningsNotification struct {
 	Warnings []string
 }
 
-func NewSchemaUpdateNotification() *PostgresNotification {
-	return &PostgresNotification{
-		StructVersion: PostgresNotificationStructVersion,
-		Type:          PgNotificationSchemaUpdate,
+// SchemaUpdateNotification is sent when the schema changes
+// if it is sent by a connection refresh, it lists the connections whose schemas were updated or deleted
+// - if these are not listed, clients should assume any connection may have changed
+type SchemaUpdateNotification struct {
+	PostgresNotification
+	UpdatedConnections []string `json:",omitempty"`
+	DeletedConnections []string `json:",omitempty"`
+}
+
+func NewSchemaUpdateNotification() *SchemaUpdateNotification {
+	return &SchemaUpdateNotification{
+		PostgresNotification: PostgresNotification{
+			StructVersion: PostgresNotificationStructVersion,
+			Type:          PgNotificationSchemaUpdate,
+		},
+	}
+}
+
+// SetConnections sets the updated and deleted connections
+// these are omitted if they would make the notification payload too large to send
+func (n *SchemaUpdateNotification) SetConnections(updated, deleted []string) {
+	n.UpdatedConnections = updated
+	n.DeletedConnections = deleted
+	if payload, err := json.Marshal(n); err != nil || len(payload) > maxNotificationPayloadSize {
+		n.UpdatedConnections = nil
+		n.DeletedConnections = nil
+	}
+}
+
+// HasConnections returns whether the notification lists the changed connections
+func (n *SchemaUpdateNotification) HasConnections() bool {
+	return len(n.UpdatedConnections)+len(n.DeletedConnections) > 0
+}
+
+// RefreshProgressNotification is sent by a connection refresh to report its progress
+type RefreshProgressNotification struct {
+	PostgresNotification
+	Status string
+}
+
+func NewRefreshProgressNotification(status string) *RefreshProgressNotification {
+	return &RefreshProgressNotification{
+		PostgresNotification: PostgresNotification{
+			StructVersion: PostgresNotificationStructVersion,
+			Type:          PgNotificationRefreshProgress,
+		},
+		Status: status,
 	}
 }
 
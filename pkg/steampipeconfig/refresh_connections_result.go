@@ -2,10 +2,15 @@ package steampipeconfig
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/steampipe/pkg/error_helpers"
 	"github.com/turbot/steampipe/pkg/utils"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 // RefreshConnectionResult is a structure used to contain the result of either a RefreshConnections or a NewLocalClient operation
@@ -13,30 +18,91 @@ type RefreshConnectionResult struct {
 	error_helpers.ErrorAndWarnings
 	UpdatedConnections bool
 	FailedConnections  map[string]string
+	// the connections whose schemas were imported, cloned or deleted
+	CreatedConnections []string
+	ClonedConnections  []string
+	DeletedConnections []string
+	// map of missing plugin name to the names of the connections which require it
+	MissingPlugins map[string][]string
+	// the ID of the refresh which produced this result
+	RefreshID string
+	// map of connection name to the catalog usage of its schema (only populated if catalog stats are enabled)
+	CatalogStats map[string]*ConnectionCatalogStats
+
+	// protects the result when merging from multiple goroutines
+	mut sync.Mutex
 }
 
 func NewErrorRefreshConnectionResult(err error) *RefreshConnectionResult {
 	return &RefreshConnectionResult{ErrorAndWarnings: *error_helpers.NewErrorsAndWarning(err)}
 }
 
+// Merge folds the other result into this result
+// Merge is safe to call concurrently, and the merged result does not depend on the order results are merged in:
+// warnings and connection names are kept sorted and, if both results have an error, the error whose message sorts
+// first is retained
 func (r *RefreshConnectionResult) Merge(other *RefreshConnectionResult) {
-	if other == nil {
+	if other == nil || other == r {
 		return
 	}
-	if other.UpdatedConnections {
-		r.UpdatedConnections = other.UpdatedConnections
+	// take a copy of the other result so the two locks are never held together
+	other.mut.Lock()
+	otherUpdated := other.UpdatedConnections
+	otherRefreshID := other.RefreshID
+	otherError := other.Error
+	otherWarnings := slices.Clone(other.Warnings)
+	otherCreated := slices.Clone(other.CreatedConnections)
+	otherCloned := slices.Clone(other.ClonedConnections)
+	otherDeleted := slices.Clone(other.DeletedConnections)
+	otherMissingPlugins := maps.Clone(other.MissingPlugins)
+	otherFailed := maps.Clone(other.FailedConnections)
+	otherCatalogStats := maps.Clone(other.CatalogStats)
+	other.mut.Unlock()
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.RefreshID == "" {
+		r.RefreshID = otherRefreshID
+	}
+	if otherUpdated {
+		r.UpdatedConnections = true
+	}
+	if otherError != nil && (r.Error == nil || otherError.Error() < r.Error.Error()) {
+		r.Error = otherError
 	}
-	if other.Error != nil {
-		r.Error = other.Error
+	r.Warnings = mergeSorted(r.Warnings, otherWarnings)
+	r.CreatedConnections = mergeSorted(r.CreatedConnections, otherCreated)
+	r.ClonedConnections = mergeSorted(r.ClonedConnections, otherCloned)
+	r.DeletedConnections = mergeSorted(r.DeletedConnections, otherDeleted)
+	for plugin, connections := range otherMissingPlugins {
+		r.addMissingPlugin(plugin, connections...)
+		sort.Strings(r.MissingPlugins[plugin])
 	}
-	r.Warnings = append(r.Warnings, other.Warnings...)
-	for c, err := range other.FailedConnections {
-		if _, ok := r.FailedConnections[c]; !ok {
-			r.AddFailedConnection(c, err)
+	for c, stats := range otherCatalogStats {
+		if r.CatalogStats == nil {
+			r.CatalogStats = make(map[string]*ConnectionCatalogStats)
+		}
+		r.CatalogStats[c] = stats
+	}
+	for c, err := range otherFailed {
+		// if both results have a failure for a connection, keep the one which sorts first
+		if existing, ok := r.FailedConnections[c]; !ok || err < existing {
+			r.addFailedConnection(c, err)
 		}
 	}
 }
 
+// mergeSorted appends other to target, returning the combined slice sorted
+func mergeSorted(target, other []string) []string {
+	if len(other) == 0 {
+		return target
+	}
+	res := append(target, other...)
+	sort.Strings(res)
+	return res
+}
+
 func (r *RefreshConnectionResult) String() string {
 	var op strings.Builder
 	if len(r.Warnings) > 0 {
@@ -49,10 +115,42 @@ func (r *RefreshConnectionResult) String() string {
 	return op.String()
 }
 
+// AddWarning adds warnings to the result - this shadows ErrorAndWarnings.AddWarning so warnings may be added
+// while the result is being merged
+func (r *RefreshConnectionResult) AddWarning(warnings ...string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.Warnings = append(r.Warnings, warnings...)
+}
+
 func (r *RefreshConnectionResult) AddFailedConnection(c string, failure string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.addFailedConnection(c, failure)
+}
+
+func (r *RefreshConnectionResult) addFailedConnection(c string, failure string) {
 	if r.FailedConnections == nil {
 		r.FailedConnections = make(map[string]string)
 	}
 
 	r.FailedConnections[c] = failure
 }
+
+// AddMissingPlugin records that the given connections require a plugin which is not installed
+func (r *RefreshConnectionResult) AddMissingPlugin(plugin string, connections ...string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.addMissingPlugin(plugin, connections...)
+}
+
+func (r *RefreshConnectionResult) addMissingPlugin(plugin string, connections ...string) {
+	if r.MissingPlugins == nil {
+		r.MissingPlugins = make(map[string][]string)
+	}
+	for _, c := range connections {
+		if !helpers.StringSliceContains(r.MissingPlugins[plugin], c) {
+			r.MissingPlugins[plugin] = append(r.MissingPlugins[plugin], c)
+		}
+	}
+}
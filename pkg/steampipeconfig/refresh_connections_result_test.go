@@ -0,0 +1,73 @@
+package steampipeconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/error_helpers"
+)
+
+// build the partial result for a single connection
+func partialRefreshConnectionResult(i int) *RefreshConnectionResult {
+	name := fmt.Sprintf("conn_%02d", i)
+	res := &RefreshConnectionResult{
+		ErrorAndWarnings: error_helpers.ErrorAndWarnings{
+			Warnings: []string{fmt.Sprintf("%s: warning b", name), fmt.Sprintf("%s: warning a", name)},
+		},
+		CreatedConnections: []string{name},
+	}
+	if i%2 == 0 {
+		res.UpdatedConnections = true
+		res.AddMissingPlugin("aws", name)
+	}
+	if i%5 == 0 {
+		res.Error = fmt.Errorf("%s failed", name)
+		res.AddFailedConnection(name, "failed to start plugin")
+	}
+	return res
+}
+
+func TestRefreshConnectionResultMergeConcurrent(t *testing.T) {
+	const count = 50
+
+	// merge the partial results sequentially, in reverse order, to give the expected result
+	expected := &RefreshConnectionResult{}
+	for i := count - 1; i >= 0; i-- {
+		expected.Merge(partialRefreshConnectionResult(i))
+	}
+
+	for run := 0; run < 10; run++ {
+		res := &RefreshConnectionResult{}
+		var wg sync.WaitGroup
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				res.Merge(partialRefreshConnectionResult(i))
+			}(i)
+		}
+		wg.Wait()
+
+		if res.String() != expected.String() {
+			t.Fatalf("run %d: expected:\n%s\ngot:\n%s", run, expected.String(), res.String())
+		}
+		if !reflect.DeepEqual(res.CreatedConnections, expected.CreatedConnections) {
+			t.Errorf("run %d: expected created connections %v, got %v", run, expected.CreatedConnections, res.CreatedConnections)
+		}
+		if !reflect.DeepEqual(res.MissingPlugins, expected.MissingPlugins) {
+			t.Errorf("run %d: expected missing plugins %v, got %v", run, expected.MissingPlugins, res.MissingPlugins)
+		}
+		if !reflect.DeepEqual(res.FailedConnections, expected.FailedConnections) {
+			t.Errorf("run %d: expected failed connections %v, got %v", run, expected.FailedConnections, res.FailedConnections)
+		}
+	}
+
+	if expected.Error == nil || expected.Error.Error() != "conn_00 failed" {
+		t.Errorf("expected error 'conn_00 failed', got %v", expected.Error)
+	}
+	if expected.Warnings[0] != "conn_00: warning a" || expected.Warnings[1] != "conn_00: warning b" {
+		t.Errorf("expected warnings to be sorted, got %v", expected.Warnings[:2])
+	}
+}
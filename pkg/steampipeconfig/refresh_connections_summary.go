@@ -0,0 +1,109 @@
+package steampipeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe/pkg/constants"
+	"golang.org/x/exp/maps"
+)
+
+// RefreshConnectionSummary is a summary of the changes made by a connection refresh
+// NOTE: the json form of this struct is intended to be consumed by scripts - do not rename fields
+type RefreshConnectionSummary struct {
+	Created  int `json:"created"`
+	Cloned   int `json:"cloned"`
+	Deleted  int `json:"deleted"`
+	Failed   int `json:"failed"`
+	Warnings int `json:"warnings"`
+	// map of failed connection name to failure message
+	FailedConnections map[string]string `json:"failed_connections,omitempty"`
+	// map of connection name to the catalog usage of its schema (only populated if catalog stats are enabled)
+	CatalogStats map[string]*ConnectionCatalogStats `json:"catalog_stats,omitempty"`
+}
+
+// Summary builds a RefreshConnectionSummary from the result
+func (r *RefreshConnectionResult) Summary() *RefreshConnectionSummary {
+	// connections with a dynamic schema are deleted then recreated - do not count these as deletions
+	deleted := 0
+	for _, c := range r.DeletedConnections {
+		if !helpers.StringSliceContains(r.CreatedConnections, c) && !helpers.StringSliceContains(r.ClonedConnections, c) {
+			deleted++
+		}
+	}
+	return &RefreshConnectionSummary{
+		Created:           len(r.CreatedConnections),
+		Cloned:            len(r.ClonedConnections),
+		Deleted:           deleted,
+		Failed:            len(r.FailedConnections),
+		Warnings:          len(r.Warnings),
+		FailedConnections: r.FailedConnections,
+		CatalogStats:      r.CatalogStats,
+	}
+}
+
+// String returns a single line summary, e.g. "12 created, 3 cloned, 1 deleted, 2 failed, 4 warnings"
+func (s *RefreshConnectionSummary) String() string {
+	return fmt.Sprintf("%d created, %d cloned, %d deleted, %d failed, %d warnings", s.Created, s.Cloned, s.Deleted, s.Failed, s.Warnings)
+}
+
+// Table returns the summary as an aligned table
+// if there are catalog stats, the totals are followed by the stats of each connection
+func (s *RefreshConnectionSummary) Table() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Created\t%d\n", s.Created)
+	fmt.Fprintf(w, "Cloned\t%d\n", s.Cloned)
+	fmt.Fprintf(w, "Deleted\t%d\n", s.Deleted)
+	fmt.Fprintf(w, "Failed\t%d\n", s.Failed)
+	fmt.Fprintf(w, "Warnings\t%d\n", s.Warnings)
+	if len(s.CatalogStats) > 0 {
+		total := &ConnectionCatalogStats{}
+		for _, stats := range s.CatalogStats {
+			total.ForeignTables += stats.ForeignTables
+			total.CatalogBytes += stats.CatalogBytes
+		}
+		fmt.Fprintf(w, "Foreign tables\t%d\n", total.ForeignTables)
+		fmt.Fprintf(w, "Catalog size\t%d bytes\n", total.CatalogBytes)
+	}
+	w.Flush()
+
+	if len(s.CatalogStats) > 0 {
+		connectionNames := maps.Keys(s.CatalogStats)
+		sort.Strings(connectionNames)
+
+		sb.WriteString("\n")
+		w = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Connection\tForeign tables\tCatalog size (bytes)\n")
+		for _, connectionName := range connectionNames {
+			stats := s.CatalogStats[connectionName]
+			fmt.Fprintf(w, "%s\t%d\t%d\n", connectionName, stats.ForeignTables, stats.CatalogBytes)
+		}
+		w.Flush()
+	}
+	return sb.String()
+}
+
+// JSON returns the summary as indented json
+func (s *RefreshConnectionSummary) JSON() (string, error) {
+	res, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// Render returns the summary in the given output format (table or json)
+func (s *RefreshConnectionSummary) Render(outputFormat string) (string, error) {
+	switch outputFormat {
+	case constants.OutputFormatJSON:
+		return s.JSON()
+	case constants.OutputFormatTable, "":
+		return s.Table(), nil
+	}
+	return "", fmt.Errorf("unsupported output format '%s' - must be one of '%s' or '%s'", outputFormat, constants.OutputFormatTable, constants.OutputFormatJSON)
+}
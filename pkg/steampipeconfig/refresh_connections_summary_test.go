@@ -0,0 +1,106 @@
+package steampipeconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/error_helpers"
+)
+
+func sampleRefreshConnectionResult() *RefreshConnectionResult {
+	return &RefreshConnectionResult{
+		ErrorAndWarnings:   error_helpers.ErrorAndWarnings{Warnings: []string{"warning 1", "warning 2"}},
+		CreatedConnections: []string{"aws", "gcp", "dynamic"},
+		ClonedConnections:  []string{"aws_2"},
+		// 'dynamic' was deleted and recreated so should not be counted as a deletion
+		DeletedConnections: []string{"old", "dynamic"},
+		FailedConnections:  map[string]string{"azure": "failed to start plugin"},
+	}
+}
+
+func TestRefreshConnectionSummaryTable(t *testing.T) {
+	summary := sampleRefreshConnectionResult().Summary()
+
+	expected := `Created   3
+Cloned    1
+Deleted   1
+Failed    1
+Warnings  2
+`
+	if table := summary.Table(); table != expected {
+		t.Errorf("expected table:\n%s\ngot:\n%s", expected, table)
+	}
+	if expected := "3 created, 1 cloned, 1 deleted, 1 failed, 2 warnings"; summary.String() != expected {
+		t.Errorf("expected '%s', got '%s'", expected, summary.String())
+	}
+}
+
+func TestRefreshConnectionSummaryJSON(t *testing.T) {
+	summary := sampleRefreshConnectionResult().Summary()
+
+	jsonString, err := summary.Render(constants.OutputFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed RefreshConnectionSummary
+	if err := json.Unmarshal([]byte(jsonString), &parsed); err != nil {
+		t.Fatalf("failed to parse summary json: %s", err.Error())
+	}
+	if !reflect.DeepEqual(&parsed, summary) {
+		t.Errorf("expected %+v, got %+v", summary, parsed)
+	}
+
+	// the json field names must be stable
+	expected := `{
+  "created": 3,
+  "cloned": 1,
+  "deleted": 1,
+  "failed": 1,
+  "warnings": 2,
+  "failed_connections": {
+    "azure": "failed to start plugin"
+  }
+}`
+	if jsonString != expected {
+		t.Errorf("expected json:\n%s\ngot:\n%s", expected, jsonString)
+	}
+}
+
+func TestRefreshConnectionSummaryCatalogStats(t *testing.T) {
+	res := sampleRefreshConnectionResult()
+	res.CatalogStats = map[string]*ConnectionCatalogStats{
+		"aws": {ForeignTables: 450, CatalogBytes: 5000000},
+		"gcp": {ForeignTables: 120, CatalogBytes: 1000000},
+	}
+	summary := res.Summary()
+
+	expected := `Created         3
+Cloned          1
+Deleted         1
+Failed          1
+Warnings        2
+Foreign tables  570
+Catalog size    6000000 bytes
+
+Connection  Foreign tables  Catalog size (bytes)
+aws         450             5000000
+gcp         120             1000000
+`
+	if table := summary.Table(); table != expected {
+		t.Errorf("expected table:\n%s\ngot:\n%s", expected, table)
+	}
+
+	jsonString, err := summary.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed RefreshConnectionSummary
+	if err := json.Unmarshal([]byte(jsonString), &parsed); err != nil {
+		t.Fatalf("failed to parse summary json: %s", err.Error())
+	}
+	if !reflect.DeepEqual(parsed.CatalogStats, res.CatalogStats) {
+		t.Errorf("expected catalog stats %v, got %v", res.CatalogStats, parsed.CatalogStats)
+	}
+}
@@ -0,0 +1,243 @@
+package steampipeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TerraformConnection is a connection generated from a resource in a terraform state
+type TerraformConnection struct {
+	Name   string
+	Plugin string
+	// Config is the plugin specific connection config, in HCL
+	Config string
+	// Source is the address of the terraform resource the connection was generated from
+	Source string
+}
+
+// terraformState is the subset of a (version 4) terraform state file used to generate connections
+type terraformState struct {
+	Version   int                 `json:"version"`
+	Resources []terraformResource `json:"resources"`
+}
+
+type terraformResource struct {
+	Mode      string                      `json:"mode"`
+	Type      string                      `json:"type"`
+	Name      string                      `json:"name"`
+	Instances []terraformResourceInstance `json:"instances"`
+}
+
+type terraformResourceInstance struct {
+	Attributes map[string]any `json:"attributes"`
+}
+
+// address returns the terraform address of the resource, e.g. 'data.google_project.main'
+func (r terraformResource) address() string {
+	if r.Mode == "data" {
+		return fmt.Sprintf("data.%s.%s", r.Type, r.Name)
+	}
+	return fmt.Sprintf("%s.%s", r.Type, r.Name)
+}
+
+// LoadTerraformConnections generates connections for the cloud accounts found in a terraform state:
+//   - aws: accounts from aws_organizations_account resources and the aws_organizations_organization data source
+//     (a profile with the name of the account is assumed)
+//   - gcp: projects from google_project resources and data sources
+//   - azure: subscriptions from azurerm_subscription resources and data sources
+//
+// statePath is either a state file or a terraform working directory - for a directory, the state of the
+// currently selected terraform workspace is used
+func LoadTerraformConnections(statePath string) ([]*TerraformConnection, error) {
+	stateFile, err := terraformStateFile(statePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		return nil, err
+	}
+	var state terraformState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform state %s: %s", stateFile, err.Error())
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported terraform state version %d in %s - only version 4 is supported", state.Version, stateFile)
+	}
+	return terraformConnections(state), nil
+}
+
+// terraformStateFile resolves the state file for the given path
+// if the path is a terraform working directory, the state file of the selected workspace is returned
+func terraformStateFile(statePath string) (string, error) {
+	info, err := os.Stat(statePath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return statePath, nil
+	}
+
+	// the selected workspace is stored in .terraform/environment - if there is none, the default workspace is used
+	workspace := "default"
+	if data, err := os.ReadFile(filepath.Join(statePath, ".terraform", "environment")); err == nil {
+		if w := strings.TrimSpace(string(data)); w != "" {
+			workspace = w
+		}
+	}
+	backend, err := loadTerraformBackend(statePath)
+	if err != nil {
+		return "", err
+	}
+	// the state of a remote backend is not available locally
+	if backend.Type != "local" {
+		return "", fmt.Errorf("terraform working directory %s uses the '%s' backend - remote state is not supported: save the state with 'terraform state pull > terraform.tfstate' and import from the file", statePath, backend.Type)
+	}
+	stateFile := filepath.Join(statePath, "terraform.tfstate")
+	if backend.Config.Path != "" {
+		stateFile = filepath.Join(statePath, backend.Config.Path)
+	}
+	if workspace != "default" {
+		stateFile = filepath.Join(statePath, "terraform.tfstate.d", workspace, "terraform.tfstate")
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		return "", fmt.Errorf("no state found for terraform workspace '%s' in %s", workspace, statePath)
+	}
+	return stateFile, nil
+}
+
+// terraformBackend is the backend of a terraform working directory, as recorded in .terraform/terraform.tfstate
+type terraformBackend struct {
+	Type   string `json:"type"`
+	Config struct {
+		// the state file of a local backend (if not the default)
+		Path string `json:"path"`
+	} `json:"config"`
+}
+
+// loadTerraformBackend returns the backend initialised for the terraform working directory
+// if the directory has no backend configured, this is the local backend
+func loadTerraformBackend(dir string) (*terraformBackend, error) {
+	backend := &terraformBackend{Type: "local"}
+	data, err := os.ReadFile(filepath.Join(dir, ".terraform", "terraform.tfstate"))
+	if os.IsNotExist(err) {
+		return backend, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state struct {
+		Backend *terraformBackend `json:"backend"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse the terraform backend config of %s: %s", dir, err.Error())
+	}
+	if state.Backend != nil && state.Backend.Type != "" {
+		backend = state.Backend
+	}
+	return backend, nil
+}
+
+// terraformAccount is a cloud account found in a terraform state
+type terraformAccount struct {
+	plugin string
+	// id is the account id, project id or subscription id
+	id string
+	// name is the display name of the account (may be empty)
+	name   string
+	source string
+}
+
+// terraformConnections returns a connection for each distinct account in the state, in state order
+func terraformConnections(state terraformState) []*TerraformConnection {
+	var res []*TerraformConnection
+	// track account ids and connection names to avoid duplicates
+	accountIds := map[string]bool{}
+	names := map[string]bool{}
+	for _, account := range terraformAccounts(state) {
+		key := account.plugin + "/" + account.id
+		if account.id == "" || accountIds[key] {
+			continue
+		}
+		accountIds[key] = true
+
+		name := terraformConnectionName(account.plugin, account.name)
+		if account.name == "" || names[name] {
+			name = terraformConnectionName(account.plugin, account.id)
+		}
+		// the id may also clash (e.g. with the name of another account) - if so, add a numeric suffix
+		for i, base := 2, name; names[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		names[name] = true
+
+		res = append(res, &TerraformConnection{
+			Name:   name,
+			Plugin: account.plugin,
+			Config: terraformConnectionConfig(account),
+			Source: account.source,
+		})
+	}
+	return res
+}
+
+func terraformAccounts(state terraformState) []terraformAccount {
+	var res []terraformAccount
+	for _, r := range state.Resources {
+		for _, instance := range r.Instances {
+			attributes := instance.Attributes
+			switch r.Type {
+			case "aws_organizations_account":
+				res = append(res, terraformAccount{plugin: "aws", id: stringAttribute(attributes, "id"), name: stringAttribute(attributes, "name"), source: r.address()})
+			case "aws_organizations_organization":
+				accounts, _ := attributes["accounts"].([]any)
+				for _, a := range accounts {
+					account, _ := a.(map[string]any)
+					res = append(res, terraformAccount{plugin: "aws", id: stringAttribute(account, "id"), name: stringAttribute(account, "name"), source: r.address()})
+				}
+			case "google_project":
+				res = append(res, terraformAccount{plugin: "gcp", id: stringAttribute(attributes, "project_id"), source: r.address()})
+			case "azurerm_subscription":
+				name := stringAttribute(attributes, "subscription_name")
+				if name == "" {
+					name = stringAttribute(attributes, "display_name")
+				}
+				res = append(res, terraformAccount{plugin: "azure", id: stringAttribute(attributes, "subscription_id"), name: name, source: r.address()})
+			}
+		}
+	}
+	return res
+}
+
+func terraformConnectionConfig(account terraformAccount) string {
+	switch account.plugin {
+	case "aws":
+		profile := account.name
+		if profile == "" {
+			profile = account.id
+		}
+		return fmt.Sprintf("profile = %q", profile)
+	case "gcp":
+		return fmt.Sprintf("project = %q", account.id)
+	case "azure":
+		return fmt.Sprintf("subscription_id = %q", account.id)
+	}
+	return ""
+}
+
+var invalidConnectionNameChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// terraformConnectionName returns a valid connection name for the account, prefixed with the plugin name
+func terraformConnectionName(plugin, name string) string {
+	name = strings.Trim(invalidConnectionNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	return fmt.Sprintf("%s_%s", plugin, name)
+}
+
+func stringAttribute(attributes map[string]any, name string) string {
+	s, _ := attributes[name].(string)
+	return s
+}
@@ -0,0 +1,168 @@
+package steampipeconfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTerraformState = `{
+  "version": 4,
+  "terraform_version": "1.5.7",
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_organizations_account",
+      "name": "prod",
+      "instances": [{"attributes": {"id": "111111111111", "name": "Prod Account"}}]
+    },
+    {
+      "mode": "data",
+      "type": "aws_organizations_organization",
+      "name": "org",
+      "instances": [{"attributes": {"accounts": [
+        {"id": "111111111111", "name": "Prod Account"},
+        {"id": "222222222222", "name": "dev"},
+        {"id": "333333333333", "name": "Dev"}
+      ]}}]
+    },
+    {
+      "mode": "managed",
+      "type": "google_project",
+      "name": "main",
+      "instances": [{"attributes": {"project_id": "my-project-123"}}]
+    },
+    {
+      "mode": "data",
+      "type": "azurerm_subscription",
+      "name": "current",
+      "instances": [{"attributes": {"subscription_id": "0000-1111", "display_name": "Pay-As-You-Go"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "logs",
+      "instances": [{"attributes": {"id": "logs"}}]
+    }
+  ]
+}`
+
+func TestLoadTerraformConnections(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "terraform.tfstate")
+	if err := os.WriteFile(statePath, []byte(testTerraformState), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*TerraformConnection{
+		{Name: "aws_prod_account", Plugin: "aws", Config: `profile = "Prod Account"`, Source: "aws_organizations_account.prod"},
+		{Name: "aws_dev", Plugin: "aws", Config: `profile = "dev"`, Source: "data.aws_organizations_organization.org"},
+		// the name of this account clashes with the previous account, so the account id is used
+		{Name: "aws_333333333333", Plugin: "aws", Config: `profile = "Dev"`, Source: "data.aws_organizations_organization.org"},
+		{Name: "gcp_my_project_123", Plugin: "gcp", Config: `project = "my-project-123"`, Source: "google_project.main"},
+		{Name: "azure_pay_as_you_go", Plugin: "azure", Config: `subscription_id = "0000-1111"`, Source: "data.azurerm_subscription.current"},
+	}
+
+	// both the state file and the working directory may be used
+	for _, path := range []string{statePath, dir} {
+		connections, err := LoadTerraformConnections(path)
+		if err != nil {
+			t.Fatalf("unexpected error loading %s: %v", path, err)
+		}
+		if !reflect.DeepEqual(connections, expected) {
+			t.Errorf("unexpected connections loaded from %s:", path)
+			for _, c := range connections {
+				t.Errorf("  %+v", c)
+			}
+		}
+	}
+
+	// a selected workspace with no state is an error
+	if err := os.MkdirAll(filepath.Join(dir, ".terraform"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".terraform", "environment"), []byte("staging"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTerraformConnections(dir); err == nil {
+		t.Error("expected an error loading a workspace with no state")
+	}
+
+	// the state of the selected workspace is used
+	workspaceDir := filepath.Join(dir, "terraform.tfstate.d", "staging")
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspaceDir, "terraform.tfstate"), []byte(`{"version": 4, "resources": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if connections, err := LoadTerraformConnections(dir); err != nil || len(connections) != 0 {
+		t.Errorf("expected no connections in the staging workspace, got %v, %v", connections, err)
+	}
+
+	// older state versions are not supported
+	if err := os.WriteFile(statePath, []byte(`{"version": 3, "modules": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTerraformConnections(statePath); err == nil {
+		t.Error("expected an error loading a version 3 state")
+	}
+}
+
+func TestTerraformConnectionNameCollision(t *testing.T) {
+	// the name of the third account clashes with the first, and its id clashes with the name of the second
+	var state terraformState
+	if err := json.Unmarshal([]byte(`{"version": 4, "resources": [{
+      "mode": "data",
+      "type": "aws_organizations_organization",
+      "name": "org",
+      "instances": [{"attributes": {"accounts": [
+        {"id": "111111111111", "name": "Dev"},
+        {"id": "222222222222", "name": "333333333333"},
+        {"id": "333333333333", "name": "dev"}
+      ]}}]
+    }]}`), &state); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range terraformConnections(state) {
+		names = append(names, c.Name)
+	}
+	expected := []string{"aws_dev", "aws_333333333333", "aws_333333333333_2"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected connection names %v, got %v", expected, names)
+	}
+}
+
+func TestLoadTerraformConnectionsBackend(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".terraform"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	backendPath := filepath.Join(dir, ".terraform", "terraform.tfstate")
+
+	// the state of a remote backend is not available locally
+	if err := os.WriteFile(backendPath, []byte(`{"version": 3, "backend": {"type": "s3", "config": {"bucket": "state"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTerraformConnections(dir); err == nil || !strings.Contains(err.Error(), "'s3' backend") {
+		t.Errorf("expected a remote backend error, got %v", err)
+	}
+
+	// a local backend may use a non default state file
+	if err := os.WriteFile(backendPath, []byte(`{"version": 3, "backend": {"type": "local", "config": {"path": "state/prod.tfstate"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "state"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "state", "prod.tfstate"), []byte(testTerraformState), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if connections, err := LoadTerraformConnections(dir); err != nil || len(connections) != 5 {
+		t.Errorf("expected 5 connections from the local backend state file, got %d, %v", len(connections), err)
+	}
+}
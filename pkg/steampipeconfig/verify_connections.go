@@ -0,0 +1,112 @@
+package steampipeconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/turbot/steampipe/pkg/constants"
+	"github.com/turbot/steampipe/pkg/utils"
+)
+
+// VerifyConnectionsResult lists the connections which are marked as ready but whose schemas are empty
+// or cannot be queried
+type VerifyConnectionsResult struct {
+	// ready connections whose schema contains at least one foreign table
+	VerifiedConnections []string
+	// ready connections whose schema contains no foreign tables
+	EmptyConnections []string
+	// ready connections whose schema does not exist
+	UnqueryableConnections []string
+}
+
+// HasFailures returns whether any ready connections are empty or unqueryable
+func (r *VerifyConnectionsResult) HasFailures() bool {
+	return len(r.EmptyConnections)+len(r.UnqueryableConnections) > 0
+}
+
+func (r *VerifyConnectionsResult) String() string {
+	if !r.HasFailures() {
+		return fmt.Sprintf("Verified %d %s", len(r.VerifiedConnections), utils.Pluralize("connection", len(r.VerifiedConnections)))
+	}
+	var str []string
+	if len(r.EmptyConnections) > 0 {
+		str = append(str, fmt.Sprintf("%d ready %s with no tables: %s",
+			len(r.EmptyConnections),
+			utils.Pluralize("connection", len(r.EmptyConnections)),
+			strings.Join(r.EmptyConnections, ", ")))
+	}
+	if len(r.UnqueryableConnections) > 0 {
+		str = append(str, fmt.Sprintf("%d ready %s with no schema: %s",
+			len(r.UnqueryableConnections),
+			utils.Pluralize("connection", len(r.UnqueryableConnections)),
+			strings.Join(r.UnqueryableConnections, ", ")))
+	}
+	return strings.Join(str, "\n")
+}
+
+// VerifyConnections checks, without making any changes, that every connection in the ready state has
+// a schema containing at least one foreign table
+// this waits for any in-progress refresh to complete
+func VerifyConnections(ctx context.Context, conn *pgx.Conn) (*VerifyConnectionsResult, error) {
+	connectionState, err := LoadConnectionState(ctx, conn, WithWaitUntilReady())
+	if err != nil {
+		return nil, err
+	}
+
+	var readyConnections []string
+	for name, state := range connectionState {
+		if state.State == constants.ConnectionStateReady {
+			readyConnections = append(readyConnections, name)
+		}
+	}
+
+	tableCounts, err := loadForeignTableCounts(ctx, conn, readyConnections)
+	if err != nil {
+		return nil, err
+	}
+	return verifyConnectionSchemas(readyConnections, tableCounts), nil
+}
+
+// loadForeignTableCounts returns a map of schema name to the number of foreign tables it contains
+// schemas which do not exist are not included in the map
+func loadForeignTableCounts(ctx context.Context, conn *pgx.Conn, schemas []string) (map[string]int, error) {
+	query := `select n.nspname, count(c.oid) from pg_namespace n left join pg_class c on c.relnamespace = n.oid and c.relkind = 'f' where n.nspname = any($1) group by n.nspname`
+	rows, err := conn.Query(ctx, query, schemas)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]int, len(schemas))
+	for rows.Next() {
+		var schemaName string
+		var count int
+		if err := rows.Scan(&schemaName, &count); err != nil {
+			return nil, err
+		}
+		res[schemaName] = count
+	}
+	return res, rows.Err()
+}
+
+// verifyConnectionSchemas builds the verification result for the given ready connections,
+// from a map of schema name to foreign table count
+func verifyConnectionSchemas(readyConnections []string, tableCounts map[string]int) *VerifyConnectionsResult {
+	sort.Strings(readyConnections)
+	res := &VerifyConnectionsResult{}
+	for _, name := range readyConnections {
+		count, ok := tableCounts[name]
+		switch {
+		case !ok:
+			res.UnqueryableConnections = append(res.UnqueryableConnections, name)
+		case count == 0:
+			res.EmptyConnections = append(res.EmptyConnections, name)
+		default:
+			res.VerifiedConnections = append(res.VerifiedConnections, name)
+		}
+	}
+	return res
+}
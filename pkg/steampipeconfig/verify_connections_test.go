@@ -0,0 +1,29 @@
+package steampipeconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerifyConnectionSchemas(t *testing.T) {
+	readyConnections := []string{"healthy", "empty", "missing"}
+	// the schema of 'missing' does not exist
+	tableCounts := map[string]int{
+		"healthy": 12,
+		"empty":   0,
+	}
+
+	res := verifyConnectionSchemas(readyConnections, tableCounts)
+
+	expected := &VerifyConnectionsResult{
+		VerifiedConnections:    []string{"healthy"},
+		EmptyConnections:       []string{"empty"},
+		UnqueryableConnections: []string{"missing"},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %+v, got %+v", expected, res)
+	}
+	if !res.HasFailures() {
+		t.Errorf("expected the result to have failures")
+	}
+}
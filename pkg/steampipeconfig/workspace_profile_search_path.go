@@ -0,0 +1,77 @@
+package steampipeconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// defaultWorkspaceFileName is the file a workspace block is written to if the profile is not declared in any file
+const defaultWorkspaceFileName = "workspaces.spc"
+
+// SaveWorkspaceProfileSearchPath writes the search_path and search_path_prefix of the given workspace profile
+// to the block which declares the profile (an unset value removes the attribute)
+// if the profile is not declared in a file (i.e. it is the implicit default profile), a block is added to
+// 'workspaces.spc' in workspaceProfileDir
+// the path of the file written is returned
+func SaveWorkspaceProfileSearchPath(profile *modconfig.WorkspaceProfile, workspaceProfileDir string) (string, error) {
+	configPath := profile.DeclRange.Filename
+	if configPath == "" {
+		configPath = filepath.Join(workspaceProfileDir, defaultWorkspaceFileName)
+	}
+
+	file := hclwrite.NewEmptyFile()
+	data, err := os.ReadFile(configPath)
+	switch {
+	case err == nil:
+		var diags hcl.Diagnostics
+		file, diags = hclwrite.ParseConfig(data, configPath, hcl.InitialPos)
+		if diags.HasErrors() {
+			return "", fmt.Errorf("failed to parse %s: %s", configPath, diags.Error())
+		}
+	case !os.IsNotExist(err):
+		return "", err
+	}
+
+	block := file.Body().FirstMatchingBlock(modconfig.BlockTypeWorkspaceProfile, []string{profile.ProfileName})
+	if block == nil {
+		if len(file.Body().Attributes()) > 0 || len(file.Body().Blocks()) > 0 {
+			file.Body().AppendNewline()
+		}
+		block = file.Body().AppendNewBlock(modconfig.BlockTypeWorkspaceProfile, []string{profile.ProfileName})
+	}
+	setOptionalStringAttribute(block.Body(), "search_path", profile.SearchPath)
+	setOptionalStringAttribute(block.Body(), "search_path_prefix", profile.SearchPathPrefix)
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(configPath, hclwrite.Format(file.Bytes()), 0644); err != nil {
+		return "", err
+	}
+	return configPath, nil
+}
+
+// FormatWorkspaceSearchPath returns the search path in the form it is stored in a workspace profile,
+// i.e. the schema names separated by commas - an empty search path is returned as nil
+func FormatWorkspaceSearchPath(searchPath []string) *string {
+	if len(searchPath) == 0 {
+		return nil
+	}
+	res := strings.Join(searchPath, ",")
+	return &res
+}
+
+func setOptionalStringAttribute(body *hclwrite.Body, name string, value *string) {
+	if value == nil {
+		body.RemoveAttribute(name)
+		return
+	}
+	body.SetAttributeValue(name, cty.StringVal(*value))
+}
@@ -0,0 +1,81 @@
+package steampipeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/steampipe/pkg/steampipeconfig/modconfig"
+)
+
+func TestSaveWorkspaceProfileSearchPath(t *testing.T) {
+	profileDir := t.TempDir()
+	configPath := filepath.Join(profileDir, "profiles.spc")
+	config := `workspace "dev" {
+  install_dir = "~/dev"
+}
+
+workspace "prod" {
+  search_path = "aws"
+}
+`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	readConfig := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// the attributes are added to the block which declares the profile - other config is left in place
+	dev := &modconfig.WorkspaceProfile{
+		ProfileName:      "dev",
+		SearchPath:       FormatWorkspaceSearchPath([]string{"aws_dev", "gcp"}),
+		SearchPathPrefix: FormatWorkspaceSearchPath([]string{"net"}),
+		DeclRange:        hcl.Range{Filename: configPath},
+	}
+	if path, err := SaveWorkspaceProfileSearchPath(dev, profileDir); err != nil || path != configPath {
+		t.Fatalf("unexpected result saving search path: %s, %v", path, err)
+	}
+	expected := `workspace "dev" {
+  install_dir        = "~/dev"
+  search_path        = "aws_dev,gcp"
+  search_path_prefix = "net"
+}
+
+workspace "prod" {
+  search_path = "aws"
+}
+`
+	if actual := readConfig(configPath); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	// unset values remove the attributes
+	dev.SearchPath = nil
+	dev.SearchPathPrefix = nil
+	if _, err := SaveWorkspaceProfileSearchPath(dev, profileDir); err != nil {
+		t.Fatal(err)
+	}
+	if actual := readConfig(configPath); strings.Contains(actual, "aws_dev") || strings.Contains(actual, "net") || !strings.Contains(actual, `search_path = "aws"`) {
+		t.Errorf("expected only the search path of dev to be removed, got:\n%s", actual)
+	}
+
+	// a profile which is not declared in a file is added to workspaces.spc
+	defaultProfile := &modconfig.WorkspaceProfile{ProfileName: "default", SearchPathPrefix: FormatWorkspaceSearchPath([]string{"aws"})}
+	path, err := SaveWorkspaceProfileSearchPath(defaultProfile, profileDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(profileDir, "workspaces.spc") {
+		t.Errorf("unexpected config path %s", path)
+	}
+	if expected := "workspace \"default\" {\n  search_path_prefix = \"aws\"\n}\n"; readConfig(path) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, readConfig(path))
+	}
+}
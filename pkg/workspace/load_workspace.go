@@ -17,7 +17,11 @@ import (
 )
 
 func LoadWorkspacePromptingForVariables(ctx context.Context) (*Workspace, *error_helpers.ErrorAndWarnings) {
-	workspacePath := viper.GetString(constants.ArgModLocation)
+	return LoadWorkspaceAtPathPromptingForVariables(ctx, viper.GetString(constants.ArgModLocation))
+}
+
+// LoadWorkspaceAtPathPromptingForVariables loads the workspace at the given path, prompting for any missing variables
+func LoadWorkspaceAtPathPromptingForVariables(ctx context.Context, workspacePath string) (*Workspace, *error_helpers.ErrorAndWarnings) {
 	t := time.Now()
 	defer func() {
 		log.Printf("[TRACE] Workspace load took %dms\n", time.Since(t).Milliseconds())
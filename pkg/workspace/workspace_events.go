@@ -98,6 +98,19 @@ func (w *Workspace) handleFileWatcherEvent(ctx context.Context, client db_common
 	w.raiseDashboardChangedEvents(ctx, resourceMaps, prevResourceMaps)
 }
 
+// Reload re-parses the workspace mod on demand (rather than in response to a file watcher event)
+// if the mod fails to parse, the error is returned and the previously loaded mod is retained
+func (w *Workspace) Reload(ctx context.Context) (*modconfig.ResourceMaps, *error_helpers.ErrorAndWarnings) {
+	w.loadLock.Lock()
+	defer w.loadLock.Unlock()
+
+	errAndWarnings := w.loadWorkspaceMod(ctx)
+	if errAndWarnings.GetError() != nil {
+		return nil, errAndWarnings
+	}
+	return w.Mod.ResourceMaps, errAndWarnings
+}
+
 func (w *Workspace) onNewIntrospectionData(ctx context.Context, client db_common.Client) {
 	if viper.GetString(constants.ArgIntrospection) == constants.IntrospectionNone {
 		// nothing to do here
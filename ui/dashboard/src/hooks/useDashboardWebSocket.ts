@@ -16,6 +16,7 @@ export const SocketActions: IActions = {
   SELECT_DASHBOARD: "select_dashboard",
   SELECT_SNAPSHOT: "select_snapshot",
   INPUT_CHANGED: "input_changed",
+  CREATE_PERMALINK: "create_permalink",
 };
 
 const useDashboardWebSocket = (